@@ -11,20 +11,33 @@ import (
 	kitMiddleware "github.com/neoxelox/kit/middleware"
 	kitUtil "github.com/neoxelox/kit/util"
 
+	"backend/pkg/alert"
 	"backend/pkg/auth"
 	"backend/pkg/brevo"
+	"backend/pkg/chat"
+	"backend/pkg/churn"
 	"backend/pkg/collector"
 	"backend/pkg/config"
+	"backend/pkg/consolidation"
+	"backend/pkg/customer"
 	"backend/pkg/dataforseo"
+	"backend/pkg/digest"
 	"backend/pkg/engine"
 	"backend/pkg/exporter"
 	"backend/pkg/feedback"
 	"backend/pkg/issue"
 	"backend/pkg/metric"
 	"backend/pkg/organization"
+	"backend/pkg/outbox"
+	"backend/pkg/persona"
+	"backend/pkg/pipeline"
+	"backend/pkg/prioritization"
 	"backend/pkg/product"
+	"backend/pkg/reprocess"
 	"backend/pkg/review"
+	"backend/pkg/segment"
 	"backend/pkg/suggestion"
+	"backend/pkg/taxonomy"
 	"backend/pkg/user"
 	"backend/pkg/util"
 )
@@ -156,7 +169,7 @@ func NewAPI(ctx context.Context, config config.Config) (*API, error) {
 	errorMiddleware := kitMiddleware.NewError(observer, kitMiddleware.ErrorConfig{})
 
 	server.Use(observerMiddleware.HandleRequest)
-	server.Use(timeoutMiddleware.Handle)
+	server.Use(util.SkipStreams(timeoutMiddleware.Handle))
 	server.Use(recoverMiddleware.HandleRequest)
 	server.Use(secureMiddleware.Handle)
 	server.Use(localizerMiddleware.Handle)
@@ -213,6 +226,8 @@ func NewAPI(ctx context.Context, config config.Config) (*API, error) {
 		TaskDefaultRetry:  kitUtil.Pointer(0),
 	})
 
+	inspector := util.NewInspector(config)
+
 	limiter := kit.NewLimiter(observer, kit.LimiterConfig{
 		CacheHost:            config.Cache.Host,
 		CachePort:            config.Cache.Port,
@@ -238,10 +253,26 @@ func NewAPI(ctx context.Context, config config.Config) (*API, error) {
 	collectorRepository := collector.NewCollectorRepository(observer, database, config)
 	exporterRepository := exporter.NewExporterRepository(observer, database, config)
 	feedbackRepository := feedback.NewFeedbackRepository(observer, database, config)
+	outboxTaskRepository := outbox.NewOutboxTaskRepository(observer, database, config)
+	partialIssueRepository := issue.NewPartialIssueRepository(observer, database, config)
+	partialSuggestionRepository := suggestion.NewPartialSuggestionRepository(observer, database, config)
 	issueRepository := issue.NewIssueRepository(observer, database, config)
 	suggestionRepository := suggestion.NewSuggestionRepository(observer, database, config)
 	reviewRepository := review.NewReviewRepository(observer, database, config)
+	reprocessRepository := reprocess.NewReprocessRepository(observer, database, config)
 	metricRepository := metric.NewMetricRepository(observer, database, config)
+	pipelineRepository := pipeline.NewPipelineRepository(observer, database, config)
+	consolidationRepository := consolidation.NewConsolidationRepository(observer, database, config)
+	alertRuleRepository := alert.NewAlertRuleRepository(observer, database, config)
+	alertRepository := alert.NewAlertRepository(observer, database, config)
+	recategorizationRepository := taxonomy.NewRecategorizationRepository(observer, database, config)
+	categoryProposalRepository := taxonomy.NewCategoryProposalRepository(observer, database, config)
+	personaRepository := persona.NewPersonaRepository(observer, database, config)
+	chatRepository := chat.NewChatRepository(observer, database, config)
+	churnRiskRepository := churn.NewChurnRiskRepository(observer, database, config)
+	customerRepository := customer.NewCustomerRepository(observer, database, config)
+	segmentRepository := segment.NewSegmentRepository(observer, database, config)
+	digestRepository := digest.NewDigestRepository(observer, database, config)
 
 	/* SERVICES */
 
@@ -251,31 +282,58 @@ func NewAPI(ctx context.Context, config config.Config) (*API, error) {
 
 	/* USECASES */
 
+	outboxEnqueuer := outbox.NewOutboxEnqueuer(observer, outboxTaskRepository, config)
+
 	authVerifier := auth.NewAuthVerifier(observer, sessionRepository, userRepository, organizationRepository, config)
 	authProcessor := auth.NewAuthProcessor(observer, database, signInCodeRepository, renderer, brevoService,
 		authVerifier, userRepository, invitationRepository, organizationRepository, sessionRepository, config)
-	trustpilotCollector := collector.NewTrustpilotCollector(observer, collectorRepository, productRepository,
-		organizationRepository, feedbackRepository, enqueuer, dataForSEOService, config)
-	playStoreCollector := collector.NewPlayStoreCollector(observer, collectorRepository, productRepository,
-		organizationRepository, feedbackRepository, enqueuer, dataForSEOService, config)
-	appStoreCollector := collector.NewAppStoreCollector(observer, collectorRepository, productRepository,
-		organizationRepository, feedbackRepository, enqueuer, dataForSEOService, config)
-	amazonCollector := collector.NewAmazonCollector(observer, collectorRepository, productRepository,
-		organizationRepository, feedbackRepository, enqueuer, dataForSEOService, config)
+	trustpilotCollector := collector.NewTrustpilotCollector(observer, database, collectorRepository, productRepository,
+		organizationRepository, feedbackRepository, outboxEnqueuer, dataForSEOService, config)
+	playStoreCollector := collector.NewPlayStoreCollector(observer, database, collectorRepository, productRepository,
+		organizationRepository, feedbackRepository, outboxEnqueuer, dataForSEOService, config)
+	appStoreCollector := collector.NewAppStoreCollector(observer, database, collectorRepository, productRepository,
+		organizationRepository, feedbackRepository, outboxEnqueuer, dataForSEOService, config)
+	amazonCollector := collector.NewAmazonCollector(observer, database, collectorRepository, productRepository,
+		organizationRepository, feedbackRepository, outboxEnqueuer, dataForSEOService, config)
+
+	reprocessor := reprocess.NewReprocessor(observer, database, reprocessRepository, outboxEnqueuer, config)
+	pipelineInspector := pipeline.NewPipelineInspector(observer, database, pipelineRepository, feedbackRepository,
+		partialIssueRepository, partialSuggestionRepository, outboxEnqueuer, inspector, config)
+	engineBreaker := engine.NewEngineBreaker(observer, cache, config)
+	consolidator := consolidation.NewConsolidator(observer, database, consolidationRepository, productRepository,
+		issueRepository, suggestionRepository, outboxEnqueuer, engineService, engineBreaker, config)
+	prioritizer := prioritization.NewPrioritizer(observer, database, productRepository, issueRepository,
+		suggestionRepository, outboxEnqueuer, cache, config)
+	recategorizer := taxonomy.NewRecategorizer(observer, database, productRepository, recategorizationRepository,
+		categoryProposalRepository, outboxEnqueuer, cache, config)
+	synthesizer := persona.NewSynthesizer(observer, database, productRepository, organizationRepository,
+		personaRepository, outboxEnqueuer, engineService, config)
 
 	/* ENDPOINTS */
 
-	healthEndpoints := util.NewHealthEndpoints(observer, database, cache, config)
+	healthEndpoints := util.NewHealthEndpoints(observer, database, cache, inspector, config)
 	authEndpoints := auth.NewAuthEndpoints(observer, authProcessor, config)
 	userEndpoints := user.NewUserEndpoints(observer, database, renderer, brevoService, userRepository, invitationRepository, organizationRepository, config)
 	organizationEndpoints := organization.NewOrganizationEndpoints(observer, organizationRepository, config)
 	productEndpoints := product.NewProductEndpoints(observer, productRepository, config)
+	reprocessEndpoints := reprocess.NewReprocessEndpoints(observer, reprocessRepository, reprocessor, config)
+	pipelineEndpoints := pipeline.NewPipelineEndpoints(observer, pipelineInspector, config)
 	collectorEndpoints := collector.NewCollectorEndpoints(observer, collectorRepository, enqueuer, config)
 	exporterEndpoints := exporter.NewExporterEndpoints(observer, exporterRepository, config)
 	issueEndpoints := issue.NewIssueEndpoints(observer, issueRepository, userRepository, engineService, cache, config)
 	suggestionEndpoints := suggestion.NewSuggestionEndpoints(observer, suggestionRepository, userRepository, engineService, cache, config)
 	reviewEndpoints := review.NewReviewEndpoints(observer, reviewRepository, cache, config)
 	metricEndpoints := metric.NewMetricEndpoints(observer, metricRepository, cache, config)
+	consolidationEndpoints := consolidation.NewConsolidationEndpoints(observer, consolidator, cache, config)
+	prioritizationEndpoints := prioritization.NewPrioritizationEndpoints(observer, prioritizer, config)
+	alertEndpoints := alert.NewAlertEndpoints(observer, alertRuleRepository, alertRepository, config)
+	taxonomyEndpoints := taxonomy.NewTaxonomyEndpoints(observer, recategorizationRepository, categoryProposalRepository, recategorizer, config)
+	personaEndpoints := persona.NewPersonaEndpoints(observer, personaRepository, synthesizer, config)
+	chatEndpoints := chat.NewChatEndpoints(observer, chatRepository, engineService, config)
+	churnEndpoints := churn.NewChurnEndpoints(observer, churnRiskRepository, config)
+	customerEndpoints := customer.NewCustomerEndpoints(observer, database, customerRepository, outboxEnqueuer, config)
+	segmentEndpoints := segment.NewSegmentEndpoints(observer, segmentRepository, cache, config)
+	digestEndpoints := digest.NewDigestEndpoints(observer, digestRepository, config)
 
 	/* MIDDLEWARES */
 
@@ -288,6 +346,12 @@ func NewAPI(ctx context.Context, config config.Config) (*API, error) {
 	issueMiddleware := issue.NewIssueMiddleware(observer, issueRepository, config)
 	suggestionMiddleware := suggestion.NewSuggestionMiddleware(observer, suggestionRepository, config)
 	reviewMiddleware := review.NewReviewMiddleware(observer, reviewRepository, config)
+	alertMiddlewares := alert.NewAlertMiddlewares(observer, alertRuleRepository, alertRepository, config)
+	taxonomyMiddlewares := taxonomy.NewTaxonomyMiddlewares(observer, categoryProposalRepository, config)
+	personaMiddlewares := persona.NewPersonaMiddlewares(observer, personaRepository, config)
+	customerMiddlewares := customer.NewCustomerMiddlewares(observer, customerRepository, config)
+	segmentMiddlewares := segment.NewSegmentMiddlewares(observer, segmentRepository, config)
+	digestMiddlewares := digest.NewDigestMiddlewares(observer, digestRepository, config)
 
 	/* INTERNAL ROUTES */
 
@@ -355,7 +419,21 @@ func NewAPI(ctx context.Context, config config.Config) (*API, error) {
 	productRoutes.DELETE("/products/:product_id", productEndpoints.DeleteProduct, authMiddlewares.HandleRights)
 	productRoutes.GET("/products/:product_id/settings", productEndpoints.GetProductSettings)
 	productRoutes.PUT("/products/:product_id/settings", productEndpoints.PutProductSettings, authMiddlewares.HandleRights)
+	productRoutes.GET("/products/:product_id/attributes", productEndpoints.GetProductAttributes)
+	productRoutes.PUT("/products/:product_id/attributes", productEndpoints.PutProductAttributes, authMiddlewares.HandleRights)
 	productRoutes.GET("/products/:product_id/usage", productEndpoints.GetProductUsage)
+	productRoutes.GET("/products/:product_id/priority", prioritizationEndpoints.GetPriority)
+	productRoutes.PUT("/products/:product_id/priority", prioritizationEndpoints.PutPriority, authMiddlewares.HandleRights)
+
+	reprocessRoutes := productRoutes.Group("")
+	reprocessRoutes.GET("/products/:product_id/reprocess", reprocessEndpoints.GetReprocess)
+	reprocessRoutes.GET("/products/:product_id/reprocess/estimate", reprocessEndpoints.GetReprocessEstimate)
+	reprocessRoutes.POST("/products/:product_id/reprocess", reprocessEndpoints.PostReprocess, authMiddlewares.HandleRights)
+
+	pipelineRoutes := productRoutes.Group("", authMiddlewares.HandleRights)
+	pipelineRoutes.GET("/products/:product_id/pipeline/:stage", pipelineEndpoints.ListPipelineItems)
+	pipelineRoutes.POST("/products/:product_id/pipeline/:stage/:item_id/retry", pipelineEndpoints.PostPipelineItemRetry)
+	pipelineRoutes.POST("/products/:product_id/pipeline/:stage/:item_id/skip", pipelineEndpoints.PostPipelineItemSkip)
 
 	collectorRoutes := productRoutes.Group("")
 	collectorRoutes.GET("/products/:product_id/collectors", collectorEndpoints.ListCollectors)
@@ -373,36 +451,107 @@ func NewAPI(ctx context.Context, config config.Config) (*API, error) {
 	exporterRoutes.PUT("/products/:product_id/exporters/:exporter_id", exporterEndpoints.PutExporter, authMiddlewares.HandleRights)
 	exporterRoutes.DELETE("/products/:product_id/exporters/:exporter_id", exporterEndpoints.DeleteExporter, authMiddlewares.HandleRights)
 
+	alertRuleRoutes := productRoutes.Group("")
+	alertRuleRoutes.GET("/products/:product_id/alert-rules", alertEndpoints.ListAlertRules)
+	alertRuleRoutes.POST("/products/:product_id/alert-rules", alertEndpoints.PostAlertRule, authMiddlewares.HandleRights)
+	alertRuleRoutes = alertRuleRoutes.Group("", alertMiddlewares.HandleRule)
+	alertRuleRoutes.GET("/products/:product_id/alert-rules/:alert_rule_id", alertEndpoints.GetAlertRule)
+	alertRuleRoutes.PUT("/products/:product_id/alert-rules/:alert_rule_id", alertEndpoints.PutAlertRule, authMiddlewares.HandleRights)
+	alertRuleRoutes.DELETE("/products/:product_id/alert-rules/:alert_rule_id", alertEndpoints.DeleteAlertRule, authMiddlewares.HandleRights)
+
+	alertRoutes := productRoutes.Group("")
+	alertRoutes.GET("/products/:product_id/alerts", alertEndpoints.ListAlerts)
+	alertRoutes = alertRoutes.Group("", alertMiddlewares.HandleAlert)
+	alertRoutes.PUT("/products/:product_id/alerts/:alert_id/acknowledged", alertEndpoints.PutAlertAcknowledged)
+
+	taxonomyRoutes := productRoutes.Group("")
+	taxonomyRoutes.GET("/products/:product_id/taxonomy", taxonomyEndpoints.GetTaxonomy)
+	taxonomyRoutes.PUT("/products/:product_id/taxonomy", taxonomyEndpoints.PutTaxonomy, authMiddlewares.HandleRights)
+	taxonomyRoutes.GET("/products/:product_id/taxonomy/recategorizations", taxonomyEndpoints.ListRecategorizations)
+	taxonomyRoutes.GET("/products/:product_id/taxonomy/proposals", taxonomyEndpoints.ListCategoryProposals)
+	taxonomyRoutes = taxonomyRoutes.Group("", taxonomyMiddlewares.HandleProposal)
+	taxonomyRoutes.POST("/products/:product_id/taxonomy/proposals/:category_proposal_id/accept", taxonomyEndpoints.PostCategoryProposalAccept, authMiddlewares.HandleRights)
+	taxonomyRoutes.POST("/products/:product_id/taxonomy/proposals/:category_proposal_id/dismiss", taxonomyEndpoints.PostCategoryProposalDismiss, authMiddlewares.HandleRights)
+
+	personaRoutes := productRoutes.Group("")
+	personaRoutes.GET("/products/:product_id/personas", personaEndpoints.ListPersonas)
+	personaRoutes.POST("/products/:product_id/personas", personaEndpoints.PostPersona, authMiddlewares.HandleRights)
+	personaRoutes.POST("/products/:product_id/personas/refresh", personaEndpoints.PostPersonasRefresh, authMiddlewares.HandleRights)
+	personaRoutes = personaRoutes.Group("", personaMiddlewares.HandlePersona)
+	personaRoutes.GET("/products/:product_id/personas/:persona_id", personaEndpoints.GetPersona)
+	personaRoutes.PUT("/products/:product_id/personas/:persona_id", personaEndpoints.PutPersona, authMiddlewares.HandleRights)
+	personaRoutes.DELETE("/products/:product_id/personas/:persona_id", personaEndpoints.DeletePersona, authMiddlewares.HandleRights)
+	personaRoutes.GET("/products/:product_id/personas/:persona_id/feedbacks", personaEndpoints.ListPersonaFeedbacks)
+
+	chatRoutes := productRoutes.Group("")
+	chatRoutes.POST("/products/:product_id/chat", chatEndpoints.PostChat, rateLimitMiddleware.Handle(10, 1*time.Minute))
+	chatRoutes.GET("/products/:product_id/chat/messages", chatEndpoints.ListChatMessages)
+
+	churnRoutes := productRoutes.Group("")
+	churnRoutes.GET("/products/:product_id/customers/at-risk", churnEndpoints.ListAtRiskCustomers)
+
+	customerRoutes := productRoutes.Group("")
+	customerRoutes.GET("/products/:product_id/customers", customerEndpoints.ListCustomers)
+	customerRoutes.POST("/products/:product_id/customers/accounts", customerEndpoints.PostCustomerAccounts, authMiddlewares.HandleRights)
+	customerRoutes.POST("/products/:product_id/customers/accounts/csv", customerEndpoints.PostCustomerAccountsCSV, authMiddlewares.HandleRights)
+	customerRoutes.DELETE("/products/:product_id/customers/accounts", customerEndpoints.DeleteCustomerAccounts, authMiddlewares.HandleRights)
+	customerRoutes = customerRoutes.Group("", customerMiddlewares.HandleCustomer)
+	customerRoutes.GET("/products/:product_id/customers/:customer_id", customerEndpoints.GetCustomer)
+	customerRoutes.GET("/products/:product_id/customers/:customer_id/feedbacks", customerEndpoints.ListCustomerFeedbacks)
+	customerRoutes.POST("/products/:product_id/customers/:customer_id/merge", customerEndpoints.PostCustomerMerge, authMiddlewares.HandleRights)
+
+	segmentRoutes := productRoutes.Group("")
+	segmentRoutes.GET("/products/:product_id/segments", segmentEndpoints.ListSegments)
+	segmentRoutes.POST("/products/:product_id/segments", segmentEndpoints.PostSegment, authMiddlewares.HandleRights)
+	segmentRoutes = segmentRoutes.Group("", segmentMiddlewares.HandleSegment)
+	segmentRoutes.GET("/products/:product_id/segments/:segment_id", segmentEndpoints.GetSegment)
+	segmentRoutes.PUT("/products/:product_id/segments/:segment_id", segmentEndpoints.PutSegment, authMiddlewares.HandleRights)
+	segmentRoutes.DELETE("/products/:product_id/segments/:segment_id", segmentEndpoints.DeleteSegment, authMiddlewares.HandleRights)
+
+	digestRoutes := productRoutes.Group("")
+	digestRoutes.GET("/products/:product_id/digests", digestEndpoints.ListDigests)
+	digestRoutes = digestRoutes.Group("", digestMiddlewares.HandleDigest)
+	digestRoutes.GET("/products/:product_id/digests/:digest_id", digestEndpoints.GetDigest)
+
 	issueRoutes := productRoutes.Group("")
-	issueRoutes.GET("/products/:product_id/issues", issueEndpoints.ListIssues)
+	issueRoutes.GET("/products/:product_id/issues", issueEndpoints.ListIssues, segmentMiddlewares.HandleOptionalSegment)
 	issueRoutes = issueRoutes.Group("", issueMiddleware.Handle)
 	issueRoutes.GET("/products/:product_id/issues/:issue_id", issueEndpoints.GetIssue)
+	issueRoutes.GET("/products/:product_id/issues/:issue_id/translation", issueEndpoints.GetIssueTranslation)
 	issueRoutes.GET("/products/:product_id/issues/:issue_id/feedbacks", issueEndpoints.ListIssueFeedbacks)
 	issueRoutes.PUT("/products/:product_id/issues/:issue_id/assignee", issueEndpoints.PutIssueAssignee)
 	issueRoutes.PUT("/products/:product_id/issues/:issue_id/quality", issueEndpoints.PutIssueQuality)
 	issueRoutes.PUT("/products/:product_id/issues/:issue_id/archived", issueEndpoints.PutIssueArchived)
+	issueRoutes.GET("/products/:product_id/issues/:issue_id/states", issueEndpoints.ListIssueStateChanges)
+	issueRoutes.PUT("/products/:product_id/issues/:issue_id/state", issueEndpoints.PutIssueState)
+	issueRoutes.POST("/products/:product_id/issues/:issue_id/merge", consolidationEndpoints.PostIssueMerge)
+	issueRoutes.POST("/products/:product_id/issues/:issue_id/split", consolidationEndpoints.PostIssueSplit)
 
 	suggestionRoutes := productRoutes.Group("")
-	suggestionRoutes.GET("/products/:product_id/suggestions", suggestionEndpoints.ListSuggestions)
+	suggestionRoutes.GET("/products/:product_id/suggestions", suggestionEndpoints.ListSuggestions, segmentMiddlewares.HandleOptionalSegment)
 	suggestionRoutes = suggestionRoutes.Group("", suggestionMiddleware.Handle)
 	suggestionRoutes.GET("/products/:product_id/suggestions/:suggestion_id", suggestionEndpoints.GetSuggestion)
+	suggestionRoutes.GET("/products/:product_id/suggestions/:suggestion_id/translation", suggestionEndpoints.GetSuggestionTranslation)
 	suggestionRoutes.GET("/products/:product_id/suggestions/:suggestion_id/feedbacks", suggestionEndpoints.ListSuggestionFeedbacks)
 	suggestionRoutes.PUT("/products/:product_id/suggestions/:suggestion_id/assignee", suggestionEndpoints.PutSuggestionAssignee)
 	suggestionRoutes.PUT("/products/:product_id/suggestions/:suggestion_id/quality", suggestionEndpoints.PutSuggestionQuality)
 	suggestionRoutes.PUT("/products/:product_id/suggestions/:suggestion_id/archived", suggestionEndpoints.PutSuggestionArchived)
+	suggestionRoutes.POST("/products/:product_id/suggestions/:suggestion_id/merge", consolidationEndpoints.PostSuggestionMerge)
+	suggestionRoutes.POST("/products/:product_id/suggestions/:suggestion_id/split", consolidationEndpoints.PostSuggestionSplit)
 
 	reviewRoutes := productRoutes.Group("")
-	reviewRoutes.GET("/products/:product_id/reviews", reviewEndpoints.ListReviews)
+	reviewRoutes.GET("/products/:product_id/reviews", reviewEndpoints.ListReviews, segmentMiddlewares.HandleOptionalSegment)
 	reviewRoutes = reviewRoutes.Group("", reviewMiddleware.Handle)
 	reviewRoutes.GET("/products/:product_id/reviews/:review_id", reviewEndpoints.GetReview)
 	reviewRoutes.PUT("/products/:product_id/reviews/:review_id/quality", reviewEndpoints.PutReviewQuality)
 
-	metricRoutes := productRoutes.Group("")
+	metricRoutes := productRoutes.Group("", segmentMiddlewares.HandleOptionalSegment)
 	metricRoutes.GET("/products/:product_id/metrics/issue-count", metricEndpoints.GetIssueCount)
 	metricRoutes.GET("/products/:product_id/metrics/issue-sources", metricEndpoints.GetIssueSources)
 	metricRoutes.GET("/products/:product_id/metrics/issue-severities", metricEndpoints.GetIssueSeverities)
 	metricRoutes.GET("/products/:product_id/metrics/issue-categories", metricEndpoints.GetIssueCategories)
 	metricRoutes.GET("/products/:product_id/metrics/issue-releases", metricEndpoints.GetIssueReleases)
+	metricRoutes.GET("/products/:product_id/metrics/issue-regressions", metricEndpoints.GetIssueRegressions)
 	metricRoutes.GET("/products/:product_id/metrics/suggestion-count", metricEndpoints.GetSuggestionCount)
 	metricRoutes.GET("/products/:product_id/metrics/suggestion-sources", metricEndpoints.GetSuggestionSources)
 	metricRoutes.GET("/products/:product_id/metrics/suggestion-importances", metricEndpoints.GetSuggestionImportances)
@@ -415,8 +564,13 @@ func NewAPI(ctx context.Context, config config.Config) (*API, error) {
 	metricRoutes.GET("/products/:product_id/metrics/review-categories", metricEndpoints.GetReviewCategories)
 	metricRoutes.GET("/products/:product_id/metrics/review-releases", metricEndpoints.GetReviewReleases)
 	metricRoutes.GET("/products/:product_id/metrics/review-keywords", metricEndpoints.GetReviewKeywords)
+	metricRoutes.GET("/products/:product_id/metrics/review-attributes/:attribute", metricEndpoints.GetReviewAttribute)
+	metricRoutes.GET("/products/:product_id/metrics/review-aspects", metricEndpoints.GetReviewAspects)
+	metricRoutes.GET("/products/:product_id/metrics/review-aspects/:aspect", metricEndpoints.GetReviewAspectTrend)
 	metricRoutes.GET("/products/:product_id/metrics/nps", metricEndpoints.GetNetPromoterScore)
 	metricRoutes.GET("/products/:product_id/metrics/csat", metricEndpoints.GetCustomerSatisfactionScore)
+	metricRoutes.GET("/products/:product_id/metrics/churn-rate", metricEndpoints.GetChurnRate)
+	metricRoutes.GET("/products/:product_id/metrics/pipeline-latency", metricEndpoints.GetPipelineLatency)
 
 	return &API{
 		Run: func(ctx context.Context) error {
@@ -443,6 +597,11 @@ func NewAPI(ctx context.Context, config config.Config) (*API, error) {
 					observer.Error(ctx, err)
 				}
 
+				err = inspector.Close()
+				if err != nil {
+					observer.Error(ctx, err)
+				}
+
 				err = engineService.Close(ctx)
 				if err != nil {
 					observer.Error(ctx, err)
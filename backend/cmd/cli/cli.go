@@ -9,8 +9,22 @@ import (
 	kitMiddleware "github.com/neoxelox/kit/middleware"
 	kitUtil "github.com/neoxelox/kit/util"
 
+	"backend/pkg/aggregator"
 	"backend/pkg/config"
+	"backend/pkg/consolidation"
 	"backend/pkg/engine"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/organization"
+	"backend/pkg/outbox"
+	"backend/pkg/persona"
+	"backend/pkg/pipeline"
+	"backend/pkg/prioritization"
+	"backend/pkg/product"
+	"backend/pkg/reembed"
+	"backend/pkg/reprocess"
+	"backend/pkg/suggestion"
+	"backend/pkg/taxonomy"
 	"backend/pkg/util"
 )
 
@@ -143,19 +157,67 @@ func NewCLI(ctx context.Context, config config.Config) (*CLI, error) {
 		TaskDefaultRetry:  kitUtil.Pointer(0),
 	})
 
+	inspector := util.NewInspector(config)
+
 	/* REPOSITORIES  */
 
+	organizationRepository := organization.NewOrganizationRepositoryImpl(observer, database, config)
+	productRepository := product.NewProductRepository(observer, database, config)
+	feedbackRepository := feedback.NewFeedbackRepository(observer, database, config)
+	outboxTaskRepository := outbox.NewOutboxTaskRepository(observer, database, config)
+	issueRepository := issue.NewIssueRepository(observer, database, config)
+	suggestionRepository := suggestion.NewSuggestionRepository(observer, database, config)
+	partialIssueRepository := issue.NewPartialIssueRepository(observer, database, config)
+	partialSuggestionRepository := suggestion.NewPartialSuggestionRepository(observer, database, config)
+	reprocessRepository := reprocess.NewReprocessRepository(observer, database, config)
+	pipelineRepository := pipeline.NewPipelineRepository(observer, database, config)
+	reembedRepository := reembed.NewReembedRepository(observer, database, config)
+	consolidationRepository := consolidation.NewConsolidationRepository(observer, database, config)
+	recategorizationRepository := taxonomy.NewRecategorizationRepository(observer, database, config)
+	categoryProposalRepository := taxonomy.NewCategoryProposalRepository(observer, database, config)
+	personaRepository := persona.NewPersonaRepository(observer, database, config)
+
 	/* SERVICES */
 
+	engineService := engine.NewEngineService(observer, config)
+
 	/* USECASES */
 
+	outboxEnqueuer := outbox.NewOutboxEnqueuer(observer, outboxTaskRepository, config)
+
 	engineBreaker := engine.NewEngineBreaker(observer, cache, config)
+	reprocessor := reprocess.NewReprocessor(observer, database, reprocessRepository, outboxEnqueuer, config)
+	pipelineInspector := pipeline.NewPipelineInspector(observer, database, pipelineRepository, feedbackRepository,
+		partialIssueRepository, partialSuggestionRepository, outboxEnqueuer, inspector, config)
+	reembedder := reembed.NewReembedder(observer, database, reembedRepository, productRepository, outboxEnqueuer,
+		engineService, engineBreaker, config)
+	consolidator := consolidation.NewConsolidator(observer, database, consolidationRepository, productRepository,
+		issueRepository, suggestionRepository, outboxEnqueuer, engineService, engineBreaker, config)
+	prioritizer := prioritization.NewPrioritizer(observer, database, productRepository, issueRepository,
+		suggestionRepository, outboxEnqueuer, cache, config)
+	recategorizer := taxonomy.NewRecategorizer(observer, database, productRepository, recategorizationRepository,
+		categoryProposalRepository, outboxEnqueuer, cache, config)
+	proposer := taxonomy.NewProposer(observer, productRepository, organizationRepository, categoryProposalRepository,
+		outboxEnqueuer, engineService, config)
+	synthesizer := persona.NewSynthesizer(observer, database, productRepository, organizationRepository,
+		personaRepository, outboxEnqueuer, engineService, config)
 
 	/* COMMANDS */
 
 	databaseCommands := util.NewDatabaseCommands(observer, migrator, config)
 	workerCommands := util.NewWorkerCommands(observer, enqueuer, config)
 	engineCommands := engine.NewEngineCommands(observer, engineBreaker, config)
+	reprocessCommands := reprocess.NewReprocessCommands(observer, reprocessRepository, productRepository,
+		reprocessor, config)
+	pipelineCommands := pipeline.NewPipelineCommands(observer, pipelineInspector, config)
+	reembedCommands := reembed.NewReembedCommands(observer, reembedRepository, productRepository, reembedder, config)
+	aggregatorCommands := aggregator.NewAggregatorCommands(observer, issueRepository, suggestionRepository, config)
+	consolidationCommands := consolidation.NewConsolidationCommands(observer, consolidationRepository,
+		productRepository, consolidator, config)
+	prioritizationCommands := prioritization.NewPrioritizationCommands(observer, productRepository, prioritizer,
+		config)
+	taxonomyCommands := taxonomy.NewTaxonomyCommands(observer, productRepository, recategorizer, proposer, config)
+	personaCommands := persona.NewPersonaCommands(observer, productRepository, synthesizer, config)
 
 	/* MIDDLEWARES */
 
@@ -165,6 +227,34 @@ func NewCLI(ctx context.Context, config config.Config) (*CLI, error) {
 	runner.Register(util.WorkerCommandsEnqueue, workerCommands.Enqueue, util.WorkerCommandsEnqueueArgs{})
 	runner.Register(engine.EngineCommandsOpenBreaker, engineCommands.OpenBreaker, engine.EngineCommandsOpenBreakerArgs{})
 	runner.Register(engine.EngineCommandsCloseBreaker, engineCommands.CloseBreaker, engine.EngineCommandsCloseBreakerArgs{})
+	runner.Register(reprocess.ReprocessCommandsReprocessProduct, reprocessCommands.ReprocessProduct,
+		reprocess.ReprocessCommandsReprocessProductArgs{})
+	runner.Register(pipeline.PipelineCommandsListStuck, pipelineCommands.ListStuck,
+		pipeline.PipelineCommandsListStuckArgs{})
+	runner.Register(pipeline.PipelineCommandsRetryStuck, pipelineCommands.RetryStuck,
+		pipeline.PipelineCommandsRetryStuckArgs{})
+	runner.Register(pipeline.PipelineCommandsSkipStuck, pipelineCommands.SkipStuck,
+		pipeline.PipelineCommandsSkipStuckArgs{})
+	runner.Register(reembed.ReembedCommandsReembedProduct, reembedCommands.ReembedProduct,
+		reembed.ReembedCommandsReembedProductArgs{})
+	runner.Register(reembed.ReembedCommandsCutoverProduct, reembedCommands.CutoverProduct,
+		reembed.ReembedCommandsCutoverProductArgs{})
+	runner.Register(aggregator.AggregatorCommandsBenchmarkSimilarity, aggregatorCommands.BenchmarkSimilarity,
+		aggregator.AggregatorCommandsBenchmarkSimilarityArgs{})
+	runner.Register(consolidation.ConsolidationCommandsConsolidateProduct, consolidationCommands.ConsolidateProduct,
+		consolidation.ConsolidationCommandsConsolidateProductArgs{})
+	runner.Register(consolidation.ConsolidationCommandsListProduct, consolidationCommands.ListProduct,
+		consolidation.ConsolidationCommandsListProductArgs{})
+	runner.Register(consolidation.ConsolidationCommandsUndo, consolidationCommands.Undo,
+		consolidation.ConsolidationCommandsUndoArgs{})
+	runner.Register(prioritization.PrioritizationCommandsPrioritizeProduct, prioritizationCommands.PrioritizeProduct,
+		prioritization.PrioritizationCommandsPrioritizeProductArgs{})
+	runner.Register(taxonomy.TaxonomyCommandsRecategorizeProduct, taxonomyCommands.RecategorizeProduct,
+		taxonomy.TaxonomyCommandsRecategorizeProductArgs{})
+	runner.Register(taxonomy.TaxonomyCommandsProposeCategories, taxonomyCommands.ProposeCategories,
+		taxonomy.TaxonomyCommandsProposeCategoriesArgs{})
+	runner.Register(persona.PersonaCommandsSynthesizePersonas, personaCommands.SynthesizePersonas,
+		persona.PersonaCommandsSynthesizePersonasArgs{})
 
 	return &CLI{
 		Run: func(ctx context.Context) error {
@@ -191,6 +281,11 @@ func NewCLI(ctx context.Context, config config.Config) (*CLI, error) {
 					observer.Error(ctx, err)
 				}
 
+				err = inspector.Close()
+				if err != nil {
+					observer.Error(ctx, err)
+				}
+
 				err = cache.Close(ctx)
 				if err != nil {
 					observer.Error(ctx, err)
@@ -34,7 +34,7 @@ func NewConfig() *config.Config {
 	config.Database.User = util.GetEnv("CLANK_DATABASE_USER", "clank")
 	config.Database.Password = util.GetEnv("CLANK_DATABASE_PASSWORD", "clank")
 	config.Database.Name = util.GetEnv("CLANK_DATABASE_NAME", "clank")
-	config.Database.SchemaVersion = 4
+	config.Database.SchemaVersion = 25
 	config.Database.MinConns = 1
 	config.Database.MaxConns = 1
 	config.Database.MaxConnIdleTime = 30 * time.Minute
@@ -42,6 +42,8 @@ func NewConfig() *config.Config {
 	config.Database.DialTimeout = config.Service.GracefulTimeout
 	config.Database.StatementTimeout = config.Service.GracefulTimeout
 	config.Database.DefaultIsolationLevel = kit.IsoLvlReadCommitted
+	config.Database.VectorEfSearch = util.GetEnv("CLANK_DATABASE_VECTOR_EF_SEARCH", 100)
+	config.Database.VectorIterativeScan = util.GetEnv("CLANK_DATABASE_VECTOR_ITERATIVE_SCAN", "relaxed_order")
 
 	config.Cache.Host = util.GetEnv("CLANK_CACHE_HOST", "redis")
 	config.Cache.Port = util.GetEnv("CLANK_CACHE_PORT", 6379)
@@ -35,7 +35,7 @@ func NewConfig() *config.Config {
 	config.Database.User = util.GetEnv("CLANK_DATABASE_USER", "clank")
 	config.Database.Password = util.GetEnv("CLANK_DATABASE_PASSWORD", "clank")
 	config.Database.Name = util.GetEnv("CLANK_DATABASE_NAME", "clank")
-	config.Database.SchemaVersion = 4
+	config.Database.SchemaVersion = 25
 	config.Database.MinConns = 1
 	config.Database.MaxConns = min(8, 2*runtime.GOMAXPROCS(-1))
 	config.Database.MaxConnIdleTime = 30 * time.Minute
@@ -43,6 +43,8 @@ func NewConfig() *config.Config {
 	config.Database.DialTimeout = config.Service.GracefulTimeout
 	config.Database.StatementTimeout = config.Service.GracefulTimeout
 	config.Database.DefaultIsolationLevel = kit.IsoLvlReadCommitted
+	config.Database.VectorEfSearch = util.GetEnv("CLANK_DATABASE_VECTOR_EF_SEARCH", 100)
+	config.Database.VectorIterativeScan = util.GetEnv("CLANK_DATABASE_VECTOR_ITERATIVE_SCAN", "relaxed_order")
 
 	config.Cache.Host = util.GetEnv("CLANK_CACHE_HOST", "redis")
 	config.Cache.Port = util.GetEnv("CLANK_CACHE_PORT", 6379)
@@ -66,6 +68,8 @@ func NewConfig() *config.Config {
 	config.Worker.StopTimeout = config.Service.GracefulTimeout
 	config.Worker.HealthPort = util.GetEnv("CLANK_WORKER_HEALTH_PORT", 1112)
 
+	config.Pipeline.Streaming = util.GetEnv("CLANK_PIPELINE_STREAMING", false)
+
 	config.Sentry.DSN = util.GetEnv("CLANK_BACKEND_SENTRY_DSN", "")
 
 	config.Server.BaseURL = util.GetEnv("CLANK_API_BASE_URL", "http://api.clank.localhost")
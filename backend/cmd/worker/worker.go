@@ -12,20 +12,32 @@ import (
 	kitUtil "github.com/neoxelox/kit/util"
 
 	"backend/pkg/aggregator"
+	"backend/pkg/alert"
 	"backend/pkg/auth"
 	"backend/pkg/brevo"
+	"backend/pkg/churn"
 	"backend/pkg/collector"
 	"backend/pkg/config"
+	"backend/pkg/consolidation"
+	"backend/pkg/customer"
 	"backend/pkg/dataforseo"
+	"backend/pkg/digest"
 	"backend/pkg/engine"
 	"backend/pkg/feedback"
 	"backend/pkg/issue"
+	"backend/pkg/metric"
 	"backend/pkg/organization"
+	"backend/pkg/outbox"
+	"backend/pkg/persona"
+	"backend/pkg/prioritization"
 	"backend/pkg/processor"
 	"backend/pkg/product"
+	"backend/pkg/reembed"
+	"backend/pkg/reprocess"
 	"backend/pkg/review"
 	"backend/pkg/scraper"
 	"backend/pkg/suggestion"
+	"backend/pkg/taxonomy"
 	"backend/pkg/translator"
 	"backend/pkg/user"
 	"backend/pkg/util"
@@ -98,7 +110,7 @@ func NewWorker(ctx context.Context, config config.Config) (*Worker, error) {
 		Environment: config.Service.Environment,
 	})
 
-	_, err = kit.NewRenderer(observer, kit.RendererConfig{
+	renderer, err := kit.NewRenderer(observer, kit.RendererConfig{
 		TemplatesPath:       kitUtil.Pointer(config.Service.TemplatesPath),
 		TemplateFilePattern: kitUtil.Pointer(config.Service.TemplateFilePattern),
 	})
@@ -187,19 +199,35 @@ func NewWorker(ctx context.Context, config config.Config) (*Worker, error) {
 		TaskDefaultRetry:  kitUtil.Pointer(0),
 	})
 
+	inspector := util.NewInspector(config)
+
 	/* REPOSITORIES  */
 
+	userRepository := user.NewUserRepositoryImpl(observer, database, config)
 	invitationRepository := user.NewInvitationRepositoryImpl(observer, database, config)
 	signInCodeRepository := auth.NewSignInCodeRepositoryImpl(observer, database, config)
 	organizationRepository := organization.NewOrganizationRepositoryImpl(observer, database, config)
 	productRepository := product.NewProductRepository(observer, database, config)
 	collectorRepository := collector.NewCollectorRepository(observer, database, config)
 	feedbackRepository := feedback.NewFeedbackRepository(observer, database, config)
+	outboxTaskRepository := outbox.NewOutboxTaskRepository(observer, database, config)
 	partialIssueRepository := issue.NewPartialIssueRepository(observer, database, config)
 	partialSuggestionRepository := suggestion.NewPartialSuggestionRepository(observer, database, config)
 	issueRepository := issue.NewIssueRepository(observer, database, config)
 	suggestionRepository := suggestion.NewSuggestionRepository(observer, database, config)
 	reviewRepository := review.NewReviewRepository(observer, database, config)
+	reprocessRepository := reprocess.NewReprocessRepository(observer, database, config)
+	reembedRepository := reembed.NewReembedRepository(observer, database, config)
+	consolidationRepository := consolidation.NewConsolidationRepository(observer, database, config)
+	metricRepository := metric.NewMetricRepository(observer, database, config)
+	alertRuleRepository := alert.NewAlertRuleRepository(observer, database, config)
+	alertRepository := alert.NewAlertRepository(observer, database, config)
+	recategorizationRepository := taxonomy.NewRecategorizationRepository(observer, database, config)
+	categoryProposalRepository := taxonomy.NewCategoryProposalRepository(observer, database, config)
+	personaRepository := persona.NewPersonaRepository(observer, database, config)
+	churnRiskRepository := churn.NewChurnRiskRepository(observer, database, config)
+	customerRepository := customer.NewCustomerRepository(observer, database, config)
+	digestRepository := digest.NewDigestRepository(observer, database, config)
 
 	/* SERVICES */
 
@@ -209,30 +237,59 @@ func NewWorker(ctx context.Context, config config.Config) (*Worker, error) {
 
 	/* USECASES */
 
+	outboxEnqueuer := outbox.NewOutboxEnqueuer(observer, outboxTaskRepository, config)
+
 	engineBreaker := engine.NewEngineBreaker(observer, cache, config)
 	scraper := scraper.NewScraper(observer, config)
 
-	trustpilotCollector := collector.NewTrustpilotCollector(observer, collectorRepository, productRepository,
-		organizationRepository, feedbackRepository, enqueuer, dataForSEOService, config)
-	playStoreCollector := collector.NewPlayStoreCollector(observer, collectorRepository, productRepository,
-		organizationRepository, feedbackRepository, enqueuer, dataForSEOService, config)
-	appStoreCollector := collector.NewAppStoreCollector(observer, collectorRepository, productRepository,
-		organizationRepository, feedbackRepository, enqueuer, dataForSEOService, config)
-	amazonCollector := collector.NewAmazonCollector(observer, collectorRepository, productRepository,
-		organizationRepository, feedbackRepository, enqueuer, dataForSEOService, config)
-	iAgoraCollector := collector.NewIAgoraCollector(observer, collectorRepository, productRepository,
-		organizationRepository, feedbackRepository, enqueuer, scraper, config)
-
-	feedbackTranslator := translator.NewFeedbackTranslator(observer, feedbackRepository, productRepository,
-		organizationRepository, enqueuer, engineService, engineBreaker, config)
+	trustpilotCollector := collector.NewTrustpilotCollector(observer, database, collectorRepository, productRepository,
+		organizationRepository, feedbackRepository, outboxEnqueuer, dataForSEOService, config)
+	playStoreCollector := collector.NewPlayStoreCollector(observer, database, collectorRepository, productRepository,
+		organizationRepository, feedbackRepository, outboxEnqueuer, dataForSEOService, config)
+	appStoreCollector := collector.NewAppStoreCollector(observer, database, collectorRepository, productRepository,
+		organizationRepository, feedbackRepository, outboxEnqueuer, dataForSEOService, config)
+	amazonCollector := collector.NewAmazonCollector(observer, database, collectorRepository, productRepository,
+		organizationRepository, feedbackRepository, outboxEnqueuer, dataForSEOService, config)
+	iAgoraCollector := collector.NewIAgoraCollector(observer, database, collectorRepository, productRepository,
+		organizationRepository, feedbackRepository, outboxEnqueuer, scraper, config)
+
+	languageDetector := translator.NewLanguageDetector(observer, config)
+
+	feedbackTranslator := translator.NewFeedbackTranslator(observer, database, feedbackRepository, productRepository,
+		organizationRepository, outboxEnqueuer, engineService, engineBreaker, languageDetector, config)
 	feedbackProcessor := processor.NewFeedbackProcessor(observer, database, feedbackRepository, partialIssueRepository,
-		partialSuggestionRepository, reviewRepository, productRepository, organizationRepository, enqueuer,
+		partialSuggestionRepository, reviewRepository, productRepository, organizationRepository, outboxEnqueuer,
 		engineService, engineBreaker, config)
 	issueAggregator := aggregator.NewIssueAggregator(observer, database, partialIssueRepository, issueRepository,
-		feedbackRepository, productRepository, organizationRepository, enqueuer, engineService, engineBreaker, config)
+		feedbackRepository, productRepository, organizationRepository, outboxEnqueuer, engineService, engineBreaker,
+		config)
 	suggestionAggregator := aggregator.NewSuggestionAggregator(observer, database, partialSuggestionRepository,
-		suggestionRepository, feedbackRepository, productRepository, organizationRepository, enqueuer, engineService,
-		engineBreaker, config)
+		suggestionRepository, feedbackRepository, productRepository, organizationRepository, outboxEnqueuer,
+		engineService, engineBreaker, config)
+	reprocessor := reprocess.NewReprocessor(observer, database, reprocessRepository, outboxEnqueuer, config)
+	reembedder := reembed.NewReembedder(observer, database, reembedRepository, productRepository, outboxEnqueuer,
+		engineService, engineBreaker, config)
+	consolidator := consolidation.NewConsolidator(observer, database, consolidationRepository, productRepository,
+		issueRepository, suggestionRepository, outboxEnqueuer, engineService, engineBreaker, config)
+	emailAlertNotifier := alert.NewEmailAlertNotifier(observer, brevoService, config)
+	webhookAlertNotifier := alert.NewWebhookAlertNotifier(observer, config)
+	alertEvaluator := alert.NewAlertEvaluator(observer, database, alertRuleRepository, alertRepository,
+		productRepository, metricRepository, outboxEnqueuer,
+		[]alert.AlertNotifier{emailAlertNotifier, webhookAlertNotifier}, config)
+	prioritizer := prioritization.NewPrioritizer(observer, database, productRepository, issueRepository,
+		suggestionRepository, outboxEnqueuer, cache, config)
+	recategorizer := taxonomy.NewRecategorizer(observer, database, productRepository, recategorizationRepository,
+		categoryProposalRepository, outboxEnqueuer, cache, config)
+	proposer := taxonomy.NewProposer(observer, productRepository, organizationRepository, categoryProposalRepository,
+		outboxEnqueuer, engineService, config)
+	synthesizer := persona.NewSynthesizer(observer, database, productRepository, organizationRepository,
+		personaRepository, outboxEnqueuer, engineService, config)
+	scorer := churn.NewScorer(observer, productRepository, churnRiskRepository, outboxEnqueuer, config)
+	resolver := customer.NewResolver(observer, database, productRepository, customerRepository, outboxEnqueuer, config)
+	enricher := customer.NewEnricher(observer, productRepository, customerRepository, outboxEnqueuer, config)
+	digester := digest.NewDigester(observer, renderer, productRepository, organizationRepository, userRepository,
+		digestRepository, metricRepository, outboxEnqueuer, engineService, brevoService, config)
+	outboxRelay := outbox.NewOutboxRelay(observer, database, outboxTaskRepository, enqueuer, config)
 
 	/* TASKS */
 
@@ -283,6 +340,39 @@ func NewWorker(ctx context.Context, config config.Config) (*Worker, error) {
 	worker.Register(aggregator.SuggestionAggregatorAggregate, suggestionAggregator.Aggregate)
 	worker.Register(aggregator.SuggestionAggregatorSchedule, suggestionAggregator.Schedule)
 
+	worker.Register(reprocess.ReprocessorStart, reprocessor.Start)
+	worker.Register(reprocess.ReprocessorReconcile, reprocessor.Reconcile)
+
+	worker.Register(reembed.ReembedderEmbed, reembedder.Embed)
+	worker.Register(reembed.ReembedderSchedule, reembedder.Schedule)
+
+	worker.Register(consolidation.ConsolidatorConsolidate, consolidator.Consolidate)
+	worker.Register(consolidation.ConsolidatorSchedule, consolidator.Schedule)
+
+	worker.Register(alert.AlertEvaluatorEvaluate, alertEvaluator.Evaluate)
+	worker.Register(alert.AlertEvaluatorSchedule, alertEvaluator.Schedule)
+
+	worker.Register(prioritization.PrioritizerPrioritize, prioritizer.Prioritize)
+	worker.Register(prioritization.PrioritizerSchedule, prioritizer.Schedule)
+
+	worker.Register(taxonomy.RecategorizerRecategorize, recategorizer.Recategorize)
+	worker.Register(taxonomy.ProposerPropose, proposer.Propose)
+	worker.Register(taxonomy.ProposerSchedule, proposer.Schedule)
+
+	worker.Register(persona.SynthesizerSynthesize, synthesizer.Synthesize)
+	worker.Register(persona.SynthesizerSchedule, synthesizer.Schedule)
+
+	worker.Register(churn.ScorerScore, scorer.Score)
+	worker.Register(churn.ScorerSchedule, scorer.Schedule)
+
+	worker.Register(customer.ResolverResolve, resolver.Resolve)
+	worker.Register(customer.ResolverSchedule, resolver.Schedule)
+	worker.Register(customer.EnricherEnrich, enricher.Enrich)
+	worker.Register(customer.EnricherSchedule, enricher.Schedule)
+
+	worker.Register(digest.DigesterSend, digester.Send)
+	worker.Register(digest.DigesterSchedule, digester.Schedule)
+
 	/* SCHEDULEMENTS */
 
 	worker.Schedule(auth.AuthTasksDeleteExpiredSignInCodes, nil, "0 8 * * *", asynq.Queue("irrelevant"))                              // Every day at 08:00
@@ -294,10 +384,29 @@ func NewWorker(ctx context.Context, config config.Config) (*Worker, error) {
 	worker.Schedule(collector.AppStoreCollectorSchedule, nil, "0 2 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))             // Every day at 02:00
 	worker.Schedule(collector.AmazonCollectorSchedule, nil, "0 3 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))               // Every day at 03:00
 	worker.Schedule(collector.IAgoraCollectorSchedule, nil, "0 4 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))               // Every day at 04:00
-	worker.Schedule(translator.FeedbackTranslatorSchedule, nil, "0 19 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))          // Every day at 19:00
-	worker.Schedule(processor.FeedbackProcessorSchedule, nil, "0 20 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))            // Every day at 20:00
-	worker.Schedule(aggregator.IssueAggregatorSchedule, nil, "0 22 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))             // Every day at 22:00
-	worker.Schedule(aggregator.SuggestionAggregatorSchedule, nil, "0 23 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))        // Every day at 23:00
+	worker.Schedule(reembed.ReembedderSchedule, nil, "50 * * * *", asynq.MaxRetry(2), asynq.Unique(1*time.Hour))                      // Every hour at XX:50
+	worker.Schedule(consolidation.ConsolidatorSchedule, nil, "0 5 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))              // Every day at 05:00
+	worker.Schedule(alert.AlertEvaluatorSchedule, nil, "10 * * * *", asynq.MaxRetry(2), asynq.Unique(1*time.Hour))                    // Every hour at XX:10
+	worker.Schedule(prioritization.PrioritizerSchedule, nil, "0 6 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))              // Every day at 06:00
+	worker.Schedule(taxonomy.ProposerSchedule, nil, "0 7 * * 1", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))                       // Every monday at 07:00
+	worker.Schedule(persona.SynthesizerSchedule, nil, "0 8 * * 1", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))                     // Every monday at 08:00
+	worker.Schedule(churn.ScorerSchedule, nil, "30 6 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))                           // Every day at 06:30
+	worker.Schedule(customer.ResolverSchedule, nil, "20 * * * *", asynq.MaxRetry(2), asynq.Unique(1*time.Hour))                       // Every hour at XX:20
+	worker.Schedule(customer.EnricherSchedule, nil, "40 * * * *", asynq.MaxRetry(2), asynq.Unique(1*time.Hour))                       // Every hour at XX:40
+	worker.Schedule(digest.DigesterSchedule, nil, "0 9 * * 1", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))                         // Every monday at 09:00
+
+	if config.Pipeline.Streaming {
+		// Stages hand off to each other directly, so the schedulers only sweep what got stuck
+		worker.Schedule(translator.FeedbackTranslatorSchedule, nil, "0 * * * *", asynq.MaxRetry(2), asynq.Unique(1*time.Hour))    // Every hour at XX:00
+		worker.Schedule(processor.FeedbackProcessorSchedule, nil, "15 * * * *", asynq.MaxRetry(2), asynq.Unique(1*time.Hour))     // Every hour at XX:15
+		worker.Schedule(aggregator.IssueAggregatorSchedule, nil, "30 * * * *", asynq.MaxRetry(2), asynq.Unique(1*time.Hour))      // Every hour at XX:30
+		worker.Schedule(aggregator.SuggestionAggregatorSchedule, nil, "45 * * * *", asynq.MaxRetry(2), asynq.Unique(1*time.Hour)) // Every hour at XX:45
+	} else {
+		worker.Schedule(translator.FeedbackTranslatorSchedule, nil, "0 19 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))   // Every day at 19:00
+		worker.Schedule(processor.FeedbackProcessorSchedule, nil, "0 20 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))     // Every day at 20:00
+		worker.Schedule(aggregator.IssueAggregatorSchedule, nil, "0 22 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour))      // Every day at 22:00
+		worker.Schedule(aggregator.SuggestionAggregatorSchedule, nil, "0 23 * * *", asynq.MaxRetry(2), asynq.Unique(24*time.Hour)) // Every day at 23:00
+	}
 
 	return &Worker{
 		Run: func(ctx context.Context) error {
@@ -305,7 +414,7 @@ func NewWorker(ctx context.Context, config config.Config) (*Worker, error) {
 
 			// Create a concurrent http server to satisfy health checks
 			go func() {
-				healthEndpoints := util.NewHealthEndpoints(observer, database, cache, config)
+				healthEndpoints := util.NewHealthEndpoints(observer, database, cache, inspector, config)
 				err := http.ListenAndServe(fmt.Sprintf(":%d", config.Worker.HealthPort),
 					http.TimeoutHandler(http.HandlerFunc(healthEndpoints.GetWorkerHealth),
 						config.Service.GracefulTimeout, kit.HTTPErrServerTimeout.String()))
@@ -314,6 +423,14 @@ func NewWorker(ctx context.Context, config config.Config) (*Worker, error) {
 				}
 			}()
 
+			// Publish the tasks stored in the outbox concurrently
+			go func() {
+				err := outboxRelay.Run(ctx)
+				if err != nil {
+					observer.Error(ctx, err)
+				}
+			}()
+
 			err := worker.Run(ctx)
 			if err != nil {
 				return err
@@ -330,11 +447,26 @@ func NewWorker(ctx context.Context, config config.Config) (*Worker, error) {
 					observer.Error(ctx, err)
 				}
 
+				err = outboxRelay.Close(ctx)
+				if err != nil {
+					observer.Error(ctx, err)
+				}
+
 				err = enqueuer.Close(ctx)
 				if err != nil {
 					observer.Error(ctx, err)
 				}
 
+				err = inspector.Close()
+				if err != nil {
+					observer.Error(ctx, err)
+				}
+
+				err = webhookAlertNotifier.Close(ctx)
+				if err != nil {
+					observer.Error(ctx, err)
+				}
+
 				err = engineService.Close(ctx)
 				if err != nil {
 					observer.Error(ctx, err)
@@ -19,6 +19,7 @@ require (
 	github.com/scylladb/go-set v1.0.2
 	github.com/stretchr/testify v1.8.4
 	github.com/vk-rv/pvx v0.0.0-20210912195928-ac00bc32f6e7
+	golang.org/x/sync v0.7.0
 	golang.org/x/text v0.16.0
 	gomodules.xyz/email-providers v0.1.4
 )
@@ -97,7 +98,6 @@ require (
 	golang.org/x/crypto v0.22.0 // indirect
 	golang.org/x/net v0.24.0 // indirect
 	golang.org/x/oauth2 v0.17.0 // indirect
-	golang.org/x/sync v0.7.0 // indirect
 	golang.org/x/sys v0.21.0 // indirect
 	golang.org/x/term v0.19.0 // indirect
 	golang.org/x/time v0.5.0 // indirect
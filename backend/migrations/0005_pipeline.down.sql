@@ -0,0 +1,3 @@
+DROP INDEX CONCURRENTLY IF EXISTS "feedback_aggregated_at_id_idx";
+
+ALTER TABLE "feedback" DROP COLUMN IF EXISTS "aggregated_at";
@@ -0,0 +1,3 @@
+ALTER TABLE "feedback" ADD COLUMN IF NOT EXISTS "aggregated_at" TIMESTAMP WITH TIME ZONE NULL;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "feedback_aggregated_at_id_idx" ON "feedback" ("aggregated_at", "id");
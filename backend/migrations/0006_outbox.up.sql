@@ -0,0 +1,17 @@
+-- Params are kept as the exact bytes asynq deduplicates unique tasks by, as JSONB would normalize them
+CREATE TABLE IF NOT EXISTS "outbox" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "task" VARCHAR(100) NOT NULL,
+    "params" BYTEA NOT NULL,
+    "queue" VARCHAR(50) NULL,
+    "max_retry" BIGINT NULL,
+    "unique_for" BIGINT NULL,
+    "process_at" TIMESTAMP WITH TIME ZONE NULL,
+    "trace_id" VARCHAR(100) NOT NULL,
+    "attempts" BIGINT NOT NULL,
+    "last_error" TEXT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "relay_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "outbox_relay_at_id_idx" ON "outbox" ("relay_at", "id");
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "reprocess_snapshot";
+
+DROP TABLE IF EXISTS "reprocess_feedback";
+
+DROP TABLE IF EXISTS "reprocess";
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS "reprocess" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "period_start_at" TIMESTAMP WITH TIME ZONE NULL,
+    "period_end_at" TIMESTAMP WITH TIME ZONE NULL,
+    "feedbacks" BIGINT NOT NULL,
+    "estimated_tokens" BIGINT NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "started_at" TIMESTAMP WITH TIME ZONE NULL,
+    "finished_at" TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "reprocess_product_id_created_at_idx" ON "reprocess" ("product_id", "created_at");
+
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS "reprocess_product_id_in_progress_idx" ON "reprocess" ("product_id") WHERE "finished_at" IS NULL;
+
+CREATE TABLE IF NOT EXISTS "reprocess_feedback" (
+    "reprocess_id" VARCHAR(20) NOT NULL REFERENCES "reprocess" ("id") ON DELETE CASCADE,
+    "feedback_id" VARCHAR(20) NOT NULL REFERENCES "feedback" ("id") ON DELETE CASCADE,
+    PRIMARY KEY ("reprocess_id", "feedback_id")
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "reprocess_feedback_reprocess_id_idx" ON "reprocess_feedback" ("reprocess_id");
+
+CREATE TABLE IF NOT EXISTS "reprocess_snapshot" (
+    "reprocess_id" VARCHAR(20) NOT NULL REFERENCES "reprocess" ("id") ON DELETE CASCADE,
+    "type" VARCHAR(50) NOT NULL,
+    "source_id" VARCHAR(20) NOT NULL,
+    "embedding" VECTOR(1536) NOT NULL,
+    "assignee_id" VARCHAR(20) NULL,
+    "archived_at" TIMESTAMP WITH TIME ZONE NULL,
+    PRIMARY KEY ("reprocess_id", "type", "source_id")
+);
@@ -0,0 +1 @@
+ALTER TABLE "feedback" ADD COLUMN IF NOT EXISTS "skipped_at" TIMESTAMP WITH TIME ZONE NULL;
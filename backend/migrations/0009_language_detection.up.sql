@@ -0,0 +1 @@
+ALTER TABLE "feedback" ADD COLUMN IF NOT EXISTS "language_detection" VARCHAR(50) NULL;
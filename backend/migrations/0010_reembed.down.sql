@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "reembed_item";
+
+DROP TABLE IF EXISTS "reembed";
+
+ALTER TABLE "reprocess_snapshot" DROP COLUMN IF EXISTS "embedding_model";
+
+ALTER TABLE "suggestion" DROP COLUMN IF EXISTS "embedding_model";
+
+ALTER TABLE "issue" DROP COLUMN IF EXISTS "embedding_model";
+
+ALTER TABLE "product" DROP COLUMN IF EXISTS "embedding_model";
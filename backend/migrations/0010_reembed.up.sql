@@ -0,0 +1,36 @@
+ALTER TABLE "product" ADD COLUMN IF NOT EXISTS "embedding_model" VARCHAR(100) NOT NULL DEFAULT 'text-embedding-3-small';
+ALTER TABLE "product" ALTER COLUMN "embedding_model" DROP DEFAULT;
+
+ALTER TABLE "issue" ADD COLUMN IF NOT EXISTS "embedding_model" VARCHAR(100) NOT NULL DEFAULT 'text-embedding-3-small';
+ALTER TABLE "issue" ALTER COLUMN "embedding_model" DROP DEFAULT;
+
+ALTER TABLE "suggestion" ADD COLUMN IF NOT EXISTS "embedding_model" VARCHAR(100) NOT NULL DEFAULT 'text-embedding-3-small';
+ALTER TABLE "suggestion" ALTER COLUMN "embedding_model" DROP DEFAULT;
+
+ALTER TABLE "reprocess_snapshot" ADD COLUMN IF NOT EXISTS "embedding_model" VARCHAR(100) NOT NULL DEFAULT 'text-embedding-3-small';
+ALTER TABLE "reprocess_snapshot" ALTER COLUMN "embedding_model" DROP DEFAULT;
+
+CREATE TABLE IF NOT EXISTS "reembed" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "from_model" VARCHAR(100) NOT NULL,
+    "to_model" VARCHAR(100) NOT NULL,
+    "cutover" BOOLEAN NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "batched_at" TIMESTAMP WITH TIME ZONE NULL,
+    "cutover_at" TIMESTAMP WITH TIME ZONE NULL,
+    "finished_at" TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "reembed_product_id_created_at_idx" ON "reembed" ("product_id", "created_at");
+
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS "reembed_product_id_in_progress_idx" ON "reembed" ("product_id") WHERE "finished_at" IS NULL;
+
+CREATE TABLE IF NOT EXISTS "reembed_item" (
+    "reembed_id" VARCHAR(20) NOT NULL REFERENCES "reembed" ("id") ON DELETE CASCADE,
+    "type" VARCHAR(50) NOT NULL,
+    "source_id" VARCHAR(20) NOT NULL,
+    "embedding" VECTOR(1536) NOT NULL,
+    "embedded_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY ("reembed_id", "type", "source_id")
+);
@@ -0,0 +1,3 @@
+DROP INDEX CONCURRENTLY IF EXISTS "suggestion_embedding_idx";
+
+DROP INDEX CONCURRENTLY IF EXISTS "issue_embedding_idx";
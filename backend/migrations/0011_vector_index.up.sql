@@ -0,0 +1,6 @@
+-- The indexes are shared by all the products, so searches filtered by product rely on the iterative index scans
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "issue_embedding_idx" ON "issue" USING hnsw ("embedding" vector_cosine_ops)
+    WITH (m = 16, ef_construction = 64);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "suggestion_embedding_idx" ON "suggestion" USING hnsw ("embedding" vector_cosine_ops)
+    WITH (m = 16, ef_construction = 64);
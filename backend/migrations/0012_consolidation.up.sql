@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS "consolidation" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "type" VARCHAR(50) NOT NULL,
+    "kept_id" VARCHAR(20) NOT NULL,
+    "merged_id" VARCHAR(20) NOT NULL,
+    "kept" JSONB NOT NULL,
+    "merged" JSONB NOT NULL,
+    "feedback_ids" VARCHAR(20)[] NOT NULL,
+    "moved_feedback_ids" VARCHAR(20)[] NOT NULL,
+    "state_change_ids" VARCHAR(20)[] NOT NULL,
+    "tokens" INTEGER NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "undone_at" TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "consolidation_product_id_created_at_idx" ON "consolidation" ("product_id", "created_at");
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "consolidation_merged_id_idx" ON "consolidation" ("merged_id");
@@ -0,0 +1,12 @@
+ALTER TABLE "reprocess_snapshot" DROP COLUMN IF EXISTS "resolved_in_release";
+ALTER TABLE "reprocess_snapshot" DROP COLUMN IF EXISTS "state";
+
+DROP INDEX CONCURRENTLY IF EXISTS "issue_state_change_to_state_created_at_idx";
+DROP INDEX CONCURRENTLY IF EXISTS "issue_state_change_issue_id_created_at_idx";
+
+DROP TABLE IF EXISTS "issue_state_change";
+
+DROP INDEX CONCURRENTLY IF EXISTS "issue_product_id_state_idx";
+
+ALTER TABLE "issue" DROP COLUMN IF EXISTS "resolved_in_release";
+ALTER TABLE "issue" DROP COLUMN IF EXISTS "state";
@@ -0,0 +1,25 @@
+ALTER TABLE "issue" ADD COLUMN IF NOT EXISTS "state" VARCHAR(50) NOT NULL DEFAULT 'OPEN';
+ALTER TABLE "issue" ADD COLUMN IF NOT EXISTS "resolved_in_release" VARCHAR(50) NULL;
+
+-- Issues archived before workflow states existed are archived, unless feedback came in after archiving them
+UPDATE "issue" SET "state" = 'ARCHIVED' WHERE "state" = 'OPEN' AND "archived_at" IS NOT NULL AND "last_seen_at" <= "archived_at";
+UPDATE "issue" SET "state" = 'REGRESSED' WHERE "state" = 'OPEN' AND "archived_at" IS NOT NULL AND "last_seen_at" > "archived_at";
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "issue_product_id_state_idx" ON "issue" ("product_id", "state");
+
+CREATE TABLE IF NOT EXISTS "issue_state_change" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "issue_id" VARCHAR(20) NOT NULL REFERENCES "issue" ("id") ON DELETE CASCADE,
+    "from_state" VARCHAR(50) NOT NULL,
+    "to_state" VARCHAR(50) NOT NULL,
+    "release" VARCHAR(50) NULL,
+    "user_id" VARCHAR(20) NULL,
+    "feedback_id" VARCHAR(20) NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "issue_state_change_issue_id_created_at_idx" ON "issue_state_change" ("issue_id", "created_at");
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "issue_state_change_to_state_created_at_idx" ON "issue_state_change" ("to_state", "created_at");
+
+ALTER TABLE "reprocess_snapshot" ADD COLUMN IF NOT EXISTS "state" VARCHAR(50) NULL;
+ALTER TABLE "reprocess_snapshot" ADD COLUMN IF NOT EXISTS "resolved_in_release" VARCHAR(50) NULL;
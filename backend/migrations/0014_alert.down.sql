@@ -0,0 +1,8 @@
+DROP INDEX CONCURRENTLY IF EXISTS "alert_rule_id_idx";
+DROP INDEX CONCURRENTLY IF EXISTS "alert_product_id_created_at_idx";
+
+DROP TABLE IF EXISTS "alert";
+
+DROP INDEX CONCURRENTLY IF EXISTS "alert_rule_product_id_idx";
+
+DROP TABLE IF EXISTS "alert_rule";
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS "alert_rule" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "name" VARCHAR(100) NOT NULL,
+    "condition" VARCHAR(50) NOT NULL,
+    "threshold" DOUBLE PRECISION NOT NULL,
+    "window" BIGINT NOT NULL,
+    "cooldown" BIGINT NOT NULL,
+    "channel" VARCHAR(50) NOT NULL,
+    "channel_settings" JSONB NOT NULL,
+    "last_triggered_at" TIMESTAMP WITH TIME ZONE NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "deleted_at" TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "alert_rule_product_id_idx" ON "alert_rule" ("product_id");
+
+CREATE TABLE IF NOT EXISTS "alert" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "rule_id" VARCHAR(20) NOT NULL REFERENCES "alert_rule" ("id") ON DELETE CASCADE,
+    "product_id" VARCHAR(20) NOT NULL,
+    "condition" VARCHAR(50) NOT NULL,
+    "value" DOUBLE PRECISION NOT NULL,
+    "threshold" DOUBLE PRECISION NOT NULL,
+    "period_start_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "period_end_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "delivered_at" TIMESTAMP WITH TIME ZONE NULL,
+    "acknowledged_at" TIMESTAMP WITH TIME ZONE NULL,
+    "acknowledged_by_id" VARCHAR(20) NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "alert_product_id_created_at_idx" ON "alert" ("product_id", "created_at");
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "alert_rule_id_idx" ON "alert" ("rule_id");
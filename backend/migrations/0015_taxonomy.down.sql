@@ -0,0 +1,9 @@
+DROP INDEX CONCURRENTLY IF EXISTS "category_proposal_product_id_created_at_idx";
+
+DROP TABLE IF EXISTS "category_proposal";
+
+DROP INDEX CONCURRENTLY IF EXISTS "recategorization_product_id_created_at_idx";
+
+DROP TABLE IF EXISTS "recategorization";
+
+ALTER TABLE "product" DROP COLUMN IF EXISTS "taxonomy";
@@ -0,0 +1,42 @@
+ALTER TABLE "product" ADD COLUMN IF NOT EXISTS "taxonomy" JSONB NOT NULL DEFAULT '[]';
+
+-- The flat categories become the first level of the taxonomy
+UPDATE "product" SET "taxonomy" = (
+    SELECT COALESCE(jsonb_agg(jsonb_build_object(
+        'Name', "category",
+        'Description', '',
+        'Examples', '[]'::JSONB,
+        'Subcategories', '[]'::JSONB
+    )), '[]'::JSONB)
+    FROM unnest("categories") AS "category"
+);
+
+CREATE TABLE IF NOT EXISTS "recategorization" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "mapping" JSONB NOT NULL,
+    "issue_ids" VARCHAR(20)[] NULL,
+    "suggestion_ids" VARCHAR(20)[] NULL,
+    "issues" INTEGER NOT NULL,
+    "suggestions" INTEGER NOT NULL,
+    "reviews" INTEGER NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "finished_at" TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "recategorization_product_id_created_at_idx" ON "recategorization" ("product_id", "created_at");
+
+CREATE TABLE IF NOT EXISTS "category_proposal" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "name" VARCHAR(50) NOT NULL,
+    "description" TEXT NOT NULL,
+    "examples" TEXT[] NOT NULL,
+    "issue_ids" VARCHAR(20)[] NOT NULL,
+    "suggestion_ids" VARCHAR(20)[] NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "accepted_at" TIMESTAMP WITH TIME ZONE NULL,
+    "dismissed_at" TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "category_proposal_product_id_created_at_idx" ON "category_proposal" ("product_id", "created_at");
@@ -0,0 +1,3 @@
+ALTER TABLE "review" DROP COLUMN IF EXISTS "attributes";
+
+ALTER TABLE "product" DROP COLUMN IF EXISTS "attributes";
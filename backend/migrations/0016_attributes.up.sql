@@ -0,0 +1,3 @@
+ALTER TABLE "product" ADD COLUMN IF NOT EXISTS "attributes" JSONB NOT NULL DEFAULT '[]';
+
+ALTER TABLE "review" ADD COLUMN IF NOT EXISTS "attributes" JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+DROP INDEX CONCURRENTLY IF EXISTS "review_aspect_product_id_aspect_idx";
+
+DROP INDEX CONCURRENTLY IF EXISTS "review_aspect_review_id_idx";
+
+DROP TABLE IF EXISTS "review_aspect";
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "review_aspect" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "review_id" VARCHAR(20) NOT NULL REFERENCES "review" ("id") ON DELETE CASCADE,
+    "product_id" VARCHAR(20) NOT NULL,
+    "aspect" VARCHAR(50) NOT NULL,
+    "sentiment" VARCHAR(50) NOT NULL,
+    "quote" TEXT NOT NULL,
+    "quote_start" INTEGER NULL,
+    "quote_end" INTEGER NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "review_aspect_review_id_idx" ON "review_aspect" ("review_id");
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "review_aspect_product_id_aspect_idx" ON "review_aspect" ("product_id", "aspect");
@@ -0,0 +1,7 @@
+DROP INDEX CONCURRENTLY IF EXISTS "persona_feedback_feedback_id_idx";
+
+DROP TABLE IF EXISTS "persona_feedback";
+
+DROP INDEX CONCURRENTLY IF EXISTS "persona_product_id_idx";
+
+DROP TABLE IF EXISTS "persona";
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS "persona" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "name" VARCHAR(50) NOT NULL,
+    "description" TEXT NOT NULL,
+    "goals" TEXT[] NOT NULL,
+    "pains" TEXT[] NOT NULL,
+    "quotes" TEXT[] NOT NULL,
+    "traits" JSONB NOT NULL,
+    "customers" INTEGER NOT NULL,
+    "share" DOUBLE PRECISION NOT NULL,
+    "generated" BOOLEAN NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "persona_product_id_idx" ON "persona" ("product_id");
+
+CREATE TABLE IF NOT EXISTS "persona_feedback" (
+    "persona_id" VARCHAR(20) NOT NULL REFERENCES "persona" ("id") ON DELETE CASCADE,
+    "feedback_id" VARCHAR(20) NOT NULL REFERENCES "feedback" ("id") ON DELETE CASCADE,
+    PRIMARY KEY ("persona_id", "feedback_id")
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "persona_feedback_feedback_id_idx" ON "persona_feedback" ("feedback_id");
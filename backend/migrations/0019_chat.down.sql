@@ -0,0 +1,7 @@
+DROP INDEX CONCURRENTLY IF EXISTS "chat_message_product_id_created_at_idx";
+
+DROP TABLE IF EXISTS "chat_message";
+
+DROP INDEX CONCURRENTLY IF EXISTS "feedback_embedding_embedding_idx";
+
+DROP TABLE IF EXISTS "feedback_embedding";
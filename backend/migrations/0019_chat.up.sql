@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS "feedback_embedding" (
+    "feedback_id" VARCHAR(20) PRIMARY KEY REFERENCES "feedback" ("id") ON DELETE CASCADE,
+    "product_id" VARCHAR(20) NOT NULL,
+    "embedding" VECTOR(1536) NOT NULL,
+    "embedding_model" VARCHAR(100) NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- The index is shared by all the products, so searches filtered by product rely on the iterative index scans
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "feedback_embedding_embedding_idx" ON "feedback_embedding" USING hnsw ("embedding" vector_cosine_ops)
+    WITH (m = 16, ef_construction = 64);
+
+CREATE TABLE IF NOT EXISTS "chat_message" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "user_id" VARCHAR(20) NOT NULL,
+    "question" TEXT NOT NULL,
+    "answer" TEXT NOT NULL,
+    "citations" VARCHAR(20)[] NOT NULL,
+    "start_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "end_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "tokens" INTEGER NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "chat_message_product_id_created_at_idx" ON "chat_message" ("product_id", "created_at");
@@ -0,0 +1,3 @@
+DROP INDEX CONCURRENTLY IF EXISTS "churn_risk_product_id_score_idx";
+
+DROP TABLE IF EXISTS "churn_risk";
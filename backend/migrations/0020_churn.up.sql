@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "churn_risk" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "customer_key" TEXT NOT NULL,
+    "customer_email" TEXT NULL,
+    "customer_name" TEXT NOT NULL,
+    "source" VARCHAR(50) NOT NULL,
+    "score" INTEGER NOT NULL,
+    "reasons" VARCHAR(50)[] NOT NULL,
+    "feedbacks" INTEGER NOT NULL,
+    "unresolved_issues" INTEGER NOT NULL,
+    "last_feedback_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "computed_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE ("product_id", "customer_key")
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "churn_risk_product_id_score_idx" ON "churn_risk" ("product_id", "score", "id");
@@ -0,0 +1,11 @@
+DROP INDEX CONCURRENTLY IF EXISTS "customer_feedback_customer_id_idx";
+
+DROP TABLE IF EXISTS "customer_feedback";
+
+DROP INDEX CONCURRENTLY IF EXISTS "customer_identity_customer_id_idx";
+
+DROP TABLE IF EXISTS "customer_identity";
+
+DROP INDEX CONCURRENTLY IF EXISTS "customer_product_id_last_seen_at_idx";
+
+DROP TABLE IF EXISTS "customer";
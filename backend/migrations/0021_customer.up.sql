@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS "customer" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "name" TEXT NOT NULL,
+    "email" TEXT NULL,
+    "picture" TEXT NOT NULL,
+    "location" TEXT NULL,
+    "sources" VARCHAR(50)[] NOT NULL,
+    "feedbacks" INTEGER NOT NULL,
+    "first_seen_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "last_seen_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "customer_product_id_last_seen_at_idx" ON "customer" ("product_id", "last_seen_at", "id");
+
+CREATE TABLE IF NOT EXISTS "customer_identity" (
+    "product_id" VARCHAR(20) NOT NULL,
+    "key" TEXT NOT NULL,
+    "customer_id" VARCHAR(20) NOT NULL REFERENCES "customer" ("id") ON DELETE CASCADE,
+    PRIMARY KEY ("product_id", "key")
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "customer_identity_customer_id_idx" ON "customer_identity" ("customer_id");
+
+CREATE TABLE IF NOT EXISTS "customer_feedback" (
+    "feedback_id" VARCHAR(20) PRIMARY KEY REFERENCES "feedback" ("id") ON DELETE CASCADE,
+    "customer_id" VARCHAR(20) NOT NULL REFERENCES "customer" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "customer_feedback_customer_id_idx" ON "customer_feedback" ("customer_id");
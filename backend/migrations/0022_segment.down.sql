@@ -0,0 +1,3 @@
+DROP INDEX CONCURRENTLY IF EXISTS "segment_product_id_idx";
+
+DROP TABLE IF EXISTS "segment";
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "segment" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "name" VARCHAR(50) NOT NULL,
+    "filters" JSONB NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "segment_product_id_idx" ON "segment" ("product_id");
@@ -0,0 +1,13 @@
+DROP INDEX CONCURRENTLY IF EXISTS "suggestion_revenue_idx";
+
+ALTER TABLE "suggestion" DROP COLUMN IF EXISTS "revenue_at_risk";
+
+DROP INDEX CONCURRENTLY IF EXISTS "issue_revenue_idx";
+
+ALTER TABLE "issue" DROP COLUMN IF EXISTS "revenue_at_risk";
+
+ALTER TABLE "customer" DROP COLUMN IF EXISTS "account_owner";
+ALTER TABLE "customer" DROP COLUMN IF EXISTS "plan";
+ALTER TABLE "customer" DROP COLUMN IF EXISTS "arr";
+
+DROP TABLE IF EXISTS "customer_account";
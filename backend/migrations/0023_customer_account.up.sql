@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS "customer_account" (
+    "product_id" VARCHAR(20) NOT NULL,
+    "key" TEXT NOT NULL,
+    "arr" BIGINT NULL,
+    "plan" VARCHAR(100) NULL,
+    "owner" VARCHAR(100) NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "updated_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY ("product_id", "key")
+);
+
+ALTER TABLE "customer" ADD COLUMN IF NOT EXISTS "arr" BIGINT NULL;
+ALTER TABLE "customer" ADD COLUMN IF NOT EXISTS "plan" VARCHAR(100) NULL;
+ALTER TABLE "customer" ADD COLUMN IF NOT EXISTS "account_owner" VARCHAR(100) NULL;
+
+ALTER TABLE "issue" ADD COLUMN IF NOT EXISTS "revenue_at_risk" BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "issue_revenue_idx" ON "issue" ("revenue_at_risk", "last_seen_at", "id");
+
+ALTER TABLE "suggestion" ADD COLUMN IF NOT EXISTS "revenue_at_risk" BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "suggestion_revenue_idx" ON "suggestion" ("revenue_at_risk", "last_seen_at", "id");
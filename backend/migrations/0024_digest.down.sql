@@ -0,0 +1,3 @@
+DROP INDEX CONCURRENTLY IF EXISTS "digest_product_id_created_at_idx";
+
+DROP TABLE IF EXISTS "digest";
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "digest" (
+    "id" VARCHAR(20) PRIMARY KEY,
+    "product_id" VARCHAR(20) NOT NULL,
+    "summary" TEXT NOT NULL,
+    "highlights" TEXT[] NOT NULL,
+    "metrics" JSONB NOT NULL,
+    "issues" JSONB NOT NULL,
+    "suggestions" JSONB NOT NULL,
+    "quotes" JSONB NOT NULL,
+    "receivers" BIGINT NOT NULL,
+    "sent_to" TEXT[] NOT NULL,
+    "failed_to" TEXT[] NOT NULL,
+    "period_start_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "period_end_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "sent_at" TIMESTAMP WITH TIME ZONE NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE ("product_id", "period_end_at")
+);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS "digest_product_id_created_at_idx" ON "digest" ("product_id", "created_at");
@@ -0,0 +1,2 @@
+UPDATE "suggestion" SET "priority" = round("priority" / 1000.0);
+UPDATE "issue" SET "priority" = round("priority" / 1000.0);
@@ -0,0 +1,3 @@
+-- Priorities are now stored scaled, so the ones computed before are scaled too to keep their order with the new ones
+UPDATE "issue" SET "priority" = "priority" * 1000;
+UPDATE "suggestion" SET "priority" = "priority" * 1000;
@@ -0,0 +1,249 @@
+package aggregator
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mkideal/cli"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/issue"
+	"backend/pkg/suggestion"
+	"backend/pkg/util"
+)
+
+const (
+	AggregatorCommandsBenchmarkSimilarity = "similarity-benchmark"
+)
+
+const (
+	AggregatorCommandsBenchmarkSimilarityTypeIssue      = "issue"
+	AggregatorCommandsBenchmarkSimilarityTypeSuggestion = "suggestion"
+)
+
+type AggregatorCommands struct {
+	config               config.Config
+	observer             *kit.Observer
+	issueRepository      *issue.IssueRepository
+	suggestionRepository *suggestion.SuggestionRepository
+}
+
+func NewAggregatorCommands(observer *kit.Observer, issueRepository *issue.IssueRepository,
+	suggestionRepository *suggestion.SuggestionRepository, config config.Config) *AggregatorCommands {
+	return &AggregatorCommands{
+		config:               config,
+		observer:             observer,
+		issueRepository:      issueRepository,
+		suggestionRepository: suggestionRepository,
+	}
+}
+
+type AggregatorCommandsBenchmarkSimilarityArgs struct {
+	cli.Helper
+	Product       string `cli:"*product" usage:"id of the product whose embeddings to search"`
+	Type          string `cli:"type" dft:"issue" usage:"embeddings to search: issue or suggestion"`
+	Samples       int    `cli:"samples" dft:"50" usage:"number of random embeddings to search with"`
+	Limit         int    `cli:"limit" dft:"10" usage:"number of neighbours to retrieve per search"`
+	EfSearch      string `cli:"ef-search" dft:"40,100,200" usage:"comma separated ef_search values to benchmark"`
+	IterativeScan string `cli:"iterative-scan" dft:"relaxed_order" usage:"off, relaxed_order or strict_order"`
+}
+
+type aggregatorCommandsBenchmarkSample struct {
+	Embedding []float32
+	Model     string
+}
+
+type aggregatorCommandsBenchmarkResult struct {
+	IDs     []string
+	Latency time.Duration
+}
+
+// BenchmarkSimilarity compares the approximate similarity searches against the exact ones on a product's
+// embeddings, reporting the recall and latency of each ef_search value so the index can be tuned.
+func (self *AggregatorCommands) BenchmarkSimilarity(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*AggregatorCommandsBenchmarkSimilarityArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	if !util.IsVectorIterativeScan(args.IterativeScan) {
+		return kit.ErrRunnerGeneric.Raise().With("invalid iterative scan %s", args.IterativeScan)
+	}
+
+	if args.Samples < 1 || args.Limit < 1 {
+		return kit.ErrRunnerGeneric.Raise().With("samples and limit must be positive")
+	}
+
+	efSearches := []int{}
+	for _, value := range strings.Split(args.EfSearch, ",") {
+		efSearch, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || efSearch < 1 {
+			return kit.ErrRunnerGeneric.Raise().With("invalid ef_search %s", value)
+		}
+
+		efSearches = append(efSearches, efSearch)
+	}
+
+	var samples []aggregatorCommandsBenchmarkSample
+	var search func(ctx context.Context, sample aggregatorCommandsBenchmarkSample,
+		vectorSearch util.VectorSearch) (*aggregatorCommandsBenchmarkResult, error)
+
+	switch args.Type {
+	case AggregatorCommandsBenchmarkSimilarityTypeIssue:
+		issues, err := self.issueRepository.ListRandomByProductID(ctx, args.Product, args.Samples)
+		if err != nil {
+			return err
+		}
+
+		for _, issue := range issues {
+			samples = append(samples, aggregatorCommandsBenchmarkSample{
+				Embedding: issue.Embedding,
+				Model:     issue.EmbeddingModel,
+			})
+		}
+
+		search = func(ctx context.Context, sample aggregatorCommandsBenchmarkSample,
+			vectorSearch util.VectorSearch) (*aggregatorCommandsBenchmarkResult, error) {
+			start := time.Now()
+
+			issues, err := self.issueRepository.ListByEmbeddingAndProductID(ctx, sample.Embedding,
+				sample.Model, -1, args.Limit, args.Product, &vectorSearch)
+			if err != nil {
+				return nil, err
+			}
+
+			result := &aggregatorCommandsBenchmarkResult{Latency: time.Since(start)}
+			for _, issue := range issues {
+				result.IDs = append(result.IDs, issue.ID)
+			}
+
+			return result, nil
+		}
+
+	case AggregatorCommandsBenchmarkSimilarityTypeSuggestion:
+		suggestions, err := self.suggestionRepository.ListRandomByProductID(ctx, args.Product, args.Samples)
+		if err != nil {
+			return err
+		}
+
+		for _, suggestion := range suggestions {
+			samples = append(samples, aggregatorCommandsBenchmarkSample{
+				Embedding: suggestion.Embedding,
+				Model:     suggestion.EmbeddingModel,
+			})
+		}
+
+		search = func(ctx context.Context, sample aggregatorCommandsBenchmarkSample,
+			vectorSearch util.VectorSearch) (*aggregatorCommandsBenchmarkResult, error) {
+			start := time.Now()
+
+			suggestions, err := self.suggestionRepository.ListByEmbeddingAndProductID(ctx, sample.Embedding,
+				sample.Model, -1, args.Limit, args.Product, &vectorSearch)
+			if err != nil {
+				return nil, err
+			}
+
+			result := &aggregatorCommandsBenchmarkResult{Latency: time.Since(start)}
+			for _, suggestion := range suggestions {
+				result.IDs = append(result.IDs, suggestion.ID)
+			}
+
+			return result, nil
+		}
+
+	default:
+		return kit.ErrRunnerGeneric.Raise().With("invalid type %s", args.Type)
+	}
+
+	if len(samples) == 0 {
+		self.observer.Infof(ctx, "Product %s has no %s embeddings to benchmark", args.Product, args.Type)
+		return nil
+	}
+
+	exacts := make([]aggregatorCommandsBenchmarkResult, 0, len(samples))
+	exactLatencies := make([]time.Duration, 0, len(samples))
+	for _, sample := range samples {
+		result, err := search(ctx, sample, util.VectorSearch{
+			EfSearch:      self.config.Database.VectorEfSearch,
+			IterativeScan: util.VectorIterativeScanOff,
+			Exact:         true,
+		})
+		if err != nil {
+			return err
+		}
+
+		exacts = append(exacts, *result)
+		exactLatencies = append(exactLatencies, result.Latency)
+	}
+
+	self.observer.Infof(ctx, "Exact search over %d %s samples: recall 1.000, latency avg %s p95 %s",
+		len(samples), args.Type, self.average(exactLatencies), self.percentile(exactLatencies, 0.95))
+
+	for _, efSearch := range efSearches {
+		recall := 0.0
+		latencies := make([]time.Duration, 0, len(samples))
+
+		for i, sample := range samples {
+			result, err := search(ctx, sample, util.VectorSearch{
+				EfSearch:      efSearch,
+				IterativeScan: args.IterativeScan,
+				Exact:         false,
+			})
+			if err != nil {
+				return err
+			}
+
+			recall += self.recall(exacts[i].IDs, result.IDs)
+			latencies = append(latencies, result.Latency)
+		}
+
+		self.observer.Infof(ctx, "Approximate search with ef_search %d over %d %s samples: recall %.3f, "+
+			"latency avg %s p95 %s", efSearch, len(samples), args.Type, recall/float64(len(samples)),
+			self.average(latencies), self.percentile(latencies, 0.95))
+	}
+
+	return nil
+}
+
+func (self *AggregatorCommands) recall(exact []string, approximate []string) float64 {
+	if len(exact) == 0 {
+		return 1
+	}
+
+	found := 0
+	for _, id := range exact {
+		if slices.Contains(approximate, id) {
+			found++
+		}
+	}
+
+	return float64(found) / float64(len(exact))
+}
+
+func (self *AggregatorCommands) average(latencies []time.Duration) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	total := time.Duration(0)
+	for _, latency := range latencies {
+		total += latency
+	}
+
+	return (total / time.Duration(len(latencies))).Round(time.Microsecond)
+}
+
+func (self *AggregatorCommands) percentile(latencies []time.Duration, percentile float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+
+	return sorted[int(percentile*float64(len(sorted)-1))].Round(time.Microsecond)
+}
@@ -3,17 +3,23 @@ package aggregator
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
 	"time"
 
+	"backend/pkg/alert"
 	"backend/pkg/config"
 	"backend/pkg/engine"
 	"backend/pkg/feedback"
 	"backend/pkg/issue"
 	"backend/pkg/organization"
+	"backend/pkg/outbox"
+	"backend/pkg/priority"
 	"backend/pkg/product"
 	"backend/pkg/util"
 
 	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
 	"github.com/neoxelox/kit"
 	kitUtil "github.com/neoxelox/kit/util"
 	"github.com/rs/xid"
@@ -21,6 +27,9 @@ import (
 
 const (
 	ISSUE_AGGREGATOR_MAX_SIMILAR_ISSUES = 10
+	ISSUE_AGGREGATOR_LOCK_KEY           = "aggregator:issue:%s"
+	// Times the aggregation is decided again when the similar issues change meanwhile, before retrying the task
+	ISSUE_AGGREGATOR_MAX_ATTEMPTS = 3
 )
 
 const (
@@ -28,6 +37,43 @@ const (
 	IssueAggregatorSchedule  = "aggregator:schedule-aggregate-issue"
 )
 
+var (
+	ErrIssueAggregatorConflict = errors.New("issue aggregation conflicted")
+)
+
+// IssueAggregation is the decision of where a partial issue goes, taken with the engine without holding the
+// product lock. Target is the issue the partial is merged into, or nil if a new issue is created instead.
+type IssueAggregation struct {
+	Similar        []issue.Issue
+	Target         *issue.Issue
+	Merged         engine.Issue
+	Embedding      []float32
+	EmbeddingModel string
+	Tokens         int
+}
+
+// Stale tells whether the similar issues changed since the aggregation was decided, so it must be decided again.
+// Any new or rewritten similar issue could be the one the partial belongs to, and the target could be gone.
+func (self IssueAggregation) Stale(similar []issue.Issue) bool {
+	decided := make(map[string]issue.Issue, len(self.Similar))
+	for _, _issue := range self.Similar {
+		decided[_issue.ID] = _issue
+	}
+
+	targeted := self.Target == nil
+	for _, current := range similar {
+		previous, ok := decided[current.ID]
+		if !ok || previous.Title != current.Title || previous.Description != current.Description ||
+			!slices.Equal(previous.Steps, current.Steps) {
+			return true
+		}
+
+		targeted = targeted || current.ID == self.Target.ID
+	}
+
+	return !targeted
+}
+
 type IssueAggregator struct {
 	config                 config.Config
 	observer               *kit.Observer
@@ -37,7 +83,7 @@ type IssueAggregator struct {
 	feedbackRepository     *feedback.FeedbackRepository
 	productRepository      *product.ProductRepository
 	organizationRepository organization.OrganizationRepository
-	enqueuer               *kit.Enqueuer
+	enqueuer               *outbox.OutboxEnqueuer
 	engineService          *engine.EngineService
 	engineBreaker          *engine.EngineBreaker
 }
@@ -45,7 +91,7 @@ type IssueAggregator struct {
 func NewIssueAggregator(observer *kit.Observer, database *kit.Database,
 	partialIssueRepository *issue.PartialIssueRepository, issueRepository *issue.IssueRepository,
 	feedbackRepository *feedback.FeedbackRepository, productRepository *product.ProductRepository,
-	organizationRepository organization.OrganizationRepository, enqueuer *kit.Enqueuer,
+	organizationRepository organization.OrganizationRepository, enqueuer *outbox.OutboxEnqueuer,
 	engineService *engine.EngineService, engineBreaker *engine.EngineBreaker,
 	config config.Config) *IssueAggregator {
 	return &IssueAggregator{
@@ -63,23 +109,25 @@ func NewIssueAggregator(observer *kit.Observer, database *kit.Database,
 	}
 }
 
-func (self *IssueAggregator) createIssue(ctx context.Context, embedding []float32, partial *issue.PartialIssue,
-	feedback *feedback.Feedback, product *product.Product, tokens int) error {
+func (self *IssueAggregator) createIssue(ctx context.Context, embedding []float32, embeddingModel string,
+	partial *issue.PartialIssue, feedback *feedback.Feedback, product *product.Product, tokens int) error {
 	_issue := issue.NewIssue()
 	_issue.ID = xid.New().String()
 	_issue.ProductID = product.ID
 	_issue.Embedding = embedding
+	_issue.EmbeddingModel = embeddingModel
 	_issue.Sources = map[string]int{feedback.Source: 1}
 	_issue.Title = partial.Title
 	_issue.Description = partial.Description
 	_issue.Steps = partial.Steps
 	_issue.Severities = map[string]int{partial.Severity: 1}
-	_issue.Priority = issue.ComputePriority(_issue.Severities, 1)
 	_issue.Categories = map[string]int{partial.Category: 1}
 	_issue.Releases = map[string]int{feedback.Release: 1}
 	_issue.Customers = 1
 	_issue.AssigneeID = nil
 	_issue.Quality = nil
+	_issue.State = issue.IssueStateOpen
+	_issue.ResolvedInRelease = nil
 	_issue.FirstSeenAt = feedback.PostedAt
 	_issue.LastSeenAt = feedback.PostedAt
 	_issue.CreatedAt = time.Now()
@@ -87,6 +135,14 @@ func (self *IssueAggregator) createIssue(ctx context.Context, embedding []float3
 	_issue.LastAggregatedAt = nil
 	_issue.ExportedAt = nil
 
+	formula := product.PriorityFormula()
+	var trend *priority.PriorityTrend
+	if formula.Trending() {
+		trend = &priority.PriorityTrend{}
+		trend.Count(feedback.PostedAt, formula.TrendWindow, time.Now())
+	}
+	_issue.Priority = issue.ComputePriority(formula, *_issue, trend)
+
 	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
 		err := self.partialIssueRepository.Delete(ctx, partial.ID)
 		if err != nil {
@@ -109,73 +165,44 @@ func (self *IssueAggregator) createIssue(ctx context.Context, embedding []float3
 			return err
 		}
 
+		err = self.feedbackRepository.UpdateAggregated(ctx, feedback.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 
+	util.PipelineObserve(ctx, self.observer, util.PipelineStageAggregation, partial.CreatedAt)
+
 	self.observer.Infof(ctx, "Created a new issue using %d tokens", tokens)
 
 	return nil
 }
 
-func (self *IssueAggregator) mergeIssues(ctx context.Context, partial *issue.PartialIssue,
-	_issue *issue.Issue, feedback *feedback.Feedback, product *product.Product, tokens int) error {
+func (self *IssueAggregator) mergeIssues(ctx context.Context, aggregation IssueAggregation,
+	partial *issue.PartialIssue, feedback *feedback.Feedback, product *product.Product, tokens int) error {
 	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
-		_issue, err := self.issueRepository.GetByIDForUpdate(ctx, _issue.ID)
+		_issue, err := self.issueRepository.GetByIDForUpdate(ctx, aggregation.Target.ID)
 		if err != nil {
 			return err
 		}
 
-		miResult, err := self.engineService.MergeIssues(ctx, engine.EngineServiceMergeIssuesParams{
-			IssueA: engine.Issue{
-				Title:       partial.Title,
-				Description: partial.Description,
-				Steps:       partial.Steps,
-			},
-			IssueB: engine.Issue{
-				Title:       _issue.Title,
-				Description: _issue.Description,
-				Steps:       _issue.Steps,
-			},
-		})
-		if err != nil {
-			if engine.ErrEngineServiceTimedOut.Is(err) {
-				err := self.engineBreaker.Open(ctx)
-				if err != nil {
-					self.observer.Error(ctx, err)
-				}
-			}
-
-			return err
+		if _issue == nil {
+			return ErrIssueAggregatorConflict.Raise().With("issue %s no longer exists", aggregation.Target.ID)
 		}
 
-		_issue.Title = miResult.Issue.Title
-		_issue.Description = miResult.Issue.Description
-		_issue.Steps = miResult.Issue.Steps
-		tokens += (miResult.Usage.Input + miResult.Usage.Output)
-
-		ceResult, err := self.engineService.ComputeEmbedding(ctx, engine.EngineServiceComputeEmbeddingParams{
-			Text: _issue.Description,
-		})
-		if err != nil {
-			if engine.ErrEngineServiceTimedOut.Is(err) {
-				err := self.engineBreaker.Open(ctx)
-				if err != nil {
-					self.observer.Error(ctx, err)
-				}
-			}
-
-			return err
-		}
-
-		_issue.Embedding = ceResult.Embedding
-		tokens += (ceResult.Usage.Input + ceResult.Usage.Output)
+		_issue.Title = aggregation.Merged.Title
+		_issue.Description = aggregation.Merged.Description
+		_issue.Steps = aggregation.Merged.Steps
+		_issue.Embedding = aggregation.Embedding
+		_issue.EmbeddingModel = aggregation.EmbeddingModel
 
 		_issue.Sources[feedback.Source]++
 		_issue.Severities[partial.Severity]++
-		_issue.Priority = issue.ComputePriority(_issue.Severities, _issue.Customers+1)
 		_issue.Categories[partial.Category]++
 		_issue.Releases[feedback.Release]++
 		_issue.Customers++
@@ -187,11 +214,44 @@ func (self *IssueAggregator) mergeIssues(ctx context.Context, partial *issue.Par
 		}
 		_issue.LastAggregatedAt = kitUtil.Pointer(time.Now())
 
+		formula := product.PriorityFormula()
+		var trend *priority.PriorityTrend
+		if formula.Trending() {
+			trend, err = self.issueRepository.GetTrend(ctx, _issue.ID, formula.TrendWindow, time.Now())
+			if err != nil {
+				return err
+			}
+
+			trend.Count(feedback.PostedAt, formula.TrendWindow, time.Now())
+		}
+		_issue.Priority = issue.ComputePriority(formula, *_issue, trend)
+
 		err = self.issueRepository.UpdateAggregated(ctx, *_issue, *feedback)
 		if err != nil {
 			return err
 		}
 
+		if _issue.RegressedBy(feedback.Release, feedback.PostedAt) {
+			change := issue.NewIssueStateChange()
+			change.ID = xid.New().String()
+			change.IssueID = _issue.ID
+			change.FromState = _issue.State
+			change.ToState = issue.IssueStateRegressed
+			change.Release = &feedback.Release
+			change.UserID = nil
+			change.FeedbackID = &feedback.ID
+			change.CreatedAt = time.Now()
+
+			_issue.State = issue.IssueStateRegressed
+
+			err = self.issueRepository.UpdateState(ctx, *_issue, *change)
+			if err != nil {
+				return err
+			}
+
+			self.observer.Infof(ctx, "Issue %s %s regressed in %s", _issue.ID, change.FromState, feedback.Release)
+		}
+
 		err = self.partialIssueRepository.Delete(ctx, partial.ID)
 		if err != nil {
 			return err
@@ -208,25 +268,19 @@ func (self *IssueAggregator) mergeIssues(ctx context.Context, partial *issue.Par
 			return err
 		}
 
+		err = self.feedbackRepository.UpdateAggregated(ctx, feedback.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
 		return nil
 	})
 	if err != nil {
-		if kit.ErrDatabaseIntegrityViolation.In(err) {
-			// There are two or more issues from the same feedback trying to merge themselves.
-			// This should not be possible, but protect from retry-loop by deleting the partial.
-			self.observer.Error(ctx, err)
-
-			err = self.partialIssueRepository.Delete(ctx, partial.ID)
-			if err != nil {
-				return err
-			}
-
-			return nil
-		}
-
 		return err
 	}
 
+	util.PipelineObserve(ctx, self.observer, util.PipelineStageAggregation, partial.CreatedAt)
+
 	self.observer.Infof(ctx, "Merged 2 issues using %d tokens", tokens)
 
 	return nil
@@ -237,10 +291,6 @@ type IssueAggregatorAggregateParams struct {
 }
 
 func (self *IssueAggregator) Aggregate(ctx context.Context, task *asynq.Task) error {
-	if self.engineBreaker.IsOpen(ctx) {
-		return nil
-	}
-
 	params := IssueAggregatorAggregateParams{}
 
 	err := json.Unmarshal(task.Payload(), &params)
@@ -249,6 +299,13 @@ func (self *IssueAggregator) Aggregate(ctx context.Context, task *asynq.Task) er
 		return nil
 	}
 
+	allowed, delay := self.engineBreaker.Allow(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING,
+		engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
+	if !allowed {
+		return self.enqueuer.Enqueue(ctx, IssueAggregatorAggregate, params,
+			asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.ProcessIn(delay))
+	}
+
 	partial, err := self.partialIssueRepository.GetByID(ctx, params.PartialID)
 	if err != nil {
 		return err
@@ -302,11 +359,12 @@ func (self *IssueAggregator) Aggregate(ctx context.Context, task *asynq.Task) er
 	}
 
 	ceResult, err := self.engineService.ComputeEmbedding(ctx, engine.EngineServiceComputeEmbeddingParams{
-		Text: partial.Description,
+		Text:  partial.Description,
+		Model: product.EmbeddingModel,
 	})
 	if err != nil {
 		if engine.ErrEngineServiceTimedOut.Is(err) {
-			err := self.engineBreaker.Open(ctx)
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
 			if err != nil {
 				self.observer.Error(ctx, err)
 			}
@@ -317,18 +375,129 @@ func (self *IssueAggregator) Aggregate(ctx context.Context, task *asynq.Task) er
 
 	tokens := (ceResult.Usage.Input + ceResult.Usage.Output)
 
-	issues, err := self.issueRepository.ListByEmbeddingAndProductID(ctx, ceResult.Embedding,
-		issue.ISSUE_SIMILAR_THRESHOLD, ISSUE_AGGREGATOR_MAX_SIMILAR_ISSUES, product.ID)
+	err = self.engineBreaker.Succeed(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
+	if err != nil {
+		self.observer.Error(ctx, err)
+	}
+
+	// The engine decides where the partial goes without holding the product lock, so other aggregations of the
+	// product are not blocked meanwhile, and the decision is only applied if it still holds under the lock
+	for attempt := 1; ; attempt++ {
+		var aggregation *IssueAggregation
+		aggregation, err = self.decide(ctx, ceResult.Embedding, ceResult.Model, partial, product)
+		if err != nil {
+			return err
+		}
+
+		tokens += aggregation.Tokens
+		applied := false
+
+		err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+			// Partials describing the same new issue would otherwise both find no similar issues and
+			// create duplicates, so deciding whether to create or merge is serialized per product
+			err := util.LockTransaction(ctx, self.database, fmt.Sprintf(ISSUE_AGGREGATOR_LOCK_KEY, product.ID))
+			if err != nil {
+				return err
+			}
+
+			// The priority formula is read again under the lock, as the recomputation of the priorities holds it
+			// too, so a formula changed meanwhile is either applied here or by the recomputation afterwards
+			product, err := self.productRepository.GetByID(ctx, product.ID)
+			if err != nil {
+				return err
+			}
+
+			if product == nil {
+				applied = true
+				return nil
+			}
+
+			similar, err := self.issueRepository.ListByEmbeddingAndProductID(ctx, ceResult.Embedding, ceResult.Model,
+				issue.ISSUE_SIMILAR_THRESHOLD, ISSUE_AGGREGATOR_MAX_SIMILAR_ISSUES, product.ID, nil)
+			if err != nil {
+				return err
+			}
+
+			if aggregation.Stale(similar) {
+				return nil
+			}
+
+			applied = true
+
+			if aggregation.Target == nil {
+				return self.createIssue(ctx, ceResult.Embedding, ceResult.Model, partial, feedback, product, tokens)
+			}
+
+			return self.mergeIssues(ctx, *aggregation, partial, feedback, product, tokens)
+		})
+		if err != nil || applied {
+			break
+		}
+
+		if attempt >= ISSUE_AGGREGATOR_MAX_ATTEMPTS {
+			return ErrIssueAggregatorConflict.Raise().
+				With("similar issues of partial %s kept changing after %d attempts", partial.ID, attempt)
+		}
+
+		self.observer.Infof(ctx, "Similar issues of partial %s changed meanwhile, aggregating it again", partial.ID)
+	}
+
 	if err != nil {
+		if kit.ErrDatabaseIntegrityViolation.In(err) {
+			// There are two or more issues from the same feedback trying to merge themselves.
+			// This should not be possible, but protect from retry-loop by deleting the partial.
+			self.observer.Error(ctx, err)
+
+			err = self.partialIssueRepository.Delete(ctx, partial.ID)
+			if err != nil {
+				return err
+			}
+
+			err = self.feedbackRepository.UpdateAggregated(ctx, feedback.ID, time.Now())
+			if err != nil {
+				return err
+			}
+
+			return nil
+		}
+
 		return err
 	}
 
-	if len(issues) == 0 {
-		return self.createIssue(ctx, ceResult.Embedding, partial, feedback, product, tokens)
+	// A burst of aggregations of the same product is evaluated once
+	err = self.enqueuer.Enqueue(ctx, alert.AlertEvaluatorEvaluate, alert.AlertEvaluatorEvaluateParams{
+		ProductID: product.ID,
+	}, asynq.MaxRetry(2), asynq.ProcessIn(alert.ALERT_EVALUATOR_DEBOUNCE),
+		asynq.Unique(alert.ALERT_EVALUATOR_DEBOUNCE))
+	if err != nil {
+		self.observer.Error(ctx, err)
+	}
+
+	return nil
+}
+
+// decide asks the engine whether the partial is the same issue as any of its similar issues and, if so, merges
+// their texts, without writing anything.
+func (self *IssueAggregator) decide(ctx context.Context, embedding []float32, embeddingModel string,
+	partial *issue.PartialIssue, product *product.Product) (*IssueAggregation, error) {
+	similar, err := self.issueRepository.ListByEmbeddingAndProductID(ctx, embedding, embeddingModel,
+		issue.ISSUE_SIMILAR_THRESHOLD, ISSUE_AGGREGATOR_MAX_SIMILAR_ISSUES, product.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregation := &IssueAggregation{
+		Similar: similar,
+		Target:  nil,
+		Tokens:  0,
+	}
+
+	if len(similar) == 0 {
+		return aggregation, nil
 	}
 
-	options := make([]engine.Issue, 0, len(issues))
-	for _, issue := range issues {
+	options := make([]engine.Issue, 0, len(similar))
+	for _, issue := range similar {
 		options = append(options, engine.Issue{
 			Title:       issue.Title,
 			Description: issue.Description,
@@ -342,36 +511,102 @@ func (self *IssueAggregator) Aggregate(ctx context.Context, task *asynq.Task) er
 			Description: partial.Description,
 			Steps:       partial.Steps,
 		},
-		Options: options,
+		Options:  options,
+		Language: product.Language,
 	})
 	if err != nil {
 		if engine.ErrEngineServiceTimedOut.Is(err) {
-			err := self.engineBreaker.Open(ctx)
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
 			if err != nil {
 				self.observer.Error(ctx, err)
 			}
 		}
 
-		return err
+		return nil, err
 	}
 
-	tokens += (siResult.Usage.Input + siResult.Usage.Output)
+	aggregation.Tokens += (siResult.Usage.Input + siResult.Usage.Output)
+
+	err = self.engineBreaker.Succeed(ctx, engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
+	if err != nil {
+		self.observer.Error(ctx, err)
+	}
 
 	if siResult.Option == nil {
-		return self.createIssue(ctx, ceResult.Embedding, partial, feedback, product, tokens)
+		return aggregation, nil
 	}
 
-	return self.mergeIssues(ctx, partial, &issues[*siResult.Option], feedback, product, tokens)
+	target := similar[*siResult.Option]
+
+	miResult, err := self.engineService.MergeIssues(ctx, engine.EngineServiceMergeIssuesParams{
+		IssueA: engine.Issue{
+			Title:       partial.Title,
+			Description: partial.Description,
+			Steps:       partial.Steps,
+		},
+		IssueB: engine.Issue{
+			Title:       target.Title,
+			Description: target.Description,
+			Steps:       target.Steps,
+		},
+		Language: product.Language,
+	})
+	if err != nil {
+		if engine.ErrEngineServiceTimedOut.Is(err) {
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+
+		return nil, err
+	}
+
+	aggregation.Tokens += (miResult.Usage.Input + miResult.Usage.Output)
+
+	ceResult, err := self.engineService.ComputeEmbedding(ctx, engine.EngineServiceComputeEmbeddingParams{
+		Text:  miResult.Issue.Description,
+		Model: product.EmbeddingModel,
+	})
+	if err != nil {
+		if engine.ErrEngineServiceTimedOut.Is(err) {
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+
+		return nil, err
+	}
+
+	aggregation.Tokens += (ceResult.Usage.Input + ceResult.Usage.Output)
+
+	for _, operation := range []string{engine.ENGINE_BREAKER_OPERATION_AGGREGATION,
+		engine.ENGINE_BREAKER_OPERATION_EMBEDDING} {
+		err := self.engineBreaker.Succeed(ctx, operation)
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	aggregation.Target = &target
+	aggregation.Merged = miResult.Issue
+	aggregation.Embedding = ceResult.Embedding
+	aggregation.EmbeddingModel = ceResult.Model
+
+	return aggregation, nil
 }
 
 func (self *IssueAggregator) Schedule(ctx context.Context, _ *asynq.Task) error {
+	before := util.PipelineSweepBefore(self.config)
+
 	pagination := util.Pagination[time.Time]{
 		Limit: 1000,
 		From:  nil,
 	}
 
 	for {
-		page, err := self.partialIssueRepository.ListIDsByCreatedAt(ctx, pagination)
+		page, err := self.partialIssueRepository.ListIDsByCreatedAt(ctx, before, pagination)
 		if err != nil {
 			return err
 		}
@@ -379,7 +614,7 @@ func (self *IssueAggregator) Schedule(ctx context.Context, _ *asynq.Task) error
 		for _, id := range page.Items {
 			err := self.enqueuer.Enqueue(ctx, IssueAggregatorAggregate, IssueAggregatorAggregateParams{
 				PartialID: id,
-			}, asynq.MaxRetry(2), asynq.Unique(24*time.Hour))
+			}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.Unique(24*time.Hour))
 			if err != nil {
 				self.observer.Error(ctx, err)
 			}
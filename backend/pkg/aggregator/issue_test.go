@@ -0,0 +1,336 @@
+package aggregator_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/pkg/aggregator"
+	"backend/pkg/config"
+	"backend/pkg/engine"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/organization"
+	"backend/pkg/outbox"
+	"backend/pkg/product"
+
+	"github.com/hibiken/asynq"
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+	"github.com/neoxelox/kit/util"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	ISSUE_AGGREGATOR_TEST_CONCURRENCY = 2
+)
+
+type IssueAggregatorTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	config config.Config
+	mocks  struct {
+		organizationRepository *organization.OrganizationRepositoryMock
+	}
+	database               *kit.Database
+	cache                  *kit.Cache
+	engine                 *httptest.Server
+	productRepository      *product.ProductRepository
+	feedbackRepository     *feedback.FeedbackRepository
+	issueRepository        *issue.IssueRepository
+	partialIssueRepository *issue.PartialIssueRepository
+	aggregator             *aggregator.IssueAggregator
+	product                *product.Product
+	partials               []string
+}
+
+func (self *IssueAggregatorTestSuite) SetupTest() {
+	self.ctx = context.Background()
+
+	self.config = *config.NewConfig()
+	self.config.Service.Environment = kit.EnvIntegration
+	self.config.Service.Release = "test"
+	self.config.Service.Name = "test"
+	self.config.Database.VectorEfSearch = 100
+	self.config.Database.VectorIterativeScan = "relaxed_order"
+
+	observer, err := kit.NewObserver(self.ctx, kit.ObserverConfig{
+		Environment: self.config.Service.Environment,
+		Release:     self.config.Service.Release,
+		Service:     self.config.Service.Name,
+		Level:       kit.LvlError,
+	})
+	self.Require().NoError(err)
+
+	// Race conditions between transactions can only be reproduced against a real database
+	self.database, err = kit.NewDatabase(self.ctx, observer, kit.DatabaseConfig{
+		Host:                  util.GetEnv("CLANK_DATABASE_HOST", "localhost"),
+		Port:                  util.GetEnv("CLANK_DATABASE_PORT", 5432),
+		SSLMode:               util.GetEnv("CLANK_DATABASE_SSLMODE", "disable"),
+		User:                  util.GetEnv("CLANK_DATABASE_USER", "clank"),
+		Password:              util.GetEnv("CLANK_DATABASE_PASSWORD", "clank"),
+		Database:              util.GetEnv("CLANK_DATABASE_NAME", "clank"),
+		Service:               self.config.Service.Name,
+		MaxConns:              util.Pointer(2 * ISSUE_AGGREGATOR_TEST_CONCURRENCY),
+		DialTimeout:           util.Pointer(2 * time.Second),
+		DefaultIsolationLevel: util.Pointer(kit.IsoLvlReadCommitted),
+	})
+	if err != nil {
+		self.T().Skip("database not available")
+	}
+
+	self.cache, err = kit.NewCache(self.ctx, observer, kit.CacheConfig{
+		Host:        util.GetEnv("CLANK_CACHE_HOST", "localhost"),
+		Port:        util.GetEnv("CLANK_CACHE_PORT", 6379),
+		SSLMode:     util.GetEnv("CLANK_CACHE_SSLMODE", false),
+		Password:    util.GetEnv("CLANK_CACHE_PASSWORD", "redis"),
+		DialTimeout: util.Pointer(2 * time.Second),
+	})
+	if err != nil {
+		self.T().Skip("cache not available")
+	}
+
+	self.engine = self.newEngine()
+	self.config.Engine.BaseURL = self.engine.URL
+
+	self.mocks.organizationRepository = organization.NewOrganizationRepositoryMock()
+	self.productRepository = product.NewProductRepository(observer, self.database, self.config)
+	self.feedbackRepository = feedback.NewFeedbackRepository(observer, self.database, self.config)
+	self.issueRepository = issue.NewIssueRepository(observer, self.database, self.config)
+	self.partialIssueRepository = issue.NewPartialIssueRepository(observer, self.database, self.config)
+	outboxTaskRepository := outbox.NewOutboxTaskRepository(observer, self.database, self.config)
+
+	self.aggregator = aggregator.NewIssueAggregator(observer, self.database, self.partialIssueRepository,
+		self.issueRepository, self.feedbackRepository, self.productRepository, self.mocks.organizationRepository,
+		outbox.NewOutboxEnqueuer(observer, outboxTaskRepository, self.config),
+		engine.NewEngineService(observer, self.config), engine.NewEngineBreaker(observer, self.cache, self.config),
+		self.config)
+
+	self.product = product.NewProduct()
+	self.product.ID = xid.New().String()
+	self.product.OrganizationID = xid.New().String()
+	self.product.Name = "test"
+	self.product.Picture = "test"
+	self.product.Language = "ENGLISH"
+	self.product.EmbeddingModel = engine.ENGINE_EMBEDDING_MODEL_DEFAULT
+	self.product.Categories = []string{"test"}
+	self.product.Release = "test"
+	self.product.CreatedAt = time.Now()
+
+	self.product, err = self.productRepository.Create(self.ctx, *self.product)
+	self.Require().NoError(err)
+
+	self.partials = nil
+
+	self.mocks.organizationRepository.On("GetByID", mock.Anything, self.product.OrganizationID).
+		Return(&organization.Organization{
+			ID: self.product.OrganizationID,
+			Capacity: organization.OrganizationCapacity{
+				Included: 100,
+			},
+		}, nil)
+}
+
+func (self *IssueAggregatorTestSuite) TearDownTest() {
+	if self.product != nil {
+		for _, table := range []string{issue.ISSUE_MODEL_TABLE, feedback.FEEDBACK_MODEL_TABLE} {
+			_, err := self.database.Exec(self.ctx, sqlf.DeleteFrom(table).Where("product_id = ?", self.product.ID))
+			self.NoError(err)
+		}
+
+		// The tasks enqueued by the aggregations are left in the outbox as there is no relay running
+		_, err := self.database.Exec(self.ctx, sqlf.DeleteFrom(outbox.OUTBOX_TASK_MODEL_TABLE).
+			Where("(convert_from(params, 'UTF8')::JSONB ->> 'ProductID' = ? OR "+
+				"convert_from(params, 'UTF8')::JSONB ->> 'PartialID' = ANY(?))", self.product.ID, self.partials))
+		self.NoError(err)
+
+		_, err = self.database.Exec(self.ctx,
+			sqlf.DeleteFrom(product.PRODUCT_MODEL_TABLE).Where("id = ?", self.product.ID))
+		self.NoError(err)
+	}
+
+	if self.engine != nil {
+		self.engine.Close()
+	}
+
+	if self.cache != nil {
+		self.NoError(self.cache.Close(self.ctx))
+	}
+
+	if self.database != nil {
+		self.NoError(self.database.Close(self.ctx))
+	}
+}
+
+// newEngine fakes an engine where every issue is the same one. The embeddings are only returned once all the
+// concurrent aggregations have requested theirs, so all of them race to decide whether to create the issue.
+func (self *IssueAggregatorTestSuite) newEngine() *httptest.Server {
+	arrivals := sync.WaitGroup{}
+	arrivals.Add(ISSUE_AGGREGATOR_TEST_CONCURRENCY)
+	requests := atomic.Int32{}
+
+	embedding := make([]float32, 1536)
+	embedding[0] = 1
+
+	respond := func(res http.ResponseWriter, body any) {
+		res.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(res).Encode(body)
+		self.NoError(err)
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/aggregator/compute-embedding", func(res http.ResponseWriter, req *http.Request) {
+		request := map[string]any{}
+		err := json.NewDecoder(req.Body).Decode(&request)
+		self.NoError(err)
+
+		// Only the embeddings of the partials wait, not the ones of the merged texts
+		if requests.Add(1) <= ISSUE_AGGREGATOR_TEST_CONCURRENCY {
+			arrivals.Done()
+			arrivals.Wait()
+		}
+
+		respond(res, map[string]any{"embedding": embedding, "model": request["model"]})
+	})
+
+	mux.HandleFunc("/aggregator/similar-issue", func(res http.ResponseWriter, req *http.Request) {
+		respond(res, map[string]any{"option": 1})
+	})
+
+	mux.HandleFunc("/aggregator/merge-issues", func(res http.ResponseWriter, req *http.Request) {
+		respond(res, map[string]any{"issue": map[string]any{
+			"title":       "The app crashes",
+			"description": "The app crashes when opened",
+			"steps":       []string{"Open the app"},
+		}})
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func (self *IssueAggregatorTestSuite) createPartial() *issue.PartialIssue {
+	_feedback := feedback.NewFeedback()
+	_feedback.ID = xid.New().String()
+	_feedback.ProductID = self.product.ID
+	_feedback.Hash = _feedback.ID
+	_feedback.Source = feedback.FeedbackSourceWebhook
+	_feedback.Customer = feedback.FeedbackCustomer{Name: "test"}
+	_feedback.Content = "The app crashes when I open it"
+	_feedback.Language = "ENGLISH"
+	_feedback.Translation = _feedback.Content
+	_feedback.Release = "test"
+	_feedback.PostedAt = time.Now()
+	_feedback.CollectedAt = time.Now()
+	_feedback.TranslatedAt = util.Pointer(time.Now())
+	_feedback.ProcessedAt = util.Pointer(time.Now())
+
+	_feedback, err := self.feedbackRepository.Create(self.ctx, *_feedback)
+	self.Require().NoError(err)
+
+	partial := issue.NewPartialIssue()
+	partial.ID = xid.New().String()
+	partial.FeedbackID = _feedback.ID
+	partial.Title = "The app crashes"
+	partial.Description = "The app crashes when opened"
+	partial.Steps = []string{"Open the app"}
+	partial.Severity = issue.IssueSeverityHigh
+	partial.Category = "test"
+	partial.CreatedAt = time.Now()
+
+	partial, err = self.partialIssueRepository.Create(self.ctx, *partial)
+	self.Require().NoError(err)
+
+	self.partials = append(self.partials, partial.ID)
+
+	return partial
+}
+
+func TestIssueAggregatorSuite(t *testing.T) {
+	suite.Run(t, new(IssueAggregatorTestSuite))
+}
+
+func (self *IssueAggregatorTestSuite) TestConcurrentNewIssue() {
+	// Given: Partials from different feedbacks describing the same new issue
+	partials := make([]*issue.PartialIssue, 0, ISSUE_AGGREGATOR_TEST_CONCURRENCY)
+	for i := 0; i < ISSUE_AGGREGATOR_TEST_CONCURRENCY; i++ {
+		partials = append(partials, self.createPartial())
+	}
+
+	// When: The partials are aggregated concurrently
+	errs := make([]error, len(partials))
+	wg := sync.WaitGroup{}
+	for i, partial := range partials {
+		wg.Add(1)
+		go func(i int, partial *issue.PartialIssue) {
+			defer wg.Done()
+
+			payload, err := json.Marshal(aggregator.IssueAggregatorAggregateParams{PartialID: partial.ID})
+			self.NoError(err)
+
+			errs[i] = self.aggregator.Aggregate(self.ctx, asynq.NewTask(aggregator.IssueAggregatorAggregate, payload))
+		}(i, partial)
+	}
+	wg.Wait()
+
+	// Then: Only one issue is created and the rest are merged into it
+	for _, err := range errs {
+		self.Require().NoError(err)
+	}
+
+	issues, err := self.issueRepository.ListRandomByProductID(self.ctx, self.product.ID, 100)
+	self.Require().NoError(err)
+	self.Require().Len(issues, 1)
+	self.Equal(ISSUE_AGGREGATOR_TEST_CONCURRENCY, issues[0].Customers)
+}
+
+type IssueAggregationTestSuite struct {
+	suite.Suite
+	similar []issue.Issue
+}
+
+func (self *IssueAggregationTestSuite) SetupTest() {
+	self.similar = []issue.Issue{
+		{ID: "a", Title: "The app crashes", Description: "The app crashes when opened", Steps: []string{"Open it"}},
+		{ID: "b", Title: "The app is slow", Description: "The app is slow to load", Steps: []string{"Load it"}},
+	}
+}
+
+func TestIssueAggregationSuite(t *testing.T) {
+	suite.Run(t, new(IssueAggregationTestSuite))
+}
+
+func (self *IssueAggregationTestSuite) TestStaleUnchanged() {
+	// Given: An aggregation decided against some similar issues
+	aggregation := aggregator.IssueAggregation{Similar: self.similar, Target: &self.similar[1]}
+
+	// When: The similar issues are listed again under the lock and nothing changed
+	// Then: The aggregation still holds
+	self.False(aggregation.Stale(self.similar))
+	self.False(aggregator.IssueAggregation{Similar: self.similar}.Stale(self.similar))
+	self.False(aggregation.Stale(self.similar[1:]))
+}
+
+func (self *IssueAggregationTestSuite) TestStaleChanged() {
+	// Given: An aggregation decided against some similar issues
+	aggregation := aggregator.IssueAggregation{Similar: self.similar, Target: &self.similar[1]}
+
+	created := append(self.similar, issue.Issue{ID: "c", Title: "The app crashes on start"})
+
+	rewritten := append([]issue.Issue{}, self.similar...)
+	rewritten[0].Steps = []string{"Open it", "Wait"}
+
+	// When: The similar issues are listed again under the lock and an issue was created, rewritten or removed
+	// Then: The aggregation must be decided again
+	self.True(aggregation.Stale(created))
+	self.True(aggregator.IssueAggregation{Similar: nil}.Stale(self.similar))
+	self.True(aggregation.Stale(rewritten))
+	self.True(aggregation.Stale(self.similar[:1]))
+}
@@ -3,17 +3,22 @@ package aggregator
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
+	"backend/pkg/alert"
 	"backend/pkg/config"
 	"backend/pkg/engine"
 	"backend/pkg/feedback"
 	"backend/pkg/organization"
+	"backend/pkg/outbox"
+	"backend/pkg/priority"
 	"backend/pkg/product"
 	"backend/pkg/suggestion"
 	"backend/pkg/util"
 
 	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
 	"github.com/neoxelox/kit"
 	kitUtil "github.com/neoxelox/kit/util"
 	"github.com/rs/xid"
@@ -21,6 +26,9 @@ import (
 
 const (
 	SUGGESTION_AGGREGATOR_MAX_SIMILAR_SUGGESTIONS = 10
+	SUGGESTION_AGGREGATOR_LOCK_KEY                = "aggregator:suggestion:%s"
+	// Times the aggregation is decided again when the similar suggestions change meanwhile, before retrying the task
+	SUGGESTION_AGGREGATOR_MAX_ATTEMPTS = 3
 )
 
 const (
@@ -28,6 +36,44 @@ const (
 	SuggestionAggregatorSchedule  = "aggregator:schedule-aggregate-suggestion"
 )
 
+var (
+	ErrSuggestionAggregatorConflict = errors.New("suggestion aggregation conflicted")
+)
+
+// SuggestionAggregation is the decision of where a partial suggestion goes, taken with the engine without holding
+// the product lock. Target is the suggestion the partial is merged into, or nil if a new one is created instead.
+type SuggestionAggregation struct {
+	Similar        []suggestion.Suggestion
+	Target         *suggestion.Suggestion
+	Merged         engine.Suggestion
+	Embedding      []float32
+	EmbeddingModel string
+	Tokens         int
+}
+
+// Stale tells whether the similar suggestions changed since the aggregation was decided, so it must be decided
+// again. Any new or rewritten similar suggestion could be the one the partial belongs to, and the target could be
+// gone.
+func (self SuggestionAggregation) Stale(similar []suggestion.Suggestion) bool {
+	decided := make(map[string]suggestion.Suggestion, len(self.Similar))
+	for _, _suggestion := range self.Similar {
+		decided[_suggestion.ID] = _suggestion
+	}
+
+	targeted := self.Target == nil
+	for _, current := range similar {
+		previous, ok := decided[current.ID]
+		if !ok || previous.Title != current.Title || previous.Description != current.Description ||
+			previous.Reason != current.Reason {
+			return true
+		}
+
+		targeted = targeted || current.ID == self.Target.ID
+	}
+
+	return !targeted
+}
+
 type SuggestionAggregator struct {
 	config                      config.Config
 	observer                    *kit.Observer
@@ -37,7 +83,7 @@ type SuggestionAggregator struct {
 	feedbackRepository          *feedback.FeedbackRepository
 	productRepository           *product.ProductRepository
 	organizationRepository      organization.OrganizationRepository
-	enqueuer                    *kit.Enqueuer
+	enqueuer                    *outbox.OutboxEnqueuer
 	engineService               *engine.EngineService
 	engineBreaker               *engine.EngineBreaker
 }
@@ -45,7 +91,7 @@ type SuggestionAggregator struct {
 func NewSuggestionAggregator(observer *kit.Observer, database *kit.Database,
 	partialSuggestionRepository *suggestion.PartialSuggestionRepository, suggestionRepository *suggestion.SuggestionRepository,
 	feedbackRepository *feedback.FeedbackRepository, productRepository *product.ProductRepository,
-	organizationRepository organization.OrganizationRepository, enqueuer *kit.Enqueuer,
+	organizationRepository organization.OrganizationRepository, enqueuer *outbox.OutboxEnqueuer,
 	engineService *engine.EngineService, engineBreaker *engine.EngineBreaker,
 	config config.Config) *SuggestionAggregator {
 	return &SuggestionAggregator{
@@ -63,18 +109,18 @@ func NewSuggestionAggregator(observer *kit.Observer, database *kit.Database,
 	}
 }
 
-func (self *SuggestionAggregator) createSuggestion(ctx context.Context, embedding []float32, partial *suggestion.PartialSuggestion,
-	feedback *feedback.Feedback, product *product.Product, tokens int) error {
+func (self *SuggestionAggregator) createSuggestion(ctx context.Context, embedding []float32, embeddingModel string,
+	partial *suggestion.PartialSuggestion, feedback *feedback.Feedback, product *product.Product, tokens int) error {
 	_suggestion := suggestion.NewSuggestion()
 	_suggestion.ID = xid.New().String()
 	_suggestion.ProductID = product.ID
 	_suggestion.Embedding = embedding
+	_suggestion.EmbeddingModel = embeddingModel
 	_suggestion.Sources = map[string]int{feedback.Source: 1}
 	_suggestion.Title = partial.Title
 	_suggestion.Description = partial.Description
 	_suggestion.Reason = partial.Reason
 	_suggestion.Importances = map[string]int{partial.Importance: 1}
-	_suggestion.Priority = suggestion.ComputePriority(_suggestion.Importances, 1)
 	_suggestion.Categories = map[string]int{partial.Category: 1}
 	_suggestion.Releases = map[string]int{feedback.Release: 1}
 	_suggestion.Customers = 1
@@ -87,6 +133,14 @@ func (self *SuggestionAggregator) createSuggestion(ctx context.Context, embeddin
 	_suggestion.LastAggregatedAt = nil
 	_suggestion.ExportedAt = nil
 
+	formula := product.PriorityFormula()
+	var trend *priority.PriorityTrend
+	if formula.Trending() {
+		trend = &priority.PriorityTrend{}
+		trend.Count(feedback.PostedAt, formula.TrendWindow, time.Now())
+	}
+	_suggestion.Priority = suggestion.ComputePriority(formula, *_suggestion, trend)
+
 	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
 		err := self.partialSuggestionRepository.Delete(ctx, partial.ID)
 		if err != nil {
@@ -109,73 +163,45 @@ func (self *SuggestionAggregator) createSuggestion(ctx context.Context, embeddin
 			return err
 		}
 
+		err = self.feedbackRepository.UpdateAggregated(ctx, feedback.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 
+	util.PipelineObserve(ctx, self.observer, util.PipelineStageAggregation, partial.CreatedAt)
+
 	self.observer.Infof(ctx, "Created a new suggestion using %d tokens", tokens)
 
 	return nil
 }
 
-func (self *SuggestionAggregator) mergeSuggestions(ctx context.Context, partial *suggestion.PartialSuggestion,
-	_suggestion *suggestion.Suggestion, feedback *feedback.Feedback, product *product.Product, tokens int) error {
+func (self *SuggestionAggregator) mergeSuggestions(ctx context.Context, aggregation SuggestionAggregation,
+	partial *suggestion.PartialSuggestion, feedback *feedback.Feedback, product *product.Product, tokens int) error {
 	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
-		_suggestion, err := self.suggestionRepository.GetByIDForUpdate(ctx, _suggestion.ID)
+		_suggestion, err := self.suggestionRepository.GetByIDForUpdate(ctx, aggregation.Target.ID)
 		if err != nil {
 			return err
 		}
 
-		msResult, err := self.engineService.MergeSuggestions(ctx, engine.EngineServiceMergeSuggestionsParams{
-			SuggestionA: engine.Suggestion{
-				Title:       partial.Title,
-				Description: partial.Description,
-				Reason:      partial.Reason,
-			},
-			SuggestionB: engine.Suggestion{
-				Title:       _suggestion.Title,
-				Description: _suggestion.Description,
-				Reason:      _suggestion.Reason,
-			},
-		})
-		if err != nil {
-			if engine.ErrEngineServiceTimedOut.Is(err) {
-				err := self.engineBreaker.Open(ctx)
-				if err != nil {
-					self.observer.Error(ctx, err)
-				}
-			}
-
-			return err
-		}
-
-		_suggestion.Title = msResult.Suggestion.Title
-		_suggestion.Description = msResult.Suggestion.Description
-		_suggestion.Reason = msResult.Suggestion.Reason
-		tokens += (msResult.Usage.Input + msResult.Usage.Output)
-
-		ceResult, err := self.engineService.ComputeEmbedding(ctx, engine.EngineServiceComputeEmbeddingParams{
-			Text: _suggestion.Description,
-		})
-		if err != nil {
-			if engine.ErrEngineServiceTimedOut.Is(err) {
-				err := self.engineBreaker.Open(ctx)
-				if err != nil {
-					self.observer.Error(ctx, err)
-				}
-			}
-
-			return err
+		if _suggestion == nil {
+			return ErrSuggestionAggregatorConflict.Raise().
+				With("suggestion %s no longer exists", aggregation.Target.ID)
 		}
 
-		_suggestion.Embedding = ceResult.Embedding
-		tokens += (ceResult.Usage.Input + ceResult.Usage.Output)
+		_suggestion.Title = aggregation.Merged.Title
+		_suggestion.Description = aggregation.Merged.Description
+		_suggestion.Reason = aggregation.Merged.Reason
+		_suggestion.Embedding = aggregation.Embedding
+		_suggestion.EmbeddingModel = aggregation.EmbeddingModel
 
 		_suggestion.Sources[feedback.Source]++
 		_suggestion.Importances[partial.Importance]++
-		_suggestion.Priority = suggestion.ComputePriority(_suggestion.Importances, _suggestion.Customers+1)
 		_suggestion.Categories[partial.Category]++
 		_suggestion.Releases[feedback.Release]++
 		_suggestion.Customers++
@@ -187,6 +213,18 @@ func (self *SuggestionAggregator) mergeSuggestions(ctx context.Context, partial
 		}
 		_suggestion.LastAggregatedAt = kitUtil.Pointer(time.Now())
 
+		formula := product.PriorityFormula()
+		var trend *priority.PriorityTrend
+		if formula.Trending() {
+			trend, err = self.suggestionRepository.GetTrend(ctx, _suggestion.ID, formula.TrendWindow, time.Now())
+			if err != nil {
+				return err
+			}
+
+			trend.Count(feedback.PostedAt, formula.TrendWindow, time.Now())
+		}
+		_suggestion.Priority = suggestion.ComputePriority(formula, *_suggestion, trend)
+
 		err = self.suggestionRepository.UpdateAggregated(ctx, *_suggestion, *feedback)
 		if err != nil {
 			return err
@@ -208,25 +246,19 @@ func (self *SuggestionAggregator) mergeSuggestions(ctx context.Context, partial
 			return err
 		}
 
+		err = self.feedbackRepository.UpdateAggregated(ctx, feedback.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
 		return nil
 	})
 	if err != nil {
-		if kit.ErrDatabaseIntegrityViolation.In(err) {
-			// There are two or more suggestions from the same feedback trying to merge themselves.
-			// This should not be possible, but protect from retry-loop by deleting the partial.
-			self.observer.Error(ctx, err)
-
-			err = self.partialSuggestionRepository.Delete(ctx, partial.ID)
-			if err != nil {
-				return err
-			}
-
-			return nil
-		}
-
 		return err
 	}
 
+	util.PipelineObserve(ctx, self.observer, util.PipelineStageAggregation, partial.CreatedAt)
+
 	self.observer.Infof(ctx, "Merged 2 suggestions using %d tokens", tokens)
 
 	return nil
@@ -237,10 +269,6 @@ type SuggestionAggregatorAggregateParams struct {
 }
 
 func (self *SuggestionAggregator) Aggregate(ctx context.Context, task *asynq.Task) error {
-	if self.engineBreaker.IsOpen(ctx) {
-		return nil
-	}
-
 	params := SuggestionAggregatorAggregateParams{}
 
 	err := json.Unmarshal(task.Payload(), &params)
@@ -249,6 +277,13 @@ func (self *SuggestionAggregator) Aggregate(ctx context.Context, task *asynq.Tas
 		return nil
 	}
 
+	allowed, delay := self.engineBreaker.Allow(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING,
+		engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
+	if !allowed {
+		return self.enqueuer.Enqueue(ctx, SuggestionAggregatorAggregate, params,
+			asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.ProcessIn(delay))
+	}
+
 	partial, err := self.partialSuggestionRepository.GetByID(ctx, params.PartialID)
 	if err != nil {
 		return err
@@ -302,11 +337,12 @@ func (self *SuggestionAggregator) Aggregate(ctx context.Context, task *asynq.Tas
 	}
 
 	ceResult, err := self.engineService.ComputeEmbedding(ctx, engine.EngineServiceComputeEmbeddingParams{
-		Text: partial.Description,
+		Text:  partial.Description,
+		Model: product.EmbeddingModel,
 	})
 	if err != nil {
 		if engine.ErrEngineServiceTimedOut.Is(err) {
-			err := self.engineBreaker.Open(ctx)
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
 			if err != nil {
 				self.observer.Error(ctx, err)
 			}
@@ -317,18 +353,134 @@ func (self *SuggestionAggregator) Aggregate(ctx context.Context, task *asynq.Tas
 
 	tokens := (ceResult.Usage.Input + ceResult.Usage.Output)
 
-	suggestions, err := self.suggestionRepository.ListByEmbeddingAndProductID(ctx, ceResult.Embedding,
-		suggestion.SUGGESTION_SIMILAR_THRESHOLD, SUGGESTION_AGGREGATOR_MAX_SIMILAR_SUGGESTIONS, product.ID)
+	err = self.engineBreaker.Succeed(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
+	if err != nil {
+		self.observer.Error(ctx, err)
+	}
+
+	// The engine decides where the partial goes without holding the product lock, so other aggregations of the
+	// product are not blocked meanwhile, and the decision is only applied if it still holds under the lock
+	for attempt := 1; ; attempt++ {
+		var aggregation *SuggestionAggregation
+		aggregation, err = self.decide(ctx, ceResult.Embedding, ceResult.Model, partial, product)
+		if err != nil {
+			return err
+		}
+
+		tokens += aggregation.Tokens
+		applied := false
+
+		err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+			// Partials describing the same new suggestion would otherwise both find no similar suggestions and
+			// create duplicates, so deciding whether to create or merge is serialized per product
+			err := util.LockTransaction(ctx, self.database, fmt.Sprintf(SUGGESTION_AGGREGATOR_LOCK_KEY, product.ID))
+			if err != nil {
+				return err
+			}
+
+			// The priority formula is read again under the lock, as the recomputation of the priorities holds it
+			// too, so a formula changed meanwhile is either applied here or by the recomputation afterwards
+			product, err := self.productRepository.GetByID(ctx, product.ID)
+			if err != nil {
+				return err
+			}
+
+			if product == nil {
+				applied = true
+				return nil
+			}
+
+			similar, err := self.suggestionRepository.ListByEmbeddingAndProductID(ctx, ceResult.Embedding,
+				ceResult.Model, suggestion.SUGGESTION_SIMILAR_THRESHOLD, SUGGESTION_AGGREGATOR_MAX_SIMILAR_SUGGESTIONS,
+				product.ID, nil)
+			if err != nil {
+				return err
+			}
+
+			if aggregation.Stale(similar) {
+				return nil
+			}
+
+			applied = true
+
+			if aggregation.Target == nil {
+				return self.createSuggestion(ctx, ceResult.Embedding, ceResult.Model, partial, feedback, product,
+					tokens)
+			}
+
+			return self.mergeSuggestions(ctx, *aggregation, partial, feedback, product, tokens)
+		})
+		if err != nil || applied {
+			break
+		}
+
+		if attempt >= SUGGESTION_AGGREGATOR_MAX_ATTEMPTS {
+			return ErrSuggestionAggregatorConflict.Raise().
+				With("similar suggestions of partial %s kept changing after %d attempts", partial.ID, attempt)
+		}
+
+		self.observer.Infof(ctx, "Similar suggestions of partial %s changed meanwhile, aggregating it again",
+			partial.ID)
+	}
+
 	if err != nil {
+		if kit.ErrDatabaseIntegrityViolation.In(err) {
+			// There are two or more suggestions from the same feedback trying to merge themselves.
+			// This should not be possible, but protect from retry-loop by deleting the partial.
+			self.observer.Error(ctx, err)
+
+			err = self.partialSuggestionRepository.Delete(ctx, partial.ID)
+			if err != nil {
+				return err
+			}
+
+			err = self.feedbackRepository.UpdateAggregated(ctx, feedback.ID, time.Now())
+			if err != nil {
+				return err
+			}
+
+			return nil
+		}
+
 		return err
 	}
 
-	if len(suggestions) == 0 {
-		return self.createSuggestion(ctx, ceResult.Embedding, partial, feedback, product, tokens)
+	// Suggestions come from the same feedbacks the sentiment alerts are computed from, so a burst of aggregations
+	// of the same product is evaluated once along with the issue ones
+	err = self.enqueuer.Enqueue(ctx, alert.AlertEvaluatorEvaluate, alert.AlertEvaluatorEvaluateParams{
+		ProductID: product.ID,
+	}, asynq.MaxRetry(2), asynq.ProcessIn(alert.ALERT_EVALUATOR_DEBOUNCE),
+		asynq.Unique(alert.ALERT_EVALUATOR_DEBOUNCE))
+	if err != nil {
+		self.observer.Error(ctx, err)
+	}
+
+	return nil
+}
+
+// decide asks the engine whether the partial is the same suggestion as any of its similar suggestions and, if so,
+// merges their texts, without writing anything.
+func (self *SuggestionAggregator) decide(ctx context.Context, embedding []float32, embeddingModel string,
+	partial *suggestion.PartialSuggestion, product *product.Product) (*SuggestionAggregation, error) {
+	similar, err := self.suggestionRepository.ListByEmbeddingAndProductID(ctx, embedding,
+		embeddingModel, suggestion.SUGGESTION_SIMILAR_THRESHOLD, SUGGESTION_AGGREGATOR_MAX_SIMILAR_SUGGESTIONS,
+		product.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregation := &SuggestionAggregation{
+		Similar: similar,
+		Target:  nil,
+		Tokens:  0,
+	}
+
+	if len(similar) == 0 {
+		return aggregation, nil
 	}
 
-	options := make([]engine.Suggestion, 0, len(suggestions))
-	for _, suggestion := range suggestions {
+	options := make([]engine.Suggestion, 0, len(similar))
+	for _, suggestion := range similar {
 		options = append(options, engine.Suggestion{
 			Title:       suggestion.Title,
 			Description: suggestion.Description,
@@ -342,36 +494,102 @@ func (self *SuggestionAggregator) Aggregate(ctx context.Context, task *asynq.Tas
 			Description: partial.Description,
 			Reason:      partial.Reason,
 		},
-		Options: options,
+		Options:  options,
+		Language: product.Language,
 	})
 	if err != nil {
 		if engine.ErrEngineServiceTimedOut.Is(err) {
-			err := self.engineBreaker.Open(ctx)
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
 			if err != nil {
 				self.observer.Error(ctx, err)
 			}
 		}
 
-		return err
+		return nil, err
 	}
 
-	tokens += (ssResult.Usage.Input + ssResult.Usage.Output)
+	aggregation.Tokens += (ssResult.Usage.Input + ssResult.Usage.Output)
+
+	err = self.engineBreaker.Succeed(ctx, engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
+	if err != nil {
+		self.observer.Error(ctx, err)
+	}
 
 	if ssResult.Option == nil {
-		return self.createSuggestion(ctx, ceResult.Embedding, partial, feedback, product, tokens)
+		return aggregation, nil
+	}
+
+	target := similar[*ssResult.Option]
+
+	msResult, err := self.engineService.MergeSuggestions(ctx, engine.EngineServiceMergeSuggestionsParams{
+		SuggestionA: engine.Suggestion{
+			Title:       partial.Title,
+			Description: partial.Description,
+			Reason:      partial.Reason,
+		},
+		SuggestionB: engine.Suggestion{
+			Title:       target.Title,
+			Description: target.Description,
+			Reason:      target.Reason,
+		},
+		Language: product.Language,
+	})
+	if err != nil {
+		if engine.ErrEngineServiceTimedOut.Is(err) {
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+
+		return nil, err
 	}
 
-	return self.mergeSuggestions(ctx, partial, &suggestions[*ssResult.Option], feedback, product, tokens)
+	aggregation.Tokens += (msResult.Usage.Input + msResult.Usage.Output)
+
+	ceResult, err := self.engineService.ComputeEmbedding(ctx, engine.EngineServiceComputeEmbeddingParams{
+		Text:  msResult.Suggestion.Description,
+		Model: product.EmbeddingModel,
+	})
+	if err != nil {
+		if engine.ErrEngineServiceTimedOut.Is(err) {
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+
+		return nil, err
+	}
+
+	aggregation.Tokens += (ceResult.Usage.Input + ceResult.Usage.Output)
+
+	for _, operation := range []string{engine.ENGINE_BREAKER_OPERATION_AGGREGATION,
+		engine.ENGINE_BREAKER_OPERATION_EMBEDDING} {
+		err := self.engineBreaker.Succeed(ctx, operation)
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	aggregation.Target = &target
+	aggregation.Merged = msResult.Suggestion
+	aggregation.Embedding = ceResult.Embedding
+	aggregation.EmbeddingModel = ceResult.Model
+
+	return aggregation, nil
 }
 
 func (self *SuggestionAggregator) Schedule(ctx context.Context, _ *asynq.Task) error {
+	before := util.PipelineSweepBefore(self.config)
+
 	pagination := util.Pagination[time.Time]{
 		Limit: 1000,
 		From:  nil,
 	}
 
 	for {
-		page, err := self.partialSuggestionRepository.ListIDsByCreatedAt(ctx, pagination)
+		page, err := self.partialSuggestionRepository.ListIDsByCreatedAt(ctx, before, pagination)
 		if err != nil {
 			return err
 		}
@@ -379,7 +597,7 @@ func (self *SuggestionAggregator) Schedule(ctx context.Context, _ *asynq.Task) e
 		for _, id := range page.Items {
 			err := self.enqueuer.Enqueue(ctx, SuggestionAggregatorAggregate, SuggestionAggregatorAggregateParams{
 				PartialID: id,
-			}, asynq.MaxRetry(2), asynq.Unique(24*time.Hour))
+			}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.Unique(24*time.Hour))
 			if err != nil {
 				self.observer.Error(ctx, err)
 			}
@@ -0,0 +1,337 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/badoux/checkmail"
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+	kitUtil "github.com/neoxelox/kit/util"
+	"github.com/rs/xid"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+	"backend/pkg/user"
+	"backend/pkg/util"
+)
+
+type AlertEndpoints struct {
+	config              config.Config
+	observer            *kit.Observer
+	alertRuleRepository *AlertRuleRepository
+	alertRepository     *AlertRepository
+}
+
+func NewAlertEndpoints(observer *kit.Observer, alertRuleRepository *AlertRuleRepository,
+	alertRepository *AlertRepository, config config.Config) *AlertEndpoints {
+	return &AlertEndpoints{
+		config:              config,
+		observer:            observer,
+		alertRuleRepository: alertRuleRepository,
+		alertRepository:     alertRepository,
+	}
+}
+
+type AlertEndpointsEmailChannelSettingsRequest struct {
+	Receivers []string `json:"receivers"`
+}
+
+type AlertEndpointsWebhookChannelSettingsRequest struct {
+	URL string `json:"url"`
+}
+
+type AlertEndpointsAlertRuleRequest struct {
+	Name            string          `json:"name"`
+	Condition       string          `json:"condition"`
+	Threshold       float64         `json:"threshold"`
+	Window          int             `json:"window"`
+	Cooldown        int             `json:"cooldown"`
+	Channel         string          `json:"channel"`
+	ChannelSettings json.RawMessage `json:"channel_settings"`
+}
+
+// bindRule validates the request and sets it on the rule, returning false if it is invalid.
+func (self *AlertEndpoints) bindRule(ctx context.Context, request AlertEndpointsAlertRuleRequest,
+	rule *AlertRule) bool {
+	if len(request.Name) == 0 || len(request.Name) > ALERT_RULE_MAX_NAME_LENGTH {
+		return false
+	}
+
+	if !IsAlertCondition(request.Condition) {
+		return false
+	}
+
+	if request.Threshold <= 0 {
+		return false
+	}
+
+	window := time.Duration(request.Window) * time.Second
+	if window < ALERT_RULE_MIN_WINDOW || window > ALERT_RULE_MAX_WINDOW {
+		return false
+	}
+
+	// A cooldown shorter than the window would alert again on the same events still inside the window
+	cooldown := time.Duration(request.Cooldown) * time.Second
+	if cooldown < window || cooldown > ALERT_RULE_MAX_COOLDOWN {
+		return false
+	}
+
+	var channelSettings any
+	switch request.Channel {
+	case AlertChannelEmail:
+		var _request AlertEndpointsEmailChannelSettingsRequest
+		err := json.Unmarshal(request.ChannelSettings, &_request)
+		if err != nil {
+			return false
+		}
+
+		if len(_request.Receivers) == 0 || len(_request.Receivers) > ALERT_RULE_MAX_RECEIVERS {
+			return false
+		}
+
+		for _, receiver := range _request.Receivers {
+			err := checkmail.ValidateFormat(receiver)
+			if err != nil {
+				return false
+			}
+		}
+
+		channelSettings = EmailAlertChannelSettings{
+			Receivers: util.Unique(_request.Receivers),
+		}
+
+	case AlertChannelWebhook:
+		var _request AlertEndpointsWebhookChannelSettingsRequest
+		err := json.Unmarshal(request.ChannelSettings, &_request)
+		if err != nil {
+			return false
+		}
+
+		if !util.IsPublicURL(ctx, _request.URL) {
+			return false
+		}
+
+		channelSettings = WebhookAlertChannelSettings{
+			URL: _request.URL,
+		}
+
+	default:
+		return false
+	}
+
+	rule.Name = request.Name
+	rule.Condition = request.Condition
+	rule.Threshold = request.Threshold
+	rule.Window = window
+	rule.Cooldown = cooldown
+	rule.Channel = request.Channel
+	rule.ChannelSettings = channelSettings
+
+	return true
+}
+
+type AlertEndpointsListAlertRulesResponse struct {
+	Rules []AlertRulePayload `json:"rules"`
+}
+
+func (self *AlertEndpoints) ListAlertRules(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+
+	rules, err := self.alertRuleRepository.ListByProductID(requestCtx, requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := AlertEndpointsListAlertRulesResponse{}
+	response.Rules = make([]AlertRulePayload, 0, len(rules))
+	for _, rule := range rules {
+		response.Rules = append(response.Rules, *NewAlertRulePayload(rule))
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type AlertEndpointsPostAlertRuleRequest struct {
+	AlertEndpointsAlertRuleRequest
+}
+
+type AlertEndpointsPostAlertRuleResponse struct {
+	AlertRulePayload
+}
+
+func (self *AlertEndpoints) PostAlertRule(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := AlertEndpointsPostAlertRuleRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	rule := NewAlertRule()
+	rule.ID = xid.New().String()
+	rule.ProductID = requestProduct.ID
+	rule.LastTriggeredAt = nil
+	rule.CreatedAt = time.Now()
+	rule.DeletedAt = nil
+
+	if !self.bindRule(requestCtx, request.AlertEndpointsAlertRuleRequest, rule) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	rule, err = self.alertRuleRepository.Create(requestCtx, *rule)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := AlertEndpointsPostAlertRuleResponse{}
+	response.AlertRulePayload = *NewAlertRulePayload(*rule)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type AlertEndpointsGetAlertRuleResponse struct {
+	AlertRulePayload
+}
+
+func (self *AlertEndpoints) GetAlertRule(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestRule := RequestAlertRule(requestCtx)
+
+	response := AlertEndpointsGetAlertRuleResponse{}
+	response.AlertRulePayload = *NewAlertRulePayload(*requestRule)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type AlertEndpointsPutAlertRuleRequest struct {
+	AlertEndpointsAlertRuleRequest
+}
+
+type AlertEndpointsPutAlertRuleResponse struct {
+	AlertRulePayload
+}
+
+func (self *AlertEndpoints) PutAlertRule(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestRule := RequestAlertRule(requestCtx)
+	request := AlertEndpointsPutAlertRuleRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if !self.bindRule(requestCtx, request.AlertEndpointsAlertRuleRequest, requestRule) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	err = self.alertRuleRepository.UpdateSettings(requestCtx, *requestRule)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := AlertEndpointsPutAlertRuleResponse{}
+	response.AlertRulePayload = *NewAlertRulePayload(*requestRule)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+func (self *AlertEndpoints) DeleteAlertRule(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestRule := RequestAlertRule(requestCtx)
+
+	err := self.alertRuleRepository.UpdateDeletedAt(requestCtx, requestRule.ID, time.Now())
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, struct{}{})
+}
+
+type AlertEndpointsListAlertsRequest struct {
+	From *string `query:"from"`
+}
+
+type AlertEndpointsListAlertsResponse struct {
+	Alerts []AlertPayload `json:"alerts"`
+	Next   *string        `json:"next"`
+}
+
+func (self *AlertEndpoints) ListAlerts(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := AlertEndpointsListAlertsRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	page, err := self.alertRepository.ListByProductID(requestCtx, requestProduct.ID, util.Pagination[time.Time]{
+		Limit: 100,
+		From:  util.CursorFromString[time.Time](request.From),
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := AlertEndpointsListAlertsResponse{}
+	response.Alerts = make([]AlertPayload, 0, len(page.Items))
+	for _, alert := range page.Items {
+		response.Alerts = append(response.Alerts, *NewAlertPayload(alert))
+	}
+	response.Next = nil
+	next := util.CursorToString(page.Next)
+	if next != nil {
+		*next = url.QueryEscape(*next)
+	}
+	response.Next = next
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type AlertEndpointsPutAlertAcknowledgedRequest struct {
+	Acknowledged bool `json:"acknowledged"`
+}
+
+type AlertEndpointsPutAlertAcknowledgedResponse struct {
+	AlertPayload
+}
+
+func (self *AlertEndpoints) PutAlertAcknowledged(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestUser := user.RequestUser(requestCtx)
+	requestAlert := RequestAlert(requestCtx)
+	request := AlertEndpointsPutAlertAcknowledgedRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if request.Acknowledged {
+		requestAlert.AcknowledgedAt = kitUtil.Pointer(time.Now())
+		requestAlert.AcknowledgedByID = &requestUser.ID
+	} else {
+		requestAlert.AcknowledgedAt = nil
+		requestAlert.AcknowledgedByID = nil
+	}
+
+	err = self.alertRepository.UpdateAcknowledged(requestCtx, requestAlert.ID,
+		requestAlert.AcknowledgedAt, requestAlert.AcknowledgedByID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := AlertEndpointsPutAlertAcknowledgedResponse{}
+	response.AlertPayload = *NewAlertPayload(*requestAlert)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
@@ -0,0 +1,145 @@
+package alert
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/neoxelox/kit/util"
+)
+
+const (
+	ALERT_RULE_MAX_NAME_LENGTH = 100
+	ALERT_RULE_MIN_WINDOW      = 1 * time.Hour
+	ALERT_RULE_MAX_WINDOW      = 30 * 24 * time.Hour
+	ALERT_RULE_MAX_COOLDOWN    = 30 * 24 * time.Hour
+	ALERT_RULE_MAX_RECEIVERS   = 10
+)
+
+const (
+	// Number of issues first seen within the window whose major severity is critical
+	AlertConditionNewCriticalIssues = "NEW_CRITICAL_ISSUES"
+	// Number of archived issues that got new feedbacks within the window
+	AlertConditionArchivedIssueFeedbacks = "ARCHIVED_ISSUE_FEEDBACKS"
+	// Ratio of negative reviews within the window to the ones within the previous window
+	AlertConditionNegativeSentimentGrowth = "NEGATIVE_SENTIMENT_GROWTH"
+	// Number of issues that regressed within the window
+	AlertConditionIssueRegressions = "ISSUE_REGRESSIONS"
+)
+
+func IsAlertCondition(value string) bool {
+	return value == AlertConditionNewCriticalIssues ||
+		value == AlertConditionArchivedIssueFeedbacks ||
+		value == AlertConditionNegativeSentimentGrowth ||
+		value == AlertConditionIssueRegressions
+}
+
+const (
+	AlertChannelEmail   = "EMAIL"
+	AlertChannelWebhook = "WEBHOOK"
+)
+
+func IsAlertChannel(value string) bool {
+	return value == AlertChannelEmail ||
+		value == AlertChannelWebhook
+}
+
+type AlertChannelSettings struct {
+}
+
+type EmailAlertChannelSettings struct {
+	AlertChannelSettings
+	Receivers []string
+}
+
+type WebhookAlertChannelSettings struct {
+	AlertChannelSettings
+	URL string
+}
+
+type AlertRule struct {
+	ID              string
+	ProductID       string
+	Name            string
+	Condition       string
+	Threshold       float64
+	Window          time.Duration
+	Cooldown        time.Duration
+	Channel         string
+	ChannelSettings any
+	LastTriggeredAt *time.Time
+	CreatedAt       time.Time
+	DeletedAt       *time.Time
+}
+
+func NewAlertRule() *AlertRule {
+	return &AlertRule{}
+}
+
+func (self AlertRule) String() string {
+	return fmt.Sprintf("<AlertRule: %s (%s)>", self.Name, self.ID)
+}
+
+func (self AlertRule) Equals(other AlertRule) bool {
+	return util.Equals(self, other)
+}
+
+func (self AlertRule) Copy() *AlertRule {
+	return util.Copy(self)
+}
+
+// CoolingDown tells whether the rule triggered too recently to trigger again at the given time.
+func (self AlertRule) CoolingDown(now time.Time) bool {
+	return self.LastTriggeredAt != nil && now.Before(self.LastTriggeredAt.Add(self.Cooldown))
+}
+
+type Alert struct {
+	ID               string
+	RuleID           string
+	ProductID        string
+	Condition        string
+	Value            float64
+	Threshold        float64
+	PeriodStartAt    time.Time
+	PeriodEndAt      time.Time
+	DeliveredAt      *time.Time
+	AcknowledgedAt   *time.Time
+	AcknowledgedByID *string
+	CreatedAt        time.Time
+}
+
+func NewAlert() *Alert {
+	return &Alert{}
+}
+
+func (self Alert) String() string {
+	return fmt.Sprintf("<Alert: %s (%s)>", self.Condition, self.ID)
+}
+
+func (self Alert) Equals(other Alert) bool {
+	return util.Equals(self, other)
+}
+
+func (self Alert) Copy() *Alert {
+	return util.Copy(self)
+}
+
+// Describe summarizes in a sentence why the alert was triggered.
+func (self Alert) Describe(rule AlertRule) string {
+	window := self.PeriodEndAt.Sub(self.PeriodStartAt).Round(time.Hour)
+
+	var summary string
+	switch self.Condition {
+	case AlertConditionNewCriticalIssues:
+		summary = fmt.Sprintf("%.0f new critical issues in the last %s", self.Value, window)
+	case AlertConditionArchivedIssueFeedbacks:
+		summary = fmt.Sprintf("%.0f archived issues got new feedbacks in the last %s", self.Value, window)
+	case AlertConditionNegativeSentimentGrowth:
+		summary = fmt.Sprintf("negative reviews grew %.2fx in the last %s", self.Value, window)
+	case AlertConditionIssueRegressions:
+		summary = fmt.Sprintf("%.0f issues regressed in the last %s", self.Value, window)
+	default:
+		summary = fmt.Sprintf("%s reached %.2f", self.Condition, self.Value)
+	}
+
+	return fmt.Sprintf("%s: %s (threshold %.2f)", rule.Name, summary, self.Threshold)
+}
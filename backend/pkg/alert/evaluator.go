@@ -0,0 +1,302 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+	"github.com/neoxelox/kit/util"
+	"github.com/rs/xid"
+
+	"backend/pkg/config"
+	"backend/pkg/metric"
+	"backend/pkg/outbox"
+	"backend/pkg/product"
+	"backend/pkg/review"
+)
+
+const (
+	AlertEvaluatorEvaluate = "alert:evaluate"
+	AlertEvaluatorSchedule = "alert:schedule-evaluate"
+)
+
+const (
+	// Aggregations of the same product within this delay are evaluated together
+	ALERT_EVALUATOR_DEBOUNCE = 5 * time.Minute
+)
+
+var (
+	ErrAlertEvaluatorGeneric          = errors.New("alert evaluator failed")
+	ErrAlertEvaluatorInvalidCondition = errors.New("invalid alert condition")
+	ErrAlertEvaluatorInvalidChannel   = errors.New("invalid alert channel")
+)
+
+type AlertEvaluator struct {
+	config              config.Config
+	observer            *kit.Observer
+	database            *kit.Database
+	alertRuleRepository *AlertRuleRepository
+	alertRepository     *AlertRepository
+	productRepository   *product.ProductRepository
+	metricRepository    *metric.MetricRepository
+	enqueuer            *outbox.OutboxEnqueuer
+	notifiers           map[string]AlertNotifier
+}
+
+func NewAlertEvaluator(observer *kit.Observer, database *kit.Database, alertRuleRepository *AlertRuleRepository,
+	alertRepository *AlertRepository, productRepository *product.ProductRepository,
+	metricRepository *metric.MetricRepository, enqueuer *outbox.OutboxEnqueuer, notifiers []AlertNotifier,
+	config config.Config) *AlertEvaluator {
+	_notifiers := make(map[string]AlertNotifier, len(notifiers))
+	for _, notifier := range notifiers {
+		_notifiers[notifier.Channel()] = notifier
+	}
+
+	return &AlertEvaluator{
+		config:              config,
+		observer:            observer,
+		database:            database,
+		alertRuleRepository: alertRuleRepository,
+		alertRepository:     alertRepository,
+		productRepository:   productRepository,
+		metricRepository:    metricRepository,
+		enqueuer:            enqueuer,
+		notifiers:           _notifiers,
+	}
+}
+
+// Run checks the rules of a product against its metrics. Every rule that reaches its threshold outside of its
+// cooldown triggers an alert, which is stored before being delivered so failed deliveries remain in the history.
+func (self *AlertEvaluator) Run(ctx context.Context, product product.Product) (int, error) {
+	rules, err := self.alertRuleRepository.ListByProductID(ctx, product.ID)
+	if err != nil {
+		return 0, ErrAlertEvaluatorGeneric.Raise().Cause(err)
+	}
+
+	now := time.Now()
+	triggered := 0
+	for _, rule := range rules {
+		if rule.CoolingDown(now) {
+			continue
+		}
+
+		value, err := self.measure(ctx, rule, now)
+		if err != nil {
+			return triggered, ErrAlertEvaluatorGeneric.Raise().Cause(err)
+		}
+
+		if value < rule.Threshold {
+			continue
+		}
+
+		alert, err := self.trigger(ctx, rule, value, now)
+		if err != nil {
+			return triggered, ErrAlertEvaluatorGeneric.Raise().Cause(err)
+		}
+
+		if alert == nil {
+			continue
+		}
+
+		triggered++
+
+		self.observer.Infof(ctx, "Triggered alert %s of rule %s with value %.2f", alert.ID, rule.ID, value)
+
+		err = self.deliver(ctx, rule, *alert)
+		if err != nil {
+			// The alert is kept undelivered instead of triggering it again
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	return triggered, nil
+}
+
+func (self *AlertEvaluator) measure(ctx context.Context, rule AlertRule, now time.Time) (float64, error) {
+	params := metric.Params{
+		ProductID:     rule.ProductID,
+		PeriodStartAt: util.Pointer(now.Add(-rule.Window)),
+		PeriodEndAt:   util.Pointer(now),
+	}
+
+	switch rule.Condition {
+	case AlertConditionNewCriticalIssues:
+		result, err := self.metricRepository.GetIssueNewCriticals(ctx, metric.IssueNewCriticalsParams{
+			Params: params,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		return float64(result.Issues), nil
+
+	case AlertConditionArchivedIssueFeedbacks:
+		result, err := self.metricRepository.GetIssueArchivedActivity(ctx, metric.IssueArchivedActivityParams{
+			Params: params,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		return float64(result.Issues), nil
+
+	case AlertConditionNegativeSentimentGrowth:
+		current, err := self.metricRepository.GetReviewSentiments(ctx, metric.ReviewSentimentsParams{
+			Params: params,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		previous, err := self.metricRepository.GetReviewSentiments(ctx, metric.ReviewSentimentsParams{
+			Params: metric.Params{
+				ProductID:     rule.ProductID,
+				PeriodStartAt: util.Pointer(now.Add(-2 * rule.Window)),
+				PeriodEndAt:   util.Pointer(now.Add(-rule.Window)),
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		// A window without negative reviews counts as one, so a single review is not an infinite growth
+		return float64(current.Sentiments[review.ReviewSentimentNegative]) /
+			math.Max(float64(previous.Sentiments[review.ReviewSentimentNegative]), 1), nil
+
+	case AlertConditionIssueRegressions:
+		result, err := self.metricRepository.GetIssueRegressions(ctx, metric.IssueRegressionsParams{
+			Params: params,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		regressions := 0
+		for _, count := range result.Releases {
+			regressions += count
+		}
+
+		return float64(regressions), nil
+
+	default:
+		return 0, ErrAlertEvaluatorInvalidCondition.Raise().With("alert condition %s is not supported", rule.Condition)
+	}
+}
+
+// trigger stores the alert unless a concurrent evaluation already triggered the rule or it was deleted meanwhile.
+func (self *AlertEvaluator) trigger(ctx context.Context, rule AlertRule, value float64,
+	now time.Time) (*Alert, error) {
+	var alert *Alert
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		_rule, err := self.alertRuleRepository.GetByIDForUpdate(ctx, rule.ID)
+		if err != nil {
+			return err
+		}
+
+		if _rule == nil || _rule.DeletedAt != nil || _rule.CoolingDown(now) {
+			return nil
+		}
+
+		alert = NewAlert()
+		alert.ID = xid.New().String()
+		alert.RuleID = rule.ID
+		alert.ProductID = rule.ProductID
+		alert.Condition = rule.Condition
+		alert.Value = value
+		alert.Threshold = rule.Threshold
+		alert.PeriodStartAt = now.Add(-rule.Window)
+		alert.PeriodEndAt = now
+		alert.DeliveredAt = nil
+		alert.AcknowledgedAt = nil
+		alert.AcknowledgedByID = nil
+		alert.CreatedAt = now
+
+		alert, err = self.alertRepository.Create(ctx, *alert)
+		if err != nil {
+			return err
+		}
+
+		return self.alertRuleRepository.UpdateLastTriggeredAt(ctx, rule.ID, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+func (self *AlertEvaluator) deliver(ctx context.Context, rule AlertRule, alert Alert) error {
+	notifier, ok := self.notifiers[rule.Channel]
+	if !ok {
+		return ErrAlertEvaluatorInvalidChannel.Raise().With("alert channel %s is not supported", rule.Channel)
+	}
+
+	err := notifier.Notify(ctx, rule, alert)
+	if err != nil {
+		return err
+	}
+
+	err = self.alertRepository.UpdateDeliveredAt(ctx, alert.ID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type AlertEvaluatorEvaluateParams struct {
+	ProductID string
+}
+
+func (self *AlertEvaluator) Evaluate(ctx context.Context, task *asynq.Task) error {
+	params := AlertEvaluatorEvaluateParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	product, err := self.productRepository.GetByID(ctx, params.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	if product.DeletedAt != nil {
+		return nil
+	}
+
+	_, err = self.Run(ctx, *product)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *AlertEvaluator) Schedule(ctx context.Context, _ *asynq.Task) error {
+	ids, err := self.productRepository.ListIDsByNotDeleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := self.enqueuer.Enqueue(ctx, AlertEvaluatorEvaluate, AlertEvaluatorEvaluateParams{
+			ProductID: id,
+		}, asynq.MaxRetry(2), asynq.Unique(ALERT_EVALUATOR_DEBOUNCE))
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	return nil
+}
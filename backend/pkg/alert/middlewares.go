@@ -0,0 +1,93 @@
+package alert
+
+import (
+	"context"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+)
+
+var (
+	KeyRequestAlertRule kit.Key = kit.KeyBase + "request:alert-rule"
+	KeyRequestAlert     kit.Key = kit.KeyBase + "request:alert"
+)
+
+func RequestAlertRule(ctx context.Context) *AlertRule {
+	return ctx.Value(KeyRequestAlertRule).(*AlertRule) // nolint:forcetypeassert,errcheck
+}
+
+func RequestAlert(ctx context.Context) *Alert {
+	return ctx.Value(KeyRequestAlert).(*Alert) // nolint:forcetypeassert,errcheck
+}
+
+type AlertMiddlewares struct {
+	config              config.Config
+	observer            *kit.Observer
+	alertRuleRepository *AlertRuleRepository
+	alertRepository     *AlertRepository
+}
+
+func NewAlertMiddlewares(observer *kit.Observer, alertRuleRepository *AlertRuleRepository,
+	alertRepository *AlertRepository, config config.Config) *AlertMiddlewares {
+	return &AlertMiddlewares{
+		config:              config,
+		observer:            observer,
+		alertRuleRepository: alertRuleRepository,
+		alertRepository:     alertRepository,
+	}
+}
+
+func (self *AlertMiddlewares) HandleRule(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		requestCtx := ctx.Request().Context()
+		requestProduct := product.RequestProduct(requestCtx)
+
+		rule, err := self.alertRuleRepository.GetByID(requestCtx, ctx.Param("alert_rule_id"))
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+
+		if rule == nil {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		if rule.ProductID != requestProduct.ID {
+			return kit.HTTPErrUnauthorized
+		}
+
+		if rule.DeletedAt != nil {
+			return kit.HTTPErrUnauthorized
+		}
+
+		ctx.SetRequest(ctx.Request().WithContext(context.WithValue(requestCtx, KeyRequestAlertRule, rule)))
+
+		return next(ctx)
+	}
+}
+
+func (self *AlertMiddlewares) HandleAlert(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		requestCtx := ctx.Request().Context()
+		requestProduct := product.RequestProduct(requestCtx)
+
+		alert, err := self.alertRepository.GetByID(requestCtx, ctx.Param("alert_id"))
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+
+		if alert == nil {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		if alert.ProductID != requestProduct.ID {
+			return kit.HTTPErrUnauthorized
+		}
+
+		ctx.SetRequest(ctx.Request().WithContext(context.WithValue(requestCtx, KeyRequestAlert, alert)))
+
+		return next(ctx)
+	}
+}
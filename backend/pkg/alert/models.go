@@ -0,0 +1,136 @@
+package alert
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ALERT_RULE_MODEL_TABLE = "\"alert_rule\""
+	ALERT_MODEL_TABLE      = "\"alert\""
+)
+
+type AlertRuleModel struct {
+	ID              string     `db:"id"`
+	ProductID       string     `db:"product_id"`
+	Name            string     `db:"name"`
+	Condition       string     `db:"condition"`
+	Threshold       float64    `db:"threshold"`
+	Window          int        `db:"window"`
+	Cooldown        int        `db:"cooldown"`
+	Channel         string     `db:"channel"`
+	ChannelSettings []byte     `db:"channel_settings"`
+	LastTriggeredAt *time.Time `db:"last_triggered_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+	DeletedAt       *time.Time `db:"deleted_at"`
+}
+
+func NewAlertRuleModel(rule AlertRule) *AlertRuleModel {
+	channelSettings, err := json.Marshal(rule.ChannelSettings)
+	if err != nil {
+		panic(err)
+	}
+
+	return &AlertRuleModel{
+		ID:              rule.ID,
+		ProductID:       rule.ProductID,
+		Name:            rule.Name,
+		Condition:       rule.Condition,
+		Threshold:       rule.Threshold,
+		Window:          int(rule.Window.Seconds()),
+		Cooldown:        int(rule.Cooldown.Seconds()),
+		Channel:         rule.Channel,
+		ChannelSettings: channelSettings,
+		LastTriggeredAt: rule.LastTriggeredAt,
+		CreatedAt:       rule.CreatedAt,
+		DeletedAt:       rule.DeletedAt,
+	}
+}
+
+func (self *AlertRuleModel) ToEntity() *AlertRule {
+	var channelSettings any
+	switch self.Channel {
+	case AlertChannelEmail:
+		var _channelSettings EmailAlertChannelSettings
+		err := json.Unmarshal(self.ChannelSettings, &_channelSettings)
+		if err != nil {
+			panic(err)
+		}
+		channelSettings = _channelSettings
+
+	case AlertChannelWebhook:
+		var _channelSettings WebhookAlertChannelSettings
+		err := json.Unmarshal(self.ChannelSettings, &_channelSettings)
+		if err != nil {
+			panic(err)
+		}
+		channelSettings = _channelSettings
+
+	default:
+		panic(self.Channel)
+	}
+
+	return &AlertRule{
+		ID:              self.ID,
+		ProductID:       self.ProductID,
+		Name:            self.Name,
+		Condition:       self.Condition,
+		Threshold:       self.Threshold,
+		Window:          time.Duration(self.Window) * time.Second,
+		Cooldown:        time.Duration(self.Cooldown) * time.Second,
+		Channel:         self.Channel,
+		ChannelSettings: channelSettings,
+		LastTriggeredAt: self.LastTriggeredAt,
+		CreatedAt:       self.CreatedAt,
+		DeletedAt:       self.DeletedAt,
+	}
+}
+
+type AlertModel struct {
+	ID               string     `db:"id"`
+	RuleID           string     `db:"rule_id"`
+	ProductID        string     `db:"product_id"`
+	Condition        string     `db:"condition"`
+	Value            float64    `db:"value"`
+	Threshold        float64    `db:"threshold"`
+	PeriodStartAt    time.Time  `db:"period_start_at"`
+	PeriodEndAt      time.Time  `db:"period_end_at"`
+	DeliveredAt      *time.Time `db:"delivered_at"`
+	AcknowledgedAt   *time.Time `db:"acknowledged_at"`
+	AcknowledgedByID *string    `db:"acknowledged_by_id"`
+	CreatedAt        time.Time  `db:"created_at"`
+}
+
+func NewAlertModel(alert Alert) *AlertModel {
+	return &AlertModel{
+		ID:               alert.ID,
+		RuleID:           alert.RuleID,
+		ProductID:        alert.ProductID,
+		Condition:        alert.Condition,
+		Value:            alert.Value,
+		Threshold:        alert.Threshold,
+		PeriodStartAt:    alert.PeriodStartAt,
+		PeriodEndAt:      alert.PeriodEndAt,
+		DeliveredAt:      alert.DeliveredAt,
+		AcknowledgedAt:   alert.AcknowledgedAt,
+		AcknowledgedByID: alert.AcknowledgedByID,
+		CreatedAt:        alert.CreatedAt,
+	}
+}
+
+func (self *AlertModel) ToEntity() *Alert {
+	return &Alert{
+		ID:               self.ID,
+		RuleID:           self.RuleID,
+		ProductID:        self.ProductID,
+		Condition:        self.Condition,
+		Value:            self.Value,
+		Threshold:        self.Threshold,
+		PeriodStartAt:    self.PeriodStartAt,
+		PeriodEndAt:      self.PeriodEndAt,
+		DeliveredAt:      self.DeliveredAt,
+		AcknowledgedAt:   self.AcknowledgedAt,
+		AcknowledgedByID: self.AcknowledgedByID,
+		CreatedAt:        self.CreatedAt,
+	}
+}
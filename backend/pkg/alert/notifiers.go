@@ -0,0 +1,140 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"time"
+
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/brevo"
+	"backend/pkg/config"
+	"backend/pkg/util"
+)
+
+const (
+	ALERT_WEBHOOK_NOTIFIER_TIMEOUT = 10 * time.Second
+)
+
+var (
+	ErrAlertNotifierGeneric         = errors.New("alert notifier failed")
+	ErrAlertNotifierInvalidSettings = errors.New("invalid alert channel settings")
+)
+
+// AlertNotifier delivers the alerts of the rules configured with its channel.
+type AlertNotifier interface {
+	Channel() string
+	Notify(ctx context.Context, rule AlertRule, alert Alert) error
+}
+
+type EmailAlertNotifier struct {
+	config       config.Config
+	observer     *kit.Observer
+	brevoService *brevo.BrevoService
+}
+
+func NewEmailAlertNotifier(observer *kit.Observer, brevoService *brevo.BrevoService,
+	config config.Config) *EmailAlertNotifier {
+	return &EmailAlertNotifier{
+		config:       config,
+		observer:     observer,
+		brevoService: brevoService,
+	}
+}
+
+func (self *EmailAlertNotifier) Channel() string {
+	return AlertChannelEmail
+}
+
+func (self *EmailAlertNotifier) Notify(ctx context.Context, rule AlertRule, alert Alert) error {
+	settings, ok := rule.ChannelSettings.(EmailAlertChannelSettings)
+	if !ok {
+		return ErrAlertNotifierInvalidSettings.Raise().With("rule %s is not an email rule", rule.ID)
+	}
+
+	err := self.brevoService.SendEmail(ctx, brevo.BrevoServiceSendEmailParams{
+		Receivers: settings.Receivers,
+		Subject:   fmt.Sprintf("Alert: %s", rule.Name),
+		Body:      fmt.Sprintf("<p>%s</p>", html.EscapeString(alert.Describe(rule))),
+	})
+	if err != nil {
+		return ErrAlertNotifierGeneric.Raise().Cause(err)
+	}
+
+	return nil
+}
+
+type WebhookAlertNotifier struct {
+	config   config.Config
+	observer *kit.Observer
+	client   *http.Client
+}
+
+func NewWebhookAlertNotifier(observer *kit.Observer, config config.Config) *WebhookAlertNotifier {
+	// The webhook host could be pointed to an internal address after the rule was validated, so the IP actually
+	// dialed is checked on every connection
+	client := util.NewPublicHTTPClient(ALERT_WEBHOOK_NOTIFIER_TIMEOUT)
+
+	return &WebhookAlertNotifier{
+		config:   config,
+		observer: observer,
+		client:   client,
+	}
+}
+
+// The text field makes the body compatible with Slack and Teams incoming webhooks.
+type webhookAlertNotifierRequest struct {
+	Text  string       `json:"text"`
+	Rule  string       `json:"rule"`
+	Alert AlertPayload `json:"alert"`
+}
+
+func (self *WebhookAlertNotifier) Channel() string {
+	return AlertChannelWebhook
+}
+
+func (self *WebhookAlertNotifier) Notify(ctx context.Context, rule AlertRule, alert Alert) error {
+	settings, ok := rule.ChannelSettings.(WebhookAlertChannelSettings)
+	if !ok {
+		return ErrAlertNotifierInvalidSettings.Raise().With("rule %s is not a webhook rule", rule.ID)
+	}
+
+	requestBodyJSON, err := json.Marshal(webhookAlertNotifierRequest{
+		Text:  alert.Describe(rule),
+		Rule:  rule.Name,
+		Alert: *NewAlertPayload(alert),
+	})
+	if err != nil {
+		return ErrAlertNotifierGeneric.Raise().Cause(err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", settings.URL, bytes.NewReader(requestBodyJSON))
+	if err != nil {
+		return ErrAlertNotifierInvalidSettings.Raise().With("rule %s webhook is not valid", rule.ID).Cause(err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := self.client.Do(request)
+	if err != nil {
+		return ErrAlertNotifierGeneric.Raise().Cause(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		return ErrAlertNotifierGeneric.Raise().With("rule %s webhook responded %d", rule.ID, response.StatusCode)
+	}
+
+	return nil
+}
+
+func (self *WebhookAlertNotifier) Close(_ context.Context) error {
+	self.client.CloseIdleConnections()
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package alert
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EmailAlertPayloadChannelSettings struct {
+	AlertPayloadChannelSettings
+	Receivers []string `json:"receivers"`
+}
+
+type WebhookAlertPayloadChannelSettings struct {
+	AlertPayloadChannelSettings
+	URL string `json:"url"`
+}
+
+type AlertPayloadChannelSettings struct {
+}
+
+type AlertRulePayload struct {
+	ID              string          `json:"id"`
+	ProductID       string          `json:"product_id"`
+	Name            string          `json:"name"`
+	Condition       string          `json:"condition"`
+	Threshold       float64         `json:"threshold"`
+	Window          int             `json:"window"`
+	Cooldown        int             `json:"cooldown"`
+	Channel         string          `json:"channel"`
+	ChannelSettings json.RawMessage `json:"channel_settings"`
+	LastTriggeredAt *time.Time      `json:"last_triggered_at"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+func NewAlertRulePayload(rule AlertRule) *AlertRulePayload {
+	var err error
+
+	var channelSettings json.RawMessage
+	switch rule.Channel {
+	case AlertChannelEmail:
+		_channelSettings := rule.ChannelSettings.(EmailAlertChannelSettings) // nolint: errcheck
+		channelSettings, err = json.Marshal(EmailAlertPayloadChannelSettings{
+			Receivers: _channelSettings.Receivers,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+	case AlertChannelWebhook:
+		_channelSettings := rule.ChannelSettings.(WebhookAlertChannelSettings) // nolint: errcheck
+		channelSettings, err = json.Marshal(WebhookAlertPayloadChannelSettings{
+			URL: _channelSettings.URL,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+	default:
+		panic(rule.Channel)
+	}
+
+	return &AlertRulePayload{
+		ID:              rule.ID,
+		ProductID:       rule.ProductID,
+		Name:            rule.Name,
+		Condition:       rule.Condition,
+		Threshold:       rule.Threshold,
+		Window:          int(rule.Window.Seconds()),
+		Cooldown:        int(rule.Cooldown.Seconds()),
+		Channel:         rule.Channel,
+		ChannelSettings: channelSettings,
+		LastTriggeredAt: rule.LastTriggeredAt,
+		CreatedAt:       rule.CreatedAt,
+	}
+}
+
+type AlertPayload struct {
+	ID               string     `json:"id"`
+	RuleID           string     `json:"rule_id"`
+	ProductID        string     `json:"product_id"`
+	Condition        string     `json:"condition"`
+	Value            float64    `json:"value"`
+	Threshold        float64    `json:"threshold"`
+	PeriodStartAt    time.Time  `json:"period_start_at"`
+	PeriodEndAt      time.Time  `json:"period_end_at"`
+	DeliveredAt      *time.Time `json:"delivered_at"`
+	AcknowledgedAt   *time.Time `json:"acknowledged_at"`
+	AcknowledgedByID *string    `json:"acknowledged_by_id"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+func NewAlertPayload(alert Alert) *AlertPayload {
+	return &AlertPayload{
+		ID:               alert.ID,
+		RuleID:           alert.RuleID,
+		ProductID:        alert.ProductID,
+		Condition:        alert.Condition,
+		Value:            alert.Value,
+		Threshold:        alert.Threshold,
+		PeriodStartAt:    alert.PeriodStartAt,
+		PeriodEndAt:      alert.PeriodEndAt,
+		DeliveredAt:      alert.DeliveredAt,
+		AcknowledgedAt:   alert.AcknowledgedAt,
+		AcknowledgedByID: alert.AcknowledgedByID,
+		CreatedAt:        alert.CreatedAt,
+	}
+}
@@ -0,0 +1,331 @@
+package alert
+
+import (
+	"context"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/util"
+)
+
+type AlertRuleRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewAlertRuleRepository(observer *kit.Observer, database *kit.Database,
+	config config.Config) *AlertRuleRepository {
+	return &AlertRuleRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *AlertRuleRepository) Create(ctx context.Context, rule AlertRule) (*AlertRule, error) {
+	r := NewAlertRuleModel(rule)
+
+	// Note that window is a reserved word in PostgreSQL
+	stmt := sqlf.
+		InsertInto(ALERT_RULE_MODEL_TABLE).
+		Set("id", r.ID).
+		Set("product_id", r.ProductID).
+		Set("name", r.Name).
+		Set("condition", r.Condition).
+		Set("threshold", r.Threshold).
+		Set(`"window"`, r.Window).
+		Set("cooldown", r.Cooldown).
+		Set("channel", r.Channel).
+		Set("channel_settings", r.ChannelSettings).
+		Set("last_triggered_at", r.LastTriggeredAt).
+		Set("created_at", r.CreatedAt).
+		Set("deleted_at", r.DeletedAt).
+		Returning("*").To(&r)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ToEntity(), nil
+}
+
+func (self *AlertRuleRepository) GetByID(ctx context.Context, id string) (*AlertRule, error) {
+	var r AlertRuleModel
+
+	stmt := sqlf.
+		Select("*").To(&r).
+		From(ALERT_RULE_MODEL_TABLE).
+		Where("id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return r.ToEntity(), nil
+}
+
+func (self *AlertRuleRepository) GetByIDForUpdate(ctx context.Context, id string) (*AlertRule, error) {
+	var r AlertRuleModel
+
+	stmt := sqlf.
+		Select("*").To(&r).
+		From(ALERT_RULE_MODEL_TABLE).
+		Where("id = ?", id).
+		Clause("FOR NO KEY UPDATE")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return r.ToEntity(), nil
+}
+
+func (self *AlertRuleRepository) ListByProductID(ctx context.Context, productID string) ([]AlertRule, error) {
+	var rs []AlertRuleModel
+
+	stmt := sqlf.
+		Select("*").To(&rs).
+		From(ALERT_RULE_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		Where("deleted_at IS NULL").
+		OrderBy("created_at ASC")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []AlertRule{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]AlertRule, 0, len(rs))
+	for _, r := range rs {
+		entities = append(entities, *r.ToEntity())
+	}
+
+	return entities, nil
+}
+
+func (self *AlertRuleRepository) UpdateSettings(ctx context.Context, rule AlertRule) error {
+	r := NewAlertRuleModel(rule)
+
+	stmt := sqlf.
+		Update(ALERT_RULE_MODEL_TABLE).
+		Set("name", r.Name).
+		Set("condition", r.Condition).
+		Set("threshold", r.Threshold).
+		Set(`"window"`, r.Window).
+		Set("cooldown", r.Cooldown).
+		Set("channel", r.Channel).
+		Set("channel_settings", r.ChannelSettings).
+		Where("id = ?", r.ID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+func (self *AlertRuleRepository) UpdateLastTriggeredAt(ctx context.Context, id string,
+	lastTriggeredAt time.Time) error {
+	stmt := sqlf.
+		Update(ALERT_RULE_MODEL_TABLE).
+		Set("last_triggered_at", lastTriggeredAt).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+func (self *AlertRuleRepository) UpdateDeletedAt(ctx context.Context, id string, deletedAt time.Time) error {
+	stmt := sqlf.
+		Update(ALERT_RULE_MODEL_TABLE).
+		Set("deleted_at", deletedAt).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+type AlertRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewAlertRepository(observer *kit.Observer, database *kit.Database, config config.Config) *AlertRepository {
+	return &AlertRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *AlertRepository) Create(ctx context.Context, alert Alert) (*Alert, error) {
+	a := NewAlertModel(alert)
+
+	stmt := sqlf.
+		InsertInto(ALERT_MODEL_TABLE).
+		Set("id", a.ID).
+		Set("rule_id", a.RuleID).
+		Set("product_id", a.ProductID).
+		Set("condition", a.Condition).
+		Set("value", a.Value).
+		Set("threshold", a.Threshold).
+		Set("period_start_at", a.PeriodStartAt).
+		Set("period_end_at", a.PeriodEndAt).
+		Set("delivered_at", a.DeliveredAt).
+		Set("acknowledged_at", a.AcknowledgedAt).
+		Set("acknowledged_by_id", a.AcknowledgedByID).
+		Set("created_at", a.CreatedAt).
+		Returning("*").To(&a)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.ToEntity(), nil
+}
+
+func (self *AlertRepository) GetByID(ctx context.Context, id string) (*Alert, error) {
+	var a AlertModel
+
+	stmt := sqlf.
+		Select("*").To(&a).
+		From(ALERT_MODEL_TABLE).
+		Where("id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return a.ToEntity(), nil
+}
+
+func (self *AlertRepository) ListByProductID(ctx context.Context, productID string,
+	pagination util.Pagination[time.Time]) (*util.Page[Alert, time.Time], error) {
+	var as []AlertModel
+
+	stmt := sqlf.
+		Select("*").To(&as).
+		From(ALERT_MODEL_TABLE).
+		Where("product_id = ?", productID)
+
+	if pagination.From != nil {
+		stmt.
+			Where("(created_at, id) < (?, ?)", pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy("created_at DESC", "id DESC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[Alert, time.Time]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]Alert, 0, len(as))
+	for _, a := range as {
+		items = append(items, *a.ToEntity())
+	}
+
+	var cursor *util.Cursor[time.Time]
+	if len(as) == pagination.Limit {
+		cursor = &util.Cursor[time.Time]{
+			Value: as[pagination.Limit-1].CreatedAt,
+			ID:    as[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[Alert, time.Time]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
+
+func (self *AlertRepository) UpdateDeliveredAt(ctx context.Context, id string, deliveredAt time.Time) error {
+	stmt := sqlf.
+		Update(ALERT_MODEL_TABLE).
+		Set("delivered_at", deliveredAt).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+func (self *AlertRepository) UpdateAcknowledged(ctx context.Context, id string,
+	acknowledgedAt *time.Time, acknowledgedByID *string) error {
+	stmt := sqlf.
+		Update(ALERT_MODEL_TABLE).
+		Set("acknowledged_at", acknowledgedAt).
+		Set("acknowledged_by_id", acknowledgedByID).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
@@ -0,0 +1,346 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+	"github.com/rs/xid"
+
+	"backend/pkg/config"
+	"backend/pkg/engine"
+	"backend/pkg/organization"
+	"backend/pkg/product"
+	"backend/pkg/user"
+	"backend/pkg/util"
+)
+
+const (
+	// The answer is streamed, skipping the usual request timeout, for longer than the response write timeout allows
+	CHAT_ENDPOINTS_ANSWER_TIMEOUT = 90 * time.Second
+)
+
+const (
+	ChatEndpointsEventSources = "sources"
+	ChatEndpointsEventDelta   = "delta"
+	ChatEndpointsEventDone    = "done"
+	ChatEndpointsEventError   = "error"
+)
+
+type ChatEndpoints struct {
+	config         config.Config
+	observer       *kit.Observer
+	chatRepository *ChatRepository
+	engineService  *engine.EngineService
+}
+
+func NewChatEndpoints(observer *kit.Observer, chatRepository *ChatRepository, engineService *engine.EngineService,
+	config config.Config) *ChatEndpoints {
+	return &ChatEndpoints{
+		config:         config,
+		observer:       observer,
+		chatRepository: chatRepository,
+		engineService:  engineService,
+	}
+}
+
+// writeEvent sends a server-sent event to the client right away.
+func (self *ChatEndpoints) writeEvent(ctx echo.Context, event string, data any) error {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(ctx.Response(), "event: %s\ndata: %s\n\n", event, dataJSON)
+	if err != nil {
+		return err
+	}
+
+	ctx.Response().Flush()
+
+	return nil
+}
+
+type ChatEndpointsPostChatRequest struct {
+	Question string     `json:"question"`
+	StartAt  *time.Time `json:"start_at"`
+	EndAt    *time.Time `json:"end_at"`
+	History  []struct {
+		Question string `json:"question"`
+		Answer   string `json:"answer"`
+	} `json:"history"`
+}
+
+type ChatEndpointsPostChatDeltaEvent struct {
+	Delta string `json:"delta"`
+}
+
+type ChatEndpointsPostChatDoneEvent struct {
+	Message ChatMessagePayload `json:"message"`
+}
+
+type ChatEndpointsPostChatErrorEvent struct {
+	Code string `json:"code"`
+}
+
+// PostChat answers a question about what the customers say, streaming the sources retrieved, every fragment of the
+// answer and the stored message as server-sent events.
+func (self *ChatEndpoints) PostChat(ctx echo.Context) error {
+	if !util.IsStreamRequest(ctx.Request()) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx.Request().Context(), CHAT_ENDPOINTS_ANSWER_TIMEOUT)
+	defer cancel()
+
+	requestMe := user.RequestMe(requestCtx)
+	requestOrganization := organization.RequestOrganization(requestCtx)
+	requestProduct := product.RequestProduct(requestCtx)
+	request := ChatEndpointsPostChatRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	message := NewChatMessage()
+	message.ID = xid.New().String()
+	message.ProductID = requestProduct.ID
+	message.UserID = requestMe.ID
+	message.CreatedAt = time.Now()
+
+	err = message.SetQuestion(request.Question, request.StartAt, request.EndAt, message.CreatedAt)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if len(request.History) > CHAT_MAX_HISTORY {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	history := make([]engine.ChatMessage, 0, len(request.History))
+	for _, previous := range request.History {
+		if len(previous.Question) > CHAT_MAX_QUESTION_LENGTH || len(previous.Answer) > CHAT_MAX_ANSWER_LENGTH {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		history = append(history, engine.ChatMessage{
+			Question: previous.Question,
+			Answer:   previous.Answer,
+		})
+	}
+
+	// Chatting is charged to the organization's usage, so it stops along with the feedback pipeline
+	if requestOrganization.UsageLeft() < 1 {
+		return kit.HTTPErrUnauthorized
+	}
+
+	ceResult, err := self.engineService.ComputeEmbedding(requestCtx, engine.EngineServiceComputeEmbeddingParams{
+		Text:  message.Question,
+		Model: requestProduct.EmbeddingModel,
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	message.Answer = ""
+	message.Citations = []string{}
+	message.Tokens = ceResult.Usage.Input + ceResult.Usage.Output
+
+	// The tokens spent by the engine are charged even if the answer fails or the client leaves midway, so the
+	// message is stored on a context that outlives the request along with whatever was answered
+	save := func(answer *engine.EngineServiceAnswerQuestionResult) (*ChatMessage, error) {
+		if answer != nil {
+			message.Answer = answer.Answer
+			message.Citations = answer.Citations
+			message.Tokens += answer.Usage.Input + answer.Usage.Output
+		}
+
+		return self.chatRepository.CreateMessage(context.WithoutCancel(requestCtx), *message)
+	}
+
+	sources, err := self.retrieve(requestCtx, requestProduct.ID, *message, ceResult.Embedding, ceResult.Model)
+	if err != nil {
+		_, errSave := save(nil)
+		if errSave != nil {
+			self.observer.Error(requestCtx, errSave)
+		}
+
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	feedbacks := make([]engine.CitableFeedback, 0, len(sources.Feedbacks))
+	for _, feedback := range sources.Feedbacks {
+		content := feedback.Content
+		if feedback.Language != requestProduct.Language {
+			content = feedback.Translation
+		}
+
+		if len(content) > 0 {
+			feedbacks = append(feedbacks, engine.CitableFeedback{ID: feedback.ID, Content: content})
+		}
+	}
+
+	issues := make([]engine.Issue, 0, len(sources.Issues))
+	for _, issue := range sources.Issues {
+		issues = append(issues, engine.Issue{Title: issue.Title, Description: issue.Description})
+	}
+
+	suggestions := make([]engine.Suggestion, 0, len(sources.Suggestions))
+	for _, suggestion := range sources.Suggestions {
+		suggestions = append(suggestions, engine.Suggestion{
+			Title:       suggestion.Title,
+			Description: suggestion.Description,
+		})
+	}
+
+	deadline, _ := requestCtx.Deadline()
+
+	err = http.NewResponseController(ctx.Response()).SetWriteDeadline(deadline)
+	if err != nil {
+		_, errSave := save(nil)
+		if errSave != nil {
+			self.observer.Error(requestCtx, errSave)
+		}
+
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+	ctx.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+	ctx.Response().Header().Set(echo.HeaderConnection, "keep-alive")
+	ctx.Response().WriteHeader(http.StatusOK)
+
+	// From now on errors cannot be responded with a status, so they are sent as an event instead
+	fail := func(err error) error {
+		self.observer.Error(requestCtx, err)
+
+		err = self.writeEvent(ctx, ChatEndpointsEventError, ChatEndpointsPostChatErrorEvent{
+			Code: kit.HTTPErrServerGeneric.Code(),
+		})
+		if err != nil {
+			self.observer.Error(requestCtx, err)
+		}
+
+		return nil
+	}
+
+	err = self.writeEvent(ctx, ChatEndpointsEventSources, NewChatSourcesPayload(*sources))
+	if err != nil {
+		_, errSave := save(nil)
+		if errSave != nil {
+			self.observer.Error(requestCtx, errSave)
+		}
+
+		return fail(err)
+	}
+
+	aqResult, err := self.engineService.AnswerQuestion(requestCtx, engine.EngineServiceAnswerQuestionParams{
+		Context:     requestProduct.Context,
+		Question:    message.Question,
+		Feedbacks:   feedbacks,
+		Issues:      issues,
+		Suggestions: suggestions,
+		History:     history,
+	}, func(delta string) error {
+		return self.writeEvent(ctx, ChatEndpointsEventDelta, ChatEndpointsPostChatDeltaEvent{Delta: delta})
+	})
+	if err != nil {
+		// The partial answer is stored as well, so its usage is charged
+		_, errSave := save(aqResult)
+		if errSave != nil {
+			self.observer.Error(requestCtx, errSave)
+		}
+
+		return fail(err)
+	}
+
+	message, err = save(aqResult)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = self.writeEvent(ctx, ChatEndpointsEventDone, ChatEndpointsPostChatDoneEvent{
+		Message: *NewChatMessagePayload(*message),
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	return nil
+}
+
+func (self *ChatEndpoints) retrieve(ctx context.Context, productID string, message ChatMessage,
+	embedding []float32, model string) (*ChatSources, error) {
+	var err error
+	sources := ChatSources{}
+
+	sources.Feedbacks, err = self.chatRepository.ListFeedbacksByEmbedding(ctx, productID,
+		embedding, model, message.StartAt, message.EndAt, CHAT_RETRIEVAL_MAX_FEEDBACKS)
+	if err != nil {
+		return nil, err
+	}
+
+	sources.Issues, err = self.chatRepository.ListIssuesByEmbedding(ctx, productID,
+		embedding, model, message.StartAt, message.EndAt, CHAT_RETRIEVAL_MAX_ISSUES)
+	if err != nil {
+		return nil, err
+	}
+
+	sources.Suggestions, err = self.chatRepository.ListSuggestionsByEmbedding(ctx, productID,
+		embedding, model, message.StartAt, message.EndAt, CHAT_RETRIEVAL_MAX_SUGGESTIONS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sources, nil
+}
+
+type ChatEndpointsListChatMessagesRequest struct {
+	From *string `query:"from"`
+}
+
+type ChatEndpointsListChatMessagesResponse struct {
+	Messages []ChatMessagePayload `json:"messages"`
+	Next     *string              `json:"next"`
+}
+
+func (self *ChatEndpoints) ListChatMessages(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestMe := user.RequestMe(requestCtx)
+	requestProduct := product.RequestProduct(requestCtx)
+	request := ChatEndpointsListChatMessagesRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	page, err := self.chatRepository.ListMessages(requestCtx, requestProduct.ID, requestMe.ID,
+		util.Pagination[time.Time]{
+			Limit: 100,
+			From:  util.CursorFromString[time.Time](request.From),
+		})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := ChatEndpointsListChatMessagesResponse{}
+	response.Messages = make([]ChatMessagePayload, 0, len(page.Items))
+	for _, message := range page.Items {
+		response.Messages = append(response.Messages, *NewChatMessagePayload(message))
+	}
+	response.Next = nil
+	next := util.CursorToString(page.Next)
+	if next != nil {
+		*next = url.QueryEscape(*next)
+	}
+	response.Next = next
+
+	return ctx.JSON(http.StatusOK, &response)
+}
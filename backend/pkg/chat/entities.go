@@ -0,0 +1,100 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/neoxelox/errors"
+
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/suggestion"
+)
+
+const (
+	CHAT_MAX_QUESTION_LENGTH = 1000
+	CHAT_MAX_ANSWER_LENGTH   = 10000
+	// Previous messages given as context at most, the rest are forgotten
+	CHAT_MAX_HISTORY    = 5
+	CHAT_MAX_PERIOD     = 366 * 24 * time.Hour
+	CHAT_DEFAULT_PERIOD = 90 * 24 * time.Hour
+)
+
+const (
+	// Sources retrieved at most for every question, the most similar first
+	CHAT_RETRIEVAL_MAX_FEEDBACKS   = 20
+	CHAT_RETRIEVAL_MAX_ISSUES      = 5
+	CHAT_RETRIEVAL_MAX_SUGGESTIONS = 5
+	// Minimum cosine similarity for a source to be relevant to the question
+	CHAT_RETRIEVAL_THRESHOLD = 0.3
+)
+
+var (
+	ErrChatInvalid = errors.New("chat invalid")
+)
+
+type ChatMessage struct {
+	ID        string
+	ProductID string
+	UserID    string
+	Question  string
+	Answer    string
+	Citations []string
+	StartAt   time.Time
+	EndAt     time.Time
+	Tokens    int
+	CreatedAt time.Time
+}
+
+func NewChatMessage() *ChatMessage {
+	return &ChatMessage{}
+}
+
+// SetQuestion normalizes the question and the period of the feedbacks it is about, which is the last days until now
+// when not given.
+func (self *ChatMessage) SetQuestion(question string, startAt *time.Time, endAt *time.Time, now time.Time) error {
+	question = strings.TrimSpace(question)
+	if len(question) == 0 || len(question) > CHAT_MAX_QUESTION_LENGTH {
+		return ErrChatInvalid.Raise().With("question must have between 1 and %d characters", CHAT_MAX_QUESTION_LENGTH)
+	}
+
+	end := now
+	if endAt != nil {
+		end = *endAt
+	}
+
+	start := end.Add(-CHAT_DEFAULT_PERIOD)
+	if startAt != nil {
+		start = *startAt
+	}
+
+	if start.After(end) {
+		return ErrChatInvalid.Raise().With("start %s is after end %s", start, end)
+	}
+
+	if end.Sub(start) > CHAT_MAX_PERIOD {
+		return ErrChatInvalid.Raise().With("period cannot be longer than %s", CHAT_MAX_PERIOD)
+	}
+
+	self.Question = question
+	self.StartAt = start
+	self.EndAt = end
+
+	return nil
+}
+
+func (self ChatMessage) String() string {
+	return fmt.Sprintf("<ChatMessage: %s (%s)>", self.Question, self.ID)
+}
+
+func (self ChatMessage) Equals(other ChatMessage) bool {
+	return self.ID == other.ID
+}
+
+// ChatSources are the feedbacks, issues and suggestions relevant to a question.
+type ChatSources struct {
+	Feedbacks   []feedback.Feedback
+	Issues      []issue.Issue
+	Suggestions []suggestion.Suggestion
+}
@@ -0,0 +1,56 @@
+package chat_test
+
+import (
+	"testing"
+	"time"
+
+	"backend/pkg/chat"
+
+	"github.com/neoxelox/kit/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type ChatTestSuite struct {
+	suite.Suite
+}
+
+func TestChatSuite(t *testing.T) {
+	suite.Run(t, new(ChatTestSuite))
+}
+
+func (self *ChatTestSuite) TestSetQuestionDefaultsPeriod() {
+	// Given: A question without period
+	message := chat.NewChatMessage()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// When: The question is set
+	err := message.SetQuestion(" What do customers say about onboarding? ", nil, nil, now)
+
+	// Then: The question is trimmed and about the last days until now
+	self.Require().NoError(err)
+	self.Equal("What do customers say about onboarding?", message.Question)
+	self.Equal(now, message.EndAt)
+	self.Equal(now.Add(-chat.CHAT_DEFAULT_PERIOD), message.StartAt)
+}
+
+func (self *ChatTestSuite) TestSetQuestionRejectsInvalid() {
+	// Given: Questions without content or with invalid periods
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	inputs := []struct {
+		Question string
+		StartAt  *time.Time
+		EndAt    *time.Time
+	}{
+		{Question: " ", StartAt: nil, EndAt: nil},
+		{Question: "Pricing?", StartAt: util.Pointer(now), EndAt: util.Pointer(now.Add(-time.Hour))},
+		{Question: "Pricing?", StartAt: util.Pointer(now.AddDate(-2, 0, 0)), EndAt: util.Pointer(now)},
+	}
+
+	for _, input := range inputs {
+		// When: The question is set
+		err := chat.NewChatMessage().SetQuestion(input.Question, input.StartAt, input.EndAt, now)
+
+		// Then: The question is invalid
+		self.True(chat.ErrChatInvalid.Is(err))
+	}
+}
@@ -0,0 +1,52 @@
+package chat
+
+import (
+	"time"
+)
+
+const (
+	CHAT_MESSAGE_MODEL_TABLE = "\"chat_message\""
+)
+
+type ChatMessageModel struct {
+	ID        string    `db:"id"`
+	ProductID string    `db:"product_id"`
+	UserID    string    `db:"user_id"`
+	Question  string    `db:"question"`
+	Answer    string    `db:"answer"`
+	Citations []string  `db:"citations"`
+	StartAt   time.Time `db:"start_at"`
+	EndAt     time.Time `db:"end_at"`
+	Tokens    int       `db:"tokens"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func NewChatMessageModel(message ChatMessage) *ChatMessageModel {
+	return &ChatMessageModel{
+		ID:        message.ID,
+		ProductID: message.ProductID,
+		UserID:    message.UserID,
+		Question:  message.Question,
+		Answer:    message.Answer,
+		Citations: message.Citations,
+		StartAt:   message.StartAt,
+		EndAt:     message.EndAt,
+		Tokens:    message.Tokens,
+		CreatedAt: message.CreatedAt,
+	}
+}
+
+func (self *ChatMessageModel) ToEntity() *ChatMessage {
+	return &ChatMessage{
+		ID:        self.ID,
+		ProductID: self.ProductID,
+		UserID:    self.UserID,
+		Question:  self.Question,
+		Answer:    self.Answer,
+		Citations: self.Citations,
+		StartAt:   self.StartAt,
+		EndAt:     self.EndAt,
+		Tokens:    self.Tokens,
+		CreatedAt: self.CreatedAt,
+	}
+}
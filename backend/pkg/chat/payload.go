@@ -0,0 +1,64 @@
+package chat
+
+import (
+	"time"
+
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/suggestion"
+)
+
+type ChatMessagePayload struct {
+	ID        string    `json:"id"`
+	ProductID string    `json:"product_id"`
+	UserID    string    `json:"user_id"`
+	Question  string    `json:"question"`
+	Answer    string    `json:"answer"`
+	Citations []string  `json:"citations"`
+	StartAt   time.Time `json:"start_at"`
+	EndAt     time.Time `json:"end_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewChatMessagePayload(message ChatMessage) *ChatMessagePayload {
+	return &ChatMessagePayload{
+		ID:        message.ID,
+		ProductID: message.ProductID,
+		UserID:    message.UserID,
+		Question:  message.Question,
+		Answer:    message.Answer,
+		Citations: message.Citations,
+		StartAt:   message.StartAt,
+		EndAt:     message.EndAt,
+		CreatedAt: message.CreatedAt,
+	}
+}
+
+type ChatSourcesPayload struct {
+	Feedbacks   []feedback.FeedbackPayload     `json:"feedbacks"`
+	Issues      []issue.IssuePayload           `json:"issues"`
+	Suggestions []suggestion.SuggestionPayload `json:"suggestions"`
+}
+
+func NewChatSourcesPayload(sources ChatSources) *ChatSourcesPayload {
+	feedbacks := make([]feedback.FeedbackPayload, 0, len(sources.Feedbacks))
+	for _, _feedback := range sources.Feedbacks {
+		feedbacks = append(feedbacks, *feedback.NewFeedbackPayload(_feedback))
+	}
+
+	issues := make([]issue.IssuePayload, 0, len(sources.Issues))
+	for _, _issue := range sources.Issues {
+		issues = append(issues, *issue.NewIssuePayload(_issue))
+	}
+
+	suggestions := make([]suggestion.SuggestionPayload, 0, len(sources.Suggestions))
+	for _, _suggestion := range sources.Suggestions {
+		suggestions = append(suggestions, *suggestion.NewSuggestionPayload(_suggestion))
+	}
+
+	return &ChatSourcesPayload{
+		Feedbacks:   feedbacks,
+		Issues:      issues,
+		Suggestions: suggestions,
+	}
+}
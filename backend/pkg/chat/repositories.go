@@ -0,0 +1,253 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+	"github.com/pgvector/pgvector-go"
+
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/suggestion"
+	"backend/pkg/util"
+)
+
+type ChatRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewChatRepository(observer *kit.Observer, database *kit.Database, config config.Config) *ChatRepository {
+	return &ChatRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *ChatRepository) CreateMessage(ctx context.Context, message ChatMessage) (*ChatMessage, error) {
+	m := NewChatMessageModel(message)
+
+	stmt := sqlf.
+		InsertInto(CHAT_MESSAGE_MODEL_TABLE).
+		Set("id", m.ID).
+		Set("product_id", m.ProductID).
+		Set("user_id", m.UserID).
+		Set("question", m.Question).
+		Set("answer", m.Answer).
+		Set("citations", m.Citations).
+		Set("start_at", m.StartAt).
+		Set("end_at", m.EndAt).
+		Set("tokens", m.Tokens).
+		Set("created_at", m.CreatedAt).
+		Returning("*").To(&m)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.ToEntity(), nil
+}
+
+// ListMessages returns the messages of a user about a product, the most recent first.
+func (self *ChatRepository) ListMessages(ctx context.Context, productID string, userID string,
+	pagination util.Pagination[time.Time]) (*util.Page[ChatMessage, time.Time], error) {
+	var ms []ChatMessageModel
+
+	stmt := sqlf.
+		Select("*").To(&ms).
+		From(CHAT_MESSAGE_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		Where("user_id = ?", userID)
+
+	if pagination.From != nil {
+		stmt.Where("(created_at, id) < (?, ?)", pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy("created_at DESC", "id DESC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[ChatMessage, time.Time]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]ChatMessage, 0, len(ms))
+	for _, m := range ms {
+		items = append(items, *m.ToEntity())
+	}
+
+	var cursor *util.Cursor[time.Time]
+	if len(items) == pagination.Limit {
+		cursor = &util.Cursor[time.Time]{
+			Value: items[pagination.Limit-1].CreatedAt,
+			ID:    items[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[ChatMessage, time.Time]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
+
+// ListFeedbacksByEmbedding only compares the embedding with the ones of the feedbacks posted within the period
+// computed by the same model. The search is approximate, tuned by the configured vector search parameters.
+func (self *ChatRepository) ListFeedbacksByEmbedding(ctx context.Context, productID string, embedding []float32,
+	embeddingModel string, startAt time.Time, endAt time.Time, limit int) ([]feedback.Feedback, error) {
+	var result []struct {
+		feedback.FeedbackModel
+		Distance float64 `db:"distance"`
+	}
+
+	// The inner query must be ordered by the raw distance so it can be served by the HNSW index
+	stmt := sqlf.
+		Select("*").To(&result).
+		From("").
+		SubQuery("(", ")", sqlf.
+			Select(feedback.FEEDBACK_MODEL_TABLE+".*").
+			Select(fmt.Sprintf("%s.embedding <=> ?::vector AS distance", feedback.FEEDBACK_EMBEDDING_MODEL_TABLE),
+				pgvector.NewVector(embedding)).
+			From(feedback.FEEDBACK_EMBEDDING_MODEL_TABLE).
+			Join(feedback.FEEDBACK_MODEL_TABLE,
+				feedback.FEEDBACK_MODEL_TABLE+".id = "+feedback.FEEDBACK_EMBEDDING_MODEL_TABLE+".feedback_id").
+			Where(feedback.FEEDBACK_EMBEDDING_MODEL_TABLE+".product_id = ?", productID).
+			Where(feedback.FEEDBACK_EMBEDDING_MODEL_TABLE+".embedding_model = ?", embeddingModel).
+			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at BETWEEN ? AND ?", startAt, endAt).
+			OrderBy("distance ASC").
+			Limit(limit)).
+		Where("1 - distance >= ?", CHAT_RETRIEVAL_THRESHOLD).
+		OrderBy("distance ASC")
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := util.NewVectorSearch(self.config).Apply(ctx, self.database)
+		if err != nil {
+			return err
+		}
+
+		return self.database.Query(ctx, stmt)
+	})
+	if err != nil {
+		if kit.ErrDatabaseNoRows.In(err) {
+			return []feedback.Feedback{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]feedback.Feedback, 0, len(result))
+	for _, res := range result {
+		entities = append(entities, *res.FeedbackModel.ToEntity())
+	}
+
+	return entities, nil
+}
+
+// ListIssuesByEmbedding only compares the embedding with the ones of the issues seen within the period computed by
+// the same model. The search is approximate, tuned by the configured vector search parameters.
+func (self *ChatRepository) ListIssuesByEmbedding(ctx context.Context, productID string, embedding []float32,
+	embeddingModel string, startAt time.Time, endAt time.Time, limit int) ([]issue.Issue, error) {
+	var result []struct {
+		issue.IssueModel
+		Distance float64 `db:"distance"`
+	}
+
+	// The inner query must be ordered by the raw distance so it can be served by the HNSW index
+	stmt := sqlf.
+		Select("*").To(&result).
+		From("").
+		SubQuery("(", ")", sqlf.
+			Select("*").
+			Select("embedding <=> ?::vector AS distance", pgvector.NewVector(embedding)).
+			From(issue.ISSUE_MODEL_TABLE).
+			Where("product_id = ?", productID).
+			Where("embedding_model = ?", embeddingModel).
+			Where("first_seen_at <= ? AND last_seen_at >= ?", endAt, startAt).
+			OrderBy("distance ASC").
+			Limit(limit)).
+		Where("1 - distance >= ?", CHAT_RETRIEVAL_THRESHOLD).
+		OrderBy("distance ASC")
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := util.NewVectorSearch(self.config).Apply(ctx, self.database)
+		if err != nil {
+			return err
+		}
+
+		return self.database.Query(ctx, stmt)
+	})
+	if err != nil {
+		if kit.ErrDatabaseNoRows.In(err) {
+			return []issue.Issue{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]issue.Issue, 0, len(result))
+	for _, res := range result {
+		entities = append(entities, *res.IssueModel.ToEntity())
+	}
+
+	return entities, nil
+}
+
+// ListSuggestionsByEmbedding only compares the embedding with the ones of the suggestions seen within the period
+// computed by the same model. The search is approximate, tuned by the configured vector search parameters.
+func (self *ChatRepository) ListSuggestionsByEmbedding(ctx context.Context, productID string, embedding []float32,
+	embeddingModel string, startAt time.Time, endAt time.Time, limit int) ([]suggestion.Suggestion, error) {
+	var result []struct {
+		suggestion.SuggestionModel
+		Distance float64 `db:"distance"`
+	}
+
+	// The inner query must be ordered by the raw distance so it can be served by the HNSW index
+	stmt := sqlf.
+		Select("*").To(&result).
+		From("").
+		SubQuery("(", ")", sqlf.
+			Select("*").
+			Select("embedding <=> ?::vector AS distance", pgvector.NewVector(embedding)).
+			From(suggestion.SUGGESTION_MODEL_TABLE).
+			Where("product_id = ?", productID).
+			Where("embedding_model = ?", embeddingModel).
+			Where("first_seen_at <= ? AND last_seen_at >= ?", endAt, startAt).
+			OrderBy("distance ASC").
+			Limit(limit)).
+		Where("1 - distance >= ?", CHAT_RETRIEVAL_THRESHOLD).
+		OrderBy("distance ASC")
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := util.NewVectorSearch(self.config).Apply(ctx, self.database)
+		if err != nil {
+			return err
+		}
+
+		return self.database.Query(ctx, stmt)
+	})
+	if err != nil {
+		if kit.ErrDatabaseNoRows.In(err) {
+			return []suggestion.Suggestion{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]suggestion.Suggestion, 0, len(result))
+	for _, res := range result {
+		entities = append(entities, *res.SuggestionModel.ToEntity())
+	}
+
+	return entities, nil
+}
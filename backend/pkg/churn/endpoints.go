@@ -0,0 +1,81 @@
+package churn
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+type ChurnEndpoints struct {
+	config              config.Config
+	observer            *kit.Observer
+	churnRiskRepository *ChurnRiskRepository
+}
+
+func NewChurnEndpoints(observer *kit.Observer, churnRiskRepository *ChurnRiskRepository,
+	config config.Config) *ChurnEndpoints {
+	return &ChurnEndpoints{
+		config:              config,
+		observer:            observer,
+		churnRiskRepository: churnRiskRepository,
+	}
+}
+
+type ChurnEndpointsListAtRiskCustomersRequest struct {
+	MinScore *int    `query:"min_score"`
+	From     *string `query:"from"`
+}
+
+type ChurnEndpointsListAtRiskCustomersResponse struct {
+	Customers []ChurnRiskPayload `json:"customers"`
+	Next      *string            `json:"next"`
+}
+
+func (self *ChurnEndpoints) ListAtRiskCustomers(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := ChurnEndpointsListAtRiskCustomersRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	minScore := CHURN_AT_RISK_MIN_SCORE
+	if request.MinScore != nil {
+		if *request.MinScore < 0 || *request.MinScore > 100 {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		minScore = *request.MinScore
+	}
+
+	page, err := self.churnRiskRepository.ListByProductID(requestCtx, requestProduct.ID, minScore,
+		util.Pagination[int]{
+			Limit: 100,
+			From:  util.CursorFromString[int](request.From),
+		})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := ChurnEndpointsListAtRiskCustomersResponse{}
+	response.Customers = make([]ChurnRiskPayload, 0, len(page.Items))
+	for _, risk := range page.Items {
+		response.Customers = append(response.Customers, *NewChurnRiskPayload(risk))
+	}
+	response.Next = nil
+	next := util.CursorToString(page.Next)
+	if next != nil {
+		*next = url.QueryEscape(*next)
+	}
+	response.Next = next
+
+	return ctx.JSON(http.StatusOK, &response)
+}
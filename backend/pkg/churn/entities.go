@@ -0,0 +1,188 @@
+package churn
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/pkg/engine"
+	"backend/pkg/review"
+)
+
+const (
+	// Customers scored at once, so products with many customers are scored in pages without truncating any
+	CHURN_SCORING_CUSTOMERS_PER_PAGE = 500
+	// Only the feedbacks posted within the window are signals of the current risk
+	CHURN_SCORING_WINDOW = 180 * 24 * time.Hour
+	// Time after which a signal weighs half as much as a new one
+	CHURN_SCORING_SIGNAL_HALF_LIFE = 30 * 24 * time.Hour
+	// Time after which the risk of a silent customer weighs half as much
+	CHURN_SCORING_RECENCY_HALF_LIFE = 60 * 24 * time.Hour
+	// Unresolved issues reported after which the issues component is maxed out
+	CHURN_SCORING_MAX_UNRESOLVED_ISSUES = 3
+	// Minimum score for a customer to be at risk
+	CHURN_AT_RISK_MIN_SCORE = 50
+)
+
+const (
+	CHURN_SCORING_INTENTION_WEIGHT = 0.45
+	CHURN_SCORING_SENTIMENT_WEIGHT = 0.30
+	CHURN_SCORING_ISSUES_WEIGHT    = 0.25
+)
+
+const (
+	ChurnReasonChurnIntention     = "CHURN_INTENTION"
+	ChurnReasonNegativeSentiment  = "NEGATIVE_SENTIMENT"
+	ChurnReasonWorseningSentiment = "WORSENING_SENTIMENT"
+	ChurnReasonUnresolvedIssues   = "UNRESOLVED_ISSUES"
+)
+
+var churnIntentionRisks = map[string]float64{
+	review.ReviewIntentionChurnAndDiscourage: 1.0,
+	review.ReviewIntentionChurn:              0.8,
+	engine.OPTION_UNKNOWN:                    0.4,
+	review.ReviewIntentionRetain:             0.15,
+	review.ReviewIntentionRetainAndRecommend: 0.0,
+}
+
+var churnSentimentRisks = map[string]float64{
+	review.ReviewSentimentNegative: 1.0,
+	review.ReviewSentimentNeutral:  0.5,
+	review.ReviewSentimentPositive: 0.0,
+}
+
+// NewChurnCustomerKey identifies a customer across feedbacks by their email or, when anonymous, by their link
+// within the source. Names are not unique, so customers only known by name cannot be scored.
+func NewChurnCustomerKey(source string, email *string, link *string) string {
+	if email != nil && len(strings.TrimSpace(*email)) > 0 {
+		return "EMAIL:" + strings.ToLower(strings.TrimSpace(*email))
+	}
+
+	if link != nil && len(strings.TrimSpace(*link)) > 0 {
+		return source + ":" + strings.TrimSpace(*link)
+	}
+
+	return ""
+}
+
+// ChurnSignal is the intention and sentiment of a reviewed feedback of a customer.
+type ChurnSignal struct {
+	FeedbackID string
+	Intention  string
+	Sentiment  string
+	PostedAt   time.Time
+}
+
+type ChurnCustomer struct {
+	Key              string
+	Email            *string
+	Name             string
+	Source           string
+	Signals          []ChurnSignal
+	UnresolvedIssues int
+}
+
+type ChurnRisk struct {
+	ID               string
+	ProductID        string
+	CustomerKey      string
+	CustomerEmail    *string
+	CustomerName     string
+	Source           string
+	Score            int
+	Reasons          []string
+	Feedbacks        int
+	UnresolvedIssues int
+	LastFeedbackAt   time.Time
+	ComputedAt       time.Time
+}
+
+func NewChurnRisk() *ChurnRisk {
+	return &ChurnRisk{}
+}
+
+func (self ChurnRisk) String() string {
+	return fmt.Sprintf("<ChurnRisk: %s (%s)>", self.CustomerKey, self.ID)
+}
+
+func (self ChurnRisk) Equals(other ChurnRisk) bool {
+	return self.ID == other.ID
+}
+
+// decay weighs a signal by how long ago it happened, halving every half life.
+func decay(age time.Duration, halfLife time.Duration) float64 {
+	return math.Pow(0.5, max(0, age.Hours())/halfLife.Hours())
+}
+
+// ComputeChurnRisk scores from 0 to 100 how likely the customer is to churn, along with the reasons why. The score
+// combines the intentions and sentiments of the customer, the recent ones weighing more, and the unresolved issues
+// they reported, scaled down the longer the customer has been silent.
+func ComputeChurnRisk(customer ChurnCustomer, now time.Time) (int, []string) {
+	reasons := []string{}
+
+	if len(customer.Signals) == 0 {
+		return 0, reasons
+	}
+
+	signals := make([]ChurnSignal, len(customer.Signals))
+	copy(signals, customer.Signals)
+	sort.SliceStable(signals, func(i, j int) bool {
+		return signals[i].PostedAt.Before(signals[j].PostedAt)
+	})
+	last := signals[len(signals)-1]
+
+	intention := 0.0
+	sentiment := 0.0
+	weights := 0.0
+	for _, signal := range signals {
+		weight := decay(now.Sub(signal.PostedAt), CHURN_SCORING_SIGNAL_HALF_LIFE)
+		intention += weight * churnIntentionRisks[signal.Intention]
+		sentiment += weight * churnSentimentRisks[signal.Sentiment]
+		weights += weight
+	}
+	intention /= weights
+	sentiment /= weights
+
+	// The sentiment is worsening when the last one is worse than the previous ones on average
+	worsening := 0.0
+	if len(signals) > 1 {
+		previous := 0.0
+		for _, signal := range signals[:len(signals)-1] {
+			previous += churnSentimentRisks[signal.Sentiment]
+		}
+		previous /= float64(len(signals) - 1)
+
+		worsening = max(0, churnSentimentRisks[last.Sentiment]-previous)
+	}
+	sentiment = min(1, sentiment+0.5*worsening)
+
+	issues := min(1, float64(customer.UnresolvedIssues)/CHURN_SCORING_MAX_UNRESOLVED_ISSUES)
+
+	recency := decay(now.Sub(last.PostedAt), CHURN_SCORING_RECENCY_HALF_LIFE)
+
+	risk := CHURN_SCORING_INTENTION_WEIGHT*intention +
+		CHURN_SCORING_SENTIMENT_WEIGHT*sentiment +
+		CHURN_SCORING_ISSUES_WEIGHT*issues
+	score := int(math.Round(100 * risk * (0.4 + 0.6*recency)))
+
+	if last.Intention == review.ReviewIntentionChurn || last.Intention == review.ReviewIntentionChurnAndDiscourage ||
+		intention >= 0.5 {
+		reasons = append(reasons, ChurnReasonChurnIntention)
+	}
+
+	if sentiment >= 0.6 {
+		reasons = append(reasons, ChurnReasonNegativeSentiment)
+	}
+
+	if worsening > 0 {
+		reasons = append(reasons, ChurnReasonWorseningSentiment)
+	}
+
+	if customer.UnresolvedIssues > 0 {
+		reasons = append(reasons, ChurnReasonUnresolvedIssues)
+	}
+
+	return min(100, max(0, score)), reasons
+}
@@ -0,0 +1,87 @@
+package churn_test
+
+import (
+	"testing"
+	"time"
+
+	"backend/pkg/churn"
+	"backend/pkg/review"
+
+	"github.com/neoxelox/kit/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type ChurnTestSuite struct {
+	suite.Suite
+}
+
+func TestChurnSuite(t *testing.T) {
+	suite.Run(t, new(ChurnTestSuite))
+}
+
+func (self *ChurnTestSuite) TestComputeChurnRiskScoresUnhappyCustomersHigh() {
+	// Given: A customer that was happy and now wants to churn with issues still unresolved
+	now := time.Now()
+	customer := churn.ChurnCustomer{
+		Signals: []churn.ChurnSignal{
+			{Intention: review.ReviewIntentionChurn, Sentiment: review.ReviewSentimentNegative, PostedAt: now},
+			{Intention: review.ReviewIntentionRetain, Sentiment: review.ReviewSentimentPositive,
+				PostedAt: now.Add(-60 * 24 * time.Hour)},
+		},
+		UnresolvedIssues: 2,
+	}
+
+	// When: The churn risk is computed
+	score, reasons := churn.ComputeChurnRisk(customer, now)
+
+	// Then: The customer is at risk for all the reasons
+	self.GreaterOrEqual(score, churn.CHURN_AT_RISK_MIN_SCORE)
+	self.Equal([]string{churn.ChurnReasonChurnIntention, churn.ChurnReasonNegativeSentiment,
+		churn.ChurnReasonWorseningSentiment, churn.ChurnReasonUnresolvedIssues}, reasons)
+}
+
+func (self *ChurnTestSuite) TestComputeChurnRiskScoresHappyCustomersLow() {
+	// Given: A customer that recommends the product
+	now := time.Now()
+	customer := churn.ChurnCustomer{
+		Signals: []churn.ChurnSignal{
+			{Intention: review.ReviewIntentionRetainAndRecommend, Sentiment: review.ReviewSentimentPositive,
+				PostedAt: now},
+		},
+	}
+
+	// When: The churn risk is computed
+	score, reasons := churn.ComputeChurnRisk(customer, now)
+
+	// Then: The customer is not at risk
+	self.Equal(0, score)
+	self.Empty(reasons)
+}
+
+func (self *ChurnTestSuite) TestComputeChurnRiskDecaysWithSilence() {
+	// Given: The same unhappy customer, heard from recently and a long time ago
+	now := time.Now()
+	signal := churn.ChurnSignal{Intention: review.ReviewIntentionChurn, Sentiment: review.ReviewSentimentNegative}
+	recent := signal
+	recent.PostedAt = now.Add(-24 * time.Hour)
+	old := signal
+	old.PostedAt = now.Add(-150 * 24 * time.Hour)
+
+	// When: The churn risks are computed
+	recentScore, _ := churn.ComputeChurnRisk(churn.ChurnCustomer{Signals: []churn.ChurnSignal{recent}}, now)
+	oldScore, _ := churn.ComputeChurnRisk(churn.ChurnCustomer{Signals: []churn.ChurnSignal{old}}, now)
+
+	// Then: The silent customer scores lower
+	self.Greater(recentScore, oldScore)
+}
+
+func (self *ChurnTestSuite) TestNewChurnCustomerKey() {
+	// Given: Customers identified by email, link or nothing
+	// When: Their keys are computed
+	// Then: The most reliable identity is used, and customers without one are not identified
+	self.Equal("EMAIL:jane@acme.com",
+		churn.NewChurnCustomerKey("TRUSTPILOT", util.Pointer(" Jane@Acme.com "), util.Pointer("link")))
+	self.Equal("TRUSTPILOT:link", churn.NewChurnCustomerKey("TRUSTPILOT", nil, util.Pointer("link")))
+	self.Equal("", churn.NewChurnCustomerKey("TRUSTPILOT", util.Pointer(""), util.Pointer(" ")))
+	self.Equal("", churn.NewChurnCustomerKey("TRUSTPILOT", nil, nil))
+}
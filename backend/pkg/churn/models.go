@@ -0,0 +1,58 @@
+package churn
+
+import (
+	"time"
+)
+
+const (
+	CHURN_RISK_MODEL_TABLE = "\"churn_risk\""
+)
+
+type ChurnRiskModel struct {
+	ID               string    `db:"id"`
+	ProductID        string    `db:"product_id"`
+	CustomerKey      string    `db:"customer_key"`
+	CustomerEmail    *string   `db:"customer_email"`
+	CustomerName     string    `db:"customer_name"`
+	Source           string    `db:"source"`
+	Score            int       `db:"score"`
+	Reasons          []string  `db:"reasons"`
+	Feedbacks        int       `db:"feedbacks"`
+	UnresolvedIssues int       `db:"unresolved_issues"`
+	LastFeedbackAt   time.Time `db:"last_feedback_at"`
+	ComputedAt       time.Time `db:"computed_at"`
+}
+
+func NewChurnRiskModel(risk ChurnRisk) *ChurnRiskModel {
+	return &ChurnRiskModel{
+		ID:               risk.ID,
+		ProductID:        risk.ProductID,
+		CustomerKey:      risk.CustomerKey,
+		CustomerEmail:    risk.CustomerEmail,
+		CustomerName:     risk.CustomerName,
+		Source:           risk.Source,
+		Score:            risk.Score,
+		Reasons:          risk.Reasons,
+		Feedbacks:        risk.Feedbacks,
+		UnresolvedIssues: risk.UnresolvedIssues,
+		LastFeedbackAt:   risk.LastFeedbackAt,
+		ComputedAt:       risk.ComputedAt,
+	}
+}
+
+func (self *ChurnRiskModel) ToEntity() *ChurnRisk {
+	return &ChurnRisk{
+		ID:               self.ID,
+		ProductID:        self.ProductID,
+		CustomerKey:      self.CustomerKey,
+		CustomerEmail:    self.CustomerEmail,
+		CustomerName:     self.CustomerName,
+		Source:           self.Source,
+		Score:            self.Score,
+		Reasons:          self.Reasons,
+		Feedbacks:        self.Feedbacks,
+		UnresolvedIssues: self.UnresolvedIssues,
+		LastFeedbackAt:   self.LastFeedbackAt,
+		ComputedAt:       self.ComputedAt,
+	}
+}
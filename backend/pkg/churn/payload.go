@@ -0,0 +1,35 @@
+package churn
+
+import (
+	"time"
+)
+
+type ChurnRiskPayload struct {
+	ID               string    `json:"id"`
+	ProductID        string    `json:"product_id"`
+	CustomerEmail    *string   `json:"customer_email"`
+	CustomerName     string    `json:"customer_name"`
+	Source           string    `json:"source"`
+	Score            int       `json:"score"`
+	Reasons          []string  `json:"reasons"`
+	Feedbacks        int       `json:"feedbacks"`
+	UnresolvedIssues int       `json:"unresolved_issues"`
+	LastFeedbackAt   time.Time `json:"last_feedback_at"`
+	ComputedAt       time.Time `json:"computed_at"`
+}
+
+func NewChurnRiskPayload(risk ChurnRisk) *ChurnRiskPayload {
+	return &ChurnRiskPayload{
+		ID:               risk.ID,
+		ProductID:        risk.ProductID,
+		CustomerEmail:    risk.CustomerEmail,
+		CustomerName:     risk.CustomerName,
+		Source:           risk.Source,
+		Score:            risk.Score,
+		Reasons:          risk.Reasons,
+		Feedbacks:        risk.Feedbacks,
+		UnresolvedIssues: risk.UnresolvedIssues,
+		LastFeedbackAt:   risk.LastFeedbackAt,
+		ComputedAt:       risk.ComputedAt,
+	}
+}
@@ -0,0 +1,306 @@
+package churn
+
+import (
+	"context"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/review"
+	"backend/pkg/util"
+)
+
+const (
+	// Churn risks inserted at once, bounded by the maximum number of parameters of a statement
+	CHURN_RISK_REPOSITORY_BATCH_SIZE = 1000
+)
+
+const (
+	// The customer key of a feedback, as NewChurnCustomerKey computes it, or NULL if the customer is not identified
+	_CHURN_CUSTOMER_KEY = `CASE
+		WHEN NULLIF(trim("feedback".customer ->> 'Email'), '') IS NOT NULL
+			THEN 'EMAIL:' || lower(trim("feedback".customer ->> 'Email'))
+		WHEN NULLIF(trim("feedback".customer ->> 'Link'), '') IS NOT NULL
+			THEN "feedback".source || ':' || trim("feedback".customer ->> 'Link')
+		ELSE NULL
+	END`
+)
+
+type ChurnRiskRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewChurnRiskRepository(observer *kit.Observer, database *kit.Database,
+	config config.Config) *ChurnRiskRepository {
+	return &ChurnRiskRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+// ListCustomers returns a page of the identified customers of a product, ordered by key after the given one, with
+// the signals of their feedbacks reviewed since the given time, along with the issues they reported that are still
+// unresolved.
+func (self *ChurnRiskRepository) ListCustomers(ctx context.Context,
+	productID string, since time.Time, after *string, limit int) ([]ChurnCustomer, error) {
+	var keys []string
+
+	stmt := sqlf.
+		Select(_CHURN_CUSTOMER_KEY+" AS key").To(&keys).
+		From(review.REVIEW_MODEL_TABLE).
+		Join(feedback.FEEDBACK_MODEL_TABLE,
+			feedback.FEEDBACK_MODEL_TABLE+".id = "+review.REVIEW_MODEL_TABLE+".feedback_id").
+		Where(review.REVIEW_MODEL_TABLE+".product_id = ?", productID).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at >= ?", since).
+		Where(_CHURN_CUSTOMER_KEY + " IS NOT NULL")
+
+	if after != nil {
+		stmt.Where(_CHURN_CUSTOMER_KEY+" > ?", *after)
+	}
+
+	stmt.
+		GroupBy("key").
+		OrderBy("key ASC").
+		Limit(limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []ChurnCustomer{}, nil
+		}
+
+		return nil, err
+	}
+
+	var ss []struct {
+		FeedbackID string    `db:"feedback_id"`
+		Source     string    `db:"source"`
+		Email      *string   `db:"email"`
+		Name       *string   `db:"name"`
+		Link       *string   `db:"link"`
+		Intention  string    `db:"intention"`
+		Sentiment  string    `db:"sentiment"`
+		PostedAt   time.Time `db:"posted_at"`
+	}
+
+	stmt = sqlf.
+		Select(feedback.FEEDBACK_MODEL_TABLE+".id AS feedback_id, "+
+			feedback.FEEDBACK_MODEL_TABLE+".source, "+
+			feedback.FEEDBACK_MODEL_TABLE+".customer->>'Email' AS email, "+
+			feedback.FEEDBACK_MODEL_TABLE+".customer->>'Name' AS name, "+
+			feedback.FEEDBACK_MODEL_TABLE+".customer->>'Link' AS link, "+
+			review.REVIEW_MODEL_TABLE+".intention, "+
+			review.REVIEW_MODEL_TABLE+".sentiment, "+
+			feedback.FEEDBACK_MODEL_TABLE+".posted_at").To(&ss).
+		From(review.REVIEW_MODEL_TABLE).
+		Join(feedback.FEEDBACK_MODEL_TABLE,
+			feedback.FEEDBACK_MODEL_TABLE+".id = "+review.REVIEW_MODEL_TABLE+".feedback_id").
+		Where(review.REVIEW_MODEL_TABLE+".product_id = ?", productID).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at >= ?", since).
+		Where(_CHURN_CUSTOMER_KEY+" = ANY(?::TEXT[])", keys).
+		OrderBy(feedback.FEEDBACK_MODEL_TABLE+".posted_at DESC", feedback.FEEDBACK_MODEL_TABLE+".id DESC")
+
+	err = self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []ChurnCustomer{}, nil
+		}
+
+		return nil, err
+	}
+
+	feedbackIDs := make([]string, 0, len(ss))
+	for _, s := range ss {
+		feedbackIDs = append(feedbackIDs, s.FeedbackID)
+	}
+
+	var is []struct {
+		FeedbackID string `db:"feedback_id"`
+		IssueID    string `db:"issue_id"`
+	}
+
+	stmt = sqlf.
+		Select(issue.ISSUE_FEEDBACK_MODEL_TABLE+".feedback_id, "+
+			issue.ISSUE_FEEDBACK_MODEL_TABLE+".issue_id").To(&is).
+		From(issue.ISSUE_FEEDBACK_MODEL_TABLE).
+		Join(issue.ISSUE_MODEL_TABLE,
+			issue.ISSUE_MODEL_TABLE+".id = "+issue.ISSUE_FEEDBACK_MODEL_TABLE+".issue_id").
+		Where(issue.ISSUE_MODEL_TABLE+".product_id = ?", productID).
+		Where(issue.ISSUE_MODEL_TABLE+".state NOT IN (?, ?)", issue.IssueStateResolved, issue.IssueStateWontFix).
+		Where(issue.ISSUE_FEEDBACK_MODEL_TABLE+".feedback_id = ANY(?::TEXT[])", feedbackIDs)
+
+	err = self.database.Query(ctx, stmt)
+	if err != nil && !kit.ErrDatabaseNoRows.Is(err) {
+		return nil, err
+	}
+
+	issuesByFeedback := make(map[string][]string)
+	for _, i := range is {
+		issuesByFeedback[i.FeedbackID] = append(issuesByFeedback[i.FeedbackID], i.IssueID)
+	}
+
+	// The customers keep the order of their keys, so the last one is where the next page starts
+	customers := make([]ChurnCustomer, 0, len(keys))
+	indexes := make(map[string]int, len(keys))
+	issues := make(map[string]map[string]bool, len(keys))
+	for _, key := range keys {
+		indexes[key] = len(customers)
+		issues[key] = make(map[string]bool)
+		customers = append(customers, ChurnCustomer{
+			Key:     key,
+			Signals: []ChurnSignal{},
+		})
+	}
+
+	// The feedbacks are the most recent first, so the customers keep their latest identity details
+	for _, s := range ss {
+		name := ""
+		if s.Name != nil {
+			name = *s.Name
+		}
+
+		key := NewChurnCustomerKey(s.Source, s.Email, s.Link)
+		index, ok := indexes[key]
+		if !ok {
+			continue
+		}
+
+		if len(customers[index].Signals) == 0 {
+			customers[index].Email = s.Email
+			customers[index].Name = name
+			customers[index].Source = s.Source
+		}
+
+		customers[index].Signals = append(customers[index].Signals, ChurnSignal{
+			FeedbackID: s.FeedbackID,
+			Intention:  s.Intention,
+			Sentiment:  s.Sentiment,
+			PostedAt:   s.PostedAt,
+		})
+
+		for _, issueID := range issuesByFeedback[s.FeedbackID] {
+			issues[key][issueID] = true
+		}
+	}
+
+	for index := range customers {
+		customers[index].UnresolvedIssues = len(issues[customers[index].Key])
+	}
+
+	return customers, nil
+}
+
+// ReplaceByProductID replaces all the churn risks of a product with the ones of the latest scoring run.
+func (self *ChurnRiskRepository) ReplaceByProductID(ctx context.Context, productID string, risks []ChurnRisk) error {
+	return self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		stmt := sqlf.
+			DeleteFrom(CHURN_RISK_MODEL_TABLE).
+			Where("product_id = ?", productID)
+
+		_, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+
+		for start := 0; start < len(risks); start += CHURN_RISK_REPOSITORY_BATCH_SIZE {
+			batch := risks[start:min(start+CHURN_RISK_REPOSITORY_BATCH_SIZE, len(risks))]
+
+			stmt := sqlf.
+				InsertInto(CHURN_RISK_MODEL_TABLE)
+
+			for _, risk := range batch {
+				r := NewChurnRiskModel(risk)
+
+				stmt.
+					NewRow().
+					Set("id", r.ID).
+					Set("product_id", r.ProductID).
+					Set("customer_key", r.CustomerKey).
+					Set("customer_email", r.CustomerEmail).
+					Set("customer_name", r.CustomerName).
+					Set("source", r.Source).
+					Set("score", r.Score).
+					Set("reasons", r.Reasons).
+					Set("feedbacks", r.Feedbacks).
+					Set("unresolved_issues", r.UnresolvedIssues).
+					Set("last_feedback_at", r.LastFeedbackAt).
+					Set("computed_at", r.ComputedAt)
+			}
+
+			affected, err := self.database.Exec(ctx, stmt)
+			if err != nil {
+				return err
+			}
+
+			if affected != len(batch) {
+				return kit.ErrDatabaseUnexpectedEffect.Raise(affected, len(batch))
+			}
+		}
+
+		return nil
+	})
+}
+
+// ListByProductID returns the churn risks of a product with at least the given score, the riskiest first.
+func (self *ChurnRiskRepository) ListByProductID(ctx context.Context, productID string, minScore int,
+	pagination util.Pagination[int]) (*util.Page[ChurnRisk, int], error) {
+	var rs []ChurnRiskModel
+
+	stmt := sqlf.
+		Select("*").To(&rs).
+		From(CHURN_RISK_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		Where("score >= ?", minScore)
+
+	if pagination.From != nil {
+		stmt.Where("(score, id) < (?, ?)", pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy("score DESC", "id DESC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[ChurnRisk, int]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]ChurnRisk, 0, len(rs))
+	for _, r := range rs {
+		items = append(items, *r.ToEntity())
+	}
+
+	var cursor *util.Cursor[int]
+	if len(items) == pagination.Limit {
+		cursor = &util.Cursor[int]{
+			Value: items[pagination.Limit-1].Score,
+			ID:    items[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[ChurnRisk, int]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
+
+// NewChurnCustomerKeysQuery selects the customer keys, as NewChurnCustomerKey computes them, of the given feedbacks
+// subquery, so the churn risks can be sliced by it as a subquery.
+func NewChurnCustomerKeysQuery(feedbacks *sqlf.Stmt) *sqlf.Stmt {
+	return sqlf.
+		Select(_CHURN_CUSTOMER_KEY).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Where("").
+		SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", feedbacks)
+}
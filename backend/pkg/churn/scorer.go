@@ -0,0 +1,165 @@
+package churn
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+	"github.com/rs/xid"
+
+	"backend/pkg/config"
+	"backend/pkg/outbox"
+	"backend/pkg/product"
+)
+
+const (
+	ScorerScore    = "churn:score"
+	ScorerSchedule = "churn:schedule-score"
+)
+
+var (
+	ErrScorerGeneric = errors.New("scorer failed")
+)
+
+type Scorer struct {
+	config              config.Config
+	observer            *kit.Observer
+	productRepository   *product.ProductRepository
+	churnRiskRepository *ChurnRiskRepository
+	enqueuer            *outbox.OutboxEnqueuer
+}
+
+func NewScorer(observer *kit.Observer, productRepository *product.ProductRepository,
+	churnRiskRepository *ChurnRiskRepository, enqueuer *outbox.OutboxEnqueuer, config config.Config) *Scorer {
+	return &Scorer{
+		config:              config,
+		observer:            observer,
+		productRepository:   productRepository,
+		churnRiskRepository: churnRiskRepository,
+		enqueuer:            enqueuer,
+	}
+}
+
+// Run scores the churn risk of every identified customer of a product with feedbacks reviewed within the scoring
+// window, replacing the previous scores. Customers silent for longer are no longer scored.
+func (self *Scorer) Run(ctx context.Context, _product product.Product) ([]ChurnRisk, error) {
+	now := time.Now()
+
+	risks := []ChurnRisk{}
+	atRisk := 0
+	var after *string
+	for {
+		customers, err := self.churnRiskRepository.ListCustomers(ctx, _product.ID, now.Add(-CHURN_SCORING_WINDOW),
+			after, CHURN_SCORING_CUSTOMERS_PER_PAGE)
+		if err != nil {
+			return nil, ErrScorerGeneric.Raise().Cause(err)
+		}
+
+		for _, customer := range customers {
+			// The feedbacks of the customer could have been deleted between listing the customer and its signals
+			if len(customer.Signals) == 0 {
+				continue
+			}
+
+			score, reasons := ComputeChurnRisk(customer, now)
+
+			lastFeedbackAt := customer.Signals[0].PostedAt
+			for _, signal := range customer.Signals {
+				if signal.PostedAt.After(lastFeedbackAt) {
+					lastFeedbackAt = signal.PostedAt
+				}
+			}
+
+			risk := NewChurnRisk()
+			risk.ID = xid.New().String()
+			risk.ProductID = _product.ID
+			risk.CustomerKey = customer.Key
+			risk.CustomerEmail = customer.Email
+			risk.CustomerName = customer.Name
+			risk.Source = customer.Source
+			risk.Score = score
+			risk.Reasons = reasons
+			risk.Feedbacks = len(customer.Signals)
+			risk.UnresolvedIssues = customer.UnresolvedIssues
+			risk.LastFeedbackAt = lastFeedbackAt
+			risk.ComputedAt = now
+
+			if risk.Score >= CHURN_AT_RISK_MIN_SCORE {
+				atRisk++
+			}
+
+			risks = append(risks, *risk)
+		}
+
+		if len(customers) < CHURN_SCORING_CUSTOMERS_PER_PAGE {
+			break
+		}
+
+		after = &customers[len(customers)-1].Key
+	}
+
+	err := self.churnRiskRepository.ReplaceByProductID(ctx, _product.ID, risks)
+	if err != nil {
+		return nil, ErrScorerGeneric.Raise().Cause(err)
+	}
+
+	self.observer.Infof(ctx, "Scored the churn risk of %d customers, %d at risk, of product %s",
+		len(risks), atRisk, _product.ID)
+
+	return risks, nil
+}
+
+type ScorerScoreParams struct {
+	ProductID string
+}
+
+func (self *Scorer) Score(ctx context.Context, task *asynq.Task) error {
+	params := ScorerScoreParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	product, err := self.productRepository.GetByID(ctx, params.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	if product.DeletedAt != nil {
+		return nil
+	}
+
+	_, err = self.Run(ctx, *product)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *Scorer) Schedule(ctx context.Context, _ *asynq.Task) error {
+	ids, err := self.productRepository.ListIDsByNotDeleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := self.enqueuer.Enqueue(ctx, ScorerScore, ScorerScoreParams{
+			ProductID: id,
+		}, asynq.MaxRetry(2), asynq.Unique(24*time.Hour))
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"backend/pkg/engine"
 	"backend/pkg/feedback"
 	"backend/pkg/organization"
+	"backend/pkg/outbox"
 	"backend/pkg/product"
 	"backend/pkg/translator"
 	"backend/pkg/util"
@@ -52,21 +53,23 @@ type AmazonCollectorJobdata struct {
 type AmazonCollector struct {
 	config                 config.Config
 	observer               *kit.Observer
+	database               *kit.Database
 	collectorRepository    *CollectorRepository
 	productRepository      *product.ProductRepository
 	organizationRepository organization.OrganizationRepository
 	feedbackRepository     *feedback.FeedbackRepository
-	enqueuer               *kit.Enqueuer
+	enqueuer               *outbox.OutboxEnqueuer
 	dataForSEOService      *dataforseo.DataForSEOService
 }
 
-func NewAmazonCollector(observer *kit.Observer, collectorRepository *CollectorRepository,
+func NewAmazonCollector(observer *kit.Observer, database *kit.Database, collectorRepository *CollectorRepository,
 	productRepository *product.ProductRepository, organizationRepository organization.OrganizationRepository,
-	feedbackRepository *feedback.FeedbackRepository, enqueuer *kit.Enqueuer, dataForSEOService *dataforseo.DataForSEOService,
+	feedbackRepository *feedback.FeedbackRepository, enqueuer *outbox.OutboxEnqueuer, dataForSEOService *dataforseo.DataForSEOService,
 	config config.Config) *AmazonCollector {
 	return &AmazonCollector{
 		config:                 config,
 		observer:               observer,
+		database:               database,
 		collectorRepository:    collectorRepository,
 		productRepository:      productRepository,
 		organizationRepository: organizationRepository,
@@ -149,19 +152,31 @@ func (self *AmazonCollector) getCollectorProductAndOrganization(ctx context.Cont
 }
 
 func (self *AmazonCollector) saveAndEnqueue(ctx context.Context, feedbacks []feedback.Feedback) (int, error) {
-	newFeedbacks, err := self.feedbackRepository.BulkCreate(ctx, feedbacks)
-	if err != nil {
-		return 0, err
-	}
+	var newFeedbacks int
+
+	// Enqueue the translations through the outbox so they are not lost if the feedbacks are saved
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		var err error
 
-	for _, feedback := range feedbacks {
-		err := self.enqueuer.Enqueue(ctx, translator.FeedbackTranslatorTranslate,
-			translator.FeedbackTranslatorTranslateParams{
-				FeedbackID: feedback.ID,
-			}, asynq.MaxRetry(2), asynq.Unique(12*time.Hour))
+		newFeedbacks, err = self.feedbackRepository.BulkCreate(ctx, feedbacks)
 		if err != nil {
-			self.observer.Error(ctx, err)
+			return err
+		}
+
+		for _, feedback := range feedbacks {
+			err := self.enqueuer.Enqueue(ctx, translator.FeedbackTranslatorTranslate,
+				translator.FeedbackTranslatorTranslateParams{
+					FeedbackID: feedback.ID,
+				}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.Unique(12*time.Hour))
+			if err != nil {
+				return err
+			}
 		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return newFeedbacks, nil
@@ -13,6 +13,7 @@ import (
 	"backend/pkg/engine"
 	"backend/pkg/feedback"
 	"backend/pkg/organization"
+	"backend/pkg/outbox"
 	"backend/pkg/product"
 	"backend/pkg/translator"
 	"backend/pkg/util"
@@ -51,21 +52,23 @@ type AppStoreCollectorJobdata struct {
 type AppStoreCollector struct {
 	config                 config.Config
 	observer               *kit.Observer
+	database               *kit.Database
 	collectorRepository    *CollectorRepository
 	productRepository      *product.ProductRepository
 	organizationRepository organization.OrganizationRepository
 	feedbackRepository     *feedback.FeedbackRepository
-	enqueuer               *kit.Enqueuer
+	enqueuer               *outbox.OutboxEnqueuer
 	dataForSEOService      *dataforseo.DataForSEOService
 }
 
-func NewAppStoreCollector(observer *kit.Observer, collectorRepository *CollectorRepository,
+func NewAppStoreCollector(observer *kit.Observer, database *kit.Database, collectorRepository *CollectorRepository,
 	productRepository *product.ProductRepository, organizationRepository organization.OrganizationRepository,
-	feedbackRepository *feedback.FeedbackRepository, enqueuer *kit.Enqueuer, dataForSEOService *dataforseo.DataForSEOService,
+	feedbackRepository *feedback.FeedbackRepository, enqueuer *outbox.OutboxEnqueuer, dataForSEOService *dataforseo.DataForSEOService,
 	config config.Config) *AppStoreCollector {
 	return &AppStoreCollector{
 		config:                 config,
 		observer:               observer,
+		database:               database,
 		collectorRepository:    collectorRepository,
 		productRepository:      productRepository,
 		organizationRepository: organizationRepository,
@@ -148,19 +151,31 @@ func (self *AppStoreCollector) getCollectorProductAndOrganization(ctx context.Co
 }
 
 func (self *AppStoreCollector) saveAndEnqueue(ctx context.Context, feedbacks []feedback.Feedback) (int, error) {
-	newFeedbacks, err := self.feedbackRepository.BulkCreate(ctx, feedbacks)
-	if err != nil {
-		return 0, err
-	}
+	var newFeedbacks int
+
+	// Enqueue the translations through the outbox so they are not lost if the feedbacks are saved
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		var err error
 
-	for _, feedback := range feedbacks {
-		err := self.enqueuer.Enqueue(ctx, translator.FeedbackTranslatorTranslate,
-			translator.FeedbackTranslatorTranslateParams{
-				FeedbackID: feedback.ID,
-			}, asynq.MaxRetry(2), asynq.Unique(12*time.Hour))
+		newFeedbacks, err = self.feedbackRepository.BulkCreate(ctx, feedbacks)
 		if err != nil {
-			self.observer.Error(ctx, err)
+			return err
+		}
+
+		for _, feedback := range feedbacks {
+			err := self.enqueuer.Enqueue(ctx, translator.FeedbackTranslatorTranslate,
+				translator.FeedbackTranslatorTranslateParams{
+					FeedbackID: feedback.ID,
+				}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.Unique(12*time.Hour))
+			if err != nil {
+				return err
+			}
 		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return newFeedbacks, nil
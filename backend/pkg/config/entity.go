@@ -41,6 +41,8 @@ type ConfigDatabase struct {
 	DialTimeout           time.Duration
 	StatementTimeout      time.Duration
 	DefaultIsolationLevel kit.IsolationLevel
+	VectorEfSearch        int
+	VectorIterativeScan   string
 }
 
 type ConfigCache struct {
@@ -83,6 +85,10 @@ type ConfigWorker struct {
 type ConfigRunner struct {
 }
 
+type ConfigPipeline struct {
+	Streaming bool
+}
+
 type ConfigSentry struct {
 	DSN string
 }
@@ -134,6 +140,7 @@ type Config struct {
 	Server     ConfigServer
 	Worker     ConfigWorker
 	Runner     ConfigRunner
+	Pipeline   ConfigPipeline
 	Sentry     ConfigSentry
 	Gilk       ConfigGilk
 	Engine     ConfigEngine
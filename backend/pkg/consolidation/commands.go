@@ -0,0 +1,149 @@
+package consolidation
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/mkideal/cli"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+const (
+	ConsolidationCommandsConsolidateProduct = "consolidate-product"
+	ConsolidationCommandsListProduct        = "consolidation-list"
+	ConsolidationCommandsUndo               = "consolidation-undo"
+)
+
+type ConsolidationCommands struct {
+	config                  config.Config
+	observer                *kit.Observer
+	consolidationRepository *ConsolidationRepository
+	productRepository       *product.ProductRepository
+	consolidator            *Consolidator
+}
+
+func NewConsolidationCommands(observer *kit.Observer, consolidationRepository *ConsolidationRepository,
+	productRepository *product.ProductRepository, consolidator *Consolidator,
+	config config.Config) *ConsolidationCommands {
+	return &ConsolidationCommands{
+		config:                  config,
+		observer:                observer,
+		consolidationRepository: consolidationRepository,
+		productRepository:       productRepository,
+		consolidator:            consolidator,
+	}
+}
+
+type ConsolidationCommandsConsolidateProductArgs struct {
+	cli.Helper
+	Product string `cli:"*product" usage:"id of the product to merge the duplicates of"`
+	Type    string `cli:"type" dft:"issue" usage:"duplicates to merge: issue or suggestion"`
+}
+
+func (self *ConsolidationCommands) ConsolidateProduct(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*ConsolidationCommandsConsolidateProductArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	_type := strings.ToUpper(args.Type)
+	if !IsConsolidationType(_type) {
+		return kit.ErrRunnerGeneric.Raise().With("invalid type %s", args.Type)
+	}
+
+	product, err := self.productRepository.GetByID(ctx, args.Product)
+	if err != nil {
+		return err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return kit.ErrRunnerGeneric.Raise().With("product %s not found", args.Product)
+	}
+
+	_, err = self.consolidator.Run(ctx, *product, _type)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type ConsolidationCommandsListProductArgs struct {
+	cli.Helper
+	Product string `cli:"*product" usage:"id of the product to list the merges of"`
+}
+
+func (self *ConsolidationCommands) ListProduct(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*ConsolidationCommandsListProductArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	pagination := util.Pagination[time.Time]{
+		Limit: 100,
+		From:  nil,
+	}
+
+	count := 0
+	for {
+		page, err := self.consolidationRepository.ListByProductID(ctx, args.Product, pagination)
+		if err != nil {
+			return err
+		}
+
+		for _, consolidation := range page.Items {
+			count++
+
+			state := "active"
+			if consolidation.UndoneAt != nil {
+				state = "undone"
+			}
+
+			self.observer.Infof(ctx, "%s %s merged %s into %s with %d feedbacks at %s",
+				consolidation.String(), state, consolidation.MergedID, consolidation.KeptID,
+				len(consolidation.FeedbackIDs), consolidation.CreatedAt.Format(time.RFC3339))
+		}
+
+		if page.Next == nil {
+			break
+		}
+		pagination.From = page.Next
+	}
+
+	self.observer.Infof(ctx, "Found %d consolidations of product %s", count, args.Product)
+
+	return nil
+}
+
+type ConsolidationCommandsUndoArgs struct {
+	cli.Helper
+	ID string `cli:"*id" usage:"id of the consolidation to undo"`
+}
+
+func (self *ConsolidationCommands) Undo(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*ConsolidationCommandsUndoArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	consolidation, err := self.consolidationRepository.GetByID(ctx, args.ID)
+	if err != nil {
+		return err
+	}
+
+	if consolidation == nil {
+		return kit.ErrRunnerGeneric.Raise().With("consolidation %s not found", args.ID)
+	}
+
+	_, err = self.consolidator.Undo(ctx, *consolidation)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
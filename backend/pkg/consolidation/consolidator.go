@@ -0,0 +1,196 @@
+package consolidation
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/engine"
+	"backend/pkg/issue"
+	"backend/pkg/outbox"
+	"backend/pkg/product"
+	"backend/pkg/suggestion"
+)
+
+const (
+	ConsolidatorConsolidate = "consolidation:consolidate"
+	ConsolidatorSchedule    = "consolidation:schedule-consolidate"
+)
+
+var (
+	ErrConsolidatorGeneric       = errors.New("consolidator failed")
+	ErrConsolidatorInvalidType   = errors.New("invalid consolidation type")
+	ErrConsolidatorAlreadyUndone = errors.New("consolidation already undone")
+	ErrConsolidatorNotUndoable   = errors.New("consolidation cannot be undone")
+	ErrConsolidatorInvalidMerge  = errors.New("invalid merge")
+	ErrConsolidatorInvalidSplit  = errors.New("invalid split")
+)
+
+type Consolidator struct {
+	config                  config.Config
+	observer                *kit.Observer
+	database                *kit.Database
+	consolidationRepository *ConsolidationRepository
+	productRepository       *product.ProductRepository
+	issueRepository         *issue.IssueRepository
+	suggestionRepository    *suggestion.SuggestionRepository
+	enqueuer                *outbox.OutboxEnqueuer
+	engineService           *engine.EngineService
+	engineBreaker           *engine.EngineBreaker
+}
+
+func NewConsolidator(observer *kit.Observer, database *kit.Database,
+	consolidationRepository *ConsolidationRepository, productRepository *product.ProductRepository,
+	issueRepository *issue.IssueRepository, suggestionRepository *suggestion.SuggestionRepository,
+	enqueuer *outbox.OutboxEnqueuer, engineService *engine.EngineService, engineBreaker *engine.EngineBreaker,
+	config config.Config) *Consolidator {
+	return &Consolidator{
+		config:                  config,
+		observer:                observer,
+		database:                database,
+		consolidationRepository: consolidationRepository,
+		productRepository:       productRepository,
+		issueRepository:         issueRepository,
+		suggestionRepository:    suggestionRepository,
+		enqueuer:                enqueuer,
+		engineService:           engineService,
+		engineBreaker:           engineBreaker,
+	}
+}
+
+// Run merges the duplicated issues or suggestions of a product. Each pair of close embeddings is confirmed by
+// the engine before merging the newest one into the oldest one, and every merge is recorded so it can be undone.
+func (self *Consolidator) Run(ctx context.Context, product product.Product, _type string) (int, error) {
+	switch _type {
+	case ConsolidationTypeIssue:
+		return self.consolidateIssues(ctx, product)
+	case ConsolidationTypeSuggestion:
+		return self.consolidateSuggestions(ctx, product)
+	default:
+		return 0, ErrConsolidatorInvalidType.Raise().With("consolidation type %s is not supported", _type)
+	}
+}
+
+// Undo splits the merged issue or suggestion back out of the kept one. The counts it contributed are subtracted,
+// its feedbacks are linked back to it and, unless the kept one has been aggregated since, the kept one gets its
+// previous title, description and embedding back.
+func (self *Consolidator) Undo(ctx context.Context, consolidation Consolidation) (*Consolidation, error) {
+	if consolidation.UndoneAt != nil {
+		return nil, ErrConsolidatorAlreadyUndone.Raise().With("consolidation %s was undone", consolidation.ID)
+	}
+
+	var err error
+	switch consolidation.Type {
+	case ConsolidationTypeIssue:
+		err = self.undoIssue(ctx, consolidation)
+	case ConsolidationTypeSuggestion:
+		err = self.undoSuggestion(ctx, consolidation)
+	default:
+		return nil, ErrConsolidatorInvalidType.Raise().With("consolidation type %s is not supported", consolidation.Type)
+	}
+	if err != nil {
+		if ErrConsolidatorAlreadyUndone.In(err) || ErrConsolidatorNotUndoable.In(err) {
+			return nil, err
+		}
+
+		return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+	}
+
+	now := time.Now()
+	consolidation.UndoneAt = &now
+
+	self.observer.Infof(ctx, "Undid consolidation %s of %s into %s",
+		consolidation.ID, consolidation.MergedID, consolidation.KeptID)
+
+	return &consolidation, nil
+}
+
+type ConsolidatorConsolidateParams struct {
+	ProductID string
+	Type      string
+}
+
+func (self *Consolidator) Consolidate(ctx context.Context, task *asynq.Task) error {
+	params := ConsolidatorConsolidateParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	allowed, delay := self.engineBreaker.Allow(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING,
+		engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
+	if !allowed {
+		return self.enqueuer.Enqueue(ctx, ConsolidatorConsolidate, params,
+			asynq.MaxRetry(2), asynq.ProcessIn(delay))
+	}
+
+	product, err := self.productRepository.GetByID(ctx, params.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	if product.DeletedAt != nil {
+		return nil
+	}
+
+	_, err = self.Run(ctx, *product, params.Type)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *Consolidator) Schedule(ctx context.Context, _ *asynq.Task) error {
+	ids, err := self.productRepository.ListIDsByNotDeleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		for _, _type := range []string{ConsolidationTypeIssue, ConsolidationTypeSuggestion} {
+			err := self.enqueuer.Enqueue(ctx, ConsolidatorConsolidate, ConsolidatorConsolidateParams{
+				ProductID: id,
+				Type:      _type,
+			}, asynq.MaxRetry(2), asynq.Unique(24*time.Hour))
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// computeEmbedding embeds the description of a rewritten issue or suggestion, opening the engine breaker if the
+// engine timed out.
+func (self *Consolidator) computeEmbedding(ctx context.Context, product product.Product,
+	text string) (*engine.EngineServiceComputeEmbeddingResult, error) {
+	ceResult, err := self.engineService.ComputeEmbedding(ctx, engine.EngineServiceComputeEmbeddingParams{
+		Text:  text,
+		Model: product.EmbeddingModel,
+	})
+	if err != nil {
+		if engine.ErrEngineServiceTimedOut.Is(err) {
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+
+		return nil, err
+	}
+
+	return ceResult, nil
+}
@@ -0,0 +1,197 @@
+package consolidation
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/issue"
+	"backend/pkg/product"
+	"backend/pkg/suggestion"
+	"backend/pkg/util"
+)
+
+type ConsolidationEndpoints struct {
+	config       config.Config
+	observer     *kit.Observer
+	consolidator *Consolidator
+	cache        *kit.Cache
+}
+
+func NewConsolidationEndpoints(observer *kit.Observer, consolidator *Consolidator, cache *kit.Cache,
+	config config.Config) *ConsolidationEndpoints {
+	return &ConsolidationEndpoints{
+		config:       config,
+		observer:     observer,
+		consolidator: consolidator,
+		cache:        cache,
+	}
+}
+
+type ConsolidationEndpointsPostIssueMergeRequest struct {
+	TargetIDs []string `json:"target_ids"`
+}
+
+type ConsolidationEndpointsPostIssueMergeResponse struct {
+	issue.IssuePayload
+}
+
+func (self *ConsolidationEndpoints) PostIssueMerge(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	requestIssue := issue.RequestIssue(requestCtx)
+	request := ConsolidationEndpointsPostIssueMergeRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	kept, err := self.consolidator.MergeIssues(requestCtx, *requestProduct, requestIssue.ID, request.TargetIDs)
+	switch {
+	case err == nil:
+	case ErrConsolidatorInvalidMerge.In(err):
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	default:
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	if kept == nil {
+		return kit.HTTPErrNotFound
+	}
+
+	err = util.InvalidateCache(requestCtx, self.cache, issue.ISSUE_ENDPOINTS_SEARCH_KEY+requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := ConsolidationEndpointsPostIssueMergeResponse{}
+	response.IssuePayload = *issue.NewIssuePayload(*kept)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type ConsolidationEndpointsPostIssueSplitRequest struct {
+	FeedbackIDs []string `json:"feedback_ids"`
+}
+
+type ConsolidationEndpointsPostIssueSplitResponse struct {
+	issue.IssuePayload
+}
+
+func (self *ConsolidationEndpoints) PostIssueSplit(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	requestIssue := issue.RequestIssue(requestCtx)
+	request := ConsolidationEndpointsPostIssueSplitRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	split, err := self.consolidator.SplitIssue(requestCtx, *requestProduct, requestIssue.ID, request.FeedbackIDs)
+	switch {
+	case err == nil:
+	case ErrConsolidatorInvalidSplit.In(err):
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	default:
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	err = util.InvalidateCache(requestCtx, self.cache, issue.ISSUE_ENDPOINTS_SEARCH_KEY+requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := ConsolidationEndpointsPostIssueSplitResponse{}
+	response.IssuePayload = *issue.NewIssuePayload(*split)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type ConsolidationEndpointsPostSuggestionMergeRequest struct {
+	TargetIDs []string `json:"target_ids"`
+}
+
+type ConsolidationEndpointsPostSuggestionMergeResponse struct {
+	suggestion.SuggestionPayload
+}
+
+func (self *ConsolidationEndpoints) PostSuggestionMerge(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	requestSuggestion := suggestion.RequestSuggestion(requestCtx)
+	request := ConsolidationEndpointsPostSuggestionMergeRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	kept, err := self.consolidator.MergeSuggestions(requestCtx, *requestProduct, requestSuggestion.ID,
+		request.TargetIDs)
+	switch {
+	case err == nil:
+	case ErrConsolidatorInvalidMerge.In(err):
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	default:
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	if kept == nil {
+		return kit.HTTPErrNotFound
+	}
+
+	err = util.InvalidateCache(requestCtx, self.cache, suggestion.SUGGESTION_ENDPOINTS_SEARCH_KEY+requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := ConsolidationEndpointsPostSuggestionMergeResponse{}
+	response.SuggestionPayload = *suggestion.NewSuggestionPayload(*kept)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type ConsolidationEndpointsPostSuggestionSplitRequest struct {
+	FeedbackIDs []string `json:"feedback_ids"`
+}
+
+type ConsolidationEndpointsPostSuggestionSplitResponse struct {
+	suggestion.SuggestionPayload
+}
+
+func (self *ConsolidationEndpoints) PostSuggestionSplit(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	requestSuggestion := suggestion.RequestSuggestion(requestCtx)
+	request := ConsolidationEndpointsPostSuggestionSplitRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	split, err := self.consolidator.SplitSuggestion(requestCtx, *requestProduct, requestSuggestion.ID,
+		request.FeedbackIDs)
+	switch {
+	case err == nil:
+	case ErrConsolidatorInvalidSplit.In(err):
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	default:
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	err = util.InvalidateCache(requestCtx, self.cache, suggestion.SUGGESTION_ENDPOINTS_SEARCH_KEY+requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := ConsolidationEndpointsPostSuggestionSplitResponse{}
+	response.SuggestionPayload = *suggestion.NewSuggestionPayload(*split)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
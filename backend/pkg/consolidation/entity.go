@@ -0,0 +1,71 @@
+package consolidation
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/neoxelox/kit/util"
+
+	"backend/pkg/issue"
+	"backend/pkg/suggestion"
+)
+
+const (
+	// Stricter than the aggregation threshold as every pair of the product is compared, not only the new ones
+	CONSOLIDATION_SIMILAR_THRESHOLD   = 0.8
+	CONSOLIDATION_MAX_CANDIDATES      = 5
+	CONSOLIDATION_MAX_MERGES          = 100
+	CONSOLIDATION_PAGE_SIZE           = 100
+	CONSOLIDATION_MAX_MERGE_TARGETS   = 10
+	CONSOLIDATION_MAX_SPLIT_FEEDBACKS = 20
+	// Most recent feedbacks the texts of a split issue or suggestion are written again from
+	CONSOLIDATION_MAX_REWRITE_FEEDBACKS = 20
+)
+
+const (
+	ConsolidationTypeIssue      = "ISSUE"
+	ConsolidationTypeSuggestion = "SUGGESTION"
+)
+
+func IsConsolidationType(value string) bool {
+	return value == ConsolidationTypeIssue ||
+		value == ConsolidationTypeSuggestion
+}
+
+// ConsolidationSnapshot holds the issue or the suggestion, depending on the consolidation type.
+type ConsolidationSnapshot struct {
+	Issue      *issue.Issue
+	Suggestion *suggestion.Suggestion
+}
+
+type Consolidation struct {
+	ID               string
+	ProductID        string
+	Type             string
+	KeptID           string
+	MergedID         string
+	Kept             ConsolidationSnapshot
+	Merged           ConsolidationSnapshot
+	FeedbackIDs      []string
+	MovedFeedbackIDs []string
+	StateChangeIDs   []string
+	Tokens           int
+	CreatedAt        time.Time
+	UndoneAt         *time.Time
+}
+
+func NewConsolidation() *Consolidation {
+	return &Consolidation{}
+}
+
+func (self Consolidation) String() string {
+	return fmt.Sprintf("<Consolidation: %s into %s (%s)>", self.MergedID, self.KeptID, self.ID)
+}
+
+func (self Consolidation) Equals(other Consolidation) bool {
+	return util.Equals(self, other)
+}
+
+func (self Consolidation) Copy() *Consolidation {
+	return util.Copy(self)
+}
@@ -0,0 +1,803 @@
+package consolidation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	kitUtil "github.com/neoxelox/kit/util"
+	"github.com/rs/xid"
+
+	"backend/pkg/aggregator"
+	"backend/pkg/engine"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/priority"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+func (self *Consolidator) consolidateIssues(ctx context.Context, product product.Product) (int, error) {
+	merged := map[string]bool{}
+	tokens := 0
+
+	pagination := util.Pagination[time.Time]{
+		Limit: CONSOLIDATION_PAGE_SIZE,
+		From:  nil,
+	}
+
+	for len(merged) < CONSOLIDATION_MAX_MERGES {
+		page, err := self.issueRepository.ListByProductIDAndNotArchived(ctx, product.ID, pagination)
+		if err != nil {
+			return len(merged), err
+		}
+
+		for _, _issue := range page.Items {
+			if merged[_issue.ID] || len(merged) >= CONSOLIDATION_MAX_MERGES {
+				continue
+			}
+
+			mergedID, used, err := self.consolidateIssue(ctx, product, _issue, merged)
+			tokens += used
+			if err != nil {
+				return len(merged), err
+			}
+
+			if mergedID != nil {
+				merged[*mergedID] = true
+			}
+		}
+
+		if page.Next == nil {
+			break
+		}
+		pagination.From = page.Next
+	}
+
+	self.observer.Infof(ctx, "Consolidated %d issues using %d tokens", len(merged), tokens)
+
+	return len(merged), nil
+}
+
+func (self *Consolidator) consolidateIssue(ctx context.Context, product product.Product,
+	_issue issue.Issue, merged map[string]bool) (*string, int, error) {
+	// Embeddings of different models cannot be compared, the issue will be picked up after the reembed
+	if _issue.EmbeddingModel != product.EmbeddingModel {
+		return nil, 0, nil
+	}
+
+	candidates, err := self.issueRepository.ListByEmbeddingAndProductID(ctx, _issue.Embedding,
+		_issue.EmbeddingModel, CONSOLIDATION_SIMILAR_THRESHOLD, CONSOLIDATION_MAX_CANDIDATES+1, product.ID, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	candidates = util.Filter(candidates, func(candidate issue.Issue) bool {
+		return candidate.ID != _issue.ID && candidate.ArchivedAt == nil && !merged[candidate.ID]
+	})
+
+	if len(candidates) == 0 {
+		return nil, 0, nil
+	}
+
+	options := make([]engine.Issue, 0, len(candidates))
+	for _, candidate := range candidates {
+		options = append(options, engine.Issue{
+			Title:       candidate.Title,
+			Description: candidate.Description,
+			Steps:       candidate.Steps,
+		})
+	}
+
+	siResult, err := self.engineService.SimilarIssue(ctx, engine.EngineServiceSimilarIssueParams{
+		Issue: engine.Issue{
+			Title:       _issue.Title,
+			Description: _issue.Description,
+			Steps:       _issue.Steps,
+		},
+		Options:  options,
+		Language: product.Language,
+	})
+	if err != nil {
+		if engine.ErrEngineServiceTimedOut.Is(err) {
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+
+		return nil, 0, err
+	}
+
+	tokens := (siResult.Usage.Input + siResult.Usage.Output)
+
+	err = self.engineBreaker.Succeed(ctx, engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
+	if err != nil {
+		self.observer.Error(ctx, err)
+	}
+
+	if siResult.Option == nil {
+		return nil, tokens, nil
+	}
+
+	// The oldest issue is kept as it is the one most likely referenced from outside
+	kept, other := _issue, candidates[*siResult.Option]
+	if other.CreatedAt.Before(kept.CreatedAt) {
+		kept, other = other, kept
+	}
+
+	consolidated, used, err := self.mergeIssues(ctx, product, kept.ID, other.ID, tokens)
+	tokens += used
+	if err != nil {
+		return nil, tokens, err
+	}
+
+	if !consolidated {
+		return nil, tokens, nil
+	}
+
+	return &other.ID, tokens, nil
+}
+
+func (self *Consolidator) mergeIssues(ctx context.Context, product product.Product,
+	keptID string, mergedID string, tokens int) (bool, int, error) {
+	used := 0
+	consolidated := false
+
+	kept, err := self.issueRepository.GetByID(ctx, keptID)
+	if err != nil {
+		return false, used, err
+	}
+
+	merged, err := self.issueRepository.GetByID(ctx, mergedID)
+	if err != nil {
+		return false, used, err
+	}
+
+	if kept == nil || merged == nil || kept.ArchivedAt != nil || merged.ArchivedAt != nil {
+		return false, used, nil
+	}
+
+	// The engine is called before taking the product lock, so the aggregation is not blocked meanwhile
+	miResult, err := self.engineService.MergeIssues(ctx, engine.EngineServiceMergeIssuesParams{
+		IssueA:   issueTexts(*merged),
+		IssueB:   issueTexts(*kept),
+		Language: product.Language,
+	})
+	if err != nil {
+		if engine.ErrEngineServiceTimedOut.Is(err) {
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+
+		return false, used, err
+	}
+
+	used += (miResult.Usage.Input + miResult.Usage.Output)
+
+	ceResult, err := self.engineService.ComputeEmbedding(ctx, engine.EngineServiceComputeEmbeddingParams{
+		Text:  miResult.Issue.Description,
+		Model: product.EmbeddingModel,
+	})
+	if err != nil {
+		if engine.ErrEngineServiceTimedOut.Is(err) {
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+
+		return false, used, err
+	}
+
+	used += (ceResult.Usage.Input + ceResult.Usage.Output)
+
+	for _, operation := range []string{engine.ENGINE_BREAKER_OPERATION_AGGREGATION,
+		engine.ENGINE_BREAKER_OPERATION_EMBEDDING} {
+		err := self.engineBreaker.Succeed(ctx, operation)
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	before, other := issueTexts(*kept), issueTexts(*merged)
+
+	err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		// Do not race with the aggregation of new partial issues into any of both issues
+		err := util.LockTransaction(ctx, self.database, fmt.Sprintf(aggregator.ISSUE_AGGREGATOR_LOCK_KEY, product.ID))
+		if err != nil {
+			return err
+		}
+
+		kept, err := self.issueRepository.GetByIDForUpdate(ctx, keptID)
+		if err != nil {
+			return err
+		}
+
+		merged, err := self.issueRepository.GetByIDForUpdate(ctx, mergedID)
+		if err != nil {
+			return err
+		}
+
+		// Any of both issues could have been archived, consolidated or rewritten by the aggregation in the
+		// meantime, in which case the merged texts are stale and the issues are compared again next time
+		if kept == nil || merged == nil || kept.ArchivedAt != nil || merged.ArchivedAt != nil ||
+			!kitUtil.Equals(issueTexts(*kept), before) || !kitUtil.Equals(issueTexts(*merged), other) {
+			return nil
+		}
+
+		snapshot := kept.Copy()
+
+		kept.Title = miResult.Issue.Title
+		kept.Description = miResult.Issue.Description
+		kept.Steps = miResult.Issue.Steps
+		kept.Embedding = ceResult.Embedding
+		kept.EmbeddingModel = ceResult.Model
+
+		for source, count := range merged.Sources {
+			kept.Sources[source] += count
+		}
+		for severity, count := range merged.Severities {
+			kept.Severities[severity] += count
+		}
+		for category, count := range merged.Categories {
+			kept.Categories[category] += count
+		}
+		for release, count := range merged.Releases {
+			kept.Releases[release] += count
+		}
+		kept.Customers += merged.Customers
+		if merged.FirstSeenAt.Before(kept.FirstSeenAt) {
+			kept.FirstSeenAt = merged.FirstSeenAt
+		}
+		if merged.LastSeenAt.After(kept.LastSeenAt) {
+			kept.LastSeenAt = merged.LastSeenAt
+		}
+		if kept.AssigneeID == nil {
+			kept.AssigneeID = merged.AssigneeID
+		}
+		if kept.Quality == nil {
+			kept.Quality = merged.Quality
+		}
+		now := time.Now()
+		kept.LastAggregatedAt = &now
+
+		err = self.issueRepository.UpdateConsolidated(ctx, *kept)
+		if err != nil {
+			return err
+		}
+
+		feedbackIDs, movedFeedbackIDs, err := self.issueRepository.MoveFeedbacks(ctx, merged.ID, kept.ID)
+		if err != nil {
+			return err
+		}
+
+		err = self.prioritizeIssues(ctx, product.ID, kept)
+		if err != nil {
+			return err
+		}
+
+		// Otherwise the history of the merged issue would be deleted along with it
+		stateChangeIDs, err := self.issueRepository.MoveStateChanges(ctx, merged.ID, kept.ID)
+		if err != nil {
+			return err
+		}
+
+		err = self.issueRepository.Delete(ctx, merged.ID)
+		if err != nil {
+			return err
+		}
+
+		consolidation := NewConsolidation()
+		consolidation.ID = xid.New().String()
+		consolidation.ProductID = product.ID
+		consolidation.Type = ConsolidationTypeIssue
+		consolidation.KeptID = kept.ID
+		consolidation.MergedID = merged.ID
+		consolidation.Kept = ConsolidationSnapshot{Issue: snapshot}
+		consolidation.Merged = ConsolidationSnapshot{Issue: merged}
+		consolidation.FeedbackIDs = feedbackIDs
+		consolidation.MovedFeedbackIDs = movedFeedbackIDs
+		consolidation.StateChangeIDs = stateChangeIDs
+		consolidation.Tokens = tokens + used
+		consolidation.CreatedAt = now
+		consolidation.UndoneAt = nil
+
+		_, err = self.consolidationRepository.Create(ctx, *consolidation)
+		if err != nil {
+			return err
+		}
+
+		consolidated = true
+
+		return nil
+	})
+	if err != nil {
+		return false, used, err
+	}
+
+	if consolidated {
+		self.observer.Infof(ctx, "Consolidated issue %s into %s", mergedID, keptID)
+	}
+
+	return consolidated, used, nil
+}
+
+func (self *Consolidator) undoIssue(ctx context.Context, consolidation Consolidation) error {
+	before := consolidation.Kept.Issue
+	merged := consolidation.Merged.Issue
+	if before == nil || merged == nil {
+		return ErrConsolidatorNotUndoable.Raise().With("consolidation %s has no issue snapshots", consolidation.ID)
+	}
+
+	return self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := util.LockTransaction(ctx, self.database,
+			fmt.Sprintf(aggregator.ISSUE_AGGREGATOR_LOCK_KEY, consolidation.ProductID))
+		if err != nil {
+			return err
+		}
+
+		current, err := self.consolidationRepository.GetByIDForUpdate(ctx, consolidation.ID)
+		if err != nil {
+			return err
+		}
+
+		if current == nil || current.UndoneAt != nil {
+			return ErrConsolidatorAlreadyUndone.Raise().With("consolidation %s was undone", consolidation.ID)
+		}
+
+		kept, err := self.issueRepository.GetByIDForUpdate(ctx, consolidation.KeptID)
+		if err != nil {
+			return err
+		}
+
+		if kept == nil {
+			later, err := self.consolidationRepository.GetByMergedIDAndNotUndone(ctx,
+				ConsolidationTypeIssue, consolidation.KeptID)
+			if err != nil {
+				return err
+			}
+
+			if later != nil {
+				return ErrConsolidatorNotUndoable.Raise().
+					With("issue %s was consolidated by %s, undo it first", consolidation.KeptID, later.ID)
+			}
+
+			return ErrConsolidatorNotUndoable.Raise().With("issue %s no longer exists", consolidation.KeptID)
+		}
+
+		for source, count := range merged.Sources {
+			kept.Sources[source] -= count
+			if kept.Sources[source] <= 0 {
+				delete(kept.Sources, source)
+			}
+		}
+		for severity, count := range merged.Severities {
+			kept.Severities[severity] -= count
+			if kept.Severities[severity] <= 0 {
+				delete(kept.Severities, severity)
+			}
+		}
+		for category, count := range merged.Categories {
+			kept.Categories[category] -= count
+			if kept.Categories[category] <= 0 {
+				delete(kept.Categories, category)
+			}
+		}
+		for release, count := range merged.Releases {
+			kept.Releases[release] -= count
+			if kept.Releases[release] <= 0 {
+				delete(kept.Releases, release)
+			}
+		}
+		kept.Customers = max(1, kept.Customers-merged.Customers)
+		if kept.FirstSeenAt.Equal(merged.FirstSeenAt) && merged.FirstSeenAt.Before(before.FirstSeenAt) {
+			kept.FirstSeenAt = before.FirstSeenAt
+		}
+		if kept.LastSeenAt.Equal(merged.LastSeenAt) && merged.LastSeenAt.After(before.LastSeenAt) {
+			kept.LastSeenAt = before.LastSeenAt
+		}
+		if before.AssigneeID == nil && kitUtil.Equals(kept.AssigneeID, merged.AssigneeID) {
+			kept.AssigneeID = nil
+		}
+		if before.Quality == nil && kitUtil.Equals(kept.Quality, merged.Quality) {
+			kept.Quality = nil
+		}
+
+		// Otherwise the current texts also describe the partial issues aggregated after the merge
+		if kept.LastAggregatedAt == nil || !kept.LastAggregatedAt.After(consolidation.CreatedAt) {
+			kept.Title = before.Title
+			kept.Description = before.Description
+			kept.Steps = before.Steps
+			kept.Embedding = before.Embedding
+			kept.EmbeddingModel = before.EmbeddingModel
+		}
+
+		err = self.issueRepository.UpdateConsolidated(ctx, *kept)
+		if err != nil {
+			return err
+		}
+
+		err = self.issueRepository.UnlinkFeedbacks(ctx, kept.ID, consolidation.MovedFeedbackIDs)
+		if err != nil {
+			return err
+		}
+
+		err = self.issueRepository.Restore(ctx, *merged)
+		if err != nil {
+			return err
+		}
+
+		err = self.issueRepository.LinkFeedbacks(ctx, merged.ID, consolidation.FeedbackIDs)
+		if err != nil {
+			return err
+		}
+
+		err = self.issueRepository.LinkStateChanges(ctx, merged.ID, consolidation.StateChangeIDs)
+		if err != nil {
+			return err
+		}
+
+		err = self.prioritizeIssues(ctx, consolidation.ProductID, kept, merged)
+		if err != nil {
+			return err
+		}
+
+		err = self.consolidationRepository.UpdateUndoneAt(ctx, consolidation.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// MergeIssues merges the issues into the kept one as the consolidation job would, so each merge can be undone.
+func (self *Consolidator) MergeIssues(ctx context.Context, product product.Product,
+	keptID string, mergedIDs []string) (*issue.Issue, error) {
+	if len(mergedIDs) == 0 || len(mergedIDs) > CONSOLIDATION_MAX_MERGE_TARGETS {
+		return nil, ErrConsolidatorInvalidMerge.Raise().
+			With("between 1 and %d issues can be merged at once", CONSOLIDATION_MAX_MERGE_TARGETS)
+	}
+
+	if len(util.Unique(mergedIDs)) != len(mergedIDs) || slices.Contains(mergedIDs, keptID) {
+		return nil, ErrConsolidatorInvalidMerge.Raise().With("issues to merge must be distinct")
+	}
+
+	for _, id := range append([]string{keptID}, mergedIDs...) {
+		_issue, err := self.issueRepository.GetByID(ctx, id)
+		if err != nil {
+			return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+		}
+
+		if _issue == nil || _issue.ProductID != product.ID || _issue.ArchivedAt != nil {
+			return nil, ErrConsolidatorInvalidMerge.Raise().With("issue %s cannot be merged", id)
+		}
+	}
+
+	for _, mergedID := range mergedIDs {
+		consolidated, _, err := self.mergeIssues(ctx, product, keptID, mergedID, 0)
+		if err != nil {
+			return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+		}
+
+		if !consolidated {
+			return nil, ErrConsolidatorInvalidMerge.Raise().With("issue %s cannot be merged", mergedID)
+		}
+	}
+
+	kept, err := self.issueRepository.GetByID(ctx, keptID)
+	if err != nil {
+		return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+	}
+
+	return kept, nil
+}
+
+// SplitIssue moves the feedbacks out of a issue into a new one, whose title and description are written by
+// merging the feedbacks one by one, as the ones of the split issue are written again from its remaining most recent
+// feedbacks. The severities and categories of each feedback are not kept after the aggregation, so the new issue
+// starts without any and the split issue keeps its own, as they cannot be told apart.
+func (self *Consolidator) SplitIssue(ctx context.Context, product product.Product,
+	id string, feedbackIDs []string) (*issue.Issue, error) {
+	if len(feedbackIDs) == 0 || len(feedbackIDs) > CONSOLIDATION_MAX_SPLIT_FEEDBACKS {
+		return nil, ErrConsolidatorInvalidSplit.Raise().
+			With("between 1 and %d feedbacks can be split at once", CONSOLIDATION_MAX_SPLIT_FEEDBACKS)
+	}
+
+	if len(util.Unique(feedbackIDs)) != len(feedbackIDs) {
+		return nil, ErrConsolidatorInvalidSplit.Raise().With("feedbacks to split must be distinct")
+	}
+
+	original, err := self.issueRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+	}
+
+	if original == nil || original.ArchivedAt != nil {
+		return nil, ErrConsolidatorInvalidSplit.Raise().With("issue %s cannot be split", id)
+	}
+
+	feedbacks, err := self.issueRepository.ListFeedbacksByIDs(ctx, id, feedbackIDs)
+	if err != nil {
+		return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+	}
+
+	if len(feedbacks) != len(feedbackIDs) {
+		return nil, ErrConsolidatorInvalidSplit.Raise().With("feedbacks to split must belong to issue %s", id)
+	}
+
+	count, err := self.issueRepository.CountFeedbacks(ctx, id)
+	if err != nil {
+		return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+	}
+
+	if len(feedbacks) >= count {
+		return nil, ErrConsolidatorInvalidSplit.Raise().With("issue %s cannot be left without feedbacks", id)
+	}
+
+	page, err := self.issueRepository.ListFeedbacks(ctx, id, util.Pagination[time.Time]{
+		Limit: CONSOLIDATION_MAX_REWRITE_FEEDBACKS + len(feedbackIDs),
+		From:  nil,
+	})
+	if err != nil {
+		return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+	}
+
+	// The feedbacks are listed the most recent first, but are merged the oldest first as the split ones are
+	remaining := make([]feedback.Feedback, 0, CONSOLIDATION_MAX_REWRITE_FEEDBACKS)
+	for _, feedback := range page.Items {
+		if !slices.Contains(feedbackIDs, feedback.ID) && len(remaining) < CONSOLIDATION_MAX_REWRITE_FEEDBACKS {
+			remaining = append(remaining, feedback)
+		}
+	}
+	slices.Reverse(remaining)
+
+	tokens := 0
+
+	texts, usage, err := self.writeIssue(ctx, product, feedbacks)
+	if err != nil {
+		return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+	}
+
+	tokens += usage
+
+	sourceTexts, usage, err := self.writeIssue(ctx, product, remaining)
+	if err != nil {
+		return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+	}
+
+	tokens += usage
+
+	ceResult, err := self.computeEmbedding(ctx, product, texts.Description)
+	if err != nil {
+		return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+	}
+
+	tokens += (ceResult.Usage.Input + ceResult.Usage.Output)
+
+	sourceCEResult, err := self.computeEmbedding(ctx, product, sourceTexts.Description)
+	if err != nil {
+		return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+	}
+
+	tokens += (sourceCEResult.Usage.Input + sourceCEResult.Usage.Output)
+
+	for _, operation := range []string{engine.ENGINE_BREAKER_OPERATION_AGGREGATION,
+		engine.ENGINE_BREAKER_OPERATION_EMBEDDING} {
+		err := self.engineBreaker.Succeed(ctx, operation)
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	split := issue.NewIssue()
+
+	err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := util.LockTransaction(ctx, self.database, fmt.Sprintf(aggregator.ISSUE_AGGREGATOR_LOCK_KEY, product.ID))
+		if err != nil {
+			return err
+		}
+
+		source, err := self.issueRepository.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if source == nil || source.ArchivedAt != nil {
+			return ErrConsolidatorInvalidSplit.Raise().With("issue %s cannot be split", id)
+		}
+
+		// The texts written again would drop the feedbacks aggregated into the issue in the meantime
+		if !kitUtil.Equals(issueTexts(*source), issueTexts(*original)) {
+			return ErrConsolidatorInvalidSplit.Raise().With("issue %s changed while being split", id)
+		}
+
+		// The feedbacks could have been moved in the meantime
+		feedbacks, err := self.issueRepository.ListFeedbacksByIDs(ctx, id, feedbackIDs)
+		if err != nil {
+			return err
+		}
+
+		if len(feedbacks) != len(feedbackIDs) {
+			return ErrConsolidatorInvalidSplit.Raise().With("feedbacks to split must belong to issue %s", id)
+		}
+
+		now := time.Now()
+		split.ID = xid.New().String()
+		split.ProductID = product.ID
+		split.Embedding = ceResult.Embedding
+		split.EmbeddingModel = ceResult.Model
+		split.Sources = map[string]int{}
+		split.Title = texts.Title
+		split.Description = texts.Description
+		split.Steps = texts.Steps
+		split.Severities = map[string]int{}
+		split.Categories = map[string]int{}
+		split.Releases = map[string]int{}
+		split.Customers = len(feedbacks)
+		split.AssigneeID = nil
+		split.Quality = nil
+		split.State = issue.IssueStateOpen
+		split.ResolvedInRelease = nil
+		split.FirstSeenAt = feedbacks[0].PostedAt
+		split.LastSeenAt = feedbacks[len(feedbacks)-1].PostedAt
+		split.CreatedAt = now
+		split.ArchivedAt = nil
+		split.LastAggregatedAt = &now
+		split.ExportedAt = nil
+
+		for _, feedback := range feedbacks {
+			split.Sources[feedback.Source]++
+			split.Releases[feedback.Release]++
+		}
+
+		for _source, count := range split.Sources {
+			source.Sources[_source] -= count
+			if source.Sources[_source] <= 0 {
+				delete(source.Sources, _source)
+			}
+		}
+		for release, count := range split.Releases {
+			source.Releases[release] -= count
+			if source.Releases[release] <= 0 {
+				delete(source.Releases, release)
+			}
+		}
+		source.Embedding = sourceCEResult.Embedding
+		source.EmbeddingModel = sourceCEResult.Model
+		source.Title = sourceTexts.Title
+		source.Description = sourceTexts.Description
+		source.Steps = sourceTexts.Steps
+		source.Customers = max(1, source.Customers-split.Customers)
+		source.LastAggregatedAt = &now
+
+		err = self.issueRepository.Restore(ctx, *split)
+		if err != nil {
+			return err
+		}
+
+		err = self.issueRepository.UnlinkFeedbacks(ctx, source.ID, feedbackIDs)
+		if err != nil {
+			return err
+		}
+
+		err = self.issueRepository.LinkFeedbacks(ctx, split.ID, feedbackIDs)
+		if err != nil {
+			return err
+		}
+
+		firstSeenAt, lastSeenAt, err := self.issueRepository.GetSeenAt(ctx, source.ID)
+		if err != nil {
+			return err
+		}
+
+		if firstSeenAt == nil || lastSeenAt == nil {
+			return ErrConsolidatorInvalidSplit.Raise().With("issue %s cannot be left without feedbacks", id)
+		}
+
+		source.FirstSeenAt = *firstSeenAt
+		source.LastSeenAt = *lastSeenAt
+
+		err = self.issueRepository.UpdateConsolidated(ctx, *source)
+		if err != nil {
+			return err
+		}
+
+		err = self.prioritizeIssues(ctx, product.ID, source, split)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		if ErrConsolidatorInvalidSplit.In(err) {
+			return nil, err
+		}
+
+		return nil, ErrConsolidatorGeneric.Raise().Cause(err)
+	}
+
+	self.observer.Infof(ctx, "Split %d feedbacks of issue %s into %s using %d tokens",
+		len(feedbackIDs), id, split.ID, tokens)
+
+	return split, nil
+}
+
+// writeIssue writes the title, description and steps of an issue by merging its feedbacks one by one. Merging
+// the first feedback into an empty issue lets the engine write its title too.
+func (self *Consolidator) writeIssue(ctx context.Context, product product.Product,
+	feedbacks []feedback.Feedback) (*engine.Issue, int, error) {
+	texts := engine.Issue{}
+	tokens := 0
+
+	for _, feedback := range feedbacks {
+		miResult, err := self.engineService.MergeIssues(ctx, engine.EngineServiceMergeIssuesParams{
+			IssueA: engine.Issue{
+				Title:       "",
+				Description: feedback.Text(product.Language),
+				Steps:       []string{},
+			},
+			IssueB:   texts,
+			Language: product.Language,
+		})
+		if err != nil {
+			if engine.ErrEngineServiceTimedOut.Is(err) {
+				err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_AGGREGATION)
+				if err != nil {
+					self.observer.Error(ctx, err)
+				}
+			}
+
+			return nil, 0, err
+		}
+
+		texts = miResult.Issue
+		tokens += (miResult.Usage.Input + miResult.Usage.Output)
+	}
+
+	return &texts, tokens, nil
+}
+
+func issueTexts(_issue issue.Issue) engine.Issue {
+	return engine.Issue{
+		Title:       _issue.Title,
+		Description: _issue.Description,
+		Steps:       _issue.Steps,
+	}
+}
+
+// prioritizeIssues scores the issues with the current formula of their product. It must be called while holding
+// the product lock and once the feedbacks of the issues are linked, as trending formulas count them.
+func (self *Consolidator) prioritizeIssues(ctx context.Context, productID string, issues ...*issue.Issue) error {
+	product, err := self.productRepository.GetByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	formula := product.PriorityFormula()
+	now := time.Now()
+	priorities := make(map[string]int, len(issues))
+	for _, _issue := range issues {
+		var trend *priority.PriorityTrend
+		if formula.Trending() {
+			trend, err = self.issueRepository.GetTrend(ctx, _issue.ID, formula.TrendWindow, now)
+			if err != nil {
+				return err
+			}
+		}
+
+		_issue.Priority = issue.ComputePriority(formula, *_issue, trend)
+		priorities[_issue.ID] = _issue.Priority
+	}
+
+	return self.issueRepository.UpdatePriorities(ctx, priorities)
+}
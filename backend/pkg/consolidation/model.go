@@ -0,0 +1,84 @@
+package consolidation
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	CONSOLIDATION_MODEL_TABLE = "\"consolidation\""
+)
+
+type ConsolidationModel struct {
+	ID               string     `db:"id"`
+	ProductID        string     `db:"product_id"`
+	Type             string     `db:"type"`
+	KeptID           string     `db:"kept_id"`
+	MergedID         string     `db:"merged_id"`
+	Kept             []byte     `db:"kept"`
+	Merged           []byte     `db:"merged"`
+	FeedbackIDs      []string   `db:"feedback_ids"`
+	MovedFeedbackIDs []string   `db:"moved_feedback_ids"`
+	StateChangeIDs   []string   `db:"state_change_ids"`
+	Tokens           int        `db:"tokens"`
+	CreatedAt        time.Time  `db:"created_at"`
+	UndoneAt         *time.Time `db:"undone_at"`
+}
+
+func NewConsolidationModel(consolidation Consolidation) *ConsolidationModel {
+	kept, err := json.Marshal(consolidation.Kept)
+	if err != nil {
+		panic(err)
+	}
+
+	merged, err := json.Marshal(consolidation.Merged)
+	if err != nil {
+		panic(err)
+	}
+
+	return &ConsolidationModel{
+		ID:               consolidation.ID,
+		ProductID:        consolidation.ProductID,
+		Type:             consolidation.Type,
+		KeptID:           consolidation.KeptID,
+		MergedID:         consolidation.MergedID,
+		Kept:             kept,
+		Merged:           merged,
+		FeedbackIDs:      consolidation.FeedbackIDs,
+		MovedFeedbackIDs: consolidation.MovedFeedbackIDs,
+		StateChangeIDs:   consolidation.StateChangeIDs,
+		Tokens:           consolidation.Tokens,
+		CreatedAt:        consolidation.CreatedAt,
+		UndoneAt:         consolidation.UndoneAt,
+	}
+}
+
+func (self *ConsolidationModel) ToEntity() *Consolidation {
+	var kept ConsolidationSnapshot
+	err := json.Unmarshal(self.Kept, &kept)
+	if err != nil {
+		panic(err)
+	}
+
+	var merged ConsolidationSnapshot
+	err = json.Unmarshal(self.Merged, &merged)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Consolidation{
+		ID:               self.ID,
+		ProductID:        self.ProductID,
+		Type:             self.Type,
+		KeptID:           self.KeptID,
+		MergedID:         self.MergedID,
+		Kept:             kept,
+		Merged:           merged,
+		FeedbackIDs:      self.FeedbackIDs,
+		MovedFeedbackIDs: self.MovedFeedbackIDs,
+		StateChangeIDs:   self.StateChangeIDs,
+		Tokens:           self.Tokens,
+		CreatedAt:        self.CreatedAt,
+		UndoneAt:         self.UndoneAt,
+	}
+}
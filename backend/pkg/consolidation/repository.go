@@ -0,0 +1,187 @@
+package consolidation
+
+import (
+	"context"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/util"
+)
+
+type ConsolidationRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewConsolidationRepository(observer *kit.Observer, database *kit.Database,
+	config config.Config) *ConsolidationRepository {
+	return &ConsolidationRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *ConsolidationRepository) Create(ctx context.Context,
+	consolidation Consolidation) (*Consolidation, error) {
+	c := NewConsolidationModel(consolidation)
+
+	stmt := sqlf.
+		InsertInto(CONSOLIDATION_MODEL_TABLE).
+		Set("id", c.ID).
+		Set("product_id", c.ProductID).
+		Set("type", c.Type).
+		Set("kept_id", c.KeptID).
+		Set("merged_id", c.MergedID).
+		Set("kept", c.Kept).
+		Set("merged", c.Merged).
+		Set("feedback_ids", c.FeedbackIDs).
+		Set("moved_feedback_ids", c.MovedFeedbackIDs).
+		Set("state_change_ids", c.StateChangeIDs).
+		Set("tokens", c.Tokens).
+		Set("created_at", c.CreatedAt).
+		Set("undone_at", c.UndoneAt).
+		Returning("*").To(&c)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ToEntity(), nil
+}
+
+func (self *ConsolidationRepository) GetByID(ctx context.Context, id string) (*Consolidation, error) {
+	var c ConsolidationModel
+
+	stmt := sqlf.
+		Select("*").To(&c).
+		From(CONSOLIDATION_MODEL_TABLE).
+		Where("id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return c.ToEntity(), nil
+}
+
+func (self *ConsolidationRepository) GetByIDForUpdate(ctx context.Context, id string) (*Consolidation, error) {
+	var c ConsolidationModel
+
+	stmt := sqlf.
+		Select("*").To(&c).
+		From(CONSOLIDATION_MODEL_TABLE).
+		Where("id = ?", id).
+		Clause("FOR NO KEY UPDATE NOWAIT")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return c.ToEntity(), nil
+}
+
+// GetByMergedIDAndNotUndone returns the consolidation that merged away the given issue or suggestion, if any.
+func (self *ConsolidationRepository) GetByMergedIDAndNotUndone(ctx context.Context,
+	_type string, mergedID string) (*Consolidation, error) {
+	var c ConsolidationModel
+
+	stmt := sqlf.
+		Select("*").To(&c).
+		From(CONSOLIDATION_MODEL_TABLE).
+		Where("type = ?", _type).
+		Where("merged_id = ?", mergedID).
+		Where("undone_at IS NULL").
+		OrderBy("created_at DESC").
+		Limit(1)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return c.ToEntity(), nil
+}
+
+func (self *ConsolidationRepository) ListByProductID(ctx context.Context, productID string,
+	pagination util.Pagination[time.Time]) (*util.Page[Consolidation, time.Time], error) {
+	var cs []ConsolidationModel
+
+	stmt := sqlf.
+		Select("*").To(&cs).
+		From(CONSOLIDATION_MODEL_TABLE).
+		Where("product_id = ?", productID)
+
+	if pagination.From != nil {
+		stmt.
+			Where("(created_at, id) < (?, ?)", pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy("created_at DESC", "id DESC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[Consolidation, time.Time]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]Consolidation, 0, len(cs))
+	for _, c := range cs {
+		items = append(items, *c.ToEntity())
+	}
+
+	var cursor *util.Cursor[time.Time]
+	if len(cs) == pagination.Limit {
+		cursor = &util.Cursor[time.Time]{
+			Value: cs[pagination.Limit-1].CreatedAt,
+			ID:    cs[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[Consolidation, time.Time]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
+
+func (self *ConsolidationRepository) UpdateUndoneAt(ctx context.Context, id string, undoneAt time.Time) error {
+	stmt := sqlf.
+		Update(CONSOLIDATION_MODEL_TABLE).
+		Set("undone_at", undoneAt).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
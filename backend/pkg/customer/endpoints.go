@@ -0,0 +1,393 @@
+package customer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/outbox"
+	"backend/pkg/product"
+	"backend/pkg/suggestion"
+	"backend/pkg/util"
+)
+
+type CustomerEndpoints struct {
+	config             config.Config
+	observer           *kit.Observer
+	database           *kit.Database
+	customerRepository *CustomerRepository
+	enqueuer           *outbox.OutboxEnqueuer
+}
+
+func NewCustomerEndpoints(observer *kit.Observer, database *kit.Database, customerRepository *CustomerRepository,
+	enqueuer *outbox.OutboxEnqueuer, config config.Config) *CustomerEndpoints {
+	return &CustomerEndpoints{
+		config:             config,
+		observer:           observer,
+		database:           database,
+		customerRepository: customerRepository,
+		enqueuer:           enqueuer,
+	}
+}
+
+type CustomerEndpointsListCustomersRequest struct {
+	From *string `query:"from"`
+}
+
+type CustomerEndpointsListCustomersResponse struct {
+	Customers []CustomerPayload `json:"customers"`
+	Next      *string           `json:"next"`
+}
+
+func (self *CustomerEndpoints) ListCustomers(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := CustomerEndpointsListCustomersRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	page, err := self.customerRepository.ListByProductID(requestCtx, requestProduct.ID, util.Pagination[time.Time]{
+		Limit: 100,
+		From:  util.CursorFromString[time.Time](request.From),
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := CustomerEndpointsListCustomersResponse{}
+	response.Customers = make([]CustomerPayload, 0, len(page.Items))
+	for _, customer := range page.Items {
+		response.Customers = append(response.Customers, *NewCustomerPayload(customer))
+	}
+	response.Next = nil
+	next := util.CursorToString(page.Next)
+	if next != nil {
+		*next = url.QueryEscape(*next)
+	}
+	response.Next = next
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type CustomerEndpointsGetCustomerResponse struct {
+	CustomerPayload
+	Issues      []issue.IssuePayload           `json:"issues"`
+	Suggestions []suggestion.SuggestionPayload `json:"suggestions"`
+	Sentiments  []CustomerSentimentPayload     `json:"sentiments"`
+}
+
+func (self *CustomerEndpoints) GetCustomer(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestCustomer := RequestCustomer(requestCtx)
+
+	issues, err := self.customerRepository.ListIssues(requestCtx, requestCustomer.ID, CUSTOMER_PROFILE_MAX_REPORTS)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	suggestions, err := self.customerRepository.ListSuggestions(requestCtx, requestCustomer.ID,
+		CUSTOMER_PROFILE_MAX_REPORTS)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	sentiments, err := self.customerRepository.ListSentiments(requestCtx, requestCustomer.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := CustomerEndpointsGetCustomerResponse{}
+	response.CustomerPayload = *NewCustomerPayload(*requestCustomer)
+	response.Issues = make([]issue.IssuePayload, 0, len(issues))
+	for _, _issue := range issues {
+		response.Issues = append(response.Issues, *issue.NewIssuePayload(_issue))
+	}
+	response.Suggestions = make([]suggestion.SuggestionPayload, 0, len(suggestions))
+	for _, _suggestion := range suggestions {
+		response.Suggestions = append(response.Suggestions, *suggestion.NewSuggestionPayload(_suggestion))
+	}
+	response.Sentiments = make([]CustomerSentimentPayload, 0, len(sentiments))
+	for _, sentiment := range sentiments {
+		response.Sentiments = append(response.Sentiments, *NewCustomerSentimentPayload(sentiment))
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type CustomerEndpointsListCustomerFeedbacksRequest struct {
+	From *string `query:"from"`
+}
+
+type CustomerEndpointsListCustomerFeedbacksResponse struct {
+	Feedbacks []feedback.FeedbackPayload `json:"feedbacks"`
+	Next      *string                    `json:"next"`
+}
+
+func (self *CustomerEndpoints) ListCustomerFeedbacks(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestCustomer := RequestCustomer(requestCtx)
+	request := CustomerEndpointsListCustomerFeedbacksRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	page, err := self.customerRepository.ListFeedbacks(requestCtx, requestCustomer.ID, util.Pagination[time.Time]{
+		Limit: 100,
+		From:  util.CursorFromString[time.Time](request.From),
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := CustomerEndpointsListCustomerFeedbacksResponse{}
+	response.Feedbacks = make([]feedback.FeedbackPayload, 0, len(page.Items))
+	for _, _feedback := range page.Items {
+		response.Feedbacks = append(response.Feedbacks, *feedback.NewFeedbackPayload(_feedback))
+	}
+	response.Next = nil
+	next := util.CursorToString(page.Next)
+	if next != nil {
+		*next = url.QueryEscape(*next)
+	}
+	response.Next = next
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type CustomerEndpointsPostCustomerMergeRequest struct {
+	CustomerIDs []string `json:"customer_ids"`
+}
+
+type CustomerEndpointsPostCustomerMergeResponse struct {
+	CustomerPayload
+}
+
+func (self *CustomerEndpoints) PostCustomerMerge(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestCustomer := RequestCustomer(requestCtx)
+	request := CustomerEndpointsPostCustomerMergeRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	ids := []string{}
+	for _, id := range request.CustomerIDs {
+		if id == requestCustomer.ID || slices.Contains(ids, id) {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 || len(ids) > CUSTOMER_MERGING_MAX_CUSTOMERS {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	var customer *Customer
+
+	// The customers are read again under the product lock, so a concurrent resolution cannot overwrite the merged
+	// counts nor bring back the merged customers
+	err = self.database.Transaction(requestCtx, nil, func(requestCtx context.Context) error {
+		err := util.LockTransaction(requestCtx, self.database,
+			fmt.Sprintf(CUSTOMER_LOCK_KEY, requestCustomer.ProductID))
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+
+		customer, err = self.customerRepository.GetByID(requestCtx, requestCustomer.ID)
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+
+		if customer == nil {
+			return kit.HTTPErrNotFound
+		}
+
+		others, err := self.customerRepository.ListByIDs(requestCtx, ids)
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+
+		if len(others) != len(ids) {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		for _, other := range others {
+			if other.ProductID != customer.ProductID {
+				return kit.HTTPErrUnauthorized
+			}
+
+			customer.Merge(other)
+		}
+		customer.UpdatedAt = time.Now()
+
+		err = self.customerRepository.Merge(requestCtx, *customer, others)
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	response := CustomerEndpointsPostCustomerMergeResponse{}
+	response.CustomerPayload = *NewCustomerPayload(*customer)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type CustomerEndpointsPostCustomerAccountsRequest struct {
+	Accounts []struct {
+		Email        *string `json:"email"`
+		ExternalID   *string `json:"external_id"`
+		ARR          *int    `json:"arr"`
+		Plan         *string `json:"plan"`
+		AccountOwner *string `json:"account_owner"`
+	} `json:"accounts"`
+}
+
+type CustomerEndpointsPostCustomerAccountsResponse struct {
+	Accounts int `json:"accounts"`
+}
+
+func (self *CustomerEndpoints) PostCustomerAccounts(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := CustomerEndpointsPostCustomerAccountsRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if len(request.Accounts) == 0 || len(request.Accounts) > CUSTOMER_ACCOUNT_IMPORTING_MAX_ACCOUNTS {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	now := time.Now()
+	accounts := make([]CustomerAccount, 0, len(request.Accounts))
+	for _, _account := range request.Accounts {
+		account, err := NewCustomerAccount(requestProduct.ID, _account.Email, _account.ExternalID, _account.ARR,
+			_account.Plan, _account.AccountOwner, now)
+		if err != nil {
+			return kit.HTTPErrInvalidRequest.Cause(err)
+		}
+
+		accounts = append(accounts, *account)
+	}
+
+	err = self.importAccounts(requestCtx, requestProduct.ID, accounts)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := CustomerEndpointsPostCustomerAccountsResponse{}
+	response.Accounts = len(accounts)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type CustomerEndpointsPostCustomerAccountsCSVResponse struct {
+	Accounts int `json:"accounts"`
+}
+
+func (self *CustomerEndpoints) PostCustomerAccountsCSV(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+	defer file.Close()
+
+	accounts, err := NewCustomerAccountsFromCSV(requestProduct.ID, file, time.Now())
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if len(accounts) == 0 {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	err = self.importAccounts(requestCtx, requestProduct.ID, accounts)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := CustomerEndpointsPostCustomerAccountsCSVResponse{}
+	response.Accounts = len(accounts)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+func (self *CustomerEndpoints) DeleteCustomerAccounts(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+
+	err := self.customerRepository.DeleteAccountsByProductID(requestCtx, requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	err = self.enrich(requestCtx, requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, struct{}{})
+}
+
+func (self *CustomerEndpoints) importAccounts(ctx context.Context, productID string,
+	accounts []CustomerAccount) error {
+	// The same account can only be saved once at a time, the last one imported wins
+	unique := make([]CustomerAccount, 0, len(accounts))
+	indexes := make(map[string]int, len(accounts))
+	for _, account := range accounts {
+		if i, ok := indexes[account.Key]; ok {
+			unique[i] = account
+			continue
+		}
+
+		indexes[account.Key] = len(unique)
+		unique = append(unique, account)
+	}
+
+	err := self.customerRepository.SaveAccounts(ctx, unique)
+	if err != nil {
+		return err
+	}
+
+	return self.enrich(ctx, productID)
+}
+
+func (self *CustomerEndpoints) enrich(ctx context.Context, productID string) error {
+	// Not unique, as an enrichment already running could have read the previous accounts
+	return self.enqueuer.Enqueue(ctx, EnricherEnrich, EnricherEnrichParams{
+		ProductID: productID,
+	}, asynq.MaxRetry(2))
+}
@@ -0,0 +1,144 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/outbox"
+	"backend/pkg/prioritization"
+	"backend/pkg/product"
+)
+
+const (
+	EnricherEnrich   = "customer:enrich"
+	EnricherSchedule = "customer:schedule-enrich"
+)
+
+var (
+	ErrEnricherGeneric = errors.New("enricher failed")
+)
+
+type Enricher struct {
+	config             config.Config
+	observer           *kit.Observer
+	productRepository  *product.ProductRepository
+	customerRepository *CustomerRepository
+	enqueuer           *outbox.OutboxEnqueuer
+}
+
+func NewEnricher(observer *kit.Observer, productRepository *product.ProductRepository,
+	customerRepository *CustomerRepository, enqueuer *outbox.OutboxEnqueuer, config config.Config) *Enricher {
+	return &Enricher{
+		config:             config,
+		observer:           observer,
+		productRepository:  productRepository,
+		customerRepository: customerRepository,
+		enqueuer:           enqueuer,
+	}
+}
+
+// Run joins the imported accounts of a product to its customers and recomputes the revenue at risk of its issues
+// and suggestions, reprioritizing them if the priority formula of the product depends on it.
+func (self *Enricher) Run(ctx context.Context, _product product.Product) (int, error) {
+	accounts, err := self.customerRepository.ListAccountsByProductID(ctx, _product.ID)
+	if err != nil {
+		return 0, ErrEnricherGeneric.Raise().Cause(err)
+	}
+
+	keys := []string{}
+	for _, account := range accounts {
+		keys = append(keys, account.IdentityKeys()...)
+	}
+
+	identities, err := self.customerRepository.ListIdentitiesByKeys(ctx, _product.ID, keys)
+	if err != nil {
+		return 0, ErrEnricherGeneric.Raise().Cause(err)
+	}
+
+	enrichment := NewCustomerEnrichment(accounts, identities)
+
+	err = self.customerRepository.UpdateEnrichment(ctx, _product.ID, enrichment)
+	if err != nil {
+		return 0, ErrEnricherGeneric.Raise().Cause(err)
+	}
+
+	err = self.customerRepository.UpdateRevenuesAtRisk(ctx, _product.ID)
+	if err != nil {
+		return 0, ErrEnricherGeneric.Raise().Cause(err)
+	}
+
+	if _product.PriorityFormula().RevenueWeight > 0 {
+		err = self.enqueuer.Enqueue(ctx, prioritization.PrioritizerPrioritize,
+			prioritization.PrioritizerPrioritizeParams{
+				ProductID: _product.ID,
+			}, asynq.MaxRetry(2))
+		if err != nil {
+			return 0, ErrEnricherGeneric.Raise().Cause(err)
+		}
+	}
+
+	self.observer.Infof(ctx, "Enriched %d customers with %d accounts of product %s",
+		len(enrichment), len(accounts), _product.ID)
+
+	return len(enrichment), nil
+}
+
+type EnricherEnrichParams struct {
+	ProductID string
+}
+
+func (self *Enricher) Enrich(ctx context.Context, task *asynq.Task) error {
+	params := EnricherEnrichParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	product, err := self.productRepository.GetByID(ctx, params.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	if product.DeletedAt != nil {
+		return nil
+	}
+
+	_, err = self.Run(ctx, *product)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Schedule enriches the products with imported accounts, as their customers and the feedbacks of their issues and
+// suggestions keep changing even if no account is imported.
+func (self *Enricher) Schedule(ctx context.Context, _ *asynq.Task) error {
+	ids, err := self.customerRepository.ListProductIDsWithAccounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := self.enqueuer.Enqueue(ctx, EnricherEnrich, EnricherEnrichParams{
+			ProductID: id,
+		}, asynq.MaxRetry(2), asynq.Unique(1*time.Hour))
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	return nil
+}
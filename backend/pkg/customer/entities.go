@@ -0,0 +1,477 @@
+package customer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neoxelox/errors"
+	"github.com/rs/xid"
+
+	"backend/pkg/feedback"
+)
+
+const (
+	// Feedbacks without a customer resolved at most on every run, oldest first
+	CUSTOMER_RESOLVING_MAX_FEEDBACKS = 10000
+	// Other customers merged at most at once into a customer
+	CUSTOMER_MERGING_MAX_CUSTOMERS = 25
+	// Issues and suggestions reported by a customer shown at most on their profile, most recent first
+	CUSTOMER_PROFILE_MAX_REPORTS = 50
+	// Accounts imported at most at once
+	CUSTOMER_ACCOUNT_IMPORTING_MAX_ACCOUNTS = 10000
+	CUSTOMER_ACCOUNT_MAX_VALUE_LENGTH       = 100
+	// Resolutions and merges of the customers of a product run one at a time under this lock
+	CUSTOMER_LOCK_KEY = "customer:%s"
+)
+
+const (
+	CustomerIdentityEmail        = "EMAIL"
+	CustomerIdentityLink         = "LINK"
+	CustomerIdentityNameLocation = "NAME_LOCATION"
+)
+
+type Customer struct {
+	ID          string
+	ProductID   string
+	Name        string
+	Email       *string
+	Picture     string
+	Location    *string
+	Sources     []string
+	Feedbacks   int
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+	// Attributes of the account of the customer in the CRM, if any
+	ARR          *int
+	Plan         *string
+	AccountOwner *string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func NewCustomer() *Customer {
+	return &Customer{}
+}
+
+func (self Customer) String() string {
+	return fmt.Sprintf("<Customer: %s (%s)>", self.Name, self.ID)
+}
+
+func (self Customer) Equals(other Customer) bool {
+	return self.ID == other.ID
+}
+
+// See accounts a feedback of the customer, whose details are the ones of their most recent feedback.
+func (self *Customer) See(_feedback feedback.Feedback) {
+	if !slices.Contains(self.Sources, _feedback.Source) {
+		self.Sources = append(self.Sources, _feedback.Source)
+	}
+
+	if self.Feedbacks == 0 || _feedback.PostedAt.Before(self.FirstSeenAt) {
+		self.FirstSeenAt = _feedback.PostedAt
+	}
+
+	if self.Feedbacks == 0 || !_feedback.PostedAt.Before(self.LastSeenAt) {
+		self.LastSeenAt = _feedback.PostedAt
+		self.Name = _feedback.Customer.Name
+		self.Picture = _feedback.Customer.Picture
+
+		if _feedback.Customer.Email != nil {
+			self.Email = _feedback.Customer.Email
+		}
+
+		if _feedback.Customer.Location != nil {
+			self.Location = _feedback.Customer.Location
+		}
+	}
+
+	self.Feedbacks++
+}
+
+// Merge accounts the feedbacks of another customer found to be the same one.
+func (self *Customer) Merge(other Customer) {
+	for _, source := range other.Sources {
+		if !slices.Contains(self.Sources, source) {
+			self.Sources = append(self.Sources, source)
+		}
+	}
+
+	if other.FirstSeenAt.Before(self.FirstSeenAt) {
+		self.FirstSeenAt = other.FirstSeenAt
+	}
+
+	if other.LastSeenAt.After(self.LastSeenAt) {
+		self.LastSeenAt = other.LastSeenAt
+		self.Name = other.Name
+		self.Picture = other.Picture
+
+		if other.Email != nil {
+			self.Email = other.Email
+		}
+
+		if other.Location != nil {
+			self.Location = other.Location
+		}
+	}
+
+	if self.Email == nil {
+		self.Email = other.Email
+	}
+
+	if self.Location == nil {
+		self.Location = other.Location
+	}
+
+	self.Feedbacks += other.Feedbacks
+}
+
+type CustomerIdentity struct {
+	ProductID  string
+	Key        string
+	CustomerID string
+}
+
+// normalize lowercases the text and strips its punctuation marks and redundant spaces.
+func normalize(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(feedback.PUNCTUATION_MARKS, r) {
+			return ' '
+		}
+
+		return r
+	}, strings.ToLower(text))
+
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// NewCustomerIdentityKeys returns the keys identifying the customer of a feedback, the most reliable first: their
+// email, their user within the source and their name along with their location. Customers matching any key are
+// the same one.
+func NewCustomerIdentityKeys(_feedback feedback.Feedback) []string {
+	keys := []string{}
+
+	if _feedback.Customer.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*_feedback.Customer.Email))
+		if strings.Contains(email, "@") {
+			keys = append(keys, CustomerIdentityEmail+":"+email)
+		}
+	}
+
+	// The link to the profile of the customer is the only user id sources expose
+	if _feedback.Customer.Link != nil {
+		link := strings.TrimSpace(*_feedback.Customer.Link)
+		if len(link) > 0 {
+			keys = append(keys, CustomerIdentityLink+":"+_feedback.Source+":"+link)
+		}
+	}
+
+	if _feedback.Customer.Location != nil {
+		name := normalize(_feedback.Customer.Name)
+		location := normalize(*_feedback.Customer.Location)
+		if len(name) > 0 && len(location) > 0 {
+			keys = append(keys, CustomerIdentityNameLocation+":"+name+"|"+location)
+		}
+	}
+
+	return keys
+}
+
+// CustomerResolution matches feedbacks to the customers sharing any of their identity keys, creating a new
+// customer for the feedbacks not matching any. The matching is deterministic: the key that matched first, in order
+// of reliability, decides the customer, and the rest of the keys become identities of that customer unless they
+// already identify another one, which can still be merged by hand.
+type CustomerResolution struct {
+	ProductID  string
+	Customers  map[string]*Customer
+	Created    map[string]bool
+	Identities []CustomerIdentity
+	Feedbacks  map[string]string
+	keys       map[string]string
+}
+
+func NewCustomerResolution(productID string, customers []Customer,
+	identities []CustomerIdentity) *CustomerResolution {
+	resolution := &CustomerResolution{
+		ProductID:  productID,
+		Customers:  make(map[string]*Customer, len(customers)),
+		Created:    map[string]bool{},
+		Identities: []CustomerIdentity{},
+		Feedbacks:  map[string]string{},
+		keys:       make(map[string]string, len(identities)),
+	}
+
+	for _, customer := range customers {
+		resolution.Customers[customer.ID] = &customer
+	}
+
+	for _, identity := range identities {
+		resolution.keys[identity.Key] = identity.CustomerID
+	}
+
+	return resolution
+}
+
+// Resolve matches the feedback to its customer, returning it.
+func (self *CustomerResolution) Resolve(_feedback feedback.Feedback, now time.Time) *Customer {
+	keys := NewCustomerIdentityKeys(_feedback)
+
+	var customer *Customer
+	for _, key := range keys {
+		if id, ok := self.keys[key]; ok && self.Customers[id] != nil {
+			customer = self.Customers[id]
+			break
+		}
+	}
+
+	if customer == nil {
+		customer = NewCustomer()
+		customer.ID = xid.New().String()
+		customer.ProductID = self.ProductID
+		customer.Sources = []string{}
+		customer.CreatedAt = now
+		self.Customers[customer.ID] = customer
+		self.Created[customer.ID] = true
+	}
+
+	customer.See(_feedback)
+	customer.UpdatedAt = now
+
+	for _, key := range keys {
+		if _, ok := self.keys[key]; ok {
+			continue
+		}
+
+		self.keys[key] = customer.ID
+		self.Identities = append(self.Identities, CustomerIdentity{
+			ProductID:  self.ProductID,
+			Key:        key,
+			CustomerID: customer.ID,
+		})
+	}
+
+	self.Feedbacks[_feedback.ID] = customer.ID
+
+	return customer
+}
+
+// CustomerSentiment is the count of the reviewed feedbacks of a customer by sentiment within a period.
+type CustomerSentiment struct {
+	Period   time.Time
+	Positive int
+	Neutral  int
+	Negative int
+}
+
+const (
+	CustomerAccountKeyEmail    = "EMAIL"
+	CustomerAccountKeyExternal = "EXTERNAL"
+)
+
+// Sources whose customer links are the ids of the users within the product itself, which is what accounts keyed
+// by external id refer to
+var CustomerAccountExternalSources = []string{feedback.FeedbackSourceWebhook, feedback.FeedbackSourceWidget}
+
+var (
+	ErrCustomerAccountInvalid = errors.New("customer account is invalid")
+)
+
+// CustomerAccount holds the attributes of a customer in the CRM of the organization, keyed by their email or by
+// their id within the product, so they can be joined to the customers of the feedbacks.
+type CustomerAccount struct {
+	ProductID string
+	Key       string
+	ARR       *int
+	Plan      *string
+	Owner     *string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func optional(value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	_value := strings.TrimSpace(*value)
+	if len(_value) == 0 {
+		return nil, nil
+	}
+
+	if len(_value) > CUSTOMER_ACCOUNT_MAX_VALUE_LENGTH {
+		return nil, ErrCustomerAccountInvalid.Raise().With("value %s is too long", _value)
+	}
+
+	return &_value, nil
+}
+
+// NewCustomerAccount normalizes and validates an account, which is keyed by the email when it has both.
+func NewCustomerAccount(productID string, email *string, externalID *string, arr *int, plan *string,
+	owner *string, now time.Time) (*CustomerAccount, error) {
+	account := &CustomerAccount{
+		ProductID: productID,
+		ARR:       arr,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	email, err := optional(email)
+	if err != nil {
+		return nil, err
+	}
+
+	externalID, err = optional(externalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if email != nil {
+		if !strings.Contains(*email, "@") {
+			return nil, ErrCustomerAccountInvalid.Raise().With("email %s is invalid", *email)
+		}
+
+		account.Key = CustomerAccountKeyEmail + ":" + strings.ToLower(*email)
+	} else if externalID != nil {
+		account.Key = CustomerAccountKeyExternal + ":" + *externalID
+	} else {
+		return nil, ErrCustomerAccountInvalid.Raise().With("email or external id is missing")
+	}
+
+	if arr != nil && *arr < 0 {
+		return nil, ErrCustomerAccountInvalid.Raise().With("arr %d is negative", *arr)
+	}
+
+	account.Plan, err = optional(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Owner, err = optional(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (self CustomerAccount) String() string {
+	return fmt.Sprintf("<CustomerAccount: %s (%s)>", self.Key, self.ProductID)
+}
+
+// IdentityKeys returns the identity keys of the customers the account is of.
+func (self CustomerAccount) IdentityKeys() []string {
+	if strings.HasPrefix(self.Key, CustomerAccountKeyEmail+":") {
+		return []string{CustomerIdentityEmail + ":" + strings.TrimPrefix(self.Key, CustomerAccountKeyEmail+":")}
+	}
+
+	keys := make([]string, 0, len(CustomerAccountExternalSources))
+	for _, source := range CustomerAccountExternalSources {
+		keys = append(keys, CustomerIdentityLink+":"+source+":"+
+			strings.TrimPrefix(self.Key, CustomerAccountKeyExternal+":"))
+	}
+
+	return keys
+}
+
+// NewCustomerAccountsFromCSV parses the accounts of a CSV whose header names its columns: email and external_id,
+// at least one of them, and any of arr, plan and account_owner. Empty cells are missing values.
+func NewCustomerAccountsFromCSV(productID string, reader io.Reader, now time.Time) ([]CustomerAccount, error) {
+	rows, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, ErrCustomerAccountInvalid.Raise().Cause(err)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrCustomerAccountInvalid.Raise().With("header is missing")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	_, hasEmail := columns["email"]
+	_, hasExternalID := columns["external_id"]
+	if !hasEmail && !hasExternalID {
+		return nil, ErrCustomerAccountInvalid.Raise().With("email or external_id column is missing")
+	}
+
+	if len(rows)-1 > CUSTOMER_ACCOUNT_IMPORTING_MAX_ACCOUNTS {
+		return nil, ErrCustomerAccountInvalid.Raise().With("too many accounts")
+	}
+
+	cell := func(row []string, column string) *string {
+		i, ok := columns[column]
+		if !ok || i >= len(row) {
+			return nil
+		}
+
+		return &row[i]
+	}
+
+	accounts := make([]CustomerAccount, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		var arr *int
+		if value, _ := optional(cell(row, "arr")); value != nil {
+			_arr, err := strconv.ParseFloat(strings.ReplaceAll(*value, ",", ""), 64)
+			if err != nil {
+				return nil, ErrCustomerAccountInvalid.Raise().With("arr of line %d is invalid", line+2).Cause(err)
+			}
+
+			rounded := int(math.Round(_arr))
+			arr = &rounded
+		}
+
+		account, err := NewCustomerAccount(productID, cell(row, "email"), cell(row, "external_id"), arr,
+			cell(row, "plan"), cell(row, "account_owner"), now)
+		if err != nil {
+			return nil, ErrCustomerAccountInvalid.Raise().With("line %d is invalid", line+2).Cause(err)
+		}
+
+		accounts = append(accounts, *account)
+	}
+
+	return accounts, nil
+}
+
+// NewCustomerEnrichment matches the accounts to the customers identified by them. A customer with several
+// accounts gets the one keyed by their email or else the most recently updated one.
+func NewCustomerEnrichment(accounts []CustomerAccount, identities []CustomerIdentity) map[string]CustomerAccount {
+	customers := make(map[string]string, len(identities))
+	for _, identity := range identities {
+		customers[identity.Key] = identity.CustomerID
+	}
+
+	enrichment := map[string]CustomerAccount{}
+	for _, account := range accounts {
+		for _, key := range account.IdentityKeys() {
+			id, ok := customers[key]
+			if !ok {
+				continue
+			}
+
+			current, ok := enrichment[id]
+			if ok {
+				currentByEmail := strings.HasPrefix(current.Key, CustomerAccountKeyEmail+":")
+				accountByEmail := strings.HasPrefix(account.Key, CustomerAccountKeyEmail+":")
+
+				if currentByEmail != accountByEmail {
+					if currentByEmail {
+						continue
+					}
+				} else if !account.UpdatedAt.After(current.UpdatedAt) {
+					continue
+				}
+			}
+
+			enrichment[id] = account
+		}
+	}
+
+	return enrichment
+}
@@ -0,0 +1,163 @@
+package customer_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend/pkg/customer"
+	"backend/pkg/feedback"
+
+	"github.com/neoxelox/kit/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type CustomerTestSuite struct {
+	suite.Suite
+}
+
+func TestCustomerSuite(t *testing.T) {
+	suite.Run(t, new(CustomerTestSuite))
+}
+
+func (self *CustomerTestSuite) newFeedback(id string, source string, name string, email *string,
+	location *string, link *string, postedAt time.Time) feedback.Feedback {
+	_feedback := feedback.NewFeedback()
+	_feedback.ID = id
+	_feedback.Source = source
+	_feedback.Customer = feedback.FeedbackCustomer{
+		Email:    email,
+		Name:     name,
+		Location: location,
+		Link:     link,
+	}
+	_feedback.PostedAt = postedAt
+
+	return *_feedback
+}
+
+func (self *CustomerTestSuite) TestNewCustomerIdentityKeys() {
+	// Given: A feedback with every identity of its customer, written with different formats
+	_feedback := self.newFeedback("1", feedback.FeedbackSourceTrustpilot, " Jane  O'Brien ",
+		util.Pointer(" Jane@Acme.com"), util.Pointer("ES"), util.Pointer("https://trustpilot.com/users/1"),
+		time.Now())
+
+	// When: The identity keys are computed
+	keys := customer.NewCustomerIdentityKeys(_feedback)
+
+	// Then: The keys are normalized, the most reliable first
+	self.Equal([]string{
+		"EMAIL:jane@acme.com",
+		"LINK:TRUSTPILOT:https://trustpilot.com/users/1",
+		"NAME_LOCATION:jane o brien|es",
+	}, keys)
+}
+
+func (self *CustomerTestSuite) TestResolveMatchesAcrossSources() {
+	// Given: The same customer on different sources and a stored customer known by their email
+	now := time.Now()
+	stored := customer.NewCustomer()
+	stored.ID = "stored"
+	stored.Sources = []string{feedback.FeedbackSourceWidget}
+	stored.Feedbacks = 1
+	stored.FirstSeenAt = now.Add(-48 * time.Hour)
+	stored.LastSeenAt = stored.FirstSeenAt
+	resolution := customer.NewCustomerResolution("product", []customer.Customer{*stored},
+		[]customer.CustomerIdentity{{ProductID: "product", Key: "EMAIL:jane@acme.com", CustomerID: "stored"}})
+
+	// When: Their feedbacks are resolved
+	first := resolution.Resolve(self.newFeedback("1", feedback.FeedbackSourceTrustpilot, "Jane",
+		util.Pointer("jane@acme.com"), util.Pointer("Spain"), nil, now.Add(-24*time.Hour)), now)
+	second := resolution.Resolve(self.newFeedback("2", feedback.FeedbackSourceAmazon, "JANE",
+		nil, util.Pointer("spain"), util.Pointer("amazon/1"), now), now)
+	third := resolution.Resolve(self.newFeedback("3", feedback.FeedbackSourceAppStore, "Jane",
+		nil, nil, nil, now), now)
+
+	// Then: The feedbacks with a shared identity are the stored customer's and the rest a new one's
+	self.Equal("stored", first.ID)
+	self.Equal("stored", second.ID)
+	self.NotEqual("stored", third.ID)
+	self.Equal(3, first.Feedbacks)
+	self.Equal("JANE", first.Name)
+	self.ElementsMatch([]string{feedback.FeedbackSourceWidget, feedback.FeedbackSourceTrustpilot,
+		feedback.FeedbackSourceAmazon}, first.Sources)
+	self.Len(resolution.Identities, 2)
+	self.Equal(map[string]bool{third.ID: true}, resolution.Created)
+	self.Equal(map[string]string{"1": "stored", "2": "stored", "3": third.ID}, resolution.Feedbacks)
+}
+
+func (self *CustomerTestSuite) TestMergeKeepsLatestDetails() {
+	// Given: Two customers that are the same one
+	now := time.Now()
+	entity := customer.NewCustomer()
+	entity.Name = "Jane"
+	entity.Email = util.Pointer("jane@acme.com")
+	entity.Sources = []string{feedback.FeedbackSourceWidget}
+	entity.Feedbacks = 2
+	entity.FirstSeenAt = now.Add(-24 * time.Hour)
+	entity.LastSeenAt = now.Add(-24 * time.Hour)
+	other := customer.NewCustomer()
+	other.Name = "Jane O."
+	other.Location = util.Pointer("Spain")
+	other.Sources = []string{feedback.FeedbackSourceTrustpilot}
+	other.Feedbacks = 3
+	other.FirstSeenAt = now.Add(-48 * time.Hour)
+	other.LastSeenAt = now
+
+	// When: The other customer is merged into the customer
+	entity.Merge(*other)
+
+	// Then: The customer has the feedbacks of both and the most recent details
+	self.Equal(5, entity.Feedbacks)
+	self.Equal("Jane O.", entity.Name)
+	self.Equal("jane@acme.com", *entity.Email)
+	self.Equal("Spain", *entity.Location)
+	self.Equal(other.FirstSeenAt, entity.FirstSeenAt)
+	self.Equal(other.LastSeenAt, entity.LastSeenAt)
+	self.Equal([]string{feedback.FeedbackSourceWidget, feedback.FeedbackSourceTrustpilot}, entity.Sources)
+}
+
+func (self *CustomerTestSuite) TestNewCustomerAccountsFromCSV() {
+	// Given: A CSV export of a CRM keyed by email or by external id
+	reader := strings.NewReader("Email,External_ID,ARR,Plan,Account_Owner\n" +
+		" Jane@Acme.com ,user-1,\"12,000.4\",Enterprise,Bob\n" +
+		",user-2,,Free,\n")
+
+	// When: The accounts are parsed
+	accounts, err := customer.NewCustomerAccountsFromCSV("product", reader, time.Now())
+
+	// Then: Every row is an account keyed by its email or else by its external id
+	self.Require().NoError(err)
+	self.Require().Len(accounts, 2)
+	self.Equal("EMAIL:jane@acme.com", accounts[0].Key)
+	self.Equal(12000, *accounts[0].ARR)
+	self.Equal("Enterprise", *accounts[0].Plan)
+	self.Equal("Bob", *accounts[0].Owner)
+	self.Equal("EXTERNAL:user-2", accounts[1].Key)
+	self.Nil(accounts[1].ARR)
+	self.Nil(accounts[1].Owner)
+	self.Equal([]string{"LINK:WEBHOOK:user-2", "LINK:WIDGET:user-2"}, accounts[1].IdentityKeys())
+}
+
+func (self *CustomerTestSuite) TestEnrichmentPrefersEmailAccounts() {
+	// Given: A customer known by their email and their external id, with an account for each
+	now := time.Now()
+	byExternalID, err := customer.NewCustomerAccount("product", nil, util.Pointer("user-1"), util.Pointer(500),
+		nil, nil, now)
+	self.Require().NoError(err)
+	byEmail, err := customer.NewCustomerAccount("product", util.Pointer("jane@acme.com"), nil, util.Pointer(100),
+		nil, nil, now.Add(-time.Hour))
+	self.Require().NoError(err)
+	identities := []customer.CustomerIdentity{
+		{ProductID: "product", Key: "EMAIL:jane@acme.com", CustomerID: "jane"},
+		{ProductID: "product", Key: "LINK:WIDGET:user-1", CustomerID: "jane"},
+		{ProductID: "product", Key: "EMAIL:john@acme.com", CustomerID: "john"},
+	}
+
+	// When: The accounts are matched to the customers
+	enrichment := customer.NewCustomerEnrichment([]customer.CustomerAccount{*byExternalID, *byEmail}, identities)
+
+	// Then: The customer gets the account keyed by their email, even if older, and the rest none
+	self.Len(enrichment, 1)
+	self.Equal(100, *enrichment["jane"].ARR)
+}
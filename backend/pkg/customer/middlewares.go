@@ -0,0 +1,58 @@
+package customer
+
+import (
+	"context"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+)
+
+var (
+	KeyRequestCustomer kit.Key = kit.KeyBase + "request:customer"
+)
+
+func RequestCustomer(ctx context.Context) *Customer {
+	return ctx.Value(KeyRequestCustomer).(*Customer) // nolint:forcetypeassert,errcheck
+}
+
+type CustomerMiddlewares struct {
+	config             config.Config
+	observer           *kit.Observer
+	customerRepository *CustomerRepository
+}
+
+func NewCustomerMiddlewares(observer *kit.Observer, customerRepository *CustomerRepository,
+	config config.Config) *CustomerMiddlewares {
+	return &CustomerMiddlewares{
+		config:             config,
+		observer:           observer,
+		customerRepository: customerRepository,
+	}
+}
+
+func (self *CustomerMiddlewares) HandleCustomer(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		requestCtx := ctx.Request().Context()
+		requestProduct := product.RequestProduct(requestCtx)
+
+		customer, err := self.customerRepository.GetByID(requestCtx, ctx.Param("customer_id"))
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+
+		if customer == nil {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		if customer.ProductID != requestProduct.ID {
+			return kit.HTTPErrUnauthorized
+		}
+
+		ctx.SetRequest(ctx.Request().WithContext(context.WithValue(requestCtx, KeyRequestCustomer, customer)))
+
+		return next(ctx)
+	}
+}
@@ -0,0 +1,126 @@
+package customer
+
+import (
+	"time"
+)
+
+const (
+	CUSTOMER_MODEL_TABLE          = "\"customer\""
+	CUSTOMER_IDENTITY_MODEL_TABLE = "\"customer_identity\""
+	CUSTOMER_FEEDBACK_MODEL_TABLE = "\"customer_feedback\""
+	CUSTOMER_ACCOUNT_MODEL_TABLE  = "\"customer_account\""
+)
+
+type CustomerModel struct {
+	ID           string    `db:"id"`
+	ProductID    string    `db:"product_id"`
+	Name         string    `db:"name"`
+	Email        *string   `db:"email"`
+	Picture      string    `db:"picture"`
+	Location     *string   `db:"location"`
+	Sources      []string  `db:"sources"`
+	Feedbacks    int       `db:"feedbacks"`
+	FirstSeenAt  time.Time `db:"first_seen_at"`
+	LastSeenAt   time.Time `db:"last_seen_at"`
+	ARR          *int      `db:"arr"`
+	Plan         *string   `db:"plan"`
+	AccountOwner *string   `db:"account_owner"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+func NewCustomerModel(customer Customer) *CustomerModel {
+	return &CustomerModel{
+		ID:           customer.ID,
+		ProductID:    customer.ProductID,
+		Name:         customer.Name,
+		Email:        customer.Email,
+		Picture:      customer.Picture,
+		Location:     customer.Location,
+		Sources:      customer.Sources,
+		Feedbacks:    customer.Feedbacks,
+		FirstSeenAt:  customer.FirstSeenAt,
+		LastSeenAt:   customer.LastSeenAt,
+		ARR:          customer.ARR,
+		Plan:         customer.Plan,
+		AccountOwner: customer.AccountOwner,
+		CreatedAt:    customer.CreatedAt,
+		UpdatedAt:    customer.UpdatedAt,
+	}
+}
+
+func (self *CustomerModel) ToEntity() *Customer {
+	return &Customer{
+		ID:           self.ID,
+		ProductID:    self.ProductID,
+		Name:         self.Name,
+		Email:        self.Email,
+		Picture:      self.Picture,
+		Location:     self.Location,
+		Sources:      self.Sources,
+		Feedbacks:    self.Feedbacks,
+		FirstSeenAt:  self.FirstSeenAt,
+		LastSeenAt:   self.LastSeenAt,
+		ARR:          self.ARR,
+		Plan:         self.Plan,
+		AccountOwner: self.AccountOwner,
+		CreatedAt:    self.CreatedAt,
+		UpdatedAt:    self.UpdatedAt,
+	}
+}
+
+type CustomerIdentityModel struct {
+	ProductID  string `db:"product_id"`
+	Key        string `db:"key"`
+	CustomerID string `db:"customer_id"`
+}
+
+func NewCustomerIdentityModel(identity CustomerIdentity) *CustomerIdentityModel {
+	return &CustomerIdentityModel{
+		ProductID:  identity.ProductID,
+		Key:        identity.Key,
+		CustomerID: identity.CustomerID,
+	}
+}
+
+func (self *CustomerIdentityModel) ToEntity() *CustomerIdentity {
+	return &CustomerIdentity{
+		ProductID:  self.ProductID,
+		Key:        self.Key,
+		CustomerID: self.CustomerID,
+	}
+}
+
+type CustomerAccountModel struct {
+	ProductID string    `db:"product_id"`
+	Key       string    `db:"key"`
+	ARR       *int      `db:"arr"`
+	Plan      *string   `db:"plan"`
+	Owner     *string   `db:"owner"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func NewCustomerAccountModel(account CustomerAccount) *CustomerAccountModel {
+	return &CustomerAccountModel{
+		ProductID: account.ProductID,
+		Key:       account.Key,
+		ARR:       account.ARR,
+		Plan:      account.Plan,
+		Owner:     account.Owner,
+		CreatedAt: account.CreatedAt,
+		UpdatedAt: account.UpdatedAt,
+	}
+}
+
+func (self *CustomerAccountModel) ToEntity() *CustomerAccount {
+	return &CustomerAccount{
+		ProductID: self.ProductID,
+		Key:       self.Key,
+		ARR:       self.ARR,
+		Plan:      self.Plan,
+		Owner:     self.Owner,
+		CreatedAt: self.CreatedAt,
+		UpdatedAt: self.UpdatedAt,
+	}
+}
@@ -0,0 +1,59 @@
+package customer
+
+import (
+	"time"
+)
+
+type CustomerPayload struct {
+	ID           string    `json:"id"`
+	ProductID    string    `json:"product_id"`
+	Name         string    `json:"name"`
+	Email        *string   `json:"email"`
+	Picture      string    `json:"picture"`
+	Location     *string   `json:"location"`
+	Sources      []string  `json:"sources"`
+	Feedbacks    int       `json:"feedbacks"`
+	FirstSeenAt  time.Time `json:"first_seen_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+	ARR          *int      `json:"arr"`
+	Plan         *string   `json:"plan"`
+	AccountOwner *string   `json:"account_owner"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func NewCustomerPayload(customer Customer) *CustomerPayload {
+	return &CustomerPayload{
+		ID:           customer.ID,
+		ProductID:    customer.ProductID,
+		Name:         customer.Name,
+		Email:        customer.Email,
+		Picture:      customer.Picture,
+		Location:     customer.Location,
+		Sources:      customer.Sources,
+		Feedbacks:    customer.Feedbacks,
+		FirstSeenAt:  customer.FirstSeenAt,
+		LastSeenAt:   customer.LastSeenAt,
+		ARR:          customer.ARR,
+		Plan:         customer.Plan,
+		AccountOwner: customer.AccountOwner,
+		CreatedAt:    customer.CreatedAt,
+		UpdatedAt:    customer.UpdatedAt,
+	}
+}
+
+type CustomerSentimentPayload struct {
+	Period   time.Time `json:"period"`
+	Positive int       `json:"positive"`
+	Neutral  int       `json:"neutral"`
+	Negative int       `json:"negative"`
+}
+
+func NewCustomerSentimentPayload(sentiment CustomerSentiment) *CustomerSentimentPayload {
+	return &CustomerSentimentPayload{
+		Period:   sentiment.Period,
+		Positive: sentiment.Positive,
+		Neutral:  sentiment.Neutral,
+		Negative: sentiment.Negative,
+	}
+}
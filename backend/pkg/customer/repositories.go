@@ -0,0 +1,717 @@
+package customer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/review"
+	"backend/pkg/suggestion"
+	"backend/pkg/util"
+)
+
+const (
+	CUSTOMER_REPOSITORY_BATCH_SIZE = 1000
+)
+
+type CustomerRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewCustomerRepository(observer *kit.Observer, database *kit.Database,
+	config config.Config) *CustomerRepository {
+	return &CustomerRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *CustomerRepository) GetByID(ctx context.Context, id string) (*Customer, error) {
+	var c CustomerModel
+
+	stmt := sqlf.
+		Select("*").To(&c).
+		From(CUSTOMER_MODEL_TABLE).
+		Where("id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return c.ToEntity(), nil
+}
+
+func (self *CustomerRepository) ListByIDs(ctx context.Context, ids []string) ([]Customer, error) {
+	var cs []CustomerModel
+
+	if len(ids) == 0 {
+		return []Customer{}, nil
+	}
+
+	stmt := sqlf.
+		Select("*").To(&cs).
+		From(CUSTOMER_MODEL_TABLE).
+		Where("id").In(util.Spread(ids)...)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []Customer{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]Customer, 0, len(cs))
+	for _, c := range cs {
+		entities = append(entities, *c.ToEntity())
+	}
+
+	return entities, nil
+}
+
+// ListByProductID returns the customers of a product, the most recently seen first.
+func (self *CustomerRepository) ListByProductID(ctx context.Context,
+	productID string, pagination util.Pagination[time.Time]) (*util.Page[Customer, time.Time], error) {
+	var cs []CustomerModel
+
+	stmt := sqlf.
+		Select("*").To(&cs).
+		From(CUSTOMER_MODEL_TABLE).
+		Where("product_id = ?", productID)
+
+	if pagination.From != nil {
+		stmt.Where("(last_seen_at, id) < (?, ?)", pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy("last_seen_at DESC", "id DESC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[Customer, time.Time]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]Customer, 0, len(cs))
+	for _, c := range cs {
+		items = append(items, *c.ToEntity())
+	}
+
+	var cursor *util.Cursor[time.Time]
+	if len(items) == pagination.Limit {
+		cursor = &util.Cursor[time.Time]{
+			Value: items[pagination.Limit-1].LastSeenAt,
+			ID:    items[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[Customer, time.Time]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
+
+// ListUnresolvedFeedbacks returns the feedbacks of a product not matched to any customer yet, the oldest first.
+func (self *CustomerRepository) ListUnresolvedFeedbacks(ctx context.Context,
+	productID string, limit int) ([]feedback.Feedback, error) {
+	var fs []feedback.FeedbackModel
+
+	stmt := sqlf.
+		Select(feedback.FEEDBACK_MODEL_TABLE+".*").To(&fs).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".product_id = ?", productID).
+		Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s WHERE %s.feedback_id = %s.id)",
+			CUSTOMER_FEEDBACK_MODEL_TABLE, CUSTOMER_FEEDBACK_MODEL_TABLE, feedback.FEEDBACK_MODEL_TABLE)).
+		OrderBy(feedback.FEEDBACK_MODEL_TABLE+".posted_at ASC", feedback.FEEDBACK_MODEL_TABLE+".id ASC").
+		Limit(limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []feedback.Feedback{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]feedback.Feedback, 0, len(fs))
+	for _, f := range fs {
+		entities = append(entities, *f.ToEntity())
+	}
+
+	return entities, nil
+}
+
+func (self *CustomerRepository) ListIdentitiesByKeys(ctx context.Context,
+	productID string, keys []string) ([]CustomerIdentity, error) {
+	entities := make([]CustomerIdentity, 0, len(keys))
+
+	for start := 0; start < len(keys); start += CUSTOMER_REPOSITORY_BATCH_SIZE {
+		batch := keys[start:min(start+CUSTOMER_REPOSITORY_BATCH_SIZE, len(keys))]
+
+		var is []CustomerIdentityModel
+
+		stmt := sqlf.
+			Select("*").To(&is).
+			From(CUSTOMER_IDENTITY_MODEL_TABLE).
+			Where("product_id = ?", productID).
+			Where("key").In(util.Spread(batch)...)
+
+		err := self.database.Query(ctx, stmt)
+		if err != nil && !kit.ErrDatabaseNoRows.Is(err) {
+			return nil, err
+		}
+
+		for _, i := range is {
+			entities = append(entities, *i.ToEntity())
+		}
+	}
+
+	return entities, nil
+}
+
+// SaveResolution stores the customers of a resolution along with their new identities and the feedbacks matched
+// to them. Customers already stored are updated with the feedbacks they have been seen on since.
+func (self *CustomerRepository) SaveResolution(ctx context.Context, resolution CustomerResolution) error {
+	customers := make([]Customer, 0, len(resolution.Customers))
+	for _, customer := range resolution.Customers {
+		customers = append(customers, *customer)
+	}
+
+	feedbackIDs := make([]string, 0, len(resolution.Feedbacks))
+	for feedbackID := range resolution.Feedbacks {
+		feedbackIDs = append(feedbackIDs, feedbackID)
+	}
+
+	return self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		for start := 0; start < len(customers); start += CUSTOMER_REPOSITORY_BATCH_SIZE {
+			batch := customers[start:min(start+CUSTOMER_REPOSITORY_BATCH_SIZE, len(customers))]
+
+			stmt := sqlf.
+				InsertInto(CUSTOMER_MODEL_TABLE)
+
+			for _, customer := range batch {
+				c := NewCustomerModel(customer)
+
+				stmt.
+					NewRow().
+					Set("id", c.ID).
+					Set("product_id", c.ProductID).
+					Set("name", c.Name).
+					Set("email", c.Email).
+					Set("picture", c.Picture).
+					Set("location", c.Location).
+					Set("sources", c.Sources).
+					Set("feedbacks", c.Feedbacks).
+					Set("first_seen_at", c.FirstSeenAt).
+					Set("last_seen_at", c.LastSeenAt).
+					Set("created_at", c.CreatedAt).
+					Set("updated_at", c.UpdatedAt)
+			}
+
+			stmt.
+				Clause("ON CONFLICT (id) DO UPDATE SET " +
+					"name = EXCLUDED.name, " +
+					"email = EXCLUDED.email, " +
+					"picture = EXCLUDED.picture, " +
+					"location = EXCLUDED.location, " +
+					"sources = EXCLUDED.sources, " +
+					"feedbacks = EXCLUDED.feedbacks, " +
+					"first_seen_at = EXCLUDED.first_seen_at, " +
+					"last_seen_at = EXCLUDED.last_seen_at, " +
+					"updated_at = EXCLUDED.updated_at")
+
+			affected, err := self.database.Exec(ctx, stmt)
+			if err != nil {
+				return err
+			}
+
+			if affected != len(batch) {
+				return kit.ErrDatabaseUnexpectedEffect.Raise(affected, len(batch))
+			}
+		}
+
+		for start := 0; start < len(resolution.Identities); start += CUSTOMER_REPOSITORY_BATCH_SIZE {
+			batch := resolution.Identities[start:min(start+CUSTOMER_REPOSITORY_BATCH_SIZE,
+				len(resolution.Identities))]
+
+			stmt := sqlf.
+				InsertInto(CUSTOMER_IDENTITY_MODEL_TABLE)
+
+			for _, identity := range batch {
+				i := NewCustomerIdentityModel(identity)
+
+				stmt.
+					NewRow().
+					Set("product_id", i.ProductID).
+					Set("key", i.Key).
+					Set("customer_id", i.CustomerID)
+			}
+
+			stmt.
+				Clause("ON CONFLICT DO NOTHING")
+
+			_, err := self.database.Exec(ctx, stmt)
+			if err != nil {
+				return err
+			}
+		}
+
+		for start := 0; start < len(feedbackIDs); start += CUSTOMER_REPOSITORY_BATCH_SIZE {
+			batch := feedbackIDs[start:min(start+CUSTOMER_REPOSITORY_BATCH_SIZE, len(feedbackIDs))]
+
+			stmt := sqlf.
+				InsertInto(CUSTOMER_FEEDBACK_MODEL_TABLE)
+
+			for _, feedbackID := range batch {
+				stmt.
+					NewRow().
+					Set("feedback_id", feedbackID).
+					Set("customer_id", resolution.Feedbacks[feedbackID])
+			}
+
+			stmt.
+				Clause("ON CONFLICT DO NOTHING")
+
+			_, err := self.database.Exec(ctx, stmt)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Merge moves the identities and feedbacks of the other customers to the customer, deleting them, so the
+// feedbacks matching any of their identities are the customer's from now on.
+func (self *CustomerRepository) Merge(ctx context.Context, customer Customer, others []Customer) error {
+	c := NewCustomerModel(customer)
+
+	ids := make([]string, 0, len(others))
+	for _, other := range others {
+		ids = append(ids, other.ID)
+	}
+
+	return self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		stmt := sqlf.
+			Update(CUSTOMER_IDENTITY_MODEL_TABLE).
+			Set("customer_id", c.ID).
+			Where("customer_id").In(util.Spread(ids)...)
+
+		_, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+
+		stmt = sqlf.
+			Update(CUSTOMER_FEEDBACK_MODEL_TABLE).
+			Set("customer_id", c.ID).
+			Where("customer_id").In(util.Spread(ids)...)
+
+		_, err = self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+
+		stmt = sqlf.
+			DeleteFrom(CUSTOMER_MODEL_TABLE).
+			Where("id").In(util.Spread(ids)...)
+
+		affected, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+
+		if affected != len(ids) {
+			return kit.ErrDatabaseUnexpectedEffect.Raise(affected, len(ids))
+		}
+
+		stmt = sqlf.
+			Update(CUSTOMER_MODEL_TABLE).
+			Set("name", c.Name).
+			Set("email", c.Email).
+			Set("picture", c.Picture).
+			Set("location", c.Location).
+			Set("sources", c.Sources).
+			Set("feedbacks", c.Feedbacks).
+			Set("first_seen_at", c.FirstSeenAt).
+			Set("last_seen_at", c.LastSeenAt).
+			Set("updated_at", c.UpdatedAt).
+			Where("id = ?", c.ID)
+
+		affected, err = self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+
+		if affected != 1 {
+			return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+		}
+
+		return nil
+	})
+}
+
+func (self *CustomerRepository) ListFeedbacks(ctx context.Context,
+	id string, pagination util.Pagination[time.Time]) (*util.Page[feedback.Feedback, time.Time], error) {
+	var fs []feedback.FeedbackModel
+
+	stmt := sqlf.
+		Select(feedback.FEEDBACK_MODEL_TABLE+".*").To(&fs).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Join(CUSTOMER_FEEDBACK_MODEL_TABLE,
+			CUSTOMER_FEEDBACK_MODEL_TABLE+".feedback_id = "+feedback.FEEDBACK_MODEL_TABLE+".id").
+		Where(CUSTOMER_FEEDBACK_MODEL_TABLE+".customer_id = ?", id)
+
+	if pagination.From != nil {
+		stmt.
+			Where(fmt.Sprintf("(%s.posted_at, %s.id) < (?, ?)",
+				feedback.FEEDBACK_MODEL_TABLE, feedback.FEEDBACK_MODEL_TABLE),
+				pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy(feedback.FEEDBACK_MODEL_TABLE+".posted_at DESC", feedback.FEEDBACK_MODEL_TABLE+".id DESC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[feedback.Feedback, time.Time]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]feedback.Feedback, 0, len(fs))
+	for _, f := range fs {
+		items = append(items, *f.ToEntity())
+	}
+
+	var cursor *util.Cursor[time.Time]
+	if len(items) == pagination.Limit {
+		cursor = &util.Cursor[time.Time]{
+			Value: items[pagination.Limit-1].PostedAt,
+			ID:    items[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[feedback.Feedback, time.Time]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
+
+// ListIssues returns the issues the feedbacks of the customer are part of, the most recently seen first.
+func (self *CustomerRepository) ListIssues(ctx context.Context, id string, limit int) ([]issue.Issue, error) {
+	var is []issue.IssueModel
+
+	stmt := sqlf.
+		Select("*").To(&is).
+		From(issue.ISSUE_MODEL_TABLE).
+		Where(fmt.Sprintf("id IN (SELECT %s.issue_id FROM %s JOIN %s ON %s.feedback_id = %s.feedback_id "+
+			"WHERE %s.customer_id = ?)",
+			issue.ISSUE_FEEDBACK_MODEL_TABLE, issue.ISSUE_FEEDBACK_MODEL_TABLE, CUSTOMER_FEEDBACK_MODEL_TABLE,
+			CUSTOMER_FEEDBACK_MODEL_TABLE, issue.ISSUE_FEEDBACK_MODEL_TABLE, CUSTOMER_FEEDBACK_MODEL_TABLE), id).
+		OrderBy("last_seen_at DESC", "id DESC").
+		Limit(limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []issue.Issue{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]issue.Issue, 0, len(is))
+	for _, i := range is {
+		entities = append(entities, *i.ToEntity())
+	}
+
+	return entities, nil
+}
+
+// ListSuggestions returns the suggestions the feedbacks of the customer are part of, the most recently seen first.
+func (self *CustomerRepository) ListSuggestions(ctx context.Context,
+	id string, limit int) ([]suggestion.Suggestion, error) {
+	var ss []suggestion.SuggestionModel
+
+	stmt := sqlf.
+		Select("*").To(&ss).
+		From(suggestion.SUGGESTION_MODEL_TABLE).
+		Where(fmt.Sprintf("id IN (SELECT %s.suggestion_id FROM %s JOIN %s ON %s.feedback_id = %s.feedback_id "+
+			"WHERE %s.customer_id = ?)",
+			suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE, suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE,
+			CUSTOMER_FEEDBACK_MODEL_TABLE, CUSTOMER_FEEDBACK_MODEL_TABLE, suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE,
+			CUSTOMER_FEEDBACK_MODEL_TABLE), id).
+		OrderBy("last_seen_at DESC", "id DESC").
+		Limit(limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []suggestion.Suggestion{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]suggestion.Suggestion, 0, len(ss))
+	for _, s := range ss {
+		entities = append(entities, *s.ToEntity())
+	}
+
+	return entities, nil
+}
+
+// ListSentiments returns the sentiments of the reviewed feedbacks of the customer by month, the oldest first.
+func (self *CustomerRepository) ListSentiments(ctx context.Context, id string) ([]CustomerSentiment, error) {
+	var result []struct {
+		Period   time.Time `db:"period"`
+		Positive int       `db:"positive"`
+		Neutral  int       `db:"neutral"`
+		Negative int       `db:"negative"`
+	}
+
+	stmt := sqlf.
+		Select(fmt.Sprintf("date_trunc('month', %s.posted_at) AS period, "+
+			"COUNT(*) FILTER (WHERE %s.sentiment = ?) AS positive, "+
+			"COUNT(*) FILTER (WHERE %s.sentiment = ?) AS neutral, "+
+			"COUNT(*) FILTER (WHERE %s.sentiment = ?) AS negative",
+			feedback.FEEDBACK_MODEL_TABLE, review.REVIEW_MODEL_TABLE, review.REVIEW_MODEL_TABLE,
+			review.REVIEW_MODEL_TABLE),
+			review.ReviewSentimentPositive, review.ReviewSentimentNeutral, review.ReviewSentimentNegative).
+		To(&result).
+		From(review.REVIEW_MODEL_TABLE).
+		Join(feedback.FEEDBACK_MODEL_TABLE,
+			feedback.FEEDBACK_MODEL_TABLE+".id = "+review.REVIEW_MODEL_TABLE+".feedback_id").
+		Join(CUSTOMER_FEEDBACK_MODEL_TABLE,
+			CUSTOMER_FEEDBACK_MODEL_TABLE+".feedback_id = "+review.REVIEW_MODEL_TABLE+".feedback_id").
+		Where(CUSTOMER_FEEDBACK_MODEL_TABLE+".customer_id = ?", id).
+		GroupBy("period").
+		OrderBy("period ASC")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []CustomerSentiment{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]CustomerSentiment, 0, len(result))
+	for _, res := range result {
+		entities = append(entities, CustomerSentiment{
+			Period:   res.Period,
+			Positive: res.Positive,
+			Neutral:  res.Neutral,
+			Negative: res.Negative,
+		})
+	}
+
+	return entities, nil
+}
+
+// SaveAccounts creates the accounts of a product or updates the ones already imported with the same key.
+func (self *CustomerRepository) SaveAccounts(ctx context.Context, accounts []CustomerAccount) error {
+	return self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		for start := 0; start < len(accounts); start += CUSTOMER_REPOSITORY_BATCH_SIZE {
+			batch := accounts[start:min(start+CUSTOMER_REPOSITORY_BATCH_SIZE, len(accounts))]
+
+			stmt := sqlf.
+				InsertInto(CUSTOMER_ACCOUNT_MODEL_TABLE)
+
+			for _, account := range batch {
+				a := NewCustomerAccountModel(account)
+
+				stmt.
+					NewRow().
+					Set("product_id", a.ProductID).
+					Set("key", a.Key).
+					Set("arr", a.ARR).
+					Set("plan", a.Plan).
+					Set("owner", a.Owner).
+					Set("created_at", a.CreatedAt).
+					Set("updated_at", a.UpdatedAt)
+			}
+
+			stmt.
+				Clause("ON CONFLICT (product_id, key) DO UPDATE SET " +
+					"arr = EXCLUDED.arr, " +
+					"plan = EXCLUDED.plan, " +
+					"owner = EXCLUDED.owner, " +
+					"updated_at = EXCLUDED.updated_at")
+
+			affected, err := self.database.Exec(ctx, stmt)
+			if err != nil {
+				return err
+			}
+
+			if affected != len(batch) {
+				return kit.ErrDatabaseUnexpectedEffect.Raise(affected, len(batch))
+			}
+		}
+
+		return nil
+	})
+}
+
+func (self *CustomerRepository) ListAccountsByProductID(ctx context.Context,
+	productID string) ([]CustomerAccount, error) {
+	var as []CustomerAccountModel
+
+	stmt := sqlf.
+		Select("*").To(&as).
+		From(CUSTOMER_ACCOUNT_MODEL_TABLE).
+		Where("product_id = ?", productID)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []CustomerAccount{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]CustomerAccount, 0, len(as))
+	for _, a := range as {
+		entities = append(entities, *a.ToEntity())
+	}
+
+	return entities, nil
+}
+
+func (self *CustomerRepository) ListProductIDsWithAccounts(ctx context.Context) ([]string, error) {
+	var ids []string
+
+	stmt := sqlf.
+		Select("DISTINCT product_id").To(&ids).
+		From(CUSTOMER_ACCOUNT_MODEL_TABLE)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []string{}, nil
+		}
+
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (self *CustomerRepository) DeleteAccountsByProductID(ctx context.Context, productID string) error {
+	stmt := sqlf.
+		DeleteFrom(CUSTOMER_ACCOUNT_MODEL_TABLE).
+		Where("product_id = ?", productID)
+
+	_, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateEnrichment sets the attributes of the accounts, by customer id, to the customers of a product, clearing
+// the ones of the rest of its customers.
+func (self *CustomerRepository) UpdateEnrichment(ctx context.Context,
+	productID string, enrichment map[string]CustomerAccount) error {
+	ids := make([]string, 0, len(enrichment))
+	arrs := make([]*int, 0, len(enrichment))
+	plans := make([]*string, 0, len(enrichment))
+	owners := make([]*string, 0, len(enrichment))
+	for id, account := range enrichment {
+		ids = append(ids, id)
+		arrs = append(arrs, account.ARR)
+		plans = append(plans, account.Plan)
+		owners = append(owners, account.Owner)
+	}
+
+	return self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		stmt := sqlf.
+			Update(CUSTOMER_MODEL_TABLE).
+			Set("arr", nil).
+			Set("plan", nil).
+			Set("account_owner", nil).
+			Where("product_id = ?", productID).
+			Where("(arr IS NOT NULL OR plan IS NOT NULL OR account_owner IS NOT NULL)")
+
+		_, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		stmt = sqlf.
+			New(`UPDATE `+CUSTOMER_MODEL_TABLE+` SET "arr" = "enriched"."arr", "plan" = "enriched"."plan",
+				"account_owner" = "enriched"."owner"
+				FROM unnest(?::TEXT[], ?::BIGINT[], ?::TEXT[], ?::TEXT[]) AS "enriched" ("id", "arr", "plan", "owner")
+				WHERE `+CUSTOMER_MODEL_TABLE+`."id" = "enriched"."id"`, ids, arrs, plans, owners)
+
+		_, err = self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// UpdateRevenuesAtRisk sets the revenue at risk of the issues and suggestions of a product to the annual revenue
+// of the distinct customers of their feedbacks.
+func (self *CustomerRepository) UpdateRevenuesAtRisk(ctx context.Context, productID string) error {
+	revenue := func(table string, linkTable string, column string) *sqlf.Stmt {
+		return sqlf.
+			New(`UPDATE `+table+` SET "revenue_at_risk" = COALESCE((
+				SELECT SUM(`+CUSTOMER_MODEL_TABLE+`."arr") FROM `+CUSTOMER_MODEL_TABLE+`
+				WHERE `+CUSTOMER_MODEL_TABLE+`."id" IN (
+					SELECT `+CUSTOMER_FEEDBACK_MODEL_TABLE+`."customer_id" FROM `+CUSTOMER_FEEDBACK_MODEL_TABLE+`
+					JOIN `+linkTable+` ON `+linkTable+`."feedback_id" = `+CUSTOMER_FEEDBACK_MODEL_TABLE+`."feedback_id"
+					WHERE `+linkTable+`."`+column+`" = `+table+`."id")), 0)
+				WHERE `+table+`."product_id" = ?`, productID)
+	}
+
+	return self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		_, err := self.database.Exec(ctx,
+			revenue(issue.ISSUE_MODEL_TABLE, issue.ISSUE_FEEDBACK_MODEL_TABLE, "issue_id"))
+		if err != nil {
+			return err
+		}
+
+		_, err = self.database.Exec(ctx,
+			revenue(suggestion.SUGGESTION_MODEL_TABLE, suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE, "suggestion_id"))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,167 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/outbox"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+const (
+	ResolverResolve  = "customer:resolve"
+	ResolverSchedule = "customer:schedule-resolve"
+)
+
+var (
+	ErrResolverGeneric = errors.New("resolver failed")
+)
+
+type Resolver struct {
+	config             config.Config
+	observer           *kit.Observer
+	database           *kit.Database
+	productRepository  *product.ProductRepository
+	customerRepository *CustomerRepository
+	enqueuer           *outbox.OutboxEnqueuer
+}
+
+func NewResolver(observer *kit.Observer, database *kit.Database, productRepository *product.ProductRepository,
+	customerRepository *CustomerRepository, enqueuer *outbox.OutboxEnqueuer, config config.Config) *Resolver {
+	return &Resolver{
+		config:             config,
+		observer:           observer,
+		database:           database,
+		productRepository:  productRepository,
+		customerRepository: customerRepository,
+		enqueuer:           enqueuer,
+	}
+}
+
+// Run matches the feedbacks of a product without a customer to the customers sharing their identities, creating
+// the ones not seen before. The rest of the feedbacks are left for the next run. Customers are read and saved
+// under the product lock, so a concurrent run or merge cannot be overwritten with stale counts.
+func (self *Resolver) Run(ctx context.Context, _product product.Product) (*CustomerResolution, error) {
+	var resolution *CustomerResolution
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := util.LockTransaction(ctx, self.database, fmt.Sprintf(CUSTOMER_LOCK_KEY, _product.ID))
+		if err != nil {
+			return err
+		}
+
+		feedbacks, err := self.customerRepository.ListUnresolvedFeedbacks(ctx, _product.ID,
+			CUSTOMER_RESOLVING_MAX_FEEDBACKS)
+		if err != nil {
+			return err
+		}
+
+		keys := []string{}
+		for _, feedback := range feedbacks {
+			keys = append(keys, NewCustomerIdentityKeys(feedback)...)
+		}
+
+		identities, err := self.customerRepository.ListIdentitiesByKeys(ctx, _product.ID, keys)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(identities))
+		seen := make(map[string]bool, len(identities))
+		for _, identity := range identities {
+			if !seen[identity.CustomerID] {
+				ids = append(ids, identity.CustomerID)
+				seen[identity.CustomerID] = true
+			}
+		}
+
+		customers, err := self.customerRepository.ListByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		resolution = NewCustomerResolution(_product.ID, customers, identities)
+		for _, feedback := range feedbacks {
+			resolution.Resolve(feedback, now)
+		}
+
+		if len(resolution.Feedbacks) == 0 {
+			return nil
+		}
+
+		return self.customerRepository.SaveResolution(ctx, *resolution)
+	})
+	if err != nil {
+		return nil, ErrResolverGeneric.Raise().Cause(err)
+	}
+
+	if len(resolution.Feedbacks) == 0 {
+		return resolution, nil
+	}
+
+	self.observer.Infof(ctx, "Resolved the customers of %d feedbacks, %d new customers, of product %s",
+		len(resolution.Feedbacks), len(resolution.Created), _product.ID)
+
+	return resolution, nil
+}
+
+type ResolverResolveParams struct {
+	ProductID string
+}
+
+func (self *Resolver) Resolve(ctx context.Context, task *asynq.Task) error {
+	params := ResolverResolveParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	product, err := self.productRepository.GetByID(ctx, params.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	if product.DeletedAt != nil {
+		return nil
+	}
+
+	_, err = self.Run(ctx, *product)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *Resolver) Schedule(ctx context.Context, _ *asynq.Task) error {
+	ids, err := self.productRepository.ListIDsByNotDeleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := self.enqueuer.Enqueue(ctx, ResolverResolve, ResolverResolveParams{
+			ProductID: id,
+		}, asynq.MaxRetry(2), asynq.Unique(1*time.Hour))
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	return nil
+}
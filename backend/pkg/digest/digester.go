@@ -0,0 +1,477 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+	kitUtil "github.com/neoxelox/kit/util"
+	"github.com/rs/xid"
+
+	"backend/pkg/brevo"
+	"backend/pkg/config"
+	"backend/pkg/engine"
+	"backend/pkg/metric"
+	"backend/pkg/organization"
+	"backend/pkg/outbox"
+	"backend/pkg/product"
+	"backend/pkg/review"
+	"backend/pkg/user"
+	"backend/pkg/util"
+)
+
+const (
+	DigesterSend     = "digest:send"
+	DigesterSchedule = "digest:schedule-send"
+)
+
+const (
+	DIGESTER_EMAIL_SUBJECT  = "Your weekly digest of %s"
+	DIGESTER_EMAIL_TEMPLATE = "emails/digest.html"
+)
+
+var (
+	ErrDigesterGeneric = errors.New("digester failed")
+)
+
+type Digester struct {
+	config                 config.Config
+	observer               *kit.Observer
+	renderer               *kit.Renderer
+	productRepository      *product.ProductRepository
+	organizationRepository organization.OrganizationRepository
+	userRepository         user.UserRepository
+	digestRepository       *DigestRepository
+	metricRepository       *metric.MetricRepository
+	enqueuer               *outbox.OutboxEnqueuer
+	engineService          *engine.EngineService
+	brevoService           *brevo.BrevoService
+}
+
+func NewDigester(observer *kit.Observer, renderer *kit.Renderer, productRepository *product.ProductRepository,
+	organizationRepository organization.OrganizationRepository, userRepository user.UserRepository,
+	digestRepository *DigestRepository, metricRepository *metric.MetricRepository, enqueuer *outbox.OutboxEnqueuer,
+	engineService *engine.EngineService, brevoService *brevo.BrevoService, config config.Config) *Digester {
+	return &Digester{
+		config:                 config,
+		observer:               observer,
+		renderer:               renderer,
+		productRepository:      productRepository,
+		organizationRepository: organizationRepository,
+		userRepository:         userRepository,
+		digestRepository:       digestRepository,
+		metricRepository:       metricRepository,
+		enqueuer:               enqueuer,
+		engineService:          engineService,
+		brevoService:           brevoService,
+	}
+}
+
+// Run builds the digest of the last period of a product, archives it and sends it to the users of its organization
+// who opted in. A digest already archived is only sent again if it could not be sent before, and periods without
+// any activity are skipped.
+func (self *Digester) Run(ctx context.Context, _product product.Product) (*Digest, error) {
+	periodStartAt, periodEndAt := NewDigestPeriod(time.Now())
+
+	digest, err := self.digestRepository.GetByProductIDAndPeriodEndAt(ctx, _product.ID, periodEndAt)
+	if err != nil {
+		return nil, ErrDigesterGeneric.Raise().Cause(err)
+	}
+
+	if digest != nil && digest.SentAt != nil {
+		return digest, nil
+	}
+
+	receivers, err := self.listReceivers(ctx, _product.OrganizationID)
+	if err != nil {
+		return nil, ErrDigesterGeneric.Raise().Cause(err)
+	}
+
+	if digest == nil {
+		digest, err = self.build(ctx, _product, periodStartAt, periodEndAt)
+		if err != nil {
+			return nil, ErrDigesterGeneric.Raise().Cause(err)
+		}
+
+		if digest == nil {
+			self.observer.Infof(ctx, "Nothing to digest of product %s", _product.ID)
+			return nil, nil
+		}
+
+		digest.Receivers = len(receivers)
+
+		digest, err = self.digestRepository.Create(ctx, *digest)
+		if err != nil {
+			return nil, ErrDigesterGeneric.Raise().Cause(err)
+		}
+	}
+
+	// Users who already got the digest in a previous attempt do not get it again
+	receivers = util.Filter(receivers, func(receiver string) bool {
+		return !slices.Contains(digest.SentTo, receiver)
+	})
+
+	if len(receivers) == 0 {
+		return digest, nil
+	}
+
+	sentTo, failedTo, err := self.send(ctx, _product, *digest, receivers)
+	if err != nil {
+		return nil, ErrDigesterGeneric.Raise().Cause(err)
+	}
+
+	digest.SentTo = append(digest.SentTo, sentTo...)
+	digest.FailedTo = failedTo
+	if len(failedTo) == 0 {
+		digest.SentAt = kitUtil.Pointer(time.Now())
+	}
+
+	err = self.digestRepository.UpdateDelivery(ctx, *digest)
+	if err != nil {
+		return nil, ErrDigesterGeneric.Raise().Cause(err)
+	}
+
+	self.observer.Infof(ctx, "Sent digest %s of product %s to %d users", digest.ID, _product.ID, len(sentTo))
+
+	// The task is retried so only the users the digest could not be sent to get it
+	if len(failedTo) > 0 {
+		return nil, ErrDigesterGeneric.Raise().
+			With("digest %s could not be sent to %d users", digest.ID, len(failedTo))
+	}
+
+	return digest, nil
+}
+
+func (self *Digester) listReceivers(ctx context.Context, organizationID string) ([]string, error) {
+	users, err := self.userRepository.ListByOrganizationID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	receivers := []string{}
+	for _, user := range users {
+		if user.DeletedAt == nil && user.Settings.Digest {
+			receivers = append(receivers, user.Email)
+		}
+	}
+
+	return receivers, nil
+}
+
+// build computes the metrics of the period, which the engine only puts into words as it cannot compute them.
+func (self *Digester) build(ctx context.Context, _product product.Product, periodStartAt time.Time,
+	periodEndAt time.Time) (*Digest, error) {
+	params := metric.Params{
+		ProductID:     _product.ID,
+		PeriodStartAt: &periodStartAt,
+		PeriodEndAt:   &periodEndAt,
+	}
+	previousParams := metric.Params{
+		ProductID:     _product.ID,
+		PeriodStartAt: kitUtil.Pointer(periodStartAt.Add(-periodEndAt.Sub(periodStartAt))),
+		PeriodEndAt:   &periodStartAt,
+	}
+
+	digest := NewDigest()
+	digest.ID = xid.New().String()
+	digest.ProductID = _product.ID
+	digest.PeriodStartAt = periodStartAt
+	digest.PeriodEndAt = periodEndAt
+	digest.SentTo = []string{}
+	digest.FailedTo = []string{}
+	digest.SentAt = nil
+	digest.CreatedAt = time.Now()
+
+	for _, period := range []struct {
+		Params  metric.Params
+		Reviews *int
+		NPS     *float64
+		CSAT    *float64
+	}{
+		{
+			Params:  params,
+			Reviews: &digest.Metrics.Reviews,
+			NPS:     &digest.Metrics.NetPromoterScore.Current,
+			CSAT:    &digest.Metrics.CustomerSatisfactionScore.Current,
+		},
+		{
+			Params:  previousParams,
+			Reviews: &digest.Metrics.PreviousReviews,
+			NPS:     &digest.Metrics.NetPromoterScore.Previous,
+			CSAT:    &digest.Metrics.CustomerSatisfactionScore.Previous,
+		},
+	} {
+		sentiments, err := self.metricRepository.GetReviewSentiments(ctx, metric.ReviewSentimentsParams{
+			Params: period.Params,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, count := range sentiments.Sentiments {
+			*period.Reviews += count
+		}
+
+		nps, err := self.metricRepository.GetNetPromoterScore(ctx, metric.NetPromoterScoreParams{
+			Params: period.Params,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		*period.NPS = nps.Score
+
+		csat, err := self.metricRepository.GetCustomerSatisfactionScore(ctx, metric.CustomerSatisfactionScoreParams{
+			Params: period.Params,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		*period.CSAT = csat.Score
+	}
+
+	issues, err := self.metricRepository.GetIssueCount(ctx, metric.IssueCountParams{
+		Params: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digest.Metrics.NewIssues = issues.NewIssues
+
+	criticals, err := self.metricRepository.GetIssueNewCriticals(ctx, metric.IssueNewCriticalsParams{
+		Params: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digest.Metrics.NewCriticalIssues = criticals.Issues
+
+	suggestions, err := self.metricRepository.GetSuggestionCount(ctx, metric.SuggestionCountParams{
+		Params: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digest.Metrics.NewSuggestions = suggestions.NewSuggestions
+
+	digest.Issues, err = self.digestRepository.ListIssues(ctx, _product.ID, periodStartAt, periodEndAt,
+		DIGEST_MAX_ISSUES)
+	if err != nil {
+		return nil, err
+	}
+
+	digest.Suggestions, err = self.digestRepository.ListSuggestions(ctx, _product.ID, periodStartAt, periodEndAt,
+		DIGEST_MAX_SUGGESTIONS)
+	if err != nil {
+		return nil, err
+	}
+
+	digest.Quotes, err = self.digestRepository.ListQuotes(ctx, _product.ID, _product.Language, periodStartAt,
+		periodEndAt, DIGEST_MAX_QUOTES)
+	if err != nil {
+		return nil, err
+	}
+
+	if digest.Empty() {
+		return nil, nil
+	}
+
+	issueDescriptions := make([]string, 0, len(digest.Issues))
+	for _, issue := range digest.Issues {
+		issueDescriptions = append(issueDescriptions, issue.Describe())
+	}
+
+	suggestionDescriptions := make([]string, 0, len(digest.Suggestions))
+	for _, suggestion := range digest.Suggestions {
+		suggestionDescriptions = append(suggestionDescriptions, suggestion.Describe())
+	}
+
+	quotes := make([]engine.Feedback, 0, len(digest.Quotes))
+	for _, quote := range digest.Quotes {
+		quotes = append(quotes, engine.Feedback{Content: quote.Content})
+	}
+
+	sdResult, err := self.engineService.SummarizeDigest(ctx, engine.EngineServiceSummarizeDigestParams{
+		Context:     _product.Context,
+		Facts:       digest.Facts(),
+		Issues:      issueDescriptions,
+		Suggestions: suggestionDescriptions,
+		Quotes:      quotes,
+		Language:    _product.Language,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	digest.Summary = sdResult.Digest.Summary
+	digest.Highlights = sdResult.Digest.Highlights
+
+	self.observer.Infof(ctx, "Summarized digest of product %s using %d tokens",
+		_product.ID, sdResult.Usage.Input+sdResult.Usage.Output)
+
+	return digest, nil
+}
+
+type digesterEmailMetric struct {
+	Name   string
+	Value  string
+	Change string
+}
+
+type digesterEmailQuote struct {
+	Content  string
+	Positive bool
+}
+
+// send emails the digest to every receiver on their own, so receivers do not see each other, returning the
+// receivers it was sent to and the ones it could not be sent to.
+func (self *Digester) send(ctx context.Context, _product product.Product, digest Digest,
+	receivers []string) ([]string, []string, error) {
+	metrics := []digesterEmailMetric{
+		{Name: "Reviews", Value: fmt.Sprint(digest.Metrics.Reviews),
+			Change: fmt.Sprintf("%+d", digest.Metrics.Reviews-digest.Metrics.PreviousReviews)},
+		{Name: "New issues", Value: fmt.Sprint(digest.Metrics.NewIssues)},
+		{Name: "New critical issues", Value: fmt.Sprint(digest.Metrics.NewCriticalIssues)},
+		{Name: "New suggestions", Value: fmt.Sprint(digest.Metrics.NewSuggestions)},
+	}
+
+	// Scores without reviews in any of the periods cannot be compared
+	if digest.Metrics.Reviews > 0 && digest.Metrics.PreviousReviews > 0 {
+		metrics = append(metrics,
+			digesterEmailMetric{Name: "NPS", Value: fmt.Sprintf("%.0f", digest.Metrics.NetPromoterScore.Current),
+				Change: fmt.Sprintf("%+.0f", digest.Metrics.NetPromoterScore.Delta())},
+			digesterEmailMetric{Name: "CSAT",
+				Value:  fmt.Sprintf("%.0f", digest.Metrics.CustomerSatisfactionScore.Current),
+				Change: fmt.Sprintf("%+.0f", digest.Metrics.CustomerSatisfactionScore.Delta())})
+	}
+
+	issues := make([]string, 0, len(digest.Issues))
+	for _, issue := range digest.Issues {
+		issues = append(issues, issue.Describe())
+	}
+
+	suggestions := make([]string, 0, len(digest.Suggestions))
+	for _, suggestion := range digest.Suggestions {
+		suggestions = append(suggestions, suggestion.Describe())
+	}
+
+	quotes := make([]digesterEmailQuote, 0, len(digest.Quotes))
+	for _, quote := range digest.Quotes {
+		quotes = append(quotes, digesterEmailQuote{
+			Content:  quote.Content,
+			Positive: quote.Sentiment == review.ReviewSentimentPositive,
+		})
+	}
+
+	body, err := self.renderer.RenderString(DIGESTER_EMAIL_TEMPLATE, map[string]any{
+		"Product": _product.Name,
+		"Period": fmt.Sprintf("%s - %s", digest.PeriodStartAt.Format("January 2"),
+			digest.PeriodEndAt.Add(-time.Nanosecond).Format("January 2, 2006")),
+		"Summary":     digest.Summary,
+		"Highlights":  digest.Highlights,
+		"Metrics":     metrics,
+		"Issues":      issues,
+		"Suggestions": suggestions,
+		"Quotes":      quotes,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sentTo := make([]string, 0, len(receivers))
+	failedTo := []string{}
+	for _, receiver := range receivers {
+		err := self.brevoService.SendEmail(ctx, brevo.BrevoServiceSendEmailParams{
+			Receivers: []string{receiver},
+			Subject:   fmt.Sprintf(DIGESTER_EMAIL_SUBJECT, _product.Name),
+			Body:      body,
+		})
+		if err != nil {
+			self.observer.Error(ctx, err)
+			failedTo = append(failedTo, receiver)
+			continue
+		}
+
+		sentTo = append(sentTo, receiver)
+	}
+
+	return sentTo, failedTo, nil
+}
+
+type DigesterSendParams struct {
+	ProductID string
+}
+
+func (self *Digester) Send(ctx context.Context, task *asynq.Task) error {
+	params := DigesterSendParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	product, err := self.productRepository.GetByID(ctx, params.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	if product.DeletedAt != nil {
+		return nil
+	}
+
+	organization, err := self.organizationRepository.GetByID(ctx, product.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	if organization == nil {
+		return nil
+	}
+
+	if organization.DeletedAt != nil {
+		return nil
+	}
+
+	if organization.UsageLeft() < 1 {
+		return nil
+	}
+
+	_, err = self.Run(ctx, *product)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *Digester) Schedule(ctx context.Context, _ *asynq.Task) error {
+	ids, err := self.productRepository.ListIDsByNotDeleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := self.enqueuer.Enqueue(ctx, DigesterSend, DigesterSendParams{
+			ProductID: id,
+		}, asynq.MaxRetry(2), asynq.Unique(24*time.Hour))
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	return nil
+}
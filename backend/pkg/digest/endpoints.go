@@ -0,0 +1,85 @@
+package digest
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+type DigestEndpoints struct {
+	config           config.Config
+	observer         *kit.Observer
+	digestRepository *DigestRepository
+}
+
+func NewDigestEndpoints(observer *kit.Observer, digestRepository *DigestRepository,
+	config config.Config) *DigestEndpoints {
+	return &DigestEndpoints{
+		config:           config,
+		observer:         observer,
+		digestRepository: digestRepository,
+	}
+}
+
+type DigestEndpointsListDigestsRequest struct {
+	From *string `query:"from"`
+}
+
+type DigestEndpointsListDigestsResponse struct {
+	Digests []DigestPayload `json:"digests"`
+	Next    *string         `json:"next"`
+}
+
+func (self *DigestEndpoints) ListDigests(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := DigestEndpointsListDigestsRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	page, err := self.digestRepository.ListByProductID(requestCtx, requestProduct.ID, util.Pagination[time.Time]{
+		Limit: 25,
+		From:  util.CursorFromString[time.Time](request.From),
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := DigestEndpointsListDigestsResponse{}
+	response.Digests = make([]DigestPayload, 0, len(page.Items))
+	for _, digest := range page.Items {
+		response.Digests = append(response.Digests, *NewDigestPayload(digest))
+	}
+	response.Next = nil
+	next := util.CursorToString(page.Next)
+	if next != nil {
+		*next = url.QueryEscape(*next)
+	}
+	response.Next = next
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type DigestEndpointsGetDigestResponse struct {
+	DigestPayload
+}
+
+func (self *DigestEndpoints) GetDigest(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestDigest := RequestDigest(requestCtx)
+
+	response := DigestEndpointsGetDigestResponse{}
+	response.DigestPayload = *NewDigestPayload(*requestDigest)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
@@ -0,0 +1,152 @@
+package digest
+
+import (
+	"fmt"
+	"time"
+
+	kitUtil "github.com/neoxelox/kit/util"
+)
+
+const (
+	DIGEST_PERIOD          = 7 * 24 * time.Hour
+	DIGEST_MAX_ISSUES      = 5
+	DIGEST_MAX_SUGGESTIONS = 5
+	// Notable quotes of every sentiment, the ones from the customers with the strongest intentions
+	DIGEST_MAX_QUOTES = 2
+	// Quotes shorter say too little and longer ones do not fit in an email
+	DIGEST_MIN_QUOTE_LENGTH = 40
+	DIGEST_MAX_QUOTE_LENGTH = 300
+)
+
+// DigestScore is the value of a score within the period of the digest and within the previous one.
+type DigestScore struct {
+	Current  float64
+	Previous float64
+}
+
+func (self DigestScore) Delta() float64 {
+	return self.Current - self.Previous
+}
+
+type DigestMetrics struct {
+	Reviews                   int
+	PreviousReviews           int
+	NewIssues                 int
+	NewCriticalIssues         int
+	NewSuggestions            int
+	NetPromoterScore          DigestScore
+	CustomerSatisfactionScore DigestScore
+}
+
+// DigestIssue is an issue that got more feedbacks within the period of the digest than within the previous one,
+// which includes the issues first seen within the period.
+type DigestIssue struct {
+	ID                string
+	Title             string
+	Feedbacks         int
+	PreviousFeedbacks int
+	New               bool
+}
+
+type DigestSuggestion struct {
+	ID        string
+	Title     string
+	Feedbacks int
+}
+
+type DigestQuote struct {
+	FeedbackID string
+	Content    string
+	Sentiment  string
+}
+
+type Digest struct {
+	ID            string
+	ProductID     string
+	Summary       string
+	Highlights    []string
+	Metrics       DigestMetrics
+	Issues        []DigestIssue
+	Suggestions   []DigestSuggestion
+	Quotes        []DigestQuote
+	Receivers     int
+	SentTo        []string
+	FailedTo      []string
+	PeriodStartAt time.Time
+	PeriodEndAt   time.Time
+	SentAt        *time.Time
+	CreatedAt     time.Time
+}
+
+func NewDigest() *Digest {
+	return &Digest{}
+}
+
+func (self Digest) String() string {
+	return fmt.Sprintf("<Digest: %s (%s)>", self.PeriodEndAt.Format(time.DateOnly), self.ID)
+}
+
+func (self Digest) Equals(other Digest) bool {
+	return kitUtil.Equals(self, other)
+}
+
+func (self Digest) Copy() *Digest {
+	return kitUtil.Copy(self)
+}
+
+// NewDigestPeriod returns the period of the digest sent at a time, which ends at the start of its day so every
+// attempt to build the same digest covers the same feedbacks.
+func NewDigestPeriod(now time.Time) (time.Time, time.Time) {
+	endAt := now.UTC().Truncate(24 * time.Hour)
+
+	return endAt.Add(-DIGEST_PERIOD), endAt
+}
+
+// Empty reports whether nothing happened within the period of the digest, so there is nothing to summarize.
+func (self Digest) Empty() bool {
+	return self.Metrics.Reviews == 0 && len(self.Issues) == 0 && len(self.Suggestions) == 0
+}
+
+// Facts describes the metrics of the digest for the engine to summarize, as it cannot compute them by itself.
+// Scores are only compared when there were reviews within both periods, otherwise the change is meaningless.
+func (self Digest) Facts() []string {
+	facts := []string{
+		fmt.Sprintf("Reviews: %d (previous period: %d)", self.Metrics.Reviews, self.Metrics.PreviousReviews),
+		fmt.Sprintf("New issues: %d, of which critical: %d", self.Metrics.NewIssues, self.Metrics.NewCriticalIssues),
+		fmt.Sprintf("New suggestions: %d", self.Metrics.NewSuggestions),
+	}
+
+	if self.Metrics.Reviews == 0 {
+		return facts
+	}
+
+	for _, score := range []struct {
+		Name  string
+		Score DigestScore
+	}{
+		{Name: "Net Promoter Score", Score: self.Metrics.NetPromoterScore},
+		{Name: "Customer Satisfaction Score", Score: self.Metrics.CustomerSatisfactionScore},
+	} {
+		if self.Metrics.PreviousReviews == 0 {
+			facts = append(facts, fmt.Sprintf("%s: %.0f", score.Name, score.Score.Current))
+			continue
+		}
+
+		facts = append(facts, fmt.Sprintf("%s: %.0f (previous period: %.0f, change: %+.0f)",
+			score.Name, score.Score.Current, score.Score.Previous, score.Score.Delta()))
+	}
+
+	return facts
+}
+
+func (self DigestIssue) Describe() string {
+	if self.New {
+		return fmt.Sprintf("%s (%d feedbacks, new)", self.Title, self.Feedbacks)
+	}
+
+	return fmt.Sprintf("%s (%d feedbacks, up from %d)", self.Title, self.Feedbacks, self.PreviousFeedbacks)
+}
+
+func (self DigestSuggestion) Describe() string {
+	return fmt.Sprintf("%s (%d feedbacks)", self.Title, self.Feedbacks)
+}
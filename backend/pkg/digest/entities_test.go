@@ -0,0 +1,64 @@
+package digest_test
+
+import (
+	"testing"
+	"time"
+
+	"backend/pkg/digest"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DigestTestSuite struct {
+	suite.Suite
+}
+
+func TestDigestSuite(t *testing.T) {
+	suite.Run(t, new(DigestTestSuite))
+}
+
+func (self *DigestTestSuite) TestNewDigestPeriodIsStableWithinTheDay() {
+	// Given: Two attempts to build the digest on the same day
+	first := time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC)
+	retry := time.Date(2024, 6, 10, 17, 30, 0, 0, time.UTC)
+
+	// When: The periods of both attempts are computed
+	firstStartAt, firstEndAt := digest.NewDigestPeriod(first)
+	retryStartAt, retryEndAt := digest.NewDigestPeriod(retry)
+
+	// Then: Both cover the same last week, up to the start of the day
+	self.Equal(time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC), firstEndAt)
+	self.Equal(time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), firstStartAt)
+	self.Equal(firstStartAt, retryStartAt)
+	self.Equal(firstEndAt, retryEndAt)
+}
+
+func (self *DigestTestSuite) TestFactsCompareScoresOnlyWithPreviousReviews() {
+	// Given: A digest whose previous period had reviews and another whose previous period had none
+	compared := digest.Digest{Metrics: digest.DigestMetrics{
+		Reviews:                   40,
+		PreviousReviews:           25,
+		NewIssues:                 3,
+		NewCriticalIssues:         1,
+		NewSuggestions:            2,
+		NetPromoterScore:          digest.DigestScore{Current: 12, Previous: 20},
+		CustomerSatisfactionScore: digest.DigestScore{Current: 71, Previous: 65},
+	}}
+	uncompared := compared
+	uncompared.Metrics.PreviousReviews = 0
+
+	// When: The facts of both digests are described
+	comparedFacts := compared.Facts()
+	uncomparedFacts := uncompared.Facts()
+
+	// Then: Only the scores of the first one state their change
+	self.Equal([]string{
+		"Reviews: 40 (previous period: 25)",
+		"New issues: 3, of which critical: 1",
+		"New suggestions: 2",
+		"Net Promoter Score: 12 (previous period: 20, change: -8)",
+		"Customer Satisfaction Score: 71 (previous period: 65, change: +6)",
+	}, comparedFacts)
+	self.Equal("Net Promoter Score: 12", uncomparedFacts[3])
+	self.Equal("Customer Satisfaction Score: 71", uncomparedFacts[4])
+}
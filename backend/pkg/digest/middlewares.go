@@ -0,0 +1,58 @@
+package digest
+
+import (
+	"context"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+)
+
+var (
+	KeyRequestDigest kit.Key = kit.KeyBase + "request:digest"
+)
+
+func RequestDigest(ctx context.Context) *Digest {
+	return ctx.Value(KeyRequestDigest).(*Digest) // nolint:forcetypeassert,errcheck
+}
+
+type DigestMiddlewares struct {
+	config           config.Config
+	observer         *kit.Observer
+	digestRepository *DigestRepository
+}
+
+func NewDigestMiddlewares(observer *kit.Observer, digestRepository *DigestRepository,
+	config config.Config) *DigestMiddlewares {
+	return &DigestMiddlewares{
+		config:           config,
+		observer:         observer,
+		digestRepository: digestRepository,
+	}
+}
+
+func (self *DigestMiddlewares) HandleDigest(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		requestCtx := ctx.Request().Context()
+		requestProduct := product.RequestProduct(requestCtx)
+
+		digest, err := self.digestRepository.GetByID(requestCtx, ctx.Param("digest_id"))
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+
+		if digest == nil {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		if digest.ProductID != requestProduct.ID {
+			return kit.HTTPErrUnauthorized
+		}
+
+		ctx.SetRequest(ctx.Request().WithContext(context.WithValue(requestCtx, KeyRequestDigest, digest)))
+
+		return next(ctx)
+	}
+}
@@ -0,0 +1,112 @@
+package digest
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DIGEST_MODEL_TABLE = "\"digest\""
+)
+
+type DigestModel struct {
+	ID            string     `db:"id"`
+	ProductID     string     `db:"product_id"`
+	Summary       string     `db:"summary"`
+	Highlights    []string   `db:"highlights"`
+	Metrics       []byte     `db:"metrics"`
+	Issues        []byte     `db:"issues"`
+	Suggestions   []byte     `db:"suggestions"`
+	Quotes        []byte     `db:"quotes"`
+	Receivers     int        `db:"receivers"`
+	SentTo        []string   `db:"sent_to"`
+	FailedTo      []string   `db:"failed_to"`
+	PeriodStartAt time.Time  `db:"period_start_at"`
+	PeriodEndAt   time.Time  `db:"period_end_at"`
+	SentAt        *time.Time `db:"sent_at"`
+	CreatedAt     time.Time  `db:"created_at"`
+}
+
+func NewDigestModel(digest Digest) *DigestModel {
+	metrics, err := json.Marshal(digest.Metrics)
+	if err != nil {
+		panic(err)
+	}
+
+	issues, err := json.Marshal(digest.Issues)
+	if err != nil {
+		panic(err)
+	}
+
+	suggestions, err := json.Marshal(digest.Suggestions)
+	if err != nil {
+		panic(err)
+	}
+
+	quotes, err := json.Marshal(digest.Quotes)
+	if err != nil {
+		panic(err)
+	}
+
+	return &DigestModel{
+		ID:            digest.ID,
+		ProductID:     digest.ProductID,
+		Summary:       digest.Summary,
+		Highlights:    digest.Highlights,
+		Metrics:       metrics,
+		Issues:        issues,
+		Suggestions:   suggestions,
+		Quotes:        quotes,
+		Receivers:     digest.Receivers,
+		SentTo:        digest.SentTo,
+		FailedTo:      digest.FailedTo,
+		PeriodStartAt: digest.PeriodStartAt,
+		PeriodEndAt:   digest.PeriodEndAt,
+		SentAt:        digest.SentAt,
+		CreatedAt:     digest.CreatedAt,
+	}
+}
+
+func (self *DigestModel) ToEntity() *Digest {
+	var metrics DigestMetrics
+	err := json.Unmarshal(self.Metrics, &metrics)
+	if err != nil {
+		panic(err)
+	}
+
+	var issues []DigestIssue
+	err = json.Unmarshal(self.Issues, &issues)
+	if err != nil {
+		panic(err)
+	}
+
+	var suggestions []DigestSuggestion
+	err = json.Unmarshal(self.Suggestions, &suggestions)
+	if err != nil {
+		panic(err)
+	}
+
+	var quotes []DigestQuote
+	err = json.Unmarshal(self.Quotes, &quotes)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Digest{
+		ID:            self.ID,
+		ProductID:     self.ProductID,
+		Summary:       self.Summary,
+		Highlights:    self.Highlights,
+		Metrics:       metrics,
+		Issues:        issues,
+		Suggestions:   suggestions,
+		Quotes:        quotes,
+		Receivers:     self.Receivers,
+		SentTo:        self.SentTo,
+		FailedTo:      self.FailedTo,
+		PeriodStartAt: self.PeriodStartAt,
+		PeriodEndAt:   self.PeriodEndAt,
+		SentAt:        self.SentAt,
+		CreatedAt:     self.CreatedAt,
+	}
+}
@@ -0,0 +1,123 @@
+package digest
+
+import (
+	"time"
+)
+
+type DigestScorePayload struct {
+	Current  float64 `json:"current"`
+	Previous float64 `json:"previous"`
+}
+
+type DigestMetricsPayload struct {
+	Reviews                   int                `json:"reviews"`
+	PreviousReviews           int                `json:"previous_reviews"`
+	NewIssues                 int                `json:"new_issues"`
+	NewCriticalIssues         int                `json:"new_critical_issues"`
+	NewSuggestions            int                `json:"new_suggestions"`
+	NetPromoterScore          DigestScorePayload `json:"net_promoter_score"`
+	CustomerSatisfactionScore DigestScorePayload `json:"customer_satisfaction_score"`
+}
+
+func NewDigestMetricsPayload(metrics DigestMetrics) *DigestMetricsPayload {
+	return &DigestMetricsPayload{
+		Reviews:           metrics.Reviews,
+		PreviousReviews:   metrics.PreviousReviews,
+		NewIssues:         metrics.NewIssues,
+		NewCriticalIssues: metrics.NewCriticalIssues,
+		NewSuggestions:    metrics.NewSuggestions,
+		NetPromoterScore: DigestScorePayload{
+			Current:  metrics.NetPromoterScore.Current,
+			Previous: metrics.NetPromoterScore.Previous,
+		},
+		CustomerSatisfactionScore: DigestScorePayload{
+			Current:  metrics.CustomerSatisfactionScore.Current,
+			Previous: metrics.CustomerSatisfactionScore.Previous,
+		},
+	}
+}
+
+type DigestIssuePayload struct {
+	ID                string `json:"id"`
+	Title             string `json:"title"`
+	Feedbacks         int    `json:"feedbacks"`
+	PreviousFeedbacks int    `json:"previous_feedbacks"`
+	New               bool   `json:"new"`
+}
+
+type DigestSuggestionPayload struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Feedbacks int    `json:"feedbacks"`
+}
+
+type DigestQuotePayload struct {
+	FeedbackID string `json:"feedback_id"`
+	Content    string `json:"content"`
+	Sentiment  string `json:"sentiment"`
+}
+
+type DigestPayload struct {
+	ID            string                    `json:"id"`
+	ProductID     string                    `json:"product_id"`
+	Summary       string                    `json:"summary"`
+	Highlights    []string                  `json:"highlights"`
+	Metrics       DigestMetricsPayload      `json:"metrics"`
+	Issues        []DigestIssuePayload      `json:"issues"`
+	Suggestions   []DigestSuggestionPayload `json:"suggestions"`
+	Quotes        []DigestQuotePayload      `json:"quotes"`
+	Receivers     int                       `json:"receivers"`
+	Failures      int                       `json:"failures"`
+	PeriodStartAt time.Time                 `json:"period_start_at"`
+	PeriodEndAt   time.Time                 `json:"period_end_at"`
+	SentAt        *time.Time                `json:"sent_at"`
+	CreatedAt     time.Time                 `json:"created_at"`
+}
+
+func NewDigestPayload(digest Digest) *DigestPayload {
+	issues := make([]DigestIssuePayload, 0, len(digest.Issues))
+	for _, issue := range digest.Issues {
+		issues = append(issues, DigestIssuePayload{
+			ID:                issue.ID,
+			Title:             issue.Title,
+			Feedbacks:         issue.Feedbacks,
+			PreviousFeedbacks: issue.PreviousFeedbacks,
+			New:               issue.New,
+		})
+	}
+
+	suggestions := make([]DigestSuggestionPayload, 0, len(digest.Suggestions))
+	for _, suggestion := range digest.Suggestions {
+		suggestions = append(suggestions, DigestSuggestionPayload{
+			ID:        suggestion.ID,
+			Title:     suggestion.Title,
+			Feedbacks: suggestion.Feedbacks,
+		})
+	}
+
+	quotes := make([]DigestQuotePayload, 0, len(digest.Quotes))
+	for _, quote := range digest.Quotes {
+		quotes = append(quotes, DigestQuotePayload{
+			FeedbackID: quote.FeedbackID,
+			Content:    quote.Content,
+			Sentiment:  quote.Sentiment,
+		})
+	}
+
+	return &DigestPayload{
+		ID:            digest.ID,
+		ProductID:     digest.ProductID,
+		Summary:       digest.Summary,
+		Highlights:    digest.Highlights,
+		Metrics:       *NewDigestMetricsPayload(digest.Metrics),
+		Issues:        issues,
+		Suggestions:   suggestions,
+		Quotes:        quotes,
+		Receivers:     digest.Receivers,
+		Failures:      len(digest.FailedTo),
+		PeriodStartAt: digest.PeriodStartAt,
+		PeriodEndAt:   digest.PeriodEndAt,
+		SentAt:        digest.SentAt,
+		CreatedAt:     digest.CreatedAt,
+	}
+}
@@ -0,0 +1,328 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/review"
+	"backend/pkg/suggestion"
+	"backend/pkg/util"
+)
+
+type DigestRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewDigestRepository(observer *kit.Observer, database *kit.Database, config config.Config) *DigestRepository {
+	return &DigestRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *DigestRepository) Create(ctx context.Context, digest Digest) (*Digest, error) {
+	d := NewDigestModel(digest)
+
+	stmt := sqlf.
+		InsertInto(DIGEST_MODEL_TABLE).
+		Set("id", d.ID).
+		Set("product_id", d.ProductID).
+		Set("summary", d.Summary).
+		Set("highlights", d.Highlights).
+		Set("metrics", d.Metrics).
+		Set("issues", d.Issues).
+		Set("suggestions", d.Suggestions).
+		Set("quotes", d.Quotes).
+		Set("receivers", d.Receivers).
+		Set("sent_to", d.SentTo).
+		Set("failed_to", d.FailedTo).
+		Set("period_start_at", d.PeriodStartAt).
+		Set("period_end_at", d.PeriodEndAt).
+		Set("sent_at", d.SentAt).
+		Set("created_at", d.CreatedAt).
+		Returning("*").To(&d)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.ToEntity(), nil
+}
+
+func (self *DigestRepository) GetByID(ctx context.Context, id string) (*Digest, error) {
+	var d DigestModel
+
+	stmt := sqlf.
+		Select("*").To(&d).
+		From(DIGEST_MODEL_TABLE).
+		Where("id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return d.ToEntity(), nil
+}
+
+func (self *DigestRepository) GetByProductIDAndPeriodEndAt(ctx context.Context, productID string,
+	periodEndAt time.Time) (*Digest, error) {
+	var d DigestModel
+
+	stmt := sqlf.
+		Select("*").To(&d).
+		From(DIGEST_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		Where("period_end_at = ?", periodEndAt)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return d.ToEntity(), nil
+}
+
+func (self *DigestRepository) ListByProductID(ctx context.Context, productID string,
+	pagination util.Pagination[time.Time]) (*util.Page[Digest, time.Time], error) {
+	var ds []DigestModel
+
+	stmt := sqlf.
+		Select("*").To(&ds).
+		From(DIGEST_MODEL_TABLE).
+		Where("product_id = ?", productID)
+
+	if pagination.From != nil {
+		stmt.
+			Where("(created_at, id) < (?, ?)", pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy("created_at DESC", "id DESC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[Digest, time.Time]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]Digest, 0, len(ds))
+	for _, d := range ds {
+		items = append(items, *d.ToEntity())
+	}
+
+	var cursor *util.Cursor[time.Time]
+	if len(ds) == pagination.Limit {
+		cursor = &util.Cursor[time.Time]{
+			Value: ds[pagination.Limit-1].CreatedAt,
+			ID:    ds[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[Digest, time.Time]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
+
+func (self *DigestRepository) UpdateDelivery(ctx context.Context, digest Digest) error {
+	d := NewDigestModel(digest)
+
+	stmt := sqlf.
+		Update(DIGEST_MODEL_TABLE).
+		Set("sent_to", d.SentTo).
+		Set("failed_to", d.FailedTo).
+		Set("sent_at", d.SentAt).
+		Where("id = ?", d.ID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+// ListIssues returns the active issues of a product that got more feedbacks within the period than within the
+// previous one of the same length, the ones with the most feedbacks within the period first.
+func (self *DigestRepository) ListIssues(ctx context.Context, productID string, periodStartAt time.Time,
+	periodEndAt time.Time, limit int) ([]DigestIssue, error) {
+	var is []struct {
+		ID                string    `db:"id"`
+		Title             string    `db:"title"`
+		FirstSeenAt       time.Time `db:"first_seen_at"`
+		Feedbacks         int       `db:"feedbacks"`
+		PreviousFeedbacks int       `db:"previous_feedbacks"`
+	}
+
+	stmt := sqlf.
+		Select(issue.ISSUE_MODEL_TABLE+".id, "+
+			issue.ISSUE_MODEL_TABLE+".title, "+
+			issue.ISSUE_MODEL_TABLE+".first_seen_at").
+		Select(fmt.Sprintf("COUNT(*) FILTER (WHERE %s.posted_at >= ?) AS feedbacks",
+			feedback.FEEDBACK_MODEL_TABLE), periodStartAt).
+		Select(fmt.Sprintf("COUNT(*) FILTER (WHERE %s.posted_at < ?) AS previous_feedbacks",
+			feedback.FEEDBACK_MODEL_TABLE), periodStartAt).To(&is).
+		From(issue.ISSUE_MODEL_TABLE).
+		Join(issue.ISSUE_FEEDBACK_MODEL_TABLE,
+			issue.ISSUE_FEEDBACK_MODEL_TABLE+".issue_id = "+issue.ISSUE_MODEL_TABLE+".id").
+		Join(feedback.FEEDBACK_MODEL_TABLE,
+			feedback.FEEDBACK_MODEL_TABLE+".id = "+issue.ISSUE_FEEDBACK_MODEL_TABLE+".feedback_id").
+		Where(issue.ISSUE_MODEL_TABLE+".product_id = ?", productID).
+		Where(issue.ISSUE_MODEL_TABLE+".archived_at IS NULL").
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at >= ?", periodStartAt.Add(-periodEndAt.Sub(periodStartAt))).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at < ?", periodEndAt).
+		GroupBy(issue.ISSUE_MODEL_TABLE+".id").
+		Having(fmt.Sprintf("COUNT(*) FILTER (WHERE %s.posted_at >= ?) > COUNT(*) FILTER (WHERE %s.posted_at < ?)",
+			feedback.FEEDBACK_MODEL_TABLE, feedback.FEEDBACK_MODEL_TABLE), periodStartAt, periodStartAt).
+		OrderBy("feedbacks DESC", issue.ISSUE_MODEL_TABLE+".priority DESC", issue.ISSUE_MODEL_TABLE+".id ASC").
+		Limit(limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []DigestIssue{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]DigestIssue, 0, len(is))
+	for _, i := range is {
+		entities = append(entities, DigestIssue{
+			ID:                i.ID,
+			Title:             i.Title,
+			Feedbacks:         i.Feedbacks,
+			PreviousFeedbacks: i.PreviousFeedbacks,
+			New:               !i.FirstSeenAt.Before(periodStartAt),
+		})
+	}
+
+	return entities, nil
+}
+
+// ListSuggestions returns the active suggestions of a product with the most feedbacks within the period.
+func (self *DigestRepository) ListSuggestions(ctx context.Context, productID string, periodStartAt time.Time,
+	periodEndAt time.Time, limit int) ([]DigestSuggestion, error) {
+	var ss []struct {
+		ID        string `db:"id"`
+		Title     string `db:"title"`
+		Feedbacks int    `db:"feedbacks"`
+	}
+
+	stmt := sqlf.
+		Select(suggestion.SUGGESTION_MODEL_TABLE+".id, "+
+			suggestion.SUGGESTION_MODEL_TABLE+".title, "+
+			"COUNT(*) AS feedbacks").To(&ss).
+		From(suggestion.SUGGESTION_MODEL_TABLE).
+		Join(suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE,
+			suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE+".suggestion_id = "+suggestion.SUGGESTION_MODEL_TABLE+".id").
+		Join(feedback.FEEDBACK_MODEL_TABLE,
+			feedback.FEEDBACK_MODEL_TABLE+".id = "+suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE+".feedback_id").
+		Where(suggestion.SUGGESTION_MODEL_TABLE+".product_id = ?", productID).
+		Where(suggestion.SUGGESTION_MODEL_TABLE+".archived_at IS NULL").
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at >= ?", periodStartAt).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at < ?", periodEndAt).
+		GroupBy(suggestion.SUGGESTION_MODEL_TABLE+".id").
+		OrderBy("feedbacks DESC", suggestion.SUGGESTION_MODEL_TABLE+".priority DESC",
+			suggestion.SUGGESTION_MODEL_TABLE+".id ASC").
+		Limit(limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []DigestSuggestion{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]DigestSuggestion, 0, len(ss))
+	for _, s := range ss {
+		entities = append(entities, DigestSuggestion{
+			ID:        s.ID,
+			Title:     s.Title,
+			Feedbacks: s.Feedbacks,
+		})
+	}
+
+	return entities, nil
+}
+
+// ListQuotes returns the notable positive and negative feedbacks of a product within the period, in the language of
+// the product. The feedbacks of the customers with the strongest intentions are preferred, then the most recent.
+func (self *DigestRepository) ListQuotes(ctx context.Context, productID string, language string,
+	periodStartAt time.Time, periodEndAt time.Time, limit int) ([]DigestQuote, error) {
+	var qs []struct {
+		FeedbackID  string `db:"feedback_id"`
+		Content     string `db:"content"`
+		Translation string `db:"translation"`
+		Language    string `db:"language"`
+		Sentiment   string `db:"sentiment"`
+	}
+
+	stmt := sqlf.New(fmt.Sprintf(`SELECT feedback_id, content, translation, language, sentiment FROM (
+			SELECT %[1]s.id AS feedback_id, %[1]s.content, %[1]s.translation, %[1]s.language, %[2]s.sentiment,
+				ROW_NUMBER() OVER (PARTITION BY %[2]s.sentiment ORDER BY %[2]s.intention IN (?, ?) DESC,
+					%[1]s.posted_at DESC, %[1]s.id DESC) AS rank
+			FROM %[2]s JOIN %[1]s ON %[1]s.id = %[2]s.feedback_id
+			WHERE %[2]s.product_id = ? AND %[2]s.sentiment IN (?, ?) AND %[1]s.posted_at >= ? AND %[1]s.posted_at < ?
+				AND char_length(%[1]s.content) BETWEEN ? AND ?
+		) AS quote WHERE rank <= ? ORDER BY sentiment DESC, rank ASC`,
+		feedback.FEEDBACK_MODEL_TABLE, review.REVIEW_MODEL_TABLE),
+		review.ReviewIntentionRetainAndRecommend, review.ReviewIntentionChurnAndDiscourage,
+		productID, review.ReviewSentimentPositive, review.ReviewSentimentNegative, periodStartAt, periodEndAt,
+		DIGEST_MIN_QUOTE_LENGTH, DIGEST_MAX_QUOTE_LENGTH, limit).To(&qs)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []DigestQuote{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]DigestQuote, 0, len(qs))
+	for _, q := range qs {
+		content := q.Content
+		if q.Language != language {
+			content = q.Translation
+		}
+
+		entities = append(entities, DigestQuote{
+			FeedbackID: q.FeedbackID,
+			Content:    content,
+			Sentiment:  q.Sentiment,
+		})
+	}
+
+	return entities, nil
+}
@@ -2,6 +2,8 @@ package engine
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -12,16 +14,48 @@ import (
 )
 
 const (
-	ENGINE_BREAKER_KEY          = "engine:breaker"
-	ENGINE_BREAKER_TIMEOUT      = 30 * time.Second
-	ENGINE_BREAKER_MIN_FAILURES = 25
+	ENGINE_BREAKER_KEY              = "engine:breaker:%s"
+	ENGINE_BREAKER_TIMEOUT          = 30 * time.Second
+	ENGINE_BREAKER_MAX_TIMEOUT      = 10 * time.Minute
+	ENGINE_BREAKER_MIN_FAILURES     = 25
+	ENGINE_BREAKER_MAX_PROBES       = 3
+	ENGINE_BREAKER_PROBE_TIMEOUT    = 2 * ENGINE_SERVICE_TIMEOUT
+	ENGINE_BREAKER_REQUEUE_JITTER   = 30 * time.Second
+	ENGINE_BREAKER_STATE_RETENTION  = 1 * time.Hour
+	ENGINE_BREAKER_STATUS_CLOSED    = "CLOSED"
+	ENGINE_BREAKER_STATUS_OPEN      = "OPEN"
+	ENGINE_BREAKER_STATUS_HALF_OPEN = "HALF_OPEN"
 )
 
+const (
+	ENGINE_BREAKER_OPERATION_TRANSLATION = "translation"
+	ENGINE_BREAKER_OPERATION_EXTRACTION  = "extraction"
+	ENGINE_BREAKER_OPERATION_EMBEDDING   = "embedding"
+	ENGINE_BREAKER_OPERATION_AGGREGATION = "aggregation"
+)
+
+var ENGINE_BREAKER_OPERATIONS = []string{
+	ENGINE_BREAKER_OPERATION_TRANSLATION,
+	ENGINE_BREAKER_OPERATION_EXTRACTION,
+	ENGINE_BREAKER_OPERATION_EMBEDDING,
+	ENGINE_BREAKER_OPERATION_AGGREGATION,
+}
+
 var (
-	ErrEngineBreakerGeneric = errors.New("engine breaker failed")
-	ErrEngineBreakerOpened  = errors.New("engine breaker opened")
+	ErrEngineBreakerGeneric  = errors.New("engine breaker failed")
+	ErrEngineBreakerOpened   = errors.New("engine breaker opened")
+	ErrEngineBreakerReopened = errors.New("engine breaker reopened")
 )
 
+type EngineBreakerState struct {
+	Status   string
+	Failures int
+	Probes   int
+	Timeout  time.Duration
+	OpenedAt time.Time
+	ProbedAt time.Time
+}
+
 type EngineBreaker struct {
 	config   config.Config
 	observer *kit.Observer
@@ -38,72 +72,206 @@ func NewEngineBreaker(observer *kit.Observer, cache *kit.Cache, config config.Co
 	}
 }
 
-func (self *EngineBreaker) IsOpen(ctx context.Context) bool {
-	var failures int
+func (self *EngineBreaker) get(ctx context.Context, operation string) (*EngineBreakerState, error) {
+	state := EngineBreakerState{
+		Status: ENGINE_BREAKER_STATUS_CLOSED,
+	}
 
-	err := self.cache.Get(ctx, ENGINE_BREAKER_KEY, &failures)
+	err := self.cache.Get(ctx, fmt.Sprintf(ENGINE_BREAKER_KEY, operation), &state)
+	if err != nil && !kit.ErrCacheMiss.Is(err) {
+		return nil, ErrEngineBreakerGeneric.Raise().Cause(err)
+	}
+
+	now := time.Now()
+
+	// The open timeout has elapsed so let some probe requests through
+	if state.Status == ENGINE_BREAKER_STATUS_OPEN && now.After(state.OpenedAt.Add(state.Timeout)) {
+		state.Status = ENGINE_BREAKER_STATUS_HALF_OPEN
+		state.Probes = 0
+	}
+
+	// Probes that never reported back (e.g. the worker died) must not keep the circuit half-open forever
+	if state.Status == ENGINE_BREAKER_STATUS_HALF_OPEN && now.After(state.ProbedAt.Add(ENGINE_BREAKER_PROBE_TIMEOUT)) {
+		state.Probes = 0
+	}
+
+	return &state, nil
+}
+
+// TODO: Refactor set functionality with Redis transactional pipelines!
+func (self *EngineBreaker) set(ctx context.Context, operation string, state EngineBreakerState) error {
+	ttl := ENGINE_BREAKER_TIMEOUT
+	if state.Status != ENGINE_BREAKER_STATUS_CLOSED {
+		ttl = state.Timeout + ENGINE_BREAKER_STATE_RETENTION
+	}
+
+	err := self.cache.Set(ctx, fmt.Sprintf(ENGINE_BREAKER_KEY, operation), state, &ttl)
 	if err != nil {
-		if kit.ErrCacheMiss.Is(err) {
-			return false
+		return ErrEngineBreakerGeneric.Raise().Cause(err)
+	}
+
+	return nil
+}
+
+func (self *EngineBreaker) open(ctx context.Context, operation string,
+	state *EngineBreakerState, timeout time.Duration) error {
+	state.Status = ENGINE_BREAKER_STATUS_OPEN
+	state.Failures = max(state.Failures, ENGINE_BREAKER_MIN_FAILURES)
+	state.Probes = 0
+	state.Timeout = timeout
+	state.OpenedAt = time.Now()
+
+	return self.set(ctx, operation, *state)
+}
+
+// Allow reports whether a request for every operation can be sent to the engine, taking a probe slot of each
+// half-open one only when all of them are allowed. When they cannot, it also returns how long the caller should
+// wait before trying again.
+func (self *EngineBreaker) Allow(ctx context.Context, operations ...string) (bool, time.Duration) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	states := make([]*EngineBreakerState, 0, len(operations))
+	for _, operation := range operations {
+		state, err := self.get(ctx, operation)
+		if err != nil {
+			self.observer.Error(ctx, err)
+
+			return false, ENGINE_BREAKER_TIMEOUT + self.jitter()
 		}
 
-		self.observer.Error(ctx, ErrEngineBreakerGeneric.Raise().Cause(err))
+		switch state.Status {
+		case ENGINE_BREAKER_STATUS_OPEN:
+			return false, time.Until(state.OpenedAt.Add(state.Timeout)) + self.jitter()
 
-		return true
+		case ENGINE_BREAKER_STATUS_HALF_OPEN:
+			if state.Probes >= ENGINE_BREAKER_MAX_PROBES {
+				return false, ENGINE_BREAKER_PROBE_TIMEOUT + self.jitter()
+			}
+		}
+
+		states = append(states, state)
 	}
 
-	if failures >= ENGINE_BREAKER_MIN_FAILURES {
-		return true
+	for i, state := range states {
+		if state.Status != ENGINE_BREAKER_STATUS_HALF_OPEN {
+			continue
+		}
+
+		state.Probes++
+		state.ProbedAt = time.Now()
+
+		err := self.set(ctx, operations[i], *state)
+		if err != nil {
+			self.observer.Error(ctx, err)
+
+			return false, ENGINE_BREAKER_TIMEOUT + self.jitter()
+		}
 	}
 
-	return false
+	return true, 0
 }
 
-// TODO: Refactor open functionality with Redis transactional pipelines!
-func (self *EngineBreaker) open(ctx context.Context, failures int, timeout time.Duration) error {
-	err := self.cache.Set(ctx, ENGINE_BREAKER_KEY, failures, &timeout)
+// Open records a failed request for the operation, opening the circuit
+// when there are too many of them or when a probe request fails.
+func (self *EngineBreaker) Open(ctx context.Context, operation string) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	state, err := self.get(ctx, operation)
 	if err != nil {
-		return ErrEngineBreakerGeneric.Raise().Cause(err)
+		return err
 	}
 
-	if failures == ENGINE_BREAKER_MIN_FAILURES {
+	switch state.Status {
+	case ENGINE_BREAKER_STATUS_CLOSED:
+		state.Failures++
+
+		if state.Failures < ENGINE_BREAKER_MIN_FAILURES {
+			return self.set(ctx, operation, *state)
+		}
+
+		err := self.open(ctx, operation, state, ENGINE_BREAKER_TIMEOUT)
+		if err != nil {
+			return err
+		}
+
 		self.observer.Error(ctx, ErrEngineBreakerOpened.Raise().
-			Extra(map[string]any{"timeout": int(timeout.Seconds())}))
+			Extra(map[string]any{"operation": operation, "timeout": int(state.Timeout.Seconds())}))
+
+	case ENGINE_BREAKER_STATUS_HALF_OPEN:
+		err := self.open(ctx, operation, state, min(2*state.Timeout, ENGINE_BREAKER_MAX_TIMEOUT))
+		if err != nil {
+			return err
+		}
+
+		self.observer.Error(ctx, ErrEngineBreakerReopened.Raise().
+			Extra(map[string]any{"operation": operation, "timeout": int(state.Timeout.Seconds())}))
 	}
 
 	return nil
 }
 
-func (self *EngineBreaker) Open(ctx context.Context) error {
+// Succeed records a successful request for the operation, closing the circuit if it was a probe request.
+func (self *EngineBreaker) Succeed(ctx context.Context, operation string) error {
 	self.mutex.Lock()
 	defer self.mutex.Unlock()
 
-	var failures int
+	state, err := self.get(ctx, operation)
+	if err != nil {
+		return err
+	}
 
-	err := self.cache.Get(ctx, ENGINE_BREAKER_KEY, &failures)
-	if err != nil && !kit.ErrCacheMiss.Is(err) {
+	if state.Status != ENGINE_BREAKER_STATUS_HALF_OPEN {
+		return nil
+	}
+
+	err = self.cache.Delete(ctx, fmt.Sprintf(ENGINE_BREAKER_KEY, operation))
+	if err != nil {
 		return ErrEngineBreakerGeneric.Raise().Cause(err)
 	}
 
-	failures++
+	self.observer.Infof(ctx, "Engine %s circuit breaker closed after a successful probe", operation)
 
-	return self.open(ctx, failures, ENGINE_BREAKER_TIMEOUT)
+	return nil
 }
 
-func (self *EngineBreaker) Force(ctx context.Context, timeout time.Duration) error {
+func (self *EngineBreaker) Force(ctx context.Context, operation string, timeout time.Duration) error {
 	self.mutex.Lock()
 	defer self.mutex.Unlock()
 
-	return self.open(ctx, ENGINE_BREAKER_MIN_FAILURES, timeout)
+	err := self.open(ctx, operation, &EngineBreakerState{}, timeout)
+	if err != nil {
+		return err
+	}
+
+	self.observer.Infof(ctx, "Engine %s circuit breaker forced open for %s", operation, timeout)
+
+	return nil
 }
 
-func (self *EngineBreaker) Close(ctx context.Context) error {
-	err := self.cache.Delete(ctx, ENGINE_BREAKER_KEY)
+func (self *EngineBreaker) Close(ctx context.Context, operation string) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	err := self.cache.Delete(ctx, fmt.Sprintf(ENGINE_BREAKER_KEY, operation))
 	if err != nil {
 		return ErrEngineBreakerGeneric.Raise().Cause(err)
 	}
 
-	self.observer.Info(ctx, "Engine circuit breaker closed")
+	self.observer.Infof(ctx, "Engine %s circuit breaker closed", operation)
 
 	return nil
 }
+
+func (self *EngineBreaker) State(ctx context.Context, operation string) (*EngineBreakerState, error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return self.get(ctx, operation)
+}
+
+// Spread requeued tasks so they do not hammer the engine at the same time when the circuit half-opens.
+func (self *EngineBreaker) jitter() time.Duration {
+	return time.Duration(rand.Int63n(int64(ENGINE_BREAKER_REQUEUE_JITTER))) // nolint:gosec
+}
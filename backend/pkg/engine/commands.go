@@ -2,6 +2,7 @@ package engine
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/mkideal/cli"
@@ -31,7 +32,8 @@ func NewEngineCommands(observer *kit.Observer, engineBreaker *EngineBreaker, con
 
 type EngineCommandsOpenBreakerArgs struct {
 	cli.Helper
-	Timeout int `cli:"*timeout" usage:"how long the circuit is opened in seconds"`
+	Timeout   int    `cli:"*timeout" usage:"how long the circuit is opened in seconds"`
+	Operation string `cli:"operation" dft:"" usage:"engine operation whose circuit is opened (all if empty)"`
 }
 
 func (self *EngineCommands) OpenBreaker(ctx context.Context, command *cli.Context) error {
@@ -40,9 +42,20 @@ func (self *EngineCommands) OpenBreaker(ctx context.Context, command *cli.Contex
 		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
 	}
 
-	err := self.engineBreaker.Force(ctx, time.Duration(args.Timeout)*time.Second)
-	if err != nil {
-		return err
+	operations := ENGINE_BREAKER_OPERATIONS
+	if len(args.Operation) > 0 {
+		if !slices.Contains(ENGINE_BREAKER_OPERATIONS, args.Operation) {
+			return kit.ErrRunnerGeneric.Raise().With("unknown engine operation %s", args.Operation)
+		}
+
+		operations = []string{args.Operation}
+	}
+
+	for _, operation := range operations {
+		err := self.engineBreaker.Force(ctx, operation, time.Duration(args.Timeout)*time.Second)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -50,17 +63,29 @@ func (self *EngineCommands) OpenBreaker(ctx context.Context, command *cli.Contex
 
 type EngineCommandsCloseBreakerArgs struct {
 	cli.Helper
+	Operation string `cli:"operation" dft:"" usage:"engine operation whose circuit is closed (all if empty)"`
 }
 
 func (self *EngineCommands) CloseBreaker(ctx context.Context, command *cli.Context) error {
-	_, ok := command.Argv().(*EngineCommandsCloseBreakerArgs)
+	args, ok := command.Argv().(*EngineCommandsCloseBreakerArgs)
 	if !ok {
 		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
 	}
 
-	err := self.engineBreaker.Close(ctx)
-	if err != nil {
-		return err
+	operations := ENGINE_BREAKER_OPERATIONS
+	if len(args.Operation) > 0 {
+		if !slices.Contains(ENGINE_BREAKER_OPERATIONS, args.Operation) {
+			return kit.ErrRunnerGeneric.Raise().With("unknown engine operation %s", args.Operation)
+		}
+
+		operations = []string{args.Operation}
+	}
+
+	for _, operation := range operations {
+		err := self.engineBreaker.Close(ctx, operation)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -13,6 +13,52 @@ type Feedback struct {
 	Content string
 }
 
+// CitableFeedback is a feedback the engine can cite by its ID.
+type CitableFeedback struct {
+	ID      string
+	Content string
+}
+
+// ChatMessage is a question already answered, given as context for the next one.
+type ChatMessage struct {
+	Question string
+	Answer   string
+}
+
+// Category describes one of the options items are classified with.
+type Category struct {
+	Key         string
+	Description string
+	Examples    []string
+}
+
+// Attribute describes one of the custom properties extracted from reviews.
+type Attribute struct {
+	Name        string
+	Type        string
+	Values      []string
+	Description string
+}
+
+type ProposedCategory struct {
+	Name        string
+	Description string
+	Examples    []string
+}
+
+type Persona struct {
+	Name        string
+	Description string
+	Goals       []string
+	Pains       []string
+	Quotes      []string
+}
+
+type Digest struct {
+	Summary    string
+	Highlights []string
+}
+
 type Issue struct {
 	Title       string
 	Description string
@@ -29,11 +75,23 @@ type Suggestion struct {
 	Category    string
 }
 
-type Review struct {
-	Content   string
-	Keywords  []string
+// ReviewAspect is an opinion about one aspect of the product, quoted from the characters between start and end of
+// the feedback.
+type ReviewAspect struct {
+	Aspect    string
 	Sentiment string
-	Emotions  []string
-	Intention string
-	Category  string
+	Quote     string
+	Start     int
+	End       int
+}
+
+type Review struct {
+	Content    string
+	Keywords   []string
+	Sentiment  string
+	Emotions   []string
+	Intention  string
+	Category   string
+	Attributes map[string]string
+	Aspects    []ReviewAspect
 }
@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"time"
@@ -8,14 +9,37 @@ import (
 	"github.com/neoxelox/errors"
 	"github.com/neoxelox/kit"
 	"github.com/neoxelox/kit/util"
+	"golang.org/x/sync/errgroup"
 
 	"backend/pkg/config"
 )
 
 const (
 	ENGINE_SERVICE_TIMEOUT = 59 * time.Second
+	// Rough ratio used to estimate the usage the engine could not report
+	ENGINE_SERVICE_CHARACTERS_PER_TOKEN = 4
+	// Translations of several texts are sent at once, but without flooding the engine
+	ENGINE_SERVICE_MAX_CONCURRENT_TRANSLATIONS = 5
 )
 
+const (
+	ENGINE_EMBEDDING_MODEL_DEFAULT = "text-embedding-3-small"
+)
+
+var (
+	ENGINE_EMBEDDING_MODELS_SUPPORTED = []string{"text-embedding-3-small", "text-embedding-3-large"}
+)
+
+func IsEmbeddingModelSupported(model string) bool {
+	for i := 0; i < len(ENGINE_EMBEDDING_MODELS_SUPPORTED); i++ {
+		if model == ENGINE_EMBEDDING_MODELS_SUPPORTED[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
 var (
 	ErrEngineServiceGeneric  = errors.New("engine service failed")
 	ErrEngineServiceTimedOut = errors.New("engine service timed out")
@@ -169,10 +193,91 @@ func (self *EngineService) TranslateFeedback(ctx context.Context,
 	return &result, nil
 }
 
+type EngineServiceTranslateTextsParams struct {
+	Texts        []string
+	FromLanguage string
+	ToLanguage   string
+}
+
+type EngineServiceTranslateTextsResult struct {
+	Translations []string
+	Usage        Usage
+}
+
+// TranslateTexts translates several short texts concurrently keeping their order, empty texts are not translated.
+func (self *EngineService) TranslateTexts(ctx context.Context,
+	params EngineServiceTranslateTextsParams) (*EngineServiceTranslateTextsResult, error) {
+	results := make([]*EngineServiceTranslateFeedbackResult, len(params.Texts))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(ENGINE_SERVICE_MAX_CONCURRENT_TRANSLATIONS)
+	for i, text := range params.Texts {
+		if len(text) == 0 {
+			continue
+		}
+
+		group.Go(func() error {
+			var err error
+			results[i], err = self.TranslateFeedback(groupCtx, EngineServiceTranslateFeedbackParams{
+				Feedback: Feedback{
+					Content: text,
+				},
+				FromLanguage: params.FromLanguage,
+				ToLanguage:   params.ToLanguage,
+			})
+
+			return err
+		})
+	}
+
+	err := group.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	result := EngineServiceTranslateTextsResult{}
+	result.Translations = make([]string, len(params.Texts))
+	for i := range params.Texts {
+		if results[i] == nil {
+			continue
+		}
+
+		result.Translations[i] = results[i].Translation
+		result.Usage.Input += results[i].Usage.Input
+		result.Usage.Output += results[i].Usage.Output
+	}
+
+	return &result, nil
+}
+
+type postProcessorCategory struct {
+	Key         string   `json:"key"`
+	Description string   `json:"description"`
+	Examples    []string `json:"examples"`
+}
+
+// newPostProcessorCategories keeps sending the plain keys, which are the valid options, along with their details.
+func newPostProcessorCategories(categories []Category) ([]string, []postProcessorCategory) {
+	keys := make([]string, 0, len(categories))
+	taxonomy := make([]postProcessorCategory, 0, len(categories))
+	for _, category := range categories {
+		keys = append(keys, category.Key)
+		taxonomy = append(taxonomy, postProcessorCategory{
+			Key:         category.Key,
+			Description: category.Description,
+			Examples:    category.Examples,
+		})
+	}
+
+	return keys, taxonomy
+}
+
 type postProcessorExtractIssuesRequest struct {
-	Context    string   `json:"context"`
-	Categories []string `json:"categories"`
-	Feedback   string   `json:"feedback"`
+	Context    string                  `json:"context"`
+	Categories []string                `json:"categories"`
+	Taxonomy   []postProcessorCategory `json:"taxonomy"`
+	Feedback   string                  `json:"feedback"`
+	Language   string                  `json:"language"`
 }
 
 type postProcessorExtractIssuesResponse struct {
@@ -191,8 +296,9 @@ type postProcessorExtractIssuesResponse struct {
 
 type EngineServiceExtractIssuesParams struct {
 	Context    string
-	Categories []string
+	Categories []Category
 	Feedback   Feedback
+	Language   string
 }
 
 type EngineServiceExtractIssuesResult struct {
@@ -204,8 +310,9 @@ func (self *EngineService) ExtractIssues(ctx context.Context,
 	params EngineServiceExtractIssuesParams) (*EngineServiceExtractIssuesResult, error) {
 	requestBody := postProcessorExtractIssuesRequest{}
 	requestBody.Context = params.Context
-	requestBody.Categories = params.Categories
+	requestBody.Categories, requestBody.Taxonomy = newPostProcessorCategories(params.Categories)
 	requestBody.Feedback = params.Feedback.Content
+	requestBody.Language = params.Language
 
 	requestBodyJSON, err := json.Marshal(requestBody)
 	if err != nil {
@@ -249,9 +356,11 @@ func (self *EngineService) ExtractIssues(ctx context.Context,
 }
 
 type postProcessorExtractSuggestionsRequest struct {
-	Context    string   `json:"context"`
-	Categories []string `json:"categories"`
-	Feedback   string   `json:"feedback"`
+	Context    string                  `json:"context"`
+	Categories []string                `json:"categories"`
+	Taxonomy   []postProcessorCategory `json:"taxonomy"`
+	Feedback   string                  `json:"feedback"`
+	Language   string                  `json:"language"`
 }
 
 type postProcessorExtractSuggestionsResponse struct {
@@ -270,8 +379,9 @@ type postProcessorExtractSuggestionsResponse struct {
 
 type EngineServiceExtractSuggestionsParams struct {
 	Context    string
-	Categories []string
+	Categories []Category
 	Feedback   Feedback
+	Language   string
 }
 
 type EngineServiceExtractSuggestionsResult struct {
@@ -283,8 +393,9 @@ func (self *EngineService) ExtractSuggestions(ctx context.Context,
 	params EngineServiceExtractSuggestionsParams) (*EngineServiceExtractSuggestionsResult, error) {
 	requestBody := postProcessorExtractSuggestionsRequest{}
 	requestBody.Context = params.Context
-	requestBody.Categories = params.Categories
+	requestBody.Categories, requestBody.Taxonomy = newPostProcessorCategories(params.Categories)
 	requestBody.Feedback = params.Feedback.Content
+	requestBody.Language = params.Language
 
 	requestBodyJSON, err := json.Marshal(requestBody)
 	if err != nil {
@@ -327,20 +438,36 @@ func (self *EngineService) ExtractSuggestions(ctx context.Context,
 	return &result, nil
 }
 
+type postProcessorAttribute struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Values      []string `json:"values"`
+	Description string   `json:"description"`
+}
+
 type postProcessorExtractReviewRequest struct {
-	Context    string   `json:"context"`
-	Categories []string `json:"categories"`
-	Feedback   string   `json:"feedback"`
+	Context    string                   `json:"context"`
+	Categories []string                 `json:"categories"`
+	Taxonomy   []postProcessorCategory  `json:"taxonomy"`
+	Attributes []postProcessorAttribute `json:"attributes"`
+	Feedback   string                   `json:"feedback"`
+	Language   string                   `json:"language"`
 }
 
 type postProcessorExtractReviewResponse struct {
 	Review struct {
-		Content   string   `json:"content"`
-		Keywords  []string `json:"keywords"`
-		Sentiment string   `json:"sentiment"`
-		Emotions  []string `json:"emotions"`
-		Intention string   `json:"intention"`
-		Category  string   `json:"category"`
+		Content    string            `json:"content"`
+		Keywords   []string          `json:"keywords"`
+		Sentiment  string            `json:"sentiment"`
+		Emotions   []string          `json:"emotions"`
+		Intention  string            `json:"intention"`
+		Category   string            `json:"category"`
+		Attributes map[string]string `json:"attributes"`
+		Aspects    []struct {
+			Aspect    string `json:"aspect"`
+			Sentiment string `json:"sentiment"`
+			Quote     string `json:"quote"`
+		} `json:"aspects"`
 	} `json:"review"`
 	Usage struct {
 		Input  int `json:"input"`
@@ -350,8 +477,10 @@ type postProcessorExtractReviewResponse struct {
 
 type EngineServiceExtractReviewParams struct {
 	Context    string
-	Categories []string
+	Categories []Category
+	Attributes []Attribute
 	Feedback   Feedback
+	Language   string
 }
 
 type EngineServiceExtractReviewResult struct {
@@ -363,8 +492,18 @@ func (self *EngineService) ExtractReview(ctx context.Context,
 	params EngineServiceExtractReviewParams) (*EngineServiceExtractReviewResult, error) {
 	requestBody := postProcessorExtractReviewRequest{}
 	requestBody.Context = params.Context
-	requestBody.Categories = params.Categories
+	requestBody.Categories, requestBody.Taxonomy = newPostProcessorCategories(params.Categories)
+	requestBody.Attributes = make([]postProcessorAttribute, 0, len(params.Attributes))
+	for _, attribute := range params.Attributes {
+		requestBody.Attributes = append(requestBody.Attributes, postProcessorAttribute{
+			Name:        attribute.Name,
+			Type:        attribute.Type,
+			Values:      attribute.Values,
+			Description: attribute.Description,
+		})
+	}
 	requestBody.Feedback = params.Feedback.Content
+	requestBody.Language = params.Language
 
 	requestBodyJSON, err := json.Marshal(requestBody)
 	if err != nil {
@@ -390,12 +529,257 @@ func (self *EngineService) ExtractReview(ctx context.Context,
 
 	result := EngineServiceExtractReviewResult{}
 	result.Review = Review{
-		Content:   responseBody.Review.Content,
-		Keywords:  responseBody.Review.Keywords,
-		Sentiment: responseBody.Review.Sentiment,
-		Emotions:  responseBody.Review.Emotions,
-		Intention: responseBody.Review.Intention,
-		Category:  responseBody.Review.Category,
+		Content:    responseBody.Review.Content,
+		Keywords:   responseBody.Review.Keywords,
+		Sentiment:  responseBody.Review.Sentiment,
+		Emotions:   responseBody.Review.Emotions,
+		Intention:  responseBody.Review.Intention,
+		Category:   responseBody.Review.Category,
+		Attributes: responseBody.Review.Attributes,
+		Aspects:    make([]ReviewAspect, 0, len(responseBody.Review.Aspects)),
+	}
+	for _, aspect := range responseBody.Review.Aspects {
+		result.Review.Aspects = append(result.Review.Aspects, ReviewAspect{
+			Aspect:    aspect.Aspect,
+			Sentiment: aspect.Sentiment,
+			Quote:     aspect.Quote,
+		})
+	}
+	result.Usage = Usage{
+		Input:  responseBody.Usage.Input,
+		Output: responseBody.Usage.Output,
+	}
+
+	return &result, nil
+}
+
+type postProcessorProposeCategoryRequest struct {
+	Context    string   `json:"context"`
+	Categories []string `json:"categories"`
+	Items      []string `json:"items"`
+}
+
+type postProcessorProposeCategoryResponse struct {
+	Category struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Examples    []string `json:"examples"`
+	} `json:"category"`
+	Usage struct {
+		Input  int `json:"input"`
+		Output int `json:"output"`
+	} `json:"usage"`
+}
+
+type EngineServiceProposeCategoryParams struct {
+	Context    string
+	Categories []string
+	Items      []string
+}
+
+type EngineServiceProposeCategoryResult struct {
+	Category ProposedCategory
+	Usage    Usage
+}
+
+func (self *EngineService) ProposeCategory(ctx context.Context,
+	params EngineServiceProposeCategoryParams) (*EngineServiceProposeCategoryResult, error) {
+	requestBody := postProcessorProposeCategoryRequest{}
+	requestBody.Context = params.Context
+	requestBody.Categories = params.Categories
+	requestBody.Items = params.Items
+
+	requestBodyJSON, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
+	}
+
+	response, err := self.client.Request(ctx, "POST", "/processor/propose-category", requestBodyJSON, nil)
+	if err != nil {
+		if kit.ErrHTTPClientTimedOut.Is(err) {
+			return nil, ErrEngineServiceTimedOut.Raise().Cause(err)
+		}
+
+		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
+	}
+	defer response.Body.Close()
+
+	responseBody := postProcessorProposeCategoryResponse{}
+
+	err = json.NewDecoder(response.Body).Decode(&responseBody)
+	if err != nil {
+		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
+	}
+
+	result := EngineServiceProposeCategoryResult{}
+	result.Category = ProposedCategory{
+		Name:        responseBody.Category.Name,
+		Description: responseBody.Category.Description,
+		Examples:    responseBody.Category.Examples,
+	}
+	result.Usage = Usage{
+		Input:  responseBody.Usage.Input,
+		Output: responseBody.Usage.Output,
+	}
+
+	return &result, nil
+}
+
+type postProcessorSynthesizePersonaRequest struct {
+	Context   string   `json:"context"`
+	Traits    []string `json:"traits"`
+	Feedbacks []string `json:"feedbacks"`
+	Language  string   `json:"language"`
+}
+
+type postProcessorSynthesizePersonaResponse struct {
+	Persona struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Goals       []string `json:"goals"`
+		Pains       []string `json:"pains"`
+		Quotes      []string `json:"quotes"`
+	} `json:"persona"`
+	Usage struct {
+		Input  int `json:"input"`
+		Output int `json:"output"`
+	} `json:"usage"`
+}
+
+type EngineServiceSynthesizePersonaParams struct {
+	Context   string
+	Traits    []string
+	Feedbacks []Feedback
+	Language  string
+}
+
+type EngineServiceSynthesizePersonaResult struct {
+	Persona Persona
+	Usage   Usage
+}
+
+func (self *EngineService) SynthesizePersona(ctx context.Context,
+	params EngineServiceSynthesizePersonaParams) (*EngineServiceSynthesizePersonaResult, error) {
+	requestBody := postProcessorSynthesizePersonaRequest{}
+	requestBody.Context = params.Context
+	requestBody.Traits = params.Traits
+	requestBody.Feedbacks = make([]string, 0, len(params.Feedbacks))
+	for _, feedback := range params.Feedbacks {
+		requestBody.Feedbacks = append(requestBody.Feedbacks, feedback.Content)
+	}
+	requestBody.Language = params.Language
+
+	requestBodyJSON, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
+	}
+
+	response, err := self.client.Request(ctx, "POST", "/processor/synthesize-persona", requestBodyJSON, nil)
+	if err != nil {
+		if kit.ErrHTTPClientTimedOut.Is(err) {
+			return nil, ErrEngineServiceTimedOut.Raise().Cause(err)
+		}
+
+		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
+	}
+	defer response.Body.Close()
+
+	responseBody := postProcessorSynthesizePersonaResponse{}
+
+	err = json.NewDecoder(response.Body).Decode(&responseBody)
+	if err != nil {
+		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
+	}
+
+	result := EngineServiceSynthesizePersonaResult{}
+	result.Persona = Persona{
+		Name:        responseBody.Persona.Name,
+		Description: responseBody.Persona.Description,
+		Goals:       responseBody.Persona.Goals,
+		Pains:       responseBody.Persona.Pains,
+		Quotes:      responseBody.Persona.Quotes,
+	}
+	result.Usage = Usage{
+		Input:  responseBody.Usage.Input,
+		Output: responseBody.Usage.Output,
+	}
+
+	return &result, nil
+}
+
+type postProcessorSummarizeDigestRequest struct {
+	Context     string   `json:"context"`
+	Facts       []string `json:"facts"`
+	Issues      []string `json:"issues"`
+	Suggestions []string `json:"suggestions"`
+	Quotes      []string `json:"quotes"`
+	Language    string   `json:"language"`
+}
+
+type postProcessorSummarizeDigestResponse struct {
+	Digest struct {
+		Summary    string   `json:"summary"`
+		Highlights []string `json:"highlights"`
+	} `json:"digest"`
+	Usage struct {
+		Input  int `json:"input"`
+		Output int `json:"output"`
+	} `json:"usage"`
+}
+
+type EngineServiceSummarizeDigestParams struct {
+	Context     string
+	Facts       []string
+	Issues      []string
+	Suggestions []string
+	Quotes      []Feedback
+	Language    string
+}
+
+type EngineServiceSummarizeDigestResult struct {
+	Digest Digest
+	Usage  Usage
+}
+
+func (self *EngineService) SummarizeDigest(ctx context.Context,
+	params EngineServiceSummarizeDigestParams) (*EngineServiceSummarizeDigestResult, error) {
+	requestBody := postProcessorSummarizeDigestRequest{}
+	requestBody.Context = params.Context
+	requestBody.Facts = params.Facts
+	requestBody.Issues = params.Issues
+	requestBody.Suggestions = params.Suggestions
+	requestBody.Quotes = make([]string, 0, len(params.Quotes))
+	for _, quote := range params.Quotes {
+		requestBody.Quotes = append(requestBody.Quotes, quote.Content)
+	}
+	requestBody.Language = params.Language
+
+	requestBodyJSON, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
+	}
+
+	response, err := self.client.Request(ctx, "POST", "/processor/summarize-digest", requestBodyJSON, nil)
+	if err != nil {
+		if kit.ErrHTTPClientTimedOut.Is(err) {
+			return nil, ErrEngineServiceTimedOut.Raise().Cause(err)
+		}
+
+		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
+	}
+	defer response.Body.Close()
+
+	responseBody := postProcessorSummarizeDigestResponse{}
+
+	err = json.NewDecoder(response.Body).Decode(&responseBody)
+	if err != nil {
+		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
+	}
+
+	result := EngineServiceSummarizeDigestResult{}
+	result.Digest = Digest{
+		Summary:    responseBody.Digest.Summary,
+		Highlights: responseBody.Digest.Highlights,
 	}
 	result.Usage = Usage{
 		Input:  responseBody.Usage.Input,
@@ -406,11 +790,13 @@ func (self *EngineService) ExtractReview(ctx context.Context,
 }
 
 type postAggregatorComputeEmbeddingRequest struct {
-	Text string `json:"text"`
+	Text  string `json:"text"`
+	Model string `json:"model"`
 }
 
 type postAggregatorComputeEmbeddingResponse struct {
 	Embedding []float32 `json:"embedding"`
+	Model     string    `json:"model"`
 	Usage     struct {
 		Input  int `json:"input"`
 		Output int `json:"output"`
@@ -418,11 +804,13 @@ type postAggregatorComputeEmbeddingResponse struct {
 }
 
 type EngineServiceComputeEmbeddingParams struct {
-	Text string
+	Text  string
+	Model string
 }
 
 type EngineServiceComputeEmbeddingResult struct {
 	Embedding []float32
+	Model     string
 	Usage     Usage
 }
 
@@ -430,6 +818,7 @@ func (self *EngineService) ComputeEmbedding(ctx context.Context,
 	params EngineServiceComputeEmbeddingParams) (*EngineServiceComputeEmbeddingResult, error) {
 	requestBody := postAggregatorComputeEmbeddingRequest{}
 	requestBody.Text = params.Text
+	requestBody.Model = params.Model
 
 	requestBodyJSON, err := json.Marshal(requestBody)
 	if err != nil {
@@ -453,8 +842,15 @@ func (self *EngineService) ComputeEmbedding(ctx context.Context,
 		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
 	}
 
+	// Embeddings of different models cannot be compared, so never accept one from an unexpected model
+	if responseBody.Model != params.Model {
+		return nil, ErrEngineServiceGeneric.Raise().
+			With("expected embedding model %s but got %s", params.Model, responseBody.Model)
+	}
+
 	result := EngineServiceComputeEmbeddingResult{}
 	result.Embedding = responseBody.Embedding
+	result.Model = responseBody.Model
 	result.Usage = Usage{
 		Input:  responseBody.Usage.Input,
 		Output: responseBody.Usage.Output,
@@ -464,8 +860,9 @@ func (self *EngineService) ComputeEmbedding(ctx context.Context,
 }
 
 type postAggregatorSimilarIssueRequest struct {
-	Issue   string   `json:"issue"`
-	Options []string `json:"options"`
+	Issue    string   `json:"issue"`
+	Options  []string `json:"options"`
+	Language string   `json:"language"`
 }
 
 type postAggregatorSimilarIssueResponse struct {
@@ -477,8 +874,9 @@ type postAggregatorSimilarIssueResponse struct {
 }
 
 type EngineServiceSimilarIssueParams struct {
-	Issue   Issue
-	Options []Issue
+	Issue    Issue
+	Options  []Issue
+	Language string
 }
 
 type EngineServiceSimilarIssueResult struct {
@@ -494,6 +892,7 @@ func (self *EngineService) SimilarIssue(ctx context.Context,
 	for _, issue := range params.Options {
 		requestBody.Options = append(requestBody.Options, issue.Description)
 	}
+	requestBody.Language = params.Language
 
 	requestBodyJSON, err := json.Marshal(requestBody)
 	if err != nil {
@@ -541,6 +940,7 @@ type postAggregatorMergeIssuesRequest struct {
 		Description string   `json:"description"`
 		Steps       []string `json:"steps"`
 	} `json:"issue_b"`
+	Language string `json:"language"`
 }
 
 type postAggregatorMergeIssuesResponse struct {
@@ -556,8 +956,9 @@ type postAggregatorMergeIssuesResponse struct {
 }
 
 type EngineServiceMergeIssuesParams struct {
-	IssueA Issue
-	IssueB Issue
+	IssueA   Issue
+	IssueB   Issue
+	Language string
 }
 
 type EngineServiceMergeIssuesResult struct {
@@ -574,6 +975,7 @@ func (self *EngineService) MergeIssues(ctx context.Context,
 	requestBody.IssueB.Title = params.IssueB.Title
 	requestBody.IssueB.Description = params.IssueB.Description
 	requestBody.IssueB.Steps = params.IssueB.Steps
+	requestBody.Language = params.Language
 
 	requestBodyJSON, err := json.Marshal(requestBody)
 	if err != nil {
@@ -616,6 +1018,7 @@ func (self *EngineService) MergeIssues(ctx context.Context,
 type postAggregatorSimilarSuggestionRequest struct {
 	Suggestion string   `json:"suggestion"`
 	Options    []string `json:"options"`
+	Language   string   `json:"language"`
 }
 
 type postAggregatorSimilarSuggestionResponse struct {
@@ -629,6 +1032,7 @@ type postAggregatorSimilarSuggestionResponse struct {
 type EngineServiceSimilarSuggestionParams struct {
 	Suggestion Suggestion
 	Options    []Suggestion
+	Language   string
 }
 
 type EngineServiceSimilarSuggestionResult struct {
@@ -644,6 +1048,7 @@ func (self *EngineService) SimilarSuggestion(ctx context.Context,
 	for _, suggestion := range params.Options {
 		requestBody.Options = append(requestBody.Options, suggestion.Description)
 	}
+	requestBody.Language = params.Language
 
 	requestBodyJSON, err := json.Marshal(requestBody)
 	if err != nil {
@@ -691,6 +1096,7 @@ type postAggregatorMergeSuggestionsRequest struct {
 		Description string `json:"description"`
 		Reason      string `json:"reason"`
 	} `json:"suggestion_b"`
+	Language string `json:"language"`
 }
 
 type postAggregatorMergeSuggestionsResponse struct {
@@ -708,6 +1114,7 @@ type postAggregatorMergeSuggestionsResponse struct {
 type EngineServiceMergeSuggestionsParams struct {
 	SuggestionA Suggestion
 	SuggestionB Suggestion
+	Language    string
 }
 
 type EngineServiceMergeSuggestionsResult struct {
@@ -724,6 +1131,7 @@ func (self *EngineService) MergeSuggestions(ctx context.Context,
 	requestBody.SuggestionB.Title = params.SuggestionB.Title
 	requestBody.SuggestionB.Description = params.SuggestionB.Description
 	requestBody.SuggestionB.Reason = params.SuggestionB.Reason
+	requestBody.Language = params.Language
 
 	requestBodyJSON, err := json.Marshal(requestBody)
 	if err != nil {
@@ -763,6 +1171,171 @@ func (self *EngineService) MergeSuggestions(ctx context.Context,
 	return &result, nil
 }
 
+type postChatFeedback struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+}
+
+type postChatSummary struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type postChatMessage struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+type postChatAnswerQuestionRequest struct {
+	Context     string             `json:"context"`
+	Question    string             `json:"question"`
+	Feedbacks   []postChatFeedback `json:"feedbacks"`
+	Issues      []postChatSummary  `json:"issues"`
+	Suggestions []postChatSummary  `json:"suggestions"`
+	History     []postChatMessage  `json:"history"`
+}
+
+type postChatAnswerQuestionEvent struct {
+	Delta     *string   `json:"delta"`
+	Citations *[]string `json:"citations"`
+	Usage     *struct {
+		Input  int `json:"input"`
+		Output int `json:"output"`
+	} `json:"usage"`
+}
+
+type EngineServiceAnswerQuestionParams struct {
+	Context     string
+	Question    string
+	Feedbacks   []CitableFeedback
+	Issues      []Issue
+	Suggestions []Suggestion
+	History     []ChatMessage
+}
+
+type EngineServiceAnswerQuestionResult struct {
+	Answer    string
+	Citations []string
+	Usage     Usage
+}
+
+// AnswerQuestion streams the answer to a question grounded on the sources, calling onDelta with every fragment of
+// the answer as soon as it is received. If onDelta fails the answer is aborted. Once the question is sent, the
+// answer received so far is returned even on failure, as its usage has to be charged anyway.
+func (self *EngineService) AnswerQuestion(ctx context.Context, params EngineServiceAnswerQuestionParams,
+	onDelta func(delta string) error) (*EngineServiceAnswerQuestionResult, error) {
+	requestBody := postChatAnswerQuestionRequest{}
+	requestBody.Context = params.Context
+	requestBody.Question = params.Question
+	requestBody.Feedbacks = make([]postChatFeedback, 0, len(params.Feedbacks))
+	for _, feedback := range params.Feedbacks {
+		requestBody.Feedbacks = append(requestBody.Feedbacks, postChatFeedback{
+			ID:      feedback.ID,
+			Content: feedback.Content,
+		})
+	}
+	requestBody.Issues = make([]postChatSummary, 0, len(params.Issues))
+	for _, issue := range params.Issues {
+		requestBody.Issues = append(requestBody.Issues, postChatSummary{
+			Title:       issue.Title,
+			Description: issue.Description,
+		})
+	}
+	requestBody.Suggestions = make([]postChatSummary, 0, len(params.Suggestions))
+	for _, suggestion := range params.Suggestions {
+		requestBody.Suggestions = append(requestBody.Suggestions, postChatSummary{
+			Title:       suggestion.Title,
+			Description: suggestion.Description,
+		})
+	}
+	requestBody.History = make([]postChatMessage, 0, len(params.History))
+	for _, message := range params.History {
+		requestBody.History = append(requestBody.History, postChatMessage{
+			Question: message.Question,
+			Answer:   message.Answer,
+		})
+	}
+
+	requestBodyJSON, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
+	}
+
+	response, err := self.client.Request(ctx, "POST", "/chat/answer-question", requestBodyJSON, nil)
+	if err != nil {
+		if kit.ErrHTTPClientTimedOut.Is(err) {
+			return nil, ErrEngineServiceTimedOut.Raise().Cause(err)
+		}
+
+		return nil, ErrEngineServiceGeneric.Raise().Cause(err)
+	}
+	defer response.Body.Close()
+
+	result := EngineServiceAnswerQuestionResult{}
+	result.Citations = []string{}
+	done := false
+
+	// The engine only reports the usage at the end of the stream, so an interrupted answer is estimated from the
+	// characters sent and received
+	abort := func(err error) (*EngineServiceAnswerQuestionResult, error) {
+		if !done {
+			result.Usage = Usage{
+				Input:  len(requestBodyJSON) / ENGINE_SERVICE_CHARACTERS_PER_TOKEN,
+				Output: len(result.Answer) / ENGINE_SERVICE_CHARACTERS_PER_TOKEN,
+			}
+		}
+
+		return &result, err
+	}
+
+	// Every line of the response is an event, the last one has the citations and the usage
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		event := postChatAnswerQuestionEvent{}
+
+		err = json.Unmarshal(scanner.Bytes(), &event)
+		if err != nil {
+			return abort(ErrEngineServiceGeneric.Raise().Cause(err))
+		}
+
+		if event.Delta != nil {
+			result.Answer += *event.Delta
+
+			err = onDelta(*event.Delta)
+			if err != nil {
+				return abort(ErrEngineServiceGeneric.Raise().Cause(err))
+			}
+		}
+
+		if event.Citations != nil && *event.Citations != nil {
+			result.Citations = *event.Citations
+		}
+
+		if event.Usage != nil {
+			result.Usage = Usage{
+				Input:  event.Usage.Input,
+				Output: event.Usage.Output,
+			}
+			done = true
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return abort(ErrEngineServiceGeneric.Raise().Cause(err))
+	}
+
+	if !done {
+		return abort(ErrEngineServiceGeneric.Raise().With("answer stream ended unexpectedly"))
+	}
+
+	return &result, nil
+}
+
 func (self *EngineService) Close(ctx context.Context) error {
 	err := util.Deadline(ctx, func(exceeded <-chan struct{}) error {
 		self.observer.Info(ctx, "Closing Engine service")
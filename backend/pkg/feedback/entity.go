@@ -24,6 +24,11 @@ const (
 	FeedbackSourceWidget     = "WIDGET"
 )
 
+const (
+	FeedbackLanguageDetectionLocal  = "LOCAL"
+	FeedbackLanguageDetectionEngine = "ENGINE"
+)
+
 func IsFeedbackSource(value string) bool {
 	return value == FeedbackSourceTrustpilot ||
 		value == FeedbackSourcePlayStore ||
@@ -53,21 +58,24 @@ type FeedbackMetadata struct {
 }
 
 type Feedback struct {
-	ID           string
-	ProductID    string
-	Hash         string
-	Source       string
-	Customer     FeedbackCustomer
-	Content      string
-	Language     string
-	Translation  string
-	Release      string
-	Metadata     FeedbackMetadata
-	Tokens       int
-	PostedAt     time.Time
-	CollectedAt  time.Time
-	TranslatedAt *time.Time
-	ProcessedAt  *time.Time
+	ID                string
+	ProductID         string
+	Hash              string
+	Source            string
+	Customer          FeedbackCustomer
+	Content           string
+	Language          string
+	LanguageDetection *string
+	Translation       string
+	Release           string
+	Metadata          FeedbackMetadata
+	Tokens            int
+	PostedAt          time.Time
+	CollectedAt       time.Time
+	TranslatedAt      *time.Time
+	ProcessedAt       *time.Time
+	AggregatedAt      *time.Time
+	SkippedAt         *time.Time
 }
 
 func NewFeedback() *Feedback {
@@ -86,6 +94,16 @@ func (self Feedback) Copy() *Feedback {
 	return util.Copy(self)
 }
 
+// Text returns the feedback in the language of its product, which is its content when it was already written in it
+// and its translation otherwise.
+func (self Feedback) Text(language string) string {
+	if self.Language == language {
+		return self.Content
+	}
+
+	return self.Translation
+}
+
 const (
 	// nolint: lll, revive
 	PUNCTUATION_MARKS = "!\"#%&'()*,./:;?@[\\]^_`{|}~\xA0¡¦§¨©ª«¬\xAD®¯²³´µ¶·¸¹º»¿‐‑‒–—―‖‗‘’‚‛“”„‟†‡•‣․‥…‧‰‱′″‴‵‶‷‸‹›※‼‽‾‿⁀⁁⁂⁃⁄⁅⁆⁇⁈⁉⁊⁋⁌⁍⁎⁏⁐⁑⁒⁓⁔⁕⁖⁗⁘⁙⁚⁛⁜⁝⁞™"
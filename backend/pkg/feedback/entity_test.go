@@ -0,0 +1,45 @@
+package feedback_test
+
+import (
+	"testing"
+
+	"backend/pkg/feedback"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FeedbackTestSuite struct {
+	suite.Suite
+}
+
+func TestFeedbackSuite(t *testing.T) {
+	suite.Run(t, new(FeedbackTestSuite))
+}
+
+func (self *FeedbackTestSuite) TestTextSameLanguage() {
+	// Given: A feedback written in the language of its product, which is never translated
+	_feedback := feedback.NewFeedback()
+	_feedback.Content = "The app crashes when I open the camera"
+	_feedback.Language = "ENGLISH"
+	_feedback.Translation = ""
+
+	// When: The feedback is read in the language of its product
+	text := _feedback.Text("ENGLISH")
+
+	// Then: Its content is read
+	self.Equal("The app crashes when I open the camera", text)
+}
+
+func (self *FeedbackTestSuite) TestTextTranslated() {
+	// Given: A feedback written in another language than the one of its product
+	_feedback := feedback.NewFeedback()
+	_feedback.Content = "La app se cierra al abrir la cámara"
+	_feedback.Language = "SPANISH"
+	_feedback.Translation = "The app closes when opening the camera"
+
+	// When: The feedback is read in the language of its product
+	text := _feedback.Text("ENGLISH")
+
+	// Then: Its translation is read
+	self.Equal("The app closes when opening the camera", text)
+}
@@ -6,25 +6,29 @@ import (
 )
 
 const (
-	FEEDBACK_MODEL_TABLE = "\"feedback\""
+	FEEDBACK_MODEL_TABLE           = "\"feedback\""
+	FEEDBACK_EMBEDDING_MODEL_TABLE = "\"feedback_embedding\""
 )
 
 type FeedbackModel struct {
-	ID           string     `db:"id"`
-	ProductID    string     `db:"product_id"`
-	Hash         string     `db:"hash"`
-	Source       string     `db:"source"`
-	Customer     []byte     `db:"customer"`
-	Content      string     `db:"content"`
-	Language     string     `db:"language"`
-	Translation  string     `db:"translation"`
-	Release      string     `db:"release"`
-	Metadata     []byte     `db:"metadata"`
-	Tokens       int        `db:"tokens"`
-	PostedAt     time.Time  `db:"posted_at"`
-	CollectedAt  time.Time  `db:"collected_at"`
-	TranslatedAt *time.Time `db:"translated_at"`
-	ProcessedAt  *time.Time `db:"processed_at"`
+	ID                string     `db:"id"`
+	ProductID         string     `db:"product_id"`
+	Hash              string     `db:"hash"`
+	Source            string     `db:"source"`
+	Customer          []byte     `db:"customer"`
+	Content           string     `db:"content"`
+	Language          string     `db:"language"`
+	LanguageDetection *string    `db:"language_detection"`
+	Translation       string     `db:"translation"`
+	Release           string     `db:"release"`
+	Metadata          []byte     `db:"metadata"`
+	Tokens            int        `db:"tokens"`
+	PostedAt          time.Time  `db:"posted_at"`
+	CollectedAt       time.Time  `db:"collected_at"`
+	TranslatedAt      *time.Time `db:"translated_at"`
+	ProcessedAt       *time.Time `db:"processed_at"`
+	AggregatedAt      *time.Time `db:"aggregated_at"`
+	SkippedAt         *time.Time `db:"skipped_at"`
 }
 
 func NewFeedbackModel(feedback Feedback) *FeedbackModel {
@@ -39,21 +43,24 @@ func NewFeedbackModel(feedback Feedback) *FeedbackModel {
 	}
 
 	return &FeedbackModel{
-		ID:           feedback.ID,
-		ProductID:    feedback.ProductID,
-		Hash:         feedback.Hash,
-		Source:       feedback.Source,
-		Customer:     customer,
-		Content:      feedback.Content,
-		Language:     feedback.Language,
-		Translation:  feedback.Translation,
-		Release:      feedback.Release,
-		Metadata:     metadata,
-		Tokens:       feedback.Tokens,
-		PostedAt:     feedback.PostedAt,
-		CollectedAt:  feedback.CollectedAt,
-		TranslatedAt: feedback.TranslatedAt,
-		ProcessedAt:  feedback.ProcessedAt,
+		ID:                feedback.ID,
+		ProductID:         feedback.ProductID,
+		Hash:              feedback.Hash,
+		Source:            feedback.Source,
+		Customer:          customer,
+		Content:           feedback.Content,
+		Language:          feedback.Language,
+		LanguageDetection: feedback.LanguageDetection,
+		Translation:       feedback.Translation,
+		Release:           feedback.Release,
+		Metadata:          metadata,
+		Tokens:            feedback.Tokens,
+		PostedAt:          feedback.PostedAt,
+		CollectedAt:       feedback.CollectedAt,
+		TranslatedAt:      feedback.TranslatedAt,
+		ProcessedAt:       feedback.ProcessedAt,
+		AggregatedAt:      feedback.AggregatedAt,
+		SkippedAt:         feedback.SkippedAt,
 	}
 }
 
@@ -71,20 +78,23 @@ func (self *FeedbackModel) ToEntity() *Feedback {
 	}
 
 	return &Feedback{
-		ID:           self.ID,
-		ProductID:    self.ProductID,
-		Hash:         self.Hash,
-		Source:       self.Source,
-		Customer:     customer,
-		Content:      self.Content,
-		Language:     self.Language,
-		Translation:  self.Translation,
-		Release:      self.Release,
-		Metadata:     metadata,
-		Tokens:       self.Tokens,
-		PostedAt:     self.PostedAt,
-		CollectedAt:  self.CollectedAt,
-		TranslatedAt: self.TranslatedAt,
-		ProcessedAt:  self.ProcessedAt,
+		ID:                self.ID,
+		ProductID:         self.ProductID,
+		Hash:              self.Hash,
+		Source:            self.Source,
+		Customer:          customer,
+		Content:           self.Content,
+		Language:          self.Language,
+		LanguageDetection: self.LanguageDetection,
+		Translation:       self.Translation,
+		Release:           self.Release,
+		Metadata:          metadata,
+		Tokens:            self.Tokens,
+		PostedAt:          self.PostedAt,
+		CollectedAt:       self.CollectedAt,
+		TranslatedAt:      self.TranslatedAt,
+		ProcessedAt:       self.ProcessedAt,
+		AggregatedAt:      self.AggregatedAt,
+		SkippedAt:         self.SkippedAt,
 	}
 }
@@ -21,16 +21,17 @@ type FeedbackPayloadMetadata struct {
 }
 
 type FeedbackPayload struct {
-	ID          string                  `json:"id"`
-	ProductID   string                  `json:"product_id"`
-	Source      string                  `json:"source"`
-	Customer    FeedbackPayloadCustomer `json:"customer"`
-	Content     string                  `json:"content"`
-	Language    string                  `json:"language"`
-	Translation string                  `json:"translation"`
-	Release     string                  `json:"release"`
-	Metadata    FeedbackPayloadMetadata `json:"metadata"`
-	PostedAt    time.Time               `json:"posted_at"`
+	ID                string                  `json:"id"`
+	ProductID         string                  `json:"product_id"`
+	Source            string                  `json:"source"`
+	Customer          FeedbackPayloadCustomer `json:"customer"`
+	Content           string                  `json:"content"`
+	Language          string                  `json:"language"`
+	LanguageDetection *string                 `json:"language_detection"`
+	Translation       string                  `json:"translation"`
+	Release           string                  `json:"release"`
+	Metadata          FeedbackPayloadMetadata `json:"metadata"`
+	PostedAt          time.Time               `json:"posted_at"`
 }
 
 func NewFeedbackPayload(feedback Feedback) *FeedbackPayload {
@@ -47,10 +48,11 @@ func NewFeedbackPayload(feedback Feedback) *FeedbackPayload {
 			Reviews:  feedback.Customer.Reviews,
 			Link:     feedback.Customer.Link,
 		},
-		Content:     feedback.Content,
-		Language:    feedback.Language,
-		Translation: feedback.Translation,
-		Release:     feedback.Release,
+		Content:           feedback.Content,
+		Language:          feedback.Language,
+		LanguageDetection: feedback.LanguageDetection,
+		Translation:       feedback.Translation,
+		Release:           feedback.Release,
 		Metadata: FeedbackPayloadMetadata{
 			Rating:   feedback.Metadata.Rating,
 			Media:    feedback.Metadata.Media,
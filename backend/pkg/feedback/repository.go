@@ -6,6 +6,7 @@ import (
 
 	"github.com/leporo/sqlf"
 	"github.com/neoxelox/kit"
+	"github.com/pgvector/pgvector-go"
 
 	"backend/pkg/config"
 	"backend/pkg/util"
@@ -37,6 +38,7 @@ func (self *FeedbackRepository) Create(ctx context.Context, feedback Feedback) (
 		Set("customer", f.Customer).
 		Set("content", f.Content).
 		Set("language", f.Language).
+		Set("language_detection", f.LanguageDetection).
 		Set("translation", f.Translation).
 		Set("release", f.Release).
 		Set("metadata", f.Metadata).
@@ -45,6 +47,8 @@ func (self *FeedbackRepository) Create(ctx context.Context, feedback Feedback) (
 		Set("collected_at", f.CollectedAt).
 		Set("translated_at", f.TranslatedAt).
 		Set("processed_at", f.ProcessedAt).
+		Set("aggregated_at", f.AggregatedAt).
+		Set("skipped_at", f.SkippedAt).
 		Returning("*").To(&f)
 
 	err := self.database.Query(ctx, stmt)
@@ -75,6 +79,7 @@ func (self *FeedbackRepository) BulkCreate(ctx context.Context, feedbacks []Feed
 			Set("customer", f.Customer).
 			Set("content", f.Content).
 			Set("language", f.Language).
+			Set("language_detection", f.LanguageDetection).
 			Set("translation", f.Translation).
 			Set("release", f.Release).
 			Set("metadata", f.Metadata).
@@ -82,7 +87,9 @@ func (self *FeedbackRepository) BulkCreate(ctx context.Context, feedbacks []Feed
 			Set("posted_at", f.PostedAt).
 			Set("collected_at", f.CollectedAt).
 			Set("translated_at", f.TranslatedAt).
-			Set("processed_at", f.ProcessedAt)
+			Set("processed_at", f.ProcessedAt).
+			Set("aggregated_at", f.AggregatedAt).
+			Set("skipped_at", f.SkippedAt)
 	}
 
 	stmt.
@@ -137,7 +144,7 @@ func (self *FeedbackRepository) GetByIDForUpdate(ctx context.Context, id string)
 	return f.ToEntity(), nil
 }
 
-func (self *FeedbackRepository) ListIDsByNotTranslated(ctx context.Context,
+func (self *FeedbackRepository) ListIDsByNotTranslated(ctx context.Context, before time.Time,
 	pagination util.Pagination[time.Time]) (*util.Page[string, time.Time], error) {
 	var result []struct {
 		ID          string    `db:"id"`
@@ -147,7 +154,9 @@ func (self *FeedbackRepository) ListIDsByNotTranslated(ctx context.Context,
 	stmt := sqlf.
 		Select("id, collected_at").To(&result).
 		From(FEEDBACK_MODEL_TABLE).
-		Where("translated_at IS NULL")
+		Where("translated_at IS NULL").
+		Where("skipped_at IS NULL").
+		Where("collected_at <= ?", before)
 
 	if pagination.From != nil {
 		stmt.
@@ -186,7 +195,7 @@ func (self *FeedbackRepository) ListIDsByNotTranslated(ctx context.Context,
 	}, nil
 }
 
-func (self *FeedbackRepository) ListIDsByNotProcessed(ctx context.Context,
+func (self *FeedbackRepository) ListIDsByNotProcessed(ctx context.Context, before time.Time,
 	pagination util.Pagination[time.Time]) (*util.Page[string, time.Time], error) {
 	var result []struct {
 		ID           string    `db:"id"`
@@ -197,7 +206,8 @@ func (self *FeedbackRepository) ListIDsByNotProcessed(ctx context.Context,
 		Select("id, translated_at").To(&result).
 		From(FEEDBACK_MODEL_TABLE).
 		Where("processed_at IS NULL").
-		Where("translated_at IS NOT NULL")
+		Where("skipped_at IS NULL").
+		Where("translated_at <= ?", before)
 
 	if pagination.From != nil {
 		stmt.
@@ -242,6 +252,7 @@ func (self *FeedbackRepository) UpdateTranslated(ctx context.Context, feedback F
 	stmt := sqlf.
 		Update(FEEDBACK_MODEL_TABLE).
 		Set("language", f.Language).
+		Set("language_detection", f.LanguageDetection).
 		Set("translation", f.Translation).
 		Set("tokens", f.Tokens).
 		Set("translated_at", f.TranslatedAt).
@@ -266,6 +277,7 @@ func (self *FeedbackRepository) UpdateProcessed(ctx context.Context, feedback Fe
 		Update(FEEDBACK_MODEL_TABLE).
 		Set("tokens", f.Tokens).
 		Set("processed_at", f.ProcessedAt).
+		Set("aggregated_at", f.AggregatedAt).
 		Where("id = ?", f.ID)
 
 	affected, err := self.database.Exec(ctx, stmt)
@@ -280,6 +292,33 @@ func (self *FeedbackRepository) UpdateProcessed(ctx context.Context, feedback Fe
 	return nil
 }
 
+// UpsertEmbedding stores the embedding of a feedback, replacing the previous one when the feedback is reprocessed.
+func (self *FeedbackRepository) UpsertEmbedding(ctx context.Context, feedback Feedback,
+	embedding []float32, embeddingModel string) error {
+	stmt := sqlf.
+		InsertInto(FEEDBACK_EMBEDDING_MODEL_TABLE).
+		Set("feedback_id", feedback.ID).
+		Set("product_id", feedback.ProductID).
+		Set("embedding", pgvector.NewVector(embedding)).
+		Set("embedding_model", embeddingModel).
+		Set("created_at", time.Now()).
+		Clause("ON CONFLICT (feedback_id) DO UPDATE SET " +
+			"embedding = EXCLUDED.embedding, " +
+			"embedding_model = EXCLUDED.embedding_model, " +
+			"created_at = EXCLUDED.created_at")
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
 func (self *FeedbackRepository) UpdateTokens(ctx context.Context, id string, tokens int) error {
 	stmt := sqlf.
 		Update(FEEDBACK_MODEL_TABLE).
@@ -297,3 +336,40 @@ func (self *FeedbackRepository) UpdateTokens(ctx context.Context, id string, tok
 
 	return nil
 }
+
+func (self *FeedbackRepository) UpdateAggregated(ctx context.Context, id string, aggregatedAt time.Time) error {
+	// The feedback is only aggregated once all of its partial issues and suggestions have been aggregated
+	stmt := sqlf.
+		Update(FEEDBACK_MODEL_TABLE).
+		Set("aggregated_at", aggregatedAt).
+		Where("id = ?", id).
+		Where("processed_at IS NOT NULL").
+		Where("aggregated_at IS NULL").
+		Where("NOT EXISTS (SELECT 1 FROM \"partial_issue\" WHERE feedback_id = ?)", id).
+		Where("NOT EXISTS (SELECT 1 FROM \"partial_suggestion\" WHERE feedback_id = ?)", id)
+
+	_, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *FeedbackRepository) UpdateSkipped(ctx context.Context, id string, skippedAt time.Time) error {
+	stmt := sqlf.
+		Update(FEEDBACK_MODEL_TABLE).
+		Set("skipped_at", skippedAt).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
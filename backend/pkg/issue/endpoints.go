@@ -3,18 +3,21 @@ package issue
 import (
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/neoxelox/kit"
 	kitUtil "github.com/neoxelox/kit/util"
+	"github.com/rs/xid"
 
 	"backend/pkg/config"
 	"backend/pkg/engine"
 	"backend/pkg/feedback"
 	"backend/pkg/organization"
 	"backend/pkg/product"
+	"backend/pkg/segment"
 	"backend/pkg/user"
 	"backend/pkg/util"
 )
@@ -27,6 +30,8 @@ const (
 	ISSUE_ENDPOINTS_SEARCH_MAX_LIMIT          = 100
 	ISSUE_ENDPOINTS_SEARCH_MIN_CONTENT_LENGTH = 5
 	ISSUE_ENDPOINTS_SEARCH_MAX_CONTENT_LENGTH = 250
+	ISSUE_ENDPOINTS_TRANSLATION_KEY           = "issue:endpoints:translation:"
+	ISSUE_ENDPOINTS_TRANSLATION_TTL           = 7 * 24 * time.Hour
 )
 
 type IssueEndpoints struct {
@@ -58,6 +63,7 @@ type IssueEndpointsListIssuesRequest struct {
 		Releases         []string   `query:"releases"`
 		Categories       []string   `query:"categories"`
 		Assignees        []string   `query:"assignees"`
+		States           []string   `query:"states"`
 		Status           *string    `query:"status"`
 		FirstSeenStartAt *time.Time `query:"first_seen_start_at"`
 		FirstSeenEndAt   *time.Time `query:"first_seen_end_at"`
@@ -66,6 +72,7 @@ type IssueEndpointsListIssuesRequest struct {
 	}
 	Orders struct {
 		Relevance *string `query:"relevance"`
+		Revenue   *string `query:"revenue"`
 	}
 	Pagination struct {
 		Limit *int    `query:"limit"`
@@ -105,6 +112,12 @@ func (self *IssueEndpoints) ListIssues(ctx echo.Context) error {
 		}
 	}
 
+	for _, state := range request.Filters.States {
+		if !IsIssueState(state) {
+			return kit.HTTPErrInvalidRequest
+		}
+	}
+
 	if request.Filters.Status != nil && !IsIssueSearchFiltersStatus(*request.Filters.Status) {
 		return kit.HTTPErrInvalidRequest
 	}
@@ -128,6 +141,13 @@ func (self *IssueEndpoints) ListIssues(ctx echo.Context) error {
 		request.Orders.Relevance = kitUtil.Pointer(IssueSearchOrdersDescending)
 	}
 
+	if request.Orders.Revenue != nil {
+		if *request.Orders.Revenue != IssueSearchOrdersDescending &&
+			*request.Orders.Revenue != IssueSearchOrdersAscending {
+			return kit.HTTPErrInvalidRequest
+		}
+	}
+
 	if request.Pagination.Limit != nil {
 		if *request.Pagination.Limit > ISSUE_ENDPOINTS_SEARCH_MAX_LIMIT {
 			return kit.HTTPErrInvalidRequest
@@ -138,12 +158,15 @@ func (self *IssueEndpoints) ListIssues(ctx echo.Context) error {
 
 	var embedding []float32
 	if request.Filters.Content != nil {
-		err := self.cache.Get(requestCtx, ISSUE_ENDPOINTS_SEARCH_CONTENT_KEY+*request.Filters.Content, &embedding)
+		contentKey := ISSUE_ENDPOINTS_SEARCH_CONTENT_KEY + requestProduct.EmbeddingModel + ":" + *request.Filters.Content
+
+		err := self.cache.Get(requestCtx, contentKey, &embedding)
 		if err != nil {
 			if kit.ErrCacheMiss.Is(err) {
 				result, err := self.engineService.ComputeEmbedding(requestCtx,
 					engine.EngineServiceComputeEmbeddingParams{
-						Text: *request.Filters.Content,
+						Text:  *request.Filters.Content,
+						Model: requestProduct.EmbeddingModel,
 					})
 				if err != nil {
 					return kit.HTTPErrServerGeneric.Cause(err)
@@ -151,8 +174,7 @@ func (self *IssueEndpoints) ListIssues(ctx echo.Context) error {
 
 				embedding = result.Embedding
 
-				err = self.cache.Set(requestCtx, ISSUE_ENDPOINTS_SEARCH_CONTENT_KEY+*request.Filters.Content,
-					embedding, kitUtil.Pointer(ISSUE_ENDPOINTS_SEARCH_CONTENT_TTL))
+				err = self.cache.Set(requestCtx, contentKey, embedding, kitUtil.Pointer(ISSUE_ENDPOINTS_SEARCH_CONTENT_TTL))
 				if err != nil {
 					return kit.HTTPErrServerGeneric.Cause(err)
 				}
@@ -165,19 +187,23 @@ func (self *IssueEndpoints) ListIssues(ctx echo.Context) error {
 	page, err := self.issueRepository.ListByProductID(requestCtx, requestProduct.ID, IssueSearch{
 		Filters: IssueSearchFilters{
 			Embedding:        &embedding,
+			EmbeddingModel:   &requestProduct.EmbeddingModel,
 			Sources:          &request.Filters.Sources,
 			Severities:       &request.Filters.Severities,
 			Releases:         &request.Filters.Releases,
 			Categories:       &request.Filters.Categories,
 			Assignees:        &request.Filters.Assignees,
+			States:           &request.Filters.States,
 			Status:           request.Filters.Status,
 			FirstSeenStartAt: request.Filters.FirstSeenStartAt,
 			FirstSeenEndAt:   request.Filters.FirstSeenEndAt,
 			LastSeenStartAt:  request.Filters.LastSeenStartAt,
 			LastSeenEndAt:    request.Filters.LastSeenEndAt,
+			Segment:          segment.RequestSegment(requestCtx),
 		},
 		Orders: IssueSearchOrders{
 			Relevance: *request.Orders.Relevance,
+			Revenue:   request.Orders.Revenue,
 		},
 		Pagination: util.Pagination[IssueSearchCursor]{
 			Limit: *request.Pagination.Limit,
@@ -223,6 +249,81 @@ func (self *IssueEndpoints) GetIssue(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, &response)
 }
 
+type IssueEndpointsGetIssueTranslationRequest struct {
+	Language *string `query:"language"`
+}
+
+type IssueEndpointsGetIssueTranslationResponse struct {
+	IssueTranslationPayload
+}
+
+func (self *IssueEndpoints) GetIssueTranslation(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestMe := user.RequestMe(requestCtx)
+	requestProduct := product.RequestProduct(requestCtx)
+	requestIssue := RequestIssue(requestCtx)
+	request := IssueEndpointsGetIssueTranslationRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	// Defaults to the preferred language of the reader, if any, else to the language of the product
+	language := requestProduct.Language
+	if request.Language != nil {
+		language = *request.Language
+	} else if requestMe.Settings.Language != nil {
+		language = *requestMe.Settings.Language
+	}
+
+	if !product.IsLanguageSupported(language) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	response := IssueEndpointsGetIssueTranslationResponse{}
+	response.IssueTranslationPayload = *NewIssueTranslationPayload(language, *requestIssue)
+
+	if language == requestProduct.Language {
+		return ctx.JSON(http.StatusOK, &response)
+	}
+
+	// The texts of the issue only change when it is aggregated, so the translation is cached until then
+	version := requestIssue.CreatedAt
+	if requestIssue.LastAggregatedAt != nil {
+		version = *requestIssue.LastAggregatedAt
+	}
+	key := ISSUE_ENDPOINTS_TRANSLATION_KEY + requestIssue.ID + ":" + language + ":" +
+		strconv.FormatInt(version.UnixMilli(), 10)
+
+	err = self.cache.Get(requestCtx, key, &response)
+	if err != nil && !kit.ErrCacheMiss.Is(err) {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	} else if err == nil {
+		return ctx.JSON(http.StatusOK, &response)
+	}
+
+	result, err := self.engineService.TranslateTexts(requestCtx, engine.EngineServiceTranslateTextsParams{
+		Texts:        append([]string{requestIssue.Title, requestIssue.Description}, requestIssue.Steps...),
+		FromLanguage: requestProduct.Language,
+		ToLanguage:   language,
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response.Title = result.Translations[0]
+	response.Description = result.Translations[1]
+	response.Steps = result.Translations[2:]
+
+	err = self.cache.Set(requestCtx, key, &response, kitUtil.Pointer(ISSUE_ENDPOINTS_TRANSLATION_TTL))
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
 type IssueEndpointsListIssueFeedbacksRequest struct {
 	From *string `query:"from"`
 }
@@ -374,6 +475,7 @@ type IssueEndpointsPutIssueArchivedResponse struct {
 
 func (self *IssueEndpoints) PutIssueArchived(ctx echo.Context) error {
 	requestCtx := ctx.Request().Context()
+	requestUser := user.RequestUser(requestCtx)
 	requestProduct := product.RequestProduct(requestCtx)
 	requestIssue := RequestIssue(requestCtx)
 	request := IssueEndpointsPutIssueArchivedRequest{}
@@ -394,6 +496,34 @@ func (self *IssueEndpoints) PutIssueArchived(ctx echo.Context) error {
 		return kit.HTTPErrServerGeneric.Cause(err)
 	}
 
+	// The workflow state follows the archival, so archived issues regress when new feedback comes in
+	state := requestIssue.State
+	if request.Archived {
+		state = IssueStateArchived
+	} else if requestIssue.State == IssueStateArchived {
+		state = IssueStateOpen
+	}
+
+	if state != requestIssue.State {
+		change := NewIssueStateChange()
+		change.ID = xid.New().String()
+		change.IssueID = requestIssue.ID
+		change.FromState = requestIssue.State
+		change.ToState = state
+		change.Release = nil
+		change.UserID = &requestUser.ID
+		change.FeedbackID = nil
+		change.CreatedAt = time.Now()
+
+		requestIssue.State = state
+		requestIssue.ResolvedInRelease = nil
+
+		err = self.issueRepository.UpdateState(requestCtx, *requestIssue, *change)
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+	}
+
 	keys, err := self.cache.Find(requestCtx, ISSUE_ENDPOINTS_SEARCH_KEY+requestProduct.ID+"*")
 	if err != nil {
 		return kit.HTTPErrServerGeneric.Cause(err)
@@ -411,3 +541,107 @@ func (self *IssueEndpoints) PutIssueArchived(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, &response)
 }
+
+type IssueEndpointsPutIssueStateRequest struct {
+	State             string  `json:"state"`
+	ResolvedInRelease *string `json:"resolved_in_release"`
+}
+
+type IssueEndpointsPutIssueStateResponse struct {
+	State             string  `json:"state"`
+	ResolvedInRelease *string `json:"resolved_in_release"`
+}
+
+func (self *IssueEndpoints) PutIssueState(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestUser := user.RequestUser(requestCtx)
+	requestProduct := product.RequestProduct(requestCtx)
+	requestIssue := RequestIssue(requestCtx)
+	request := IssueEndpointsPutIssueStateRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	// Issues only regress on their own when feedback from a fixed release comes in, and are archived separately
+	if !IsIssueState(request.State) || request.State == IssueStateRegressed || request.State == IssueStateArchived {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	if request.State != IssueStateResolved {
+		request.ResolvedInRelease = nil
+	} else if request.ResolvedInRelease != nil {
+		*request.ResolvedInRelease = strings.TrimSpace(*request.ResolvedInRelease)
+		if len(*request.ResolvedInRelease) == 0 || len(*request.ResolvedInRelease) > ISSUE_MAX_RELEASE_LENGTH {
+			return kit.HTTPErrInvalidRequest
+		}
+	}
+
+	if request.State == requestIssue.State &&
+		kitUtil.Equals(request.ResolvedInRelease, requestIssue.ResolvedInRelease) {
+		response := IssueEndpointsPutIssueStateResponse{}
+		response.State = requestIssue.State
+		response.ResolvedInRelease = requestIssue.ResolvedInRelease
+
+		return ctx.JSON(http.StatusOK, &response)
+	}
+
+	change := NewIssueStateChange()
+	change.ID = xid.New().String()
+	change.IssueID = requestIssue.ID
+	change.FromState = requestIssue.State
+	change.ToState = request.State
+	change.Release = request.ResolvedInRelease
+	change.UserID = &requestUser.ID
+	change.FeedbackID = nil
+	change.CreatedAt = time.Now()
+
+	requestIssue.State = request.State
+	requestIssue.ResolvedInRelease = request.ResolvedInRelease
+
+	err = self.issueRepository.UpdateState(requestCtx, *requestIssue, *change)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	keys, err := self.cache.Find(requestCtx, ISSUE_ENDPOINTS_SEARCH_KEY+requestProduct.ID+"*")
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	for _, key := range keys {
+		err = self.cache.Delete(requestCtx, key)
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+	}
+
+	response := IssueEndpointsPutIssueStateResponse{}
+	response.State = requestIssue.State
+	response.ResolvedInRelease = requestIssue.ResolvedInRelease
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type IssueEndpointsListIssueStateChangesResponse struct {
+	Changes []IssueStateChangePayload `json:"changes"`
+}
+
+func (self *IssueEndpoints) ListIssueStateChanges(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestIssue := RequestIssue(requestCtx)
+
+	changes, err := self.issueRepository.ListStateChanges(requestCtx, requestIssue.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := IssueEndpointsListIssueStateChangesResponse{}
+	response.Changes = make([]IssueStateChangePayload, 0, len(changes))
+	for _, change := range changes {
+		response.Changes = append(response.Changes, *NewIssueStateChangePayload(change))
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
@@ -5,15 +5,18 @@ import (
 	"time"
 
 	"backend/pkg/engine"
+	"backend/pkg/priority"
+	"backend/pkg/segment"
 	"backend/pkg/util"
 
 	kitUtil "github.com/neoxelox/kit/util"
 )
 
 const (
-	ISSUE_MIN_QUALITY       = 0
-	ISSUE_MAX_QUALITY       = 5
-	ISSUE_SIMILAR_THRESHOLD = 0.6
+	ISSUE_MIN_QUALITY        = 0
+	ISSUE_MAX_QUALITY        = 5
+	ISSUE_SIMILAR_THRESHOLD  = 0.6
+	ISSUE_MAX_RELEASE_LENGTH = 50
 )
 
 const (
@@ -37,27 +40,51 @@ var IssueSeverityWeight = map[string]int{
 	IssueSeverityLow:      1,
 }
 
+const (
+	IssueStateOpen       = "OPEN"
+	IssueStateTriaged    = "TRIAGED"
+	IssueStateInProgress = "IN_PROGRESS"
+	IssueStateResolved   = "RESOLVED"
+	IssueStateWontFix    = "WONT_FIX"
+	IssueStateRegressed  = "REGRESSED"
+	IssueStateArchived   = "ARCHIVED"
+)
+
+func IsIssueState(value string) bool {
+	return value == IssueStateOpen ||
+		value == IssueStateTriaged ||
+		value == IssueStateInProgress ||
+		value == IssueStateResolved ||
+		value == IssueStateWontFix ||
+		value == IssueStateRegressed ||
+		value == IssueStateArchived
+}
+
 type Issue struct {
-	ID               string
-	ProductID        string
-	Embedding        []float32
-	Sources          map[string]int
-	Title            string
-	Description      string
-	Steps            []string
-	Severities       map[string]int
-	Priority         int
-	Categories       map[string]int
-	Releases         map[string]int
-	Customers        int
-	AssigneeID       *string
-	Quality          *int
-	FirstSeenAt      time.Time
-	LastSeenAt       time.Time
-	CreatedAt        time.Time
-	ArchivedAt       *time.Time
-	LastAggregatedAt *time.Time
-	ExportedAt       *time.Time
+	ID                string
+	ProductID         string
+	Embedding         []float32
+	EmbeddingModel    string
+	Sources           map[string]int
+	Title             string
+	Description       string
+	Steps             []string
+	Severities        map[string]int
+	Priority          int
+	Categories        map[string]int
+	Releases          map[string]int
+	Customers         int
+	RevenueAtRisk     int
+	AssigneeID        *string
+	Quality           *int
+	State             string
+	ResolvedInRelease *string
+	FirstSeenAt       time.Time
+	LastSeenAt        time.Time
+	CreatedAt         time.Time
+	ArchivedAt        *time.Time
+	LastAggregatedAt  *time.Time
+	ExportedAt        *time.Time
 }
 
 func NewIssue() *Issue {
@@ -72,6 +99,17 @@ func (self Issue) Category() string {
 	return computeCategory(self.Categories)
 }
 
+// RegressedBy tells whether feedback from the release shows that a resolved issue is back, or whether feedback
+// seen after an issue was archived shows that it is back.
+func (self Issue) RegressedBy(release string, seenAt time.Time) bool {
+	if self.State == IssueStateArchived {
+		return self.ArchivedAt == nil || seenAt.After(*self.ArchivedAt)
+	}
+
+	return self.State == IssueStateResolved && self.ResolvedInRelease != nil &&
+		util.CompareReleases(release, *self.ResolvedInRelease) >= 0
+}
+
 func (self Issue) String() string {
 	return fmt.Sprintf("<Issue: %s (%s)>", self.Title, self.ID)
 }
@@ -97,8 +135,19 @@ func computeSeverity(severities map[string]int) string {
 	return majorSeverity
 }
 
-func ComputePriority(severities map[string]int, customers int) int {
-	return IssueSeverityWeight[computeSeverity(severities)] * customers
+// ComputePriority scores the issue with the formula of its product. The trend is only needed by trending formulas.
+func ComputePriority(formula priority.PriorityFormula, issue Issue, trend *priority.PriorityTrend) int {
+	severity := computeSeverity(issue.Severities)
+
+	return formula.Compute(priority.PriorityFactors{
+		Level:       severity,
+		LevelWeight: float64(IssueSeverityWeight[severity]),
+		Sources:     issue.Sources,
+		Customers:   issue.Customers,
+		LastSeenAt:  issue.LastSeenAt,
+		Trend:       trend,
+		Revenue:     issue.RevenueAtRisk,
+	}, time.Now())
 }
 
 func computeCategory(categories map[string]int) string {
@@ -134,16 +183,19 @@ func IsIssueSearchFiltersStatus(value string) bool {
 
 type IssueSearchFilters struct {
 	Embedding        *[]float32
+	EmbeddingModel   *string
 	Sources          *[]string
 	Severities       *[]string
 	Releases         *[]string
 	Categories       *[]string
 	Assignees        *[]string
+	States           *[]string
 	Status           *string
 	FirstSeenStartAt *time.Time
 	FirstSeenEndAt   *time.Time
 	LastSeenStartAt  *time.Time
 	LastSeenEndAt    *time.Time
+	Segment          *segment.Segment
 }
 
 const (
@@ -153,11 +205,14 @@ const (
 
 type IssueSearchOrders struct {
 	Relevance string
+	// Orders by the revenue at risk instead of the relevance if set
+	Revenue *string
 }
 
 type IssueSearchCursor struct {
-	Priority int
-	SeenAt   time.Time
+	Priority      int
+	RevenueAtRisk int
+	SeenAt        time.Time
 }
 
 type IssueSearch struct {
@@ -166,6 +221,33 @@ type IssueSearch struct {
 	Pagination util.Pagination[IssueSearchCursor]
 }
 
+type IssueStateChange struct {
+	ID         string
+	IssueID    string
+	FromState  string
+	ToState    string
+	Release    *string
+	UserID     *string
+	FeedbackID *string
+	CreatedAt  time.Time
+}
+
+func NewIssueStateChange() *IssueStateChange {
+	return &IssueStateChange{}
+}
+
+func (self IssueStateChange) String() string {
+	return fmt.Sprintf("<IssueStateChange: %s to %s (%s)>", self.FromState, self.ToState, self.ID)
+}
+
+func (self IssueStateChange) Equals(other IssueStateChange) bool {
+	return kitUtil.Equals(self, other)
+}
+
+func (self IssueStateChange) Copy() *IssueStateChange {
+	return kitUtil.Copy(self)
+}
+
 type PartialIssue struct {
 	ID          string
 	FeedbackID  string
@@ -0,0 +1,60 @@
+package issue_test
+
+import (
+	"testing"
+	"time"
+
+	"backend/pkg/issue"
+
+	"github.com/neoxelox/kit/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type IssueTestSuite struct {
+	suite.Suite
+}
+
+func TestIssueSuite(t *testing.T) {
+	suite.Run(t, new(IssueTestSuite))
+}
+
+func (self *IssueTestSuite) TestRegressedByArchived() {
+	// Given: An issue archived before workflow states existed, as backfilled by the migration
+	archivedAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	_issue := issue.NewIssue()
+	_issue.State = issue.IssueStateArchived
+	_issue.ArchivedAt = &archivedAt
+	_issue.LastSeenAt = archivedAt.Add(-24 * time.Hour)
+
+	// When: Feedback seen before and after archiving it comes in
+	// Then: Only the feedback seen after archiving it regresses the issue, whatever its release
+	self.False(_issue.RegressedBy("1.0.0", archivedAt.Add(-time.Hour)))
+	self.True(_issue.RegressedBy("1.0.0", archivedAt.Add(time.Hour)))
+	self.True(_issue.RegressedBy("", archivedAt.Add(time.Hour)))
+}
+
+func (self *IssueTestSuite) TestRegressedByRegressed() {
+	// Given: An issue that got feedback after being archived, backfilled as regressed by the migration
+	archivedAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	_issue := issue.NewIssue()
+	_issue.State = issue.IssueStateRegressed
+	_issue.ArchivedAt = &archivedAt
+	_issue.LastSeenAt = archivedAt.Add(24 * time.Hour)
+
+	// When: More feedback comes in
+	// Then: The issue does not regress again
+	self.False(_issue.RegressedBy("1.0.0", archivedAt.Add(48*time.Hour)))
+}
+
+func (self *IssueTestSuite) TestRegressedByResolved() {
+	// Given: An issue resolved in a release
+	_issue := issue.NewIssue()
+	_issue.State = issue.IssueStateResolved
+	_issue.ResolvedInRelease = util.Pointer("1.2.0")
+
+	// When: Feedback from older and newer releases comes in
+	// Then: Only the feedback from the fixed release onwards regresses the issue
+	self.False(_issue.RegressedBy("1.1.9", time.Now()))
+	self.True(_issue.RegressedBy("1.2.0", time.Now()))
+	self.True(_issue.RegressedBy("1.10.0", time.Now()))
+}
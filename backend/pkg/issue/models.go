@@ -8,8 +8,9 @@ import (
 )
 
 const (
-	ISSUE_FEEDBACK_MODEL_TABLE = "\"issue_feedback\""
-	ISSUE_MODEL_TABLE          = "\"issue\""
+	ISSUE_FEEDBACK_MODEL_TABLE     = "\"issue_feedback\""
+	ISSUE_MODEL_TABLE              = "\"issue\""
+	ISSUE_STATE_CHANGE_MODEL_TABLE = "\"issue_state_change\""
 )
 
 type IssueFeedbackModel struct {
@@ -18,26 +19,30 @@ type IssueFeedbackModel struct {
 }
 
 type IssueModel struct {
-	ID               string          `db:"id"`
-	ProductID        string          `db:"product_id"`
-	Embedding        pgvector.Vector `db:"embedding"`
-	Sources          []byte          `db:"sources"`
-	Title            string          `db:"title"`
-	Description      string          `db:"description"`
-	Steps            []string        `db:"steps"`
-	Severities       []byte          `db:"severities"`
-	Priority         int             `db:"priority"`
-	Categories       []byte          `db:"categories"`
-	Releases         []byte          `db:"releases"`
-	Customers        int             `db:"customers"`
-	AssigneeID       *string         `db:"assignee_id"`
-	Quality          *int            `db:"quality"`
-	FirstSeenAt      time.Time       `db:"first_seen_at"`
-	LastSeenAt       time.Time       `db:"last_seen_at"`
-	CreatedAt        time.Time       `db:"created_at"`
-	ArchivedAt       *time.Time      `db:"archived_at"`
-	LastAggregatedAt *time.Time      `db:"last_aggregated_at"`
-	ExportedAt       *time.Time      `db:"exported_at"`
+	ID                string          `db:"id"`
+	ProductID         string          `db:"product_id"`
+	Embedding         pgvector.Vector `db:"embedding"`
+	EmbeddingModel    string          `db:"embedding_model"`
+	Sources           []byte          `db:"sources"`
+	Title             string          `db:"title"`
+	Description       string          `db:"description"`
+	Steps             []string        `db:"steps"`
+	Severities        []byte          `db:"severities"`
+	Priority          int             `db:"priority"`
+	Categories        []byte          `db:"categories"`
+	Releases          []byte          `db:"releases"`
+	Customers         int             `db:"customers"`
+	RevenueAtRisk     int             `db:"revenue_at_risk"`
+	AssigneeID        *string         `db:"assignee_id"`
+	Quality           *int            `db:"quality"`
+	State             string          `db:"state"`
+	ResolvedInRelease *string         `db:"resolved_in_release"`
+	FirstSeenAt       time.Time       `db:"first_seen_at"`
+	LastSeenAt        time.Time       `db:"last_seen_at"`
+	CreatedAt         time.Time       `db:"created_at"`
+	ArchivedAt        *time.Time      `db:"archived_at"`
+	LastAggregatedAt  *time.Time      `db:"last_aggregated_at"`
+	ExportedAt        *time.Time      `db:"exported_at"`
 }
 
 func NewIssueModel(issue Issue) *IssueModel {
@@ -64,26 +69,30 @@ func NewIssueModel(issue Issue) *IssueModel {
 	}
 
 	return &IssueModel{
-		ID:               issue.ID,
-		ProductID:        issue.ProductID,
-		Embedding:        embedding,
-		Sources:          sources,
-		Title:            issue.Title,
-		Description:      issue.Description,
-		Steps:            issue.Steps,
-		Severities:       severities,
-		Priority:         issue.Priority,
-		Categories:       categories,
-		Releases:         releases,
-		Customers:        issue.Customers,
-		AssigneeID:       issue.AssigneeID,
-		Quality:          issue.Quality,
-		FirstSeenAt:      issue.FirstSeenAt,
-		LastSeenAt:       issue.LastSeenAt,
-		CreatedAt:        issue.CreatedAt,
-		ArchivedAt:       issue.ArchivedAt,
-		LastAggregatedAt: issue.LastAggregatedAt,
-		ExportedAt:       issue.ExportedAt,
+		ID:                issue.ID,
+		ProductID:         issue.ProductID,
+		Embedding:         embedding,
+		EmbeddingModel:    issue.EmbeddingModel,
+		Sources:           sources,
+		Title:             issue.Title,
+		Description:       issue.Description,
+		Steps:             issue.Steps,
+		Severities:        severities,
+		Priority:          issue.Priority,
+		Categories:        categories,
+		Releases:          releases,
+		Customers:         issue.Customers,
+		RevenueAtRisk:     issue.RevenueAtRisk,
+		AssigneeID:        issue.AssigneeID,
+		Quality:           issue.Quality,
+		State:             issue.State,
+		ResolvedInRelease: issue.ResolvedInRelease,
+		FirstSeenAt:       issue.FirstSeenAt,
+		LastSeenAt:        issue.LastSeenAt,
+		CreatedAt:         issue.CreatedAt,
+		ArchivedAt:        issue.ArchivedAt,
+		LastAggregatedAt:  issue.LastAggregatedAt,
+		ExportedAt:        issue.ExportedAt,
 	}
 }
 
@@ -115,26 +124,67 @@ func (self *IssueModel) ToEntity() *Issue {
 	}
 
 	return &Issue{
-		ID:               self.ID,
-		ProductID:        self.ProductID,
-		Embedding:        embedding,
-		Sources:          sources,
-		Title:            self.Title,
-		Description:      self.Description,
-		Steps:            self.Steps,
-		Severities:       severities,
-		Priority:         self.Priority,
-		Categories:       categories,
-		Releases:         releases,
-		Customers:        self.Customers,
-		AssigneeID:       self.AssigneeID,
-		Quality:          self.Quality,
-		FirstSeenAt:      self.FirstSeenAt,
-		LastSeenAt:       self.LastSeenAt,
-		CreatedAt:        self.CreatedAt,
-		ArchivedAt:       self.ArchivedAt,
-		LastAggregatedAt: self.LastAggregatedAt,
-		ExportedAt:       self.ExportedAt,
+		ID:                self.ID,
+		ProductID:         self.ProductID,
+		Embedding:         embedding,
+		EmbeddingModel:    self.EmbeddingModel,
+		Sources:           sources,
+		Title:             self.Title,
+		Description:       self.Description,
+		Steps:             self.Steps,
+		Severities:        severities,
+		Priority:          self.Priority,
+		Categories:        categories,
+		Releases:          releases,
+		Customers:         self.Customers,
+		RevenueAtRisk:     self.RevenueAtRisk,
+		AssigneeID:        self.AssigneeID,
+		Quality:           self.Quality,
+		State:             self.State,
+		ResolvedInRelease: self.ResolvedInRelease,
+		FirstSeenAt:       self.FirstSeenAt,
+		LastSeenAt:        self.LastSeenAt,
+		CreatedAt:         self.CreatedAt,
+		ArchivedAt:        self.ArchivedAt,
+		LastAggregatedAt:  self.LastAggregatedAt,
+		ExportedAt:        self.ExportedAt,
+	}
+}
+
+type IssueStateChangeModel struct {
+	ID         string    `db:"id"`
+	IssueID    string    `db:"issue_id"`
+	FromState  string    `db:"from_state"`
+	ToState    string    `db:"to_state"`
+	Release    *string   `db:"release"`
+	UserID     *string   `db:"user_id"`
+	FeedbackID *string   `db:"feedback_id"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func NewIssueStateChangeModel(change IssueStateChange) *IssueStateChangeModel {
+	return &IssueStateChangeModel{
+		ID:         change.ID,
+		IssueID:    change.IssueID,
+		FromState:  change.FromState,
+		ToState:    change.ToState,
+		Release:    change.Release,
+		UserID:     change.UserID,
+		FeedbackID: change.FeedbackID,
+		CreatedAt:  change.CreatedAt,
+	}
+}
+
+func (self *IssueStateChangeModel) ToEntity() *IssueStateChange {
+	return &IssueStateChange{
+		ID:         self.ID,
+		IssueID:    self.IssueID,
+		FromState:  self.FromState,
+		ToState:    self.ToState,
+		Release:    self.Release,
+		UserID:     self.UserID,
+		FeedbackID: self.FeedbackID,
+		CreatedAt:  self.CreatedAt,
 	}
 }
 
@@ -3,41 +3,87 @@ package issue
 import "time"
 
 type IssuePayload struct {
-	ID          string         `json:"id"`
-	ProductID   string         `json:"product_id"`
-	Sources     map[string]int `json:"sources"`
-	Title       string         `json:"title"`
-	Description string         `json:"description"`
-	Steps       []string       `json:"steps"`
-	Severities  map[string]int `json:"severities"`
-	Priority    int            `json:"priority"`
-	Categories  map[string]int `json:"categories"`
-	Releases    map[string]int `json:"releases"`
-	Customers   int            `json:"customers"`
-	AssigneeID  *string        `json:"assignee_id"`
-	Quality     *int           `json:"quality"`
-	FirstSeenAt time.Time      `json:"first_seen_at"`
-	LastSeenAt  time.Time      `json:"last_seen_at"`
-	ArchivedAt  *time.Time     `json:"archived_at"`
+	ID                string         `json:"id"`
+	ProductID         string         `json:"product_id"`
+	Sources           map[string]int `json:"sources"`
+	Title             string         `json:"title"`
+	Description       string         `json:"description"`
+	Steps             []string       `json:"steps"`
+	Severities        map[string]int `json:"severities"`
+	Priority          int            `json:"priority"`
+	Categories        map[string]int `json:"categories"`
+	Releases          map[string]int `json:"releases"`
+	Customers         int            `json:"customers"`
+	RevenueAtRisk     int            `json:"revenue_at_risk"`
+	AssigneeID        *string        `json:"assignee_id"`
+	Quality           *int           `json:"quality"`
+	State             string         `json:"state"`
+	ResolvedInRelease *string        `json:"resolved_in_release"`
+	FirstSeenAt       time.Time      `json:"first_seen_at"`
+	LastSeenAt        time.Time      `json:"last_seen_at"`
+	ArchivedAt        *time.Time     `json:"archived_at"`
 }
 
 func NewIssuePayload(issue Issue) *IssuePayload {
 	return &IssuePayload{
-		ID:          issue.ID,
-		ProductID:   issue.ProductID,
-		Sources:     issue.Sources,
+		ID:                issue.ID,
+		ProductID:         issue.ProductID,
+		Sources:           issue.Sources,
+		Title:             issue.Title,
+		Description:       issue.Description,
+		Steps:             issue.Steps,
+		Severities:        issue.Severities,
+		Priority:          issue.Priority,
+		Categories:        issue.Categories,
+		Releases:          issue.Releases,
+		Customers:         issue.Customers,
+		RevenueAtRisk:     issue.RevenueAtRisk,
+		AssigneeID:        issue.AssigneeID,
+		Quality:           issue.Quality,
+		State:             issue.State,
+		ResolvedInRelease: issue.ResolvedInRelease,
+		FirstSeenAt:       issue.FirstSeenAt,
+		LastSeenAt:        issue.LastSeenAt,
+		ArchivedAt:        issue.ArchivedAt,
+	}
+}
+
+type IssueTranslationPayload struct {
+	Language    string   `json:"language"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Steps       []string `json:"steps"`
+}
+
+func NewIssueTranslationPayload(language string, issue Issue) *IssueTranslationPayload {
+	return &IssueTranslationPayload{
+		Language:    language,
 		Title:       issue.Title,
 		Description: issue.Description,
 		Steps:       issue.Steps,
-		Severities:  issue.Severities,
-		Priority:    issue.Priority,
-		Categories:  issue.Categories,
-		Releases:    issue.Releases,
-		Customers:   issue.Customers,
-		AssigneeID:  issue.AssigneeID,
-		Quality:     issue.Quality,
-		FirstSeenAt: issue.FirstSeenAt,
-		LastSeenAt:  issue.LastSeenAt,
-		ArchivedAt:  issue.ArchivedAt,
+	}
+}
+
+type IssueStateChangePayload struct {
+	ID         string    `json:"id"`
+	IssueID    string    `json:"issue_id"`
+	FromState  string    `json:"from_state"`
+	ToState    string    `json:"to_state"`
+	Release    *string   `json:"release"`
+	UserID     *string   `json:"user_id"`
+	FeedbackID *string   `json:"feedback_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewIssueStateChangePayload(change IssueStateChange) *IssueStateChangePayload {
+	return &IssueStateChangePayload{
+		ID:         change.ID,
+		IssueID:    change.IssueID,
+		FromState:  change.FromState,
+		ToState:    change.ToState,
+		Release:    change.Release,
+		UserID:     change.UserID,
+		FeedbackID: change.FeedbackID,
+		CreatedAt:  change.CreatedAt,
 	}
 }
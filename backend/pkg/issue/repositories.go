@@ -2,6 +2,7 @@ package issue
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -10,6 +11,8 @@ import (
 
 	"backend/pkg/config"
 	"backend/pkg/feedback"
+	"backend/pkg/priority"
+	"backend/pkg/segment"
 	"backend/pkg/util"
 
 	"github.com/pgvector/pgvector-go"
@@ -38,6 +41,7 @@ func (self *IssueRepository) Create(ctx context.Context, issue Issue, feedback f
 			Set("id", i.ID).
 			Set("product_id", i.ProductID).
 			Set("embedding", i.Embedding).
+			Set("embedding_model", i.EmbeddingModel).
 			Set("sources", i.Sources).
 			Set("title", i.Title).
 			Set("description", i.Description).
@@ -49,6 +53,8 @@ func (self *IssueRepository) Create(ctx context.Context, issue Issue, feedback f
 			Set("customers", i.Customers).
 			Set("assignee_id", i.AssigneeID).
 			Set("quality", i.Quality).
+			Set("state", i.State).
+			Set("resolved_in_release", i.ResolvedInRelease).
 			Set("first_seen_at", i.FirstSeenAt).
 			Set("last_seen_at", i.LastSeenAt).
 			Set("created_at", i.CreatedAt).
@@ -85,6 +91,48 @@ func (self *IssueRepository) Create(ctx context.Context, issue Issue, feedback f
 	return i.ToEntity(), nil
 }
 
+// Restore inserts back a issue that was deleted, without linking any feedback to it.
+func (self *IssueRepository) Restore(ctx context.Context, issue Issue) error {
+	i := NewIssueModel(issue)
+
+	stmt := sqlf.
+		InsertInto(ISSUE_MODEL_TABLE).
+		Set("id", i.ID).
+		Set("product_id", i.ProductID).
+		Set("embedding", i.Embedding).
+		Set("embedding_model", i.EmbeddingModel).
+		Set("sources", i.Sources).
+		Set("title", i.Title).
+		Set("description", i.Description).
+		Set("steps", i.Steps).
+		Set("severities", i.Severities).
+		Set("priority", i.Priority).
+		Set("categories", i.Categories).
+		Set("releases", i.Releases).
+		Set("customers", i.Customers).
+		Set("assignee_id", i.AssigneeID).
+		Set("quality", i.Quality).
+		Set("state", i.State).
+		Set("resolved_in_release", i.ResolvedInRelease).
+		Set("first_seen_at", i.FirstSeenAt).
+		Set("last_seen_at", i.LastSeenAt).
+		Set("created_at", i.CreatedAt).
+		Set("archived_at", i.ArchivedAt).
+		Set("last_aggregated_at", i.LastAggregatedAt).
+		Set("exported_at", i.ExportedAt)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
 func (self *IssueRepository) GetByID(ctx context.Context, id string) (*Issue, error) {
 	var i IssueModel
 
@@ -137,6 +185,7 @@ func (self *IssueRepository) ListByProductID(ctx context.Context,
 
 	if search.Filters.Embedding != nil && len(*search.Filters.Embedding) > 0 {
 		stmt.
+			Where("embedding_model = ?", search.Filters.EmbeddingModel).
 			Where("(1 - (embedding <=> ?::vector)) >= ?", pgvector.NewVector(*search.Filters.Embedding), 0.3)
 	}
 
@@ -197,6 +246,11 @@ func (self *IssueRepository) ListByProductID(ctx context.Context,
 		}
 	}
 
+	if search.Filters.States != nil && len(*search.Filters.States) > 0 {
+		stmt.
+			Where("state").In(util.Spread(*search.Filters.States)...)
+	}
+
 	if search.Filters.Status != nil {
 		switch *search.Filters.Status {
 		case IssueSearchFiltersStatusActive:
@@ -204,8 +258,7 @@ func (self *IssueRepository) ListByProductID(ctx context.Context,
 				Where("(archived_at IS NULL OR (archived_at IS NOT NULL AND last_seen_at > archived_at))")
 		case IssueSearchFiltersStatusRegressed:
 			stmt.
-				Where("archived_at IS NOT NULL").
-				Where("last_seen_at > archived_at")
+				Where("state = ?", IssueStateRegressed)
 		case IssueSearchFiltersStatusArchived:
 			stmt.
 				Where("archived_at IS NOT NULL")
@@ -235,24 +288,43 @@ func (self *IssueRepository) ListByProductID(ctx context.Context,
 			Where("last_seen_at <= ?", *search.Filters.LastSeenEndAt)
 	}
 
+	if search.Filters.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery("id IN (", ")",
+				segment.NewSegmentLinksQuery(*search.Filters.Segment, ISSUE_FEEDBACK_MODEL_TABLE, "issue_id"))
+	}
+
+	column := "priority"
+	order := search.Orders.Relevance
+	if search.Orders.Revenue != nil {
+		column = "revenue_at_risk"
+		order = *search.Orders.Revenue
+	}
+
 	if search.Pagination.From != nil {
-		if search.Orders.Relevance == IssueSearchOrdersAscending {
+		value := search.Pagination.From.Value.Priority
+		if search.Orders.Revenue != nil {
+			value = search.Pagination.From.Value.RevenueAtRisk
+		}
+
+		if order == IssueSearchOrdersAscending {
 			stmt.
-				Where("(priority, last_seen_at, id) > (?, ?, ?)", search.Pagination.From.Value.Priority,
+				Where("("+column+", last_seen_at, id) > (?, ?, ?)", value,
 					search.Pagination.From.Value.SeenAt, search.Pagination.From.ID)
 		} else {
 			stmt.
-				Where("(priority, last_seen_at, id) < (?, ?, ?)", search.Pagination.From.Value.Priority,
+				Where("("+column+", last_seen_at, id) < (?, ?, ?)", value,
 					search.Pagination.From.Value.SeenAt, search.Pagination.From.ID)
 		}
 	}
 
-	if search.Orders.Relevance == IssueSearchOrdersAscending {
+	if order == IssueSearchOrdersAscending {
 		stmt.
-			OrderBy("priority ASC", "last_seen_at ASC", "id ASC")
+			OrderBy(column+" ASC", "last_seen_at ASC", "id ASC")
 	} else {
 		stmt.
-			OrderBy("priority DESC", "last_seen_at DESC", "id DESC")
+			OrderBy(column+" DESC", "last_seen_at DESC", "id DESC")
 	}
 
 	stmt.
@@ -276,8 +348,9 @@ func (self *IssueRepository) ListByProductID(ctx context.Context,
 	if len(items) == search.Pagination.Limit {
 		cursor = &util.Cursor[IssueSearchCursor]{
 			Value: IssueSearchCursor{
-				Priority: items[search.Pagination.Limit-1].Priority,
-				SeenAt:   items[search.Pagination.Limit-1].LastSeenAt,
+				Priority:      items[search.Pagination.Limit-1].Priority,
+				RevenueAtRisk: items[search.Pagination.Limit-1].RevenueAtRisk,
+				SeenAt:        items[search.Pagination.Limit-1].LastSeenAt,
 			},
 			ID: items[search.Pagination.Limit-1].ID,
 		}
@@ -289,28 +362,120 @@ func (self *IssueRepository) ListByProductID(ctx context.Context,
 	}, nil
 }
 
-func (self *IssueRepository) ListByEmbeddingAndProductID(ctx context.Context,
-	embedding []float32, threshold float64, limit int, productID string) ([]Issue, error) {
+func (self *IssueRepository) ListRandomByProductID(ctx context.Context,
+	productID string, limit int) ([]Issue, error) {
+	var is []IssueModel
+
+	stmt := sqlf.
+		Select("*").To(&is).
+		From(ISSUE_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		OrderBy("random()").
+		Limit(limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []Issue{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]Issue, 0, len(is))
+	for _, i := range is {
+		entities = append(entities, *i.ToEntity())
+	}
+
+	return entities, nil
+}
+
+func (self *IssueRepository) ListByProductIDAndNotArchived(ctx context.Context, productID string,
+	pagination util.Pagination[time.Time]) (*util.Page[Issue, time.Time], error) {
+	var is []IssueModel
+
+	stmt := sqlf.
+		Select("*").To(&is).
+		From(ISSUE_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		Where("archived_at IS NULL")
+
+	if pagination.From != nil {
+		stmt.
+			Where("(created_at, id) > (?, ?)", pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy("created_at ASC", "id ASC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[Issue, time.Time]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]Issue, 0, len(is))
+	for _, i := range is {
+		items = append(items, *i.ToEntity())
+	}
+
+	var cursor *util.Cursor[time.Time]
+	if len(is) == pagination.Limit {
+		cursor = &util.Cursor[time.Time]{
+			Value: is[pagination.Limit-1].CreatedAt,
+			ID:    is[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[Issue, time.Time]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
+
+// ListByEmbeddingAndProductID only compares the embedding with the ones computed by the same model.
+// The search is approximate, tuned by the configured vector search parameters unless others are given.
+func (self *IssueRepository) ListByEmbeddingAndProductID(ctx context.Context, embedding []float32,
+	embeddingModel string, threshold float64, limit int, productID string,
+	search *util.VectorSearch) ([]Issue, error) {
+	if search == nil {
+		search = util.NewVectorSearch(self.config)
+	}
+
 	var result []struct {
 		IssueModel
-		Score float64 `db:"score"`
+		Distance float64 `db:"distance"`
 	}
 
+	// The inner query must be ordered by the raw distance so it can be served by the HNSW index
 	stmt := sqlf.
 		Select("*").To(&result).
 		From("").
 		SubQuery("(", ")", sqlf.
 			Select("*").
-			Select("1 - (embedding <=> ?::vector) AS score", pgvector.NewVector(embedding)).
+			Select("embedding <=> ?::vector AS distance", pgvector.NewVector(embedding)).
 			From(ISSUE_MODEL_TABLE).
 			Where("product_id = ?", productID).
-			OrderBy("score DESC").
+			Where("embedding_model = ?", embeddingModel).
+			OrderBy("distance ASC").
 			Limit(limit)).
-		Where("score >= ?", threshold)
+		Where("1 - distance >= ?", threshold).
+		OrderBy("distance ASC")
 
-	err := self.database.Query(ctx, stmt)
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := search.Apply(ctx, self.database)
+		if err != nil {
+			return err
+		}
+
+		return self.database.Query(ctx, stmt)
+	})
 	if err != nil {
-		if kit.ErrDatabaseNoRows.Is(err) {
+		if kit.ErrDatabaseNoRows.In(err) {
 			return []Issue{}, nil
 		}
 
@@ -375,6 +540,244 @@ func (self *IssueRepository) ListFeedbacks(ctx context.Context,
 	}, nil
 }
 
+// ListFeedbacksByIDs returns which of the feedbacks are linked to a issue.
+func (self *IssueRepository) ListFeedbacksByIDs(ctx context.Context,
+	id string, feedbackIDs []string) ([]feedback.Feedback, error) {
+	var fs []feedback.FeedbackModel
+
+	if len(feedbackIDs) == 0 {
+		return []feedback.Feedback{}, nil
+	}
+
+	stmt := sqlf.
+		Select(feedback.FEEDBACK_MODEL_TABLE+".*").To(&fs).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Join(ISSUE_FEEDBACK_MODEL_TABLE,
+			ISSUE_FEEDBACK_MODEL_TABLE+".feedback_id = "+feedback.FEEDBACK_MODEL_TABLE+".id").
+		Where(ISSUE_FEEDBACK_MODEL_TABLE+".issue_id = ?", id).
+		Where(ISSUE_FEEDBACK_MODEL_TABLE+".feedback_id").In(util.Spread(feedbackIDs)...).
+		OrderBy(feedback.FEEDBACK_MODEL_TABLE+".posted_at ASC", feedback.FEEDBACK_MODEL_TABLE+".id ASC")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []feedback.Feedback{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]feedback.Feedback, 0, len(fs))
+	for _, f := range fs {
+		entities = append(entities, *f.ToEntity())
+	}
+
+	return entities, nil
+}
+
+func (self *IssueRepository) CountFeedbacks(ctx context.Context, id string) (int, error) {
+	var c int
+
+	stmt := sqlf.
+		Select("COUNT(*)").To(&c).
+		From(ISSUE_FEEDBACK_MODEL_TABLE).
+		Where("issue_id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return c, nil
+}
+
+// GetSeenAt returns when the first and the last feedbacks of a issue were posted, or nil if it has none.
+func (self *IssueRepository) GetSeenAt(ctx context.Context, id string) (*time.Time, *time.Time, error) {
+	var firstSeenAt *time.Time
+	var lastSeenAt *time.Time
+
+	stmt := sqlf.
+		Select("MIN("+feedback.FEEDBACK_MODEL_TABLE+".posted_at)").To(&firstSeenAt).
+		Select("MAX("+feedback.FEEDBACK_MODEL_TABLE+".posted_at)").To(&lastSeenAt).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Join(ISSUE_FEEDBACK_MODEL_TABLE,
+			ISSUE_FEEDBACK_MODEL_TABLE+".feedback_id = "+feedback.FEEDBACK_MODEL_TABLE+".id").
+		Where(ISSUE_FEEDBACK_MODEL_TABLE+".issue_id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil, nil
+		}
+
+		return nil, nil, err
+	}
+
+	return firstSeenAt, lastSeenAt, nil
+}
+
+// GetTrend counts the feedbacks of a issue posted within the window and within the window before.
+func (self *IssueRepository) GetTrend(ctx context.Context, id string,
+	window time.Duration, now time.Time) (*priority.PriorityTrend, error) {
+	trend := priority.PriorityTrend{}
+
+	stmt := sqlf.
+		Select("COUNT(*) FILTER (WHERE "+feedback.FEEDBACK_MODEL_TABLE+".posted_at > ?)",
+			now.Add(-window)).To(&trend.Recent).
+		Select("COUNT(*) FILTER (WHERE "+feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?)",
+			now.Add(-window)).To(&trend.Previous).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Join(ISSUE_FEEDBACK_MODEL_TABLE,
+			ISSUE_FEEDBACK_MODEL_TABLE+".feedback_id = "+feedback.FEEDBACK_MODEL_TABLE+".id").
+		Where(ISSUE_FEEDBACK_MODEL_TABLE+".issue_id = ?", id).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at > ?", now.Add(-2*window)).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", now)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &trend, nil
+		}
+
+		return nil, err
+	}
+
+	return &trend, nil
+}
+
+// ListTrendsByProductID counts, for every issue of a product with recent feedbacks, the feedbacks posted within
+// the window and within the window before.
+func (self *IssueRepository) ListTrendsByProductID(ctx context.Context, productID string,
+	window time.Duration, now time.Time) (map[string]priority.PriorityTrend, error) {
+	var ts []struct {
+		IssueID  string `db:"issue_id"`
+		Recent   int    `db:"recent"`
+		Previous int    `db:"previous"`
+	}
+
+	stmt := sqlf.
+		Select(fmt.Sprintf(`%s.issue_id,
+			COUNT(*) FILTER (WHERE %s.posted_at > ?) AS recent,
+			COUNT(*) FILTER (WHERE %s.posted_at <= ?) AS previous`,
+			ISSUE_FEEDBACK_MODEL_TABLE, feedback.FEEDBACK_MODEL_TABLE, feedback.FEEDBACK_MODEL_TABLE),
+			now.Add(-window), now.Add(-window)).To(&ts).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Join(ISSUE_FEEDBACK_MODEL_TABLE,
+			ISSUE_FEEDBACK_MODEL_TABLE+".feedback_id = "+feedback.FEEDBACK_MODEL_TABLE+".id").
+		Where(feedback.FEEDBACK_MODEL_TABLE+".product_id = ?", productID).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at > ?", now.Add(-2*window)).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", now).
+		GroupBy(ISSUE_FEEDBACK_MODEL_TABLE + ".issue_id")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return map[string]priority.PriorityTrend{}, nil
+		}
+
+		return nil, err
+	}
+
+	trends := make(map[string]priority.PriorityTrend, len(ts))
+	for _, t := range ts {
+		trends[t.IssueID] = priority.PriorityTrend{
+			Recent:   t.Recent,
+			Previous: t.Previous,
+		}
+	}
+
+	return trends, nil
+}
+
+// ListPrioritizableByProductID returns the issues of a product with only the fields their priority depends on, so
+// all of them can be rescored without loading their embeddings.
+func (self *IssueRepository) ListPrioritizableByProductID(ctx context.Context, productID string) ([]Issue, error) {
+	var is []struct {
+		ID            string    `db:"id"`
+		Sources       []byte    `db:"sources"`
+		Severities    []byte    `db:"severities"`
+		Priority      int       `db:"priority"`
+		Customers     int       `db:"customers"`
+		RevenueAtRisk int       `db:"revenue_at_risk"`
+		LastSeenAt    time.Time `db:"last_seen_at"`
+	}
+
+	stmt := sqlf.
+		Select("id, sources, severities, priority, customers, revenue_at_risk, last_seen_at").To(&is).
+		From(ISSUE_MODEL_TABLE).
+		Where("product_id = ?", productID)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []Issue{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]Issue, 0, len(is))
+	for _, i := range is {
+		var sources map[string]int
+		err := json.Unmarshal(i.Sources, &sources)
+		if err != nil {
+			return nil, err
+		}
+
+		var severities map[string]int
+		err = json.Unmarshal(i.Severities, &severities)
+		if err != nil {
+			return nil, err
+		}
+
+		entities = append(entities, Issue{
+			ID:            i.ID,
+			ProductID:     productID,
+			Sources:       sources,
+			Severities:    severities,
+			Priority:      i.Priority,
+			Customers:     i.Customers,
+			RevenueAtRisk: i.RevenueAtRisk,
+			LastSeenAt:    i.LastSeenAt,
+		})
+	}
+
+	return entities, nil
+}
+
+func (self *IssueRepository) UpdatePriorities(ctx context.Context, priorities map[string]int) error {
+	if len(priorities) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(priorities))
+	values := make([]int, 0, len(priorities))
+	for id, value := range priorities {
+		ids = append(ids, id)
+		values = append(values, value)
+	}
+
+	stmt := sqlf.
+		New(`UPDATE `+ISSUE_MODEL_TABLE+` SET "priority" = "updated"."priority"
+			FROM unnest(?::TEXT[], ?::BIGINT[]) AS "updated" ("id", "priority")
+			WHERE `+ISSUE_MODEL_TABLE+`."id" = "updated"."id"`, ids, values)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != len(priorities) {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, len(priorities))
+	}
+
+	return nil
+}
+
 func (self *IssueRepository) UpdateAssignee(ctx context.Context, id string, assigneeID *string) error {
 	stmt := sqlf.
 		Update(ISSUE_MODEL_TABLE).
@@ -429,6 +832,82 @@ func (self *IssueRepository) UpdateArchivedAt(ctx context.Context, id string, ar
 	return nil
 }
 
+// UpdateState moves a issue to another workflow state and records the change in its history.
+func (self *IssueRepository) UpdateState(ctx context.Context, issue Issue, change IssueStateChange) error {
+	i := NewIssueModel(issue)
+	c := NewIssueStateChangeModel(change)
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		stmt := sqlf.
+			Update(ISSUE_MODEL_TABLE).
+			Set("state", i.State).
+			Set("resolved_in_release", i.ResolvedInRelease).
+			Where("id = ?", i.ID)
+
+		affected, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+
+		if affected != 1 {
+			return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+		}
+
+		stmt = sqlf.
+			InsertInto(ISSUE_STATE_CHANGE_MODEL_TABLE).
+			Set("id", c.ID).
+			Set("issue_id", c.IssueID).
+			Set("from_state", c.FromState).
+			Set("to_state", c.ToState).
+			Set("release", c.Release).
+			Set("user_id", c.UserID).
+			Set("feedback_id", c.FeedbackID).
+			Set("created_at", c.CreatedAt)
+
+		affected, err = self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+
+		if affected != 1 {
+			return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *IssueRepository) ListStateChanges(ctx context.Context, id string) ([]IssueStateChange, error) {
+	var cs []IssueStateChangeModel
+
+	stmt := sqlf.
+		Select("*").To(&cs).
+		From(ISSUE_STATE_CHANGE_MODEL_TABLE).
+		Where("issue_id = ?", id).
+		OrderBy("created_at DESC", "id DESC")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []IssueStateChange{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]IssueStateChange, 0, len(cs))
+	for _, c := range cs {
+		entities = append(entities, *c.ToEntity())
+	}
+
+	return entities, nil
+}
+
 func (self *IssueRepository) UpdateAggregated(ctx context.Context, issue Issue,
 	feedback feedback.Feedback) error {
 	i := NewIssueModel(issue)
@@ -437,6 +916,7 @@ func (self *IssueRepository) UpdateAggregated(ctx context.Context, issue Issue,
 		stmt := sqlf.
 			Update(ISSUE_MODEL_TABLE).
 			Set("embedding", i.Embedding).
+			Set("embedding_model", i.EmbeddingModel).
 			Set("sources", i.Sources).
 			Set("title", i.Title).
 			Set("description", i.Description).
@@ -483,6 +963,186 @@ func (self *IssueRepository) UpdateAggregated(ctx context.Context, issue Issue,
 	return nil
 }
 
+func (self *IssueRepository) UpdateConsolidated(ctx context.Context, issue Issue) error {
+	i := NewIssueModel(issue)
+
+	stmt := sqlf.
+		Update(ISSUE_MODEL_TABLE).
+		Set("embedding", i.Embedding).
+		Set("embedding_model", i.EmbeddingModel).
+		Set("sources", i.Sources).
+		Set("title", i.Title).
+		Set("description", i.Description).
+		Set("steps", i.Steps).
+		Set("severities", i.Severities).
+		Set("priority", i.Priority).
+		Set("categories", i.Categories).
+		Set("releases", i.Releases).
+		Set("customers", i.Customers).
+		Set("assignee_id", i.AssigneeID).
+		Set("quality", i.Quality).
+		Set("first_seen_at", i.FirstSeenAt).
+		Set("last_seen_at", i.LastSeenAt).
+		Set("last_aggregated_at", i.LastAggregatedAt).
+		Where("id = ?", i.ID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+// MoveFeedbacks links every feedback of a issue to another one instead. It returns all the feedbacks that were
+// unlinked and, among them, the ones that were not already linked to the other issue.
+func (self *IssueRepository) MoveFeedbacks(ctx context.Context,
+	fromID string, toID string) ([]string, []string, error) {
+	var result []struct {
+		FeedbackID string `db:"feedback_id"`
+		Moved      bool   `db:"moved"`
+	}
+
+	stmt := sqlf.New(`WITH "deleted" AS (
+			DELETE FROM `+ISSUE_FEEDBACK_MODEL_TABLE+` WHERE "issue_id" = ? RETURNING "feedback_id"
+		), "inserted" AS (
+			INSERT INTO `+ISSUE_FEEDBACK_MODEL_TABLE+` ("issue_id", "feedback_id")
+			SELECT ?, "feedback_id" FROM "deleted"
+			ON CONFLICT DO NOTHING RETURNING "feedback_id"
+		)
+		SELECT "deleted"."feedback_id", "inserted"."feedback_id" IS NOT NULL AS "moved"
+		FROM "deleted" LEFT JOIN "inserted" USING ("feedback_id")`,
+		fromID, toID).
+		To(&result)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []string{}, []string{}, nil
+		}
+
+		return nil, nil, err
+	}
+
+	unlinked := make([]string, 0, len(result))
+	moved := make([]string, 0, len(result))
+	for _, res := range result {
+		unlinked = append(unlinked, res.FeedbackID)
+		if res.Moved {
+			moved = append(moved, res.FeedbackID)
+		}
+	}
+
+	return unlinked, moved, nil
+}
+
+// LinkFeedbacks links the feedbacks that still exist to a issue.
+func (self *IssueRepository) LinkFeedbacks(ctx context.Context, id string, feedbackIDs []string) error {
+	if len(feedbackIDs) == 0 {
+		return nil
+	}
+
+	stmt := sqlf.
+		New(`INSERT INTO `+ISSUE_FEEDBACK_MODEL_TABLE+` ("issue_id", "feedback_id")
+			SELECT ?, "id" FROM `+feedback.FEEDBACK_MODEL_TABLE, id).
+		Where("id").In(util.Spread(feedbackIDs)...).
+		Clause("ON CONFLICT DO NOTHING")
+
+	_, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *IssueRepository) UnlinkFeedbacks(ctx context.Context, id string, feedbackIDs []string) error {
+	if len(feedbackIDs) == 0 {
+		return nil
+	}
+
+	stmt := sqlf.
+		DeleteFrom(ISSUE_FEEDBACK_MODEL_TABLE).
+		Where("issue_id = ?", id).
+		Where("feedback_id").In(util.Spread(feedbackIDs)...)
+
+	_, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MoveStateChanges moves the history of a issue to another one, returning the state changes moved.
+func (self *IssueRepository) MoveStateChanges(ctx context.Context, fromID string, toID string) ([]string, error) {
+	var result []struct {
+		ID string `db:"id"`
+	}
+
+	stmt := sqlf.
+		Update(ISSUE_STATE_CHANGE_MODEL_TABLE).
+		Set("issue_id", toID).
+		Where("issue_id = ?", fromID).
+		Returning("id").To(&result)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []string{}, nil
+		}
+
+		return nil, err
+	}
+
+	moved := make([]string, 0, len(result))
+	for _, res := range result {
+		moved = append(moved, res.ID)
+	}
+
+	return moved, nil
+}
+
+// LinkStateChanges moves the state changes that still exist back to a issue.
+func (self *IssueRepository) LinkStateChanges(ctx context.Context, id string, stateChangeIDs []string) error {
+	if len(stateChangeIDs) == 0 {
+		return nil
+	}
+
+	stmt := sqlf.
+		Update(ISSUE_STATE_CHANGE_MODEL_TABLE).
+		Set("issue_id", id).
+		Where("id").In(util.Spread(stateChangeIDs)...)
+
+	_, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *IssueRepository) Delete(ctx context.Context, id string) error {
+	stmt := sqlf.
+		DeleteFrom(ISSUE_MODEL_TABLE).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
 type PartialIssueRepository struct {
 	config   config.Config
 	observer *kit.Observer
@@ -576,7 +1236,7 @@ func (self *PartialIssueRepository) GetByID(ctx context.Context, id string) (*Pa
 	return p.ToEntity(), nil
 }
 
-func (self *PartialIssueRepository) ListIDsByCreatedAt(ctx context.Context,
+func (self *PartialIssueRepository) ListIDsByCreatedAt(ctx context.Context, before time.Time,
 	pagination util.Pagination[time.Time]) (*util.Page[string, time.Time], error) {
 	var result []struct {
 		ID        string    `db:"id"`
@@ -585,7 +1245,8 @@ func (self *PartialIssueRepository) ListIDsByCreatedAt(ctx context.Context,
 
 	stmt := sqlf.
 		Select("id, created_at").To(&result).
-		From(PARTIAL_ISSUE_MODEL_TABLE)
+		From(PARTIAL_ISSUE_MODEL_TABLE).
+		Where("created_at <= ?", before)
 
 	if pagination.From != nil {
 		stmt.
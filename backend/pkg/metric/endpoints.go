@@ -2,6 +2,7 @@ package metric
 
 import (
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -10,6 +11,7 @@ import (
 
 	"backend/pkg/config"
 	"backend/pkg/product"
+	"backend/pkg/segment"
 )
 
 const (
@@ -83,6 +85,7 @@ func (self *MetricEndpoints) GetIssueCount(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -143,6 +146,7 @@ func (self *MetricEndpoints) GetIssueSources(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -200,6 +204,7 @@ func (self *MetricEndpoints) GetIssueSeverities(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -257,6 +262,7 @@ func (self *MetricEndpoints) GetIssueCategories(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -314,6 +320,7 @@ func (self *MetricEndpoints) GetIssueReleases(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -333,6 +340,64 @@ func (self *MetricEndpoints) GetIssueReleases(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, &response)
 }
 
+type MetricEndpointsGetIssueRegressionsRequest struct {
+	MetricEndpointsGetRequest
+}
+
+type MetricEndpointsGetIssueRegressionsResponse struct {
+	MetricEndpointsGetResponse
+	Releases map[string]int `json:"releases"`
+}
+
+func (self *MetricEndpoints) GetIssueRegressions(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := MetricEndpointsGetIssueRegressionsRequest{}
+
+	response := MetricEndpointsGetIssueRegressionsResponse{}
+	err := self.cache.Get(requestCtx,
+		METRIC_ENDPOINTS_KEY+"issue:regressions:"+requestProduct.ID+ctx.QueryString(), &response)
+	if err != nil && !kit.ErrCacheMiss.Is(err) {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	} else if err == nil {
+		return ctx.JSON(http.StatusOK, &response)
+	}
+
+	err = ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if request.PeriodStartAt != nil && request.PeriodEndAt != nil &&
+		request.PeriodStartAt.After(*request.PeriodEndAt) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	metric, err := self.metricRepository.GetIssueRegressions(requestCtx, IssueRegressionsParams{
+		Params: Params{
+			ProductID:     requestProduct.ID,
+			PeriodStartAt: request.PeriodStartAt,
+			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
+		},
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response = MetricEndpointsGetIssueRegressionsResponse{}
+	response.Releases = metric.Releases
+
+	err = self.cache.Set(requestCtx,
+		METRIC_ENDPOINTS_KEY+"issue:regressions:"+requestProduct.ID+ctx.QueryString(),
+		&response, kitUtil.Pointer(METRIC_ENDPOINTS_TTL))
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
 type MetricEndpointsGetSuggestionCountRequest struct {
 	MetricEndpointsGetRequest
 }
@@ -374,6 +439,7 @@ func (self *MetricEndpoints) GetSuggestionCount(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -434,6 +500,7 @@ func (self *MetricEndpoints) GetSuggestionSources(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -491,6 +558,7 @@ func (self *MetricEndpoints) GetSuggestionImportances(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -548,6 +616,7 @@ func (self *MetricEndpoints) GetSuggestionCategories(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -605,6 +674,7 @@ func (self *MetricEndpoints) GetSuggestionReleases(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -662,6 +732,7 @@ func (self *MetricEndpoints) GetReviewSentiments(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -719,6 +790,7 @@ func (self *MetricEndpoints) GetReviewSources(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -776,6 +848,7 @@ func (self *MetricEndpoints) GetReviewIntentions(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -833,6 +906,7 @@ func (self *MetricEndpoints) GetReviewEmotions(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -890,6 +964,7 @@ func (self *MetricEndpoints) GetReviewCategories(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -947,6 +1022,7 @@ func (self *MetricEndpoints) GetReviewReleases(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -1006,6 +1082,7 @@ func (self *MetricEndpoints) GetReviewKeywords(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -1027,6 +1104,233 @@ func (self *MetricEndpoints) GetReviewKeywords(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, &response)
 }
 
+type MetricEndpointsGetReviewAttributeRequest struct {
+	MetricEndpointsGetRequest
+}
+
+type MetricEndpointsGetReviewAttributeResponse struct {
+	MetricEndpointsGetResponse
+	Attribute string         `json:"attribute"`
+	Values    map[string]int `json:"values"`
+}
+
+func (self *MetricEndpoints) GetReviewAttribute(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := MetricEndpointsGetReviewAttributeRequest{}
+
+	attribute := ctx.Param("attribute")
+	if !slices.ContainsFunc(requestProduct.Attributes, func(other product.ProductAttribute) bool {
+		return other.Name == attribute
+	}) {
+		return kit.HTTPErrNotFound
+	}
+
+	response := MetricEndpointsGetReviewAttributeResponse{}
+	err := self.cache.Get(requestCtx,
+		METRIC_ENDPOINTS_KEY+"review:attribute:"+requestProduct.ID+":"+attribute+ctx.QueryString(), &response)
+	if err != nil && !kit.ErrCacheMiss.Is(err) {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	} else if err == nil {
+		return ctx.JSON(http.StatusOK, &response)
+	}
+
+	err = ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if request.PeriodStartAt != nil && request.PeriodEndAt != nil &&
+		request.PeriodStartAt.After(*request.PeriodEndAt) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	metric, err := self.metricRepository.GetReviewAttribute(requestCtx, ReviewAttributeParams{
+		Params: Params{
+			ProductID:     requestProduct.ID,
+			PeriodStartAt: request.PeriodStartAt,
+			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
+		},
+		Attribute: attribute,
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response = MetricEndpointsGetReviewAttributeResponse{}
+	response.Attribute = attribute
+	response.Values = metric.Values
+
+	err = self.cache.Set(requestCtx,
+		METRIC_ENDPOINTS_KEY+"review:attribute:"+requestProduct.ID+":"+attribute+ctx.QueryString(),
+		&response, kitUtil.Pointer(METRIC_ENDPOINTS_TTL))
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type MetricEndpointsGetReviewAspectsRequest struct {
+	MetricEndpointsGetRequest
+}
+
+type MetricEndpointsGetReviewAspectsResponseSentiments struct {
+	Positive int `json:"positive"`
+	Neutral  int `json:"neutral"`
+	Negative int `json:"negative"`
+}
+
+type MetricEndpointsGetReviewAspectsResponse struct {
+	MetricEndpointsGetResponse
+	Aspects map[string]MetricEndpointsGetReviewAspectsResponseSentiments `json:"aspects"`
+}
+
+func (self *MetricEndpoints) GetReviewAspects(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := MetricEndpointsGetReviewAspectsRequest{}
+
+	response := MetricEndpointsGetReviewAspectsResponse{}
+	err := self.cache.Get(requestCtx,
+		METRIC_ENDPOINTS_KEY+"review:aspects:"+requestProduct.ID+ctx.QueryString(), &response)
+	if err != nil && !kit.ErrCacheMiss.Is(err) {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	} else if err == nil {
+		return ctx.JSON(http.StatusOK, &response)
+	}
+
+	err = ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if request.PeriodStartAt != nil && request.PeriodEndAt != nil &&
+		request.PeriodStartAt.After(*request.PeriodEndAt) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	metric, err := self.metricRepository.GetReviewAspects(requestCtx, ReviewAspectsParams{
+		Params: Params{
+			ProductID:     requestProduct.ID,
+			PeriodStartAt: request.PeriodStartAt,
+			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
+		},
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response = MetricEndpointsGetReviewAspectsResponse{}
+	response.Aspects = make(map[string]MetricEndpointsGetReviewAspectsResponseSentiments, len(metric.Aspects))
+	for aspect, sentiments := range metric.Aspects {
+		response.Aspects[aspect] = MetricEndpointsGetReviewAspectsResponseSentiments{
+			Positive: sentiments.Positive,
+			Neutral:  sentiments.Neutral,
+			Negative: sentiments.Negative,
+		}
+	}
+
+	err = self.cache.Set(requestCtx,
+		METRIC_ENDPOINTS_KEY+"review:aspects:"+requestProduct.ID+ctx.QueryString(),
+		&response, kitUtil.Pointer(METRIC_ENDPOINTS_TTL))
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type MetricEndpointsGetReviewAspectTrendRequest struct {
+	MetricEndpointsGetRequest
+	Interval *string `query:"interval"`
+}
+
+type MetricEndpointsGetReviewAspectTrendResponsePeriod struct {
+	StartAt  time.Time `json:"start_at"`
+	Positive int       `json:"positive"`
+	Neutral  int       `json:"neutral"`
+	Negative int       `json:"negative"`
+}
+
+type MetricEndpointsGetReviewAspectTrendResponse struct {
+	MetricEndpointsGetResponse
+	Aspect  string                                              `json:"aspect"`
+	Periods []MetricEndpointsGetReviewAspectTrendResponsePeriod `json:"periods"`
+}
+
+func (self *MetricEndpoints) GetReviewAspectTrend(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := MetricEndpointsGetReviewAspectTrendRequest{}
+
+	aspect := ctx.Param("aspect")
+
+	response := MetricEndpointsGetReviewAspectTrendResponse{}
+	err := self.cache.Get(requestCtx,
+		METRIC_ENDPOINTS_KEY+"review:aspect-trend:"+requestProduct.ID+":"+aspect+ctx.QueryString(), &response)
+	if err != nil && !kit.ErrCacheMiss.Is(err) {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	} else if err == nil {
+		return ctx.JSON(http.StatusOK, &response)
+	}
+
+	err = ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if request.PeriodStartAt != nil && request.PeriodEndAt != nil &&
+		request.PeriodStartAt.After(*request.PeriodEndAt) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	if request.Interval != nil {
+		if !IsReviewAspectTrendInterval(*request.Interval) {
+			return kit.HTTPErrInvalidRequest
+		}
+	} else {
+		request.Interval = kitUtil.Pointer(ReviewAspectTrendIntervalWeek)
+	}
+
+	metric, err := self.metricRepository.GetReviewAspectTrend(requestCtx, ReviewAspectTrendParams{
+		Params: Params{
+			ProductID:     requestProduct.ID,
+			PeriodStartAt: request.PeriodStartAt,
+			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
+		},
+		Aspect:   aspect,
+		Interval: *request.Interval,
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response = MetricEndpointsGetReviewAspectTrendResponse{}
+	response.Aspect = aspect
+	response.Periods = make([]MetricEndpointsGetReviewAspectTrendResponsePeriod, 0, len(metric.Periods))
+	for _, period := range metric.Periods {
+		response.Periods = append(response.Periods, MetricEndpointsGetReviewAspectTrendResponsePeriod{
+			StartAt:  period.StartAt,
+			Positive: period.Positive,
+			Neutral:  period.Neutral,
+			Negative: period.Negative,
+		})
+	}
+
+	err = self.cache.Set(requestCtx,
+		METRIC_ENDPOINTS_KEY+"review:aspect-trend:"+requestProduct.ID+":"+aspect+ctx.QueryString(),
+		&response, kitUtil.Pointer(METRIC_ENDPOINTS_TTL))
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
 type MetricEndpointsGetNetPromoterScoreRequest struct {
 	MetricEndpointsGetRequest
 }
@@ -1065,6 +1369,7 @@ func (self *MetricEndpoints) GetNetPromoterScore(ctx echo.Context) error {
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -1122,6 +1427,7 @@ func (self *MetricEndpoints) GetCustomerSatisfactionScore(ctx echo.Context) erro
 			ProductID:     requestProduct.ID,
 			PeriodStartAt: request.PeriodStartAt,
 			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
 		},
 	})
 	if err != nil {
@@ -1140,3 +1446,138 @@ func (self *MetricEndpoints) GetCustomerSatisfactionScore(ctx echo.Context) erro
 
 	return ctx.JSON(http.StatusOK, &response)
 }
+
+type MetricEndpointsGetChurnRateRequest struct {
+	MetricEndpointsGetRequest
+}
+
+type MetricEndpointsGetChurnRateResponse struct {
+	MetricEndpointsGetResponse
+	Rate      float64 `json:"rate"`
+	Customers int     `json:"customers"`
+	AtRisk    int     `json:"at_risk"`
+}
+
+func (self *MetricEndpoints) GetChurnRate(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := MetricEndpointsGetChurnRateRequest{}
+
+	response := MetricEndpointsGetChurnRateResponse{}
+	err := self.cache.Get(requestCtx,
+		METRIC_ENDPOINTS_KEY+"churn-rate:"+requestProduct.ID+ctx.QueryString(), &response)
+	if err != nil && !kit.ErrCacheMiss.Is(err) {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	} else if err == nil {
+		return ctx.JSON(http.StatusOK, &response)
+	}
+
+	err = ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if request.PeriodStartAt != nil && request.PeriodEndAt != nil &&
+		request.PeriodStartAt.After(*request.PeriodEndAt) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	metric, err := self.metricRepository.GetChurnRate(requestCtx, ChurnRateParams{
+		Params: Params{
+			ProductID:     requestProduct.ID,
+			PeriodStartAt: request.PeriodStartAt,
+			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
+		},
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response = MetricEndpointsGetChurnRateResponse{}
+	response.Rate = metric.Rate
+	response.Customers = metric.Customers
+	response.AtRisk = metric.AtRisk
+
+	err = self.cache.Set(requestCtx,
+		METRIC_ENDPOINTS_KEY+"churn-rate:"+requestProduct.ID+ctx.QueryString(),
+		&response, kitUtil.Pointer(METRIC_ENDPOINTS_TTL))
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type MetricEndpointsGetPipelineLatencyRequest struct {
+	MetricEndpointsGetRequest
+}
+
+type MetricEndpointsGetPipelineLatencyResponseStage struct {
+	Average float64 `json:"average"`
+	P95     float64 `json:"p95"`
+}
+
+type MetricEndpointsGetPipelineLatencyResponse struct {
+	MetricEndpointsGetResponse
+	Translation  MetricEndpointsGetPipelineLatencyResponseStage `json:"translation"`
+	Processing   MetricEndpointsGetPipelineLatencyResponseStage `json:"processing"`
+	Aggregation  MetricEndpointsGetPipelineLatencyResponseStage `json:"aggregation"`
+	Total        MetricEndpointsGetPipelineLatencyResponseStage `json:"total"`
+	WithinTarget float64                                        `json:"within_target"`
+	Pending      int                                            `json:"pending"`
+}
+
+func (self *MetricEndpoints) GetPipelineLatency(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := MetricEndpointsGetPipelineLatencyRequest{}
+
+	response := MetricEndpointsGetPipelineLatencyResponse{}
+	err := self.cache.Get(requestCtx,
+		METRIC_ENDPOINTS_KEY+"pipeline:latency:"+requestProduct.ID+ctx.QueryString(), &response)
+	if err != nil && !kit.ErrCacheMiss.Is(err) {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	} else if err == nil {
+		return ctx.JSON(http.StatusOK, &response)
+	}
+
+	err = ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if request.PeriodStartAt != nil && request.PeriodEndAt != nil &&
+		request.PeriodStartAt.After(*request.PeriodEndAt) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	metric, err := self.metricRepository.GetPipelineLatency(requestCtx, PipelineLatencyParams{
+		Params: Params{
+			ProductID:     requestProduct.ID,
+			PeriodStartAt: request.PeriodStartAt,
+			PeriodEndAt:   request.PeriodEndAt,
+			Segment:       segment.RequestSegment(requestCtx),
+		},
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response = MetricEndpointsGetPipelineLatencyResponse{}
+	response.Translation = MetricEndpointsGetPipelineLatencyResponseStage(metric.Translation)
+	response.Processing = MetricEndpointsGetPipelineLatencyResponseStage(metric.Processing)
+	response.Aggregation = MetricEndpointsGetPipelineLatencyResponseStage(metric.Aggregation)
+	response.Total = MetricEndpointsGetPipelineLatencyResponseStage(metric.Total)
+	response.WithinTarget = metric.WithinTarget
+	response.Pending = metric.Pending
+
+	err = self.cache.Set(requestCtx,
+		METRIC_ENDPOINTS_KEY+"pipeline:latency:"+requestProduct.ID+ctx.QueryString(),
+		&response, kitUtil.Pointer(METRIC_ENDPOINTS_TTL))
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
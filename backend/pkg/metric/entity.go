@@ -1,11 +1,16 @@
 package metric
 
-import "time"
+import (
+	"time"
+
+	"backend/pkg/segment"
+)
 
 type Params struct {
 	ProductID     string
 	PeriodStartAt *time.Time
 	PeriodEndAt   *time.Time
+	Segment       *segment.Segment
 }
 
 type Metric struct {
@@ -59,6 +64,33 @@ type IssueReleasesMetric struct {
 	Releases map[string]int
 }
 
+type IssueRegressionsParams struct {
+	Params
+}
+
+type IssueRegressionsMetric struct {
+	Metric
+	Releases map[string]int
+}
+
+type IssueNewCriticalsParams struct {
+	Params
+}
+
+type IssueNewCriticalsMetric struct {
+	Metric
+	Issues int
+}
+
+type IssueArchivedActivityParams struct {
+	Params
+}
+
+type IssueArchivedActivityMetric struct {
+	Metric
+	Issues int
+}
+
 type SuggestionCountParams struct {
 	Params
 }
@@ -176,6 +208,67 @@ type ReviewKeywordsMetric struct {
 	Negative map[string]int
 }
 
+const (
+	METRIC_REVIEW_ATTRIBUTE_LIMIT = 50
+)
+
+type ReviewAttributeParams struct {
+	Params
+	Attribute string
+}
+
+type ReviewAttributeMetric struct {
+	Metric
+	Values map[string]int
+}
+
+const (
+	METRIC_REVIEW_ASPECTS_LIMIT = 50
+)
+
+type ReviewAspectSentiments struct {
+	Positive int
+	Neutral  int
+	Negative int
+}
+
+type ReviewAspectsParams struct {
+	Params
+}
+
+type ReviewAspectsMetric struct {
+	Metric
+	Aspects map[string]ReviewAspectSentiments
+}
+
+const (
+	ReviewAspectTrendIntervalDay   = "DAY"
+	ReviewAspectTrendIntervalWeek  = "WEEK"
+	ReviewAspectTrendIntervalMonth = "MONTH"
+)
+
+func IsReviewAspectTrendInterval(value string) bool {
+	return value == ReviewAspectTrendIntervalDay ||
+		value == ReviewAspectTrendIntervalWeek ||
+		value == ReviewAspectTrendIntervalMonth
+}
+
+type ReviewAspectTrendParams struct {
+	Params
+	Aspect   string
+	Interval string
+}
+
+type ReviewAspectTrendPeriod struct {
+	ReviewAspectSentiments
+	StartAt time.Time
+}
+
+type ReviewAspectTrendMetric struct {
+	Metric
+	Periods []ReviewAspectTrendPeriod
+}
+
 type NetPromoterScoreParams struct {
 	Params
 }
@@ -193,3 +286,35 @@ type CustomerSatisfactionScoreMetric struct {
 	Metric
 	Score float64
 }
+
+type ChurnRateParams struct {
+	Params
+}
+
+// ChurnRateMetric is the share of the customers active within the period predicted to churn, along with how many
+// are at risk of churning.
+type ChurnRateMetric struct {
+	Metric
+	Rate      float64
+	Customers int
+	AtRisk    int
+}
+
+type PipelineLatencyParams struct {
+	Params
+}
+
+type PipelineStageLatency struct {
+	Average float64
+	P95     float64
+}
+
+type PipelineLatencyMetric struct {
+	Metric
+	Translation  PipelineStageLatency
+	Processing   PipelineStageLatency
+	Aggregation  PipelineStageLatency
+	Total        PipelineStageLatency
+	WithinTarget float64
+	Pending      int
+}
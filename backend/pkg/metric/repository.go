@@ -3,16 +3,22 @@ package metric
 import (
 	"context"
 	"math"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/leporo/sqlf"
 	"github.com/neoxelox/kit"
 
+	"backend/pkg/churn"
 	"backend/pkg/config"
 	"backend/pkg/engine"
 	"backend/pkg/feedback"
 	"backend/pkg/issue"
 	"backend/pkg/review"
+	"backend/pkg/segment"
 	"backend/pkg/suggestion"
+	"backend/pkg/util"
 )
 
 type MetricRepository struct {
@@ -56,6 +62,13 @@ func (self *MetricRepository) GetIssueCount(ctx context.Context,
 			Where("first_seen_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(issue.ISSUE_MODEL_TABLE+".id IN (", ")",
+				segment.NewSegmentLinksQuery(*params.Segment, issue.ISSUE_FEEDBACK_MODEL_TABLE, "issue_id"))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		return nil, err
@@ -81,6 +94,12 @@ func (self *MetricRepository) GetIssueCount(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err = self.database.Query(ctx, stmt)
 	if err != nil {
 		return nil, err
@@ -118,6 +137,13 @@ func (self *MetricRepository) GetIssueSources(ctx context.Context,
 			Where("first_seen_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(issue.ISSUE_MODEL_TABLE+".id IN (", ")",
+				segment.NewSegmentLinksQuery(*params.Segment, issue.ISSUE_FEEDBACK_MODEL_TABLE, "issue_id"))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -160,6 +186,13 @@ func (self *MetricRepository) GetIssueSeverities(ctx context.Context,
 			Where("first_seen_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		inner.
+			Where("").
+			SubQuery(issue.ISSUE_MODEL_TABLE+".id IN (", ")",
+				segment.NewSegmentLinksQuery(*params.Segment, issue.ISSUE_FEEDBACK_MODEL_TABLE, "issue_id"))
+	}
+
 	outer := sqlf.
 		Select("severity, COUNT(*)").To(&result).
 		From("").
@@ -209,6 +242,13 @@ func (self *MetricRepository) GetIssueCategories(ctx context.Context,
 			Where("first_seen_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		inner.
+			Where("").
+			SubQuery(issue.ISSUE_MODEL_TABLE+".id IN (", ")",
+				segment.NewSegmentLinksQuery(*params.Segment, issue.ISSUE_FEEDBACK_MODEL_TABLE, "issue_id"))
+	}
+
 	outer := sqlf.
 		Select("category, COUNT(*)").To(&result).
 		From("").
@@ -258,6 +298,65 @@ func (self *MetricRepository) GetIssueReleases(ctx context.Context,
 			Where("first_seen_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(issue.ISSUE_MODEL_TABLE+".id IN (", ")",
+				segment.NewSegmentLinksQuery(*params.Segment, issue.ISSUE_FEEDBACK_MODEL_TABLE, "issue_id"))
+	}
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &metric, nil
+		}
+
+		return nil, err
+	}
+
+	for _, res := range result {
+		metric.Releases[res.Release] = res.Count
+	}
+
+	return &metric, nil
+}
+
+func (self *MetricRepository) GetIssueRegressions(ctx context.Context,
+	params IssueRegressionsParams) (*IssueRegressionsMetric, error) {
+	var result []struct {
+		Release string `db:"release"`
+		Count   int    `db:"count"`
+	}
+	metric := IssueRegressionsMetric{}
+	metric.Releases = make(map[string]int)
+
+	stmt := sqlf.
+		Select(issue.ISSUE_STATE_CHANGE_MODEL_TABLE+".release, COUNT(*)").To(&result).
+		From(issue.ISSUE_STATE_CHANGE_MODEL_TABLE).
+		Join(issue.ISSUE_MODEL_TABLE,
+			issue.ISSUE_MODEL_TABLE+".id = "+issue.ISSUE_STATE_CHANGE_MODEL_TABLE+".issue_id").
+		Where(issue.ISSUE_MODEL_TABLE+".product_id = ?", params.ProductID).
+		Where(issue.ISSUE_STATE_CHANGE_MODEL_TABLE+".to_state = ?", issue.IssueStateRegressed).
+		Where(issue.ISSUE_STATE_CHANGE_MODEL_TABLE + ".release IS NOT NULL").
+		GroupBy(issue.ISSUE_STATE_CHANGE_MODEL_TABLE + ".release")
+
+	if params.PeriodStartAt != nil {
+		stmt.
+			Where(issue.ISSUE_STATE_CHANGE_MODEL_TABLE+".created_at >= ?", *params.PeriodStartAt)
+	}
+
+	if params.PeriodEndAt != nil {
+		stmt.
+			Where(issue.ISSUE_STATE_CHANGE_MODEL_TABLE+".created_at <= ?", *params.PeriodEndAt)
+	}
+
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(issue.ISSUE_MODEL_TABLE+".id IN (", ")",
+				segment.NewSegmentLinksQuery(*params.Segment, issue.ISSUE_FEEDBACK_MODEL_TABLE, "issue_id"))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -274,6 +373,81 @@ func (self *MetricRepository) GetIssueReleases(ctx context.Context,
 	return &metric, nil
 }
 
+// GetIssueNewCriticals counts the issues first seen within the period whose major severity is critical.
+func (self *MetricRepository) GetIssueNewCriticals(ctx context.Context,
+	params IssueNewCriticalsParams) (*IssueNewCriticalsMetric, error) {
+	var metric IssueNewCriticalsMetric
+
+	// Ties are broken the same way as issue.Issue.Severity()
+	stmt := sqlf.
+		Select("COUNT(*)").To(&metric.Issues).
+		From(issue.ISSUE_MODEL_TABLE).
+		Where("product_id = ?", params.ProductID).
+		Where(`(SELECT severity.key FROM jsonb_each_text(severities) AS severity
+			ORDER BY severity.value::int DESC, severity.key DESC LIMIT 1) = ?`, issue.IssueSeverityCritical)
+
+	if params.PeriodStartAt != nil {
+		stmt.
+			Where("first_seen_at >= ?", *params.PeriodStartAt)
+	}
+
+	if params.PeriodEndAt != nil {
+		stmt.
+			Where("first_seen_at <= ?", *params.PeriodEndAt)
+	}
+
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(issue.ISSUE_MODEL_TABLE+".id IN (", ")",
+				segment.NewSegmentLinksQuery(*params.Segment, issue.ISSUE_FEEDBACK_MODEL_TABLE, "issue_id"))
+	}
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &metric, nil
+}
+
+// GetIssueArchivedActivity counts the archived issues that got new feedbacks aggregated within the period.
+func (self *MetricRepository) GetIssueArchivedActivity(ctx context.Context,
+	params IssueArchivedActivityParams) (*IssueArchivedActivityMetric, error) {
+	var metric IssueArchivedActivityMetric
+
+	stmt := sqlf.
+		Select("COUNT(*)").To(&metric.Issues).
+		From(issue.ISSUE_MODEL_TABLE).
+		Where("product_id = ?", params.ProductID).
+		Where("archived_at IS NOT NULL").
+		Where("last_aggregated_at > archived_at")
+
+	if params.PeriodStartAt != nil {
+		stmt.
+			Where("last_aggregated_at >= ?", *params.PeriodStartAt)
+	}
+
+	if params.PeriodEndAt != nil {
+		stmt.
+			Where("last_aggregated_at <= ?", *params.PeriodEndAt)
+	}
+
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(issue.ISSUE_MODEL_TABLE+".id IN (", ")",
+				segment.NewSegmentLinksQuery(*params.Segment, issue.ISSUE_FEEDBACK_MODEL_TABLE, "issue_id"))
+	}
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &metric, nil
+}
+
 func (self *MetricRepository) GetSuggestionCount(ctx context.Context,
 	params SuggestionCountParams) (*SuggestionCountMetric, error) {
 	var metric SuggestionCountMetric
@@ -301,6 +475,13 @@ func (self *MetricRepository) GetSuggestionCount(ctx context.Context,
 			Where("first_seen_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(suggestion.SUGGESTION_MODEL_TABLE+".id IN (", ")", segment.NewSegmentLinksQuery(*params.Segment,
+				suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE, "suggestion_id"))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		return nil, err
@@ -326,6 +507,12 @@ func (self *MetricRepository) GetSuggestionCount(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err = self.database.Query(ctx, stmt)
 	if err != nil {
 		return nil, err
@@ -363,6 +550,13 @@ func (self *MetricRepository) GetSuggestionSources(ctx context.Context,
 			Where("first_seen_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(suggestion.SUGGESTION_MODEL_TABLE+".id IN (", ")", segment.NewSegmentLinksQuery(*params.Segment,
+				suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE, "suggestion_id"))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -405,6 +599,13 @@ func (self *MetricRepository) GetSuggestionImportances(ctx context.Context,
 			Where("first_seen_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		inner.
+			Where("").
+			SubQuery(suggestion.SUGGESTION_MODEL_TABLE+".id IN (", ")", segment.NewSegmentLinksQuery(*params.Segment,
+				suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE, "suggestion_id"))
+	}
+
 	outer := sqlf.
 		Select("importance, COUNT(*)").To(&result).
 		From("").
@@ -454,6 +655,13 @@ func (self *MetricRepository) GetSuggestionCategories(ctx context.Context,
 			Where("first_seen_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		inner.
+			Where("").
+			SubQuery(suggestion.SUGGESTION_MODEL_TABLE+".id IN (", ")", segment.NewSegmentLinksQuery(*params.Segment,
+				suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE, "suggestion_id"))
+	}
+
 	outer := sqlf.
 		Select("category, COUNT(*)").To(&result).
 		From("").
@@ -503,6 +711,13 @@ func (self *MetricRepository) GetSuggestionReleases(ctx context.Context,
 			Where("first_seen_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(suggestion.SUGGESTION_MODEL_TABLE+".id IN (", ")", segment.NewSegmentLinksQuery(*params.Segment,
+				suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE, "suggestion_id"))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -546,6 +761,12 @@ func (self *MetricRepository) GetReviewSentiments(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -587,6 +808,12 @@ func (self *MetricRepository) GetReviewSources(ctx context.Context,
 			Where("posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -630,6 +857,12 @@ func (self *MetricRepository) GetReviewIntentions(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -673,6 +906,12 @@ func (self *MetricRepository) GetReviewEmotions(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -716,6 +955,12 @@ func (self *MetricRepository) GetReviewCategories(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -757,6 +1002,12 @@ func (self *MetricRepository) GetReviewReleases(ctx context.Context,
 			Where("posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -803,6 +1054,12 @@ func (self *MetricRepository) GetReviewKeywords(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil && !kit.ErrDatabaseNoRows.Is(err) {
 		return nil, err
@@ -839,6 +1096,12 @@ func (self *MetricRepository) GetReviewKeywords(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err = self.database.Query(ctx, stmt)
 	if err != nil && !kit.ErrDatabaseNoRows.Is(err) {
 		return nil, err
@@ -875,6 +1138,12 @@ func (self *MetricRepository) GetReviewKeywords(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err = self.database.Query(ctx, stmt)
 	if err != nil && !kit.ErrDatabaseNoRows.Is(err) {
 		return nil, err
@@ -887,6 +1156,215 @@ func (self *MetricRepository) GetReviewKeywords(ctx context.Context,
 	return &metric, nil
 }
 
+// GetReviewAttribute breaks down the reviews that mention a custom attribute by its value, most common first.
+func (self *MetricRepository) GetReviewAttribute(ctx context.Context,
+	params ReviewAttributeParams) (*ReviewAttributeMetric, error) {
+	var result []struct {
+		Value string `db:"value"`
+		Count int    `db:"count"`
+	}
+	metric := ReviewAttributeMetric{}
+	metric.Values = make(map[string]int)
+
+	stmt := sqlf.
+		Select(review.REVIEW_MODEL_TABLE+".attributes ->> ?::TEXT AS value, COUNT(*)", params.Attribute).To(&result).
+		From(review.REVIEW_MODEL_TABLE).
+		Join(feedback.FEEDBACK_MODEL_TABLE,
+			feedback.FEEDBACK_MODEL_TABLE+".id = "+review.REVIEW_MODEL_TABLE+".feedback_id").
+		Where(review.REVIEW_MODEL_TABLE+".product_id = ?", params.ProductID).
+		Where(review.REVIEW_MODEL_TABLE+".attributes ->> ?::TEXT IS NOT NULL", params.Attribute).
+		GroupBy("value").
+		OrderBy("COUNT(*) DESC").
+		Limit(METRIC_REVIEW_ATTRIBUTE_LIMIT)
+
+	if params.PeriodStartAt != nil {
+		stmt.
+			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at >= ?", *params.PeriodStartAt)
+	}
+
+	if params.PeriodEndAt != nil {
+		stmt.
+			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
+	}
+
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &metric, nil
+		}
+
+		return nil, err
+	}
+
+	for _, res := range result {
+		metric.Values[res.Value] = res.Count
+	}
+
+	return &metric, nil
+}
+
+// GetReviewAspects breaks down the sentiment towards the most mentioned aspects of the product.
+func (self *MetricRepository) GetReviewAspects(ctx context.Context,
+	params ReviewAspectsParams) (*ReviewAspectsMetric, error) {
+	var result []struct {
+		Aspect    string `db:"aspect"`
+		Sentiment string `db:"sentiment"`
+		Count     int    `db:"count"`
+	}
+	metric := ReviewAspectsMetric{}
+	metric.Aspects = make(map[string]ReviewAspectSentiments)
+
+	stmt := sqlf.
+		Select(review.REVIEW_ASPECT_MODEL_TABLE+".aspect, "+review.REVIEW_ASPECT_MODEL_TABLE+".sentiment, COUNT(*)").
+		To(&result).
+		From(review.REVIEW_ASPECT_MODEL_TABLE).
+		Join(review.REVIEW_MODEL_TABLE,
+			review.REVIEW_MODEL_TABLE+".id = "+review.REVIEW_ASPECT_MODEL_TABLE+".review_id").
+		Join(feedback.FEEDBACK_MODEL_TABLE,
+			feedback.FEEDBACK_MODEL_TABLE+".id = "+review.REVIEW_MODEL_TABLE+".feedback_id").
+		Where(review.REVIEW_ASPECT_MODEL_TABLE+".product_id = ?", params.ProductID).
+		GroupBy(review.REVIEW_ASPECT_MODEL_TABLE + ".aspect, " + review.REVIEW_ASPECT_MODEL_TABLE + ".sentiment")
+
+	if params.PeriodStartAt != nil {
+		stmt.
+			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at >= ?", *params.PeriodStartAt)
+	}
+
+	if params.PeriodEndAt != nil {
+		stmt.
+			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
+	}
+
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &metric, nil
+		}
+
+		return nil, err
+	}
+
+	for _, res := range result {
+		sentiments := metric.Aspects[res.Aspect]
+		switch res.Sentiment {
+		case review.ReviewSentimentPositive:
+			sentiments.Positive += res.Count
+		case review.ReviewSentimentNeutral:
+			sentiments.Neutral += res.Count
+		case review.ReviewSentimentNegative:
+			sentiments.Negative += res.Count
+		}
+		metric.Aspects[res.Aspect] = sentiments
+	}
+
+	if len(metric.Aspects) > METRIC_REVIEW_ASPECTS_LIMIT {
+		aspects := make([]string, 0, len(metric.Aspects))
+		for aspect := range metric.Aspects {
+			aspects = append(aspects, aspect)
+		}
+
+		mentions := func(aspect string) int {
+			sentiments := metric.Aspects[aspect]
+			return sentiments.Positive + sentiments.Neutral + sentiments.Negative
+		}
+
+		slices.SortFunc(aspects, func(a, b string) int {
+			if mentions(a) != mentions(b) {
+				return mentions(b) - mentions(a)
+			}
+
+			return strings.Compare(a, b)
+		})
+
+		for _, aspect := range aspects[METRIC_REVIEW_ASPECTS_LIMIT:] {
+			delete(metric.Aspects, aspect)
+		}
+	}
+
+	return &metric, nil
+}
+
+// GetReviewAspectTrend buckets the sentiment towards an aspect of the product by the time its feedbacks were posted.
+func (self *MetricRepository) GetReviewAspectTrend(ctx context.Context,
+	params ReviewAspectTrendParams) (*ReviewAspectTrendMetric, error) {
+	var result []struct {
+		Period    time.Time `db:"period"`
+		Sentiment string    `db:"sentiment"`
+		Count     int       `db:"count"`
+	}
+	metric := ReviewAspectTrendMetric{}
+	metric.Periods = []ReviewAspectTrendPeriod{}
+
+	stmt := sqlf.
+		Select("DATE_TRUNC(?::TEXT, "+feedback.FEEDBACK_MODEL_TABLE+".posted_at) AS period, "+
+			review.REVIEW_ASPECT_MODEL_TABLE+".sentiment, COUNT(*)", strings.ToLower(params.Interval)).
+		To(&result).
+		From(review.REVIEW_ASPECT_MODEL_TABLE).
+		Join(review.REVIEW_MODEL_TABLE,
+			review.REVIEW_MODEL_TABLE+".id = "+review.REVIEW_ASPECT_MODEL_TABLE+".review_id").
+		Join(feedback.FEEDBACK_MODEL_TABLE,
+			feedback.FEEDBACK_MODEL_TABLE+".id = "+review.REVIEW_MODEL_TABLE+".feedback_id").
+		Where(review.REVIEW_ASPECT_MODEL_TABLE+".product_id = ?", params.ProductID).
+		Where(review.REVIEW_ASPECT_MODEL_TABLE+".aspect = ?", params.Aspect).
+		GroupBy("period, " + review.REVIEW_ASPECT_MODEL_TABLE + ".sentiment").
+		OrderBy("period ASC")
+
+	if params.PeriodStartAt != nil {
+		stmt.
+			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at >= ?", *params.PeriodStartAt)
+	}
+
+	if params.PeriodEndAt != nil {
+		stmt.
+			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
+	}
+
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &metric, nil
+		}
+
+		return nil, err
+	}
+
+	for _, res := range result {
+		if len(metric.Periods) == 0 || !metric.Periods[len(metric.Periods)-1].StartAt.Equal(res.Period) {
+			metric.Periods = append(metric.Periods, ReviewAspectTrendPeriod{StartAt: res.Period})
+		}
+
+		period := &metric.Periods[len(metric.Periods)-1]
+		switch res.Sentiment {
+		case review.ReviewSentimentPositive:
+			period.Positive += res.Count
+		case review.ReviewSentimentNeutral:
+			period.Neutral += res.Count
+		case review.ReviewSentimentNegative:
+			period.Negative += res.Count
+		}
+	}
+
+	return &metric, nil
+}
+
 func (self *MetricRepository) GetNetPromoterScore(ctx context.Context,
 	params NetPromoterScoreParams) (*NetPromoterScoreMetric, error) {
 	var result []struct {
@@ -914,6 +1392,12 @@ func (self *MetricRepository) GetNetPromoterScore(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -978,6 +1462,12 @@ func (self *MetricRepository) GetCustomerSatisfactionScore(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *params.PeriodEndAt)
 	}
 
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
 	err := self.database.Query(ctx, stmt)
 	if err != nil {
 		if kit.ErrDatabaseNoRows.Is(err) {
@@ -1024,3 +1514,128 @@ func (self *MetricRepository) GetCustomerSatisfactionScore(ctx context.Context,
 
 	return &metric, nil
 }
+
+// GetChurnRate averages the churn risks of the customers whose last feedback was posted within the period, as they
+// are the probability of every customer to churn.
+func (self *MetricRepository) GetChurnRate(ctx context.Context, params ChurnRateParams) (*ChurnRateMetric, error) {
+	var result struct {
+		Customers int     `db:"customers"`
+		Average   float64 `db:"average"`
+		AtRisk    int     `db:"at_risk"`
+	}
+	metric := ChurnRateMetric{}
+	metric.Rate = 0
+	metric.Customers = 0
+	metric.AtRisk = 0
+
+	stmt := sqlf.
+		Select("COUNT(*) AS customers").To(&result.Customers).
+		Select("COALESCE(AVG(score), 0) AS average").To(&result.Average).
+		Select("COUNT(*) FILTER (WHERE score >= ?) AS at_risk", churn.CHURN_AT_RISK_MIN_SCORE).To(&result.AtRisk).
+		From(churn.CHURN_RISK_MODEL_TABLE).
+		Where("product_id = ?", params.ProductID)
+
+	if params.PeriodStartAt != nil {
+		stmt.
+			Where("last_feedback_at >= ?", *params.PeriodStartAt)
+	}
+
+	if params.PeriodEndAt != nil {
+		stmt.
+			Where("last_feedback_at <= ?", *params.PeriodEndAt)
+	}
+
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery("customer_key IN (", ")",
+				churn.NewChurnCustomerKeysQuery(segment.NewSegmentFeedbacksQuery(*params.Segment)))
+	}
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	metric.Rate = math.Round(result.Average)
+	metric.Customers = result.Customers
+	metric.AtRisk = result.AtRisk
+
+	return &metric, nil
+}
+
+func (self *MetricRepository) GetPipelineLatency(ctx context.Context,
+	params PipelineLatencyParams) (*PipelineLatencyMetric, error) {
+	var result struct {
+		TranslationAverage float64 `db:"translation_average"`
+		TranslationP95     float64 `db:"translation_p95"`
+		ProcessingAverage  float64 `db:"processing_average"`
+		ProcessingP95      float64 `db:"processing_p95"`
+		AggregationAverage float64 `db:"aggregation_average"`
+		AggregationP95     float64 `db:"aggregation_p95"`
+		TotalAverage       float64 `db:"total_average"`
+		TotalP95           float64 `db:"total_p95"`
+		Aggregated         int     `db:"aggregated"`
+		WithinTarget       int     `db:"within_target"`
+		Pending            int     `db:"pending"`
+	}
+	metric := PipelineLatencyMetric{}
+
+	latency := func(from string, to string, name string) string {
+		seconds := "EXTRACT(EPOCH FROM " + to + " - " + from + ")"
+
+		return "COALESCE(AVG(" + seconds + "), 0) AS " + name + "_average, " +
+			"COALESCE(PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY " + seconds + "), 0) AS " + name + "_p95"
+	}
+
+	// Latencies are measured since the feedback was collected, not posted, as that is when the pipeline starts
+	stmt := sqlf.
+		Select(latency("collected_at", "translated_at", "translation")).
+		Select(latency("translated_at", "processed_at", "processing")).
+		Select(latency("processed_at", "aggregated_at", "aggregation")).
+		Select(latency("collected_at", "aggregated_at", "total")).
+		Select("COUNT(*) FILTER (WHERE aggregated_at IS NOT NULL) AS aggregated").
+		Select("COUNT(*) FILTER (WHERE EXTRACT(EPOCH FROM aggregated_at - collected_at) <= ?) AS within_target",
+			util.PIPELINE_LATENCY_TARGET.Seconds()).
+		Select("COUNT(*) FILTER (WHERE aggregated_at IS NULL AND skipped_at IS NULL) AS pending").
+		To(&result).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Where("product_id = ?", params.ProductID)
+
+	if params.PeriodStartAt != nil {
+		stmt.
+			Where("collected_at >= ?", *params.PeriodStartAt)
+	}
+
+	if params.PeriodEndAt != nil {
+		stmt.
+			Where("collected_at <= ?", *params.PeriodEndAt)
+	}
+
+	if params.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")", segment.NewSegmentFeedbacksQuery(*params.Segment))
+	}
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &metric, nil
+		}
+
+		return nil, err
+	}
+
+	metric.Translation = PipelineStageLatency{Average: result.TranslationAverage, P95: result.TranslationP95}
+	metric.Processing = PipelineStageLatency{Average: result.ProcessingAverage, P95: result.ProcessingP95}
+	metric.Aggregation = PipelineStageLatency{Average: result.AggregationAverage, P95: result.AggregationP95}
+	metric.Total = PipelineStageLatency{Average: result.TotalAverage, P95: result.TotalP95}
+	metric.Pending = result.Pending
+
+	if result.Aggregated > 0 {
+		metric.WithinTarget = math.Round((float64(result.WithinTarget) / float64(result.Aggregated)) * 100)
+	}
+
+	return &metric, nil
+}
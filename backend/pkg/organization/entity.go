@@ -13,8 +13,15 @@ const (
 	ORGANIZATION_PLAN_STARTER_MAX_PRODUCTS = 5
 	ORGANIZATION_PLAN_TRIAL_MAX_MEMBERS    = 3 + 1
 	ORGANIZATION_PLAN_TRIAL_PERIOD         = 14 * 24 * time.Hour
+	ORGANIZATION_USAGE_TOKENS_PER_FEEDBACK = 10000
 )
 
+// UsageFromTokens converts the tokens spent outside the feedback pipeline, like chatting, into usage, as if they
+// were collected feedbacks. Any started feedback counts as a whole one.
+func UsageFromTokens(tokens int) int {
+	return (max(0, tokens) + ORGANIZATION_USAGE_TOKENS_PER_FEEDBACK - 1) / ORGANIZATION_USAGE_TOKENS_PER_FEEDBACK
+}
+
 func IsSafeDomain(domain string) bool {
 	return emailproviders.IsWorkEmail("work@"+domain) && domain != "privaterelay.appleid.com"
 }
@@ -12,6 +12,7 @@ type OrganizationRepository interface {
 	GetByDomain(ctx context.Context, domain string) (*Organization, error)
 	ListIDsByNotDeleted(ctx context.Context) ([]string, error)
 	CountCollectedFeedbacksThisMonthPerProduct(ctx context.Context, id string) (map[string]int, error)
+	CountChatTokensThisMonthPerProduct(ctx context.Context, id string) (map[string]int, error)
 	UpdateProfile(ctx context.Context, organization Organization) error
 	UpdateSettings(ctx context.Context, organization Organization) error
 	UpdateDomain(ctx context.Context, id string, domain string) error
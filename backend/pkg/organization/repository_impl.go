@@ -174,6 +174,39 @@ func (self *OrganizationRepositoryImpl) CountCollectedFeedbacksThisMonthPerProdu
 	return count, nil
 }
 
+func (self *OrganizationRepositoryImpl) CountChatTokensThisMonthPerProduct(ctx context.Context, id string) (map[string]int, error) {
+	var result []struct {
+		ID     string `db:"id"`
+		Tokens int    `db:"tokens"`
+	}
+	tokens := make(map[string]int)
+
+	firstDayOfMonth := util.StartOfDay(util.StartOfMonth(time.Now()))
+
+	stmt := sqlf.
+		Select(`"product".id, COALESCE(SUM("chat_message".tokens), 0) AS tokens`).To(&result).
+		From(`"product"`).
+		Join(`"chat_message"`, `"chat_message".product_id = "product".id`).
+		Where(`"product".organization_id = ?`, id).
+		Where(`"chat_message".created_at >= ?`, firstDayOfMonth).
+		GroupBy(`"product".id`)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return tokens, nil
+		}
+
+		return nil, err
+	}
+
+	for _, res := range result {
+		tokens[res.ID] = res.Tokens
+	}
+
+	return tokens, nil
+}
+
 func (self *OrganizationRepositoryImpl) UpdateProfile(ctx context.Context, organization Organization) error {
 	o := NewOrganizationModel(organization)
 
@@ -57,6 +57,11 @@ func (m *OrganizationRepositoryMock) CountCollectedFeedbacksThisMonthPerProduct(
 	return args.Get(0).(map[string]int), args.Error(1)
 }
 
+func (m *OrganizationRepositoryMock) CountChatTokensThisMonthPerProduct(ctx context.Context, id string) (map[string]int, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
 func (m *OrganizationRepositoryMock) UpdateProfile(ctx context.Context, organization Organization) error {
 	args := m.Called(ctx, organization)
 	return args.Error(0)
@@ -65,6 +65,16 @@ func (self *OrganizationTasks) ComputeUsage(ctx context.Context, task *asynq.Tas
 		return err
 	}
 
+	tokensPerProduct, err := self.organizationRepository.CountChatTokensThisMonthPerProduct(ctx, params.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	// Chatting is charged to the same usage as the collected feedbacks
+	for productID, tokens := range tokensPerProduct {
+		usagePerProduct[productID] += UsageFromTokens(tokens)
+	}
+
 	usage := 0
 	for _, count := range usagePerProduct {
 		usage += count
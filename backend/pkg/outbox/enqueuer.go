@@ -0,0 +1,85 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+	kitUtil "github.com/neoxelox/kit/util"
+	"github.com/rs/xid"
+
+	"backend/pkg/config"
+)
+
+var (
+	ErrOutboxGeneric  = errors.New("outbox failed")
+	ErrOutboxTimedOut = errors.New("outbox timed out")
+)
+
+// OutboxEnqueuer is a drop-in replacement of the kit Enqueuer that stores the tasks in the outbox,
+// so they are only enqueued, by the OutboxRelay, when the surrounding database transaction commits.
+type OutboxEnqueuer struct {
+	config               config.Config
+	observer             *kit.Observer
+	outboxTaskRepository *OutboxTaskRepository
+}
+
+func NewOutboxEnqueuer(observer *kit.Observer, outboxTaskRepository *OutboxTaskRepository,
+	config config.Config) *OutboxEnqueuer {
+	return &OutboxEnqueuer{
+		config:               config,
+		observer:             observer,
+		outboxTaskRepository: outboxTaskRepository,
+	}
+}
+
+func (self *OutboxEnqueuer) Enqueue(ctx context.Context, task string, params any, options ...asynq.Option) error {
+	if params == nil {
+		params = map[string]any{}
+	}
+
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return ErrOutboxGeneric.Raise().Cause(err)
+	}
+
+	now := time.Now()
+
+	outboxTask := NewOutboxTask()
+	outboxTask.ID = xid.New().String()
+	outboxTask.Task = task
+	outboxTask.Params = payload
+	outboxTask.TraceID = self.observer.GetTrace(ctx)
+	outboxTask.Attempts = 0
+	outboxTask.LastError = nil
+	outboxTask.CreatedAt = now
+	outboxTask.RelayAt = now
+
+	for _, option := range options {
+		switch option.Type() {
+		case asynq.MaxRetryOpt:
+			outboxTask.MaxRetry = kitUtil.Pointer(option.Value().(int))
+		case asynq.QueueOpt:
+			outboxTask.Queue = kitUtil.Pointer(option.Value().(string))
+		case asynq.UniqueOpt:
+			outboxTask.UniqueFor = kitUtil.Pointer(option.Value().(time.Duration))
+		case asynq.ProcessAtOpt:
+			outboxTask.ProcessAt = kitUtil.Pointer(option.Value().(time.Time))
+		case asynq.ProcessInOpt:
+			// The task may be relayed a while after, so anchor the delay to now
+			outboxTask.ProcessAt = kitUtil.Pointer(now.Add(option.Value().(time.Duration)))
+		default:
+			return ErrOutboxGeneric.Raise().With("unsupported option %s", option.String())
+		}
+	}
+
+	_, err = self.outboxTaskRepository.Create(ctx, *outboxTask)
+	if err != nil {
+		return ErrOutboxGeneric.Raise().Cause(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package outbox
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/kit/util"
+)
+
+type OutboxTask struct {
+	ID   string
+	Task string
+	// Payload exactly as marshaled when enqueued, as asynq deduplicates unique tasks by their payload
+	Params    []byte
+	Queue     *string
+	MaxRetry  *int
+	UniqueFor *time.Duration
+	ProcessAt *time.Time
+	TraceID   string
+	Attempts  int
+	LastError *string
+	CreatedAt time.Time
+	RelayAt   time.Time
+}
+
+func NewOutboxTask() *OutboxTask {
+	return &OutboxTask{}
+}
+
+func (self OutboxTask) String() string {
+	return fmt.Sprintf("<OutboxTask: %s (%s)>", self.Task, self.ID)
+}
+
+func (self OutboxTask) Equals(other OutboxTask) bool {
+	return util.Equals(self, other)
+}
+
+func (self OutboxTask) Copy() *OutboxTask {
+	return util.Copy(self)
+}
+
+func (self OutboxTask) Options() []asynq.Option {
+	options := []asynq.Option{}
+
+	if self.MaxRetry != nil {
+		options = append(options, asynq.MaxRetry(*self.MaxRetry))
+	}
+
+	if self.Queue != nil {
+		options = append(options, asynq.Queue(*self.Queue))
+	}
+
+	if self.UniqueFor != nil {
+		options = append(options, asynq.Unique(*self.UniqueFor))
+	}
+
+	if self.ProcessAt != nil {
+		options = append(options, asynq.ProcessAt(*self.ProcessAt))
+	}
+
+	return options
+}
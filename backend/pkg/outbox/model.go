@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/neoxelox/kit/util"
+)
+
+const (
+	OUTBOX_TASK_MODEL_TABLE = "\"outbox\""
+)
+
+type OutboxTaskModel struct {
+	ID        string     `db:"id"`
+	Task      string     `db:"task"`
+	Params    []byte     `db:"params"`
+	Queue     *string    `db:"queue"`
+	MaxRetry  *int       `db:"max_retry"`
+	UniqueFor *int       `db:"unique_for"`
+	ProcessAt *time.Time `db:"process_at"`
+	TraceID   string     `db:"trace_id"`
+	Attempts  int        `db:"attempts"`
+	LastError *string    `db:"last_error"`
+	CreatedAt time.Time  `db:"created_at"`
+	RelayAt   time.Time  `db:"relay_at"`
+}
+
+func NewOutboxTaskModel(task OutboxTask) *OutboxTaskModel {
+	var uniqueFor *int
+	if task.UniqueFor != nil {
+		uniqueFor = util.Pointer(int(task.UniqueFor.Seconds()))
+	}
+
+	return &OutboxTaskModel{
+		ID:        task.ID,
+		Task:      task.Task,
+		Params:    task.Params,
+		Queue:     task.Queue,
+		MaxRetry:  task.MaxRetry,
+		UniqueFor: uniqueFor,
+		ProcessAt: task.ProcessAt,
+		TraceID:   task.TraceID,
+		Attempts:  task.Attempts,
+		LastError: task.LastError,
+		CreatedAt: task.CreatedAt,
+		RelayAt:   task.RelayAt,
+	}
+}
+
+func (self *OutboxTaskModel) ToEntity() *OutboxTask {
+	var uniqueFor *time.Duration
+	if self.UniqueFor != nil {
+		uniqueFor = util.Pointer(time.Duration(*self.UniqueFor) * time.Second)
+	}
+
+	return &OutboxTask{
+		ID:        self.ID,
+		Task:      self.Task,
+		Params:    self.Params,
+		Queue:     self.Queue,
+		MaxRetry:  self.MaxRetry,
+		UniqueFor: uniqueFor,
+		ProcessAt: self.ProcessAt,
+		TraceID:   self.TraceID,
+		Attempts:  self.Attempts,
+		LastError: self.LastError,
+		CreatedAt: self.CreatedAt,
+		RelayAt:   self.RelayAt,
+	}
+}
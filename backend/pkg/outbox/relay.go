@@ -0,0 +1,158 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+	kitUtil "github.com/neoxelox/kit/util"
+
+	"backend/pkg/config"
+)
+
+const (
+	OUTBOX_RELAY_INTERVAL      = 1 * time.Second
+	OUTBOX_RELAY_BATCH_SIZE    = 100
+	OUTBOX_RELAY_INITIAL_DELAY = 1 * time.Second
+	OUTBOX_RELAY_LIMIT_DELAY   = 5 * time.Minute
+)
+
+// OutboxRelay publishes the outbox tasks with at-least-once semantics: a task is only
+// deleted once it has been enqueued, so it can be enqueued more than once if the deletion fails.
+type OutboxRelay struct {
+	config               config.Config
+	observer             *kit.Observer
+	database             *kit.Database
+	outboxTaskRepository *OutboxTaskRepository
+	enqueuer             *kit.Enqueuer
+	stop                 chan struct{}
+	done                 chan struct{}
+}
+
+func NewOutboxRelay(observer *kit.Observer, database *kit.Database, outboxTaskRepository *OutboxTaskRepository,
+	enqueuer *kit.Enqueuer, config config.Config) *OutboxRelay {
+	return &OutboxRelay{
+		config:               config,
+		observer:             observer,
+		database:             database,
+		outboxTaskRepository: outboxTaskRepository,
+		enqueuer:             enqueuer,
+		stop:                 make(chan struct{}),
+		done:                 make(chan struct{}),
+	}
+}
+
+func (self *OutboxRelay) Run(ctx context.Context) error {
+	defer close(self.done)
+
+	ticker := time.NewTicker(OUTBOX_RELAY_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-self.stop:
+			return nil
+		case <-ticker.C:
+			err := self.Relay(ctx)
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+	}
+}
+
+// Relay publishes all the pending outbox tasks in batches.
+func (self *OutboxRelay) Relay(ctx context.Context) error {
+	for {
+		var pending int
+		var relayed int
+
+		err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+			tasks, err := self.outboxTaskRepository.ListByRelayAtForUpdate(ctx, time.Now(), OUTBOX_RELAY_BATCH_SIZE)
+			if err != nil {
+				return err
+			}
+
+			pending = len(tasks)
+
+			for _, task := range tasks {
+				taskCtx := self.observer.SetTrace(ctx, task.TraceID)
+
+				err := self.enqueuer.Enqueue(taskCtx, task.Task, json.RawMessage(task.Params), task.Options()...)
+				// Unique tasks that are already enqueued are considered relayed
+				if err != nil && !self.isDuplicate(err) {
+					self.observer.Error(taskCtx, err)
+
+					task.Attempts++
+					task.LastError = kitUtil.Pointer(err.Error())
+					task.RelayAt = time.Now().Add(self.backoff(task.Attempts))
+
+					err = self.outboxTaskRepository.UpdateFailed(ctx, task)
+					if err != nil {
+						return err
+					}
+
+					continue
+				}
+
+				err = self.outboxTaskRepository.Delete(ctx, task.ID)
+				if err != nil {
+					return err
+				}
+
+				relayed++
+			}
+
+			return nil
+		})
+		if err != nil {
+			return ErrOutboxGeneric.Raise().Cause(err)
+		}
+
+		if relayed > 0 {
+			self.observer.Infof(ctx, "Relayed %d outbox tasks", relayed)
+		}
+
+		if pending < OUTBOX_RELAY_BATCH_SIZE {
+			return nil
+		}
+	}
+}
+
+func (self *OutboxRelay) Close(ctx context.Context) error {
+	err := kitUtil.Deadline(ctx, func(exceeded <-chan struct{}) error {
+		self.observer.Info(ctx, "Closing outbox relay")
+
+		close(self.stop)
+
+		select {
+		case <-self.done:
+		case <-exceeded:
+		}
+
+		self.observer.Info(ctx, "Closed outbox relay")
+
+		return nil
+	})
+	if err != nil {
+		if kitUtil.ErrDeadlineExceeded.Is(err) {
+			return ErrOutboxTimedOut.Raise().Cause(err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (self *OutboxRelay) isDuplicate(err error) bool {
+	_err, ok := err.(*errors.Error)
+	return ok && _err.Has(asynq.ErrDuplicateTask)
+}
+
+func (self *OutboxRelay) backoff(attempts int) time.Duration {
+	return min(OUTBOX_RELAY_INITIAL_DELAY*time.Duration(1<<min(attempts, 16)), OUTBOX_RELAY_LIMIT_DELAY)
+}
@@ -0,0 +1,122 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+)
+
+type OutboxTaskRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewOutboxTaskRepository(observer *kit.Observer, database *kit.Database,
+	config config.Config) *OutboxTaskRepository {
+	return &OutboxTaskRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *OutboxTaskRepository) Create(ctx context.Context, task OutboxTask) (*OutboxTask, error) {
+	t := NewOutboxTaskModel(task)
+
+	stmt := sqlf.
+		InsertInto(OUTBOX_TASK_MODEL_TABLE).
+		Set("id", t.ID).
+		Set("task", t.Task).
+		Set("params", t.Params).
+		Set("queue", t.Queue).
+		Set("max_retry", t.MaxRetry).
+		Set("unique_for", t.UniqueFor).
+		Set("process_at", t.ProcessAt).
+		Set("trace_id", t.TraceID).
+		Set("attempts", t.Attempts).
+		Set("last_error", t.LastError).
+		Set("created_at", t.CreatedAt).
+		Set("relay_at", t.RelayAt).
+		Returning("*").To(&t)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.ToEntity(), nil
+}
+
+// ListByRelayAtForUpdate locks the tasks so concurrent relays skip them, thus it must be called within a transaction.
+func (self *OutboxTaskRepository) ListByRelayAtForUpdate(ctx context.Context,
+	before time.Time, limit int) ([]OutboxTask, error) {
+	var ts []OutboxTaskModel
+
+	stmt := sqlf.
+		Select("*").To(&ts).
+		From(OUTBOX_TASK_MODEL_TABLE).
+		Where("relay_at <= ?", before).
+		OrderBy("relay_at ASC", "id ASC").
+		Limit(limit).
+		Clause("FOR UPDATE SKIP LOCKED")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []OutboxTask{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]OutboxTask, 0, len(ts))
+	for _, t := range ts {
+		entities = append(entities, *t.ToEntity())
+	}
+
+	return entities, nil
+}
+
+func (self *OutboxTaskRepository) UpdateFailed(ctx context.Context, task OutboxTask) error {
+	t := NewOutboxTaskModel(task)
+
+	stmt := sqlf.
+		Update(OUTBOX_TASK_MODEL_TABLE).
+		Set("attempts", t.Attempts).
+		Set("last_error", t.LastError).
+		Set("relay_at", t.RelayAt).
+		Where("id = ?", t.ID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+func (self *OutboxTaskRepository) Delete(ctx context.Context, id string) error {
+	stmt := sqlf.
+		DeleteFrom(OUTBOX_TASK_MODEL_TABLE).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
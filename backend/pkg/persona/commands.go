@@ -0,0 +1,64 @@
+package persona
+
+import (
+	"context"
+
+	"github.com/mkideal/cli"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+)
+
+const (
+	PersonaCommandsSynthesizePersonas = "synthesize-personas"
+)
+
+type PersonaCommands struct {
+	config            config.Config
+	observer          *kit.Observer
+	productRepository *product.ProductRepository
+	synthesizer       *Synthesizer
+}
+
+func NewPersonaCommands(observer *kit.Observer, productRepository *product.ProductRepository,
+	synthesizer *Synthesizer, config config.Config) *PersonaCommands {
+	return &PersonaCommands{
+		config:            config,
+		observer:          observer,
+		productRepository: productRepository,
+		synthesizer:       synthesizer,
+	}
+}
+
+type PersonaCommandsSynthesizePersonasArgs struct {
+	cli.Helper
+	Product string `cli:"*product" usage:"id of the product to synthesize the personas of"`
+}
+
+func (self *PersonaCommands) SynthesizePersonas(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*PersonaCommandsSynthesizePersonasArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	product, err := self.productRepository.GetByID(ctx, args.Product)
+	if err != nil {
+		return err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return kit.ErrRunnerGeneric.Raise().With("product %s not found", args.Product)
+	}
+
+	personas, err := self.synthesizer.Run(ctx, *product)
+	if err != nil {
+		return err
+	}
+
+	for _, persona := range personas {
+		self.observer.Infof(ctx, "Synthesized persona %s representing %d customers", persona.Name, persona.Customers)
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package persona
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+	"github.com/rs/xid"
+
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+type PersonaEndpoints struct {
+	config            config.Config
+	observer          *kit.Observer
+	personaRepository *PersonaRepository
+	synthesizer       *Synthesizer
+}
+
+func NewPersonaEndpoints(observer *kit.Observer, personaRepository *PersonaRepository, synthesizer *Synthesizer,
+	config config.Config) *PersonaEndpoints {
+	return &PersonaEndpoints{
+		config:            config,
+		observer:          observer,
+		personaRepository: personaRepository,
+		synthesizer:       synthesizer,
+	}
+}
+
+type PersonaEndpointsPersonaRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Goals       []string `json:"goals"`
+	Pains       []string `json:"pains"`
+	Quotes      []string `json:"quotes"`
+}
+
+type PersonaEndpointsListPersonasResponse struct {
+	Personas []PersonaPayload `json:"personas"`
+}
+
+func (self *PersonaEndpoints) ListPersonas(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+
+	personas, err := self.personaRepository.ListByProductID(requestCtx, requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := PersonaEndpointsListPersonasResponse{}
+	response.Personas = make([]PersonaPayload, 0, len(personas))
+	for _, persona := range personas {
+		response.Personas = append(response.Personas, *NewPersonaPayload(persona))
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type PersonaEndpointsPostPersonaRequest struct {
+	PersonaEndpointsPersonaRequest
+}
+
+type PersonaEndpointsPostPersonaResponse struct {
+	PersonaPayload
+}
+
+func (self *PersonaEndpoints) PostPersona(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := PersonaEndpointsPostPersonaRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	// Personas written by hand do not represent any clustered customer, so they are never replaced
+	persona := NewPersona()
+	persona.ID = xid.New().String()
+	persona.ProductID = requestProduct.ID
+	persona.Traits = PersonaTraits{
+		Intentions: []string{},
+		Emotions:   []string{},
+		Categories: []string{},
+		Keywords:   []string{},
+	}
+	persona.Customers = 0
+	persona.Share = 0
+	persona.Generated = false
+	persona.CreatedAt = time.Now()
+	persona.UpdatedAt = persona.CreatedAt
+
+	err = persona.SetProfile(request.Name, request.Description, request.Goals, request.Pains, request.Quotes)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	persona, err = self.personaRepository.Create(requestCtx, *persona, []string{})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := PersonaEndpointsPostPersonaResponse{}
+	response.PersonaPayload = *NewPersonaPayload(*persona)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+func (self *PersonaEndpoints) PostPersonasRefresh(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+
+	err := self.synthesizer.Refresh(requestCtx, requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, struct{}{})
+}
+
+type PersonaEndpointsGetPersonaResponse struct {
+	PersonaPayload
+}
+
+func (self *PersonaEndpoints) GetPersona(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestPersona := RequestPersona(requestCtx)
+
+	response := PersonaEndpointsGetPersonaResponse{}
+	response.PersonaPayload = *NewPersonaPayload(*requestPersona)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type PersonaEndpointsPutPersonaRequest struct {
+	PersonaEndpointsPersonaRequest
+}
+
+type PersonaEndpointsPutPersonaResponse struct {
+	PersonaPayload
+}
+
+func (self *PersonaEndpoints) PutPersona(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestPersona := RequestPersona(requestCtx)
+	request := PersonaEndpointsPutPersonaRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	err = requestPersona.SetProfile(request.Name, request.Description, request.Goals, request.Pains, request.Quotes)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	// Edited personas are kept on the next synthesis, as they are no longer the engine's
+	requestPersona.Generated = false
+	requestPersona.UpdatedAt = time.Now()
+
+	err = self.personaRepository.UpdateProfile(requestCtx, *requestPersona)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := PersonaEndpointsPutPersonaResponse{}
+	response.PersonaPayload = *NewPersonaPayload(*requestPersona)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+func (self *PersonaEndpoints) DeletePersona(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestPersona := RequestPersona(requestCtx)
+
+	err := self.personaRepository.Delete(requestCtx, requestPersona.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, struct{}{})
+}
+
+type PersonaEndpointsListPersonaFeedbacksRequest struct {
+	From *string `query:"from"`
+}
+
+type PersonaEndpointsListPersonaFeedbacksResponse struct {
+	Feedbacks []feedback.FeedbackPayload `json:"feedbacks"`
+	Next      *string                    `json:"next"`
+}
+
+func (self *PersonaEndpoints) ListPersonaFeedbacks(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestPersona := RequestPersona(requestCtx)
+	request := PersonaEndpointsListPersonaFeedbacksRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	page, err := self.personaRepository.ListFeedbacks(requestCtx, requestPersona.ID, util.Pagination[time.Time]{
+		Limit: 100,
+		From:  util.CursorFromString[time.Time](request.From),
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := PersonaEndpointsListPersonaFeedbacksResponse{}
+	response.Feedbacks = make([]feedback.FeedbackPayload, 0, len(page.Items))
+	for _, _feedback := range page.Items {
+		response.Feedbacks = append(response.Feedbacks, *feedback.NewFeedbackPayload(_feedback))
+	}
+	response.Next = nil
+	next := util.CursorToString(page.Next)
+	if next != nil {
+		*next = url.QueryEscape(*next)
+	}
+	response.Next = next
+
+	return ctx.JSON(http.StatusOK, &response)
+}
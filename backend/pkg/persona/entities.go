@@ -0,0 +1,421 @@
+package persona
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/neoxelox/errors"
+	kitUtil "github.com/neoxelox/kit/util"
+
+	"backend/pkg/engine"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+const (
+	PERSONA_MAX_NAME_LENGTH        = 50
+	PERSONA_MAX_DESCRIPTION_LENGTH = 500
+	PERSONA_MAX_ITEMS              = 10
+	PERSONA_MAX_ITEM_LENGTH        = 500
+)
+
+const (
+	// Reviewed customers clustered at most on every synthesis run, most recent first
+	PERSONA_SYNTHESIS_MAX_CUSTOMERS = 1000
+	// Minimum customers of a cluster for it to be worth a persona
+	PERSONA_SYNTHESIS_MIN_CUSTOMERS = 5
+	PERSONA_SYNTHESIS_MAX_PERSONAS  = 5
+	// Most frequent keywords used as features, the rest are too sparse to group customers by
+	PERSONA_SYNTHESIS_MAX_KEYWORDS   = 50
+	PERSONA_SYNTHESIS_MAX_ITERATIONS = 20
+	// Minimum share of the customers of a cluster with a feature for it to be one of its traits
+	PERSONA_SYNTHESIS_TRAIT_SHARE = 0.3
+	PERSONA_SYNTHESIS_MAX_TRAITS  = 3
+	// Feedbacks closest to the centroid of a cluster the engine synthesizes the persona from
+	PERSONA_SYNTHESIS_SAMPLE_CUSTOMERS = 10
+)
+
+var (
+	ErrPersonaInvalid = errors.New("persona is invalid")
+)
+
+type PersonaTraits struct {
+	Intentions []string
+	Emotions   []string
+	Categories []string
+	Keywords   []string
+}
+
+type Persona struct {
+	ID          string
+	ProductID   string
+	Name        string
+	Description string
+	Goals       []string
+	Pains       []string
+	Quotes      []string
+	Traits      PersonaTraits
+	Customers   int
+	Share       float64
+	Generated   bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func NewPersona() *Persona {
+	return &Persona{}
+}
+
+func (self Persona) String() string {
+	return fmt.Sprintf("<Persona: %s (%s)>", self.Name, self.ID)
+}
+
+func (self Persona) Equals(other Persona) bool {
+	return kitUtil.Equals(self, other)
+}
+
+func (self Persona) Copy() *Persona {
+	return kitUtil.Copy(self)
+}
+
+func newItems(items []string) ([]string, error) {
+	if len(items) > PERSONA_MAX_ITEMS {
+		return nil, ErrPersonaInvalid.Raise().With("too many items")
+	}
+
+	normalized := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 || len(item) > PERSONA_MAX_ITEM_LENGTH {
+			return nil, ErrPersonaInvalid.Raise().With("item %s is invalid", item)
+		}
+
+		normalized = append(normalized, item)
+	}
+
+	return util.Unique(normalized), nil
+}
+
+// SetProfile normalizes the profile of a persona, written by hand or synthesized by the engine, and validates it.
+func (self *Persona) SetProfile(name string, description string, goals []string, pains []string,
+	quotes []string) error {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 || len(name) > PERSONA_MAX_NAME_LENGTH {
+		return ErrPersonaInvalid.Raise().With("name %s is invalid", name)
+	}
+
+	description = strings.TrimSpace(description)
+	if len(description) > PERSONA_MAX_DESCRIPTION_LENGTH {
+		return ErrPersonaInvalid.Raise().With("description is too long")
+	}
+
+	goals, err := newItems(goals)
+	if err != nil {
+		return err
+	}
+
+	pains, err = newItems(pains)
+	if err != nil {
+		return err
+	}
+
+	quotes, err = newItems(quotes)
+	if err != nil {
+		return err
+	}
+
+	self.Name = name
+	self.Description = description
+	self.Goals = goals
+	self.Pains = pains
+	self.Quotes = quotes
+
+	return nil
+}
+
+// PersonaCustomer is a reviewed customer, which for now is the author of a single feedback.
+type PersonaCustomer struct {
+	FeedbackID  string
+	Content     string
+	Translation string
+	Language    string
+	Intention   string
+	Emotions    []string
+	Category    string
+	Keywords    []string
+}
+
+type PersonaCluster struct {
+	// Closest to the centroid first
+	Customers []PersonaCustomer
+	Traits    PersonaTraits
+}
+
+const (
+	personaFeatureIntention = "INTENTION"
+	personaFeatureEmotion   = "EMOTION"
+	personaFeatureCategory  = "CATEGORY"
+	personaFeatureKeyword   = "KEYWORD"
+)
+
+type personaFeature struct {
+	Kind  string
+	Value string
+}
+
+func newPersonaFeatures(customer PersonaCustomer) []personaFeature {
+	features := []personaFeature{}
+
+	if customer.Intention != engine.OPTION_UNKNOWN {
+		features = append(features, personaFeature{Kind: personaFeatureIntention, Value: customer.Intention})
+	}
+
+	for _, emotion := range customer.Emotions {
+		features = append(features, personaFeature{Kind: personaFeatureEmotion, Value: emotion})
+	}
+
+	// Subcategories are too specific to group customers by
+	category, _, _ := strings.Cut(customer.Category, product.PRODUCT_CATEGORY_SEPARATOR)
+	if category != engine.OPTION_UNKNOWN {
+		features = append(features, personaFeature{Kind: personaFeatureCategory, Value: category})
+	}
+
+	for _, keyword := range customer.Keywords {
+		features = append(features, personaFeature{Kind: personaFeatureKeyword, Value: strings.ToLower(keyword)})
+	}
+
+	return features
+}
+
+// NewClusters groups the customers by their intention, emotions, categories and keywords. Every customer is a
+// multi-hot vector of its features, clustered with k-means seeded deterministically by the farthest customers, the
+// most recent one first. Only the clusters big enough are returned, biggest first.
+func NewClusters(customers []PersonaCustomer) []PersonaCluster {
+	features := make([][]personaFeature, 0, len(customers))
+	frequencies := map[string]int{}
+	for _, customer := range customers {
+		customerFeatures := newPersonaFeatures(customer)
+		features = append(features, customerFeatures)
+
+		for _, feature := range util.Unique(customerFeatures) {
+			if feature.Kind == personaFeatureKeyword {
+				frequencies[feature.Value]++
+			}
+		}
+	}
+
+	keywords := make([]string, 0, len(frequencies))
+	for keyword := range frequencies {
+		keywords = append(keywords, keyword)
+	}
+	slices.SortFunc(keywords, func(a, b string) int {
+		if frequencies[a] != frequencies[b] {
+			return frequencies[b] - frequencies[a]
+		}
+		return strings.Compare(a, b)
+	})
+	if len(keywords) > PERSONA_SYNTHESIS_MAX_KEYWORDS {
+		keywords = keywords[:PERSONA_SYNTHESIS_MAX_KEYWORDS]
+	}
+
+	dimensions := map[personaFeature]int{}
+	vocabulary := []personaFeature{}
+	for _, keyword := range keywords {
+		feature := personaFeature{Kind: personaFeatureKeyword, Value: keyword}
+		dimensions[feature] = len(vocabulary)
+		vocabulary = append(vocabulary, feature)
+	}
+	for _, customerFeatures := range features {
+		for _, feature := range customerFeatures {
+			if _, ok := dimensions[feature]; !ok && feature.Kind != personaFeatureKeyword {
+				dimensions[feature] = len(vocabulary)
+				vocabulary = append(vocabulary, feature)
+			}
+		}
+	}
+
+	// Customers without any feature have nothing in common with the rest
+	members := []PersonaCustomer{}
+	vectors := [][]float32{}
+	for i, customerFeatures := range features {
+		vector := make([]float32, len(vocabulary))
+		empty := true
+		for _, feature := range customerFeatures {
+			if dimension, ok := dimensions[feature]; ok {
+				vector[dimension] = 1
+				empty = false
+			}
+		}
+
+		if !empty {
+			members = append(members, customers[i])
+			vectors = append(vectors, vector)
+		}
+	}
+
+	k := min(PERSONA_SYNTHESIS_MAX_PERSONAS, len(vectors)/PERSONA_SYNTHESIS_MIN_CUSTOMERS)
+	if k == 0 {
+		return []PersonaCluster{}
+	}
+
+	centroids := [][]float32{vectors[0]}
+	for len(centroids) < k {
+		farthest := -1
+		farthestSimilarity := 2.0
+		for i, vector := range vectors {
+			similarity := -1.0
+			for _, centroid := range centroids {
+				similarity = max(similarity, util.CosineSimilarity(vector, centroid))
+			}
+
+			if similarity < farthestSimilarity {
+				farthest = i
+				farthestSimilarity = similarity
+			}
+		}
+
+		centroids = append(centroids, vectors[farthest])
+	}
+
+	assignments := make([]int, len(vectors))
+	for iteration := 0; iteration < PERSONA_SYNTHESIS_MAX_ITERATIONS; iteration++ {
+		changed := false
+		for i, vector := range vectors {
+			closest := 0
+			closestSimilarity := -1.0
+			for j, centroid := range centroids {
+				similarity := util.CosineSimilarity(vector, centroid)
+				if similarity > closestSimilarity {
+					closest = j
+					closestSimilarity = similarity
+				}
+			}
+
+			if iteration == 0 || assignments[i] != closest {
+				assignments[i] = closest
+				changed = true
+			}
+		}
+
+		if !changed {
+			break
+		}
+
+		sizes := make([]int, k)
+		sums := make([][]float32, k)
+		for j := range sums {
+			sums[j] = make([]float32, len(vocabulary))
+		}
+		for i, vector := range vectors {
+			sizes[assignments[i]]++
+			for d, value := range vector {
+				sums[assignments[i]][d] += value
+			}
+		}
+
+		// Empty clusters keep their previous centroid
+		for j := range centroids {
+			if sizes[j] == 0 {
+				continue
+			}
+
+			for d := range sums[j] {
+				sums[j][d] /= float32(sizes[j])
+			}
+			centroids[j] = sums[j]
+		}
+	}
+
+	clusters := []PersonaCluster{}
+	for j, centroid := range centroids {
+		indexes := []int{}
+		for i := range vectors {
+			if assignments[i] == j {
+				indexes = append(indexes, i)
+			}
+		}
+
+		if len(indexes) < PERSONA_SYNTHESIS_MIN_CUSTOMERS {
+			continue
+		}
+
+		slices.SortStableFunc(indexes, func(a, b int) int {
+			similarityA := util.CosineSimilarity(vectors[a], centroid)
+			similarityB := util.CosineSimilarity(vectors[b], centroid)
+			switch {
+			case similarityA > similarityB:
+				return -1
+			case similarityA < similarityB:
+				return 1
+			default:
+				return 0
+			}
+		})
+
+		cluster := PersonaCluster{
+			Customers: make([]PersonaCustomer, 0, len(indexes)),
+			Traits:    newPersonaTraits(vocabulary, centroid),
+		}
+		for _, i := range indexes {
+			cluster.Customers = append(cluster.Customers, members[i])
+		}
+
+		clusters = append(clusters, cluster)
+	}
+
+	slices.SortStableFunc(clusters, func(a, b PersonaCluster) int {
+		return len(b.Customers) - len(a.Customers)
+	})
+
+	return clusters
+}
+
+// newPersonaTraits returns the features most of the customers of a cluster share, as the centroid holds the share
+// of its customers with every feature.
+func newPersonaTraits(vocabulary []personaFeature, centroid []float32) PersonaTraits {
+	dimensions := make([]int, 0, len(vocabulary))
+	for d := range vocabulary {
+		if centroid[d] >= PERSONA_SYNTHESIS_TRAIT_SHARE {
+			dimensions = append(dimensions, d)
+		}
+	}
+
+	slices.SortStableFunc(dimensions, func(a, b int) int {
+		switch {
+		case centroid[a] > centroid[b]:
+			return -1
+		case centroid[a] < centroid[b]:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	traits := PersonaTraits{
+		Intentions: []string{},
+		Emotions:   []string{},
+		Categories: []string{},
+		Keywords:   []string{},
+	}
+	for _, d := range dimensions {
+		var values *[]string
+		switch vocabulary[d].Kind {
+		case personaFeatureIntention:
+			values = &traits.Intentions
+		case personaFeatureEmotion:
+			values = &traits.Emotions
+		case personaFeatureCategory:
+			values = &traits.Categories
+		case personaFeatureKeyword:
+			values = &traits.Keywords
+		}
+
+		if len(*values) < PERSONA_SYNTHESIS_MAX_TRAITS {
+			*values = append(*values, vocabulary[d].Value)
+		}
+	}
+
+	return traits
+}
@@ -0,0 +1,103 @@
+package persona_test
+
+import (
+	"fmt"
+	"testing"
+
+	"backend/pkg/persona"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PersonaTestSuite struct {
+	suite.Suite
+}
+
+func TestPersonaSuite(t *testing.T) {
+	suite.Run(t, new(PersonaTestSuite))
+}
+
+func (self *PersonaTestSuite) newCustomers(prefix string, n int, intention string, emotions []string,
+	category string, keywords []string) []persona.PersonaCustomer {
+	customers := []persona.PersonaCustomer{}
+	for i := 0; i < n; i++ {
+		customers = append(customers, persona.PersonaCustomer{
+			FeedbackID: fmt.Sprintf("%s%d", prefix, i),
+			Intention:  intention,
+			Emotions:   emotions,
+			Category:   category,
+			Keywords:   keywords,
+		})
+	}
+
+	return customers
+}
+
+func (self *PersonaTestSuite) TestNewClustersGroupsSimilarCustomers() {
+	// Given: Frustrated customers about billing and delighted customers about the interface, interleaved
+	billing := self.newCustomers("billing", 6, "RETURN", []string{"FRUSTRATION"}, "BILLING/REFUNDS",
+		[]string{"Refund", "charge"})
+	design := self.newCustomers("interface", 8, "RECOMMEND", []string{"JOY"}, "USER_INTERFACE",
+		[]string{"design"})
+	customers := []persona.PersonaCustomer{}
+	for i := 0; i < 8; i++ {
+		if i < len(billing) {
+			customers = append(customers, billing[i])
+		}
+		customers = append(customers, design[i])
+	}
+
+	// When: The customers are clustered
+	clusters := persona.NewClusters(customers)
+
+	// Then: Every group is a cluster, the biggest first, with its traits
+	self.Require().Len(clusters, 2)
+	self.Len(clusters[0].Customers, 8)
+	self.Equal([]string{"RECOMMEND"}, clusters[0].Traits.Intentions)
+	self.Equal([]string{"USER_INTERFACE"}, clusters[0].Traits.Categories)
+	self.Len(clusters[1].Customers, 6)
+	self.Equal([]string{"FRUSTRATION"}, clusters[1].Traits.Emotions)
+	self.Equal([]string{"BILLING"}, clusters[1].Traits.Categories)
+	self.ElementsMatch([]string{"refund", "charge"}, clusters[1].Traits.Keywords)
+}
+
+func (self *PersonaTestSuite) TestNewClustersSkipsSmallClusters() {
+	// Given: Too few customers and customers without anything in common
+	inputs := [][]persona.PersonaCustomer{
+		self.newCustomers("few", 4, "BUY", []string{}, "PRICING", []string{}),
+		self.newCustomers("none", 10, "UNKNOWN", []string{}, "UNKNOWN", []string{}),
+	}
+
+	for _, input := range inputs {
+		// When: The customers are clustered
+		clusters := persona.NewClusters(input)
+
+		// Then: There is no cluster big enough for a persona
+		self.Empty(clusters)
+	}
+}
+
+func (self *PersonaTestSuite) TestSetProfileNormalizes() {
+	// Given: A profile written by hand
+	entity := persona.NewPersona()
+
+	// When: The profile is set
+	err := entity.SetProfile(" Budget owner ", " Pays the bills ", []string{" Save money", "Save money "},
+		[]string{"Hidden fees"}, []string{})
+
+	// Then: The profile is trimmed and deduplicated
+	self.Require().NoError(err)
+	self.Equal("Budget owner", entity.Name)
+	self.Equal("Pays the bills", entity.Description)
+	self.Equal([]string{"Save money"}, entity.Goals)
+}
+
+func (self *PersonaTestSuite) TestSetProfileRejectsInvalid() {
+	// Given: Profiles without a name and with empty items
+	entity := persona.NewPersona()
+
+	// When: The profiles are set
+	// Then: The profiles are invalid
+	self.True(persona.ErrPersonaInvalid.Is(entity.SetProfile(" ", "", []string{}, []string{}, []string{})))
+	self.True(persona.ErrPersonaInvalid.Is(entity.SetProfile("Admin", "", []string{""}, []string{}, []string{})))
+}
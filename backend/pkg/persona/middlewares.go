@@ -0,0 +1,58 @@
+package persona
+
+import (
+	"context"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+)
+
+var (
+	KeyRequestPersona kit.Key = kit.KeyBase + "request:persona"
+)
+
+func RequestPersona(ctx context.Context) *Persona {
+	return ctx.Value(KeyRequestPersona).(*Persona) // nolint:forcetypeassert,errcheck
+}
+
+type PersonaMiddlewares struct {
+	config            config.Config
+	observer          *kit.Observer
+	personaRepository *PersonaRepository
+}
+
+func NewPersonaMiddlewares(observer *kit.Observer, personaRepository *PersonaRepository,
+	config config.Config) *PersonaMiddlewares {
+	return &PersonaMiddlewares{
+		config:            config,
+		observer:          observer,
+		personaRepository: personaRepository,
+	}
+}
+
+func (self *PersonaMiddlewares) HandlePersona(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		requestCtx := ctx.Request().Context()
+		requestProduct := product.RequestProduct(requestCtx)
+
+		persona, err := self.personaRepository.GetByID(requestCtx, ctx.Param("persona_id"))
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+
+		if persona == nil {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		if persona.ProductID != requestProduct.ID {
+			return kit.HTTPErrUnauthorized
+		}
+
+		ctx.SetRequest(ctx.Request().WithContext(context.WithValue(requestCtx, KeyRequestPersona, persona)))
+
+		return next(ctx)
+	}
+}
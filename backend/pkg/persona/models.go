@@ -0,0 +1,74 @@
+package persona
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	PERSONA_MODEL_TABLE          = "\"persona\""
+	PERSONA_FEEDBACK_MODEL_TABLE = "\"persona_feedback\""
+)
+
+type PersonaModel struct {
+	ID          string    `db:"id"`
+	ProductID   string    `db:"product_id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	Goals       []string  `db:"goals"`
+	Pains       []string  `db:"pains"`
+	Quotes      []string  `db:"quotes"`
+	Traits      []byte    `db:"traits"`
+	Customers   int       `db:"customers"`
+	Share       float64   `db:"share"`
+	Generated   bool      `db:"generated"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func NewPersonaModel(persona Persona) *PersonaModel {
+	traits, err := json.Marshal(persona.Traits)
+	if err != nil {
+		panic(err)
+	}
+
+	return &PersonaModel{
+		ID:          persona.ID,
+		ProductID:   persona.ProductID,
+		Name:        persona.Name,
+		Description: persona.Description,
+		Goals:       persona.Goals,
+		Pains:       persona.Pains,
+		Quotes:      persona.Quotes,
+		Traits:      traits,
+		Customers:   persona.Customers,
+		Share:       persona.Share,
+		Generated:   persona.Generated,
+		CreatedAt:   persona.CreatedAt,
+		UpdatedAt:   persona.UpdatedAt,
+	}
+}
+
+func (self *PersonaModel) ToEntity() *Persona {
+	var traits PersonaTraits
+	err := json.Unmarshal(self.Traits, &traits)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Persona{
+		ID:          self.ID,
+		ProductID:   self.ProductID,
+		Name:        self.Name,
+		Description: self.Description,
+		Goals:       self.Goals,
+		Pains:       self.Pains,
+		Quotes:      self.Quotes,
+		Traits:      traits,
+		Customers:   self.Customers,
+		Share:       self.Share,
+		Generated:   self.Generated,
+		CreatedAt:   self.CreatedAt,
+		UpdatedAt:   self.UpdatedAt,
+	}
+}
@@ -0,0 +1,55 @@
+package persona
+
+import (
+	"time"
+)
+
+type PersonaTraitsPayload struct {
+	Intentions []string `json:"intentions"`
+	Emotions   []string `json:"emotions"`
+	Categories []string `json:"categories"`
+	Keywords   []string `json:"keywords"`
+}
+
+func NewPersonaTraitsPayload(traits PersonaTraits) *PersonaTraitsPayload {
+	return &PersonaTraitsPayload{
+		Intentions: traits.Intentions,
+		Emotions:   traits.Emotions,
+		Categories: traits.Categories,
+		Keywords:   traits.Keywords,
+	}
+}
+
+type PersonaPayload struct {
+	ID          string               `json:"id"`
+	ProductID   string               `json:"product_id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Goals       []string             `json:"goals"`
+	Pains       []string             `json:"pains"`
+	Quotes      []string             `json:"quotes"`
+	Traits      PersonaTraitsPayload `json:"traits"`
+	Customers   int                  `json:"customers"`
+	Share       float64              `json:"share"`
+	Generated   bool                 `json:"generated"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+func NewPersonaPayload(persona Persona) *PersonaPayload {
+	return &PersonaPayload{
+		ID:          persona.ID,
+		ProductID:   persona.ProductID,
+		Name:        persona.Name,
+		Description: persona.Description,
+		Goals:       persona.Goals,
+		Pains:       persona.Pains,
+		Quotes:      persona.Quotes,
+		Traits:      *NewPersonaTraitsPayload(persona.Traits),
+		Customers:   persona.Customers,
+		Share:       persona.Share,
+		Generated:   persona.Generated,
+		CreatedAt:   persona.CreatedAt,
+		UpdatedAt:   persona.UpdatedAt,
+	}
+}
@@ -0,0 +1,300 @@
+package persona
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/review"
+	"backend/pkg/util"
+)
+
+type PersonaRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewPersonaRepository(observer *kit.Observer, database *kit.Database, config config.Config) *PersonaRepository {
+	return &PersonaRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+// Create stores the persona linked to the feedbacks of the customers it represents.
+func (self *PersonaRepository) Create(ctx context.Context, persona Persona, feedbackIDs []string) (*Persona, error) {
+	p := NewPersonaModel(persona)
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		stmt := sqlf.
+			InsertInto(PERSONA_MODEL_TABLE).
+			Set("id", p.ID).
+			Set("product_id", p.ProductID).
+			Set("name", p.Name).
+			Set("description", p.Description).
+			Set("goals", p.Goals).
+			Set("pains", p.Pains).
+			Set("quotes", p.Quotes).
+			Set("traits", p.Traits).
+			Set("customers", p.Customers).
+			Set("share", p.Share).
+			Set("generated", p.Generated).
+			Set("created_at", p.CreatedAt).
+			Set("updated_at", p.UpdatedAt).
+			Returning("*").To(&p)
+
+		err := self.database.Query(ctx, stmt)
+		if err != nil {
+			return err
+		}
+
+		if len(feedbackIDs) == 0 {
+			return nil
+		}
+
+		stmt = sqlf.
+			InsertInto(PERSONA_FEEDBACK_MODEL_TABLE)
+
+		for _, feedbackID := range feedbackIDs {
+			stmt.
+				NewRow().
+				Set("persona_id", p.ID).
+				Set("feedback_id", feedbackID)
+		}
+
+		affected, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+
+		if affected != len(feedbackIDs) {
+			return kit.ErrDatabaseUnexpectedEffect.Raise(affected, len(feedbackIDs))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ToEntity(), nil
+}
+
+func (self *PersonaRepository) GetByID(ctx context.Context, id string) (*Persona, error) {
+	var p PersonaModel
+
+	stmt := sqlf.
+		Select("*").To(&p).
+		From(PERSONA_MODEL_TABLE).
+		Where("id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return p.ToEntity(), nil
+}
+
+// ListByProductID returns the personas of a product, the ones representing more customers first.
+func (self *PersonaRepository) ListByProductID(ctx context.Context, productID string) ([]Persona, error) {
+	var ps []PersonaModel
+
+	stmt := sqlf.
+		Select("*").To(&ps).
+		From(PERSONA_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		OrderBy("share DESC", "created_at ASC", "id ASC")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []Persona{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]Persona, 0, len(ps))
+	for _, p := range ps {
+		entities = append(entities, *p.ToEntity())
+	}
+
+	return entities, nil
+}
+
+// ListCustomers returns the most recently reviewed customers of a product.
+func (self *PersonaRepository) ListCustomers(ctx context.Context,
+	productID string, limit int) ([]PersonaCustomer, error) {
+	var cs []struct {
+		FeedbackID  string   `db:"feedback_id"`
+		Content     string   `db:"content"`
+		Translation string   `db:"translation"`
+		Language    string   `db:"language"`
+		Intention   string   `db:"intention"`
+		Emotions    []string `db:"emotions"`
+		Category    string   `db:"category"`
+		Keywords    []string `db:"keywords"`
+	}
+
+	stmt := sqlf.
+		Select(feedback.FEEDBACK_MODEL_TABLE+".id AS feedback_id, "+
+			feedback.FEEDBACK_MODEL_TABLE+".content, "+
+			feedback.FEEDBACK_MODEL_TABLE+".translation, "+
+			feedback.FEEDBACK_MODEL_TABLE+".language, "+
+			review.REVIEW_MODEL_TABLE+".intention, "+
+			review.REVIEW_MODEL_TABLE+".emotions, "+
+			review.REVIEW_MODEL_TABLE+".category, "+
+			review.REVIEW_MODEL_TABLE+".keywords").To(&cs).
+		From(review.REVIEW_MODEL_TABLE).
+		Join(feedback.FEEDBACK_MODEL_TABLE,
+			feedback.FEEDBACK_MODEL_TABLE+".id = "+review.REVIEW_MODEL_TABLE+".feedback_id").
+		Where(review.REVIEW_MODEL_TABLE+".product_id = ?", productID).
+		OrderBy(feedback.FEEDBACK_MODEL_TABLE+".posted_at DESC", feedback.FEEDBACK_MODEL_TABLE+".id DESC").
+		Limit(limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []PersonaCustomer{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]PersonaCustomer, 0, len(cs))
+	for _, c := range cs {
+		entities = append(entities, PersonaCustomer{
+			FeedbackID:  c.FeedbackID,
+			Content:     c.Content,
+			Translation: c.Translation,
+			Language:    c.Language,
+			Intention:   c.Intention,
+			Emotions:    c.Emotions,
+			Category:    c.Category,
+			Keywords:    c.Keywords,
+		})
+	}
+
+	return entities, nil
+}
+
+func (self *PersonaRepository) ListFeedbacks(ctx context.Context,
+	id string, pagination util.Pagination[time.Time]) (*util.Page[feedback.Feedback, time.Time], error) {
+	var fs []feedback.FeedbackModel
+
+	stmt := sqlf.
+		Select(feedback.FEEDBACK_MODEL_TABLE+".*").To(&fs).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Join(PERSONA_FEEDBACK_MODEL_TABLE,
+			PERSONA_FEEDBACK_MODEL_TABLE+".feedback_id = "+feedback.FEEDBACK_MODEL_TABLE+".id").
+		Where(PERSONA_FEEDBACK_MODEL_TABLE+".persona_id = ?", id)
+
+	if pagination.From != nil {
+		stmt.
+			Where(fmt.Sprintf("(%s.posted_at, %s.id) < (?, ?)",
+				feedback.FEEDBACK_MODEL_TABLE, feedback.FEEDBACK_MODEL_TABLE),
+				pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy(feedback.FEEDBACK_MODEL_TABLE+".posted_at DESC", feedback.FEEDBACK_MODEL_TABLE+".id DESC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[feedback.Feedback, time.Time]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]feedback.Feedback, 0, len(fs))
+	for _, f := range fs {
+		items = append(items, *f.ToEntity())
+	}
+
+	var cursor *util.Cursor[time.Time]
+	if len(items) == pagination.Limit {
+		cursor = &util.Cursor[time.Time]{
+			Value: items[pagination.Limit-1].PostedAt,
+			ID:    items[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[feedback.Feedback, time.Time]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
+
+// UpdateProfile stores the profile of a persona, which is no longer replaced on the next synthesis once edited.
+func (self *PersonaRepository) UpdateProfile(ctx context.Context, persona Persona) error {
+	p := NewPersonaModel(persona)
+
+	stmt := sqlf.
+		Update(PERSONA_MODEL_TABLE).
+		Set("name", p.Name).
+		Set("description", p.Description).
+		Set("goals", p.Goals).
+		Set("pains", p.Pains).
+		Set("quotes", p.Quotes).
+		Set("generated", p.Generated).
+		Set("updated_at", p.UpdatedAt).
+		Where("id = ?", p.ID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+func (self *PersonaRepository) Delete(ctx context.Context, id string) error {
+	stmt := sqlf.
+		DeleteFrom(PERSONA_MODEL_TABLE).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+// DeleteGeneratedByProductID deletes the personas of a product synthesized by the engine and not edited since,
+// along with their links to the feedbacks.
+func (self *PersonaRepository) DeleteGeneratedByProductID(ctx context.Context, productID string) (int, error) {
+	stmt := sqlf.
+		DeleteFrom(PERSONA_MODEL_TABLE).
+		Where("product_id = ? AND generated", productID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
@@ -0,0 +1,269 @@
+package persona
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+	"github.com/rs/xid"
+
+	"backend/pkg/config"
+	"backend/pkg/engine"
+	"backend/pkg/organization"
+	"backend/pkg/outbox"
+	"backend/pkg/product"
+)
+
+const (
+	SynthesizerSynthesize = "persona:synthesize"
+	SynthesizerSchedule   = "persona:schedule-synthesize"
+)
+
+var (
+	ErrSynthesizerGeneric = errors.New("synthesizer failed")
+)
+
+type Synthesizer struct {
+	config                 config.Config
+	observer               *kit.Observer
+	database               *kit.Database
+	productRepository      *product.ProductRepository
+	organizationRepository organization.OrganizationRepository
+	personaRepository      *PersonaRepository
+	enqueuer               *outbox.OutboxEnqueuer
+	engineService          *engine.EngineService
+}
+
+func NewSynthesizer(observer *kit.Observer, database *kit.Database, productRepository *product.ProductRepository,
+	organizationRepository organization.OrganizationRepository, personaRepository *PersonaRepository,
+	enqueuer *outbox.OutboxEnqueuer, engineService *engine.EngineService, config config.Config) *Synthesizer {
+	return &Synthesizer{
+		config:                 config,
+		observer:               observer,
+		database:               database,
+		productRepository:      productRepository,
+		organizationRepository: organizationRepository,
+		personaRepository:      personaRepository,
+		enqueuer:               enqueuer,
+		engineService:          engineService,
+	}
+}
+
+// Refresh enqueues the synthesis of the personas of a product, which is skipped if one is already enqueued.
+func (self *Synthesizer) Refresh(ctx context.Context, productID string) error {
+	err := self.enqueuer.Enqueue(ctx, SynthesizerSynthesize, SynthesizerSynthesizeParams{
+		ProductID: productID,
+	}, asynq.MaxRetry(2), asynq.Unique(10*time.Minute))
+	if err != nil {
+		return ErrSynthesizerGeneric.Raise().Cause(err)
+	}
+
+	return nil
+}
+
+// newTraits describes the traits of a cluster for the engine to ground the persona on.
+func (self *Synthesizer) newTraits(traits PersonaTraits) []string {
+	descriptions := []string{}
+
+	for _, trait := range []struct {
+		Name   string
+		Values []string
+	}{
+		{Name: "Intentions", Values: traits.Intentions},
+		{Name: "Emotions", Values: traits.Emotions},
+		{Name: "Categories", Values: traits.Categories},
+		{Name: "Keywords", Values: traits.Keywords},
+	} {
+		if len(trait.Values) > 0 {
+			descriptions = append(descriptions, trait.Name+": "+strings.Join(trait.Values, ", "))
+		}
+	}
+
+	return descriptions
+}
+
+// Run clusters the most recently reviewed customers of a product and synthesizes a persona for every cluster big
+// enough. The personas synthesized on the previous run are replaced, while the ones written or edited by hand are
+// kept. If there are not enough reviewed customers, the previous personas are kept as well.
+func (self *Synthesizer) Run(ctx context.Context, _product product.Product) ([]Persona, error) {
+	customers, err := self.personaRepository.ListCustomers(ctx, _product.ID, PERSONA_SYNTHESIS_MAX_CUSTOMERS)
+	if err != nil {
+		return nil, ErrSynthesizerGeneric.Raise().Cause(err)
+	}
+
+	clusters := NewClusters(customers)
+	if len(clusters) == 0 {
+		self.observer.Infof(ctx, "Not enough reviewed customers to synthesize personas of product %s", _product.ID)
+		return []Persona{}, nil
+	}
+
+	type synthesizedPersona struct {
+		Persona     Persona
+		FeedbackIDs []string
+	}
+
+	synthesized := []synthesizedPersona{}
+	tokens := 0
+	now := time.Now()
+
+	for _, cluster := range clusters {
+		feedbacks := []engine.Feedback{}
+		for _, customer := range cluster.Customers {
+			if len(feedbacks) >= PERSONA_SYNTHESIS_SAMPLE_CUSTOMERS {
+				break
+			}
+
+			content := customer.Content
+			if customer.Language != _product.Language {
+				content = customer.Translation
+			}
+
+			if len(content) > 0 {
+				feedbacks = append(feedbacks, engine.Feedback{Content: content})
+			}
+		}
+
+		if len(feedbacks) == 0 {
+			continue
+		}
+
+		spResult, err := self.engineService.SynthesizePersona(ctx, engine.EngineServiceSynthesizePersonaParams{
+			Context:   _product.Context,
+			Traits:    self.newTraits(cluster.Traits),
+			Feedbacks: feedbacks,
+			Language:  _product.Language,
+		})
+		if err != nil {
+			return nil, ErrSynthesizerGeneric.Raise().Cause(err)
+		}
+
+		tokens += (spResult.Usage.Input + spResult.Usage.Output)
+
+		persona := NewPersona()
+		persona.ID = xid.New().String()
+		persona.ProductID = _product.ID
+		persona.Traits = cluster.Traits
+		persona.Customers = len(cluster.Customers)
+		persona.Share = float64(len(cluster.Customers)) / float64(len(customers))
+		persona.Generated = true
+		persona.CreatedAt = now
+		persona.UpdatedAt = now
+
+		err = persona.SetProfile(spResult.Persona.Name, spResult.Persona.Description,
+			spResult.Persona.Goals, spResult.Persona.Pains, spResult.Persona.Quotes)
+		if err != nil {
+			self.observer.Infof(ctx, "Discarded synthesized persona %s of product %s", spResult.Persona.Name,
+				_product.ID)
+			continue
+		}
+
+		feedbackIDs := make([]string, 0, len(cluster.Customers))
+		for _, customer := range cluster.Customers {
+			feedbackIDs = append(feedbackIDs, customer.FeedbackID)
+		}
+
+		synthesized = append(synthesized, synthesizedPersona{Persona: *persona, FeedbackIDs: feedbackIDs})
+	}
+
+	personas := make([]Persona, 0, len(synthesized))
+	replaced := 0
+
+	err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		replaced, err = self.personaRepository.DeleteGeneratedByProductID(ctx, _product.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range synthesized {
+			persona, err := self.personaRepository.Create(ctx, item.Persona, item.FeedbackIDs)
+			if err != nil {
+				return err
+			}
+
+			personas = append(personas, *persona)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, ErrSynthesizerGeneric.Raise().Cause(err)
+	}
+
+	self.observer.Infof(ctx, "Synthesized %d personas, replacing %d, from %d customers of product %s using %d tokens",
+		len(personas), replaced, len(customers), _product.ID, tokens)
+
+	return personas, nil
+}
+
+type SynthesizerSynthesizeParams struct {
+	ProductID string
+}
+
+func (self *Synthesizer) Synthesize(ctx context.Context, task *asynq.Task) error {
+	params := SynthesizerSynthesizeParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	product, err := self.productRepository.GetByID(ctx, params.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	if product.DeletedAt != nil {
+		return nil
+	}
+
+	organization, err := self.organizationRepository.GetByID(ctx, product.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	if organization == nil {
+		return nil
+	}
+
+	if organization.DeletedAt != nil {
+		return nil
+	}
+
+	if organization.UsageLeft() < 1 {
+		return nil
+	}
+
+	_, err = self.Run(ctx, *product)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *Synthesizer) Schedule(ctx context.Context, _ *asynq.Task) error {
+	ids, err := self.productRepository.ListIDsByNotDeleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := self.enqueuer.Enqueue(ctx, SynthesizerSynthesize, SynthesizerSynthesizeParams{
+			ProductID: id,
+		}, asynq.MaxRetry(2), asynq.Unique(7*24*time.Hour))
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/mkideal/cli"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/util"
+)
+
+const (
+	PipelineCommandsListStuck  = "pipeline-list"
+	PipelineCommandsRetryStuck = "pipeline-retry"
+	PipelineCommandsSkipStuck  = "pipeline-skip"
+)
+
+type PipelineCommands struct {
+	config            config.Config
+	observer          *kit.Observer
+	pipelineInspector *PipelineInspector
+}
+
+func NewPipelineCommands(observer *kit.Observer, pipelineInspector *PipelineInspector,
+	config config.Config) *PipelineCommands {
+	return &PipelineCommands{
+		config:            config,
+		observer:          observer,
+		pipelineInspector: pipelineInspector,
+	}
+}
+
+func (self *PipelineCommands) each(ctx context.Context, stage string, productID *string,
+	fn func(item PipelineItem) error) error {
+	if !IsPipelineStage(stage) {
+		return kit.ErrRunnerGeneric.Raise().With("invalid stage %s", stage)
+	}
+
+	pagination := util.Pagination[time.Time]{
+		Limit: 100,
+		From:  nil,
+	}
+
+	for {
+		page, err := self.pipelineInspector.List(ctx, stage, productID, pagination)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range page.Items {
+			err := fn(item)
+			if err != nil {
+				return err
+			}
+		}
+
+		if page.Next == nil {
+			break
+		}
+		pagination.From = page.Next
+	}
+
+	return nil
+}
+
+type PipelineCommandsListStuckArgs struct {
+	cli.Helper
+	Stage   string `cli:"*stage" usage:"pipeline stage: translation, processing or aggregation"`
+	Product string `cli:"product" dft:"" usage:"id of the product to list the items of (all if empty)"`
+}
+
+func (self *PipelineCommands) ListStuck(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*PipelineCommandsListStuckArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	var productID *string
+	if len(args.Product) > 0 {
+		productID = &args.Product
+	}
+
+	count := 0
+	err := self.each(ctx, args.Stage, productID, func(item PipelineItem) error {
+		count++
+
+		if item.Task == nil {
+			self.observer.Infof(ctx, "%s of feedback %s stuck for %s without failed task",
+				item.String(), item.FeedbackID, item.Age().Round(time.Second))
+			return nil
+		}
+
+		self.observer.Infof(ctx, "%s of feedback %s stuck for %s with %s task %s after %d/%d attempts: %s",
+			item.String(), item.FeedbackID, item.Age().Round(time.Second), item.Task.State, item.Task.ID,
+			item.Task.Attempts, item.Task.MaxRetry+1, item.Task.LastError)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	self.observer.Infof(ctx, "Found %d items stuck in the %s stage", count, args.Stage)
+
+	return nil
+}
+
+type PipelineCommandsRetryStuckArgs struct {
+	cli.Helper
+	Stage   string `cli:"*stage" usage:"pipeline stage: translation, processing or aggregation"`
+	Item    string `cli:"item" dft:"" usage:"id of the item to retry (all the failed ones if empty)"`
+	Product string `cli:"product" dft:"" usage:"id of the product to retry the failed items of (all if empty)"`
+}
+
+func (self *PipelineCommands) RetryStuck(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*PipelineCommandsRetryStuckArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	if len(args.Item) > 0 {
+		item, err := self.getItem(ctx, args.Stage, args.Item)
+		if err != nil {
+			return err
+		}
+
+		return self.pipelineInspector.Retry(ctx, *item)
+	}
+
+	var productID *string
+	if len(args.Product) > 0 {
+		productID = &args.Product
+	}
+
+	count := 0
+	err := self.each(ctx, args.Stage, productID, func(item PipelineItem) error {
+		if item.Task == nil {
+			return nil
+		}
+
+		count++
+		return self.pipelineInspector.Retry(ctx, item)
+	})
+	if err != nil {
+		return err
+	}
+
+	self.observer.Infof(ctx, "Retried %d failed items of the %s stage", count, args.Stage)
+
+	return nil
+}
+
+type PipelineCommandsSkipStuckArgs struct {
+	cli.Helper
+	Stage string `cli:"*stage" usage:"pipeline stage: translation, processing or aggregation"`
+	Item  string `cli:"*item" usage:"id of the item to skip"`
+}
+
+func (self *PipelineCommands) SkipStuck(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*PipelineCommandsSkipStuckArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	item, err := self.getItem(ctx, args.Stage, args.Item)
+	if err != nil {
+		return err
+	}
+
+	return self.pipelineInspector.Skip(ctx, *item)
+}
+
+func (self *PipelineCommands) getItem(ctx context.Context, stage string, id string) (*PipelineItem, error) {
+	if !IsPipelineStage(stage) {
+		return nil, kit.ErrRunnerGeneric.Raise().With("invalid stage %s", stage)
+	}
+
+	item, err := self.pipelineInspector.Get(ctx, stage, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if item == nil {
+		return nil, kit.ErrRunnerGeneric.Raise().With("item %s is not stuck in the %s stage", id, stage)
+	}
+
+	return item, nil
+}
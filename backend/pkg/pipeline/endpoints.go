@@ -0,0 +1,165 @@
+package pipeline
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+	kitUtil "github.com/neoxelox/kit/util"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+const (
+	PIPELINE_ENDPOINTS_LIST_MAX_LIMIT = 100
+)
+
+type PipelineEndpoints struct {
+	config            config.Config
+	observer          *kit.Observer
+	pipelineInspector *PipelineInspector
+}
+
+func NewPipelineEndpoints(observer *kit.Observer, pipelineInspector *PipelineInspector,
+	config config.Config) *PipelineEndpoints {
+	return &PipelineEndpoints{
+		config:            config,
+		observer:          observer,
+		pipelineInspector: pipelineInspector,
+	}
+}
+
+type PipelineEndpointsListPipelineItemsRequest struct {
+	Stage      string `param:"stage"`
+	Pagination struct {
+		Limit *int    `query:"limit"`
+		From  *string `query:"from"`
+	}
+}
+
+type PipelineEndpointsListPipelineItemsResponse struct {
+	Items []PipelineItemPayload `json:"items"`
+	Next  *string               `json:"next"`
+}
+
+func (self *PipelineEndpoints) ListPipelineItems(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := PipelineEndpointsListPipelineItemsRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if !IsPipelineStage(request.Stage) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	if request.Pagination.Limit != nil {
+		if *request.Pagination.Limit > PIPELINE_ENDPOINTS_LIST_MAX_LIMIT {
+			return kit.HTTPErrInvalidRequest
+		}
+	} else {
+		request.Pagination.Limit = kitUtil.Pointer(100)
+	}
+
+	page, err := self.pipelineInspector.List(requestCtx, request.Stage, &requestProduct.ID,
+		util.Pagination[time.Time]{
+			Limit: *request.Pagination.Limit,
+			From:  util.CursorFromString[time.Time](request.Pagination.From),
+		})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := PipelineEndpointsListPipelineItemsResponse{}
+	response.Items = make([]PipelineItemPayload, 0, len(page.Items))
+	for _, item := range page.Items {
+		response.Items = append(response.Items, *NewPipelineItemPayload(item))
+	}
+	next := util.CursorToString(page.Next)
+	if next != nil {
+		*next = url.QueryEscape(*next)
+	}
+	response.Next = next
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type PipelineEndpointsPostPipelineItemRequest struct {
+	Stage  string `param:"stage"`
+	ItemID string `param:"item_id"`
+}
+
+type PipelineEndpointsPostPipelineItemResponse struct {
+	PipelineItemPayload
+}
+
+func (self *PipelineEndpoints) getRequestItem(ctx echo.Context) (*PipelineItem, error) {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := PipelineEndpointsPostPipelineItemRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return nil, kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if !IsPipelineStage(request.Stage) {
+		return nil, kit.HTTPErrInvalidRequest
+	}
+
+	item, err := self.pipelineInspector.Get(requestCtx, request.Stage, request.ItemID)
+	if err != nil {
+		return nil, kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	if item == nil || item.ProductID != requestProduct.ID {
+		return nil, kit.HTTPErrNotFound
+	}
+
+	return item, nil
+}
+
+func (self *PipelineEndpoints) PostPipelineItemRetry(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+
+	item, err := self.getRequestItem(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = self.pipelineInspector.Retry(requestCtx, *item)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := PipelineEndpointsPostPipelineItemResponse{}
+	response.PipelineItemPayload = *NewPipelineItemPayload(*item)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+func (self *PipelineEndpoints) PostPipelineItemSkip(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+
+	item, err := self.getRequestItem(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = self.pipelineInspector.Skip(requestCtx, *item)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := PipelineEndpointsPostPipelineItemResponse{}
+	response.PipelineItemPayload = *NewPipelineItemPayload(*item)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
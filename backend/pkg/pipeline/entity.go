@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	kitUtil "github.com/neoxelox/kit/util"
+
+	"backend/pkg/util"
+)
+
+const (
+	PIPELINE_STUCK_AFTER          = util.PIPELINE_SWEEPER_GRACE
+	PIPELINE_TASKS_LIST_PAGE_SIZE = 1000
+	// Failed tasks older than these pages of each queue and state are not shown
+	PIPELINE_TASKS_LIST_MAX_PAGES = 10
+)
+
+const (
+	PipelineItemTypeFeedback          = "FEEDBACK"
+	PipelineItemTypePartialIssue      = "PARTIAL_ISSUE"
+	PipelineItemTypePartialSuggestion = "PARTIAL_SUGGESTION"
+)
+
+func IsPipelineStage(value string) bool {
+	return value == util.PipelineStageTranslation ||
+		value == util.PipelineStageProcessing ||
+		value == util.PipelineStageAggregation
+}
+
+type PipelineTask struct {
+	ID           string
+	Queue        string
+	State        string
+	Attempts     int
+	MaxRetry     int
+	LastError    string
+	LastFailedAt *time.Time
+}
+
+func NewPipelineTask(info asynq.TaskInfo) *PipelineTask {
+	// Tasks waiting to be retried have already counted the failed attempt, archived ones have not
+	attempts := info.Retried
+	if info.State == asynq.TaskStateArchived {
+		attempts++
+	}
+
+	var lastFailedAt *time.Time
+	if !info.LastFailedAt.IsZero() {
+		lastFailedAt = &info.LastFailedAt
+	}
+
+	return &PipelineTask{
+		ID:           info.ID,
+		Queue:        info.Queue,
+		State:        info.State.String(),
+		Attempts:     attempts,
+		MaxRetry:     info.MaxRetry,
+		LastError:    info.LastErr,
+		LastFailedAt: lastFailedAt,
+	}
+}
+
+type PipelineItem struct {
+	ID         string
+	Type       string
+	Stage      string
+	ProductID  string
+	FeedbackID string
+	Since      time.Time
+	Task       *PipelineTask
+}
+
+func NewPipelineItem() *PipelineItem {
+	return &PipelineItem{}
+}
+
+func (self PipelineItem) String() string {
+	return fmt.Sprintf("<PipelineItem: %s %s (%s)>", self.Stage, self.Type, self.ID)
+}
+
+func (self PipelineItem) Equals(other PipelineItem) bool {
+	return kitUtil.Equals(self, other)
+}
+
+func (self PipelineItem) Copy() *PipelineItem {
+	return kitUtil.Copy(self)
+}
+
+func (self PipelineItem) Age() time.Duration {
+	return time.Since(self.Since)
+}
@@ -0,0 +1,281 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/aggregator"
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/outbox"
+	"backend/pkg/processor"
+	"backend/pkg/suggestion"
+	"backend/pkg/translator"
+	"backend/pkg/util"
+)
+
+var (
+	ErrPipelineInspectorGeneric = errors.New("pipeline inspector failed")
+)
+
+type PipelineInspector struct {
+	config                      config.Config
+	observer                    *kit.Observer
+	database                    *kit.Database
+	pipelineRepository          *PipelineRepository
+	feedbackRepository          *feedback.FeedbackRepository
+	partialIssueRepository      *issue.PartialIssueRepository
+	partialSuggestionRepository *suggestion.PartialSuggestionRepository
+	enqueuer                    *outbox.OutboxEnqueuer
+	inspector                   *asynq.Inspector
+}
+
+func NewPipelineInspector(observer *kit.Observer, database *kit.Database, pipelineRepository *PipelineRepository,
+	feedbackRepository *feedback.FeedbackRepository, partialIssueRepository *issue.PartialIssueRepository,
+	partialSuggestionRepository *suggestion.PartialSuggestionRepository, enqueuer *outbox.OutboxEnqueuer,
+	inspector *asynq.Inspector, config config.Config) *PipelineInspector {
+	return &PipelineInspector{
+		config:                      config,
+		observer:                    observer,
+		database:                    database,
+		pipelineRepository:          pipelineRepository,
+		feedbackRepository:          feedbackRepository,
+		partialIssueRepository:      partialIssueRepository,
+		partialSuggestionRepository: partialSuggestionRepository,
+		enqueuer:                    enqueuer,
+		inspector:                   inspector,
+	}
+}
+
+type pipelineTaskParams struct {
+	FeedbackID string
+	PartialID  string
+}
+
+func pipelineTaskStage(_type string) string {
+	switch _type {
+	case translator.FeedbackTranslatorTranslate:
+		return util.PipelineStageTranslation
+	case processor.FeedbackProcessorProcess:
+		return util.PipelineStageProcessing
+	case aggregator.IssueAggregatorAggregate, aggregator.SuggestionAggregatorAggregate:
+		return util.PipelineStageAggregation
+	default:
+		return ""
+	}
+}
+
+// tasks returns the pipeline tasks of the items of the stage that have failed at least once, whether they are
+// waiting to be retried or have exhausted their retries and have been archived, keyed by the id of the item they
+// handle. Listing stops as soon as every item has its task, and only the most recent failed tasks are looked at.
+func (self *PipelineInspector) tasks(ctx context.Context, stage string, ids []string) (map[string]PipelineTask, error) {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	queues, err := self.inspector.Queues()
+	if err != nil {
+		return nil, ErrPipelineInspectorGeneric.Raise().Cause(err)
+	}
+
+	tasks := make(map[string]PipelineTask)
+	for _, queue := range queues {
+		for _, list := range []func(string, ...asynq.ListOption) ([]*asynq.TaskInfo, error){
+			self.inspector.ListRetryTasks,
+			self.inspector.ListArchivedTasks,
+		} {
+			for page := 1; page <= PIPELINE_TASKS_LIST_MAX_PAGES; page++ {
+				infos, err := list(queue, asynq.PageSize(PIPELINE_TASKS_LIST_PAGE_SIZE), asynq.Page(page))
+				if err != nil {
+					return nil, ErrPipelineInspectorGeneric.Raise().Cause(err)
+				}
+
+				for _, info := range infos {
+					if pipelineTaskStage(info.Type) != stage {
+						continue
+					}
+
+					params := pipelineTaskParams{}
+
+					err := json.Unmarshal(info.Payload, &params)
+					if err != nil {
+						self.observer.Error(ctx, ErrPipelineInspectorGeneric.Raise().Cause(err))
+						continue
+					}
+
+					id := params.FeedbackID
+					if stage == util.PipelineStageAggregation {
+						id = params.PartialID
+					}
+
+					if !wanted[id] {
+						continue
+					}
+
+					// A task waiting to be retried is more recent than an archived one of the same item
+					if _, ok := tasks[id]; !ok {
+						tasks[id] = *NewPipelineTask(*info)
+					}
+
+					if len(tasks) == len(wanted) {
+						return tasks, nil
+					}
+				}
+
+				if len(infos) < PIPELINE_TASKS_LIST_PAGE_SIZE {
+					break
+				}
+			}
+		}
+	}
+
+	return tasks, nil
+}
+
+// List returns the items that have been stuck in the stage for a while with their last failed task, if any.
+func (self *PipelineInspector) List(ctx context.Context, stage string, productID *string,
+	pagination util.Pagination[time.Time]) (*util.Page[PipelineItem, time.Time], error) {
+	page, err := self.pipelineRepository.ListByStage(ctx, stage, productID,
+		time.Now().Add(-PIPELINE_STUCK_AFTER), pagination)
+	if err != nil {
+		return nil, ErrPipelineInspectorGeneric.Raise().Cause(err)
+	}
+
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	ids := make([]string, 0, len(page.Items))
+	for _, item := range page.Items {
+		ids = append(ids, item.ID)
+	}
+
+	tasks, err := self.tasks(ctx, stage, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range page.Items {
+		if task, ok := tasks[page.Items[i].ID]; ok {
+			page.Items[i].Task = &task
+		}
+	}
+
+	return page, nil
+}
+
+// Get returns the item if it has been stuck in the stage for a while with its last failed task, if any.
+func (self *PipelineInspector) Get(ctx context.Context, stage string, id string) (*PipelineItem, error) {
+	item, err := self.pipelineRepository.GetByStageAndID(ctx, stage, id, time.Now().Add(-PIPELINE_STUCK_AFTER))
+	if err != nil {
+		return nil, ErrPipelineInspectorGeneric.Raise().Cause(err)
+	}
+
+	if item == nil {
+		return nil, nil
+	}
+
+	tasks, err := self.tasks(ctx, stage, []string{item.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	if task, ok := tasks[item.ID]; ok {
+		item.Task = &task
+	}
+
+	return item, nil
+}
+
+// Retry runs the failed task of the item again or, if the item has no failed task, enqueues a new one.
+func (self *PipelineInspector) Retry(ctx context.Context, item PipelineItem) error {
+	if item.Task != nil {
+		err := self.inspector.RunTask(item.Task.Queue, item.Task.ID)
+		if err == nil {
+			self.observer.Infof(ctx, "Retried pipeline task %s of %s", item.Task.ID, item.String())
+			return nil
+		}
+
+		if !stdErrors.Is(err, asynq.ErrTaskNotFound) {
+			return ErrPipelineInspectorGeneric.Raise().Cause(err)
+		}
+	}
+
+	var err error
+	switch item.Type {
+	case PipelineItemTypeFeedback:
+		if item.Stage == util.PipelineStageTranslation {
+			err = self.enqueuer.Enqueue(ctx, translator.FeedbackTranslatorTranslate,
+				translator.FeedbackTranslatorTranslateParams{
+					FeedbackID: item.ID,
+				}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)))
+		} else {
+			err = self.enqueuer.Enqueue(ctx, processor.FeedbackProcessorProcess,
+				processor.FeedbackProcessorProcessParams{
+					FeedbackID: item.ID,
+				}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)))
+		}
+	case PipelineItemTypePartialIssue:
+		err = self.enqueuer.Enqueue(ctx, aggregator.IssueAggregatorAggregate,
+			aggregator.IssueAggregatorAggregateParams{
+				PartialID: item.ID,
+			}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)))
+	case PipelineItemTypePartialSuggestion:
+		err = self.enqueuer.Enqueue(ctx, aggregator.SuggestionAggregatorAggregate,
+			aggregator.SuggestionAggregatorAggregateParams{
+				PartialID: item.ID,
+			}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)))
+	}
+	if err != nil {
+		return ErrPipelineInspectorGeneric.Raise().Cause(err)
+	}
+
+	self.observer.Infof(ctx, "Enqueued a new pipeline task for %s", item.String())
+
+	return nil
+}
+
+// Skip takes the item out of the pipeline and deletes its failed task, if any. Skipped feedbacks are not
+// swept again, while skipped partial issues and suggestions are deleted without being aggregated.
+func (self *PipelineInspector) Skip(ctx context.Context, item PipelineItem) error {
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		switch item.Type {
+		case PipelineItemTypeFeedback:
+			return self.feedbackRepository.UpdateSkipped(ctx, item.ID, time.Now())
+		case PipelineItemTypePartialIssue:
+			err := self.partialIssueRepository.Delete(ctx, item.ID)
+			if err != nil {
+				return err
+			}
+		case PipelineItemTypePartialSuggestion:
+			err := self.partialSuggestionRepository.Delete(ctx, item.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return self.feedbackRepository.UpdateAggregated(ctx, item.FeedbackID, time.Now())
+	})
+	if err != nil {
+		return ErrPipelineInspectorGeneric.Raise().Cause(err)
+	}
+
+	if item.Task != nil {
+		err := self.inspector.DeleteTask(item.Task.Queue, item.Task.ID)
+		if err != nil && !stdErrors.Is(err, asynq.ErrTaskNotFound) {
+			return ErrPipelineInspectorGeneric.Raise().Cause(err)
+		}
+	}
+
+	self.observer.Infof(ctx, "Skipped %s", item.String())
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package pipeline
+
+import (
+	"time"
+)
+
+type PipelineItemModel struct {
+	ID         string    `db:"id"`
+	Type       string    `db:"type"`
+	ProductID  string    `db:"product_id"`
+	FeedbackID string    `db:"feedback_id"`
+	Since      time.Time `db:"since"`
+}
+
+func (self *PipelineItemModel) ToEntity(stage string) *PipelineItem {
+	return &PipelineItem{
+		ID:         self.ID,
+		Type:       self.Type,
+		Stage:      stage,
+		ProductID:  self.ProductID,
+		FeedbackID: self.FeedbackID,
+		Since:      self.Since,
+		Task:       nil,
+	}
+}
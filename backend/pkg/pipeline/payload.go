@@ -0,0 +1,52 @@
+package pipeline
+
+import "time"
+
+type PipelineTaskPayload struct {
+	ID           string     `json:"id"`
+	Queue        string     `json:"queue"`
+	State        string     `json:"state"`
+	Attempts     int        `json:"attempts"`
+	MaxRetry     int        `json:"max_retry"`
+	LastError    string     `json:"last_error"`
+	LastFailedAt *time.Time `json:"last_failed_at"`
+}
+
+func NewPipelineTaskPayload(task PipelineTask) *PipelineTaskPayload {
+	return &PipelineTaskPayload{
+		ID:           task.ID,
+		Queue:        task.Queue,
+		State:        task.State,
+		Attempts:     task.Attempts,
+		MaxRetry:     task.MaxRetry,
+		LastError:    task.LastError,
+		LastFailedAt: task.LastFailedAt,
+	}
+}
+
+type PipelineItemPayload struct {
+	ID         string               `json:"id"`
+	Type       string               `json:"type"`
+	Stage      string               `json:"stage"`
+	FeedbackID string               `json:"feedback_id"`
+	Since      time.Time            `json:"since"`
+	Age        int64                `json:"age"`
+	Task       *PipelineTaskPayload `json:"task"`
+}
+
+func NewPipelineItemPayload(item PipelineItem) *PipelineItemPayload {
+	var task *PipelineTaskPayload
+	if item.Task != nil {
+		task = NewPipelineTaskPayload(*item.Task)
+	}
+
+	return &PipelineItemPayload{
+		ID:         item.ID,
+		Type:       item.Type,
+		Stage:      item.Stage,
+		FeedbackID: item.FeedbackID,
+		Since:      item.Since,
+		Age:        int64(item.Age().Seconds()),
+		Task:       task,
+	}
+}
@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/suggestion"
+	"backend/pkg/util"
+)
+
+type PipelineRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewPipelineRepository(observer *kit.Observer, database *kit.Database, config config.Config) *PipelineRepository {
+	return &PipelineRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+// The pending items of each stage are the feedbacks not yet translated or processed, which have not been
+// skipped, and the partial issues and suggestions not yet aggregated, since when they entered the stage.
+func (self *PipelineRepository) pending(stage string) string {
+	switch stage {
+	case util.PipelineStageTranslation:
+		return `(SELECT "id", '` + PipelineItemTypeFeedback + `' AS "type", "product_id", "id" AS "feedback_id",
+			"collected_at" AS "since" FROM ` + feedback.FEEDBACK_MODEL_TABLE + `
+			WHERE "translated_at" IS NULL AND "skipped_at" IS NULL) AS "item"`
+	case util.PipelineStageProcessing:
+		return `(SELECT "id", '` + PipelineItemTypeFeedback + `' AS "type", "product_id", "id" AS "feedback_id",
+			"translated_at" AS "since" FROM ` + feedback.FEEDBACK_MODEL_TABLE + `
+			WHERE "translated_at" IS NOT NULL AND "processed_at" IS NULL AND "skipped_at" IS NULL) AS "item"`
+	default:
+		return `(SELECT "p"."id", '` + PipelineItemTypePartialIssue + `' AS "type", "f"."product_id",
+			"p"."feedback_id", "p"."created_at" AS "since" FROM ` + issue.PARTIAL_ISSUE_MODEL_TABLE + ` AS "p"
+			JOIN ` + feedback.FEEDBACK_MODEL_TABLE + ` AS "f" ON "f"."id" = "p"."feedback_id"
+			UNION ALL
+			SELECT "p"."id", '` + PipelineItemTypePartialSuggestion + `' AS "type", "f"."product_id",
+			"p"."feedback_id", "p"."created_at" AS "since" FROM ` + suggestion.PARTIAL_SUGGESTION_MODEL_TABLE + ` AS "p"
+			JOIN ` + feedback.FEEDBACK_MODEL_TABLE + ` AS "f" ON "f"."id" = "p"."feedback_id") AS "item"`
+	}
+}
+
+func (self *PipelineRepository) GetByStageAndID(ctx context.Context, stage string, id string,
+	before time.Time) (*PipelineItem, error) {
+	var p PipelineItemModel
+
+	stmt := sqlf.
+		Select("*").To(&p).
+		From(self.pending(stage)).
+		Where("id = ?", id).
+		Where("since <= ?", before)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return p.ToEntity(stage), nil
+}
+
+func (self *PipelineRepository) ListByStage(ctx context.Context, stage string, productID *string,
+	before time.Time, pagination util.Pagination[time.Time]) (*util.Page[PipelineItem, time.Time], error) {
+	var result []PipelineItemModel
+
+	stmt := sqlf.
+		Select("*").To(&result).
+		From(self.pending(stage)).
+		Where("since <= ?", before)
+
+	if productID != nil {
+		stmt.
+			Where("product_id = ?", *productID)
+	}
+
+	if pagination.From != nil {
+		stmt.
+			Where("(since, id) > (?, ?)", pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy("since ASC", "id ASC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[PipelineItem, time.Time]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]PipelineItem, 0, len(result))
+	for _, res := range result {
+		items = append(items, *res.ToEntity(stage))
+	}
+
+	var cursor *util.Cursor[time.Time]
+	if len(result) == pagination.Limit {
+		cursor = &util.Cursor[time.Time]{
+			Value: result[pagination.Limit-1].Since,
+			ID:    result[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[PipelineItem, time.Time]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
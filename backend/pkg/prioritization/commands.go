@@ -0,0 +1,60 @@
+package prioritization
+
+import (
+	"context"
+
+	"github.com/mkideal/cli"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+)
+
+const (
+	PrioritizationCommandsPrioritizeProduct = "prioritize-product"
+)
+
+type PrioritizationCommands struct {
+	config            config.Config
+	observer          *kit.Observer
+	productRepository *product.ProductRepository
+	prioritizer       *Prioritizer
+}
+
+func NewPrioritizationCommands(observer *kit.Observer, productRepository *product.ProductRepository,
+	prioritizer *Prioritizer, config config.Config) *PrioritizationCommands {
+	return &PrioritizationCommands{
+		config:            config,
+		observer:          observer,
+		productRepository: productRepository,
+		prioritizer:       prioritizer,
+	}
+}
+
+type PrioritizationCommandsPrioritizeProductArgs struct {
+	cli.Helper
+	Product string `cli:"*product" usage:"id of the product to recompute the priorities of"`
+}
+
+func (self *PrioritizationCommands) PrioritizeProduct(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*PrioritizationCommandsPrioritizeProductArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	product, err := self.productRepository.GetByID(ctx, args.Product)
+	if err != nil {
+		return err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return kit.ErrRunnerGeneric.Raise().With("product %s not found", args.Product)
+	}
+
+	_, err = self.prioritizer.Run(ctx, *product)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
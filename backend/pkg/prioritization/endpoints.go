@@ -0,0 +1,169 @@
+package prioritization
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/priority"
+	"backend/pkg/product"
+	"backend/pkg/suggestion"
+)
+
+type PrioritizationEndpoints struct {
+	config      config.Config
+	observer    *kit.Observer
+	prioritizer *Prioritizer
+}
+
+func NewPrioritizationEndpoints(observer *kit.Observer, prioritizer *Prioritizer,
+	config config.Config) *PrioritizationEndpoints {
+	return &PrioritizationEndpoints{
+		config:      config,
+		observer:    observer,
+		prioritizer: prioritizer,
+	}
+}
+
+type PrioritizationEndpointsGetPriorityResponse struct {
+	priority.PriorityFormulaPayload
+}
+
+func (self *PrioritizationEndpoints) GetPriority(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+
+	response := PrioritizationEndpointsGetPriorityResponse{}
+	response.PriorityFormulaPayload = *priority.NewPriorityFormulaPayload(requestProduct.PriorityFormula())
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+// Any tuned weight turns the preset into a custom formula based on it.
+type PrioritizationEndpointsPutPriorityRequest struct {
+	Preset           string              `json:"preset"`
+	LevelWeights     *map[string]float64 `json:"level_weights"`
+	SourceWeights    *map[string]float64 `json:"source_weights"`
+	CustomerExponent *float64            `json:"customer_exponent"`
+	RecencyHalfLife  *int                `json:"recency_half_life"`
+	TrendWindow      *int                `json:"trend_window"`
+	TrendWeight      *float64            `json:"trend_weight"`
+	RevenueWeight    *float64            `json:"revenue_weight"`
+}
+
+type PrioritizationEndpointsPutPriorityResponse struct {
+	priority.PriorityFormulaPayload
+}
+
+func (self *PrioritizationEndpoints) PutPriority(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := PrioritizationEndpointsPutPriorityRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if !priority.IsPriorityPreset(request.Preset) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	formula := priority.NewPriorityFormula(request.Preset)
+
+	if request.LevelWeights != nil {
+		for level, weight := range *request.LevelWeights {
+			if !issue.IsIssueSeverity(level) && !suggestion.IsSuggestionImportance(level) {
+				return kit.HTTPErrInvalidRequest
+			}
+
+			if weight < 0 || weight > priority.PRIORITY_MAX_WEIGHT {
+				return kit.HTTPErrInvalidRequest
+			}
+		}
+
+		formula.Preset = priority.PriorityPresetCustom
+		formula.LevelWeights = *request.LevelWeights
+	}
+
+	if request.SourceWeights != nil {
+		for source, weight := range *request.SourceWeights {
+			if !feedback.IsFeedbackSource(source) {
+				return kit.HTTPErrInvalidRequest
+			}
+
+			if weight < 0 || weight > priority.PRIORITY_MAX_WEIGHT {
+				return kit.HTTPErrInvalidRequest
+			}
+		}
+
+		formula.Preset = priority.PriorityPresetCustom
+		formula.SourceWeights = *request.SourceWeights
+	}
+
+	if request.CustomerExponent != nil {
+		if *request.CustomerExponent <= 0 || *request.CustomerExponent > priority.PRIORITY_MAX_CUSTOMER_EXPONENT {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		formula.Preset = priority.PriorityPresetCustom
+		formula.CustomerExponent = *request.CustomerExponent
+	}
+
+	if request.RecencyHalfLife != nil {
+		recencyHalfLife := time.Duration(*request.RecencyHalfLife) * time.Second
+		if recencyHalfLife < 0 || recencyHalfLife > priority.PRIORITY_MAX_RECENCY_HALF_LIFE {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		formula.Preset = priority.PriorityPresetCustom
+		formula.RecencyHalfLife = recencyHalfLife
+	}
+
+	if request.TrendWindow != nil {
+		trendWindow := time.Duration(*request.TrendWindow) * time.Second
+		if trendWindow < priority.PRIORITY_MIN_TREND_WINDOW || trendWindow > priority.PRIORITY_MAX_TREND_WINDOW {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		formula.Preset = priority.PriorityPresetCustom
+		formula.TrendWindow = trendWindow
+	}
+
+	if request.TrendWeight != nil {
+		if *request.TrendWeight < 0 || *request.TrendWeight > priority.PRIORITY_MAX_WEIGHT {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		formula.Preset = priority.PriorityPresetCustom
+		formula.TrendWeight = *request.TrendWeight
+	}
+
+	if request.RevenueWeight != nil {
+		if *request.RevenueWeight < 0 || *request.RevenueWeight > priority.PRIORITY_MAX_WEIGHT {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		formula.Preset = priority.PriorityPresetCustom
+		formula.RevenueWeight = *request.RevenueWeight
+	}
+
+	if formula.TrendWeight > 0 && formula.TrendWindow == 0 {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	err = self.prioritizer.Update(requestCtx, *requestProduct, *formula)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := PrioritizationEndpointsPutPriorityResponse{}
+	response.PriorityFormulaPayload = *priority.NewPriorityFormulaPayload(*formula)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
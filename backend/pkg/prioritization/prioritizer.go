@@ -0,0 +1,300 @@
+package prioritization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/aggregator"
+	"backend/pkg/config"
+	"backend/pkg/issue"
+	"backend/pkg/outbox"
+	"backend/pkg/priority"
+	"backend/pkg/product"
+	"backend/pkg/suggestion"
+	"backend/pkg/util"
+)
+
+const (
+	PrioritizerPrioritize = "prioritization:prioritize"
+	PrioritizerSchedule   = "prioritization:schedule-prioritize"
+)
+
+var (
+	ErrPrioritizerGeneric = errors.New("prioritizer failed")
+)
+
+type Prioritizer struct {
+	config               config.Config
+	observer             *kit.Observer
+	database             *kit.Database
+	productRepository    *product.ProductRepository
+	issueRepository      *issue.IssueRepository
+	suggestionRepository *suggestion.SuggestionRepository
+	enqueuer             *outbox.OutboxEnqueuer
+	cache                *kit.Cache
+}
+
+func NewPrioritizer(observer *kit.Observer, database *kit.Database, productRepository *product.ProductRepository,
+	issueRepository *issue.IssueRepository, suggestionRepository *suggestion.SuggestionRepository,
+	enqueuer *outbox.OutboxEnqueuer, cache *kit.Cache, config config.Config) *Prioritizer {
+	return &Prioritizer{
+		config:               config,
+		observer:             observer,
+		database:             database,
+		productRepository:    productRepository,
+		issueRepository:      issueRepository,
+		suggestionRepository: suggestionRepository,
+		enqueuer:             enqueuer,
+		cache:                cache,
+	}
+}
+
+// Update stores the priority formula of a product and enqueues the recomputation of its priorities, which is only
+// enqueued if the formula is stored.
+func (self *Prioritizer) Update(ctx context.Context, product product.Product,
+	formula priority.PriorityFormula) error {
+	product.Settings.Priority = &formula
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := self.productRepository.UpdateSettings(ctx, product)
+		if err != nil {
+			return err
+		}
+
+		// Not unique, as a recomputation already running could have read the previous formula
+		return self.enqueuer.Enqueue(ctx, PrioritizerPrioritize, PrioritizerPrioritizeParams{
+			ProductID: product.ID,
+		}, asynq.MaxRetry(2))
+	})
+	if err != nil {
+		return ErrPrioritizerGeneric.Raise().Cause(err)
+	}
+
+	return nil
+}
+
+// Run recomputes the priorities of all the issues and suggestions of a product with its current formula.
+// Each kind is recomputed while holding its aggregator lock, so no aggregation or consolidation scores them with
+// a different formula meanwhile and the relevance ordering stays consistent.
+func (self *Prioritizer) Run(ctx context.Context, product product.Product) (int, error) {
+	issues, err := self.prioritizeIssues(ctx, product.ID)
+	if err != nil {
+		return 0, ErrPrioritizerGeneric.Raise().Cause(err)
+	}
+
+	suggestions, err := self.prioritizeSuggestions(ctx, product.ID)
+	if err != nil {
+		return issues, ErrPrioritizerGeneric.Raise().Cause(err)
+	}
+
+	for _, prefix := range []string{issue.ISSUE_ENDPOINTS_SEARCH_KEY, suggestion.SUGGESTION_ENDPOINTS_SEARCH_KEY} {
+		err := util.InvalidateCache(ctx, self.cache, prefix+product.ID)
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	self.observer.Infof(ctx, "Reprioritized %d issues and %d suggestions of product %s",
+		issues, suggestions, product.ID)
+
+	return issues + suggestions, nil
+}
+
+func (self *Prioritizer) prioritizeIssues(ctx context.Context, productID string) (int, error) {
+	changed := 0
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := util.LockTransaction(ctx, self.database, fmt.Sprintf(aggregator.ISSUE_AGGREGATOR_LOCK_KEY, productID))
+		if err != nil {
+			return err
+		}
+
+		product, err := self.productRepository.GetByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		if product == nil {
+			return nil
+		}
+
+		issues, err := self.issueRepository.ListPrioritizableByProductID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		formula := product.PriorityFormula()
+		now := time.Now()
+
+		trends := map[string]priority.PriorityTrend{}
+		if formula.Trending() {
+			trends, err = self.issueRepository.ListTrendsByProductID(ctx, productID, formula.TrendWindow, now)
+			if err != nil {
+				return err
+			}
+		}
+
+		priorities := map[string]int{}
+		for _, _issue := range issues {
+			var trend *priority.PriorityTrend
+			if formula.Trending() {
+				_trend := trends[_issue.ID]
+				trend = &_trend
+			}
+
+			_priority := issue.ComputePriority(formula, _issue, trend)
+			if _priority != _issue.Priority {
+				priorities[_issue.ID] = _priority
+			}
+		}
+
+		err = self.issueRepository.UpdatePriorities(ctx, priorities)
+		if err != nil {
+			return err
+		}
+
+		changed = len(priorities)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return changed, nil
+}
+
+func (self *Prioritizer) prioritizeSuggestions(ctx context.Context, productID string) (int, error) {
+	changed := 0
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := util.LockTransaction(ctx, self.database,
+			fmt.Sprintf(aggregator.SUGGESTION_AGGREGATOR_LOCK_KEY, productID))
+		if err != nil {
+			return err
+		}
+
+		product, err := self.productRepository.GetByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		if product == nil {
+			return nil
+		}
+
+		suggestions, err := self.suggestionRepository.ListPrioritizableByProductID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		formula := product.PriorityFormula()
+		now := time.Now()
+
+		trends := map[string]priority.PriorityTrend{}
+		if formula.Trending() {
+			trends, err = self.suggestionRepository.ListTrendsByProductID(ctx, productID, formula.TrendWindow, now)
+			if err != nil {
+				return err
+			}
+		}
+
+		priorities := map[string]int{}
+		for _, _suggestion := range suggestions {
+			var trend *priority.PriorityTrend
+			if formula.Trending() {
+				_trend := trends[_suggestion.ID]
+				trend = &_trend
+			}
+
+			_priority := suggestion.ComputePriority(formula, _suggestion, trend)
+			if _priority != _suggestion.Priority {
+				priorities[_suggestion.ID] = _priority
+			}
+		}
+
+		err = self.suggestionRepository.UpdatePriorities(ctx, priorities)
+		if err != nil {
+			return err
+		}
+
+		changed = len(priorities)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return changed, nil
+}
+
+type PrioritizerPrioritizeParams struct {
+	ProductID string
+}
+
+func (self *Prioritizer) Prioritize(ctx context.Context, task *asynq.Task) error {
+	params := PrioritizerPrioritizeParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	product, err := self.productRepository.GetByID(ctx, params.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	if product.DeletedAt != nil {
+		return nil
+	}
+
+	_, err = self.Run(ctx, *product)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Schedule recomputes the priorities of the products whose formula changes them as time goes by, such as the ones
+// decaying with recency or boosted by trends, even if no new feedback is aggregated.
+func (self *Prioritizer) Schedule(ctx context.Context, _ *asynq.Task) error {
+	ids, err := self.productRepository.ListIDsByNotDeleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		product, err := self.productRepository.GetByID(ctx, id)
+		if err != nil {
+			self.observer.Error(ctx, err)
+			continue
+		}
+
+		if product == nil || !product.PriorityFormula().Drifts() {
+			continue
+		}
+
+		err = self.enqueuer.Enqueue(ctx, PrioritizerPrioritize, PrioritizerPrioritizeParams{
+			ProductID: id,
+		}, asynq.MaxRetry(2), asynq.Unique(24*time.Hour))
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package priority
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	PRIORITY_MAX_WEIGHT            = 100
+	PRIORITY_MAX_CUSTOMER_EXPONENT = 2
+	PRIORITY_MAX_RECENCY_HALF_LIFE = 365 * 24 * time.Hour
+	PRIORITY_MIN_TREND_WINDOW      = 24 * time.Hour
+	PRIORITY_MAX_TREND_WINDOW      = 90 * 24 * time.Hour
+	// Scores are stored as integers scaled by this factor, so decayed scores below one are still ordered
+	PRIORITY_SCALE = 1000
+)
+
+const (
+	// Level weight times customers, the original formula
+	PriorityPresetVolume = "VOLUME"
+	// Volume halved every two weeks without new feedbacks
+	PriorityPresetRecent = "RECENT"
+	// Recent boosted by the growth of feedbacks week over week
+	PriorityPresetTrending = "TRENDING"
+	// Volume boosted by the annual revenue of the customers reporting
+	PriorityPresetRevenue = "REVENUE"
+	// Volume with tuned weights
+	PriorityPresetCustom = "CUSTOM"
+)
+
+func IsPriorityPreset(value string) bool {
+	return value == PriorityPresetVolume ||
+		value == PriorityPresetRecent ||
+		value == PriorityPresetTrending ||
+		value == PriorityPresetRevenue ||
+		value == PriorityPresetCustom
+}
+
+// PriorityFormula scores issues and suggestions, so they can be sorted by relevance. The level is the major
+// severity of an issue or the major importance of a suggestion.
+type PriorityFormula struct {
+	Preset string
+	// Overrides the default weight of each level
+	LevelWeights map[string]float64
+	// Multiplies the customers coming from each source, 1 if not set
+	SourceWeights map[string]float64
+	// Below 1 each additional customer counts less, above 1 it counts more
+	CustomerExponent float64
+	// Time without new feedbacks after which the priority is halved, no decay if 0
+	RecencyHalfLife time.Duration
+	// Period whose feedbacks are compared with the ones of the previous period to measure the growth
+	TrendWindow time.Duration
+	// How much the growth boosts the priority, no boost if 0
+	TrendWeight float64
+	// How much each order of magnitude of the revenue at risk boosts the priority, no boost if 0
+	RevenueWeight float64
+}
+
+func NewPriorityFormula(preset string) *PriorityFormula {
+	formula := &PriorityFormula{
+		Preset:           preset,
+		LevelWeights:     map[string]float64{},
+		SourceWeights:    map[string]float64{},
+		CustomerExponent: 1,
+		RecencyHalfLife:  0,
+		TrendWindow:      0,
+		TrendWeight:      0,
+		RevenueWeight:    0,
+	}
+
+	switch preset {
+	case PriorityPresetRecent:
+		formula.RecencyHalfLife = 14 * 24 * time.Hour
+	case PriorityPresetTrending:
+		formula.RecencyHalfLife = 14 * 24 * time.Hour
+		formula.TrendWindow = 7 * 24 * time.Hour
+		formula.TrendWeight = 1
+	case PriorityPresetRevenue:
+		formula.RevenueWeight = 1
+	}
+
+	return formula
+}
+
+// Trending tells whether the formula needs the trend of feedbacks.
+func (self PriorityFormula) Trending() bool {
+	return self.TrendWeight > 0 && self.TrendWindow > 0
+}
+
+// Drifts tells whether the priorities change with time alone, so they have to be recomputed periodically.
+func (self PriorityFormula) Drifts() bool {
+	return self.RecencyHalfLife > 0 || self.Trending()
+}
+
+type PriorityTrend struct {
+	// Feedbacks posted within the trend window
+	Recent int
+	// Feedbacks posted within the window before
+	Previous int
+}
+
+// Count adds a feedback posted at the given time to the trend.
+func (self *PriorityTrend) Count(postedAt time.Time, window time.Duration, now time.Time) {
+	if postedAt.After(now) || !postedAt.After(now.Add(-2*window)) {
+		return
+	}
+
+	if postedAt.After(now.Add(-window)) {
+		self.Recent++
+	} else {
+		self.Previous++
+	}
+}
+
+type PriorityFactors struct {
+	Level       string
+	LevelWeight float64
+	Sources     map[string]int
+	Customers   int
+	LastSeenAt  time.Time
+	Trend       *PriorityTrend
+	// Annual revenue of the customers reporting
+	Revenue int
+}
+
+func (self PriorityFormula) Compute(factors PriorityFactors, now time.Time) int {
+	weight := factors.LevelWeight
+	if override, ok := self.LevelWeights[factors.Level]; ok {
+		weight = override
+	}
+
+	exponent := self.CustomerExponent
+	if exponent <= 0 {
+		exponent = 1
+	}
+
+	score := weight * math.Pow(float64(factors.Customers), exponent)
+
+	if len(self.SourceWeights) > 0 {
+		total := 0
+		weighted := 0.0
+		for source, count := range factors.Sources {
+			sourceWeight, ok := self.SourceWeights[source]
+			if !ok {
+				sourceWeight = 1
+			}
+
+			total += count
+			weighted += sourceWeight * float64(count)
+		}
+
+		if total > 0 {
+			score *= weighted / float64(total)
+		}
+	}
+
+	if self.RecencyHalfLife > 0 {
+		age := now.Sub(factors.LastSeenAt)
+		if age > 0 {
+			score *= math.Pow(0.5, age.Hours()/self.RecencyHalfLife.Hours())
+		}
+	}
+
+	if self.Trending() && factors.Trend != nil {
+		// A period without feedbacks counts as one, so a single feedback is not an infinite growth
+		growth := float64(factors.Trend.Recent-factors.Trend.Previous) / math.Max(float64(factors.Trend.Previous), 1)
+		if growth > 0 {
+			score *= 1 + self.TrendWeight*growth
+		}
+	}
+
+	if self.RevenueWeight > 0 && factors.Revenue > 0 {
+		// Logarithmic, so a single big account does not outweigh every other customer
+		score *= 1 + self.RevenueWeight*math.Log10(1+float64(factors.Revenue))
+	}
+
+	return int(math.Round(score * PRIORITY_SCALE))
+}
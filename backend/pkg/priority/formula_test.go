@@ -0,0 +1,127 @@
+package priority_test
+
+import (
+	"testing"
+	"time"
+
+	"backend/pkg/priority"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PriorityFormulaTestSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+func (self *PriorityFormulaTestSuite) SetupTest() {
+	self.now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPriorityFormulaSuite(t *testing.T) {
+	suite.Run(t, new(PriorityFormulaTestSuite))
+}
+
+func (self *PriorityFormulaTestSuite) TestVolumeMatchesOriginalFormula() {
+	// Given: The volume preset and an old critical issue
+	formula := priority.NewPriorityFormula(priority.PriorityPresetVolume)
+	factors := priority.PriorityFactors{
+		Level:       "CRITICAL",
+		LevelWeight: 4,
+		Sources:     map[string]int{"APP_STORE": 2, "IAGORA": 5},
+		Customers:   7,
+		LastSeenAt:  self.now.Add(-365 * 24 * time.Hour),
+		Trend:       &priority.PriorityTrend{Recent: 10, Previous: 1},
+	}
+
+	// When: The priority is computed
+	result := formula.Compute(factors, self.now)
+
+	// Then: The priority is the level weight times the customers
+	self.Equal(28*priority.PRIORITY_SCALE, result)
+}
+
+func (self *PriorityFormulaTestSuite) TestWeightsAndDecay() {
+	// Given: A formula with tuned weights that halves every week
+	formula := priority.NewPriorityFormula(priority.PriorityPresetCustom)
+	formula.LevelWeights = map[string]float64{"HIGH": 10}
+	formula.SourceWeights = map[string]float64{"APP_STORE": 3}
+	formula.RecencyHalfLife = 7 * 24 * time.Hour
+	factors := priority.PriorityFactors{
+		Level:       "HIGH",
+		LevelWeight: 3,
+		Sources:     map[string]int{"APP_STORE": 2, "IAGORA": 2},
+		Customers:   4,
+		LastSeenAt:  self.now.Add(-14 * 24 * time.Hour),
+		Trend:       nil,
+	}
+
+	// When: The priority is computed
+	result := formula.Compute(factors, self.now)
+
+	// Then: The overridden level weight, the weighted customers and two half-lives are applied
+	self.Equal(20*priority.PRIORITY_SCALE, result) // 10 * 4 * (3*2 + 1*2) / 4 * 0.25
+}
+
+func (self *PriorityFormulaTestSuite) TestTrendBoost() {
+	// Given: The trending preset and an issue whose feedbacks tripled since the previous week
+	formula := priority.NewPriorityFormula(priority.PriorityPresetTrending)
+	factors := priority.PriorityFactors{
+		Level:       "LOW",
+		LevelWeight: 1,
+		Sources:     map[string]int{},
+		Customers:   10,
+		LastSeenAt:  self.now,
+		Trend:       &priority.PriorityTrend{Recent: 6, Previous: 2},
+	}
+
+	// When: The priority is computed
+	result := formula.Compute(factors, self.now)
+
+	// Then: The growth of feedbacks boosts the priority
+	self.Equal(30*priority.PRIORITY_SCALE, result) // 1 * 10 * (1 + (6-2)/2)
+}
+
+func (self *PriorityFormulaTestSuite) TestRevenueBoost() {
+	// Given: The revenue preset and an issue reported by customers worth almost 100k a year
+	formula := priority.NewPriorityFormula(priority.PriorityPresetRevenue)
+	factors := priority.PriorityFactors{
+		Level:       "MEDIUM",
+		LevelWeight: 3,
+		Sources:     map[string]int{},
+		Customers:   2,
+		LastSeenAt:  self.now,
+		Trend:       nil,
+		Revenue:     99999,
+	}
+
+	// When: The priority is computed
+	result := formula.Compute(factors, self.now)
+
+	// Then: Every order of magnitude of the revenue boosts the priority
+	self.Equal(36*priority.PRIORITY_SCALE, result) // 3 * 2 * (1 + log10(100000))
+}
+
+func (self *PriorityFormulaTestSuite) TestDecayOrdersLowScores() {
+	// Given: The recent preset and two issues reported by one customer, one seen today and one a week ago
+	formula := priority.NewPriorityFormula(priority.PriorityPresetRecent)
+	factors := priority.PriorityFactors{
+		Level:       "LOW",
+		LevelWeight: 1,
+		Sources:     map[string]int{},
+		Customers:   1,
+		LastSeenAt:  self.now,
+		Trend:       nil,
+	}
+	stale := factors
+	stale.LastSeenAt = self.now.Add(-7 * 24 * time.Hour)
+
+	// When: The priorities are computed
+	result := formula.Compute(factors, self.now)
+	staleResult := formula.Compute(stale, self.now)
+
+	// Then: The decay orders them even if both scores round to one
+	self.Equal(1*priority.PRIORITY_SCALE, result)
+	self.Equal(707, staleResult) // 1 * 1 * 0.5^(7/14) * 1000
+	self.Greater(result, staleResult)
+}
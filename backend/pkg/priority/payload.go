@@ -0,0 +1,29 @@
+package priority
+
+import (
+	"time"
+)
+
+type PriorityFormulaPayload struct {
+	Preset           string             `json:"preset"`
+	LevelWeights     map[string]float64 `json:"level_weights"`
+	SourceWeights    map[string]float64 `json:"source_weights"`
+	CustomerExponent float64            `json:"customer_exponent"`
+	RecencyHalfLife  int                `json:"recency_half_life"`
+	TrendWindow      int                `json:"trend_window"`
+	TrendWeight      float64            `json:"trend_weight"`
+	RevenueWeight    float64            `json:"revenue_weight"`
+}
+
+func NewPriorityFormulaPayload(formula PriorityFormula) *PriorityFormulaPayload {
+	return &PriorityFormulaPayload{
+		Preset:           formula.Preset,
+		LevelWeights:     formula.LevelWeights,
+		SourceWeights:    formula.SourceWeights,
+		CustomerExponent: formula.CustomerExponent,
+		RecencyHalfLife:  int(formula.RecencyHalfLife / time.Second),
+		TrendWindow:      int(formula.TrendWindow / time.Second),
+		TrendWeight:      formula.TrendWeight,
+		RevenueWeight:    formula.RevenueWeight,
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"backend/pkg/feedback"
 	"backend/pkg/issue"
 	"backend/pkg/organization"
+	"backend/pkg/outbox"
 	"backend/pkg/product"
 	"backend/pkg/review"
 	"backend/pkg/suggestion"
@@ -38,7 +39,7 @@ type FeedbackProcessor struct {
 	reviewRepository            *review.ReviewRepository
 	productRepository           *product.ProductRepository
 	organizationRepository      organization.OrganizationRepository
-	enqueuer                    *kit.Enqueuer
+	enqueuer                    *outbox.OutboxEnqueuer
 	engineService               *engine.EngineService
 	engineBreaker               *engine.EngineBreaker
 }
@@ -47,7 +48,7 @@ func NewFeedbackProcessor(observer *kit.Observer, database *kit.Database,
 	feedbackRepository *feedback.FeedbackRepository, partialIssueRepository *issue.PartialIssueRepository,
 	partialSuggestionRepository *suggestion.PartialSuggestionRepository,
 	reviewRepository *review.ReviewRepository, productRepository *product.ProductRepository,
-	organizationRepository organization.OrganizationRepository, enqueuer *kit.Enqueuer,
+	organizationRepository organization.OrganizationRepository, enqueuer *outbox.OutboxEnqueuer,
 	engineService *engine.EngineService, engineBreaker *engine.EngineBreaker,
 	config config.Config) *FeedbackProcessor {
 	return &FeedbackProcessor{
@@ -70,11 +71,66 @@ type FeedbackProcessorProcessParams struct {
 	FeedbackID string
 }
 
-func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) error {
-	if self.engineBreaker.IsOpen(ctx) {
-		return nil
+// newEngineCategories describes the taxonomy to the engine, where each subcategory is an option on its own.
+func newEngineCategories(taxonomy []product.ProductCategory) []engine.Category {
+	categories := []engine.Category{}
+
+	for _, category := range taxonomy {
+		categories = append(categories, engine.Category{
+			Key:         category.Name,
+			Description: category.Description,
+			Examples:    category.Examples,
+		})
+
+		for _, subcategory := range category.Subcategories {
+			categories = append(categories, engine.Category{
+				Key:         category.Name + product.PRODUCT_CATEGORY_SEPARATOR + subcategory.Name,
+				Description: subcategory.Description,
+				Examples:    subcategory.Examples,
+			})
+		}
+	}
+
+	return categories
+}
+
+func newEngineAttributes(attributes []product.ProductAttribute) []engine.Attribute {
+	engineAttributes := make([]engine.Attribute, 0, len(attributes))
+
+	for _, attribute := range attributes {
+		engineAttributes = append(engineAttributes, engine.Attribute{
+			Name:        attribute.Name,
+			Type:        attribute.Type,
+			Values:      attribute.Values,
+			Description: attribute.Description,
+		})
+	}
+
+	return engineAttributes
+}
+
+// newReviewAttributes keeps the extracted values that fit the current definition of their attribute.
+func newReviewAttributes(attributes []product.ProductAttribute, values map[string]string) map[string]string {
+	reviewAttributes := map[string]string{}
+
+	for _, attribute := range attributes {
+		value, ok := values[attribute.Name]
+		if !ok {
+			continue
+		}
+
+		value, ok = attribute.Parse(value)
+		if !ok {
+			continue
+		}
+
+		reviewAttributes[attribute.Name] = value
 	}
 
+	return reviewAttributes
+}
+
+func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) error {
 	params := FeedbackProcessorProcessParams{}
 
 	err := json.Unmarshal(task.Payload(), &params)
@@ -83,6 +139,13 @@ func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) er
 		return nil
 	}
 
+	allowed, delay := self.engineBreaker.Allow(ctx, engine.ENGINE_BREAKER_OPERATION_EXTRACTION,
+		engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
+	if !allowed {
+		return self.enqueuer.Enqueue(ctx, FeedbackProcessorProcess, params,
+			asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.ProcessIn(delay))
+	}
+
 	feedback, err := self.feedbackRepository.GetByID(ctx, params.FeedbackID)
 	if err != nil {
 		return err
@@ -92,7 +155,7 @@ func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) er
 		return nil
 	}
 
-	if feedback.ProcessedAt != nil {
+	if feedback.ProcessedAt != nil || feedback.SkippedAt != nil {
 		return nil
 	}
 
@@ -135,6 +198,8 @@ func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) er
 		return nil
 	}
 
+	categories := newEngineCategories(product.Taxonomy)
+
 	var group sync.WaitGroup
 
 	var eiResult *engine.EngineServiceExtractIssuesResult
@@ -144,13 +209,14 @@ func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) er
 		defer group.Done()
 		eiResult, eiErr = self.engineService.ExtractIssues(ctx, engine.EngineServiceExtractIssuesParams{
 			Context:    product.Context,
-			Categories: product.Categories,
+			Categories: categories,
 			Feedback: engine.Feedback{
 				Content: content,
 			},
+			Language: product.Language,
 		})
 		if eiErr != nil && engine.ErrEngineServiceTimedOut.Is(eiErr) {
-			err := self.engineBreaker.Open(ctx)
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_EXTRACTION)
 			if err != nil {
 				self.observer.Error(ctx, err)
 			}
@@ -164,13 +230,14 @@ func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) er
 		defer group.Done()
 		esResult, esErr = self.engineService.ExtractSuggestions(ctx, engine.EngineServiceExtractSuggestionsParams{
 			Context:    product.Context,
-			Categories: product.Categories,
+			Categories: categories,
 			Feedback: engine.Feedback{
 				Content: content,
 			},
+			Language: product.Language,
 		})
 		if esErr != nil && engine.ErrEngineServiceTimedOut.Is(esErr) {
-			err := self.engineBreaker.Open(ctx)
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_EXTRACTION)
 			if err != nil {
 				self.observer.Error(ctx, err)
 			}
@@ -184,13 +251,33 @@ func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) er
 		defer group.Done()
 		erResult, erErr = self.engineService.ExtractReview(ctx, engine.EngineServiceExtractReviewParams{
 			Context:    product.Context,
-			Categories: product.Categories,
+			Categories: categories,
+			Attributes: newEngineAttributes(product.Attributes),
 			Feedback: engine.Feedback{
 				Content: content,
 			},
+			Language: product.Language,
 		})
 		if erErr != nil && engine.ErrEngineServiceTimedOut.Is(erErr) {
-			err := self.engineBreaker.Open(ctx)
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_EXTRACTION)
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+	}()
+
+	// The feedback is embedded so it can be retrieved when chatting with the customers
+	var ceResult *engine.EngineServiceComputeEmbeddingResult
+	var ceErr error
+	group.Add(1)
+	go func() {
+		defer group.Done()
+		ceResult, ceErr = self.engineService.ComputeEmbedding(ctx, engine.EngineServiceComputeEmbeddingParams{
+			Text:  content,
+			Model: product.EmbeddingModel,
+		})
+		if ceErr != nil && engine.ErrEngineServiceTimedOut.Is(ceErr) {
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
 			if err != nil {
 				self.observer.Error(ctx, err)
 			}
@@ -205,6 +292,16 @@ func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) er
 		return esErr
 	} else if erErr != nil {
 		return erErr
+	} else if ceErr != nil {
+		return ceErr
+	}
+
+	for _, operation := range []string{engine.ENGINE_BREAKER_OPERATION_EXTRACTION,
+		engine.ENGINE_BREAKER_OPERATION_EMBEDDING} {
+		err := self.engineBreaker.Succeed(ctx, operation)
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
 	}
 
 	now := time.Now()
@@ -239,8 +336,30 @@ func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) er
 		suggestions = append(suggestions, *suggestion)
 	}
 
+	reviewID := xid.New().String()
+
+	aspects := []review.ReviewAspect{}
+	for _, resAspect := range erResult.Review.Aspects {
+		if len(resAspect.Aspect) == 0 || len(resAspect.Aspect) > review.REVIEW_MAX_ASPECT_LENGTH ||
+			!review.IsReviewSentiment(resAspect.Sentiment) {
+			continue
+		}
+
+		aspect := review.NewReviewAspect()
+		aspect.ID = xid.New().String()
+		aspect.ReviewID = reviewID
+		aspect.ProductID = product.ID
+		aspect.Aspect = resAspect.Aspect
+		aspect.Sentiment = resAspect.Sentiment
+		aspect.Quote = resAspect.Quote
+		aspect.Locate(feedback.Content)
+		aspect.CreatedAt = now
+
+		aspects = append(aspects, *aspect)
+	}
+
 	review := review.NewReview()
-	review.ID = xid.New().String()
+	review.ID = reviewID
 	review.ProductID = product.ID
 	review.Feedback = *feedback
 	review.Keywords = erResult.Review.Keywords
@@ -248,17 +367,25 @@ func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) er
 	review.Emotions = erResult.Review.Emotions
 	review.Intention = erResult.Review.Intention
 	review.Category = erResult.Review.Category
+	review.Attributes = newReviewAttributes(product.Attributes, erResult.Review.Attributes)
+	review.Aspects = aspects
 	review.Quality = nil
 	review.CreatedAt = now
 	review.ExportedAt = nil
 
 	tokens := eiResult.Usage.Input + eiResult.Usage.Output +
 		esResult.Usage.Input + esResult.Usage.Output +
-		erResult.Usage.Input + erResult.Usage.Output
+		erResult.Usage.Input + erResult.Usage.Output +
+		ceResult.Usage.Input + ceResult.Usage.Output
 
 	feedback.Tokens += tokens
 	feedback.ProcessedAt = kitUtil.Pointer(time.Now())
 
+	// There is nothing to aggregate so the feedback has gone through the whole pipeline
+	if len(issues) == 0 && len(suggestions) == 0 {
+		feedback.AggregatedAt = feedback.ProcessedAt
+	}
+
 	err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
 		if len(issues) > 0 {
 			err := self.partialIssueRepository.BulkCreate(ctx, issues)
@@ -284,30 +411,39 @@ func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) er
 			return err
 		}
 
+		err = self.feedbackRepository.UpsertEmbedding(ctx, *feedback, ceResult.Embedding, ceResult.Model)
+		if err != nil {
+			return err
+		}
+
+		for _, partial := range issues {
+			err := self.enqueuer.Enqueue(ctx, aggregator.IssueAggregatorAggregate,
+				aggregator.IssueAggregatorAggregateParams{
+					PartialID: partial.ID,
+				}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.Unique(12*time.Hour))
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, partial := range suggestions {
+			err := self.enqueuer.Enqueue(ctx, aggregator.SuggestionAggregatorAggregate,
+				aggregator.SuggestionAggregatorAggregateParams{
+					PartialID: partial.ID,
+				}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.Unique(12*time.Hour))
+			if err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 
-	for _, partial := range issues {
-		err := self.enqueuer.Enqueue(ctx, aggregator.IssueAggregatorAggregate,
-			aggregator.IssueAggregatorAggregateParams{
-				PartialID: partial.ID,
-			}, asynq.MaxRetry(2), asynq.Unique(12*time.Hour))
-		if err != nil {
-			self.observer.Error(ctx, err)
-		}
-	}
-
-	for _, partial := range suggestions {
-		err := self.enqueuer.Enqueue(ctx, aggregator.SuggestionAggregatorAggregate,
-			aggregator.SuggestionAggregatorAggregateParams{
-				PartialID: partial.ID,
-			}, asynq.MaxRetry(2), asynq.Unique(12*time.Hour))
-		if err != nil {
-			self.observer.Error(ctx, err)
-		}
+	if feedback.TranslatedAt != nil {
+		util.PipelineObserve(ctx, self.observer, util.PipelineStageProcessing, *feedback.TranslatedAt)
 	}
 
 	self.observer.Infof(ctx, "Processed a feedback with %d issues, %d suggestions and 1 review using %d tokens",
@@ -317,13 +453,15 @@ func (self *FeedbackProcessor) Process(ctx context.Context, task *asynq.Task) er
 }
 
 func (self *FeedbackProcessor) Schedule(ctx context.Context, _ *asynq.Task) error {
+	before := util.PipelineSweepBefore(self.config)
+
 	pagination := util.Pagination[time.Time]{
 		Limit: 1000,
 		From:  nil,
 	}
 
 	for {
-		page, err := self.feedbackRepository.ListIDsByNotProcessed(ctx, pagination)
+		page, err := self.feedbackRepository.ListIDsByNotProcessed(ctx, before, pagination)
 		if err != nil {
 			return err
 		}
@@ -331,7 +469,7 @@ func (self *FeedbackProcessor) Schedule(ctx context.Context, _ *asynq.Task) erro
 		for _, id := range page.Items {
 			err := self.enqueuer.Enqueue(ctx, FeedbackProcessorProcess, FeedbackProcessorProcessParams{
 				FeedbackID: id,
-			}, asynq.MaxRetry(2), asynq.Unique(24*time.Hour))
+			}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.Unique(24*time.Hour))
 			if err != nil {
 				self.observer.Error(ctx, err)
 			}
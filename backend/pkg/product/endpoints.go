@@ -149,6 +149,10 @@ func (self *ProductEndpoints) PostProduct(ctx echo.Context) error {
 				return kit.HTTPErrInvalidRequest
 			}
 
+			if strings.Contains((*request.Categories)[i], PRODUCT_CATEGORY_SEPARATOR) {
+				return kit.HTTPErrInvalidRequest
+			}
+
 			(*request.Categories)[i] = strings.ToUpper(strings.ReplaceAll((*request.Categories)[i], " ", "_"))
 		}
 		*request.Categories = strset.New(*request.Categories...).List()
@@ -174,9 +178,11 @@ func (self *ProductEndpoints) PostProduct(ctx echo.Context) error {
 	product.Name = request.Name
 	product.Picture = *request.Picture
 	product.Language = request.Language
+	product.EmbeddingModel = engine.ENGINE_EMBEDDING_MODEL_DEFAULT
 	product.Context = *request.Context
-	product.Categories = *request.Categories
+	product.SetCategories(*request.Categories)
 	product.Release = *request.Release
+	product.Attributes = []ProductAttribute{}
 	product.Settings = ProductSettings{}
 	product.Usage = 0
 	product.CreatedAt = time.Now()
@@ -271,11 +277,15 @@ func (self *ProductEndpoints) PutProduct(ctx echo.Context) error {
 				return kit.HTTPErrInvalidRequest
 			}
 
+			if strings.Contains((*request.Categories)[i], PRODUCT_CATEGORY_SEPARATOR) {
+				return kit.HTTPErrInvalidRequest
+			}
+
 			(*request.Categories)[i] = strings.ToUpper(strings.ReplaceAll((*request.Categories)[i], " ", "_"))
 		}
 		*request.Categories = strset.New(*request.Categories...).List()
 
-		requestProduct.Categories = *request.Categories
+		requestProduct.SetCategories(*request.Categories)
 	}
 
 	if request.Release != nil {
@@ -355,6 +365,65 @@ func (self *ProductEndpoints) PutProductSettings(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, &response)
 }
 
+type ProductEndpointsGetProductAttributesResponse struct {
+	Attributes []ProductAttributePayload `json:"attributes"`
+}
+
+func (self *ProductEndpoints) GetProductAttributes(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := RequestProduct(requestCtx)
+
+	response := ProductEndpointsGetProductAttributesResponse{}
+	response.Attributes = NewProductPayload(*requestProduct).Attributes
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+// Only the reviews processed from now on are extracted with the new attributes, the previous ones keep their values.
+type ProductEndpointsPutProductAttributesRequest struct {
+	Attributes []ProductAttributePayload `json:"attributes"`
+}
+
+type ProductEndpointsPutProductAttributesResponse struct {
+	Attributes []ProductAttributePayload `json:"attributes"`
+}
+
+func (self *ProductEndpoints) PutProductAttributes(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := RequestProduct(requestCtx)
+	request := ProductEndpointsPutProductAttributesRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	attributes := make([]ProductAttribute, 0, len(request.Attributes))
+	for _, attribute := range request.Attributes {
+		attributes = append(attributes, ProductAttribute{
+			Name:        attribute.Name,
+			Type:        attribute.Type,
+			Values:      attribute.Values,
+			Description: attribute.Description,
+		})
+	}
+
+	requestProduct.Attributes, err = NewAttributes(attributes)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	err = self.productRepository.UpdateAttributes(requestCtx, *requestProduct)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := ProductEndpointsPutProductAttributesResponse{}
+	response.Attributes = NewProductPayload(*requestProduct).Attributes
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
 type ProductEndpointsGetProductUsageResponse struct {
 	Usage int `json:"usage"`
 }
@@ -2,21 +2,46 @@ package product
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/neoxelox/errors"
 	"github.com/neoxelox/kit/util"
+
+	"backend/pkg/priority"
 )
 
 const (
 	PRODUCT_DEFAULT_PICTURE    = "https://clank.so/images/pictures/product.png"
 	PRODUCT_MAX_CONTEXT_LENGTH = 2500
 	PRODUCT_MAX_CATEGORIES     = 25
+	PRODUCT_MAX_SUBCATEGORIES  = 10
+	// Keys are stored in columns of 50 characters
+	PRODUCT_MAX_CATEGORY_KEY_LENGTH         = 50
+	PRODUCT_MAX_CATEGORY_DESCRIPTION_LENGTH = 500
+	PRODUCT_MAX_CATEGORY_EXAMPLES           = 5
+	PRODUCT_MAX_CATEGORY_EXAMPLE_LENGTH     = 200
+	// Separates the category from the subcategory in the keys items are classified with
+	PRODUCT_CATEGORY_SEPARATOR = "/"
+	PRODUCT_MAX_ATTRIBUTES     = 10
+	// Attribute names are keys of the attributes of reviews, so they are restricted to a safe format
+	PRODUCT_MAX_ATTRIBUTE_NAME_LENGTH        = 50
+	PRODUCT_MAX_ATTRIBUTE_VALUES             = 25
+	PRODUCT_MAX_ATTRIBUTE_VALUE_LENGTH       = 100
+	PRODUCT_MAX_ATTRIBUTE_DESCRIPTION_LENGTH = 500
 )
 
 var (
-	PRODUCT_LANGUAGES_SUPPORTED = []string{"ENGLISH"}
+	PRODUCT_LANGUAGES_SUPPORTED = []string{"ENGLISH", "SPANISH", "GERMAN", "FRENCH", "ITALIAN", "PORTUGUESE"}
 	PRODUCT_DEFAULT_CONTEXT     = map[string]string{
-		"ENGLISH": "The product is called %s.",
+		"ENGLISH":    "The product is called %s.",
+		"SPANISH":    "El producto se llama %s.",
+		"GERMAN":     "Das Produkt heißt %s.",
+		"FRENCH":     "Le produit s'appelle %s.",
+		"ITALIAN":    "Il prodotto si chiama %s.",
+		"PORTUGUESE": "O produto chama-se %s.",
 	}
 )
 
@@ -30,7 +55,161 @@ func IsLanguageSupported(language string) bool {
 	return false
 }
 
+// ProductCategory is a node of the two level taxonomy of a product. The description and the example phrases help
+// the engine tell apart similar categories.
+type ProductCategory struct {
+	Name          string
+	Description   string
+	Examples      []string
+	Subcategories []ProductCategory
+}
+
+// TaxonomyKeys lists the keys items can be classified with, which are every category and every subcategory
+// prefixed by its category.
+func TaxonomyKeys(taxonomy []ProductCategory) []string {
+	keys := []string{}
+
+	for _, category := range taxonomy {
+		keys = append(keys, category.Name)
+		for _, subcategory := range category.Subcategories {
+			keys = append(keys, category.Name+PRODUCT_CATEGORY_SEPARATOR+subcategory.Name)
+		}
+	}
+
+	return keys
+}
+
+// NormalizeCategory turns a category name into the format the engine answers with.
+func NormalizeCategory(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+}
+
+const (
+	ProductAttributeTypeEnum   = "ENUM"
+	ProductAttributeTypeNumber = "NUMBER"
+	ProductAttributeTypeText   = "TEXT"
+)
+
+func IsProductAttributeType(value string) bool {
+	return value == ProductAttributeTypeEnum ||
+		value == ProductAttributeTypeNumber ||
+		value == ProductAttributeTypeText
+}
+
+var (
+	ErrProductAttributesInvalid = errors.New("product attributes are invalid")
+)
+
+var productAttributeNameRegex = regexp.MustCompile(`^[A-Z0-9_]+$`)
+
+// ProductAttribute is a custom property the engine extracts from every review of a product. Enums take one of the
+// allowed values, numbers a plain number and texts a short phrase.
+type ProductAttribute struct {
+	Name        string
+	Type        string
+	Values      []string
+	Description string
+}
+
+// Parse normalizes a value extracted for the attribute, which is discarded when it does not fit its type.
+func (self ProductAttribute) Parse(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return "", false
+	}
+
+	switch self.Type {
+	case ProductAttributeTypeEnum:
+		value = NormalizeCategory(value)
+		for _, allowed := range self.Values {
+			if value == allowed {
+				return value, true
+			}
+		}
+
+		return "", false
+
+	case ProductAttributeTypeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", false
+		}
+
+		return strconv.FormatFloat(number, 'f', -1, 64), true
+
+	case ProductAttributeTypeText:
+		if len(value) > PRODUCT_MAX_ATTRIBUTE_VALUE_LENGTH {
+			value = strings.TrimSpace(value[:PRODUCT_MAX_ATTRIBUTE_VALUE_LENGTH])
+		}
+
+		return value, true
+	}
+
+	return "", false
+}
+
+// NewAttributes normalizes the names and the allowed values of the attributes of a product and validates them.
+func NewAttributes(attributes []ProductAttribute) ([]ProductAttribute, error) {
+	if len(attributes) > PRODUCT_MAX_ATTRIBUTES {
+		return nil, ErrProductAttributesInvalid.Raise().With("too many attributes")
+	}
+
+	normalized := make([]ProductAttribute, 0, len(attributes))
+	names := map[string]bool{}
+	for _, attribute := range attributes {
+		attribute.Name = NormalizeCategory(attribute.Name)
+
+		if len(attribute.Name) == 0 || len(attribute.Name) > PRODUCT_MAX_ATTRIBUTE_NAME_LENGTH ||
+			!productAttributeNameRegex.MatchString(attribute.Name) {
+			return nil, ErrProductAttributesInvalid.Raise().With("attribute %s has an invalid name", attribute.Name)
+		}
+
+		if names[attribute.Name] {
+			return nil, ErrProductAttributesInvalid.Raise().With("attribute %s is duplicated", attribute.Name)
+		}
+		names[attribute.Name] = true
+
+		if !IsProductAttributeType(attribute.Type) {
+			return nil, ErrProductAttributesInvalid.Raise().With("attribute %s has an invalid type", attribute.Name)
+		}
+
+		attribute.Description = strings.TrimSpace(attribute.Description)
+		if len(attribute.Description) > PRODUCT_MAX_ATTRIBUTE_DESCRIPTION_LENGTH {
+			return nil, ErrProductAttributesInvalid.Raise().With("attribute %s has a too long description",
+				attribute.Name)
+		}
+
+		values := []string{}
+		if attribute.Type == ProductAttributeTypeEnum {
+			if len(attribute.Values) == 0 || len(attribute.Values) > PRODUCT_MAX_ATTRIBUTE_VALUES {
+				return nil, ErrProductAttributesInvalid.Raise().With("attribute %s has an invalid number of values",
+					attribute.Name)
+			}
+
+			seen := map[string]bool{}
+			for _, value := range attribute.Values {
+				value = NormalizeCategory(value)
+				if len(value) == 0 || len(value) > PRODUCT_MAX_ATTRIBUTE_VALUE_LENGTH || seen[value] {
+					return nil, ErrProductAttributesInvalid.Raise().With("attribute %s has an invalid value %s",
+						attribute.Name, value)
+				}
+				seen[value] = true
+
+				values = append(values, value)
+			}
+		} else if len(attribute.Values) > 0 {
+			return nil, ErrProductAttributesInvalid.Raise().With("attribute %s cannot have values", attribute.Name)
+		}
+		attribute.Values = values
+
+		normalized = append(normalized, attribute)
+	}
+
+	return normalized, nil
+}
+
 type ProductSettings struct {
+	Priority *priority.PriorityFormula
 }
 
 type Product struct {
@@ -39,8 +218,11 @@ type Product struct {
 	Name           string
 	Picture        string
 	Language       string
+	EmbeddingModel string
 	Context        string
 	Categories     []string
+	Taxonomy       []ProductCategory
+	Attributes     []ProductAttribute
 	Release        string
 	Settings       ProductSettings
 	Usage          int
@@ -63,3 +245,43 @@ func (self Product) Equals(other Product) bool {
 func (self Product) Copy() *Product {
 	return util.Copy(self)
 }
+
+// SetTaxonomy replaces the taxonomy of the product, keeping the categories in sync with its keys.
+func (self *Product) SetTaxonomy(taxonomy []ProductCategory) {
+	self.Taxonomy = taxonomy
+	self.Categories = TaxonomyKeys(taxonomy)
+}
+
+// SetCategories replaces the first level of the taxonomy, keeping the details of the categories that remain.
+func (self *Product) SetCategories(categories []string) {
+	current := make(map[string]ProductCategory, len(self.Taxonomy))
+	for _, category := range self.Taxonomy {
+		current[category.Name] = category
+	}
+
+	taxonomy := make([]ProductCategory, 0, len(categories))
+	for _, name := range categories {
+		category, ok := current[name]
+		if !ok {
+			category = ProductCategory{
+				Name:          name,
+				Description:   "",
+				Examples:      []string{},
+				Subcategories: []ProductCategory{},
+			}
+		}
+
+		taxonomy = append(taxonomy, category)
+	}
+
+	self.SetTaxonomy(taxonomy)
+}
+
+// PriorityFormula returns the formula the issues and suggestions of the product are scored with.
+func (self Product) PriorityFormula() priority.PriorityFormula {
+	if self.Settings.Priority == nil {
+		return *priority.NewPriorityFormula(priority.PriorityPresetVolume)
+	}
+
+	return *self.Settings.Priority
+}
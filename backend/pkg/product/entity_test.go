@@ -0,0 +1,85 @@
+package product_test
+
+import (
+	"testing"
+
+	"backend/pkg/product"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProductAttributeTestSuite struct {
+	suite.Suite
+}
+
+func TestProductAttributeSuite(t *testing.T) {
+	suite.Run(t, new(ProductAttributeTestSuite))
+}
+
+func (self *ProductAttributeTestSuite) TestNewAttributesNormalizes() {
+	// Given: Attributes written by hand
+	input := []product.ProductAttribute{
+		{Name: "device type", Type: "ENUM", Values: []string{"mobile", " desktop"}, Description: " Device used "},
+		{Name: "order value", Type: "NUMBER"},
+	}
+
+	// When: The attributes are validated
+	result, err := product.NewAttributes(input)
+
+	// Then: The names and the allowed values are in the format the engine answers with
+	self.Require().NoError(err)
+	self.Equal("DEVICE_TYPE", result[0].Name)
+	self.Equal([]string{"MOBILE", "DESKTOP"}, result[0].Values)
+	self.Equal("Device used", result[0].Description)
+	self.Equal([]string{}, result[1].Values)
+}
+
+func (self *ProductAttributeTestSuite) TestNewAttributesRejectsInvalid() {
+	// Given: Attributes with a duplicated name, an unknown type, an enum without values, a number with values and
+	// a name that cannot be filtered by
+	inputs := [][]product.ProductAttribute{
+		{{Name: "PLAN", Type: "TEXT"}, {Name: "plan", Type: "TEXT"}},
+		{{Name: "PLAN", Type: "BOOLEAN"}},
+		{{Name: "PLAN", Type: "ENUM"}},
+		{{Name: "SEATS", Type: "NUMBER", Values: []string{"1"}}},
+		{{Name: "PLAN:TIER", Type: "TEXT"}},
+	}
+
+	for _, input := range inputs {
+		// When: The attributes are validated
+		_, err := product.NewAttributes(input)
+
+		// Then: The attributes are invalid
+		self.True(product.ErrProductAttributesInvalid.Is(err))
+	}
+}
+
+func (self *ProductAttributeTestSuite) TestParse() {
+	// Given: An attribute of every type
+	enum := product.ProductAttribute{Name: "PLAN", Type: "ENUM", Values: []string{"FREE", "PRO"}}
+	number := product.ProductAttribute{Name: "SEATS", Type: "NUMBER"}
+	text := product.ProductAttribute{Name: "COMPETITOR", Type: "TEXT"}
+
+	// When: Extracted values are parsed
+	// Then: The values that fit are normalized and the rest are discarded
+	value, ok := enum.Parse(" pro ")
+	self.True(ok)
+	self.Equal("PRO", value)
+
+	_, ok = enum.Parse("ENTERPRISE")
+	self.False(ok)
+
+	value, ok = number.Parse("12.50")
+	self.True(ok)
+	self.Equal("12.5", value)
+
+	_, ok = number.Parse("a dozen")
+	self.False(ok)
+
+	value, ok = text.Parse(" Acme ")
+	self.True(ok)
+	self.Equal("Acme", value)
+
+	_, ok = text.Parse("  ")
+	self.False(ok)
+}
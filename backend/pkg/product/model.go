@@ -15,8 +15,11 @@ type ProductModel struct {
 	Name           string     `db:"name"`
 	Picture        string     `db:"picture"`
 	Language       string     `db:"language"`
+	EmbeddingModel string     `db:"embedding_model"`
 	Context        string     `db:"context"`
 	Categories     []string   `db:"categories"`
+	Taxonomy       []byte     `db:"taxonomy"`
+	Attributes     []byte     `db:"attributes"`
 	Release        string     `db:"release"`
 	Settings       []byte     `db:"settings"`
 	Usage          int        `db:"usage"`
@@ -25,6 +28,16 @@ type ProductModel struct {
 }
 
 func NewProductModel(product Product) *ProductModel {
+	taxonomy, err := json.Marshal(product.Taxonomy)
+	if err != nil {
+		panic(err)
+	}
+
+	attributes, err := json.Marshal(product.Attributes)
+	if err != nil {
+		panic(err)
+	}
+
 	settings, err := json.Marshal(product.Settings)
 	if err != nil {
 		panic(err)
@@ -36,8 +49,11 @@ func NewProductModel(product Product) *ProductModel {
 		Name:           product.Name,
 		Picture:        product.Picture,
 		Language:       product.Language,
+		EmbeddingModel: product.EmbeddingModel,
 		Context:        product.Context,
 		Categories:     product.Categories,
+		Taxonomy:       taxonomy,
+		Attributes:     attributes,
 		Release:        product.Release,
 		Settings:       settings,
 		Usage:          product.Usage,
@@ -47,8 +63,20 @@ func NewProductModel(product Product) *ProductModel {
 }
 
 func (self *ProductModel) ToEntity() *Product {
+	var taxonomy []ProductCategory
+	err := json.Unmarshal(self.Taxonomy, &taxonomy)
+	if err != nil {
+		panic(err)
+	}
+
+	var attributes []ProductAttribute
+	err = json.Unmarshal(self.Attributes, &attributes)
+	if err != nil {
+		panic(err)
+	}
+
 	var settings ProductSettings
-	err := json.Unmarshal(self.Settings, &settings)
+	err = json.Unmarshal(self.Settings, &settings)
 	if err != nil {
 		panic(err)
 	}
@@ -59,8 +87,11 @@ func (self *ProductModel) ToEntity() *Product {
 		Name:           self.Name,
 		Picture:        self.Picture,
 		Language:       self.Language,
+		EmbeddingModel: self.EmbeddingModel,
 		Context:        self.Context,
 		Categories:     self.Categories,
+		Taxonomy:       taxonomy,
+		Attributes:     attributes,
 		Release:        self.Release,
 		Settings:       settings,
 		Usage:          self.Usage,
@@ -1,22 +1,76 @@
 package product
 
+import (
+	"backend/pkg/priority"
+)
+
+type ProductCategoryPayload struct {
+	Name          string                   `json:"name"`
+	Description   string                   `json:"description"`
+	Examples      []string                 `json:"examples"`
+	Subcategories []ProductCategoryPayload `json:"subcategories"`
+}
+
+func NewProductCategoryPayload(category ProductCategory) *ProductCategoryPayload {
+	subcategories := make([]ProductCategoryPayload, 0, len(category.Subcategories))
+	for _, subcategory := range category.Subcategories {
+		subcategories = append(subcategories, *NewProductCategoryPayload(subcategory))
+	}
+
+	return &ProductCategoryPayload{
+		Name:          category.Name,
+		Description:   category.Description,
+		Examples:      category.Examples,
+		Subcategories: subcategories,
+	}
+}
+
+type ProductAttributePayload struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Values      []string `json:"values"`
+	Description string   `json:"description"`
+}
+
+func NewProductAttributePayload(attribute ProductAttribute) *ProductAttributePayload {
+	return &ProductAttributePayload{
+		Name:        attribute.Name,
+		Type:        attribute.Type,
+		Values:      attribute.Values,
+		Description: attribute.Description,
+	}
+}
+
 type ProductPayloadSettings struct {
+	Priority priority.PriorityFormulaPayload `json:"priority"`
 }
 
 type ProductPayload struct {
-	ID             string                 `json:"id"`
-	OrganizationID string                 `json:"organization_id"`
-	Name           string                 `json:"name"`
-	Picture        string                 `json:"picture"`
-	Language       string                 `json:"language"`
-	Context        string                 `json:"context"`
-	Categories     []string               `json:"categories"`
-	Release        string                 `json:"release"`
-	Settings       ProductPayloadSettings `json:"settings"`
-	Usage          int                    `json:"usage"`
+	ID             string                    `json:"id"`
+	OrganizationID string                    `json:"organization_id"`
+	Name           string                    `json:"name"`
+	Picture        string                    `json:"picture"`
+	Language       string                    `json:"language"`
+	Context        string                    `json:"context"`
+	Categories     []string                  `json:"categories"`
+	Taxonomy       []ProductCategoryPayload  `json:"taxonomy"`
+	Attributes     []ProductAttributePayload `json:"attributes"`
+	Release        string                    `json:"release"`
+	Settings       ProductPayloadSettings    `json:"settings"`
+	Usage          int                       `json:"usage"`
 }
 
 func NewProductPayload(product Product) *ProductPayload {
+	taxonomy := make([]ProductCategoryPayload, 0, len(product.Taxonomy))
+	for _, category := range product.Taxonomy {
+		taxonomy = append(taxonomy, *NewProductCategoryPayload(category))
+	}
+
+	attributes := make([]ProductAttributePayload, 0, len(product.Attributes))
+	for _, attribute := range product.Attributes {
+		attributes = append(attributes, *NewProductAttributePayload(attribute))
+	}
+
 	return &ProductPayload{
 		ID:             product.ID,
 		OrganizationID: product.OrganizationID,
@@ -25,8 +79,12 @@ func NewProductPayload(product Product) *ProductPayload {
 		Language:       product.Language,
 		Context:        product.Context,
 		Categories:     product.Categories,
+		Taxonomy:       taxonomy,
+		Attributes:     attributes,
 		Release:        product.Release,
-		Settings:       ProductPayloadSettings{},
-		Usage:          product.Usage,
+		Settings: ProductPayloadSettings{
+			Priority: *priority.NewPriorityFormulaPayload(product.PriorityFormula()),
+		},
+		Usage: product.Usage,
 	}
 }
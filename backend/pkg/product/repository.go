@@ -34,8 +34,11 @@ func (self *ProductRepository) Create(ctx context.Context, product Product) (*Pr
 		Set("name", p.Name).
 		Set("picture", p.Picture).
 		Set("language", p.Language).
+		Set("embedding_model", p.EmbeddingModel).
 		Set("context", p.Context).
 		Set("categories", p.Categories).
+		Set("taxonomy", p.Taxonomy).
+		Set("attributes", p.Attributes).
 		Set("release", p.Release).
 		Set("settings", p.Settings).
 		Set("usage", p.Usage).
@@ -96,6 +99,33 @@ func (self *ProductRepository) ListByOrganizationID(ctx context.Context, organiz
 	return entities, nil
 }
 
+func (self *ProductRepository) ListIDsByNotDeleted(ctx context.Context) ([]string, error) {
+	var result []struct {
+		ID string `db:"id"`
+	}
+
+	stmt := sqlf.
+		Select("id").To(&result).
+		From(PRODUCT_MODEL_TABLE).
+		Where("deleted_at IS NULL")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []string{}, nil
+		}
+
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(result))
+	for _, res := range result {
+		ids = append(ids, res.ID)
+	}
+
+	return ids, nil
+}
+
 func (self *ProductRepository) ExistsByOrganizationID(ctx context.Context, organizationID string) (bool, error) {
 	var e bool
 
@@ -149,6 +179,7 @@ func (self *ProductRepository) UpdateInfo(ctx context.Context, product Product)
 		Set("picture", p.Picture).
 		Set("context", p.Context).
 		Set("categories", p.Categories).
+		Set("taxonomy", p.Taxonomy).
 		Set("release", p.Release).
 		Where("id = ?", p.ID)
 
@@ -164,6 +195,47 @@ func (self *ProductRepository) UpdateInfo(ctx context.Context, product Product)
 	return nil
 }
 
+func (self *ProductRepository) UpdateTaxonomy(ctx context.Context, product Product) error {
+	p := NewProductModel(product)
+
+	stmt := sqlf.
+		Update(PRODUCT_MODEL_TABLE).
+		Set("categories", p.Categories).
+		Set("taxonomy", p.Taxonomy).
+		Where("id = ?", p.ID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+func (self *ProductRepository) UpdateAttributes(ctx context.Context, product Product) error {
+	p := NewProductModel(product)
+
+	stmt := sqlf.
+		Update(PRODUCT_MODEL_TABLE).
+		Set("attributes", p.Attributes).
+		Where("id = ?", p.ID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
 func (self *ProductRepository) UpdateSettings(ctx context.Context, product Product) error {
 	p := NewProductModel(product)
 
@@ -184,6 +256,24 @@ func (self *ProductRepository) UpdateSettings(ctx context.Context, product Produ
 	return nil
 }
 
+func (self *ProductRepository) UpdateEmbeddingModel(ctx context.Context, id string, embeddingModel string) error {
+	stmt := sqlf.
+		Update(PRODUCT_MODEL_TABLE).
+		Set("embedding_model", embeddingModel).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
 func (self *ProductRepository) UpdateDeletedAt(ctx context.Context, id string, deletedAt time.Time) error {
 	stmt := sqlf.
 		Update(PRODUCT_MODEL_TABLE).
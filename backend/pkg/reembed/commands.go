@@ -0,0 +1,145 @@
+package reembed
+
+import (
+	"context"
+	"time"
+
+	"github.com/mkideal/cli"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+)
+
+const (
+	REEMBED_COMMANDS_FOLLOW_INTERVAL = 10 * time.Second
+)
+
+const (
+	ReembedCommandsReembedProduct = "reembed-product"
+	ReembedCommandsCutoverProduct = "reembed-cutover"
+)
+
+type ReembedCommands struct {
+	config            config.Config
+	observer          *kit.Observer
+	reembedRepository *ReembedRepository
+	productRepository *product.ProductRepository
+	reembedder        *Reembedder
+}
+
+func NewReembedCommands(observer *kit.Observer, reembedRepository *ReembedRepository,
+	productRepository *product.ProductRepository, reembedder *Reembedder, config config.Config) *ReembedCommands {
+	return &ReembedCommands{
+		config:            config,
+		observer:          observer,
+		reembedRepository: reembedRepository,
+		productRepository: productRepository,
+		reembedder:        reembedder,
+	}
+}
+
+type ReembedCommandsReembedProductArgs struct {
+	cli.Helper
+	Product string `cli:"*product" usage:"id of the product to reembed"`
+	Model   string `cli:"*model" usage:"embedding model to recompute the issues and suggestions embeddings with"`
+	Cutover bool   `cli:"cutover" dft:"false" usage:"switch to the new model as soon as every embedding is recomputed"`
+	Follow  bool   `cli:"follow" dft:"false" usage:"report the progress until every embedding is recomputed"`
+}
+
+func (self *ReembedCommands) ReembedProduct(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*ReembedCommandsReembedProductArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	product, err := self.productRepository.GetByID(ctx, args.Product)
+	if err != nil {
+		return err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return kit.ErrRunnerGeneric.Raise().With("product %s not found", args.Product)
+	}
+
+	reembed, err := self.reembedder.Create(ctx, *product, args.Model, args.Cutover)
+	if err != nil {
+		return err
+	}
+
+	self.observer.Infof(ctx, "Created reembed %s from %s to %s", reembed.ID, reembed.FromModel, reembed.ToModel)
+
+	if !args.Follow {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(REEMBED_COMMANDS_FOLLOW_INTERVAL):
+		}
+
+		reembed, err = self.reembedRepository.GetByID(ctx, reembed.ID)
+		if err != nil {
+			return err
+		}
+
+		if reembed == nil {
+			return nil
+		}
+
+		progress, err := self.reembedRepository.GetProgress(ctx, *reembed)
+		if err != nil {
+			return err
+		}
+
+		self.observer.Infof(ctx, "Reembed %s has embedded %d of %d items",
+			reembed.ID, progress.Embedded, progress.Items)
+
+		if reembed.CutoverAt != nil {
+			self.observer.Infof(ctx, "Reembed %s cut over", reembed.ID)
+			return nil
+		}
+
+		if !reembed.Cutover && progress.Embedded >= progress.Items {
+			self.observer.Infof(ctx, "Reembed %s is ready, run %s to cut over", reembed.ID, ReembedCommandsCutoverProduct)
+			return nil
+		}
+	}
+}
+
+type ReembedCommandsCutoverProductArgs struct {
+	cli.Helper
+	Product string `cli:"*product" usage:"id of the product to switch to the new embedding model"`
+}
+
+func (self *ReembedCommands) CutoverProduct(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*ReembedCommandsCutoverProductArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	reembed, err := self.reembedRepository.GetLastByProductID(ctx, args.Product)
+	if err != nil {
+		return err
+	}
+
+	if reembed == nil || reembed.FinishedAt != nil {
+		return kit.ErrRunnerGeneric.Raise().With("product %s has no reembed in progress", args.Product)
+	}
+
+	if reembed.CutoverAt != nil {
+		self.observer.Infof(ctx, "Reembed %s already cut over", reembed.ID)
+		return nil
+	}
+
+	reembed, err = self.reembedder.Cutover(ctx, *reembed)
+	if err != nil {
+		return err
+	}
+
+	self.observer.Infof(ctx, "Product %s now uses %s", reembed.ProductID, reembed.ToModel)
+
+	return nil
+}
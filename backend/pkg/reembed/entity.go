@@ -0,0 +1,62 @@
+package reembed
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/neoxelox/kit/util"
+)
+
+const (
+	REEMBED_BATCH_SIZE     = 100
+	REEMBED_BATCH_INTERVAL = 30 * time.Second
+	// In-flight aggregations may still store embeddings of the previous model shortly after the cutover
+	REEMBED_CUTOVER_GRACE = 10 * time.Minute
+	// Batches wait at most the cutover grace or the engine breaker timeout for the next one, so a reembed not
+	// batched for longer has its chain of batches broken
+	REEMBED_STALLED_AFTER = 1 * time.Hour
+)
+
+const (
+	ReembedItemTypeIssue      = "ISSUE"
+	ReembedItemTypeSuggestion = "SUGGESTION"
+)
+
+type Reembed struct {
+	ID         string
+	ProductID  string
+	FromModel  string
+	ToModel    string
+	Cutover    bool
+	CreatedAt  time.Time
+	BatchedAt  *time.Time
+	CutoverAt  *time.Time
+	FinishedAt *time.Time
+}
+
+func NewReembed() *Reembed {
+	return &Reembed{}
+}
+
+func (self Reembed) String() string {
+	return fmt.Sprintf("<Reembed: %s (%s)>", self.ProductID, self.ID)
+}
+
+func (self Reembed) Equals(other Reembed) bool {
+	return util.Equals(self, other)
+}
+
+func (self Reembed) Copy() *Reembed {
+	return util.Copy(self)
+}
+
+type ReembedItem struct {
+	Type string
+	ID   string
+	Text string
+}
+
+type ReembedProgress struct {
+	Items    int
+	Embedded int
+}
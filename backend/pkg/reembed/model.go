@@ -0,0 +1,50 @@
+package reembed
+
+import (
+	"time"
+)
+
+const (
+	REEMBED_MODEL_TABLE      = "\"reembed\""
+	REEMBED_ITEM_MODEL_TABLE = "\"reembed_item\""
+)
+
+type ReembedModel struct {
+	ID         string     `db:"id"`
+	ProductID  string     `db:"product_id"`
+	FromModel  string     `db:"from_model"`
+	ToModel    string     `db:"to_model"`
+	Cutover    bool       `db:"cutover"`
+	CreatedAt  time.Time  `db:"created_at"`
+	BatchedAt  *time.Time `db:"batched_at"`
+	CutoverAt  *time.Time `db:"cutover_at"`
+	FinishedAt *time.Time `db:"finished_at"`
+}
+
+func NewReembedModel(reembed Reembed) *ReembedModel {
+	return &ReembedModel{
+		ID:         reembed.ID,
+		ProductID:  reembed.ProductID,
+		FromModel:  reembed.FromModel,
+		ToModel:    reembed.ToModel,
+		Cutover:    reembed.Cutover,
+		CreatedAt:  reembed.CreatedAt,
+		BatchedAt:  reembed.BatchedAt,
+		CutoverAt:  reembed.CutoverAt,
+		FinishedAt: reembed.FinishedAt,
+	}
+}
+
+func (self *ReembedModel) ToEntity() *Reembed {
+	return &Reembed{
+		ID:         self.ID,
+		ProductID:  self.ProductID,
+		FromModel:  self.FromModel,
+		ToModel:    self.ToModel,
+		Cutover:    self.Cutover,
+		CreatedAt:  self.CreatedAt,
+		BatchedAt:  self.BatchedAt,
+		CutoverAt:  self.CutoverAt,
+		FinishedAt: self.FinishedAt,
+	}
+}
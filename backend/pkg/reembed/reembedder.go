@@ -0,0 +1,346 @@
+package reembed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+	"github.com/rs/xid"
+
+	"backend/pkg/config"
+	"backend/pkg/engine"
+	"backend/pkg/outbox"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+const (
+	ReembedderEmbed    = "reembed:embed-reembed"
+	ReembedderSchedule = "reembed:schedule-embed-reembed"
+)
+
+const (
+	REEMBEDDER_LOCK_KEY = "reembed:%s"
+)
+
+var (
+	ErrReembedderGeneric      = errors.New("reembedder failed")
+	ErrReembedderInProgress   = errors.New("reembed already in progress")
+	ErrReembedderInvalidModel = errors.New("invalid embedding model")
+	ErrReembedderNotReady     = errors.New("reembed not ready for cutover")
+)
+
+type Reembedder struct {
+	config            config.Config
+	observer          *kit.Observer
+	database          *kit.Database
+	reembedRepository *ReembedRepository
+	productRepository *product.ProductRepository
+	enqueuer          *outbox.OutboxEnqueuer
+	engineService     *engine.EngineService
+	engineBreaker     *engine.EngineBreaker
+}
+
+func NewReembedder(observer *kit.Observer, database *kit.Database, reembedRepository *ReembedRepository,
+	productRepository *product.ProductRepository, enqueuer *outbox.OutboxEnqueuer,
+	engineService *engine.EngineService, engineBreaker *engine.EngineBreaker, config config.Config) *Reembedder {
+	return &Reembedder{
+		config:            config,
+		observer:          observer,
+		database:          database,
+		reembedRepository: reembedRepository,
+		productRepository: productRepository,
+		enqueuer:          enqueuer,
+		engineService:     engineService,
+		engineBreaker:     engineBreaker,
+	}
+}
+
+// Create schedules the recomputation of the product issue and suggestion embeddings with another model. The new
+// embeddings are staged and only replace the current ones on cutover, so aggregation keeps working meanwhile.
+func (self *Reembedder) Create(ctx context.Context, product product.Product,
+	model string, cutover bool) (*Reembed, error) {
+	if !engine.IsEmbeddingModelSupported(model) {
+		return nil, ErrReembedderInvalidModel.Raise().With("embedding model %s is not supported", model)
+	}
+
+	if model == product.EmbeddingModel {
+		return nil, ErrReembedderInvalidModel.Raise().With("product %s already uses %s", product.ID, model)
+	}
+
+	last, err := self.reembedRepository.GetLastByProductID(ctx, product.ID)
+	if err != nil {
+		return nil, ErrReembedderGeneric.Raise().Cause(err)
+	}
+
+	if last != nil && last.FinishedAt == nil {
+		return nil, ErrReembedderInProgress.Raise().With("reembed %s is not finished", last.ID)
+	}
+
+	reembed := NewReembed()
+	reembed.ID = xid.New().String()
+	reembed.ProductID = product.ID
+	reembed.FromModel = product.EmbeddingModel
+	reembed.ToModel = model
+	reembed.Cutover = cutover
+	reembed.CreatedAt = time.Now()
+	reembed.BatchedAt = nil
+	reembed.CutoverAt = nil
+	reembed.FinishedAt = nil
+
+	err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		// Concurrent requests would otherwise both find no reembed in progress and start one each
+		err := util.LockTransaction(ctx, self.database, fmt.Sprintf(REEMBEDDER_LOCK_KEY, product.ID))
+		if err != nil {
+			return err
+		}
+
+		last, err := self.reembedRepository.GetLastByProductID(ctx, product.ID)
+		if err != nil {
+			return err
+		}
+
+		if last != nil && last.FinishedAt == nil {
+			return ErrReembedderInProgress.Raise().With("reembed %s is not finished", last.ID)
+		}
+
+		reembed, err = self.reembedRepository.Create(ctx, *reembed)
+		if err != nil {
+			if kit.ErrDatabaseIntegrityViolation.In(err) {
+				return ErrReembedderInProgress.Raise().With("product %s has a reembed not finished", product.ID)
+			}
+
+			return err
+		}
+
+		err = self.enqueuer.Enqueue(ctx, ReembedderEmbed, ReembedderEmbedParams{
+			ReembedID: reembed.ID,
+		}, asynq.MaxRetry(2))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		if ErrReembedderInProgress.In(err) {
+			return nil, err
+		}
+
+		return nil, ErrReembedderGeneric.Raise().Cause(err)
+	}
+
+	return reembed, nil
+}
+
+// Cutover switches the product, and its issues and suggestions, to the new embedding model once every
+// embedding has been recomputed.
+func (self *Reembedder) Cutover(ctx context.Context, reembed Reembed) (*Reembed, error) {
+	if reembed.CutoverAt != nil {
+		return &reembed, nil
+	}
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		progress, err := self.reembedRepository.GetProgress(ctx, reembed)
+		if err != nil {
+			return err
+		}
+
+		if progress.Embedded < progress.Items {
+			return ErrReembedderNotReady.Raise().
+				With("reembed %s has embedded %d of %d items", reembed.ID, progress.Embedded, progress.Items)
+		}
+
+		_, err = self.reembedRepository.ApplyItems(ctx, reembed)
+		if err != nil {
+			return err
+		}
+
+		err = self.productRepository.UpdateEmbeddingModel(ctx, reembed.ProductID, reembed.ToModel)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err = self.reembedRepository.UpdateCutoverAt(ctx, reembed.ID, now)
+		if err != nil {
+			return err
+		}
+
+		reembed.CutoverAt = &now
+
+		// Pick up the issues and suggestions that were aggregated with the previous model during the cutover
+		err = self.enqueuer.Enqueue(ctx, ReembedderEmbed, ReembedderEmbedParams{
+			ReembedID: reembed.ID,
+		}, asynq.MaxRetry(2), asynq.ProcessIn(REEMBED_BATCH_INTERVAL))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		if ErrReembedderNotReady.Is(err) {
+			return nil, err
+		}
+
+		return nil, ErrReembedderGeneric.Raise().Cause(err)
+	}
+
+	self.observer.Infof(ctx, "Cut over reembed %s from %s to %s", reembed.ID, reembed.FromModel, reembed.ToModel)
+
+	return &reembed, nil
+}
+
+type ReembedderEmbedParams struct {
+	ReembedID string
+}
+
+// Embed recomputes the embeddings of a batch of items and enqueues itself until there are none left, so it can
+// be resumed at any moment as the progress is derived from the staged embeddings.
+func (self *Reembedder) Embed(ctx context.Context, task *asynq.Task) error {
+	params := ReembedderEmbedParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	allowed, delay := self.engineBreaker.Allow(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
+	if !allowed {
+		return self.enqueuer.Enqueue(ctx, ReembedderEmbed, params, asynq.MaxRetry(2), asynq.ProcessIn(delay))
+	}
+
+	reembed, err := self.reembedRepository.GetByID(ctx, params.ReembedID)
+	if err != nil {
+		return err
+	}
+
+	if reembed == nil {
+		return nil
+	}
+
+	if reembed.FinishedAt != nil {
+		return nil
+	}
+
+	// Tells the schedule that the chain of batches is still alive
+	err = self.reembedRepository.UpdateBatchedAt(ctx, reembed.ID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Anything aggregated after this moment has to be embedded again as its description may have changed
+	listedAt := time.Now()
+
+	items, err := self.reembedRepository.ListPendingItems(ctx, *reembed, REEMBED_BATCH_SIZE)
+	if err != nil {
+		return err
+	}
+
+	tokens := 0
+	for _, item := range items {
+		result, err := self.engineService.ComputeEmbedding(ctx, engine.EngineServiceComputeEmbeddingParams{
+			Text:  item.Text,
+			Model: reembed.ToModel,
+		})
+		if err != nil {
+			if engine.ErrEngineServiceTimedOut.Is(err) {
+				err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
+				if err != nil {
+					self.observer.Error(ctx, err)
+				}
+			}
+
+			return err
+		}
+
+		tokens += (result.Usage.Input + result.Usage.Output)
+
+		err = self.reembedRepository.CreateItem(ctx, reembed.ID, item, result.Embedding, listedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(items) > 0 {
+		err = self.engineBreaker.Succeed(ctx, engine.ENGINE_BREAKER_OPERATION_EMBEDDING)
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	// After the cutover the staged embeddings can be applied right away
+	if reembed.CutoverAt != nil {
+		_, err := self.reembedRepository.ApplyItems(ctx, *reembed)
+		if err != nil {
+			return err
+		}
+	}
+
+	self.observer.Infof(ctx, "Embedded %d items of reembed %s using %d tokens", len(items), reembed.ID, tokens)
+
+	if len(items) > 0 {
+		return self.enqueuer.Enqueue(ctx, ReembedderEmbed, params,
+			asynq.MaxRetry(2), asynq.ProcessIn(REEMBED_BATCH_INTERVAL))
+	}
+
+	if reembed.CutoverAt == nil {
+		if !reembed.Cutover {
+			self.observer.Infof(ctx, "Reembed %s is ready for cutover", reembed.ID)
+			return nil
+		}
+
+		_, err := self.Cutover(ctx, *reembed)
+		if err != nil {
+			// Something has been aggregated in the meantime, it will be embedded on the next batch
+			if ErrReembedderNotReady.Is(err) {
+				return self.enqueuer.Enqueue(ctx, ReembedderEmbed, params,
+					asynq.MaxRetry(2), asynq.ProcessIn(REEMBED_BATCH_INTERVAL))
+			}
+
+			return err
+		}
+
+		return nil
+	}
+
+	if time.Since(*reembed.CutoverAt) < REEMBED_CUTOVER_GRACE {
+		return self.enqueuer.Enqueue(ctx, ReembedderEmbed, params,
+			asynq.MaxRetry(2), asynq.ProcessIn(REEMBED_CUTOVER_GRACE-time.Since(*reembed.CutoverAt)))
+	}
+
+	err = self.reembedRepository.UpdateFinishedAt(ctx, reembed.ID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	self.observer.Infof(ctx, "Finished reembed %s", reembed.ID)
+
+	return nil
+}
+
+func (self *Reembedder) Schedule(ctx context.Context, _ *asynq.Task) error {
+	// Resume only the reembeds whose chain of batches got broken, e.g. after exhausting the retries of a batch, as
+	// resuming a live one would run a second chain along with it
+	ids, err := self.reembedRepository.ListIDsByStalled(ctx, time.Now().Add(-REEMBED_STALLED_AFTER))
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := self.enqueuer.Enqueue(ctx, ReembedderEmbed, ReembedderEmbedParams{
+			ReembedID: id,
+		}, asynq.MaxRetry(2), asynq.Unique(1*time.Hour))
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	return nil
+}
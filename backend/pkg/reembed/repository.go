@@ -0,0 +1,321 @@
+package reembed
+
+import (
+	"context"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+	"github.com/pgvector/pgvector-go"
+
+	"backend/pkg/config"
+	"backend/pkg/issue"
+	"backend/pkg/suggestion"
+)
+
+type ReembedRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewReembedRepository(observer *kit.Observer, database *kit.Database, config config.Config) *ReembedRepository {
+	return &ReembedRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *ReembedRepository) Create(ctx context.Context, reembed Reembed) (*Reembed, error) {
+	r := NewReembedModel(reembed)
+
+	stmt := sqlf.
+		InsertInto(REEMBED_MODEL_TABLE).
+		Set("id", r.ID).
+		Set("product_id", r.ProductID).
+		Set("from_model", r.FromModel).
+		Set("to_model", r.ToModel).
+		Set("cutover", r.Cutover).
+		Set("created_at", r.CreatedAt).
+		Set("batched_at", r.BatchedAt).
+		Set("cutover_at", r.CutoverAt).
+		Set("finished_at", r.FinishedAt).
+		Returning("*").To(&r)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ToEntity(), nil
+}
+
+func (self *ReembedRepository) GetByID(ctx context.Context, id string) (*Reembed, error) {
+	var r ReembedModel
+
+	stmt := sqlf.
+		Select("*").To(&r).
+		From(REEMBED_MODEL_TABLE).
+		Where("id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return r.ToEntity(), nil
+}
+
+func (self *ReembedRepository) GetLastByProductID(ctx context.Context, productID string) (*Reembed, error) {
+	var r ReembedModel
+
+	stmt := sqlf.
+		Select("*").To(&r).
+		From(REEMBED_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		OrderBy("created_at DESC").
+		Limit(1)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return r.ToEntity(), nil
+}
+
+// ListIDsByStalled returns the reembeds not finished that have not been batched since the given time, or that
+// have never been batched when they were created before it.
+func (self *ReembedRepository) ListIDsByStalled(ctx context.Context, before time.Time) ([]string, error) {
+	var result []struct {
+		ID string `db:"id"`
+	}
+
+	stmt := sqlf.
+		Select("id").To(&result).
+		From(REEMBED_MODEL_TABLE).
+		Where("finished_at IS NULL").
+		Where("COALESCE(batched_at, created_at) < ?", before)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []string{}, nil
+		}
+
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(result))
+	for _, res := range result {
+		ids = append(ids, res.ID)
+	}
+
+	return ids, nil
+}
+
+// An item needs to be embedded again when it is not using the new model yet and it has no staged embedding
+// or the staged one was computed before the item was last aggregated, as its description may have changed.
+func (self *ReembedRepository) pending(table string, _type string) string {
+	return `"product_id" = ? AND "embedding_model" <> ? AND NOT EXISTS (
+		SELECT 1 FROM ` + REEMBED_ITEM_MODEL_TABLE + ` AS "item"
+		WHERE "item"."reembed_id" = ? AND "item"."type" = '` + _type + `' AND "item"."source_id" = ` + table + `."id"
+		AND "item"."embedded_at" >= COALESCE(` + table + `."last_aggregated_at", ` + table + `."created_at")
+	)`
+}
+
+func (self *ReembedRepository) ListPendingItems(ctx context.Context,
+	reembed Reembed, limit int) ([]ReembedItem, error) {
+	var result []struct {
+		Type        string `db:"type"`
+		ID          string `db:"id"`
+		Description string `db:"description"`
+	}
+
+	stmt := sqlf.New(`
+		(SELECT ?::VARCHAR AS "type", "id", "description" FROM `+issue.ISSUE_MODEL_TABLE+`
+		WHERE `+self.pending(issue.ISSUE_MODEL_TABLE, ReembedItemTypeIssue)+`
+		ORDER BY "created_at" ASC LIMIT ?)
+		UNION ALL
+		(SELECT ?::VARCHAR AS "type", "id", "description" FROM `+suggestion.SUGGESTION_MODEL_TABLE+`
+		WHERE `+self.pending(suggestion.SUGGESTION_MODEL_TABLE, ReembedItemTypeSuggestion)+`
+		ORDER BY "created_at" ASC LIMIT ?)
+		LIMIT ?`,
+		ReembedItemTypeIssue, reembed.ProductID, reembed.ToModel, reembed.ID, limit,
+		ReembedItemTypeSuggestion, reembed.ProductID, reembed.ToModel, reembed.ID, limit,
+		limit).
+		To(&result)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []ReembedItem{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]ReembedItem, 0, len(result))
+	for _, res := range result {
+		items = append(items, ReembedItem{
+			Type: res.Type,
+			ID:   res.ID,
+			Text: res.Description,
+		})
+	}
+
+	return items, nil
+}
+
+func (self *ReembedRepository) CreateItem(ctx context.Context, id string, item ReembedItem,
+	embedding []float32, embeddedAt time.Time) error {
+	stmt := sqlf.
+		InsertInto(REEMBED_ITEM_MODEL_TABLE).
+		Set("reembed_id", id).
+		Set("type", item.Type).
+		Set("source_id", item.ID).
+		Set("embedding", pgvector.NewVector(embedding)).
+		Set("embedded_at", embeddedAt).
+		Clause(`ON CONFLICT ("reembed_id", "type", "source_id") DO UPDATE SET
+			"embedding" = EXCLUDED."embedding", "embedded_at" = EXCLUDED."embedded_at"`)
+
+	_, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *ReembedRepository) GetProgress(ctx context.Context, reembed Reembed) (*ReembedProgress, error) {
+	var result struct {
+		Items    int `db:"items"`
+		Embedded int `db:"embedded"`
+	}
+
+	stmt := sqlf.New(`SELECT COUNT(*) AS "items", COUNT(*) FILTER (WHERE NOT "pending") AS "embedded" FROM (
+			SELECT `+self.pending(issue.ISSUE_MODEL_TABLE, ReembedItemTypeIssue)+` AS "pending"
+			FROM `+issue.ISSUE_MODEL_TABLE+` WHERE "product_id" = ?
+			UNION ALL
+			SELECT `+self.pending(suggestion.SUGGESTION_MODEL_TABLE, ReembedItemTypeSuggestion)+` AS "pending"
+			FROM `+suggestion.SUGGESTION_MODEL_TABLE+` WHERE "product_id" = ?
+		) AS "items"`,
+		reembed.ProductID, reembed.ToModel, reembed.ID, reembed.ProductID,
+		reembed.ProductID, reembed.ToModel, reembed.ID, reembed.ProductID).
+		To(&result)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &ReembedProgress{}, nil
+		}
+
+		return nil, err
+	}
+
+	return &ReembedProgress{
+		Items:    result.Items,
+		Embedded: result.Embedded,
+	}, nil
+}
+
+// ApplyItems replaces the embeddings of the issues and suggestions with their staged ones, as long as they
+// are still fresh, and discards the staged embeddings. Stale ones will be embedded again by the next batch.
+func (self *ReembedRepository) ApplyItems(ctx context.Context, reembed Reembed) (int, error) {
+	apply := func(table string, _type string) *sqlf.Stmt {
+		return sqlf.New(`UPDATE `+table+` SET
+			"embedding" = "item"."embedding",
+			"embedding_model" = ?
+			FROM `+REEMBED_ITEM_MODEL_TABLE+` AS "item"
+			WHERE "item"."reembed_id" = ? AND "item"."type" = ? AND "item"."source_id" = `+table+`."id"
+			AND "item"."embedded_at" >= COALESCE(`+table+`."last_aggregated_at", `+table+`."created_at")`,
+			reembed.ToModel, reembed.ID, _type)
+	}
+
+	applied := 0
+	for _, stmt := range []*sqlf.Stmt{
+		apply(issue.ISSUE_MODEL_TABLE, ReembedItemTypeIssue),
+		apply(suggestion.SUGGESTION_MODEL_TABLE, ReembedItemTypeSuggestion),
+	} {
+		affected, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return 0, err
+		}
+
+		applied += affected
+	}
+
+	stmt := sqlf.
+		DeleteFrom(REEMBED_ITEM_MODEL_TABLE).
+		Where("reembed_id = ?", reembed.ID)
+
+	_, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+
+	return applied, nil
+}
+
+func (self *ReembedRepository) UpdateBatchedAt(ctx context.Context, id string, batchedAt time.Time) error {
+	stmt := sqlf.
+		Update(REEMBED_MODEL_TABLE).
+		Set("batched_at", batchedAt).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+func (self *ReembedRepository) UpdateCutoverAt(ctx context.Context, id string, cutoverAt time.Time) error {
+	stmt := sqlf.
+		Update(REEMBED_MODEL_TABLE).
+		Set("cutover_at", cutoverAt).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+func (self *ReembedRepository) UpdateFinishedAt(ctx context.Context, id string, finishedAt time.Time) error {
+	stmt := sqlf.
+		Update(REEMBED_MODEL_TABLE).
+		Set("finished_at", finishedAt).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package reprocess
+
+import (
+	"context"
+	"time"
+
+	"github.com/mkideal/cli"
+	"github.com/neoxelox/kit"
+	kitUtil "github.com/neoxelox/kit/util"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+)
+
+const (
+	REPROCESS_COMMANDS_FOLLOW_INTERVAL = 10 * time.Second
+)
+
+const (
+	ReprocessCommandsReprocessProduct = "reprocess-product"
+)
+
+type ReprocessCommands struct {
+	config              config.Config
+	observer            *kit.Observer
+	reprocessRepository *ReprocessRepository
+	productRepository   *product.ProductRepository
+	reprocessor         *Reprocessor
+}
+
+func NewReprocessCommands(observer *kit.Observer, reprocessRepository *ReprocessRepository,
+	productRepository *product.ProductRepository, reprocessor *Reprocessor, config config.Config) *ReprocessCommands {
+	return &ReprocessCommands{
+		config:              config,
+		observer:            observer,
+		reprocessRepository: reprocessRepository,
+		productRepository:   productRepository,
+		reprocessor:         reprocessor,
+	}
+}
+
+type ReprocessCommandsReprocessProductArgs struct {
+	cli.Helper
+	Product string `cli:"*product" usage:"id of the product to reprocess"`
+	From    string `cli:"from" dft:"" usage:"reprocess feedbacks posted since this RFC3339 date (all if empty)"`
+	To      string `cli:"to" dft:"" usage:"reprocess feedbacks posted until this RFC3339 date (all if empty)"`
+	Confirm bool   `cli:"confirm" dft:"false" usage:"start the reprocess instead of only estimating its cost"`
+	Follow  bool   `cli:"follow" dft:"false" usage:"report the progress until the reprocess finishes"`
+}
+
+func (self *ReprocessCommands) ReprocessProduct(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*ReprocessCommandsReprocessProductArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	var periodStartAt *time.Time
+	if len(args.From) > 0 {
+		from, err := time.Parse(time.RFC3339, args.From)
+		if err != nil {
+			return kit.ErrRunnerGeneric.Raise().With("invalid from date %s", args.From).Cause(err)
+		}
+
+		periodStartAt = kitUtil.Pointer(from)
+	}
+
+	var periodEndAt *time.Time
+	if len(args.To) > 0 {
+		to, err := time.Parse(time.RFC3339, args.To)
+		if err != nil {
+			return kit.ErrRunnerGeneric.Raise().With("invalid to date %s", args.To).Cause(err)
+		}
+
+		periodEndAt = kitUtil.Pointer(to)
+	}
+
+	product, err := self.productRepository.GetByID(ctx, args.Product)
+	if err != nil {
+		return err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return kit.ErrRunnerGeneric.Raise().With("product %s not found", args.Product)
+	}
+
+	estimate, err := self.reprocessRepository.Estimate(ctx, product.ID, periodStartAt, periodEndAt)
+	if err != nil {
+		return err
+	}
+
+	self.observer.Infof(ctx, "Reprocessing %s would process %d feedbacks using about %d tokens",
+		product.ID, estimate.Feedbacks, estimate.Tokens)
+
+	if !args.Confirm {
+		self.observer.Info(ctx, "Run the command again with --confirm to start the reprocess")
+		return nil
+	}
+
+	reprocess, err := self.reprocessor.Create(ctx, product.ID, periodStartAt, periodEndAt)
+	if err != nil {
+		return err
+	}
+
+	self.observer.Infof(ctx, "Created reprocess %s", reprocess.ID)
+
+	if !args.Follow {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(REPROCESS_COMMANDS_FOLLOW_INTERVAL):
+		}
+
+		reprocess, err = self.reprocessRepository.GetByID(ctx, reprocess.ID)
+		if err != nil {
+			return err
+		}
+
+		if reprocess == nil {
+			return nil
+		}
+
+		progress, err := self.reprocessRepository.GetProgress(ctx, reprocess.ID)
+		if err != nil {
+			return err
+		}
+
+		self.observer.Infof(ctx, "Reprocess %s has processed %d and aggregated %d of %d feedbacks",
+			reprocess.ID, progress.Processed, progress.Aggregated, progress.Feedbacks)
+
+		if reprocess.FinishedAt != nil {
+			self.observer.Infof(ctx, "Reprocess %s finished", reprocess.ID)
+			return nil
+		}
+	}
+}
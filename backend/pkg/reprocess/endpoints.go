@@ -0,0 +1,132 @@
+package reprocess
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+)
+
+type ReprocessEndpoints struct {
+	config              config.Config
+	observer            *kit.Observer
+	reprocessRepository *ReprocessRepository
+	reprocessor         *Reprocessor
+}
+
+func NewReprocessEndpoints(observer *kit.Observer, reprocessRepository *ReprocessRepository,
+	reprocessor *Reprocessor, config config.Config) *ReprocessEndpoints {
+	return &ReprocessEndpoints{
+		config:              config,
+		observer:            observer,
+		reprocessRepository: reprocessRepository,
+		reprocessor:         reprocessor,
+	}
+}
+
+type ReprocessEndpointsGetReprocessEstimateRequest struct {
+	PeriodStartAt *time.Time `query:"period_start_at"`
+	PeriodEndAt   *time.Time `query:"period_end_at"`
+}
+
+type ReprocessEndpointsGetReprocessEstimateResponse struct {
+	ReprocessEstimatePayload
+}
+
+func (self *ReprocessEndpoints) GetReprocessEstimate(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := ReprocessEndpointsGetReprocessEstimateRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if request.PeriodStartAt != nil && request.PeriodEndAt != nil &&
+		request.PeriodStartAt.After(*request.PeriodEndAt) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	estimate, err := self.reprocessRepository.Estimate(requestCtx,
+		requestProduct.ID, request.PeriodStartAt, request.PeriodEndAt)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := ReprocessEndpointsGetReprocessEstimateResponse{}
+	response.ReprocessEstimatePayload = *NewReprocessEstimatePayload(*estimate)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type ReprocessEndpointsGetReprocessResponse struct {
+	ReprocessPayload
+}
+
+func (self *ReprocessEndpoints) GetReprocess(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+
+	reprocess, err := self.reprocessRepository.GetLastByProductID(requestCtx, requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	if reprocess == nil {
+		return kit.HTTPErrNotFound
+	}
+
+	progress, err := self.reprocessRepository.GetProgress(requestCtx, reprocess.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := ReprocessEndpointsGetReprocessResponse{}
+	response.ReprocessPayload = *NewReprocessPayload(*reprocess, *progress)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type ReprocessEndpointsPostReprocessRequest struct {
+	PeriodStartAt *time.Time `json:"period_start_at"`
+	PeriodEndAt   *time.Time `json:"period_end_at"`
+}
+
+type ReprocessEndpointsPostReprocessResponse struct {
+	ReprocessPayload
+}
+
+func (self *ReprocessEndpoints) PostReprocess(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := ReprocessEndpointsPostReprocessRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	if request.PeriodStartAt != nil && request.PeriodEndAt != nil &&
+		request.PeriodStartAt.After(*request.PeriodEndAt) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	reprocess, err := self.reprocessor.Create(requestCtx, requestProduct.ID, request.PeriodStartAt, request.PeriodEndAt)
+	if err != nil {
+		if ErrReprocessorInProgress.In(err) {
+			return kit.HTTPErrInvalidRequest.Cause(err)
+		}
+
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := ReprocessEndpointsPostReprocessResponse{}
+	response.ReprocessPayload = *NewReprocessPayload(*reprocess, ReprocessProgress{})
+
+	return ctx.JSON(http.StatusOK, &response)
+}
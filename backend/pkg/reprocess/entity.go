@@ -0,0 +1,58 @@
+package reprocess
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/neoxelox/kit/util"
+)
+
+const (
+	REPROCESS_TASKS_PER_MINUTE   = 300
+	REPROCESS_RECONCILE_INTERVAL = 5 * time.Minute
+	REPROCESS_MAX_DURATION       = 48 * time.Hour
+)
+
+const (
+	ReprocessSnapshotTypeIssue      = "ISSUE"
+	ReprocessSnapshotTypeSuggestion = "SUGGESTION"
+)
+
+type Reprocess struct {
+	ID              string
+	ProductID       string
+	PeriodStartAt   *time.Time
+	PeriodEndAt     *time.Time
+	Feedbacks       int
+	EstimatedTokens int
+	CreatedAt       time.Time
+	StartedAt       *time.Time
+	FinishedAt      *time.Time
+}
+
+func NewReprocess() *Reprocess {
+	return &Reprocess{}
+}
+
+func (self Reprocess) String() string {
+	return fmt.Sprintf("<Reprocess: %s (%s)>", self.ProductID, self.ID)
+}
+
+func (self Reprocess) Equals(other Reprocess) bool {
+	return util.Equals(self, other)
+}
+
+func (self Reprocess) Copy() *Reprocess {
+	return util.Copy(self)
+}
+
+type ReprocessEstimate struct {
+	Feedbacks int
+	Tokens    int
+}
+
+type ReprocessProgress struct {
+	Feedbacks  int
+	Processed  int
+	Aggregated int
+}
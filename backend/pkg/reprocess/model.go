@@ -0,0 +1,51 @@
+package reprocess
+
+import (
+	"time"
+)
+
+const (
+	REPROCESS_MODEL_TABLE          = "\"reprocess\""
+	REPROCESS_FEEDBACK_MODEL_TABLE = "\"reprocess_feedback\""
+	REPROCESS_SNAPSHOT_MODEL_TABLE = "\"reprocess_snapshot\""
+)
+
+type ReprocessModel struct {
+	ID              string     `db:"id"`
+	ProductID       string     `db:"product_id"`
+	PeriodStartAt   *time.Time `db:"period_start_at"`
+	PeriodEndAt     *time.Time `db:"period_end_at"`
+	Feedbacks       int        `db:"feedbacks"`
+	EstimatedTokens int        `db:"estimated_tokens"`
+	CreatedAt       time.Time  `db:"created_at"`
+	StartedAt       *time.Time `db:"started_at"`
+	FinishedAt      *time.Time `db:"finished_at"`
+}
+
+func NewReprocessModel(reprocess Reprocess) *ReprocessModel {
+	return &ReprocessModel{
+		ID:              reprocess.ID,
+		ProductID:       reprocess.ProductID,
+		PeriodStartAt:   reprocess.PeriodStartAt,
+		PeriodEndAt:     reprocess.PeriodEndAt,
+		Feedbacks:       reprocess.Feedbacks,
+		EstimatedTokens: reprocess.EstimatedTokens,
+		CreatedAt:       reprocess.CreatedAt,
+		StartedAt:       reprocess.StartedAt,
+		FinishedAt:      reprocess.FinishedAt,
+	}
+}
+
+func (self *ReprocessModel) ToEntity() *Reprocess {
+	return &Reprocess{
+		ID:              self.ID,
+		ProductID:       self.ProductID,
+		PeriodStartAt:   self.PeriodStartAt,
+		PeriodEndAt:     self.PeriodEndAt,
+		Feedbacks:       self.Feedbacks,
+		EstimatedTokens: self.EstimatedTokens,
+		CreatedAt:       self.CreatedAt,
+		StartedAt:       self.StartedAt,
+		FinishedAt:      self.FinishedAt,
+	}
+}
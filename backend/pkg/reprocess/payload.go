@@ -0,0 +1,45 @@
+package reprocess
+
+import "time"
+
+type ReprocessEstimatePayload struct {
+	Feedbacks int `json:"feedbacks"`
+	Tokens    int `json:"tokens"`
+}
+
+func NewReprocessEstimatePayload(estimate ReprocessEstimate) *ReprocessEstimatePayload {
+	return &ReprocessEstimatePayload{
+		Feedbacks: estimate.Feedbacks,
+		Tokens:    estimate.Tokens,
+	}
+}
+
+type ReprocessPayload struct {
+	ID              string     `json:"id"`
+	ProductID       string     `json:"product_id"`
+	PeriodStartAt   *time.Time `json:"period_start_at"`
+	PeriodEndAt     *time.Time `json:"period_end_at"`
+	Feedbacks       int        `json:"feedbacks"`
+	EstimatedTokens int        `json:"estimated_tokens"`
+	Processed       int        `json:"processed"`
+	Aggregated      int        `json:"aggregated"`
+	CreatedAt       time.Time  `json:"created_at"`
+	StartedAt       *time.Time `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+}
+
+func NewReprocessPayload(reprocess Reprocess, progress ReprocessProgress) *ReprocessPayload {
+	return &ReprocessPayload{
+		ID:              reprocess.ID,
+		ProductID:       reprocess.ProductID,
+		PeriodStartAt:   reprocess.PeriodStartAt,
+		PeriodEndAt:     reprocess.PeriodEndAt,
+		Feedbacks:       reprocess.Feedbacks,
+		EstimatedTokens: reprocess.EstimatedTokens,
+		Processed:       progress.Processed,
+		Aggregated:      progress.Aggregated,
+		CreatedAt:       reprocess.CreatedAt,
+		StartedAt:       reprocess.StartedAt,
+		FinishedAt:      reprocess.FinishedAt,
+	}
+}
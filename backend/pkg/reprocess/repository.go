@@ -0,0 +1,407 @@
+package reprocess
+
+import (
+	"context"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/review"
+	"backend/pkg/suggestion"
+)
+
+type ReprocessRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewReprocessRepository(observer *kit.Observer, database *kit.Database, config config.Config) *ReprocessRepository {
+	return &ReprocessRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *ReprocessRepository) Create(ctx context.Context, reprocess Reprocess) (*Reprocess, error) {
+	r := NewReprocessModel(reprocess)
+
+	stmt := sqlf.
+		InsertInto(REPROCESS_MODEL_TABLE).
+		Set("id", r.ID).
+		Set("product_id", r.ProductID).
+		Set("period_start_at", r.PeriodStartAt).
+		Set("period_end_at", r.PeriodEndAt).
+		Set("feedbacks", r.Feedbacks).
+		Set("estimated_tokens", r.EstimatedTokens).
+		Set("created_at", r.CreatedAt).
+		Set("started_at", r.StartedAt).
+		Set("finished_at", r.FinishedAt).
+		Returning("*").To(&r)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ToEntity(), nil
+}
+
+func (self *ReprocessRepository) GetByID(ctx context.Context, id string) (*Reprocess, error) {
+	var r ReprocessModel
+
+	stmt := sqlf.
+		Select("*").To(&r).
+		From(REPROCESS_MODEL_TABLE).
+		Where("id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return r.ToEntity(), nil
+}
+
+func (self *ReprocessRepository) GetLastByProductID(ctx context.Context, productID string) (*Reprocess, error) {
+	var r ReprocessModel
+
+	stmt := sqlf.
+		Select("*").To(&r).
+		From(REPROCESS_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		OrderBy("created_at DESC").
+		Limit(1)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return r.ToEntity(), nil
+}
+
+// The feedbacks to reprocess are the ones in the period plus, transitively, the ones that share an
+// issue or a suggestion with them, as the whole issue or suggestion has to be aggregated again.
+func (self *ReprocessRepository) closure(productID string,
+	periodStartAt *time.Time, periodEndAt *time.Time) (string, []any) {
+	args := []any{productID, productID, productID}
+
+	period := ""
+	if periodStartAt != nil {
+		period += ` AND "posted_at" >= ?`
+		args = append(args, *periodStartAt)
+	}
+
+	if periodEndAt != nil {
+		period += ` AND "posted_at" <= ?`
+		args = append(args, *periodEndAt)
+	}
+
+	sql := `WITH RECURSIVE "link" ("group_id", "feedback_id") AS (
+		SELECT 'I' || "issue_id", "feedback_id" FROM ` + issue.ISSUE_FEEDBACK_MODEL_TABLE + `
+		JOIN ` + issue.ISSUE_MODEL_TABLE + ` ON "id" = "issue_id" WHERE "product_id" = ?
+		UNION ALL
+		SELECT 'S' || "suggestion_id", "feedback_id" FROM ` + suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE + `
+		JOIN ` + suggestion.SUGGESTION_MODEL_TABLE + ` ON "id" = "suggestion_id" WHERE "product_id" = ?
+	), "closure" ("feedback_id") AS (
+		SELECT "id" FROM ` + feedback.FEEDBACK_MODEL_TABLE + ` WHERE "product_id" = ?` + period + `
+		UNION
+		SELECT "target"."feedback_id" FROM "closure"
+		JOIN "link" AS "source" ON "source"."feedback_id" = "closure"."feedback_id"
+		JOIN "link" AS "target" ON "target"."group_id" = "source"."group_id"
+	)`
+
+	return sql, args
+}
+
+func (self *ReprocessRepository) Estimate(ctx context.Context, productID string,
+	periodStartAt *time.Time, periodEndAt *time.Time) (*ReprocessEstimate, error) {
+	var result struct {
+		Feedbacks int `db:"feedbacks"`
+		Tokens    int `db:"tokens"`
+	}
+
+	closure, args := self.closure(productID, periodStartAt, periodEndAt)
+	args = append(args, productID)
+
+	// The cost of each feedback is estimated with the average of the already processed ones
+	stmt := sqlf.New(closure+`
+		SELECT COUNT(*) AS "feedbacks", COALESCE(ROUND(COUNT(*) * (
+			SELECT AVG("tokens") FROM `+feedback.FEEDBACK_MODEL_TABLE+`
+			WHERE "product_id" = ? AND "processed_at" IS NOT NULL
+		)), 0)::BIGINT AS "tokens" FROM "closure"`, args...).
+		To(&result)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &ReprocessEstimate{}, nil
+		}
+
+		return nil, err
+	}
+
+	return &ReprocessEstimate{
+		Feedbacks: result.Feedbacks,
+		Tokens:    result.Tokens,
+	}, nil
+}
+
+func (self *ReprocessRepository) CreateFeedbacks(ctx context.Context, reprocess Reprocess) (int, error) {
+	closure, args := self.closure(reprocess.ProductID, reprocess.PeriodStartAt, reprocess.PeriodEndAt)
+	args = append(args, reprocess.ID)
+
+	stmt := sqlf.New(closure+`
+		INSERT INTO `+REPROCESS_FEEDBACK_MODEL_TABLE+` ("reprocess_id", "feedback_id")
+		SELECT ?, "feedback_id" FROM "closure" ON CONFLICT DO NOTHING`, args...)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+// CreateSnapshots remembers the assignee and archive state, and the workflow state of the issues,
+// of the issues and suggestions that are going to be cleared, so they can be restored on the ones that replace them.
+func (self *ReprocessRepository) CreateSnapshots(ctx context.Context, id string) error {
+	stmt := sqlf.New(`INSERT INTO `+REPROCESS_SNAPSHOT_MODEL_TABLE+`
+		("reprocess_id", "type", "source_id", "embedding", "embedding_model", "assignee_id", "archived_at",
+		"state", "resolved_in_release")
+		SELECT ?, ?, "id", "embedding", "embedding_model", "assignee_id", "archived_at",
+		"state", "resolved_in_release" FROM `+issue.ISSUE_MODEL_TABLE+`
+		WHERE ("assignee_id" IS NOT NULL OR "archived_at" IS NOT NULL OR "state" <> ?) AND "id" IN (
+			SELECT "issue_id" FROM `+issue.ISSUE_FEEDBACK_MODEL_TABLE+` WHERE "feedback_id" IN (
+				SELECT "feedback_id" FROM `+REPROCESS_FEEDBACK_MODEL_TABLE+` WHERE "reprocess_id" = ?
+			)
+		)`, id, ReprocessSnapshotTypeIssue, issue.IssueStateOpen, id)
+
+	_, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	stmt = sqlf.New(`INSERT INTO `+REPROCESS_SNAPSHOT_MODEL_TABLE+`
+		("reprocess_id", "type", "source_id", "embedding", "embedding_model", "assignee_id", "archived_at")
+		SELECT ?, ?, "id", "embedding", "embedding_model", "assignee_id", "archived_at" FROM `+suggestion.SUGGESTION_MODEL_TABLE+`
+		WHERE ("assignee_id" IS NOT NULL OR "archived_at" IS NOT NULL) AND "id" IN (
+			SELECT "suggestion_id" FROM `+suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE+` WHERE "feedback_id" IN (
+				SELECT "feedback_id" FROM `+REPROCESS_FEEDBACK_MODEL_TABLE+` WHERE "reprocess_id" = ?
+			)
+		)`, id, ReprocessSnapshotTypeSuggestion, id)
+
+	_, err = self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ClearFeedbacks deletes everything derived from the feedbacks to reprocess and marks them as not processed.
+func (self *ReprocessRepository) ClearFeedbacks(ctx context.Context, id string) error {
+	feedbacks := `SELECT "feedback_id" FROM ` + REPROCESS_FEEDBACK_MODEL_TABLE + ` WHERE "reprocess_id" = ?`
+
+	stmts := []*sqlf.Stmt{
+		sqlf.
+			DeleteFrom(review.REVIEW_MODEL_TABLE).
+			Where(`"feedback_id" IN (`+feedbacks+`)`, id),
+		sqlf.
+			DeleteFrom(issue.PARTIAL_ISSUE_MODEL_TABLE).
+			Where(`"feedback_id" IN (`+feedbacks+`)`, id),
+		sqlf.
+			DeleteFrom(suggestion.PARTIAL_SUGGESTION_MODEL_TABLE).
+			Where(`"feedback_id" IN (`+feedbacks+`)`, id),
+		sqlf.
+			DeleteFrom(issue.ISSUE_MODEL_TABLE).
+			Where(`"id" IN (SELECT "issue_id" FROM `+issue.ISSUE_FEEDBACK_MODEL_TABLE+
+				` WHERE "feedback_id" IN (`+feedbacks+`))`, id),
+		sqlf.
+			DeleteFrom(suggestion.SUGGESTION_MODEL_TABLE).
+			Where(`"id" IN (SELECT "suggestion_id" FROM `+suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE+
+				` WHERE "feedback_id" IN (`+feedbacks+`))`, id),
+		sqlf.
+			Update(feedback.FEEDBACK_MODEL_TABLE).
+			Set("processed_at", nil).
+			Set("aggregated_at", nil).
+			Set("skipped_at", nil).
+			Where(`"id" IN (`+feedbacks+`)`, id),
+	}
+
+	for _, stmt := range stmts {
+		_, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListTranslatedFeedbackIDs returns the feedbacks that can be processed again right away,
+// the rest are still going through the translation stage of the pipeline.
+func (self *ReprocessRepository) ListTranslatedFeedbackIDs(ctx context.Context, id string) ([]string, error) {
+	var result []struct {
+		ID string `db:"id"`
+	}
+
+	stmt := sqlf.
+		Select(feedback.FEEDBACK_MODEL_TABLE+".id").To(&result).
+		From(REPROCESS_FEEDBACK_MODEL_TABLE).
+		Join(feedback.FEEDBACK_MODEL_TABLE,
+			feedback.FEEDBACK_MODEL_TABLE+".id = "+REPROCESS_FEEDBACK_MODEL_TABLE+".feedback_id").
+		Where(REPROCESS_FEEDBACK_MODEL_TABLE+".reprocess_id = ?", id).
+		Where(feedback.FEEDBACK_MODEL_TABLE + ".translated_at IS NOT NULL").
+		OrderBy(feedback.FEEDBACK_MODEL_TABLE + ".posted_at ASC")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []string{}, nil
+		}
+
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(result))
+	for _, res := range result {
+		ids = append(ids, res.ID)
+	}
+
+	return ids, nil
+}
+
+func (self *ReprocessRepository) GetProgress(ctx context.Context, id string) (*ReprocessProgress, error) {
+	var result struct {
+		Feedbacks  int `db:"feedbacks"`
+		Processed  int `db:"processed"`
+		Aggregated int `db:"aggregated"`
+	}
+
+	stmt := sqlf.
+		Select("COUNT(*) AS feedbacks").
+		Select("COUNT(*) FILTER (WHERE "+feedback.FEEDBACK_MODEL_TABLE+".processed_at IS NOT NULL) AS processed").
+		Select("COUNT(*) FILTER (WHERE "+feedback.FEEDBACK_MODEL_TABLE+".aggregated_at IS NOT NULL) AS aggregated").
+		To(&result).
+		From(REPROCESS_FEEDBACK_MODEL_TABLE).
+		Join(feedback.FEEDBACK_MODEL_TABLE,
+			feedback.FEEDBACK_MODEL_TABLE+".id = "+REPROCESS_FEEDBACK_MODEL_TABLE+".feedback_id").
+		Where(REPROCESS_FEEDBACK_MODEL_TABLE+".reprocess_id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &ReprocessProgress{}, nil
+		}
+
+		return nil, err
+	}
+
+	return &ReprocessProgress{
+		Feedbacks:  result.Feedbacks,
+		Processed:  result.Processed,
+		Aggregated: result.Aggregated,
+	}, nil
+}
+
+// RestoreSnapshots copies the assignee and archive state, and the workflow state of the issues, of the cleared
+// issues and suggestions to the most similar ones created since the reprocess started, without overriding theirs.
+func (self *ReprocessRepository) RestoreSnapshots(ctx context.Context, reprocess Reprocess) error {
+	restore := func(table string, _type string, threshold float64, extra string) *sqlf.Stmt {
+		return sqlf.New(`UPDATE `+table+` SET
+			"assignee_id" = COALESCE(`+table+`."assignee_id", "matched"."assignee_id"),
+			"archived_at" = COALESCE(`+table+`."archived_at", "matched"."archived_at")`+extra+`
+			FROM (
+				SELECT "match"."id", "snapshot"."assignee_id", "snapshot"."archived_at",
+				"snapshot"."state", "snapshot"."resolved_in_release"
+				FROM `+REPROCESS_SNAPSHOT_MODEL_TABLE+` AS "snapshot"
+				JOIN LATERAL (
+					SELECT "id" FROM `+table+`
+					WHERE "product_id" = ? AND "created_at" >= ?
+					AND "embedding_model" = "snapshot"."embedding_model"
+					AND (1 - ("embedding" <=> "snapshot"."embedding")) >= ?
+					ORDER BY "embedding" <=> "snapshot"."embedding" ASC LIMIT 1
+				) AS "match" ON TRUE
+				WHERE "snapshot"."reprocess_id" = ? AND "snapshot"."type" = ?
+			) AS "matched"
+			WHERE `+table+`."id" = "matched"."id"`,
+			reprocess.ProductID, *reprocess.StartedAt, threshold, reprocess.ID, _type)
+	}
+
+	stmts := []*sqlf.Stmt{
+		restore(issue.ISSUE_MODEL_TABLE, ReprocessSnapshotTypeIssue, issue.ISSUE_SIMILAR_THRESHOLD, `,
+			"resolved_in_release" = CASE WHEN `+issue.ISSUE_MODEL_TABLE+`."state" = '`+issue.IssueStateOpen+`'
+				THEN "matched"."resolved_in_release" ELSE `+issue.ISSUE_MODEL_TABLE+`."resolved_in_release" END,
+			"state" = CASE WHEN `+issue.ISSUE_MODEL_TABLE+`."state" = '`+issue.IssueStateOpen+`'
+				THEN COALESCE("matched"."state", `+issue.ISSUE_MODEL_TABLE+`."state")
+				ELSE `+issue.ISSUE_MODEL_TABLE+`."state" END`),
+		restore(suggestion.SUGGESTION_MODEL_TABLE, ReprocessSnapshotTypeSuggestion,
+			suggestion.SUGGESTION_SIMILAR_THRESHOLD, ""),
+		sqlf.
+			DeleteFrom(REPROCESS_SNAPSHOT_MODEL_TABLE).
+			Where("reprocess_id = ?", reprocess.ID),
+	}
+
+	for _, stmt := range stmts {
+		_, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (self *ReprocessRepository) UpdateStarted(ctx context.Context, reprocess Reprocess) error {
+	r := NewReprocessModel(reprocess)
+
+	stmt := sqlf.
+		Update(REPROCESS_MODEL_TABLE).
+		Set("feedbacks", r.Feedbacks).
+		Set("started_at", r.StartedAt).
+		Where("id = ?", r.ID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+func (self *ReprocessRepository) UpdateFinished(ctx context.Context, id string, finishedAt time.Time) error {
+	stmt := sqlf.
+		Update(REPROCESS_MODEL_TABLE).
+		Set("finished_at", finishedAt).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+package reprocess
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+	kitUtil "github.com/neoxelox/kit/util"
+	"github.com/rs/xid"
+
+	"backend/pkg/config"
+	"backend/pkg/outbox"
+	"backend/pkg/processor"
+	"backend/pkg/util"
+)
+
+const (
+	ReprocessorStart     = "reprocess:start-reprocess"
+	ReprocessorReconcile = "reprocess:reconcile-reprocess"
+)
+
+const (
+	REPROCESSOR_LOCK_KEY = "reprocess:%s"
+)
+
+var (
+	ErrReprocessorGeneric    = errors.New("reprocessor failed")
+	ErrReprocessorInProgress = errors.New("reprocess already in progress")
+)
+
+type Reprocessor struct {
+	config              config.Config
+	observer            *kit.Observer
+	database            *kit.Database
+	reprocessRepository *ReprocessRepository
+	enqueuer            *outbox.OutboxEnqueuer
+}
+
+func NewReprocessor(observer *kit.Observer, database *kit.Database, reprocessRepository *ReprocessRepository,
+	enqueuer *outbox.OutboxEnqueuer, config config.Config) *Reprocessor {
+	return &Reprocessor{
+		config:              config,
+		observer:            observer,
+		database:            database,
+		reprocessRepository: reprocessRepository,
+		enqueuer:            enqueuer,
+	}
+}
+
+// Create schedules the reprocessing of the product feedbacks posted within the period (all if empty).
+func (self *Reprocessor) Create(ctx context.Context, productID string,
+	periodStartAt *time.Time, periodEndAt *time.Time) (*Reprocess, error) {
+	last, err := self.reprocessRepository.GetLastByProductID(ctx, productID)
+	if err != nil {
+		return nil, ErrReprocessorGeneric.Raise().Cause(err)
+	}
+
+	if last != nil && last.FinishedAt == nil {
+		return nil, ErrReprocessorInProgress.Raise().With("reprocess %s is not finished", last.ID)
+	}
+
+	estimate, err := self.reprocessRepository.Estimate(ctx, productID, periodStartAt, periodEndAt)
+	if err != nil {
+		return nil, ErrReprocessorGeneric.Raise().Cause(err)
+	}
+
+	reprocess := NewReprocess()
+	reprocess.ID = xid.New().String()
+	reprocess.ProductID = productID
+	reprocess.PeriodStartAt = periodStartAt
+	reprocess.PeriodEndAt = periodEndAt
+	reprocess.Feedbacks = estimate.Feedbacks
+	reprocess.EstimatedTokens = estimate.Tokens
+	reprocess.CreatedAt = time.Now()
+	reprocess.StartedAt = nil
+	reprocess.FinishedAt = nil
+
+	err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		// Concurrent requests would otherwise both find no reprocess in progress and start one each
+		err := util.LockTransaction(ctx, self.database, fmt.Sprintf(REPROCESSOR_LOCK_KEY, productID))
+		if err != nil {
+			return err
+		}
+
+		last, err := self.reprocessRepository.GetLastByProductID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		if last != nil && last.FinishedAt == nil {
+			return ErrReprocessorInProgress.Raise().With("reprocess %s is not finished", last.ID)
+		}
+
+		reprocess, err = self.reprocessRepository.Create(ctx, *reprocess)
+		if err != nil {
+			if kit.ErrDatabaseIntegrityViolation.In(err) {
+				return ErrReprocessorInProgress.Raise().With("product %s has a reprocess not finished", productID)
+			}
+
+			return err
+		}
+
+		err = self.enqueuer.Enqueue(ctx, ReprocessorStart, ReprocessorStartParams{
+			ReprocessID: reprocess.ID,
+		}, asynq.MaxRetry(2))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		if ErrReprocessorInProgress.In(err) {
+			return nil, err
+		}
+
+		return nil, ErrReprocessorGeneric.Raise().Cause(err)
+	}
+
+	return reprocess, nil
+}
+
+type ReprocessorStartParams struct {
+	ReprocessID string
+}
+
+func (self *Reprocessor) Start(ctx context.Context, task *asynq.Task) error {
+	params := ReprocessorStartParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	reprocess, err := self.reprocessRepository.GetByID(ctx, params.ReprocessID)
+	if err != nil {
+		return err
+	}
+
+	if reprocess == nil {
+		return nil
+	}
+
+	if reprocess.StartedAt != nil {
+		return nil
+	}
+
+	var feedbackIDs []string
+
+	err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		feedbacks, err := self.reprocessRepository.CreateFeedbacks(ctx, *reprocess)
+		if err != nil {
+			return err
+		}
+
+		err = self.reprocessRepository.CreateSnapshots(ctx, reprocess.ID)
+		if err != nil {
+			return err
+		}
+
+		err = self.reprocessRepository.ClearFeedbacks(ctx, reprocess.ID)
+		if err != nil {
+			return err
+		}
+
+		reprocess.Feedbacks = feedbacks
+		reprocess.StartedAt = kitUtil.Pointer(time.Now())
+
+		err = self.reprocessRepository.UpdateStarted(ctx, *reprocess)
+		if err != nil {
+			return err
+		}
+
+		feedbackIDs, err = self.reprocessRepository.ListTranslatedFeedbackIDs(ctx, reprocess.ID)
+		if err != nil {
+			return err
+		}
+
+		// Spread the feedbacks over time so the reprocess does not exhaust the engine rate limits
+		for i, id := range feedbackIDs {
+			err := self.enqueuer.Enqueue(ctx, processor.FeedbackProcessorProcess, processor.FeedbackProcessorProcessParams{
+				FeedbackID: id,
+			}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)),
+				asynq.ProcessIn(time.Duration(i)*time.Minute/REPROCESS_TASKS_PER_MINUTE))
+			if err != nil {
+				return err
+			}
+		}
+
+		err = self.enqueuer.Enqueue(ctx, ReprocessorReconcile, ReprocessorReconcileParams{
+			ReprocessID: reprocess.ID,
+		}, asynq.MaxRetry(2), asynq.ProcessIn(REPROCESS_RECONCILE_INTERVAL))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	self.observer.Infof(ctx, "Started reprocess %s of %d feedbacks, %d of them are being processed",
+		reprocess.ID, reprocess.Feedbacks, len(feedbackIDs))
+
+	return nil
+}
+
+type ReprocessorReconcileParams struct {
+	ReprocessID string
+}
+
+func (self *Reprocessor) Reconcile(ctx context.Context, task *asynq.Task) error {
+	params := ReprocessorReconcileParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	reprocess, err := self.reprocessRepository.GetByID(ctx, params.ReprocessID)
+	if err != nil {
+		return err
+	}
+
+	if reprocess == nil {
+		return nil
+	}
+
+	if reprocess.StartedAt == nil || reprocess.FinishedAt != nil {
+		return nil
+	}
+
+	progress, err := self.reprocessRepository.GetProgress(ctx, reprocess.ID)
+	if err != nil {
+		return err
+	}
+
+	// Feedbacks that cannot be processed (e.g. the organization ran out of usage) must not block the reprocess forever
+	if progress.Aggregated < progress.Feedbacks && time.Since(*reprocess.StartedAt) < REPROCESS_MAX_DURATION {
+		return self.enqueuer.Enqueue(ctx, ReprocessorReconcile, params,
+			asynq.MaxRetry(2), asynq.ProcessIn(REPROCESS_RECONCILE_INTERVAL))
+	}
+
+	err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := self.reprocessRepository.RestoreSnapshots(ctx, *reprocess)
+		if err != nil {
+			return err
+		}
+
+		err = self.reprocessRepository.UpdateFinished(ctx, reprocess.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	self.observer.Infof(ctx, "Finished reprocess %s with %d of %d feedbacks aggregated",
+		reprocess.ID, progress.Aggregated, progress.Feedbacks)
+
+	return nil
+}
@@ -2,6 +2,7 @@ package review
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"net/url"
@@ -11,6 +12,7 @@ import (
 
 	"backend/pkg/config"
 	"backend/pkg/product"
+	"backend/pkg/segment"
 	"backend/pkg/util"
 
 	kitUtil "github.com/neoxelox/kit/util"
@@ -20,6 +22,8 @@ const (
 	REVIEW_ENDPOINTS_SEARCH_KEY       = "review:endpoints:search:"
 	REVIEW_ENDPOINTS_SEARCH_TTL       = 10 * time.Minute
 	REVIEW_ENDPOINTS_SEARCH_MAX_LIMIT = 100
+	// Attribute and aspect filters are written as `NAME:VALUE`
+	REVIEW_ENDPOINTS_FILTER_SEPARATOR = ":"
 )
 
 type ReviewEndpoints struct {
@@ -49,6 +53,8 @@ type ReviewEndpointsListReviewsRequest struct {
 		Emotions    []string   `query:"emotions"`
 		Intentions  []string   `query:"intentions"`
 		Languages   []string   `query:"languages"`
+		Attributes  []string   `query:"attributes"`
+		Aspects     []string   `query:"aspects"`
 		SeenStartAt *time.Time `query:"seen_start_at"`
 		SeenEndAt   *time.Time `query:"seen_end_at"`
 	}
@@ -89,6 +95,26 @@ func (self *ReviewEndpoints) ListReviews(ctx echo.Context) error {
 		return kit.HTTPErrInvalidRequest
 	}
 
+	attributes := map[string][]string{}
+	for _, attribute := range request.Filters.Attributes {
+		name, value, ok := strings.Cut(attribute, REVIEW_ENDPOINTS_FILTER_SEPARATOR)
+		if !ok || len(name) == 0 || len(value) == 0 {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		attributes[name] = append(attributes[name], value)
+	}
+
+	aspects := map[string][]string{}
+	for _, aspect := range request.Filters.Aspects {
+		name, sentiment, ok := strings.Cut(aspect, REVIEW_ENDPOINTS_FILTER_SEPARATOR)
+		if !ok || len(name) == 0 || !IsReviewSentiment(sentiment) {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		aspects[name] = append(aspects[name], sentiment)
+	}
+
 	if request.Orders.Recency != nil {
 		if *request.Orders.Recency != ReviewSearchOrdersDescending &&
 			*request.Orders.Recency != ReviewSearchOrdersAscending {
@@ -116,8 +142,11 @@ func (self *ReviewEndpoints) ListReviews(ctx echo.Context) error {
 			Emotions:    &request.Filters.Emotions,
 			Intentions:  &request.Filters.Intentions,
 			Languages:   &request.Filters.Languages,
+			Attributes:  &attributes,
+			Aspects:     &aspects,
 			SeenStartAt: request.Filters.SeenStartAt,
 			SeenEndAt:   request.Filters.SeenEndAt,
+			Segment:     segment.RequestSegment(requestCtx),
 		},
 		Orders: ReviewSearchOrders{
 			Recency: *request.Orders.Recency,
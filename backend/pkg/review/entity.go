@@ -2,9 +2,13 @@ package review
 
 import (
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"backend/pkg/feedback"
+	"backend/pkg/segment"
 	"backend/pkg/util"
 
 	kitUtil "github.com/neoxelox/kit/util"
@@ -13,6 +17,8 @@ import (
 const (
 	REVIEW_MIN_QUALITY = 0
 	REVIEW_MAX_QUALITY = 5
+	// Aspects are stored in columns of 50 characters
+	REVIEW_MAX_ASPECT_LENGTH = 50
 )
 
 const (
@@ -79,6 +85,75 @@ func IsReviewIntention(value string) bool {
 		value == ReviewIntentionChurnAndDiscourage
 }
 
+// ReviewAspect is the sentiment of a review towards one aspect of the product. The quote spans the UTF-16 code
+// units between start and end of the original content of the feedback, as highlighted by the frontend, or has no
+// span when it was quoted from the translation of the feedback.
+type ReviewAspect struct {
+	ID        string
+	ReviewID  string
+	ProductID string
+	Aspect    string
+	Sentiment string
+	Quote     string
+	Start     *int
+	End       *int
+	CreatedAt time.Time
+}
+
+func NewReviewAspect() *ReviewAspect {
+	return &ReviewAspect{}
+}
+
+func (self ReviewAspect) String() string {
+	return fmt.Sprintf("<ReviewAspect: %s %s (%s)>", self.Aspect, self.Sentiment, self.ID)
+}
+
+func (self ReviewAspect) Equals(other ReviewAspect) bool {
+	return kitUtil.Equals(self, other)
+}
+
+func (self ReviewAspect) Copy() *ReviewAspect {
+	return kitUtil.Copy(self)
+}
+
+// Locate spans the quote over the content case insensitively, as it is extracted from the feedback translation
+// and models tend to change the case of the first letter, copying the quote as written in the content if found.
+func (self *ReviewAspect) Locate(content string) {
+	self.Start = nil
+	self.End = nil
+
+	// Lowering keeps the number of runes, so rune offsets into the lowered strings are valid for the originals too
+	quote := strings.Map(unicode.ToLower, strings.TrimSpace(self.Quote))
+	lowered := strings.Map(unicode.ToLower, content)
+
+	index := strings.Index(lowered, quote)
+	if len(quote) == 0 || index < 0 {
+		return
+	}
+
+	runes := []rune(content)
+	start := utf8.RuneCountInString(lowered[:index])
+	end := start + utf8.RuneCountInString(quote)
+
+	self.Quote = string(runes[start:end])
+	self.Start = kitUtil.Pointer(utf16Length(runes[:start]))
+	self.End = kitUtil.Pointer(*self.Start + utf16Length(runes[start:end]))
+}
+
+func utf16Length(runes []rune) int {
+	length := 0
+	for _, r := range runes {
+		// Runes outside the basic multilingual plane are encoded as a surrogate pair
+		if r >= 0x10000 {
+			length += 2
+		} else {
+			length++
+		}
+	}
+
+	return length
+}
+
 type Review struct {
 	ID         string
 	ProductID  string
@@ -88,6 +163,8 @@ type Review struct {
 	Emotions   []string
 	Intention  string
 	Category   string
+	Attributes map[string]string
+	Aspects    []ReviewAspect
 	Quality    *int
 	CreatedAt  time.Time
 	ExportedAt *time.Time
@@ -118,8 +195,11 @@ type ReviewSearchFilters struct {
 	Emotions    *[]string
 	Intentions  *[]string
 	Languages   *[]string
+	Attributes  *map[string][]string
+	Aspects     *map[string][]string
 	SeenStartAt *time.Time
 	SeenEndAt   *time.Time
+	Segment     *segment.Segment
 }
 
 const (
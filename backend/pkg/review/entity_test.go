@@ -0,0 +1,48 @@
+package review_test
+
+import (
+	"testing"
+
+	"backend/pkg/review"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ReviewTestSuite struct {
+	suite.Suite
+}
+
+func TestReviewSuite(t *testing.T) {
+	suite.Run(t, new(ReviewTestSuite))
+}
+
+func (self *ReviewTestSuite) TestLocate() {
+	// Given: A feedback with characters encoded as one and two UTF-16 code units before the quote
+	content := "Génial 👍 but the Support is awful"
+	aspect := review.NewReviewAspect()
+	aspect.Quote = " the support is awful "
+
+	// When: The quote is located in the feedback
+	aspect.Locate(content)
+
+	// Then: The quote is copied as written and spans the UTF-16 code units the frontend highlights
+	self.Equal("the Support is awful", aspect.Quote)
+	self.Require().NotNil(aspect.Start)
+	self.Require().NotNil(aspect.End)
+	self.Equal(14, *aspect.Start)
+	self.Equal(34, *aspect.End)
+}
+
+func (self *ReviewTestSuite) TestLocateTranslated() {
+	// Given: A quote extracted from the translation of a feedback
+	aspect := review.NewReviewAspect()
+	aspect.Quote = "the support is awful"
+
+	// When: The quote is located in the original feedback
+	aspect.Locate("Genial, pero el soporte es horrible")
+
+	// Then: The quote is kept without a span
+	self.Equal("the support is awful", aspect.Quote)
+	self.Nil(aspect.Start)
+	self.Nil(aspect.End)
+}
@@ -1,13 +1,15 @@
 package review
 
 import (
+	"encoding/json"
 	"time"
 
 	"backend/pkg/feedback"
 )
 
 const (
-	REVIEW_MODEL_TABLE = "\"review\""
+	REVIEW_MODEL_TABLE        = "\"review\""
+	REVIEW_ASPECT_MODEL_TABLE = "\"review_aspect\""
 )
 
 type ReviewFeedbackModel struct {
@@ -24,12 +26,18 @@ type ReviewModel struct {
 	Emotions   []string   `db:"emotions"`
 	Intention  string     `db:"intention"`
 	Category   string     `db:"category"`
+	Attributes []byte     `db:"attributes"`
 	Quality    *int       `db:"quality"`
 	CreatedAt  time.Time  `db:"created_at"`
 	ExportedAt *time.Time `db:"exported_at"`
 }
 
 func NewReviewModel(review Review) *ReviewModel {
+	attributes, err := json.Marshal(review.Attributes)
+	if err != nil {
+		panic(err)
+	}
+
 	return &ReviewModel{
 		ID:         review.ID,
 		ProductID:  review.ProductID,
@@ -39,15 +47,27 @@ func NewReviewModel(review Review) *ReviewModel {
 		Emotions:   review.Emotions,
 		Intention:  review.Intention,
 		Category:   review.Category,
+		Attributes: attributes,
 		Quality:    review.Quality,
 		CreatedAt:  review.CreatedAt,
 		ExportedAt: review.ExportedAt,
 	}
 }
 
-func (self *ReviewModel) ToEntity(_feedback feedback.FeedbackModel) *Review {
+func (self *ReviewModel) ToEntity(_feedback feedback.FeedbackModel, _aspects []ReviewAspectModel) *Review {
 	feedback := _feedback.ToEntity()
 
+	var attributes map[string]string
+	err := json.Unmarshal(self.Attributes, &attributes)
+	if err != nil {
+		panic(err)
+	}
+
+	aspects := make([]ReviewAspect, 0, len(_aspects))
+	for _, aspect := range _aspects {
+		aspects = append(aspects, *aspect.ToEntity())
+	}
+
 	return &Review{
 		ID:         self.ID,
 		ProductID:  self.ProductID,
@@ -57,8 +77,50 @@ func (self *ReviewModel) ToEntity(_feedback feedback.FeedbackModel) *Review {
 		Emotions:   self.Emotions,
 		Intention:  self.Intention,
 		Category:   self.Category,
+		Attributes: attributes,
+		Aspects:    aspects,
 		Quality:    self.Quality,
 		CreatedAt:  self.CreatedAt,
 		ExportedAt: self.ExportedAt,
 	}
 }
+
+type ReviewAspectModel struct {
+	ID        string    `db:"id"`
+	ReviewID  string    `db:"review_id"`
+	ProductID string    `db:"product_id"`
+	Aspect    string    `db:"aspect"`
+	Sentiment string    `db:"sentiment"`
+	Quote     string    `db:"quote"`
+	Start     *int      `db:"quote_start"`
+	End       *int      `db:"quote_end"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func NewReviewAspectModel(aspect ReviewAspect) *ReviewAspectModel {
+	return &ReviewAspectModel{
+		ID:        aspect.ID,
+		ReviewID:  aspect.ReviewID,
+		ProductID: aspect.ProductID,
+		Aspect:    aspect.Aspect,
+		Sentiment: aspect.Sentiment,
+		Quote:     aspect.Quote,
+		Start:     aspect.Start,
+		End:       aspect.End,
+		CreatedAt: aspect.CreatedAt,
+	}
+}
+
+func (self *ReviewAspectModel) ToEntity() *ReviewAspect {
+	return &ReviewAspect{
+		ID:        self.ID,
+		ReviewID:  self.ReviewID,
+		ProductID: self.ProductID,
+		Aspect:    self.Aspect,
+		Sentiment: self.Sentiment,
+		Quote:     self.Quote,
+		Start:     self.Start,
+		End:       self.End,
+		CreatedAt: self.CreatedAt,
+	}
+}
@@ -4,30 +4,57 @@ import (
 	"backend/pkg/feedback"
 )
 
+type ReviewAspectPayload struct {
+	Aspect    string `json:"aspect"`
+	Sentiment string `json:"sentiment"`
+	Quote     string `json:"quote"`
+	Start     *int   `json:"start"`
+	End       *int   `json:"end"`
+}
+
+func NewReviewAspectPayload(aspect ReviewAspect) *ReviewAspectPayload {
+	return &ReviewAspectPayload{
+		Aspect:    aspect.Aspect,
+		Sentiment: aspect.Sentiment,
+		Quote:     aspect.Quote,
+		Start:     aspect.Start,
+		End:       aspect.End,
+	}
+}
+
 type ReviewPayload struct {
-	ID        string                   `json:"id"`
-	ProductID string                   `json:"product_id"`
-	Feedback  feedback.FeedbackPayload `json:"feedback"`
-	Keywords  []string                 `json:"keywords"`
-	Sentiment string                   `json:"sentiment"`
-	Emotions  []string                 `json:"emotions"`
-	Intention string                   `json:"intention"`
-	Category  string                   `json:"category"`
-	Quality   *int                     `json:"quality"`
+	ID         string                   `json:"id"`
+	ProductID  string                   `json:"product_id"`
+	Feedback   feedback.FeedbackPayload `json:"feedback"`
+	Keywords   []string                 `json:"keywords"`
+	Sentiment  string                   `json:"sentiment"`
+	Emotions   []string                 `json:"emotions"`
+	Intention  string                   `json:"intention"`
+	Category   string                   `json:"category"`
+	Attributes map[string]string        `json:"attributes"`
+	Aspects    []ReviewAspectPayload    `json:"aspects"`
+	Quality    *int                     `json:"quality"`
 }
 
 func NewReviewPayload(review Review) *ReviewPayload {
 	feedback := feedback.NewFeedbackPayload(review.Feedback)
 
+	aspects := make([]ReviewAspectPayload, 0, len(review.Aspects))
+	for _, aspect := range review.Aspects {
+		aspects = append(aspects, *NewReviewAspectPayload(aspect))
+	}
+
 	return &ReviewPayload{
-		ID:        review.ID,
-		ProductID: review.ProductID,
-		Feedback:  *feedback,
-		Keywords:  review.Keywords,
-		Sentiment: review.Sentiment,
-		Emotions:  review.Emotions,
-		Intention: review.Intention,
-		Category:  review.Category,
-		Quality:   review.Quality,
+		ID:         review.ID,
+		ProductID:  review.ProductID,
+		Feedback:   *feedback,
+		Keywords:   review.Keywords,
+		Sentiment:  review.Sentiment,
+		Emotions:   review.Emotions,
+		Intention:  review.Intention,
+		Category:   review.Category,
+		Attributes: review.Attributes,
+		Aspects:    aspects,
+		Quality:    review.Quality,
 	}
 }
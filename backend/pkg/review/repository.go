@@ -10,6 +10,7 @@ import (
 
 	"backend/pkg/config"
 	"backend/pkg/feedback"
+	"backend/pkg/segment"
 	"backend/pkg/util"
 )
 
@@ -43,6 +44,7 @@ func (self *ReviewRepository) Create(ctx context.Context, review Review) (*Revie
 		Set("emotions", m.Review.Emotions).
 		Set("intention", m.Review.Intention).
 		Set("category", m.Review.Category).
+		Set("attributes", m.Review.Attributes).
 		Set("quality", m.Review.Quality).
 		Set("created_at", m.Review.CreatedAt).
 		Set("exported_at", m.Review.ExportedAt).
@@ -53,7 +55,72 @@ func (self *ReviewRepository) Create(ctx context.Context, review Review) (*Revie
 		return nil, err
 	}
 
-	return m.Review.ToEntity(m.Feedback), nil
+	aspects := make([]ReviewAspectModel, 0, len(review.Aspects))
+	for _, aspect := range review.Aspects {
+		aspects = append(aspects, *NewReviewAspectModel(aspect))
+	}
+
+	if len(aspects) > 0 {
+		stmt := sqlf.
+			InsertInto(REVIEW_ASPECT_MODEL_TABLE)
+
+		for _, a := range aspects {
+			stmt.
+				NewRow().
+				Set("id", a.ID).
+				Set("review_id", a.ReviewID).
+				Set("product_id", a.ProductID).
+				Set("aspect", a.Aspect).
+				Set("sentiment", a.Sentiment).
+				Set("quote", a.Quote).
+				Set("quote_start", a.Start).
+				Set("quote_end", a.End).
+				Set("created_at", a.CreatedAt)
+		}
+
+		affected, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return nil, err
+		}
+
+		if affected != len(aspects) {
+			return nil, kit.ErrDatabaseUnexpectedEffect.Raise(affected, len(aspects))
+		}
+	}
+
+	return m.Review.ToEntity(m.Feedback, aspects), nil
+}
+
+// listAspects returns the aspects of the reviews grouped by the review they belong to.
+func (self *ReviewRepository) listAspects(ctx context.Context,
+	reviewIDs []string) (map[string][]ReviewAspectModel, error) {
+	var as []ReviewAspectModel
+	aspects := make(map[string][]ReviewAspectModel)
+
+	if len(reviewIDs) == 0 {
+		return aspects, nil
+	}
+
+	stmt := sqlf.
+		Select("*").To(&as).
+		From(REVIEW_ASPECT_MODEL_TABLE).
+		Where("review_id = ANY(?)", reviewIDs).
+		OrderBy("quote_start ASC")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return aspects, nil
+		}
+
+		return nil, err
+	}
+
+	for _, a := range as {
+		aspects[a.ReviewID] = append(aspects[a.ReviewID], a)
+	}
+
+	return aspects, nil
 }
 
 func (self *ReviewRepository) GetByID(ctx context.Context, id string) (*Review, error) {
@@ -77,7 +144,12 @@ func (self *ReviewRepository) GetByID(ctx context.Context, id string) (*Review,
 		return nil, err
 	}
 
-	return m.Review.ToEntity(m.Feedback), nil
+	aspects, err := self.listAspects(ctx, []string{m.Review.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Review.ToEntity(m.Feedback, aspects[m.Review.ID]), nil
 }
 
 func (self *ReviewRepository) ListByProductID(ctx context.Context,
@@ -133,6 +205,25 @@ func (self *ReviewRepository) ListByProductID(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE + ".language").In(util.Spread(*search.Filters.Languages)...)
 	}
 
+	if search.Filters.Attributes != nil {
+		for name, values := range *search.Filters.Attributes {
+			if len(values) > 0 {
+				stmt.
+					Where("("+REVIEW_MODEL_TABLE+".attributes ->> ?::TEXT) = ANY(?)", name, values)
+			}
+		}
+	}
+
+	if search.Filters.Aspects != nil {
+		for aspect, sentiments := range *search.Filters.Aspects {
+			if len(sentiments) > 0 {
+				stmt.
+					Where(`EXISTS (SELECT 1 FROM `+REVIEW_ASPECT_MODEL_TABLE+` WHERE "review_id" = `+
+						REVIEW_MODEL_TABLE+`."id" AND "aspect" = ? AND "sentiment" = ANY(?))`, aspect, sentiments)
+			}
+		}
+	}
+
 	if search.Filters.SeenStartAt != nil {
 		stmt.
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at >= ?", *search.Filters.SeenStartAt)
@@ -143,6 +234,13 @@ func (self *ReviewRepository) ListByProductID(ctx context.Context,
 			Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", *search.Filters.SeenEndAt)
 	}
 
+	if search.Filters.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery(feedback.FEEDBACK_MODEL_TABLE+".id IN (", ")",
+				segment.NewSegmentFeedbacksQuery(*search.Filters.Segment))
+	}
+
 	if search.Pagination.From != nil {
 		if search.Orders.Recency == ReviewSearchOrdersAscending {
 			stmt.
@@ -177,9 +275,19 @@ func (self *ReviewRepository) ListByProductID(ctx context.Context,
 		return nil, err
 	}
 
+	ids := make([]string, 0, len(ms))
+	for _, m := range ms {
+		ids = append(ids, m.Review.ID)
+	}
+
+	aspects, err := self.listAspects(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
 	items := make([]Review, 0, len(ms))
 	for _, m := range ms {
-		items = append(items, *m.Review.ToEntity(m.Feedback))
+		items = append(items, *m.Review.ToEntity(m.Feedback, aspects[m.Review.ID]))
 	}
 
 	var cursor *util.Cursor[time.Time]
@@ -0,0 +1,198 @@
+package segment
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+	"github.com/rs/xid"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+const (
+	// Cached metrics and searches are keyed by their product followed by their query string, which holds the segment
+	SEGMENT_ENDPOINTS_CACHED_KEY = "*:endpoints:*%s*segment_id=%s"
+)
+
+type SegmentEndpoints struct {
+	config            config.Config
+	observer          *kit.Observer
+	segmentRepository *SegmentRepository
+	cache             *kit.Cache
+}
+
+func NewSegmentEndpoints(observer *kit.Observer, segmentRepository *SegmentRepository, cache *kit.Cache,
+	config config.Config) *SegmentEndpoints {
+	return &SegmentEndpoints{
+		config:            config,
+		observer:          observer,
+		segmentRepository: segmentRepository,
+		cache:             cache,
+	}
+}
+
+// invalidate deletes the cached metrics and searches sliced by the segment, so they are not served with its
+// previous definition.
+func (self *SegmentEndpoints) invalidate(ctx context.Context, segment Segment) error {
+	return util.InvalidateCache(ctx, self.cache,
+		fmt.Sprintf(SEGMENT_ENDPOINTS_CACHED_KEY, segment.ProductID, segment.ID))
+}
+
+type SegmentEndpointsSegmentRequest struct {
+	Name    string                 `json:"name"`
+	Filters []SegmentFilterPayload `json:"filters"`
+}
+
+func (self SegmentEndpointsSegmentRequest) filters() []SegmentFilter {
+	filters := make([]SegmentFilter, 0, len(self.Filters))
+	for _, filter := range self.Filters {
+		filters = append(filters, SegmentFilter{
+			Field:    filter.Field,
+			Key:      filter.Key,
+			Operator: filter.Operator,
+			Values:   filter.Values,
+		})
+	}
+
+	return filters
+}
+
+type SegmentEndpointsListSegmentsResponse struct {
+	Segments []SegmentPayload `json:"segments"`
+}
+
+func (self *SegmentEndpoints) ListSegments(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+
+	segments, err := self.segmentRepository.ListByProductID(requestCtx, requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := SegmentEndpointsListSegmentsResponse{}
+	response.Segments = make([]SegmentPayload, 0, len(segments))
+	for _, segment := range segments {
+		response.Segments = append(response.Segments, *NewSegmentPayload(segment))
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type SegmentEndpointsPostSegmentRequest struct {
+	SegmentEndpointsSegmentRequest
+}
+
+type SegmentEndpointsPostSegmentResponse struct {
+	SegmentPayload
+}
+
+func (self *SegmentEndpoints) PostSegment(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := SegmentEndpointsPostSegmentRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	segment := NewSegment()
+	segment.ID = xid.New().String()
+	segment.ProductID = requestProduct.ID
+	segment.CreatedAt = time.Now()
+	segment.UpdatedAt = segment.CreatedAt
+
+	err = segment.SetDefinition(request.Name, request.filters())
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	segment, err = self.segmentRepository.Create(requestCtx, *segment)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := SegmentEndpointsPostSegmentResponse{}
+	response.SegmentPayload = *NewSegmentPayload(*segment)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type SegmentEndpointsGetSegmentResponse struct {
+	SegmentPayload
+}
+
+func (self *SegmentEndpoints) GetSegment(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestSegment := RequestSegment(requestCtx)
+
+	response := SegmentEndpointsGetSegmentResponse{}
+	response.SegmentPayload = *NewSegmentPayload(*requestSegment)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type SegmentEndpointsPutSegmentRequest struct {
+	SegmentEndpointsSegmentRequest
+}
+
+type SegmentEndpointsPutSegmentResponse struct {
+	SegmentPayload
+}
+
+func (self *SegmentEndpoints) PutSegment(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestSegment := RequestSegment(requestCtx)
+	request := SegmentEndpointsPutSegmentRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	err = requestSegment.SetDefinition(request.Name, request.filters())
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	requestSegment.UpdatedAt = time.Now()
+
+	err = self.segmentRepository.UpdateDefinition(requestCtx, *requestSegment)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	err = self.invalidate(requestCtx, *requestSegment)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := SegmentEndpointsPutSegmentResponse{}
+	response.SegmentPayload = *NewSegmentPayload(*requestSegment)
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+func (self *SegmentEndpoints) DeleteSegment(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestSegment := RequestSegment(requestCtx)
+
+	err := self.segmentRepository.Delete(requestCtx, requestSegment.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	err = self.invalidate(requestCtx, *requestSegment)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, struct{}{})
+}
@@ -0,0 +1,173 @@
+package segment
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/neoxelox/errors"
+	kitUtil "github.com/neoxelox/kit/util"
+
+	"backend/pkg/feedback"
+	"backend/pkg/util"
+)
+
+const (
+	SEGMENT_MAX_NAME_LENGTH  = 50
+	SEGMENT_MAX_FILTERS      = 10
+	SEGMENT_MAX_VALUES       = 50
+	SEGMENT_MAX_VALUE_LENGTH = 100
+	SEGMENT_MAX_KEY_LENGTH   = 100
+)
+
+const (
+	SegmentFilterFieldSource   = "SOURCE"
+	SegmentFilterFieldCountry  = "COUNTRY"
+	SegmentFilterFieldRelease  = "RELEASE"
+	SegmentFilterFieldCustomer = "CUSTOMER"
+	SegmentFilterFieldMetadata = "METADATA"
+)
+
+func IsSegmentFilterField(value string) bool {
+	return value == SegmentFilterFieldSource ||
+		value == SegmentFilterFieldCountry ||
+		value == SegmentFilterFieldRelease ||
+		value == SegmentFilterFieldCustomer ||
+		value == SegmentFilterFieldMetadata
+}
+
+const (
+	SegmentFilterOperatorIs    = "IS"
+	SegmentFilterOperatorIsNot = "IS_NOT"
+)
+
+func IsSegmentFilterOperator(value string) bool {
+	return value == SegmentFilterOperatorIs ||
+		value == SegmentFilterOperatorIsNot
+}
+
+// Attributes of the customer of a feedback that segments can be defined by
+var SegmentFilterCustomerKeys = []string{"Email", "Name", "Location", "Verified", "Reviews", "Link"}
+
+var (
+	ErrSegmentInvalid = errors.New("segment is invalid")
+)
+
+// SegmentFilter matches the feedbacks whose field, or the key of it for the customer attributes and the metadata,
+// is or is not any of the values. Values are matched case-insensitively but for the sources and releases.
+type SegmentFilter struct {
+	Field    string
+	Key      *string
+	Operator string
+	Values   []string
+}
+
+type Segment struct {
+	ID        string
+	ProductID string
+	Name      string
+	Filters   []SegmentFilter
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewSegment() *Segment {
+	return &Segment{}
+}
+
+func (self Segment) String() string {
+	return fmt.Sprintf("<Segment: %s (%s)>", self.Name, self.ID)
+}
+
+func (self Segment) Equals(other Segment) bool {
+	return kitUtil.Equals(self, other)
+}
+
+func (self Segment) Copy() *Segment {
+	return kitUtil.Copy(self)
+}
+
+func newFilter(filter SegmentFilter) (*SegmentFilter, error) {
+	if !IsSegmentFilterField(filter.Field) {
+		return nil, ErrSegmentInvalid.Raise().With("field %s is invalid", filter.Field)
+	}
+
+	if !IsSegmentFilterOperator(filter.Operator) {
+		return nil, ErrSegmentInvalid.Raise().With("operator %s is invalid", filter.Operator)
+	}
+
+	switch filter.Field {
+	case SegmentFilterFieldCustomer:
+		if filter.Key == nil || !slices.Contains(SegmentFilterCustomerKeys, *filter.Key) {
+			return nil, ErrSegmentInvalid.Raise().With("customer attribute is invalid")
+		}
+
+	case SegmentFilterFieldMetadata:
+		if filter.Key == nil {
+			return nil, ErrSegmentInvalid.Raise().With("metadata key is missing")
+		}
+
+		key := strings.TrimSpace(*filter.Key)
+		if len(key) == 0 || len(key) > SEGMENT_MAX_KEY_LENGTH {
+			return nil, ErrSegmentInvalid.Raise().With("metadata key %s is invalid", key)
+		}
+		filter.Key = &key
+
+	default:
+		filter.Key = nil
+	}
+
+	if len(filter.Values) == 0 || len(filter.Values) > SEGMENT_MAX_VALUES {
+		return nil, ErrSegmentInvalid.Raise().With("filter values are invalid")
+	}
+
+	values := make([]string, 0, len(filter.Values))
+	for _, value := range filter.Values {
+		value = strings.TrimSpace(value)
+		if len(value) == 0 || len(value) > SEGMENT_MAX_VALUE_LENGTH {
+			return nil, ErrSegmentInvalid.Raise().With("value %s is invalid", value)
+		}
+
+		if filter.Field == SegmentFilterFieldSource && !feedback.IsFeedbackSource(value) {
+			return nil, ErrSegmentInvalid.Raise().With("source %s is invalid", value)
+		}
+
+		if filter.Field != SegmentFilterFieldSource && filter.Field != SegmentFilterFieldRelease {
+			value = strings.ToLower(value)
+		}
+
+		values = append(values, value)
+	}
+	filter.Values = util.Unique(values)
+
+	return &filter, nil
+}
+
+// SetDefinition normalizes the name and filters of a segment and validates them. Feedbacks are within the segment
+// when they match all of its filters.
+func (self *Segment) SetDefinition(name string, filters []SegmentFilter) error {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 || len(name) > SEGMENT_MAX_NAME_LENGTH {
+		return ErrSegmentInvalid.Raise().With("name %s is invalid", name)
+	}
+
+	if len(filters) == 0 || len(filters) > SEGMENT_MAX_FILTERS {
+		return ErrSegmentInvalid.Raise().With("filters are invalid")
+	}
+
+	normalized := make([]SegmentFilter, 0, len(filters))
+	for _, filter := range filters {
+		_filter, err := newFilter(filter)
+		if err != nil {
+			return err
+		}
+
+		normalized = append(normalized, *_filter)
+	}
+
+	self.Name = name
+	self.Filters = normalized
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package segment_test
+
+import (
+	"testing"
+
+	"backend/pkg/feedback"
+	"backend/pkg/segment"
+
+	"github.com/neoxelox/kit/util"
+	"github.com/stretchr/testify/suite"
+)
+
+type SegmentTestSuite struct {
+	suite.Suite
+}
+
+func TestSegmentSuite(t *testing.T) {
+	suite.Run(t, new(SegmentTestSuite))
+}
+
+func (self *SegmentTestSuite) TestSetDefinitionNormalizesFilters() {
+	// Given: A segment defined with untidy names, keys and values
+	entity := segment.NewSegment()
+
+	// When: Its definition is set
+	err := entity.SetDefinition(" Enterprise in Spain ", []segment.SegmentFilter{
+		{Field: segment.SegmentFilterFieldSource, Key: util.Pointer("ignored"), Operator: segment.SegmentFilterOperatorIs,
+			Values: []string{feedback.FeedbackSourceTrustpilot, feedback.FeedbackSourceTrustpilot}},
+		{Field: segment.SegmentFilterFieldCountry, Operator: segment.SegmentFilterOperatorIs, Values: []string{" Spain"}},
+		{Field: segment.SegmentFilterFieldRelease, Operator: segment.SegmentFilterOperatorIsNot, Values: []string{"V1.0"}},
+		{Field: segment.SegmentFilterFieldMetadata, Key: util.Pointer(" plan "),
+			Operator: segment.SegmentFilterOperatorIs, Values: []string{"Enterprise"}},
+	})
+
+	// Then: The name, keys and values are trimmed and the values lowercased but for the sources and releases
+	self.Require().NoError(err)
+	self.Equal("Enterprise in Spain", entity.Name)
+	self.Equal([]segment.SegmentFilter{
+		{Field: segment.SegmentFilterFieldSource, Operator: segment.SegmentFilterOperatorIs,
+			Values: []string{feedback.FeedbackSourceTrustpilot}},
+		{Field: segment.SegmentFilterFieldCountry, Operator: segment.SegmentFilterOperatorIs, Values: []string{"spain"}},
+		{Field: segment.SegmentFilterFieldRelease, Operator: segment.SegmentFilterOperatorIsNot, Values: []string{"V1.0"}},
+		{Field: segment.SegmentFilterFieldMetadata, Key: util.Pointer("plan"),
+			Operator: segment.SegmentFilterOperatorIs, Values: []string{"enterprise"}},
+	}, entity.Filters)
+}
+
+func (self *SegmentTestSuite) TestSetDefinitionRejectsInvalidFilters() {
+	// Given: Filters that cannot be matched against the feedbacks
+	invalids := [][]segment.SegmentFilter{
+		{},
+		{{Field: "LANGUAGE", Operator: segment.SegmentFilterOperatorIs, Values: []string{"en"}}},
+		{{Field: segment.SegmentFilterFieldSource, Operator: segment.SegmentFilterOperatorIs, Values: []string{"FAX"}}},
+		{{Field: segment.SegmentFilterFieldCustomer, Key: util.Pointer("Password"),
+			Operator: segment.SegmentFilterOperatorIs, Values: []string{"secret"}}},
+		{{Field: segment.SegmentFilterFieldMetadata, Operator: segment.SegmentFilterOperatorIs, Values: []string{"pro"}}},
+		{{Field: segment.SegmentFilterFieldCountry, Operator: segment.SegmentFilterOperatorIsNot, Values: []string{" "}}},
+	}
+
+	for _, filters := range invalids {
+		entity := segment.NewSegment()
+
+		// When: A segment is defined by them
+		err := entity.SetDefinition("Segment", filters)
+
+		// Then: The definition is rejected
+		self.True(segment.ErrSegmentInvalid.Is(err))
+		self.Empty(entity.Name)
+	}
+}
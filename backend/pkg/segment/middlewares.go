@@ -0,0 +1,76 @@
+package segment
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+)
+
+var (
+	KeyRequestSegment kit.Key = kit.KeyBase + "request:segment"
+)
+
+// RequestSegment returns the segment of the request, or nil when the request is not sliced by any.
+func RequestSegment(ctx context.Context) *Segment {
+	segment, _ := ctx.Value(KeyRequestSegment).(*Segment)
+	return segment
+}
+
+type SegmentMiddlewares struct {
+	config            config.Config
+	observer          *kit.Observer
+	segmentRepository *SegmentRepository
+}
+
+func NewSegmentMiddlewares(observer *kit.Observer, segmentRepository *SegmentRepository,
+	config config.Config) *SegmentMiddlewares {
+	return &SegmentMiddlewares{
+		config:            config,
+		observer:          observer,
+		segmentRepository: segmentRepository,
+	}
+}
+
+func (self *SegmentMiddlewares) handle(ctx echo.Context, next echo.HandlerFunc, id string) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+
+	segment, err := self.segmentRepository.GetByID(requestCtx, id)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	if segment == nil {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	if segment.ProductID != requestProduct.ID {
+		return kit.HTTPErrUnauthorized
+	}
+
+	ctx.SetRequest(ctx.Request().WithContext(context.WithValue(requestCtx, KeyRequestSegment, segment)))
+
+	return next(ctx)
+}
+
+func (self *SegmentMiddlewares) HandleSegment(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		return self.handle(ctx, next, ctx.Param("segment_id"))
+	}
+}
+
+// HandleOptionalSegment slices the request by the segment given in the query, if any.
+func (self *SegmentMiddlewares) HandleOptionalSegment(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		id := ctx.QueryParam("segment_id")
+		if len(id) == 0 {
+			return next(ctx)
+		}
+
+		return self.handle(ctx, next, id)
+	}
+}
@@ -0,0 +1,52 @@
+package segment
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	SEGMENT_MODEL_TABLE = "\"segment\""
+)
+
+type SegmentModel struct {
+	ID        string    `db:"id"`
+	ProductID string    `db:"product_id"`
+	Name      string    `db:"name"`
+	Filters   []byte    `db:"filters"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func NewSegmentModel(segment Segment) *SegmentModel {
+	filters, err := json.Marshal(segment.Filters)
+	if err != nil {
+		panic(err)
+	}
+
+	return &SegmentModel{
+		ID:        segment.ID,
+		ProductID: segment.ProductID,
+		Name:      segment.Name,
+		Filters:   filters,
+		CreatedAt: segment.CreatedAt,
+		UpdatedAt: segment.UpdatedAt,
+	}
+}
+
+func (self *SegmentModel) ToEntity() *Segment {
+	var filters []SegmentFilter
+	err := json.Unmarshal(self.Filters, &filters)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Segment{
+		ID:        self.ID,
+		ProductID: self.ProductID,
+		Name:      self.Name,
+		Filters:   filters,
+		CreatedAt: self.CreatedAt,
+		UpdatedAt: self.UpdatedAt,
+	}
+}
@@ -0,0 +1,46 @@
+package segment
+
+import (
+	"time"
+)
+
+type SegmentFilterPayload struct {
+	Field    string   `json:"field"`
+	Key      *string  `json:"key"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values"`
+}
+
+func NewSegmentFilterPayload(filter SegmentFilter) *SegmentFilterPayload {
+	return &SegmentFilterPayload{
+		Field:    filter.Field,
+		Key:      filter.Key,
+		Operator: filter.Operator,
+		Values:   filter.Values,
+	}
+}
+
+type SegmentPayload struct {
+	ID        string                 `json:"id"`
+	ProductID string                 `json:"product_id"`
+	Name      string                 `json:"name"`
+	Filters   []SegmentFilterPayload `json:"filters"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+func NewSegmentPayload(segment Segment) *SegmentPayload {
+	filters := make([]SegmentFilterPayload, 0, len(segment.Filters))
+	for _, filter := range segment.Filters {
+		filters = append(filters, *NewSegmentFilterPayload(filter))
+	}
+
+	return &SegmentPayload{
+		ID:        segment.ID,
+		ProductID: segment.ProductID,
+		Name:      segment.Name,
+		Filters:   filters,
+		CreatedAt: segment.CreatedAt,
+		UpdatedAt: segment.UpdatedAt,
+	}
+}
@@ -0,0 +1,184 @@
+package segment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/feedback"
+	"backend/pkg/util"
+)
+
+type SegmentRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewSegmentRepository(observer *kit.Observer, database *kit.Database, config config.Config) *SegmentRepository {
+	return &SegmentRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *SegmentRepository) Create(ctx context.Context, segment Segment) (*Segment, error) {
+	s := NewSegmentModel(segment)
+
+	stmt := sqlf.
+		InsertInto(SEGMENT_MODEL_TABLE).
+		Set("id", s.ID).
+		Set("product_id", s.ProductID).
+		Set("name", s.Name).
+		Set("filters", s.Filters).
+		Set("created_at", s.CreatedAt).
+		Set("updated_at", s.UpdatedAt).
+		Returning("*").To(&s)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ToEntity(), nil
+}
+
+func (self *SegmentRepository) GetByID(ctx context.Context, id string) (*Segment, error) {
+	var s SegmentModel
+
+	stmt := sqlf.
+		Select("*").To(&s).
+		From(SEGMENT_MODEL_TABLE).
+		Where("id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return s.ToEntity(), nil
+}
+
+func (self *SegmentRepository) ListByProductID(ctx context.Context, productID string) ([]Segment, error) {
+	var ss []SegmentModel
+
+	stmt := sqlf.
+		Select("*").To(&ss).
+		From(SEGMENT_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		OrderBy("name ASC", "id ASC")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []Segment{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]Segment, 0, len(ss))
+	for _, s := range ss {
+		entities = append(entities, *s.ToEntity())
+	}
+
+	return entities, nil
+}
+
+func (self *SegmentRepository) UpdateDefinition(ctx context.Context, segment Segment) error {
+	s := NewSegmentModel(segment)
+
+	stmt := sqlf.
+		Update(SEGMENT_MODEL_TABLE).
+		Set("name", s.Name).
+		Set("filters", s.Filters).
+		Set("updated_at", s.UpdatedAt).
+		Where("id = ?", s.ID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+func (self *SegmentRepository) Delete(ctx context.Context, id string) error {
+	stmt := sqlf.
+		DeleteFrom(SEGMENT_MODEL_TABLE).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+// NewSegmentFeedbacksQuery selects the ids of the feedbacks within the segment, so the queries of other
+// repositories can be sliced by it as a subquery.
+func NewSegmentFeedbacksQuery(segment Segment) *sqlf.Stmt {
+	stmt := sqlf.
+		Select(feedback.FEEDBACK_MODEL_TABLE+".id").
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".product_id = ?", segment.ProductID)
+
+	for _, filter := range segment.Filters {
+		var expression string
+		args := []any{}
+
+		switch filter.Field {
+		case SegmentFilterFieldSource:
+			expression = feedback.FEEDBACK_MODEL_TABLE + ".source"
+		case SegmentFilterFieldRelease:
+			expression = feedback.FEEDBACK_MODEL_TABLE + ".release"
+		case SegmentFilterFieldCountry:
+			// The country is the last part of the location of the customer, which can be just the country
+			expression = fmt.Sprintf("lower(trim(regexp_replace(%s.customer ->> 'Location', '^.*,', '')))",
+				feedback.FEEDBACK_MODEL_TABLE)
+		case SegmentFilterFieldCustomer:
+			expression = fmt.Sprintf("lower(%s.customer ->> ?::TEXT)", feedback.FEEDBACK_MODEL_TABLE)
+			args = append(args, *filter.Key)
+		case SegmentFilterFieldMetadata:
+			expression = fmt.Sprintf("lower(%s.metadata ->> ?::TEXT)", feedback.FEEDBACK_MODEL_TABLE)
+			args = append(args, *filter.Key)
+		}
+
+		if filter.Operator == SegmentFilterOperatorIsNot {
+			stmt.
+				Where("COALESCE("+expression+", '') NOT", args...).In(util.Spread(filter.Values)...)
+		} else {
+			stmt.
+				Where(expression, args...).In(util.Spread(filter.Values)...)
+		}
+	}
+
+	return stmt
+}
+
+// NewSegmentLinksQuery selects the ids, in the column of the table linking them to their feedbacks, of the issues
+// or suggestions with any feedback within the segment, so they can be sliced by it as a subquery.
+func NewSegmentLinksQuery(segment Segment, table string, column string) *sqlf.Stmt {
+	return sqlf.
+		Select(table+"."+column).
+		From(table).
+		Where("").
+		SubQuery(table+".feedback_id IN (", ")", NewSegmentFeedbacksQuery(segment))
+}
@@ -3,6 +3,7 @@ package suggestion
 import (
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"backend/pkg/feedback"
 	"backend/pkg/organization"
 	"backend/pkg/product"
+	"backend/pkg/segment"
 	"backend/pkg/user"
 	"backend/pkg/util"
 )
@@ -27,6 +29,8 @@ const (
 	SUGGESTION_ENDPOINTS_SEARCH_MAX_LIMIT          = 100
 	SUGGESTION_ENDPOINTS_SEARCH_MIN_CONTENT_LENGTH = 5
 	SUGGESTION_ENDPOINTS_SEARCH_MAX_CONTENT_LENGTH = 250
+	SUGGESTION_ENDPOINTS_TRANSLATION_KEY           = "suggestion:endpoints:translation:"
+	SUGGESTION_ENDPOINTS_TRANSLATION_TTL           = 7 * 24 * time.Hour
 )
 
 type SuggestionEndpoints struct {
@@ -67,6 +71,7 @@ type SuggestionEndpointsListSuggestionsRequest struct {
 	}
 	Orders struct {
 		Relevance *string `query:"relevance"`
+		Revenue   *string `query:"revenue"`
 	}
 	Pagination struct {
 		Limit *int    `query:"limit"`
@@ -129,6 +134,13 @@ func (self *SuggestionEndpoints) ListSuggestions(ctx echo.Context) error {
 		request.Orders.Relevance = kitUtil.Pointer(SuggestionSearchOrdersDescending)
 	}
 
+	if request.Orders.Revenue != nil {
+		if *request.Orders.Revenue != SuggestionSearchOrdersDescending &&
+			*request.Orders.Revenue != SuggestionSearchOrdersAscending {
+			return kit.HTTPErrInvalidRequest
+		}
+	}
+
 	if request.Pagination.Limit != nil {
 		if *request.Pagination.Limit > SUGGESTION_ENDPOINTS_SEARCH_MAX_LIMIT {
 			return kit.HTTPErrInvalidRequest
@@ -139,12 +151,15 @@ func (self *SuggestionEndpoints) ListSuggestions(ctx echo.Context) error {
 
 	var embedding []float32
 	if request.Filters.Content != nil {
-		err := self.cache.Get(requestCtx, SUGGESTION_ENDPOINTS_SEARCH_CONTENT_KEY+*request.Filters.Content, &embedding)
+		contentKey := SUGGESTION_ENDPOINTS_SEARCH_CONTENT_KEY + requestProduct.EmbeddingModel + ":" + *request.Filters.Content
+
+		err := self.cache.Get(requestCtx, contentKey, &embedding)
 		if err != nil {
 			if kit.ErrCacheMiss.Is(err) {
 				result, err := self.engineService.ComputeEmbedding(requestCtx,
 					engine.EngineServiceComputeEmbeddingParams{
-						Text: *request.Filters.Content,
+						Text:  *request.Filters.Content,
+						Model: requestProduct.EmbeddingModel,
 					})
 				if err != nil {
 					return kit.HTTPErrServerGeneric.Cause(err)
@@ -152,8 +167,7 @@ func (self *SuggestionEndpoints) ListSuggestions(ctx echo.Context) error {
 
 				embedding = result.Embedding
 
-				err = self.cache.Set(requestCtx, SUGGESTION_ENDPOINTS_SEARCH_CONTENT_KEY+*request.Filters.Content,
-					embedding, kitUtil.Pointer(SUGGESTION_ENDPOINTS_SEARCH_CONTENT_TTL))
+				err = self.cache.Set(requestCtx, contentKey, embedding, kitUtil.Pointer(SUGGESTION_ENDPOINTS_SEARCH_CONTENT_TTL))
 				if err != nil {
 					return kit.HTTPErrServerGeneric.Cause(err)
 				}
@@ -166,6 +180,7 @@ func (self *SuggestionEndpoints) ListSuggestions(ctx echo.Context) error {
 	page, err := self.suggestionRepository.ListByProductID(requestCtx, requestProduct.ID, SuggestionSearch{
 		Filters: SuggestionSearchFilters{
 			Embedding:        &embedding,
+			EmbeddingModel:   &requestProduct.EmbeddingModel,
 			Sources:          &request.Filters.Sources,
 			Importances:      &request.Filters.Importances,
 			Releases:         &request.Filters.Releases,
@@ -176,9 +191,11 @@ func (self *SuggestionEndpoints) ListSuggestions(ctx echo.Context) error {
 			FirstSeenEndAt:   request.Filters.FirstSeenEndAt,
 			LastSeenStartAt:  request.Filters.LastSeenStartAt,
 			LastSeenEndAt:    request.Filters.LastSeenEndAt,
+			Segment:          segment.RequestSegment(requestCtx),
 		},
 		Orders: SuggestionSearchOrders{
 			Relevance: *request.Orders.Relevance,
+			Revenue:   request.Orders.Revenue,
 		},
 		Pagination: util.Pagination[SuggestionSearchCursor]{
 			Limit: *request.Pagination.Limit,
@@ -224,6 +241,81 @@ func (self *SuggestionEndpoints) GetSuggestion(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, &response)
 }
 
+type SuggestionEndpointsGetSuggestionTranslationRequest struct {
+	Language *string `query:"language"`
+}
+
+type SuggestionEndpointsGetSuggestionTranslationResponse struct {
+	SuggestionTranslationPayload
+}
+
+func (self *SuggestionEndpoints) GetSuggestionTranslation(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestMe := user.RequestMe(requestCtx)
+	requestProduct := product.RequestProduct(requestCtx)
+	requestSuggestion := RequestSuggestion(requestCtx)
+	request := SuggestionEndpointsGetSuggestionTranslationRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	// Defaults to the preferred language of the reader, if any, else to the language of the product
+	language := requestProduct.Language
+	if request.Language != nil {
+		language = *request.Language
+	} else if requestMe.Settings.Language != nil {
+		language = *requestMe.Settings.Language
+	}
+
+	if !product.IsLanguageSupported(language) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	response := SuggestionEndpointsGetSuggestionTranslationResponse{}
+	response.SuggestionTranslationPayload = *NewSuggestionTranslationPayload(language, *requestSuggestion)
+
+	if language == requestProduct.Language {
+		return ctx.JSON(http.StatusOK, &response)
+	}
+
+	// The texts of the suggestion only change when it is aggregated, so the translation is cached until then
+	version := requestSuggestion.CreatedAt
+	if requestSuggestion.LastAggregatedAt != nil {
+		version = *requestSuggestion.LastAggregatedAt
+	}
+	key := SUGGESTION_ENDPOINTS_TRANSLATION_KEY + requestSuggestion.ID + ":" + language + ":" +
+		strconv.FormatInt(version.UnixMilli(), 10)
+
+	err = self.cache.Get(requestCtx, key, &response)
+	if err != nil && !kit.ErrCacheMiss.Is(err) {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	} else if err == nil {
+		return ctx.JSON(http.StatusOK, &response)
+	}
+
+	result, err := self.engineService.TranslateTexts(requestCtx, engine.EngineServiceTranslateTextsParams{
+		Texts:        []string{requestSuggestion.Title, requestSuggestion.Description, requestSuggestion.Reason},
+		FromLanguage: requestProduct.Language,
+		ToLanguage:   language,
+	})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response.Title = result.Translations[0]
+	response.Description = result.Translations[1]
+	response.Reason = result.Translations[2]
+
+	err = self.cache.Set(requestCtx, key, &response, kitUtil.Pointer(SUGGESTION_ENDPOINTS_TRANSLATION_TTL))
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
 type SuggestionEndpointsListSuggestionFeedbacksRequest struct {
 	From *string `query:"from"`
 }
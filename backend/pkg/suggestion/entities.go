@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"backend/pkg/engine"
+	"backend/pkg/priority"
+	"backend/pkg/segment"
 	"backend/pkg/util"
 
 	kitUtil "github.com/neoxelox/kit/util"
@@ -41,6 +43,7 @@ type Suggestion struct {
 	ID               string
 	ProductID        string
 	Embedding        []float32
+	EmbeddingModel   string
 	Sources          map[string]int
 	Title            string
 	Description      string
@@ -50,6 +53,7 @@ type Suggestion struct {
 	Categories       map[string]int
 	Releases         map[string]int
 	Customers        int
+	RevenueAtRisk    int
 	AssigneeID       *string
 	Quality          *int
 	FirstSeenAt      time.Time
@@ -97,8 +101,20 @@ func computeImportance(importances map[string]int) string {
 	return majorImportance
 }
 
-func ComputePriority(importances map[string]int, customers int) int {
-	return SuggestionImportanceWeight[computeImportance(importances)] * customers
+// ComputePriority scores the suggestion with the formula of its product. The trend is only needed by trending
+// formulas.
+func ComputePriority(formula priority.PriorityFormula, suggestion Suggestion, trend *priority.PriorityTrend) int {
+	importance := computeImportance(suggestion.Importances)
+
+	return formula.Compute(priority.PriorityFactors{
+		Level:       importance,
+		LevelWeight: float64(SuggestionImportanceWeight[importance]),
+		Sources:     suggestion.Sources,
+		Customers:   suggestion.Customers,
+		LastSeenAt:  suggestion.LastSeenAt,
+		Trend:       trend,
+		Revenue:     suggestion.RevenueAtRisk,
+	}, time.Now())
 }
 func computeCategory(categories map[string]int) string {
 	majorCategory := engine.OPTION_UNKNOWN
@@ -133,6 +149,7 @@ func IsSuggestionSearchFiltersStatus(value string) bool {
 
 type SuggestionSearchFilters struct {
 	Embedding        *[]float32
+	EmbeddingModel   *string
 	Sources          *[]string
 	Importances      *[]string
 	Releases         *[]string
@@ -143,6 +160,7 @@ type SuggestionSearchFilters struct {
 	FirstSeenEndAt   *time.Time
 	LastSeenStartAt  *time.Time
 	LastSeenEndAt    *time.Time
+	Segment          *segment.Segment
 }
 
 const (
@@ -152,11 +170,14 @@ const (
 
 type SuggestionSearchOrders struct {
 	Relevance string
+	// Orders by the revenue at risk instead of the relevance if set
+	Revenue *string
 }
 
 type SuggestionSearchCursor struct {
-	Priority int
-	SeenAt   time.Time
+	Priority      int
+	RevenueAtRisk int
+	SeenAt        time.Time
 }
 
 type SuggestionSearch struct {
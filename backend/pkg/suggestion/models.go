@@ -21,6 +21,7 @@ type SuggestionModel struct {
 	ID               string          `db:"id"`
 	ProductID        string          `db:"product_id"`
 	Embedding        pgvector.Vector `db:"embedding"`
+	EmbeddingModel   string          `db:"embedding_model"`
 	Sources          []byte          `db:"sources"`
 	Title            string          `db:"title"`
 	Description      string          `db:"description"`
@@ -30,6 +31,7 @@ type SuggestionModel struct {
 	Categories       []byte          `db:"categories"`
 	Releases         []byte          `db:"releases"`
 	Customers        int             `db:"customers"`
+	RevenueAtRisk    int             `db:"revenue_at_risk"`
 	AssigneeID       *string         `db:"assignee_id"`
 	Quality          *int            `db:"quality"`
 	FirstSeenAt      time.Time       `db:"first_seen_at"`
@@ -67,6 +69,7 @@ func NewSuggestionModel(suggestion Suggestion) *SuggestionModel {
 		ID:               suggestion.ID,
 		ProductID:        suggestion.ProductID,
 		Embedding:        embedding,
+		EmbeddingModel:   suggestion.EmbeddingModel,
 		Sources:          sources,
 		Title:            suggestion.Title,
 		Description:      suggestion.Description,
@@ -76,6 +79,7 @@ func NewSuggestionModel(suggestion Suggestion) *SuggestionModel {
 		Categories:       categories,
 		Releases:         releases,
 		Customers:        suggestion.Customers,
+		RevenueAtRisk:    suggestion.RevenueAtRisk,
 		AssigneeID:       suggestion.AssigneeID,
 		Quality:          suggestion.Quality,
 		FirstSeenAt:      suggestion.FirstSeenAt,
@@ -118,6 +122,7 @@ func (self *SuggestionModel) ToEntity() *Suggestion {
 		ID:               self.ID,
 		ProductID:        self.ProductID,
 		Embedding:        embedding,
+		EmbeddingModel:   self.EmbeddingModel,
 		Sources:          sources,
 		Title:            self.Title,
 		Description:      self.Description,
@@ -127,6 +132,7 @@ func (self *SuggestionModel) ToEntity() *Suggestion {
 		Categories:       categories,
 		Releases:         releases,
 		Customers:        self.Customers,
+		RevenueAtRisk:    self.RevenueAtRisk,
 		AssigneeID:       self.AssigneeID,
 		Quality:          self.Quality,
 		FirstSeenAt:      self.FirstSeenAt,
@@ -2,6 +2,7 @@ package suggestion
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -10,6 +11,8 @@ import (
 
 	"backend/pkg/config"
 	"backend/pkg/feedback"
+	"backend/pkg/priority"
+	"backend/pkg/segment"
 	"backend/pkg/util"
 
 	"github.com/pgvector/pgvector-go"
@@ -40,6 +43,7 @@ func (self *SuggestionRepository) Create(ctx context.Context,
 			Set("id", s.ID).
 			Set("product_id", s.ProductID).
 			Set("embedding", s.Embedding).
+			Set("embedding_model", s.EmbeddingModel).
 			Set("sources", s.Sources).
 			Set("title", s.Title).
 			Set("description", s.Description).
@@ -87,6 +91,46 @@ func (self *SuggestionRepository) Create(ctx context.Context,
 	return s.ToEntity(), nil
 }
 
+// Restore inserts back a suggestion that was deleted, without linking any feedback to it.
+func (self *SuggestionRepository) Restore(ctx context.Context, suggestion Suggestion) error {
+	s := NewSuggestionModel(suggestion)
+
+	stmt := sqlf.
+		InsertInto(SUGGESTION_MODEL_TABLE).
+		Set("id", s.ID).
+		Set("product_id", s.ProductID).
+		Set("embedding", s.Embedding).
+		Set("embedding_model", s.EmbeddingModel).
+		Set("sources", s.Sources).
+		Set("title", s.Title).
+		Set("description", s.Description).
+		Set("reason", s.Reason).
+		Set("importances", s.Importances).
+		Set("priority", s.Priority).
+		Set("categories", s.Categories).
+		Set("releases", s.Releases).
+		Set("customers", s.Customers).
+		Set("assignee_id", s.AssigneeID).
+		Set("quality", s.Quality).
+		Set("first_seen_at", s.FirstSeenAt).
+		Set("last_seen_at", s.LastSeenAt).
+		Set("created_at", s.CreatedAt).
+		Set("archived_at", s.ArchivedAt).
+		Set("last_aggregated_at", s.LastAggregatedAt).
+		Set("exported_at", s.ExportedAt)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
 func (self *SuggestionRepository) GetByID(ctx context.Context, id string) (*Suggestion, error) {
 	var s SuggestionModel
 
@@ -139,6 +183,7 @@ func (self *SuggestionRepository) ListByProductID(ctx context.Context,
 
 	if search.Filters.Embedding != nil && len(*search.Filters.Embedding) > 0 {
 		stmt.
+			Where("embedding_model = ?", search.Filters.EmbeddingModel).
 			Where("(1 - (embedding <=> ?::vector)) >= ?", pgvector.NewVector(*search.Filters.Embedding), 0.3)
 	}
 
@@ -237,24 +282,43 @@ func (self *SuggestionRepository) ListByProductID(ctx context.Context,
 			Where("last_seen_at <= ?", *search.Filters.LastSeenEndAt)
 	}
 
+	if search.Filters.Segment != nil {
+		stmt.
+			Where("").
+			SubQuery("id IN (", ")",
+				segment.NewSegmentLinksQuery(*search.Filters.Segment, SUGGESTION_FEEDBACK_MODEL_TABLE, "suggestion_id"))
+	}
+
+	column := "priority"
+	order := search.Orders.Relevance
+	if search.Orders.Revenue != nil {
+		column = "revenue_at_risk"
+		order = *search.Orders.Revenue
+	}
+
 	if search.Pagination.From != nil {
-		if search.Orders.Relevance == SuggestionSearchOrdersAscending {
+		value := search.Pagination.From.Value.Priority
+		if search.Orders.Revenue != nil {
+			value = search.Pagination.From.Value.RevenueAtRisk
+		}
+
+		if order == SuggestionSearchOrdersAscending {
 			stmt.
-				Where("(priority, last_seen_at, id) > (?, ?, ?)", search.Pagination.From.Value.Priority,
+				Where("("+column+", last_seen_at, id) > (?, ?, ?)", value,
 					search.Pagination.From.Value.SeenAt, search.Pagination.From.ID)
 		} else {
 			stmt.
-				Where("(priority, last_seen_at, id) < (?, ?, ?)", search.Pagination.From.Value.Priority,
+				Where("("+column+", last_seen_at, id) < (?, ?, ?)", value,
 					search.Pagination.From.Value.SeenAt, search.Pagination.From.ID)
 		}
 	}
 
-	if search.Orders.Relevance == SuggestionSearchOrdersAscending {
+	if order == SuggestionSearchOrdersAscending {
 		stmt.
-			OrderBy("priority ASC", "last_seen_at ASC", "id ASC")
+			OrderBy(column+" ASC", "last_seen_at ASC", "id ASC")
 	} else {
 		stmt.
-			OrderBy("priority DESC", "last_seen_at DESC", "id DESC")
+			OrderBy(column+" DESC", "last_seen_at DESC", "id DESC")
 	}
 
 	stmt.
@@ -278,8 +342,9 @@ func (self *SuggestionRepository) ListByProductID(ctx context.Context,
 	if len(items) == search.Pagination.Limit {
 		cursor = &util.Cursor[SuggestionSearchCursor]{
 			Value: SuggestionSearchCursor{
-				Priority: items[search.Pagination.Limit-1].Priority,
-				SeenAt:   items[search.Pagination.Limit-1].LastSeenAt,
+				Priority:      items[search.Pagination.Limit-1].Priority,
+				RevenueAtRisk: items[search.Pagination.Limit-1].RevenueAtRisk,
+				SeenAt:        items[search.Pagination.Limit-1].LastSeenAt,
 			},
 			ID: items[search.Pagination.Limit-1].ID,
 		}
@@ -291,28 +356,120 @@ func (self *SuggestionRepository) ListByProductID(ctx context.Context,
 	}, nil
 }
 
-func (self *SuggestionRepository) ListByEmbeddingAndProductID(ctx context.Context,
-	embedding []float32, threshold float64, limit int, productID string) ([]Suggestion, error) {
+func (self *SuggestionRepository) ListRandomByProductID(ctx context.Context,
+	productID string, limit int) ([]Suggestion, error) {
+	var ss []SuggestionModel
+
+	stmt := sqlf.
+		Select("*").To(&ss).
+		From(SUGGESTION_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		OrderBy("random()").
+		Limit(limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []Suggestion{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]Suggestion, 0, len(ss))
+	for _, s := range ss {
+		entities = append(entities, *s.ToEntity())
+	}
+
+	return entities, nil
+}
+
+func (self *SuggestionRepository) ListByProductIDAndNotArchived(ctx context.Context, productID string,
+	pagination util.Pagination[time.Time]) (*util.Page[Suggestion, time.Time], error) {
+	var ss []SuggestionModel
+
+	stmt := sqlf.
+		Select("*").To(&ss).
+		From(SUGGESTION_MODEL_TABLE).
+		Where("product_id = ?", productID).
+		Where("archived_at IS NULL")
+
+	if pagination.From != nil {
+		stmt.
+			Where("(created_at, id) > (?, ?)", pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy("created_at ASC", "id ASC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[Suggestion, time.Time]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]Suggestion, 0, len(ss))
+	for _, s := range ss {
+		items = append(items, *s.ToEntity())
+	}
+
+	var cursor *util.Cursor[time.Time]
+	if len(ss) == pagination.Limit {
+		cursor = &util.Cursor[time.Time]{
+			Value: ss[pagination.Limit-1].CreatedAt,
+			ID:    ss[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[Suggestion, time.Time]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
+
+// ListByEmbeddingAndProductID only compares the embedding with the ones computed by the same model.
+// The search is approximate, tuned by the configured vector search parameters unless others are given.
+func (self *SuggestionRepository) ListByEmbeddingAndProductID(ctx context.Context, embedding []float32,
+	embeddingModel string, threshold float64, limit int, productID string,
+	search *util.VectorSearch) ([]Suggestion, error) {
+	if search == nil {
+		search = util.NewVectorSearch(self.config)
+	}
+
 	var result []struct {
 		SuggestionModel
-		Score float64 `db:"score"`
+		Distance float64 `db:"distance"`
 	}
 
+	// The inner query must be ordered by the raw distance so it can be served by the HNSW index
 	stmt := sqlf.
 		Select("*").To(&result).
 		From("").
 		SubQuery("(", ")", sqlf.
 			Select("*").
-			Select("1 - (embedding <=> ?::vector) AS score", pgvector.NewVector(embedding)).
+			Select("embedding <=> ?::vector AS distance", pgvector.NewVector(embedding)).
 			From(SUGGESTION_MODEL_TABLE).
 			Where("product_id = ?", productID).
-			OrderBy("score DESC").
+			Where("embedding_model = ?", embeddingModel).
+			OrderBy("distance ASC").
 			Limit(limit)).
-		Where("score >= ?", threshold)
+		Where("1 - distance >= ?", threshold).
+		OrderBy("distance ASC")
 
-	err := self.database.Query(ctx, stmt)
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := search.Apply(ctx, self.database)
+		if err != nil {
+			return err
+		}
+
+		return self.database.Query(ctx, stmt)
+	})
 	if err != nil {
-		if kit.ErrDatabaseNoRows.Is(err) {
+		if kit.ErrDatabaseNoRows.In(err) {
 			return []Suggestion{}, nil
 		}
 
@@ -377,6 +534,245 @@ func (self *SuggestionRepository) ListFeedbacks(ctx context.Context,
 	}, nil
 }
 
+// ListFeedbacksByIDs returns which of the feedbacks are linked to a suggestion.
+func (self *SuggestionRepository) ListFeedbacksByIDs(ctx context.Context,
+	id string, feedbackIDs []string) ([]feedback.Feedback, error) {
+	var fs []feedback.FeedbackModel
+
+	if len(feedbackIDs) == 0 {
+		return []feedback.Feedback{}, nil
+	}
+
+	stmt := sqlf.
+		Select(feedback.FEEDBACK_MODEL_TABLE+".*").To(&fs).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Join(SUGGESTION_FEEDBACK_MODEL_TABLE,
+			SUGGESTION_FEEDBACK_MODEL_TABLE+".feedback_id = "+feedback.FEEDBACK_MODEL_TABLE+".id").
+		Where(SUGGESTION_FEEDBACK_MODEL_TABLE+".suggestion_id = ?", id).
+		Where(SUGGESTION_FEEDBACK_MODEL_TABLE+".feedback_id").In(util.Spread(feedbackIDs)...).
+		OrderBy(feedback.FEEDBACK_MODEL_TABLE+".posted_at ASC", feedback.FEEDBACK_MODEL_TABLE+".id ASC")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []feedback.Feedback{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]feedback.Feedback, 0, len(fs))
+	for _, f := range fs {
+		entities = append(entities, *f.ToEntity())
+	}
+
+	return entities, nil
+}
+
+func (self *SuggestionRepository) CountFeedbacks(ctx context.Context, id string) (int, error) {
+	var c int
+
+	stmt := sqlf.
+		Select("COUNT(*)").To(&c).
+		From(SUGGESTION_FEEDBACK_MODEL_TABLE).
+		Where("suggestion_id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return c, nil
+}
+
+// GetSeenAt returns when the first and the last feedbacks of a suggestion were posted, or nil if it has none.
+func (self *SuggestionRepository) GetSeenAt(ctx context.Context, id string) (*time.Time, *time.Time, error) {
+	var firstSeenAt *time.Time
+	var lastSeenAt *time.Time
+
+	stmt := sqlf.
+		Select("MIN("+feedback.FEEDBACK_MODEL_TABLE+".posted_at)").To(&firstSeenAt).
+		Select("MAX("+feedback.FEEDBACK_MODEL_TABLE+".posted_at)").To(&lastSeenAt).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Join(SUGGESTION_FEEDBACK_MODEL_TABLE,
+			SUGGESTION_FEEDBACK_MODEL_TABLE+".feedback_id = "+feedback.FEEDBACK_MODEL_TABLE+".id").
+		Where(SUGGESTION_FEEDBACK_MODEL_TABLE+".suggestion_id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil, nil
+		}
+
+		return nil, nil, err
+	}
+
+	return firstSeenAt, lastSeenAt, nil
+}
+
+// GetTrend counts the feedbacks of a suggestion posted within the window and within the window before.
+func (self *SuggestionRepository) GetTrend(ctx context.Context, id string,
+	window time.Duration, now time.Time) (*priority.PriorityTrend, error) {
+	trend := priority.PriorityTrend{}
+
+	stmt := sqlf.
+		Select("COUNT(*) FILTER (WHERE "+feedback.FEEDBACK_MODEL_TABLE+".posted_at > ?)",
+			now.Add(-window)).To(&trend.Recent).
+		Select("COUNT(*) FILTER (WHERE "+feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?)",
+			now.Add(-window)).To(&trend.Previous).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Join(SUGGESTION_FEEDBACK_MODEL_TABLE,
+			SUGGESTION_FEEDBACK_MODEL_TABLE+".feedback_id = "+feedback.FEEDBACK_MODEL_TABLE+".id").
+		Where(SUGGESTION_FEEDBACK_MODEL_TABLE+".suggestion_id = ?", id).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at > ?", now.Add(-2*window)).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", now)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &trend, nil
+		}
+
+		return nil, err
+	}
+
+	return &trend, nil
+}
+
+// ListTrendsByProductID counts, for every suggestion of a product with recent feedbacks, the feedbacks posted within
+// the window and within the window before.
+func (self *SuggestionRepository) ListTrendsByProductID(ctx context.Context, productID string,
+	window time.Duration, now time.Time) (map[string]priority.PriorityTrend, error) {
+	var ts []struct {
+		SuggestionID string `db:"suggestion_id"`
+		Recent       int    `db:"recent"`
+		Previous     int    `db:"previous"`
+	}
+
+	stmt := sqlf.
+		Select(fmt.Sprintf(`%s.suggestion_id,
+			COUNT(*) FILTER (WHERE %s.posted_at > ?) AS recent,
+			COUNT(*) FILTER (WHERE %s.posted_at <= ?) AS previous`,
+			SUGGESTION_FEEDBACK_MODEL_TABLE, feedback.FEEDBACK_MODEL_TABLE, feedback.FEEDBACK_MODEL_TABLE),
+			now.Add(-window), now.Add(-window)).To(&ts).
+		From(feedback.FEEDBACK_MODEL_TABLE).
+		Join(SUGGESTION_FEEDBACK_MODEL_TABLE,
+			SUGGESTION_FEEDBACK_MODEL_TABLE+".feedback_id = "+feedback.FEEDBACK_MODEL_TABLE+".id").
+		Where(feedback.FEEDBACK_MODEL_TABLE+".product_id = ?", productID).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at > ?", now.Add(-2*window)).
+		Where(feedback.FEEDBACK_MODEL_TABLE+".posted_at <= ?", now).
+		GroupBy(SUGGESTION_FEEDBACK_MODEL_TABLE + ".suggestion_id")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return map[string]priority.PriorityTrend{}, nil
+		}
+
+		return nil, err
+	}
+
+	trends := make(map[string]priority.PriorityTrend, len(ts))
+	for _, t := range ts {
+		trends[t.SuggestionID] = priority.PriorityTrend{
+			Recent:   t.Recent,
+			Previous: t.Previous,
+		}
+	}
+
+	return trends, nil
+}
+
+// ListPrioritizableByProductID returns the suggestions of a product with only the fields their priority depends
+// on, so all of them can be rescored without loading their embeddings.
+func (self *SuggestionRepository) ListPrioritizableByProductID(ctx context.Context,
+	productID string) ([]Suggestion, error) {
+	var ss []struct {
+		ID            string    `db:"id"`
+		Sources       []byte    `db:"sources"`
+		Importances   []byte    `db:"importances"`
+		Priority      int       `db:"priority"`
+		Customers     int       `db:"customers"`
+		RevenueAtRisk int       `db:"revenue_at_risk"`
+		LastSeenAt    time.Time `db:"last_seen_at"`
+	}
+
+	stmt := sqlf.
+		Select("id, sources, importances, priority, customers, revenue_at_risk, last_seen_at").To(&ss).
+		From(SUGGESTION_MODEL_TABLE).
+		Where("product_id = ?", productID)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []Suggestion{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]Suggestion, 0, len(ss))
+	for _, s := range ss {
+		var sources map[string]int
+		err := json.Unmarshal(s.Sources, &sources)
+		if err != nil {
+			return nil, err
+		}
+
+		var importances map[string]int
+		err = json.Unmarshal(s.Importances, &importances)
+		if err != nil {
+			return nil, err
+		}
+
+		entities = append(entities, Suggestion{
+			ID:            s.ID,
+			ProductID:     productID,
+			Sources:       sources,
+			Importances:   importances,
+			Priority:      s.Priority,
+			Customers:     s.Customers,
+			RevenueAtRisk: s.RevenueAtRisk,
+			LastSeenAt:    s.LastSeenAt,
+		})
+	}
+
+	return entities, nil
+}
+
+func (self *SuggestionRepository) UpdatePriorities(ctx context.Context, priorities map[string]int) error {
+	if len(priorities) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(priorities))
+	values := make([]int, 0, len(priorities))
+	for id, value := range priorities {
+		ids = append(ids, id)
+		values = append(values, value)
+	}
+
+	stmt := sqlf.
+		New(`UPDATE `+SUGGESTION_MODEL_TABLE+` SET "priority" = "updated"."priority"
+			FROM unnest(?::TEXT[], ?::BIGINT[]) AS "updated" ("id", "priority")
+			WHERE `+SUGGESTION_MODEL_TABLE+`."id" = "updated"."id"`, ids, values)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != len(priorities) {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, len(priorities))
+	}
+
+	return nil
+}
+
 func (self *SuggestionRepository) UpdateAssignee(ctx context.Context, id string, assigneeID *string) error {
 	stmt := sqlf.
 		Update(SUGGESTION_MODEL_TABLE).
@@ -439,6 +835,7 @@ func (self *SuggestionRepository) UpdateAggregated(ctx context.Context, suggesti
 		stmt := sqlf.
 			Update(SUGGESTION_MODEL_TABLE).
 			Set("embedding", s.Embedding).
+			Set("embedding_model", s.EmbeddingModel).
 			Set("sources", s.Sources).
 			Set("title", s.Title).
 			Set("description", s.Description).
@@ -485,6 +882,138 @@ func (self *SuggestionRepository) UpdateAggregated(ctx context.Context, suggesti
 	return nil
 }
 
+func (self *SuggestionRepository) UpdateConsolidated(ctx context.Context, suggestion Suggestion) error {
+	s := NewSuggestionModel(suggestion)
+
+	stmt := sqlf.
+		Update(SUGGESTION_MODEL_TABLE).
+		Set("embedding", s.Embedding).
+		Set("embedding_model", s.EmbeddingModel).
+		Set("sources", s.Sources).
+		Set("title", s.Title).
+		Set("description", s.Description).
+		Set("reason", s.Reason).
+		Set("importances", s.Importances).
+		Set("priority", s.Priority).
+		Set("categories", s.Categories).
+		Set("releases", s.Releases).
+		Set("customers", s.Customers).
+		Set("assignee_id", s.AssigneeID).
+		Set("quality", s.Quality).
+		Set("first_seen_at", s.FirstSeenAt).
+		Set("last_seen_at", s.LastSeenAt).
+		Set("last_aggregated_at", s.LastAggregatedAt).
+		Where("id = ?", s.ID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+// MoveFeedbacks links every feedback of a suggestion to another one instead. It returns all the feedbacks that were
+// unlinked and, among them, the ones that were not already linked to the other suggestion.
+func (self *SuggestionRepository) MoveFeedbacks(ctx context.Context,
+	fromID string, toID string) ([]string, []string, error) {
+	var result []struct {
+		FeedbackID string `db:"feedback_id"`
+		Moved      bool   `db:"moved"`
+	}
+
+	stmt := sqlf.New(`WITH "deleted" AS (
+			DELETE FROM `+SUGGESTION_FEEDBACK_MODEL_TABLE+` WHERE "suggestion_id" = ? RETURNING "feedback_id"
+		), "inserted" AS (
+			INSERT INTO `+SUGGESTION_FEEDBACK_MODEL_TABLE+` ("suggestion_id", "feedback_id")
+			SELECT ?, "feedback_id" FROM "deleted"
+			ON CONFLICT DO NOTHING RETURNING "feedback_id"
+		)
+		SELECT "deleted"."feedback_id", "inserted"."feedback_id" IS NOT NULL AS "moved"
+		FROM "deleted" LEFT JOIN "inserted" USING ("feedback_id")`,
+		fromID, toID).
+		To(&result)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []string{}, []string{}, nil
+		}
+
+		return nil, nil, err
+	}
+
+	unlinked := make([]string, 0, len(result))
+	moved := make([]string, 0, len(result))
+	for _, res := range result {
+		unlinked = append(unlinked, res.FeedbackID)
+		if res.Moved {
+			moved = append(moved, res.FeedbackID)
+		}
+	}
+
+	return unlinked, moved, nil
+}
+
+// LinkFeedbacks links the feedbacks that still exist to a suggestion.
+func (self *SuggestionRepository) LinkFeedbacks(ctx context.Context, id string, feedbackIDs []string) error {
+	if len(feedbackIDs) == 0 {
+		return nil
+	}
+
+	stmt := sqlf.
+		New(`INSERT INTO `+SUGGESTION_FEEDBACK_MODEL_TABLE+` ("suggestion_id", "feedback_id")
+			SELECT ?, "id" FROM `+feedback.FEEDBACK_MODEL_TABLE, id).
+		Where("id").In(util.Spread(feedbackIDs)...).
+		Clause("ON CONFLICT DO NOTHING")
+
+	_, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *SuggestionRepository) UnlinkFeedbacks(ctx context.Context, id string, feedbackIDs []string) error {
+	if len(feedbackIDs) == 0 {
+		return nil
+	}
+
+	stmt := sqlf.
+		DeleteFrom(SUGGESTION_FEEDBACK_MODEL_TABLE).
+		Where("suggestion_id = ?", id).
+		Where("feedback_id").In(util.Spread(feedbackIDs)...)
+
+	_, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *SuggestionRepository) Delete(ctx context.Context, id string) error {
+	stmt := sqlf.
+		DeleteFrom(SUGGESTION_MODEL_TABLE).
+		Where("id = ?", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
 type PartialSuggestionRepository struct {
 	config   config.Config
 	observer *kit.Observer
@@ -579,7 +1108,7 @@ func (self *PartialSuggestionRepository) GetByID(ctx context.Context, id string)
 	return p.ToEntity(), nil
 }
 
-func (self *PartialSuggestionRepository) ListIDsByCreatedAt(ctx context.Context,
+func (self *PartialSuggestionRepository) ListIDsByCreatedAt(ctx context.Context, before time.Time,
 	pagination util.Pagination[time.Time]) (*util.Page[string, time.Time], error) {
 	var result []struct {
 		ID        string    `db:"id"`
@@ -588,7 +1117,8 @@ func (self *PartialSuggestionRepository) ListIDsByCreatedAt(ctx context.Context,
 
 	stmt := sqlf.
 		Select("id, created_at").To(&result).
-		From(PARTIAL_SUGGESTION_MODEL_TABLE)
+		From(PARTIAL_SUGGESTION_MODEL_TABLE).
+		Where("created_at <= ?", before)
 
 	if pagination.From != nil {
 		stmt.
@@ -0,0 +1,96 @@
+package taxonomy
+
+import (
+	"context"
+
+	"github.com/mkideal/cli"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+)
+
+const (
+	TaxonomyCommandsRecategorizeProduct = "recategorize-product"
+	TaxonomyCommandsProposeCategories   = "propose-categories"
+)
+
+type TaxonomyCommands struct {
+	config            config.Config
+	observer          *kit.Observer
+	productRepository *product.ProductRepository
+	recategorizer     *Recategorizer
+	proposer          *Proposer
+}
+
+func NewTaxonomyCommands(observer *kit.Observer, productRepository *product.ProductRepository,
+	recategorizer *Recategorizer, proposer *Proposer, config config.Config) *TaxonomyCommands {
+	return &TaxonomyCommands{
+		config:            config,
+		observer:          observer,
+		productRepository: productRepository,
+		recategorizer:     recategorizer,
+		proposer:          proposer,
+	}
+}
+
+type TaxonomyCommandsRecategorizeProductArgs struct {
+	cli.Helper
+	Product string `cli:"*product" usage:"id of the product to apply the pending recategorizations of"`
+}
+
+func (self *TaxonomyCommands) RecategorizeProduct(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*TaxonomyCommandsRecategorizeProductArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	product, err := self.productRepository.GetByID(ctx, args.Product)
+	if err != nil {
+		return err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return kit.ErrRunnerGeneric.Raise().With("product %s not found", args.Product)
+	}
+
+	_, err = self.recategorizer.Run(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type TaxonomyCommandsProposeCategoriesArgs struct {
+	cli.Helper
+	Product string `cli:"*product" usage:"id of the product to propose categories for"`
+}
+
+func (self *TaxonomyCommands) ProposeCategories(ctx context.Context, command *cli.Context) error {
+	args, ok := command.Argv().(*TaxonomyCommandsProposeCategoriesArgs)
+	if !ok {
+		return kit.ErrRunnerGeneric.Raise().With("cannot get command arguments")
+	}
+
+	product, err := self.productRepository.GetByID(ctx, args.Product)
+	if err != nil {
+		return err
+	}
+
+	if product == nil || product.DeletedAt != nil {
+		return kit.ErrRunnerGeneric.Raise().With("product %s not found", args.Product)
+	}
+
+	proposals, err := self.proposer.Run(ctx, *product)
+	if err != nil {
+		return err
+	}
+
+	for _, proposal := range proposals {
+		self.observer.Infof(ctx, "Proposed category %s with %d issues and %d suggestions",
+			proposal.Name, len(proposal.IssueIDs), len(proposal.SuggestionIDs))
+	}
+
+	return nil
+}
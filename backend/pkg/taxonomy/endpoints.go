@@ -0,0 +1,235 @@
+package taxonomy
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+type TaxonomyEndpoints struct {
+	config                     config.Config
+	observer                   *kit.Observer
+	recategorizationRepository *RecategorizationRepository
+	categoryProposalRepository *CategoryProposalRepository
+	recategorizer              *Recategorizer
+}
+
+func NewTaxonomyEndpoints(observer *kit.Observer, recategorizationRepository *RecategorizationRepository,
+	categoryProposalRepository *CategoryProposalRepository, recategorizer *Recategorizer,
+	config config.Config) *TaxonomyEndpoints {
+	return &TaxonomyEndpoints{
+		config:                     config,
+		observer:                   observer,
+		recategorizationRepository: recategorizationRepository,
+		categoryProposalRepository: categoryProposalRepository,
+		recategorizer:              recategorizer,
+	}
+}
+
+func newTaxonomyPayload(taxonomy []product.ProductCategory) []product.ProductCategoryPayload {
+	payload := make([]product.ProductCategoryPayload, 0, len(taxonomy))
+	for _, category := range taxonomy {
+		payload = append(payload, *product.NewProductCategoryPayload(category))
+	}
+
+	return payload
+}
+
+func newTaxonomyFromPayload(payload []product.ProductCategoryPayload) []product.ProductCategory {
+	taxonomy := make([]product.ProductCategory, 0, len(payload))
+	for _, category := range payload {
+		taxonomy = append(taxonomy, product.ProductCategory{
+			Name:          category.Name,
+			Description:   category.Description,
+			Examples:      category.Examples,
+			Subcategories: newTaxonomyFromPayload(category.Subcategories),
+		})
+	}
+
+	return taxonomy
+}
+
+type TaxonomyEndpointsGetTaxonomyResponse struct {
+	Taxonomy   []product.ProductCategoryPayload `json:"taxonomy"`
+	Categories []string                         `json:"categories"`
+}
+
+func (self *TaxonomyEndpoints) GetTaxonomy(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+
+	response := TaxonomyEndpointsGetTaxonomyResponse{}
+	response.Taxonomy = newTaxonomyPayload(requestProduct.Taxonomy)
+	response.Categories = requestProduct.Categories
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+// The mapping renames or merges categories by their keys, the removed ones not mapped fall into their category,
+// or the unknown option, by default.
+type TaxonomyEndpointsPutTaxonomyRequest struct {
+	Taxonomy []product.ProductCategoryPayload `json:"taxonomy"`
+	Mapping  map[string]string                `json:"mapping"`
+}
+
+type TaxonomyEndpointsPutTaxonomyResponse struct {
+	Taxonomy         []product.ProductCategoryPayload `json:"taxonomy"`
+	Categories       []string                         `json:"categories"`
+	Recategorization *RecategorizationPayload         `json:"recategorization"`
+}
+
+func (self *TaxonomyEndpoints) PutTaxonomy(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := TaxonomyEndpointsPutTaxonomyRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	taxonomy, err := NewTaxonomy(newTaxonomyFromPayload(request.Taxonomy))
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	renames := make(map[string]string, len(request.Mapping))
+	for from, to := range request.Mapping {
+		renames[product.NormalizeCategory(from)] = product.NormalizeCategory(to)
+	}
+
+	_product, recategorization, err := self.recategorizer.Update(requestCtx, *requestProduct, taxonomy, renames)
+	if err != nil {
+		if ErrTaxonomyInvalidMapping.Is(err) || ErrRecategorizerConflict.Is(err) {
+			return kit.HTTPErrInvalidRequest.Cause(err)
+		}
+
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := TaxonomyEndpointsPutTaxonomyResponse{}
+	response.Taxonomy = newTaxonomyPayload(_product.Taxonomy)
+	response.Categories = _product.Categories
+	response.Recategorization = nil
+	if recategorization != nil {
+		response.Recategorization = NewRecategorizationPayload(*recategorization)
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type TaxonomyEndpointsListRecategorizationsRequest struct {
+	From *string `query:"from"`
+}
+
+type TaxonomyEndpointsListRecategorizationsResponse struct {
+	Recategorizations []RecategorizationPayload `json:"recategorizations"`
+	Next              *string                   `json:"next"`
+}
+
+func (self *TaxonomyEndpoints) ListRecategorizations(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	request := TaxonomyEndpointsListRecategorizationsRequest{}
+
+	err := ctx.Bind(&request)
+	if err != nil {
+		return kit.HTTPErrInvalidRequest.Cause(err)
+	}
+
+	page, err := self.recategorizationRepository.ListByProductID(requestCtx, requestProduct.ID,
+		util.Pagination[time.Time]{
+			Limit: 100,
+			From:  util.CursorFromString[time.Time](request.From),
+		})
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := TaxonomyEndpointsListRecategorizationsResponse{}
+	response.Recategorizations = make([]RecategorizationPayload, 0, len(page.Items))
+	for _, recategorization := range page.Items {
+		response.Recategorizations = append(response.Recategorizations,
+			*NewRecategorizationPayload(recategorization))
+	}
+	response.Next = nil
+	next := util.CursorToString(page.Next)
+	if next != nil {
+		*next = url.QueryEscape(*next)
+	}
+	response.Next = next
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type TaxonomyEndpointsListCategoryProposalsResponse struct {
+	Proposals []CategoryProposalPayload `json:"proposals"`
+}
+
+func (self *TaxonomyEndpoints) ListCategoryProposals(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+
+	proposals, err := self.categoryProposalRepository.ListPendingByProductID(requestCtx, requestProduct.ID)
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := TaxonomyEndpointsListCategoryProposalsResponse{}
+	response.Proposals = make([]CategoryProposalPayload, 0, len(proposals))
+	for _, proposal := range proposals {
+		response.Proposals = append(response.Proposals, *NewCategoryProposalPayload(proposal))
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+type TaxonomyEndpointsPostCategoryProposalAcceptResponse struct {
+	Taxonomy         []product.ProductCategoryPayload `json:"taxonomy"`
+	Categories       []string                         `json:"categories"`
+	Recategorization *RecategorizationPayload         `json:"recategorization"`
+}
+
+func (self *TaxonomyEndpoints) PostCategoryProposalAccept(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProduct := product.RequestProduct(requestCtx)
+	requestProposal := RequestCategoryProposal(requestCtx)
+
+	_product, recategorization, err := self.recategorizer.Accept(requestCtx, *requestProduct, *requestProposal)
+	if err != nil {
+		if ErrTaxonomyInvalid.Is(err) || ErrRecategorizerConflict.Is(err) {
+			return kit.HTTPErrInvalidRequest.Cause(err)
+		}
+
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	response := TaxonomyEndpointsPostCategoryProposalAcceptResponse{}
+	response.Taxonomy = newTaxonomyPayload(_product.Taxonomy)
+	response.Categories = _product.Categories
+	response.Recategorization = nil
+	if recategorization != nil {
+		response.Recategorization = NewRecategorizationPayload(*recategorization)
+	}
+
+	return ctx.JSON(http.StatusOK, &response)
+}
+
+func (self *TaxonomyEndpoints) PostCategoryProposalDismiss(ctx echo.Context) error {
+	requestCtx := ctx.Request().Context()
+	requestProposal := RequestCategoryProposal(requestCtx)
+
+	err := self.categoryProposalRepository.UpdateDismissed(requestCtx, requestProposal.ID, time.Now())
+	if err != nil {
+		return kit.HTTPErrServerGeneric.Cause(err)
+	}
+
+	return ctx.JSON(http.StatusOK, struct{}{})
+}
@@ -0,0 +1,263 @@
+package taxonomy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit/util"
+
+	"backend/pkg/engine"
+	"backend/pkg/product"
+)
+
+const (
+	TAXONOMY_RECATEGORIZATION_LOCK_KEY = "taxonomy:recategorization:%s"
+	// Items classified as unknown that are clustered at most on every proposal run
+	TAXONOMY_PROPOSAL_MAX_ITEMS = 500
+	// Minimum items of a cluster for it to be worth a new category
+	TAXONOMY_PROPOSAL_MIN_ITEMS = 5
+	// Items of a cluster the engine names the proposed category from
+	TAXONOMY_PROPOSAL_SAMPLE_ITEMS  = 10
+	TAXONOMY_PROPOSAL_MAX_PROPOSALS = 5
+	TAXONOMY_PROPOSAL_SIMILARITY    = 0.8
+)
+
+var (
+	ErrTaxonomyInvalid        = errors.New("taxonomy is invalid")
+	ErrTaxonomyInvalidMapping = errors.New("taxonomy mapping is invalid")
+)
+
+// NewTaxonomy normalizes the names of a taxonomy and validates it.
+func NewTaxonomy(taxonomy []product.ProductCategory) ([]product.ProductCategory, error) {
+	if len(taxonomy) > product.PRODUCT_MAX_CATEGORIES {
+		return nil, ErrTaxonomyInvalid.Raise().With("too many categories")
+	}
+
+	normalized := make([]product.ProductCategory, 0, len(taxonomy))
+	names := map[string]bool{}
+	for _, category := range taxonomy {
+		category, err := newCategory(category)
+		if err != nil {
+			return nil, err
+		}
+
+		if names[category.Name] {
+			return nil, ErrTaxonomyInvalid.Raise().With("category %s is duplicated", category.Name)
+		}
+		names[category.Name] = true
+
+		if len(category.Subcategories) > product.PRODUCT_MAX_SUBCATEGORIES {
+			return nil, ErrTaxonomyInvalid.Raise().With("category %s has too many subcategories", category.Name)
+		}
+
+		subcategories := make([]product.ProductCategory, 0, len(category.Subcategories))
+		subnames := map[string]bool{}
+		for _, subcategory := range category.Subcategories {
+			subcategory, err := newCategory(subcategory)
+			if err != nil {
+				return nil, err
+			}
+
+			if subnames[subcategory.Name] {
+				return nil, ErrTaxonomyInvalid.Raise().With("subcategory %s of %s is duplicated",
+					subcategory.Name, category.Name)
+			}
+			subnames[subcategory.Name] = true
+
+			if len(subcategory.Subcategories) > 0 {
+				return nil, ErrTaxonomyInvalid.Raise().With("subcategory %s of %s has subcategories",
+					subcategory.Name, category.Name)
+			}
+
+			key := category.Name + product.PRODUCT_CATEGORY_SEPARATOR + subcategory.Name
+			if len(key) > product.PRODUCT_MAX_CATEGORY_KEY_LENGTH {
+				return nil, ErrTaxonomyInvalid.Raise().With("subcategory %s is too long", key)
+			}
+
+			subcategories = append(subcategories, subcategory)
+		}
+
+		category.Subcategories = subcategories
+		normalized = append(normalized, category)
+	}
+
+	return normalized, nil
+}
+
+func newCategory(category product.ProductCategory) (product.ProductCategory, error) {
+	category.Name = product.NormalizeCategory(category.Name)
+
+	if len(category.Name) == 0 || len(category.Name) > product.PRODUCT_MAX_CATEGORY_KEY_LENGTH {
+		return category, ErrTaxonomyInvalid.Raise().With("category %s has an invalid name", category.Name)
+	}
+
+	if strings.Contains(category.Name, product.PRODUCT_CATEGORY_SEPARATOR) || category.Name == engine.OPTION_UNKNOWN {
+		return category, ErrTaxonomyInvalid.Raise().With("category %s has a reserved name", category.Name)
+	}
+
+	category.Description = strings.TrimSpace(category.Description)
+	if len(category.Description) > product.PRODUCT_MAX_CATEGORY_DESCRIPTION_LENGTH {
+		return category, ErrTaxonomyInvalid.Raise().With("category %s has a too long description", category.Name)
+	}
+
+	if len(category.Examples) > product.PRODUCT_MAX_CATEGORY_EXAMPLES {
+		return category, ErrTaxonomyInvalid.Raise().With("category %s has too many examples", category.Name)
+	}
+
+	examples := make([]string, 0, len(category.Examples))
+	for _, example := range category.Examples {
+		example = strings.TrimSpace(example)
+		if len(example) == 0 || len(example) > product.PRODUCT_MAX_CATEGORY_EXAMPLE_LENGTH {
+			return category, ErrTaxonomyInvalid.Raise().With("category %s has an invalid example", category.Name)
+		}
+
+		examples = append(examples, example)
+	}
+	category.Examples = examples
+
+	if category.Subcategories == nil {
+		category.Subcategories = []product.ProductCategory{}
+	}
+
+	return category, nil
+}
+
+// NewMapping computes how the keys of the previous taxonomy are rewritten into the keys of the next one. Renames
+// map previous keys, which can be merged by mapping several into the same next key. The keys that are neither kept
+// nor renamed fall into the renamed or kept subcategory with the same name, their category, or the unknown option.
+// Only the keys that change are returned.
+func NewMapping(previous []product.ProductCategory, next []product.ProductCategory,
+	renames map[string]string) (map[string]string, error) {
+	previousKeys := map[string]bool{}
+	for _, key := range product.TaxonomyKeys(previous) {
+		previousKeys[key] = true
+	}
+
+	nextKeys := map[string]bool{}
+	for _, key := range product.TaxonomyKeys(next) {
+		nextKeys[key] = true
+	}
+
+	mapping := map[string]string{}
+	for from, to := range renames {
+		if !previousKeys[from] {
+			return nil, ErrTaxonomyInvalidMapping.Raise().With("category %s does not exist", from)
+		}
+
+		if !nextKeys[to] && to != engine.OPTION_UNKNOWN {
+			return nil, ErrTaxonomyInvalidMapping.Raise().With("category %s does not exist", to)
+		}
+
+		if from != to {
+			mapping[from] = to
+		}
+	}
+
+	for _, category := range previous {
+		if _, ok := renames[category.Name]; !ok && !nextKeys[category.Name] {
+			mapping[category.Name] = engine.OPTION_UNKNOWN
+		}
+	}
+
+	for _, category := range previous {
+		parent := category.Name
+		if to, ok := mapping[category.Name]; ok {
+			parent = to
+		}
+
+		for _, subcategory := range category.Subcategories {
+			key := category.Name + product.PRODUCT_CATEGORY_SEPARATOR + subcategory.Name
+			if _, ok := renames[key]; ok || nextKeys[key] {
+				continue
+			}
+
+			sibling := parent + product.PRODUCT_CATEGORY_SEPARATOR + subcategory.Name
+			if nextKeys[sibling] {
+				mapping[key] = sibling
+			} else {
+				mapping[key] = parent
+			}
+		}
+	}
+
+	return mapping, nil
+}
+
+type Recategorization struct {
+	ID        string
+	ProductID string
+	Mapping   map[string]string
+	// Restricts the rewrite to some issues and suggestions, and the reviews of their feedbacks, instead of all
+	IssueIDs      *[]string
+	SuggestionIDs *[]string
+	Issues        int
+	Suggestions   int
+	Reviews       int
+	CreatedAt     time.Time
+	FinishedAt    *time.Time
+}
+
+func NewRecategorization() *Recategorization {
+	return &Recategorization{}
+}
+
+func (self Recategorization) String() string {
+	return fmt.Sprintf("<Recategorization: %s (%s)>", self.ProductID, self.ID)
+}
+
+func (self Recategorization) Equals(other Recategorization) bool {
+	return util.Equals(self, other)
+}
+
+func (self Recategorization) Copy() *Recategorization {
+	return util.Copy(self)
+}
+
+// Keys lists the previous keys the recategorization rewrites.
+func (self Recategorization) Keys() []string {
+	keys := make([]string, 0, len(self.Mapping))
+	for key := range self.Mapping {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+type CategoryProposal struct {
+	ID            string
+	ProductID     string
+	Name          string
+	Description   string
+	Examples      []string
+	IssueIDs      []string
+	SuggestionIDs []string
+	CreatedAt     time.Time
+	AcceptedAt    *time.Time
+	DismissedAt   *time.Time
+}
+
+func NewCategoryProposal() *CategoryProposal {
+	return &CategoryProposal{}
+}
+
+func (self CategoryProposal) String() string {
+	return fmt.Sprintf("<CategoryProposal: %s (%s)>", self.Name, self.ID)
+}
+
+func (self CategoryProposal) Equals(other CategoryProposal) bool {
+	return util.Equals(self, other)
+}
+
+func (self CategoryProposal) Copy() *CategoryProposal {
+	return util.Copy(self)
+}
+
+// UncategorizedItem is an issue or a suggestion classified mostly as unknown.
+type UncategorizedItem struct {
+	ID          string
+	Title       string
+	Description string
+	Embedding   []float32
+}
@@ -0,0 +1,118 @@
+package taxonomy_test
+
+import (
+	"testing"
+
+	"backend/pkg/product"
+	"backend/pkg/taxonomy"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TaxonomyTestSuite struct {
+	suite.Suite
+	previous []product.ProductCategory
+}
+
+func (self *TaxonomyTestSuite) SetupTest() {
+	self.previous = []product.ProductCategory{
+		{Name: "BILLING", Subcategories: []product.ProductCategory{{Name: "INVOICES"}, {Name: "REFUNDS"}}},
+		{Name: "PAYMENTS", Subcategories: []product.ProductCategory{{Name: "CARDS"}}},
+		{Name: "SHIPPING"},
+	}
+}
+
+func TestTaxonomySuite(t *testing.T) {
+	suite.Run(t, new(TaxonomyTestSuite))
+}
+
+func (self *TaxonomyTestSuite) TestNewTaxonomyNormalizes() {
+	// Given: A taxonomy written by hand
+	input := []product.ProductCategory{
+		{
+			Name:          " user interface ",
+			Description:   " Screens ",
+			Subcategories: []product.ProductCategory{{Name: "dark mode"}},
+		},
+	}
+
+	// When: The taxonomy is validated
+	result, err := taxonomy.NewTaxonomy(input)
+
+	// Then: The names are in the format the engine answers with
+	self.Require().NoError(err)
+	self.Equal("USER_INTERFACE", result[0].Name)
+	self.Equal("Screens", result[0].Description)
+	self.Equal([]string{"USER_INTERFACE", "USER_INTERFACE/DARK_MODE"}, product.TaxonomyKeys(result))
+}
+
+func (self *TaxonomyTestSuite) TestNewTaxonomyRejectsReservedNames() {
+	// Given: Taxonomies with a duplicated, an unknown and a separated category
+	inputs := [][]product.ProductCategory{
+		{{Name: "BILLING"}, {Name: "billing"}},
+		{{Name: "UNKNOWN"}},
+		{{Name: "BILLING/INVOICES"}},
+	}
+
+	for _, input := range inputs {
+		// When: The taxonomy is validated
+		_, err := taxonomy.NewTaxonomy(input)
+
+		// Then: The taxonomy is invalid
+		self.True(taxonomy.ErrTaxonomyInvalid.Is(err))
+	}
+}
+
+func (self *TaxonomyTestSuite) TestNewMappingRenamesAndMerges() {
+	// Given: Payments renamed to checkout and shipping merged into billing
+	next := []product.ProductCategory{
+		{Name: "BILLING", Subcategories: []product.ProductCategory{{Name: "INVOICES"}, {Name: "REFUNDS"}}},
+		{Name: "CHECKOUT", Subcategories: []product.ProductCategory{{Name: "CARDS"}}},
+	}
+	renames := map[string]string{"PAYMENTS": "CHECKOUT", "SHIPPING": "BILLING"}
+
+	// When: The mapping is computed
+	mapping, err := taxonomy.NewMapping(self.previous, next, renames)
+
+	// Then: The subcategories follow their renamed category and the kept keys are not rewritten
+	self.Require().NoError(err)
+	self.Equal(map[string]string{
+		"PAYMENTS":       "CHECKOUT",
+		"PAYMENTS/CARDS": "CHECKOUT/CARDS",
+		"SHIPPING":       "BILLING",
+	}, mapping)
+}
+
+func (self *TaxonomyTestSuite) TestNewMappingFallsBack() {
+	// Given: Refunds and shipping removed without being renamed
+	next := []product.ProductCategory{
+		{Name: "BILLING", Subcategories: []product.ProductCategory{{Name: "INVOICES"}}},
+		{Name: "PAYMENTS", Subcategories: []product.ProductCategory{{Name: "CARDS"}}},
+	}
+
+	// When: The mapping is computed
+	mapping, err := taxonomy.NewMapping(self.previous, next, map[string]string{})
+
+	// Then: The subcategory falls into its category and the category into the unknown option
+	self.Require().NoError(err)
+	self.Equal(map[string]string{
+		"BILLING/REFUNDS": "BILLING",
+		"SHIPPING":        "UNKNOWN",
+	}, mapping)
+}
+
+func (self *TaxonomyTestSuite) TestNewMappingRejectsUnknownKeys() {
+	// Given: Renames from a key that did not exist and into a key that will not exist
+	renamesList := []map[string]string{
+		{"MARKETING": "BILLING"},
+		{"SHIPPING": "DELIVERY"},
+	}
+
+	for _, renames := range renamesList {
+		// When: The mapping is computed
+		_, err := taxonomy.NewMapping(self.previous, self.previous, renames)
+
+		// Then: The mapping is invalid
+		self.True(taxonomy.ErrTaxonomyInvalidMapping.Is(err))
+	}
+}
@@ -0,0 +1,64 @@
+package taxonomy
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+	"backend/pkg/product"
+)
+
+var (
+	KeyRequestCategoryProposal kit.Key = kit.KeyBase + "request:category-proposal"
+)
+
+func RequestCategoryProposal(ctx context.Context) *CategoryProposal {
+	return ctx.Value(KeyRequestCategoryProposal).(*CategoryProposal) // nolint:forcetypeassert,errcheck
+}
+
+type TaxonomyMiddlewares struct {
+	config                     config.Config
+	observer                   *kit.Observer
+	categoryProposalRepository *CategoryProposalRepository
+}
+
+func NewTaxonomyMiddlewares(observer *kit.Observer, categoryProposalRepository *CategoryProposalRepository,
+	config config.Config) *TaxonomyMiddlewares {
+	return &TaxonomyMiddlewares{
+		config:                     config,
+		observer:                   observer,
+		categoryProposalRepository: categoryProposalRepository,
+	}
+}
+
+// HandleProposal only lets through the proposals neither accepted nor dismissed yet.
+func (self *TaxonomyMiddlewares) HandleProposal(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		requestCtx := ctx.Request().Context()
+		requestProduct := product.RequestProduct(requestCtx)
+
+		proposal, err := self.categoryProposalRepository.GetByID(requestCtx, ctx.Param("category_proposal_id"))
+		if err != nil {
+			return kit.HTTPErrServerGeneric.Cause(err)
+		}
+
+		if proposal == nil {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		if proposal.ProductID != requestProduct.ID {
+			return kit.HTTPErrUnauthorized
+		}
+
+		if proposal.AcceptedAt != nil || proposal.DismissedAt != nil {
+			return kit.HTTPErrInvalidRequest
+		}
+
+		ctx.SetRequest(ctx.Request().WithContext(
+			context.WithValue(requestCtx, KeyRequestCategoryProposal, proposal)))
+
+		return next(ctx)
+	}
+}
@@ -0,0 +1,108 @@
+package taxonomy
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	RECATEGORIZATION_MODEL_TABLE  = "\"recategorization\""
+	CATEGORY_PROPOSAL_MODEL_TABLE = "\"category_proposal\""
+)
+
+type RecategorizationModel struct {
+	ID            string     `db:"id"`
+	ProductID     string     `db:"product_id"`
+	Mapping       []byte     `db:"mapping"`
+	IssueIDs      *[]string  `db:"issue_ids"`
+	SuggestionIDs *[]string  `db:"suggestion_ids"`
+	Issues        int        `db:"issues"`
+	Suggestions   int        `db:"suggestions"`
+	Reviews       int        `db:"reviews"`
+	CreatedAt     time.Time  `db:"created_at"`
+	FinishedAt    *time.Time `db:"finished_at"`
+}
+
+func NewRecategorizationModel(recategorization Recategorization) *RecategorizationModel {
+	mapping, err := json.Marshal(recategorization.Mapping)
+	if err != nil {
+		panic(err)
+	}
+
+	return &RecategorizationModel{
+		ID:            recategorization.ID,
+		ProductID:     recategorization.ProductID,
+		Mapping:       mapping,
+		IssueIDs:      recategorization.IssueIDs,
+		SuggestionIDs: recategorization.SuggestionIDs,
+		Issues:        recategorization.Issues,
+		Suggestions:   recategorization.Suggestions,
+		Reviews:       recategorization.Reviews,
+		CreatedAt:     recategorization.CreatedAt,
+		FinishedAt:    recategorization.FinishedAt,
+	}
+}
+
+func (self *RecategorizationModel) ToEntity() *Recategorization {
+	var mapping map[string]string
+	err := json.Unmarshal(self.Mapping, &mapping)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Recategorization{
+		ID:            self.ID,
+		ProductID:     self.ProductID,
+		Mapping:       mapping,
+		IssueIDs:      self.IssueIDs,
+		SuggestionIDs: self.SuggestionIDs,
+		Issues:        self.Issues,
+		Suggestions:   self.Suggestions,
+		Reviews:       self.Reviews,
+		CreatedAt:     self.CreatedAt,
+		FinishedAt:    self.FinishedAt,
+	}
+}
+
+type CategoryProposalModel struct {
+	ID            string     `db:"id"`
+	ProductID     string     `db:"product_id"`
+	Name          string     `db:"name"`
+	Description   string     `db:"description"`
+	Examples      []string   `db:"examples"`
+	IssueIDs      []string   `db:"issue_ids"`
+	SuggestionIDs []string   `db:"suggestion_ids"`
+	CreatedAt     time.Time  `db:"created_at"`
+	AcceptedAt    *time.Time `db:"accepted_at"`
+	DismissedAt   *time.Time `db:"dismissed_at"`
+}
+
+func NewCategoryProposalModel(proposal CategoryProposal) *CategoryProposalModel {
+	return &CategoryProposalModel{
+		ID:            proposal.ID,
+		ProductID:     proposal.ProductID,
+		Name:          proposal.Name,
+		Description:   proposal.Description,
+		Examples:      proposal.Examples,
+		IssueIDs:      proposal.IssueIDs,
+		SuggestionIDs: proposal.SuggestionIDs,
+		CreatedAt:     proposal.CreatedAt,
+		AcceptedAt:    proposal.AcceptedAt,
+		DismissedAt:   proposal.DismissedAt,
+	}
+}
+
+func (self *CategoryProposalModel) ToEntity() *CategoryProposal {
+	return &CategoryProposal{
+		ID:            self.ID,
+		ProductID:     self.ProductID,
+		Name:          self.Name,
+		Description:   self.Description,
+		Examples:      self.Examples,
+		IssueIDs:      self.IssueIDs,
+		SuggestionIDs: self.SuggestionIDs,
+		CreatedAt:     self.CreatedAt,
+		AcceptedAt:    self.AcceptedAt,
+		DismissedAt:   self.DismissedAt,
+	}
+}
@@ -0,0 +1,61 @@
+package taxonomy
+
+import (
+	"time"
+)
+
+type RecategorizationPayload struct {
+	ID            string            `json:"id"`
+	ProductID     string            `json:"product_id"`
+	Mapping       map[string]string `json:"mapping"`
+	IssueIDs      *[]string         `json:"issue_ids"`
+	SuggestionIDs *[]string         `json:"suggestion_ids"`
+	Issues        int               `json:"issues"`
+	Suggestions   int               `json:"suggestions"`
+	Reviews       int               `json:"reviews"`
+	CreatedAt     time.Time         `json:"created_at"`
+	FinishedAt    *time.Time        `json:"finished_at"`
+}
+
+func NewRecategorizationPayload(recategorization Recategorization) *RecategorizationPayload {
+	return &RecategorizationPayload{
+		ID:            recategorization.ID,
+		ProductID:     recategorization.ProductID,
+		Mapping:       recategorization.Mapping,
+		IssueIDs:      recategorization.IssueIDs,
+		SuggestionIDs: recategorization.SuggestionIDs,
+		Issues:        recategorization.Issues,
+		Suggestions:   recategorization.Suggestions,
+		Reviews:       recategorization.Reviews,
+		CreatedAt:     recategorization.CreatedAt,
+		FinishedAt:    recategorization.FinishedAt,
+	}
+}
+
+type CategoryProposalPayload struct {
+	ID            string     `json:"id"`
+	ProductID     string     `json:"product_id"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Examples      []string   `json:"examples"`
+	IssueIDs      []string   `json:"issue_ids"`
+	SuggestionIDs []string   `json:"suggestion_ids"`
+	CreatedAt     time.Time  `json:"created_at"`
+	AcceptedAt    *time.Time `json:"accepted_at"`
+	DismissedAt   *time.Time `json:"dismissed_at"`
+}
+
+func NewCategoryProposalPayload(proposal CategoryProposal) *CategoryProposalPayload {
+	return &CategoryProposalPayload{
+		ID:            proposal.ID,
+		ProductID:     proposal.ProductID,
+		Name:          proposal.Name,
+		Description:   proposal.Description,
+		Examples:      proposal.Examples,
+		IssueIDs:      proposal.IssueIDs,
+		SuggestionIDs: proposal.SuggestionIDs,
+		CreatedAt:     proposal.CreatedAt,
+		AcceptedAt:    proposal.AcceptedAt,
+		DismissedAt:   proposal.DismissedAt,
+	}
+}
@@ -0,0 +1,260 @@
+package taxonomy
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+	"github.com/rs/xid"
+
+	"backend/pkg/config"
+	"backend/pkg/engine"
+	"backend/pkg/organization"
+	"backend/pkg/outbox"
+	"backend/pkg/product"
+	"backend/pkg/util"
+)
+
+const (
+	ProposerPropose  = "taxonomy:propose"
+	ProposerSchedule = "taxonomy:schedule-propose"
+)
+
+var (
+	ErrProposerGeneric = errors.New("proposer failed")
+)
+
+type Proposer struct {
+	config                     config.Config
+	observer                   *kit.Observer
+	productRepository          *product.ProductRepository
+	organizationRepository     organization.OrganizationRepository
+	categoryProposalRepository *CategoryProposalRepository
+	enqueuer                   *outbox.OutboxEnqueuer
+	engineService              *engine.EngineService
+}
+
+func NewProposer(observer *kit.Observer, productRepository *product.ProductRepository,
+	organizationRepository organization.OrganizationRepository, categoryProposalRepository *CategoryProposalRepository,
+	enqueuer *outbox.OutboxEnqueuer, engineService *engine.EngineService, config config.Config) *Proposer {
+	return &Proposer{
+		config:                     config,
+		observer:                   observer,
+		productRepository:          productRepository,
+		organizationRepository:     organizationRepository,
+		categoryProposalRepository: categoryProposalRepository,
+		enqueuer:                   enqueuer,
+		engineService:              engineService,
+	}
+}
+
+type proposerItem struct {
+	UncategorizedItem
+	Suggestion bool
+}
+
+// cluster groups greedily the items around the first unclustered one, most recently seen first, with all the
+// unclustered items similar enough to it. Only the clusters big enough are returned, biggest first.
+func (self *Proposer) cluster(items []proposerItem) [][]proposerItem {
+	clustered := make([]bool, len(items))
+	clusters := [][]proposerItem{}
+
+	for i := range items {
+		if clustered[i] {
+			continue
+		}
+
+		cluster := []proposerItem{items[i]}
+		clustered[i] = true
+
+		for j := i + 1; j < len(items); j++ {
+			if clustered[j] {
+				continue
+			}
+
+			if util.CosineSimilarity(items[i].Embedding, items[j].Embedding) >= TAXONOMY_PROPOSAL_SIMILARITY {
+				cluster = append(cluster, items[j])
+				clustered[j] = true
+			}
+		}
+
+		if len(cluster) >= TAXONOMY_PROPOSAL_MIN_ITEMS {
+			clusters = append(clusters, cluster)
+		}
+	}
+
+	slices.SortStableFunc(clusters, func(a, b []proposerItem) int {
+		return len(b) - len(a)
+	})
+
+	return clusters
+}
+
+// Run clusters the issues and suggestions of a product classified mostly as unknown and proposes a new category
+// for every cluster big enough, which has to be accepted before the items are moved to it.
+func (self *Proposer) Run(ctx context.Context, _product product.Product) ([]CategoryProposal, error) {
+	issues, err := self.categoryProposalRepository.ListUncategorizedIssues(ctx, _product.ID,
+		_product.EmbeddingModel, TAXONOMY_PROPOSAL_MAX_ITEMS)
+	if err != nil {
+		return nil, ErrProposerGeneric.Raise().Cause(err)
+	}
+
+	suggestions, err := self.categoryProposalRepository.ListUncategorizedSuggestions(ctx, _product.ID,
+		_product.EmbeddingModel, TAXONOMY_PROPOSAL_MAX_ITEMS)
+	if err != nil {
+		return nil, ErrProposerGeneric.Raise().Cause(err)
+	}
+
+	items := make([]proposerItem, 0, len(issues)+len(suggestions))
+	for _, issue := range issues {
+		items = append(items, proposerItem{UncategorizedItem: issue, Suggestion: false})
+	}
+	for _, suggestion := range suggestions {
+		items = append(items, proposerItem{UncategorizedItem: suggestion, Suggestion: true})
+	}
+
+	clusters := self.cluster(items)
+	if len(clusters) > TAXONOMY_PROPOSAL_MAX_PROPOSALS {
+		clusters = clusters[:TAXONOMY_PROPOSAL_MAX_PROPOSALS]
+	}
+
+	proposals := []CategoryProposal{}
+	names := map[string]bool{}
+	for _, name := range _product.Categories {
+		names[name] = true
+	}
+
+	tokens := 0
+	for _, cluster := range clusters {
+		texts := []string{}
+		for _, item := range cluster[:min(len(cluster), TAXONOMY_PROPOSAL_SAMPLE_ITEMS)] {
+			texts = append(texts, item.Title+": "+item.Description)
+		}
+
+		pcResult, err := self.engineService.ProposeCategory(ctx, engine.EngineServiceProposeCategoryParams{
+			Context:    _product.Context,
+			Categories: _product.Categories,
+			Items:      texts,
+		})
+		if err != nil {
+			return proposals, ErrProposerGeneric.Raise().Cause(err)
+		}
+
+		tokens += (pcResult.Usage.Input + pcResult.Usage.Output)
+
+		category, err := newCategory(product.ProductCategory{
+			Name:          pcResult.Category.Name,
+			Description:   pcResult.Category.Description,
+			Examples:      pcResult.Category.Examples,
+			Subcategories: []product.ProductCategory{},
+		})
+		if err != nil || names[category.Name] {
+			self.observer.Infof(ctx, "Discarded proposed category %s of product %s", category.Name, _product.ID)
+			continue
+		}
+
+		names[category.Name] = true
+
+		proposal := NewCategoryProposal()
+		proposal.ID = xid.New().String()
+		proposal.ProductID = _product.ID
+		proposal.Name = category.Name
+		proposal.Description = category.Description
+		proposal.Examples = category.Examples
+		proposal.IssueIDs = []string{}
+		proposal.SuggestionIDs = []string{}
+		for _, item := range cluster {
+			if item.Suggestion {
+				proposal.SuggestionIDs = append(proposal.SuggestionIDs, item.ID)
+			} else {
+				proposal.IssueIDs = append(proposal.IssueIDs, item.ID)
+			}
+		}
+		proposal.CreatedAt = time.Now()
+
+		proposal, err = self.categoryProposalRepository.Create(ctx, *proposal)
+		if err != nil {
+			return proposals, ErrProposerGeneric.Raise().Cause(err)
+		}
+
+		proposals = append(proposals, *proposal)
+	}
+
+	self.observer.Infof(ctx, "Proposed %d categories from %d uncategorized items of product %s using %d tokens",
+		len(proposals), len(items), _product.ID, tokens)
+
+	return proposals, nil
+}
+
+type ProposerProposeParams struct {
+	ProductID string
+}
+
+func (self *Proposer) Propose(ctx context.Context, task *asynq.Task) error {
+	params := ProposerProposeParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	product, err := self.productRepository.GetByID(ctx, params.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if product == nil {
+		return nil
+	}
+
+	if product.DeletedAt != nil {
+		return nil
+	}
+
+	organization, err := self.organizationRepository.GetByID(ctx, product.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	if organization == nil {
+		return nil
+	}
+
+	if organization.DeletedAt != nil {
+		return nil
+	}
+
+	if organization.UsageLeft() < 1 {
+		return nil
+	}
+
+	_, err = self.Run(ctx, *product)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (self *Proposer) Schedule(ctx context.Context, _ *asynq.Task) error {
+	ids, err := self.productRepository.ListIDsByNotDeleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := self.enqueuer.Enqueue(ctx, ProposerPropose, ProposerProposeParams{
+			ProductID: id,
+		}, asynq.MaxRetry(2), asynq.Unique(7*24*time.Hour))
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package taxonomy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/neoxelox/errors"
+	"github.com/neoxelox/kit"
+	kitUtil "github.com/neoxelox/kit/util"
+	"github.com/rs/xid"
+
+	"backend/pkg/aggregator"
+	"backend/pkg/config"
+	"backend/pkg/engine"
+	"backend/pkg/issue"
+	"backend/pkg/outbox"
+	"backend/pkg/product"
+	"backend/pkg/review"
+	"backend/pkg/suggestion"
+	"backend/pkg/util"
+)
+
+const (
+	RecategorizerRecategorize = "taxonomy:recategorize"
+)
+
+var (
+	ErrRecategorizerGeneric  = errors.New("recategorizer failed")
+	ErrRecategorizerConflict = errors.New("taxonomy changed meanwhile")
+)
+
+type Recategorizer struct {
+	config                     config.Config
+	observer                   *kit.Observer
+	database                   *kit.Database
+	productRepository          *product.ProductRepository
+	recategorizationRepository *RecategorizationRepository
+	categoryProposalRepository *CategoryProposalRepository
+	enqueuer                   *outbox.OutboxEnqueuer
+	cache                      *kit.Cache
+}
+
+func NewRecategorizer(observer *kit.Observer, database *kit.Database, productRepository *product.ProductRepository,
+	recategorizationRepository *RecategorizationRepository, categoryProposalRepository *CategoryProposalRepository,
+	enqueuer *outbox.OutboxEnqueuer, cache *kit.Cache, config config.Config) *Recategorizer {
+	return &Recategorizer{
+		config:                     config,
+		observer:                   observer,
+		database:                   database,
+		productRepository:          productRepository,
+		recategorizationRepository: recategorizationRepository,
+		categoryProposalRepository: categoryProposalRepository,
+		enqueuer:                   enqueuer,
+		cache:                      cache,
+	}
+}
+
+// Update replaces the taxonomy of a product and, if any key changes, enqueues the rewrite of the categories of its
+// issues, suggestions and reviews. The previous taxonomy is the one the renames were decided on, so the update is
+// rejected if it changed meanwhile.
+func (self *Recategorizer) Update(ctx context.Context, previous product.Product,
+	taxonomy []product.ProductCategory, renames map[string]string) (*product.Product, *Recategorization, error) {
+	mapping, err := NewMapping(previous.Taxonomy, taxonomy, renames)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *product.Product
+	var recategorization *Recategorization
+
+	err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		next, recategorization, err = self.update(ctx, previous, taxonomy, mapping, nil, nil)
+		return err
+	})
+	if err != nil {
+		if ErrRecategorizerConflict.Is(err) {
+			return nil, nil, err
+		}
+
+		return nil, nil, ErrRecategorizerGeneric.Raise().Cause(err)
+	}
+
+	return next, recategorization, nil
+}
+
+// Accept adds the proposed category to the taxonomy of a product and moves the proposed issues and suggestions, and
+// the reviews of their feedbacks, from the unknown option to it.
+func (self *Recategorizer) Accept(ctx context.Context, previous product.Product,
+	proposal CategoryProposal) (*product.Product, *Recategorization, error) {
+	taxonomy := append(*kitUtil.Copy(previous.Taxonomy), product.ProductCategory{
+		Name:          proposal.Name,
+		Description:   proposal.Description,
+		Examples:      proposal.Examples,
+		Subcategories: []product.ProductCategory{},
+	})
+
+	taxonomy, err := NewTaxonomy(taxonomy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *product.Product
+	var recategorization *Recategorization
+
+	err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := self.categoryProposalRepository.UpdateAccepted(ctx, proposal.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		next, recategorization, err = self.update(ctx, previous, taxonomy,
+			map[string]string{engine.OPTION_UNKNOWN: proposal.Name}, &proposal.IssueIDs, &proposal.SuggestionIDs)
+		return err
+	})
+	if err != nil {
+		if ErrRecategorizerConflict.Is(err) {
+			return nil, nil, err
+		}
+
+		return nil, nil, ErrRecategorizerGeneric.Raise().Cause(err)
+	}
+
+	return next, recategorization, nil
+}
+
+func (self *Recategorizer) update(ctx context.Context, previous product.Product,
+	taxonomy []product.ProductCategory, mapping map[string]string, issueIDs *[]string,
+	suggestionIDs *[]string) (*product.Product, *Recategorization, error) {
+	err := util.LockTransaction(ctx, self.database, fmt.Sprintf(TAXONOMY_RECATEGORIZATION_LOCK_KEY, previous.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, err := self.productRepository.GetByID(ctx, previous.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if current == nil || !kitUtil.Equals(current.Taxonomy, previous.Taxonomy) {
+		return nil, nil, ErrRecategorizerConflict.Raise()
+	}
+
+	current.SetTaxonomy(taxonomy)
+
+	err = self.productRepository.UpdateTaxonomy(ctx, *current)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(mapping) == 0 {
+		return current, nil, nil
+	}
+
+	recategorization := NewRecategorization()
+	recategorization.ID = xid.New().String()
+	recategorization.ProductID = current.ID
+	recategorization.Mapping = mapping
+	recategorization.IssueIDs = issueIDs
+	recategorization.SuggestionIDs = suggestionIDs
+	recategorization.CreatedAt = time.Now()
+
+	recategorization, err = self.recategorizationRepository.Create(ctx, *recategorization)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Not unique, as a recategorization already running could have missed this one
+	err = self.enqueuer.Enqueue(ctx, RecategorizerRecategorize, RecategorizerRecategorizeParams{
+		ProductID: current.ID,
+	}, asynq.MaxRetry(2))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return current, recategorization, nil
+}
+
+// Run applies the pending recategorizations of a product in the order they were created. Both aggregator locks are
+// held, so no aggregation or consolidation writes the previous keys back meanwhile.
+func (self *Recategorizer) Run(ctx context.Context, productID string) ([]Recategorization, error) {
+	var recategorizations []Recategorization
+
+	err := self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := util.LockTransaction(ctx, self.database, fmt.Sprintf(TAXONOMY_RECATEGORIZATION_LOCK_KEY, productID))
+		if err != nil {
+			return err
+		}
+
+		recategorizations, err = self.recategorizationRepository.ListUnfinishedByProductID(ctx, productID)
+		if err != nil {
+			return err
+		}
+
+		if len(recategorizations) == 0 {
+			return nil
+		}
+
+		err = util.LockTransaction(ctx, self.database, fmt.Sprintf(aggregator.ISSUE_AGGREGATOR_LOCK_KEY, productID))
+		if err != nil {
+			return err
+		}
+
+		err = util.LockTransaction(ctx, self.database,
+			fmt.Sprintf(aggregator.SUGGESTION_AGGREGATOR_LOCK_KEY, productID))
+		if err != nil {
+			return err
+		}
+
+		for i := range recategorizations {
+			recategorization := &recategorizations[i]
+
+			recategorization.Issues, err = self.recategorizationRepository.RecategorizeIssues(ctx, *recategorization)
+			if err != nil {
+				return err
+			}
+
+			recategorization.Suggestions, err = self.recategorizationRepository.RecategorizeSuggestions(
+				ctx, *recategorization)
+			if err != nil {
+				return err
+			}
+
+			recategorization.Reviews, err = self.recategorizationRepository.RecategorizeReviews(ctx, *recategorization)
+			if err != nil {
+				return err
+			}
+
+			recategorization.FinishedAt = kitUtil.Pointer(time.Now())
+
+			err = self.recategorizationRepository.UpdateFinished(ctx, *recategorization)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, ErrRecategorizerGeneric.Raise().Cause(err)
+	}
+
+	if len(recategorizations) == 0 {
+		return recategorizations, nil
+	}
+
+	for _, prefix := range []string{
+		issue.ISSUE_ENDPOINTS_SEARCH_KEY,
+		suggestion.SUGGESTION_ENDPOINTS_SEARCH_KEY,
+		review.REVIEW_ENDPOINTS_SEARCH_KEY,
+	} {
+		err := util.InvalidateCache(ctx, self.cache, prefix+productID)
+		if err != nil {
+			self.observer.Error(ctx, err)
+		}
+	}
+
+	for _, recategorization := range recategorizations {
+		self.observer.Infof(ctx, "Recategorized %d issues, %d suggestions and %d reviews of product %s",
+			recategorization.Issues, recategorization.Suggestions, recategorization.Reviews, productID)
+	}
+
+	return recategorizations, nil
+}
+
+type RecategorizerRecategorizeParams struct {
+	ProductID string
+}
+
+func (self *Recategorizer) Recategorize(ctx context.Context, task *asynq.Task) error {
+	params := RecategorizerRecategorizeParams{}
+
+	err := json.Unmarshal(task.Payload(), &params)
+	if err != nil {
+		self.observer.Error(ctx, kit.ErrWorkerGeneric.Raise().Cause(err))
+		return nil
+	}
+
+	_, err = self.Run(ctx, params.ProductID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
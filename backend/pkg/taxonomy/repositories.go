@@ -0,0 +1,460 @@
+package taxonomy
+
+import (
+	"context"
+	"time"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+	"github.com/pgvector/pgvector-go"
+
+	"backend/pkg/config"
+	"backend/pkg/engine"
+	"backend/pkg/feedback"
+	"backend/pkg/issue"
+	"backend/pkg/review"
+	"backend/pkg/suggestion"
+	"backend/pkg/util"
+)
+
+// The major category of an item is the one it was classified the most times with
+const taxonomyMajorCategory = `(SELECT "category"."key" FROM jsonb_each_text("categories") AS "category"
+	ORDER BY "category"."value"::INT DESC, "category"."key" DESC LIMIT 1)`
+
+// Rewrites the keys of the categories counters of an item, adding up the counters of the merged keys
+const taxonomyRemappedCategories = `(SELECT COALESCE(jsonb_object_agg("category"."key", "category"."value"), '{}')
+	FROM (SELECT COALESCE("mapping"."value", "current"."key") AS "key", SUM("current"."value"::INT) AS "value"
+		FROM jsonb_each_text("categories") AS "current"
+		LEFT JOIN jsonb_each_text(?::JSONB) AS "mapping" ON "mapping"."key" = "current"."key"
+		GROUP BY 1) AS "category")`
+
+type RecategorizationRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewRecategorizationRepository(observer *kit.Observer, database *kit.Database,
+	config config.Config) *RecategorizationRepository {
+	return &RecategorizationRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *RecategorizationRepository) Create(ctx context.Context,
+	recategorization Recategorization) (*Recategorization, error) {
+	r := NewRecategorizationModel(recategorization)
+
+	stmt := sqlf.
+		InsertInto(RECATEGORIZATION_MODEL_TABLE).
+		Set("id", r.ID).
+		Set("product_id", r.ProductID).
+		Set("mapping", r.Mapping).
+		Set("issue_ids", r.IssueIDs).
+		Set("suggestion_ids", r.SuggestionIDs).
+		Set("issues", r.Issues).
+		Set("suggestions", r.Suggestions).
+		Set("reviews", r.Reviews).
+		Set("created_at", r.CreatedAt).
+		Set("finished_at", r.FinishedAt).
+		Returning("*").To(&r)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ToEntity(), nil
+}
+
+func (self *RecategorizationRepository) ListByProductID(ctx context.Context, productID string,
+	pagination util.Pagination[time.Time]) (*util.Page[Recategorization, time.Time], error) {
+	var rs []RecategorizationModel
+
+	stmt := sqlf.
+		Select("*").To(&rs).
+		From(RECATEGORIZATION_MODEL_TABLE).
+		Where("product_id = ?", productID)
+
+	if pagination.From != nil {
+		stmt.
+			Where("(created_at, id) < (?, ?)", pagination.From.Value, pagination.From.ID)
+	}
+
+	stmt.
+		OrderBy("created_at DESC", "id DESC").
+		Limit(pagination.Limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return &util.Page[Recategorization, time.Time]{}, nil
+		}
+
+		return nil, err
+	}
+
+	items := make([]Recategorization, 0, len(rs))
+	for _, r := range rs {
+		items = append(items, *r.ToEntity())
+	}
+
+	var cursor *util.Cursor[time.Time]
+	if len(rs) == pagination.Limit {
+		cursor = &util.Cursor[time.Time]{
+			Value: rs[pagination.Limit-1].CreatedAt,
+			ID:    rs[pagination.Limit-1].ID,
+		}
+	}
+
+	return &util.Page[Recategorization, time.Time]{
+		Items: items,
+		Next:  cursor,
+	}, nil
+}
+
+// ListUnfinishedByProductID returns the recategorizations not applied yet in the order they have to be applied.
+func (self *RecategorizationRepository) ListUnfinishedByProductID(ctx context.Context,
+	productID string) ([]Recategorization, error) {
+	var rs []RecategorizationModel
+
+	stmt := sqlf.
+		Select("*").To(&rs).
+		From(RECATEGORIZATION_MODEL_TABLE).
+		Where("product_id = ? AND finished_at IS NULL", productID).
+		OrderBy("created_at ASC", "id ASC")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []Recategorization{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]Recategorization, 0, len(rs))
+	for _, r := range rs {
+		entities = append(entities, *r.ToEntity())
+	}
+
+	return entities, nil
+}
+
+func (self *RecategorizationRepository) UpdateFinished(ctx context.Context, recategorization Recategorization) error {
+	r := NewRecategorizationModel(recategorization)
+
+	stmt := sqlf.
+		Update(RECATEGORIZATION_MODEL_TABLE).
+		Set("issues", r.Issues).
+		Set("suggestions", r.Suggestions).
+		Set("reviews", r.Reviews).
+		Set("finished_at", r.FinishedAt).
+		Where("id = ?", r.ID)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+// RecategorizeIssues rewrites the categories of the issues, and of the partial issues not aggregated yet.
+func (self *RecategorizationRepository) RecategorizeIssues(ctx context.Context,
+	recategorization Recategorization) (int, error) {
+	r := NewRecategorizationModel(recategorization)
+	keys := recategorization.Keys()
+
+	stmt := sqlf.
+		Update(issue.ISSUE_MODEL_TABLE).
+		SetExpr("categories", taxonomyRemappedCategories, string(r.Mapping)).
+		Where("product_id = ? AND jsonb_exists_any(categories, ?::TEXT[])", r.ProductID, keys)
+
+	if r.IssueIDs != nil {
+		stmt.
+			Where("id = ANY(?::TEXT[])", *r.IssueIDs)
+	}
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+
+	if r.IssueIDs == nil {
+		stmt := sqlf.
+			Update(issue.PARTIAL_ISSUE_MODEL_TABLE).
+			SetExpr("category", `COALESCE((?::JSONB)->>"category", "category")`, string(r.Mapping)).
+			Where(`"category" = ANY(?::TEXT[]) AND "feedback_id" IN (SELECT "id" FROM `+
+				feedback.FEEDBACK_MODEL_TABLE+` WHERE "product_id" = ?)`, keys, r.ProductID)
+
+		_, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return affected, nil
+}
+
+// RecategorizeSuggestions rewrites the categories of the suggestions, and of the partial suggestions not
+// aggregated yet.
+func (self *RecategorizationRepository) RecategorizeSuggestions(ctx context.Context,
+	recategorization Recategorization) (int, error) {
+	r := NewRecategorizationModel(recategorization)
+	keys := recategorization.Keys()
+
+	stmt := sqlf.
+		Update(suggestion.SUGGESTION_MODEL_TABLE).
+		SetExpr("categories", taxonomyRemappedCategories, string(r.Mapping)).
+		Where("product_id = ? AND jsonb_exists_any(categories, ?::TEXT[])", r.ProductID, keys)
+
+	if r.SuggestionIDs != nil {
+		stmt.
+			Where("id = ANY(?::TEXT[])", *r.SuggestionIDs)
+	}
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+
+	if r.SuggestionIDs == nil {
+		stmt := sqlf.
+			Update(suggestion.PARTIAL_SUGGESTION_MODEL_TABLE).
+			SetExpr("category", `COALESCE((?::JSONB)->>"category", "category")`, string(r.Mapping)).
+			Where(`"category" = ANY(?::TEXT[]) AND "feedback_id" IN (SELECT "id" FROM `+
+				feedback.FEEDBACK_MODEL_TABLE+` WHERE "product_id" = ?)`, keys, r.ProductID)
+
+		_, err := self.database.Exec(ctx, stmt)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return affected, nil
+}
+
+// RecategorizeReviews rewrites the category of the reviews. When the recategorization is restricted to some
+// issues or suggestions, only the reviews of their feedbacks are rewritten.
+func (self *RecategorizationRepository) RecategorizeReviews(ctx context.Context,
+	recategorization Recategorization) (int, error) {
+	r := NewRecategorizationModel(recategorization)
+
+	stmt := sqlf.
+		Update(review.REVIEW_MODEL_TABLE).
+		SetExpr("category", `COALESCE((?::JSONB)->>"category", "category")`, string(r.Mapping)).
+		Where(`"product_id" = ? AND "category" = ANY(?::TEXT[])`, r.ProductID, recategorization.Keys())
+
+	if r.IssueIDs != nil || r.SuggestionIDs != nil {
+		issueIDs := []string{}
+		if r.IssueIDs != nil {
+			issueIDs = *r.IssueIDs
+		}
+
+		suggestionIDs := []string{}
+		if r.SuggestionIDs != nil {
+			suggestionIDs = *r.SuggestionIDs
+		}
+
+		stmt.
+			Where(`"feedback_id" IN (
+				SELECT "feedback_id" FROM `+issue.ISSUE_FEEDBACK_MODEL_TABLE+` WHERE "issue_id" = ANY(?::TEXT[])
+				UNION
+				SELECT "feedback_id" FROM `+suggestion.SUGGESTION_FEEDBACK_MODEL_TABLE+`
+				WHERE "suggestion_id" = ANY(?::TEXT[]))`, issueIDs, suggestionIDs)
+	}
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+type CategoryProposalRepository struct {
+	config   config.Config
+	observer *kit.Observer
+	database *kit.Database
+}
+
+func NewCategoryProposalRepository(observer *kit.Observer, database *kit.Database,
+	config config.Config) *CategoryProposalRepository {
+	return &CategoryProposalRepository{
+		config:   config,
+		observer: observer,
+		database: database,
+	}
+}
+
+func (self *CategoryProposalRepository) Create(ctx context.Context,
+	proposal CategoryProposal) (*CategoryProposal, error) {
+	p := NewCategoryProposalModel(proposal)
+
+	stmt := sqlf.
+		InsertInto(CATEGORY_PROPOSAL_MODEL_TABLE).
+		Set("id", p.ID).
+		Set("product_id", p.ProductID).
+		Set("name", p.Name).
+		Set("description", p.Description).
+		Set("examples", p.Examples).
+		Set("issue_ids", p.IssueIDs).
+		Set("suggestion_ids", p.SuggestionIDs).
+		Set("created_at", p.CreatedAt).
+		Set("accepted_at", p.AcceptedAt).
+		Set("dismissed_at", p.DismissedAt).
+		Returning("*").To(&p)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ToEntity(), nil
+}
+
+func (self *CategoryProposalRepository) GetByID(ctx context.Context, id string) (*CategoryProposal, error) {
+	var p CategoryProposalModel
+
+	stmt := sqlf.
+		Select("*").To(&p).
+		From(CATEGORY_PROPOSAL_MODEL_TABLE).
+		Where("id = ?", id)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return p.ToEntity(), nil
+}
+
+// ListPendingByProductID returns the proposals neither accepted nor dismissed yet.
+func (self *CategoryProposalRepository) ListPendingByProductID(ctx context.Context,
+	productID string) ([]CategoryProposal, error) {
+	var ps []CategoryProposalModel
+
+	stmt := sqlf.
+		Select("*").To(&ps).
+		From(CATEGORY_PROPOSAL_MODEL_TABLE).
+		Where("product_id = ? AND accepted_at IS NULL AND dismissed_at IS NULL", productID).
+		OrderBy("created_at DESC", "id DESC")
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []CategoryProposal{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]CategoryProposal, 0, len(ps))
+	for _, p := range ps {
+		entities = append(entities, *p.ToEntity())
+	}
+
+	return entities, nil
+}
+
+func (self *CategoryProposalRepository) listUncategorized(ctx context.Context, table string, column string,
+	productID string, embeddingModel string, limit int) ([]UncategorizedItem, error) {
+	var is []struct {
+		ID          string          `db:"id"`
+		Title       string          `db:"title"`
+		Description string          `db:"description"`
+		Embedding   pgvector.Vector `db:"embedding"`
+	}
+
+	// Items already proposed are skipped, even if the proposal was dismissed, so they are not proposed again
+	stmt := sqlf.
+		Select("id, title, description, embedding").To(&is).
+		From(table).
+		Where("product_id = ? AND embedding_model = ? AND archived_at IS NULL", productID, embeddingModel).
+		Where(taxonomyMajorCategory+" = ?", engine.OPTION_UNKNOWN).
+		Where(`NOT EXISTS (SELECT 1 FROM `+CATEGORY_PROPOSAL_MODEL_TABLE+` AS "proposal"
+			WHERE "proposal"."product_id" = ? AND `+table+`."id" = ANY("proposal"."`+column+`"))`, productID).
+		OrderBy("last_seen_at DESC").
+		Limit(limit)
+
+	err := self.database.Query(ctx, stmt)
+	if err != nil {
+		if kit.ErrDatabaseNoRows.Is(err) {
+			return []UncategorizedItem{}, nil
+		}
+
+		return nil, err
+	}
+
+	entities := make([]UncategorizedItem, 0, len(is))
+	for _, i := range is {
+		entities = append(entities, UncategorizedItem{
+			ID:          i.ID,
+			Title:       i.Title,
+			Description: i.Description,
+			Embedding:   i.Embedding.Slice(),
+		})
+	}
+
+	return entities, nil
+}
+
+// ListUncategorizedIssues returns the most recently seen issues classified mostly as unknown.
+func (self *CategoryProposalRepository) ListUncategorizedIssues(ctx context.Context, productID string,
+	embeddingModel string, limit int) ([]UncategorizedItem, error) {
+	return self.listUncategorized(ctx, issue.ISSUE_MODEL_TABLE, "issue_ids", productID, embeddingModel, limit)
+}
+
+// ListUncategorizedSuggestions returns the most recently seen suggestions classified mostly as unknown.
+func (self *CategoryProposalRepository) ListUncategorizedSuggestions(ctx context.Context, productID string,
+	embeddingModel string, limit int) ([]UncategorizedItem, error) {
+	return self.listUncategorized(ctx, suggestion.SUGGESTION_MODEL_TABLE, "suggestion_ids",
+		productID, embeddingModel, limit)
+}
+
+func (self *CategoryProposalRepository) UpdateAccepted(ctx context.Context, id string, acceptedAt time.Time) error {
+	stmt := sqlf.
+		Update(CATEGORY_PROPOSAL_MODEL_TABLE).
+		Set("accepted_at", acceptedAt).
+		Where("id = ? AND accepted_at IS NULL AND dismissed_at IS NULL", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
+
+func (self *CategoryProposalRepository) UpdateDismissed(ctx context.Context, id string, dismissedAt time.Time) error {
+	stmt := sqlf.
+		Update(CATEGORY_PROPOSAL_MODEL_TABLE).
+		Set("dismissed_at", dismissedAt).
+		Where("id = ? AND accepted_at IS NULL AND dismissed_at IS NULL", id)
+
+	affected, err := self.database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	if affected != 1 {
+		return kit.ErrDatabaseUnexpectedEffect.Raise(affected, 1)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package translator
+
+import (
+	"embed"
+	"math"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+)
+
+const (
+	LANGUAGE_DETECTOR_MIN_NGRAM = 1
+	LANGUAGE_DETECTOR_MAX_NGRAM = 3
+	// Measured on feedbacks that are not in the corpora: every guess at or above this confidence was right, while
+	// the wrong guesses of texts with at least the minimum letters stayed below 0.72
+	LANGUAGE_DETECTOR_MIN_CONFIDENCE = 0.75
+	// Measured on the same feedbacks: shorter interjections such as "Meh" could be confidently wrong
+	LANGUAGE_DETECTOR_MIN_LETTERS  = 6
+	LANGUAGE_DETECTOR_MARGIN_SCALE = 0.1
+	// Log-likelihood ratio against the runner-up language from which a guess can be fully confident
+	LANGUAGE_DETECTOR_MIN_EVIDENCE = 8
+)
+
+//go:embed corpora/*.txt
+var languageDetectorCorpora embed.FS
+
+type languageProfile struct {
+	language    string
+	frequencies map[string]float64
+	total       float64
+}
+
+// LanguageDetector identifies the language of a text in-process by comparing its character n-grams with the
+// ones of a small corpus of customer feedback per language, using a naive bayes classifier.
+type LanguageDetector struct {
+	config   config.Config
+	observer *kit.Observer
+	profiles []languageProfile
+	ngrams   int
+}
+
+func NewLanguageDetector(observer *kit.Observer, config config.Config) *LanguageDetector {
+	files, err := languageDetectorCorpora.ReadDir("corpora")
+	if err != nil {
+		panic(err)
+	}
+
+	vocabulary := make(map[string]struct{})
+	profiles := make([]languageProfile, 0, len(files))
+	for _, file := range files {
+		corpus, err := languageDetectorCorpora.ReadFile(path.Join("corpora", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		profile := languageProfile{
+			language:    strings.ToUpper(strings.TrimSuffix(file.Name(), path.Ext(file.Name()))),
+			frequencies: make(map[string]float64),
+			total:       0,
+		}
+
+		for _, ngram := range languageDetectorNGrams(string(corpus)) {
+			profile.frequencies[ngram]++
+			profile.total++
+			vocabulary[ngram] = struct{}{}
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return &LanguageDetector{
+		config:   config,
+		observer: observer,
+		profiles: profiles,
+		ngrams:   len(vocabulary),
+	}
+}
+
+// Detect returns the most probable language of the text and the confidence of the guess between 0 and 1.
+func (self *LanguageDetector) Detect(text string) (string, float64) {
+	ngrams := languageDetectorNGrams(text)
+
+	letters := 0
+	for _, char := range text {
+		if unicode.IsLetter(char) {
+			letters++
+		}
+	}
+
+	if letters < LANGUAGE_DETECTOR_MIN_LETTERS || len(ngrams) == 0 {
+		return "", 0
+	}
+
+	scores := make([]float64, len(self.profiles))
+	best := 0
+	for i, profile := range self.profiles {
+		// Laplace smoothing so unseen n-grams do not make the whole text impossible
+		for _, ngram := range ngrams {
+			scores[i] += math.Log((profile.frequencies[ngram] + 1) / (profile.total + float64(self.ngrams)))
+		}
+
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+
+	second := -1
+	for i := range scores {
+		if i != best && (second < 0 || scores[i] > scores[second]) {
+			second = i
+		}
+	}
+
+	// Naive bayes posteriors are overconfident, so the confidence is instead based on how much more likely the
+	// best language is than the runner-up, per n-gram, and on how many of the trigrams of the text have been
+	// seen in the corpus of the best language, which is low when the text is written in an unknown language
+	margin := (scores[best] - scores[second]) / float64(len(ngrams))
+
+	seen := 0
+	trigrams := 0
+	for _, ngram := range ngrams {
+		if utf8.RuneCountInString(ngram) == LANGUAGE_DETECTOR_MAX_NGRAM {
+			trigrams++
+			if self.profiles[best].frequencies[ngram] > 0 {
+				seen++
+			}
+		}
+	}
+
+	coverage := float64(seen) / float64(trigrams)
+
+	// Short texts have too few n-grams for a high margin per n-gram to mean anything, so the confidence is also
+	// scaled by the overall log-likelihood ratio of the best language against the runner-up
+	evidence := scores[best] - scores[second]
+
+	return self.profiles[best].language, coverage * math.Min(1, margin/LANGUAGE_DETECTOR_MARGIN_SCALE) *
+		math.Min(1, evidence/LANGUAGE_DETECTOR_MIN_EVIDENCE)
+}
+
+func languageDetectorNGrams(text string) []string {
+	ngrams := make([]string, 0, len(text)*LANGUAGE_DETECTOR_MAX_NGRAM)
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(char rune) bool {
+		return !unicode.IsLetter(char)
+	}) {
+		runes := []rune(" " + word + " ")
+		for n := LANGUAGE_DETECTOR_MIN_NGRAM; n <= LANGUAGE_DETECTOR_MAX_NGRAM; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				ngram := string(runes[i : i+n])
+				if ngram == " " {
+					continue
+				}
+
+				ngrams = append(ngrams, ngram)
+			}
+		}
+	}
+
+	return ngrams
+}
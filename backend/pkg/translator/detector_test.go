@@ -0,0 +1,176 @@
+package translator_test
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"backend/pkg/config"
+	"backend/pkg/translator"
+
+	"github.com/neoxelox/kit"
+	"github.com/stretchr/testify/suite"
+)
+
+type LanguageDetectorTestSuite struct {
+	suite.Suite
+	ctx      context.Context
+	config   config.Config
+	detector *translator.LanguageDetector
+}
+
+func (self *LanguageDetectorTestSuite) SetupTest() {
+	self.ctx = context.Background()
+
+	self.config = *config.NewConfig()
+	self.config.Service.Environment = kit.EnvIntegration
+	self.config.Service.Release = "test"
+	self.config.Service.Name = "test"
+
+	observer, err := kit.NewObserver(self.ctx, kit.ObserverConfig{
+		Environment: self.config.Service.Environment,
+		Release:     self.config.Service.Release,
+		Service:     self.config.Service.Name,
+		Level:       kit.LvlError,
+	})
+	self.Require().NoError(err)
+
+	self.detector = translator.NewLanguageDetector(observer, self.config)
+}
+
+// Feedbacks that are not in the corpora, so the detector is measured on texts it has never seen
+var languageDetectorHeldOut = map[string][]string{
+	"ENGLISH": {
+		"The search never finds anything I type", "Lost all my photos after updating",
+		"Customer support never replied", "Love the new layout", "Signs me out every day", "Way too expensive",
+		"Really helpful app", "Notifications arrive hours late", "Simple and fast", "Cannot upload files",
+	},
+	"SPANISH": {
+		"El buscador nunca encuentra nada de lo que escribo", "Perdí todas mis fotos al actualizar",
+		"Soporte nunca respondió", "Me encanta el nuevo diseño", "Me desconecta todo el rato", "Demasiado caro",
+		"Aplicación muy útil", "Las notificaciones llegan con horas de retraso", "Sencilla y rápida",
+		"No puedo subir archivos",
+	},
+	"GERMAN": {
+		"Die Suche findet nie, was ich eingebe", "Nach dem Update waren alle meine Fotos weg",
+		"Der Support hat nie geantwortet", "Das neue Layout ist super", "Meldet mich ständig ab", "Viel zu teuer",
+		"Wirklich hilfreiche App", "Benachrichtigungen kommen Stunden zu spät", "Einfach und schnell",
+		"Kann keine Dateien hochladen",
+	},
+	"FRENCH": {
+		"La recherche ne trouve jamais ce que je tape", "J'ai perdu toutes mes photos après la mise à jour",
+		"Le support n'a jamais répondu", "J'adore la nouvelle présentation", "Me déconnecte sans arrêt",
+		"Beaucoup trop cher", "Application vraiment utile", "Les notifications arrivent avec des heures de retard",
+		"Simple et rapide", "Impossible d'envoyer des fichiers",
+	},
+	"ITALIAN": {
+		"La ricerca non trova mai quello che scrivo", "Ho perso tutte le foto dopo l'aggiornamento",
+		"L'assistenza non ha mai risposto", "Adoro la nuova grafica", "Mi disconnette di continuo",
+		"Decisamente troppo cara", "App davvero utile", "Le notifiche arrivano con ore di ritardo",
+		"Semplice e veloce", "Non riesco a caricare i file",
+	},
+	"PORTUGUESE": {
+		"A busca nunca encontra o que eu digito", "Perdi todas as minhas fotos depois de atualizar",
+		"O suporte nunca respondeu", "Adorei o novo visual", "Fica me desconectando", "Caro demais",
+		"Aplicativo muito útil", "As notificações chegam com horas de atraso", "Simples e rápido",
+		"Não consigo enviar arquivos",
+	},
+}
+
+func TestLanguageDetectorSuite(t *testing.T) {
+	suite.Run(t, new(LanguageDetectorTestSuite))
+}
+
+func (self *LanguageDetectorTestSuite) TestHeldOutIsNotInCorpora() {
+	// Given: The corpora the detector is trained on
+	files, err := os.ReadDir("corpora")
+	self.Require().NoError(err)
+
+	corpora := ""
+	for _, file := range files {
+		corpus, err := os.ReadFile(path.Join("corpora", file.Name()))
+		self.Require().NoError(err)
+
+		corpora += strings.ToLower(string(corpus))
+	}
+
+	for _, texts := range languageDetectorHeldOut {
+		for _, text := range texts {
+			// When: A held-out feedback is looked up in the corpora
+			found := strings.Contains(corpora, strings.ToLower(text))
+
+			// Then: The feedback is not part of the corpora
+			self.False(found, text)
+		}
+	}
+}
+
+func (self *LanguageDetectorTestSuite) TestHeldOutConfidentGuessesAreRight() {
+	// Given: Held-out feedbacks written in the supported languages
+	total := 0
+	confident := 0
+
+	for expected, texts := range languageDetectorHeldOut {
+		for _, text := range texts {
+			// When: The language of the feedback is detected
+			language, confidence := self.detector.Detect(text)
+
+			// Then: Every confident guess is right
+			total++
+			if confidence >= translator.LANGUAGE_DETECTOR_MIN_CONFIDENCE {
+				confident++
+				self.Equal(expected, language, text)
+			}
+		}
+	}
+
+	// Then: Most feedbacks, short ones included, do not need the engine
+	self.GreaterOrEqual(float64(confident)/float64(total), 0.6)
+}
+
+func (self *LanguageDetectorTestSuite) TestShortHeldOutText() {
+	// Given: Short held-out feedbacks of a couple of words
+	texts := map[string]string{
+		"Way too expensive": "ENGLISH",
+		"Sencilla y rápida": "SPANISH",
+		"Viel zu teuer":     "GERMAN",
+		"Simple et rapide":  "FRENCH",
+		"App davvero utile": "ITALIAN",
+		"Caro demais":       "PORTUGUESE",
+	}
+
+	for text, expected := range texts {
+		// When: The language of the feedback is detected
+		language, confidence := self.detector.Detect(text)
+
+		// Then: The language is detected confidently
+		self.Equal(expected, language, text)
+		self.GreaterOrEqual(confidence, translator.LANGUAGE_DETECTOR_MIN_CONFIDENCE, text)
+	}
+}
+
+func (self *LanguageDetectorTestSuite) TestTooFewLetters() {
+	// Given: Feedbacks with fewer letters than the minimum, some of which used to be confidently misdetected
+	texts := []string{"ok", "Meh", "Bof", "Top", "Nul", "Fine", "Vale"}
+
+	for _, text := range texts {
+		// When: The language of the feedback is detected
+		_, confidence := self.detector.Detect(text)
+
+		// Then: The detection is not confident
+		self.Less(confidence, translator.LANGUAGE_DETECTOR_MIN_CONFIDENCE, text)
+	}
+}
+
+func (self *LanguageDetectorTestSuite) TestUnknownLanguage() {
+	// Given: A feedback written in a language without corpus
+	text := "Aplikacja działa bardzo dobrze, polecam"
+
+	// When: The language of the feedback is detected
+	_, confidence := self.detector.Detect(text)
+
+	// Then: The detection is not confident
+	self.Less(confidence, translator.LANGUAGE_DETECTOR_MIN_CONFIDENCE)
+}
@@ -9,6 +9,7 @@ import (
 	"backend/pkg/engine"
 	"backend/pkg/feedback"
 	"backend/pkg/organization"
+	"backend/pkg/outbox"
 	"backend/pkg/processor"
 	"backend/pkg/product"
 	"backend/pkg/util"
@@ -26,27 +27,32 @@ const (
 type FeedbackTranslator struct {
 	config                 config.Config
 	observer               *kit.Observer
+	database               *kit.Database
 	feedbackRepository     *feedback.FeedbackRepository
 	productRepository      *product.ProductRepository
 	organizationRepository organization.OrganizationRepository
-	enqueuer               *kit.Enqueuer
+	enqueuer               *outbox.OutboxEnqueuer
 	engineService          *engine.EngineService
 	engineBreaker          *engine.EngineBreaker
+	languageDetector       *LanguageDetector
 }
 
-func NewFeedbackTranslator(observer *kit.Observer, feedbackRepository *feedback.FeedbackRepository,
-	productRepository *product.ProductRepository, organizationRepository organization.OrganizationRepository,
-	enqueuer *kit.Enqueuer, engineService *engine.EngineService, engineBreaker *engine.EngineBreaker,
+func NewFeedbackTranslator(observer *kit.Observer, database *kit.Database,
+	feedbackRepository *feedback.FeedbackRepository, productRepository *product.ProductRepository,
+	organizationRepository organization.OrganizationRepository, enqueuer *outbox.OutboxEnqueuer,
+	engineService *engine.EngineService, engineBreaker *engine.EngineBreaker, languageDetector *LanguageDetector,
 	config config.Config) *FeedbackTranslator {
 	return &FeedbackTranslator{
 		config:                 config,
 		observer:               observer,
+		database:               database,
 		feedbackRepository:     feedbackRepository,
 		productRepository:      productRepository,
 		organizationRepository: organizationRepository,
 		enqueuer:               enqueuer,
 		engineService:          engineService,
 		engineBreaker:          engineBreaker,
+		languageDetector:       languageDetector,
 	}
 }
 
@@ -55,10 +61,6 @@ type FeedbackTranslatorTranslateParams struct {
 }
 
 func (self *FeedbackTranslator) Translate(ctx context.Context, task *asynq.Task) error {
-	if self.engineBreaker.IsOpen(ctx) {
-		return nil
-	}
-
 	params := FeedbackTranslatorTranslateParams{}
 
 	err := json.Unmarshal(task.Payload(), &params)
@@ -67,6 +69,12 @@ func (self *FeedbackTranslator) Translate(ctx context.Context, task *asynq.Task)
 		return nil
 	}
 
+	allowed, delay := self.engineBreaker.Allow(ctx, engine.ENGINE_BREAKER_OPERATION_TRANSLATION)
+	if !allowed {
+		return self.enqueuer.Enqueue(ctx, FeedbackTranslatorTranslate, params,
+			asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.ProcessIn(delay))
+	}
+
 	feedback, err := self.feedbackRepository.GetByID(ctx, params.FeedbackID)
 	if err != nil {
 		return err
@@ -76,7 +84,7 @@ func (self *FeedbackTranslator) Translate(ctx context.Context, task *asynq.Task)
 		return nil
 	}
 
-	if feedback.TranslatedAt != nil {
+	if feedback.TranslatedAt != nil || feedback.SkippedAt != nil {
 		return nil
 	}
 
@@ -114,24 +122,13 @@ func (self *FeedbackTranslator) Translate(ctx context.Context, task *asynq.Task)
 		return nil
 	}
 
-	result, err := self.engineService.DetectLanguage(ctx, engine.EngineServiceDetectLanguageParams{
-		Feedback: engine.Feedback{
-			Content: feedback.Content,
-		},
-	})
+	language, detection, tokens, err := self.detectLanguage(ctx, feedback.Content)
 	if err != nil {
-		if engine.ErrEngineServiceTimedOut.Is(err) {
-			err := self.engineBreaker.Open(ctx)
-			if err != nil {
-				self.observer.Error(ctx, err)
-			}
-		}
-
 		return err
 	}
 
-	feedback.Language = result.Language
-	tokens := (result.Usage.Input + result.Usage.Output)
+	feedback.Language = language
+	feedback.LanguageDetection = &detection
 
 	if feedback.Language != product.Language {
 		result, err := self.engineService.TranslateFeedback(ctx, engine.EngineServiceTranslateFeedbackParams{
@@ -143,7 +140,7 @@ func (self *FeedbackTranslator) Translate(ctx context.Context, task *asynq.Task)
 		})
 		if err != nil {
 			if engine.ErrEngineServiceTimedOut.Is(err) {
-				err := self.engineBreaker.Open(ctx)
+				err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_TRANSLATION)
 				if err != nil {
 					self.observer.Error(ctx, err)
 				}
@@ -156,20 +153,34 @@ func (self *FeedbackTranslator) Translate(ctx context.Context, task *asynq.Task)
 		tokens += (result.Usage.Input + result.Usage.Output)
 	}
 
+	err = self.engineBreaker.Succeed(ctx, engine.ENGINE_BREAKER_OPERATION_TRANSLATION)
+	if err != nil {
+		self.observer.Error(ctx, err)
+	}
+
 	feedback.Tokens += tokens
 	feedback.TranslatedAt = kitUtil.Pointer(time.Now())
 
-	err = self.feedbackRepository.UpdateTranslated(ctx, *feedback)
+	err = self.database.Transaction(ctx, nil, func(ctx context.Context) error {
+		err := self.feedbackRepository.UpdateTranslated(ctx, *feedback)
+		if err != nil {
+			return err
+		}
+
+		err = self.enqueuer.Enqueue(ctx, processor.FeedbackProcessorProcess, processor.FeedbackProcessorProcessParams{
+			FeedbackID: feedback.ID,
+		}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.Unique(12*time.Hour))
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	err = self.enqueuer.Enqueue(ctx, processor.FeedbackProcessorProcess, processor.FeedbackProcessorProcessParams{
-		FeedbackID: feedback.ID,
-	}, asynq.MaxRetry(2), asynq.Unique(12*time.Hour))
-	if err != nil {
-		self.observer.Error(ctx, err)
-	}
+	util.PipelineObserve(ctx, self.observer, util.PipelineStageTranslation, feedback.CollectedAt)
 
 	self.observer.Infof(ctx, "Translated a feedback from %s to %s using %d tokens",
 		feedback.Language, product.Language, tokens)
@@ -177,14 +188,47 @@ func (self *FeedbackTranslator) Translate(ctx context.Context, task *asynq.Task)
 	return nil
 }
 
+// detectLanguage only asks the engine when the in-process detector is not confident enough about the language.
+func (self *FeedbackTranslator) detectLanguage(ctx context.Context, content string) (string, string, int, error) {
+	language, confidence := self.languageDetector.Detect(content)
+	if confidence >= LANGUAGE_DETECTOR_MIN_CONFIDENCE {
+		self.observer.Debugf(ctx, "Detected %s language locally with %.2f confidence", language, confidence)
+
+		return language, feedback.FeedbackLanguageDetectionLocal, 0, nil
+	}
+
+	result, err := self.engineService.DetectLanguage(ctx, engine.EngineServiceDetectLanguageParams{
+		Feedback: engine.Feedback{
+			Content: content,
+		},
+	})
+	if err != nil {
+		if engine.ErrEngineServiceTimedOut.Is(err) {
+			err := self.engineBreaker.Open(ctx, engine.ENGINE_BREAKER_OPERATION_TRANSLATION)
+			if err != nil {
+				self.observer.Error(ctx, err)
+			}
+		}
+
+		return "", "", 0, err
+	}
+
+	self.observer.Debugf(ctx, "Detected %s language with the engine after %.2f local confidence",
+		result.Language, confidence)
+
+	return result.Language, feedback.FeedbackLanguageDetectionEngine, result.Usage.Input + result.Usage.Output, nil
+}
+
 func (self *FeedbackTranslator) Schedule(ctx context.Context, _ *asynq.Task) error {
+	before := util.PipelineSweepBefore(self.config)
+
 	pagination := util.Pagination[time.Time]{
 		Limit: 1000,
 		From:  nil,
 	}
 
 	for {
-		page, err := self.feedbackRepository.ListIDsByNotTranslated(ctx, pagination)
+		page, err := self.feedbackRepository.ListIDsByNotTranslated(ctx, before, pagination)
 		if err != nil {
 			return err
 		}
@@ -192,7 +236,7 @@ func (self *FeedbackTranslator) Schedule(ctx context.Context, _ *asynq.Task) err
 		for _, id := range page.Items {
 			err := self.enqueuer.Enqueue(ctx, FeedbackTranslatorTranslate, FeedbackTranslatorTranslateParams{
 				FeedbackID: id,
-			}, asynq.MaxRetry(2), asynq.Unique(24*time.Hour))
+			}, asynq.MaxRetry(util.PipelineMaxRetry(self.config)), asynq.Unique(24*time.Hour))
 			if err != nil {
 				self.observer.Error(ctx, err)
 			}
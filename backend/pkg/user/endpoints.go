@@ -13,6 +13,7 @@ import (
 	"backend/pkg/brevo"
 	"backend/pkg/config"
 	"backend/pkg/organization"
+	"backend/pkg/product"
 	"backend/pkg/util"
 
 	"github.com/badoux/checkmail"
@@ -151,6 +152,8 @@ func (self *UserEndpoints) GetMySettings(ctx echo.Context) error {
 }
 
 type UserEndpointsPutMySettingsRequest struct {
+	Language *string `json:"language"`
+	Digest   *bool   `json:"digest"`
 }
 
 type UserEndpointsPutMySettingsResponse struct {
@@ -167,6 +170,16 @@ func (self *UserEndpoints) PutMySettings(ctx echo.Context) error {
 		return kit.HTTPErrInvalidRequest.Cause(err)
 	}
 
+	if request.Language != nil && !product.IsLanguageSupported(*request.Language) {
+		return kit.HTTPErrInvalidRequest
+	}
+
+	requestUser.Settings.Language = request.Language
+
+	if request.Digest != nil {
+		requestUser.Settings.Digest = *request.Digest
+	}
+
 	err = self.userRepository.UpdateSettings(requestCtx, *requestUser)
 	if err != nil {
 		return kit.HTTPErrServerGeneric.Cause(err)
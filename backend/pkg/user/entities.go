@@ -22,6 +22,9 @@ func IsUserRole(value string) bool {
 }
 
 type UserSettings struct {
+	Language *string
+	// Whether the user receives the weekly digest of the products of its organization
+	Digest bool
 }
 
 type User struct {
@@ -3,6 +3,8 @@ package user
 import "time"
 
 type UserPayloadSettings struct {
+	Language *string `json:"language"`
+	Digest   bool    `json:"digest"`
 }
 
 type UserPayload struct {
@@ -24,8 +26,11 @@ func NewUserPayload(user User) *UserPayload {
 		Picture:        user.Picture,
 		Email:          user.Email,
 		Role:           user.Role,
-		Settings:       UserPayloadSettings{},
-		LeftAt:         user.DeletedAt,
+		Settings: UserPayloadSettings{
+			Language: user.Settings.Language,
+			Digest:   user.Settings.Digest,
+		},
+		LeftAt: user.DeletedAt,
 	}
 }
 
@@ -0,0 +1,24 @@
+package util
+
+import (
+	"context"
+
+	"github.com/neoxelox/kit"
+)
+
+// InvalidateCache deletes every cached key starting with the given prefix.
+func InvalidateCache(ctx context.Context, cache *kit.Cache, prefix string) error {
+	keys, err := cache.Find(ctx, prefix+"*")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		err = cache.Delete(ctx, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
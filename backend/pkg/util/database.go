@@ -1,8 +1,12 @@
 package util
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
 )
 
 type Cursor[V any] struct {
@@ -56,3 +60,16 @@ type Pagination[C any] struct {
 	Limit int
 	From  *Cursor[C]
 }
+
+// LockTransaction blocks until no other transaction holds the same key and keeps it until the current
+// transaction commits or rolls back, so it must be called within a transaction.
+func LockTransaction(ctx context.Context, database *kit.Database, key string) error {
+	stmt := sqlf.New("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", key)
+
+	_, err := database.Exec(ctx, stmt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/hibiken/asynq"
 	"github.com/labstack/echo/v4"
 	"github.com/neoxelox/kit"
 
@@ -12,19 +13,21 @@ import (
 )
 
 type HealthEndpoints struct {
-	config   config.Config
-	observer *kit.Observer
-	database *kit.Database
-	cache    *kit.Cache
+	config    config.Config
+	observer  *kit.Observer
+	database  *kit.Database
+	cache     *kit.Cache
+	inspector *asynq.Inspector
 }
 
 func NewHealthEndpoints(observer *kit.Observer, database *kit.Database, cache *kit.Cache,
-	config config.Config) *HealthEndpoints {
+	inspector *asynq.Inspector, config config.Config) *HealthEndpoints {
 	return &HealthEndpoints{
-		config:   config,
-		observer: observer,
-		database: database,
-		cache:    cache,
+		config:    config,
+		observer:  observer,
+		database:  database,
+		cache:     cache,
+		inspector: inspector,
 	}
 }
 
@@ -33,9 +36,20 @@ type HealthEndpointsGetHealthResponseItem struct {
 	Latency int64  `json:"latency"`
 }
 
+type HealthEndpointsGetHealthResponseQueue struct {
+	Pending   int   `json:"pending"`
+	Active    int   `json:"active"`
+	Scheduled int   `json:"scheduled"`
+	Retry     int   `json:"retry"`
+	Archived  int   `json:"archived"`
+	Latency   int64 `json:"latency"`
+	Paused    bool  `json:"paused"`
+}
+
 type HealthEndpointsGetHealthResponse struct {
-	Database HealthEndpointsGetHealthResponseItem `json:"database"`
-	Cache    HealthEndpointsGetHealthResponseItem `json:"cache"`
+	Database HealthEndpointsGetHealthResponseItem             `json:"database"`
+	Cache    HealthEndpointsGetHealthResponseItem             `json:"cache"`
+	Queues   map[string]HealthEndpointsGetHealthResponseQueue `json:"queues,omitempty"`
 }
 
 func (self *HealthEndpoints) GetServerHealth(ctx echo.Context) error {
@@ -89,6 +103,33 @@ func (self *HealthEndpoints) GetWorkerHealth(res http.ResponseWriter, req *http.
 		response.Cache.Error = nil
 	}
 
+	// Queue depths are informative, a backlog does not make the worker unhealthy
+	if errC == nil {
+		queues, err := self.inspector.Queues()
+		if err != nil {
+			self.observer.Error(requestCtx, err)
+		}
+
+		response.Queues = make(map[string]HealthEndpointsGetHealthResponseQueue, len(queues))
+		for _, queue := range queues {
+			info, err := self.inspector.GetQueueInfo(queue)
+			if err != nil {
+				self.observer.Error(requestCtx, err)
+				continue
+			}
+
+			response.Queues[queue] = HealthEndpointsGetHealthResponseQueue{
+				Pending:   info.Pending,
+				Active:    info.Active,
+				Scheduled: info.Scheduled,
+				Retry:     info.Retry,
+				Archived:  info.Archived,
+				Latency:   info.Latency.Milliseconds(),
+				Paused:    info.Paused,
+			}
+		}
+	}
+
 	res.Header().Set("Content-Type", "application/json")
 	if errD != nil || errC != nil {
 		res.WriteHeader(http.StatusServiceUnavailable)
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+
+	"backend/pkg/config"
+)
+
+// NewInspector returns an inspector of the worker queues connected the same way as the enqueuer.
+func NewInspector(config config.Config) *asynq.Inspector {
+	var ssl *tls.Config
+	if config.Cache.SSLMode {
+		ssl = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return asynq.NewInspector(asynq.RedisClientOpt{
+		Addr:         fmt.Sprintf("%s:%d", config.Cache.Host, config.Cache.Port),
+		TLSConfig:    ssl,
+		Password:     config.Cache.Password,
+		DialTimeout:  config.Cache.DialTimeout,
+		ReadTimeout:  config.Cache.ReadTimeout,
+		WriteTimeout: config.Cache.WriteTimeout,
+		PoolSize:     config.Cache.MaxConns,
+	})
+}
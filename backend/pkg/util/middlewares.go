@@ -1,6 +1,8 @@
 package util
 
 import (
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -46,3 +48,24 @@ func (self *RateLimitMiddleware) Handle(limit int, period time.Duration) func(ne
 		}
 	}
 }
+
+// IsStreamRequest checks whether the client expects the response to be streamed as server-sent events.
+func IsStreamRequest(request *http.Request) bool {
+	return strings.Contains(request.Header.Get(echo.HeaderAccept), "text/event-stream")
+}
+
+// SkipStreams skips the middleware on the requests whose response is streamed, for middlewares that buffer the
+// response or bound it to the usual request timeout. Streaming endpoints must bound themselves instead.
+func SkipStreams(middleware echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		handler := middleware(next)
+
+		return func(ctx echo.Context) error {
+			if IsStreamRequest(ctx.Request()) {
+				return next(ctx)
+			}
+
+			return handler(ctx)
+		}
+	}
+}
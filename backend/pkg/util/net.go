@@ -1,7 +1,23 @@
 package util
 
 import (
+	"context"
+	"net"
+	"net/http"
 	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/neoxelox/errors"
+)
+
+var (
+	// Shared address space of carrier-grade NATs, which net.IP.IsPrivate does not cover
+	_SHARED_ADDRESS_SPACE = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+)
+
+var (
+	ErrNotPublicAddress = errors.New("address %s is not public")
 )
 
 func SameOrigin(urlA string, urlB string) bool {
@@ -25,3 +41,73 @@ func SameOrigin(urlA string, urlB string) bool {
 
 	return true
 }
+
+// IsPublicIP tells whether the IP is routable on the internet, rejecting private, loopback, link-local and other
+// internal addresses.
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		_SHARED_ADDRESS_SPACE.Contains(ip))
+}
+
+// IsPublicURL tells whether the URL is an HTTPS URL whose host currently only resolves to public IPs. The host can
+// be pointed elsewhere afterwards, so requests to it must still be made with NewPublicHTTPClient.
+func IsPublicURL(ctx context.Context, rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.Scheme != "https" || len(parsedURL.Hostname()) == 0 {
+		return false
+	}
+
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, parsedURL.Hostname())
+	if err != nil || len(ips) == 0 {
+		return false
+	}
+
+	for _, ip := range ips {
+		if !IsPublicIP(ip.IP) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PublicDialControl rejects connections to IPs that are not public. As a dialer control it checks the IP that is
+// actually dialed once the host has been resolved, so the host cannot be rebound to an internal address.
+func PublicDialControl(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return ErrNotPublicAddress.Raise(address)
+	}
+
+	return nil
+}
+
+// NewPublicHTTPClient returns an HTTP client that can only connect to public IPs and does not follow redirects,
+// for requests made to URLs given by users.
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: PublicDialControl,
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			// A proxy would dial the internal address on behalf of the client
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: timeout,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Timeout: timeout,
+	}
+}
@@ -0,0 +1,84 @@
+package util_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/pkg/util"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type NetTestSuite struct {
+	suite.Suite
+}
+
+func TestNetSuite(t *testing.T) {
+	suite.Run(t, new(NetTestSuite))
+}
+
+func (self *NetTestSuite) TestIsPublicURL() {
+	// Given: URLs pointing to internal services and to the internet by their IP
+	internal := []string{
+		"https://127.0.0.1/hook",
+		"https://10.0.0.1/hook",
+		"https://192.168.1.1/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://100.64.0.1/hook",
+		"https://0.0.0.0/hook",
+		"https://[::1]/hook",
+		"https://[fe80::1]/hook",
+		"https://[fd00::1]/hook",
+		"http://8.8.8.8/hook",
+	}
+	public := []string{
+		"https://8.8.8.8/hook",
+		"https://[2001:4860:4860::8888]:8443/hook",
+	}
+
+	// When: They are checked
+	// Then: Only the HTTPS ones reaching the internet are public
+	for _, rawURL := range internal {
+		self.False(util.IsPublicURL(context.Background(), rawURL), rawURL)
+	}
+
+	for _, rawURL := range public {
+		self.True(util.IsPublicURL(context.Background(), rawURL), rawURL)
+	}
+}
+
+func (self *NetTestSuite) TestPublicDialControl() {
+	// Given: Resolved addresses of internal services and of the internet
+	internal := []string{"127.0.0.1:443", "169.254.169.254:80", "10.0.0.1:443", "[::1]:443", "[fd00::1]:443"}
+	public := []string{"8.8.8.8:443", "[2001:4860:4860::8888]:443"}
+
+	// When: They are about to be dialed
+	// Then: Only the ones reaching the internet are connected to
+	for _, address := range internal {
+		self.Error(util.PublicDialControl("tcp", address, nil), address)
+	}
+
+	for _, address := range public {
+		self.NoError(util.PublicDialControl("tcp", address, nil), address)
+	}
+}
+
+func (self *NetTestSuite) TestPublicHTTPClientRejectsInternal() {
+	// Given: A server listening on the loopback interface
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, _ *http.Request) {
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// When: A request is made to it with the public client
+	response, err := util.NewPublicHTTPClient(2 * time.Second).Get(server.URL)
+	if response != nil {
+		response.Body.Close()
+	}
+
+	// Then: The connection is refused before being made
+	self.Error(err)
+}
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"context"
+	"time"
+
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+)
+
+const (
+	PIPELINE_BATCH_MAX_RETRY     = 2
+	PIPELINE_STREAMING_MAX_RETRY = 5
+	PIPELINE_SWEEPER_GRACE       = 15 * time.Minute
+	PIPELINE_LATENCY_TARGET      = 15 * time.Minute
+)
+
+const (
+	PipelineStageTranslation = "translation"
+	PipelineStageProcessing  = "processing"
+	PipelineStageAggregation = "aggregation"
+)
+
+// PipelineMaxRetry returns how many times a pipeline task is retried by the worker.
+func PipelineMaxRetry(config config.Config) int {
+	if config.Pipeline.Streaming {
+		return PIPELINE_STREAMING_MAX_RETRY
+	}
+
+	return PIPELINE_BATCH_MAX_RETRY
+}
+
+// PipelineSweepBefore returns until when pending items are swept by the pipeline schedulers.
+// In streaming mode items are handed off through the outbox, so only the ones stuck for a while are swept.
+func PipelineSweepBefore(config config.Config) time.Time {
+	if config.Pipeline.Streaming {
+		return time.Now().Add(-PIPELINE_SWEEPER_GRACE)
+	}
+
+	return time.Now()
+}
+
+// PipelineObserve logs how long an item took to go through a stage of the pipeline.
+func PipelineObserve(ctx context.Context, observer *kit.Observer, stage string, since time.Time) {
+	observer.Infof(ctx, "Pipeline %s stage latency %.3fs", stage, time.Since(since).Seconds())
+}
@@ -0,0 +1,91 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CompareReleases orders two releases the way version numbers are, so "1.10.0" goes after "1.9.2",
+// "v2" after "1.9" and "2.0.0" after "2.0.0-beta". It returns -1, 0 or 1 like strings.Compare.
+func CompareReleases(first string, second string) int {
+	firstParts := splitRelease(first)
+	secondParts := splitRelease(second)
+
+	for i := 0; i < len(firstParts) && i < len(secondParts); i++ {
+		firstNumber, firstErr := strconv.Atoi(firstParts[i])
+		secondNumber, secondErr := strconv.Atoi(secondParts[i])
+
+		switch {
+		case firstErr == nil && secondErr == nil:
+			if firstNumber != secondNumber {
+				if firstNumber < secondNumber {
+					return -1
+				}
+				return 1
+			}
+		case firstErr == nil:
+			return 1
+		case secondErr == nil:
+			return -1
+		default:
+			comparison := strings.Compare(firstParts[i], secondParts[i])
+			if comparison != 0 {
+				return comparison
+			}
+		}
+	}
+
+	// A trailing number is a later build, while a trailing word is a pre-release, so "2.0.0-beta" < "2.0.0"
+	switch {
+	case len(firstParts) < len(secondParts):
+		if isReleaseNumber(secondParts[len(firstParts)]) {
+			return -1
+		}
+		return 1
+	case len(firstParts) > len(secondParts):
+		if isReleaseNumber(firstParts[len(secondParts)]) {
+			return 1
+		}
+		return -1
+	default:
+		return 0
+	}
+}
+
+func isReleaseNumber(part string) bool {
+	_, err := strconv.Atoi(part)
+	return err == nil
+}
+
+func splitRelease(release string) []string {
+	release = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(release)), "v")
+
+	parts := []string{}
+	part := strings.Builder{}
+	digits := false
+
+	flush := func() {
+		if part.Len() > 0 {
+			parts = append(parts, part.String())
+			part.Reset()
+		}
+	}
+
+	for _, char := range release {
+		if !unicode.IsLetter(char) && !unicode.IsDigit(char) {
+			flush()
+			continue
+		}
+
+		if part.Len() > 0 && unicode.IsDigit(char) != digits {
+			flush()
+		}
+
+		digits = unicode.IsDigit(char)
+		part.WriteRune(char)
+	}
+	flush()
+
+	return parts
+}
@@ -0,0 +1,44 @@
+package util_test
+
+import (
+	"testing"
+
+	"backend/pkg/util"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ReleaseTestSuite struct {
+	suite.Suite
+}
+
+func TestReleaseSuite(t *testing.T) {
+	suite.Run(t, new(ReleaseTestSuite))
+}
+
+func (self *ReleaseTestSuite) TestCompareReleases() {
+	// Given: Pairs of releases in increasing order
+	pairs := [][2]string{
+		{"1.9.2", "1.10.0"},
+		{"1.2", "1.2.1"},
+		{"1.9", "v2"},
+		{"2.0.0-beta", "2.0.0"},
+		{"2.0.0-alpha", "2.0.0-beta"},
+		{"1.0.0 (100)", "1.0.0 (101)"},
+	}
+
+	for _, pair := range pairs {
+		// When: The releases are compared both ways
+		// Then: The older release goes before the newer one
+		self.Equal(-1, util.CompareReleases(pair[0], pair[1]), pair)
+		self.Equal(1, util.CompareReleases(pair[1], pair[0]), pair)
+	}
+}
+
+func (self *ReleaseTestSuite) TestCompareEqualReleases() {
+	// Given: Releases written differently
+	// When: The releases are compared
+	// Then: The releases are the same
+	self.Equal(0, util.CompareReleases("v1.2.0", "1.2.0"))
+	self.Equal(0, util.CompareReleases("1.2.0", " 1_2_0 "))
+}
@@ -36,3 +36,17 @@ func Extract[T any](slice []T, condition func(T) bool) ([]T, []T) {
 
 	return extracted, filtered
 }
+
+func Unique[T comparable](slice []T) []T {
+	seen := make(map[T]bool, len(slice))
+	unique := []T{}
+
+	for _, item := range slice {
+		if !seen[item] {
+			seen[item] = true
+			unique = append(unique, item)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/leporo/sqlf"
+	"github.com/neoxelox/kit"
+
+	"backend/pkg/config"
+)
+
+const (
+	VectorIterativeScanOff     = "off"
+	VectorIterativeScanRelaxed = "relaxed_order"
+	VectorIterativeScanStrict  = "strict_order"
+)
+
+func IsVectorIterativeScan(value string) bool {
+	return value == VectorIterativeScanOff ||
+		value == VectorIterativeScanRelaxed ||
+		value == VectorIterativeScanStrict
+}
+
+// VectorSearch tunes the similarity searches over the HNSW indexes. The higher the EfSearch the better the recall
+// but the slower the search. Iterative scans keep traversing the index until enough rows pass the filters, which
+// the per product searches need as the indexes are shared by all the products. Exact skips the indexes entirely.
+type VectorSearch struct {
+	EfSearch      int
+	IterativeScan string
+	Exact         bool
+}
+
+func NewVectorSearch(config config.Config) *VectorSearch {
+	return &VectorSearch{
+		EfSearch:      config.Database.VectorEfSearch,
+		IterativeScan: config.Database.VectorIterativeScan,
+		Exact:         false,
+	}
+}
+
+// Apply sets the search parameters for the rest of the current transaction.
+func (self VectorSearch) Apply(ctx context.Context, database *kit.Database) error {
+	if !IsVectorIterativeScan(self.IterativeScan) {
+		return kit.ErrDatabaseGeneric.Raise().With("invalid vector iterative scan %s", self.IterativeScan)
+	}
+
+	stmts := []*sqlf.Stmt{
+		sqlf.New(fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", max(1, self.EfSearch))),
+		sqlf.New(fmt.Sprintf("SET LOCAL hnsw.iterative_scan = %s", self.IterativeScan)),
+	}
+
+	if self.Exact {
+		stmts = append(stmts, sqlf.New("SET LOCAL enable_indexscan = off"))
+	}
+
+	for _, stmt := range stmts {
+		_, err := database.Exec(ctx, stmt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors of the same dimensions, or 0 if any is null.
+func CosineSimilarity(a []float32, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -1,6 +1,6 @@
 import json
 from functools import partial
-from typing import List
+from typing import List, Literal, Tuple
 
 from flashrank import Ranker, RerankRequest
 from openai import OpenAI
@@ -9,12 +9,21 @@ from pydantic import BaseModel
 from src.common import Usage
 from src.common.utils import get_tokens
 from src.config import Config
+from src.translator.texts_translator import TextsTranslator
 
 from .issue_merger import IssueMerger
 from .issue_similarity_discernor import IssueSimilarityDiscernor
 from .suggestion_merger import SuggestionMerger
 from .suggestion_similarity_discernor import SuggestionSimilarityDiscernor
 
+# The backend stores every embedding alongside the model that computed it, so models can be switched safely
+EmbeddingModel = Literal["text-embedding-3-small", "text-embedding-3-large"]
+DEFAULT_EMBEDDING_MODEL = "text-embedding-3-small"
+EMBEDDING_DIMENSIONS = 1536
+
+# The mergers are tuned in English, so their output is localized afterwards when another language is requested
+DEFAULT_LANGUAGE = "ENGLISH"
+
 
 class Aggregator:
     def __init__(self, config: Config) -> None:
@@ -22,7 +31,7 @@ class Aggregator:
 
         self.embedder = partial(
             OpenAI(api_key=config.lm.openai.api_key).embeddings.create,
-            model="text-embedding-3-small",
+            dimensions=EMBEDDING_DIMENSIONS,
             encoding_format="float",
         )
 
@@ -30,21 +39,59 @@ class Aggregator:
             model_name="rank-T5-flan", cache_dir=f"{config.service.resources_path}/rank-T5-flan"
         ).rerank
 
+        # The default ranker only understands English, so other languages are ranked by a multilingual one
+        self.multilingual_ranker = Ranker(
+            model_name="ms-marco-MultiBERT-L-12", cache_dir=f"{config.service.resources_path}/ms-marco-MultiBERT-L-12"
+        ).rerank
+
+        self.texts_translator = TextsTranslator(config=config)
+
         self.issue_similarity_discernor = IssueSimilarityDiscernor(config=config)
         self.issue_merger = IssueMerger(config=config)
 
         self.suggestion_similarity_discernor = SuggestionSimilarityDiscernor(config=config)
         self.suggestion_merger = SuggestionMerger(config=config)
 
+    def rank(self, query: str, passages: List[str], language: str) -> List[dict]:
+        ranker = self.ranker if language == DEFAULT_LANGUAGE else self.multilingual_ranker
+
+        return ranker(
+            RerankRequest(
+                query=query,
+                passages=[{"index": index, "text": passage} for index, passage in enumerate(passages)],
+            )
+        )
+
+    # All the texts of a result are localized at once, so a result costs a single translation whatever its length
+    def localize(self, texts: List[str], language: str) -> Tuple[List[str], Usage]:
+        if not any(texts) or language == DEFAULT_LANGUAGE:
+            return texts, Usage(input=0, output=0)
+
+        translations = self.texts_translator(
+            input=TextsTranslator.Input(
+                texts=texts,
+                from_language=DEFAULT_LANGUAGE,
+                to_language=language,
+            )
+        ).output.translations
+
+        # TODO: Use real usage
+        input_tokens = get_tokens(json.dumps(texts)) + 1000
+        output_tokens = get_tokens(json.dumps(translations)) + 100
+
+        return translations, Usage(input=input_tokens, output=output_tokens)
+
     class ComputeEmbeddingParams(BaseModel):
         text: str
+        model: EmbeddingModel = DEFAULT_EMBEDDING_MODEL
 
     class ComputeEmbeddingResult(BaseModel):
         embedding: List[float]
+        model: str
         usage: Usage
 
     def compute_embedding(self, params: ComputeEmbeddingParams) -> ComputeEmbeddingResult:
-        embedding = self.embedder(input=params.text).data[0].embedding
+        embedding = self.embedder(input=params.text, model=params.model).data[0].embedding
 
         # TODO: Use real usage
         input_tokens = get_tokens(params.text)
@@ -52,6 +99,7 @@ class Aggregator:
 
         return self.ComputeEmbeddingResult(
             embedding=embedding,
+            model=params.model,
             usage=Usage(
                 input=input_tokens,
                 output=output_tokens,
@@ -61,6 +109,7 @@ class Aggregator:
     class SimilarIssueParams(BaseModel):
         issue: str
         options: List[str]
+        language: str = DEFAULT_LANGUAGE
 
     class SimilarIssueResult(BaseModel):
         option: int
@@ -76,12 +125,7 @@ class Aggregator:
                 ),
             )
 
-        similar_issues = self.ranker(
-            RerankRequest(
-                query=params.issue,
-                passages=[{"index": index, "text": issue} for index, issue in enumerate(params.options)],
-            )
-        )
+        similar_issues = self.rank(params.issue, params.options, params.language)
 
         similar_issues = [(issue["index"], issue["text"]) for issue in similar_issues if float(issue["score"]) >= 0.30][
             :3
@@ -128,6 +172,7 @@ class Aggregator:
 
         issue_a: Issue
         issue_b: Issue
+        language: str = DEFAULT_LANGUAGE
 
     class MergeIssuesResult(BaseModel):
         class Issue(BaseModel):
@@ -160,21 +205,25 @@ class Aggregator:
         )
         output_tokens = get_tokens(issue.model_dump_json()) + 100
 
+        localized, usage = self.localize([issue.title, issue.description, *issue.steps], params.language)
+        texts = iter(localized)
+
         return self.MergeIssuesResult(
             issue=self.MergeIssuesResult.Issue(
-                title=issue.title,
-                description=issue.description,
-                steps=issue.steps,
+                title=next(texts),
+                description=next(texts),
+                steps=[next(texts) for _ in issue.steps],
             ),
             usage=Usage(
-                input=input_tokens,
-                output=output_tokens,
+                input=input_tokens + usage.input,
+                output=output_tokens + usage.output,
             ),
         )
 
     class SimilarSuggestionParams(BaseModel):
         suggestion: str
         options: List[str]
+        language: str = DEFAULT_LANGUAGE
 
     class SimilarSuggestionResult(BaseModel):
         option: int
@@ -190,12 +239,7 @@ class Aggregator:
                 ),
             )
 
-        similar_suggestions = self.ranker(
-            RerankRequest(
-                query=params.suggestion,
-                passages=[{"index": index, "text": suggestion} for index, suggestion in enumerate(params.options)],
-            )
-        )
+        similar_suggestions = self.rank(params.suggestion, params.options, params.language)
 
         similar_suggestions = [
             (suggestion["index"], suggestion["text"])
@@ -244,6 +288,7 @@ class Aggregator:
 
         suggestion_a: Suggestion
         suggestion_b: Suggestion
+        language: str = DEFAULT_LANGUAGE
 
     class MergeSuggestionsResult(BaseModel):
         class Suggestion(BaseModel):
@@ -276,14 +321,19 @@ class Aggregator:
         )
         output_tokens = get_tokens(suggestion.model_dump_json()) + 100
 
+        localized, usage = self.localize(
+            [suggestion.title, suggestion.description, suggestion.reason], params.language
+        )
+        texts = iter(localized)
+
         return self.MergeSuggestionsResult(
             suggestion=self.MergeSuggestionsResult.Suggestion(
-                title=suggestion.title,
-                description=suggestion.description,
-                reason=suggestion.reason,
+                title=next(texts),
+                description=next(texts),
+                reason=next(texts),
             ),
             usage=Usage(
-                input=input_tokens,
-                output=output_tokens,
+                input=input_tokens + usage.input,
+                output=output_tokens + usage.output,
             ),
         )
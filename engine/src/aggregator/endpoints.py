@@ -5,7 +5,7 @@ from pydantic import BaseModel
 from src.common import Usage
 from src.config import Config
 
-from .aggregator import Aggregator
+from .aggregator import DEFAULT_EMBEDDING_MODEL, DEFAULT_LANGUAGE, Aggregator, EmbeddingModel
 
 
 class AggregatorEndpoints:
@@ -15,26 +15,31 @@ class AggregatorEndpoints:
 
     class PostComputeEmbeddingRequest(BaseModel):
         text: str
+        model: EmbeddingModel = DEFAULT_EMBEDDING_MODEL
 
     class PostComputeEmbeddingResponse(BaseModel):
         embedding: List[float]
+        model: str
         usage: Usage
 
     async def post_compute_embedding(self, request: PostComputeEmbeddingRequest) -> PostComputeEmbeddingResponse:
         result = self.aggregator.compute_embedding(
             params=Aggregator.ComputeEmbeddingParams(
                 text=request.text,
+                model=request.model,
             )
         )
 
         return self.PostComputeEmbeddingResponse(
             embedding=result.embedding,
+            model=result.model,
             usage=result.usage,
         )
 
     class PostSimilarIssueRequest(BaseModel):
         issue: str
         options: List[str]
+        language: str = DEFAULT_LANGUAGE
 
     class PostSimilarIssueResponse(BaseModel):
         option: int
@@ -45,6 +50,7 @@ class AggregatorEndpoints:
             params=Aggregator.SimilarIssueParams(
                 issue=request.issue,
                 options=request.options,
+                language=request.language,
             )
         )
 
@@ -61,6 +67,7 @@ class AggregatorEndpoints:
 
         issue_a: Issue
         issue_b: Issue
+        language: str = DEFAULT_LANGUAGE
 
     class PostMergeIssuesResponse(BaseModel):
         class Issue(BaseModel):
@@ -84,6 +91,7 @@ class AggregatorEndpoints:
                     description=request.issue_b.description,
                     steps=request.issue_b.steps,
                 ),
+                language=request.language,
             )
         )
 
@@ -99,6 +107,7 @@ class AggregatorEndpoints:
     class PostSimilarSuggestionRequest(BaseModel):
         suggestion: str
         options: List[str]
+        language: str = DEFAULT_LANGUAGE
 
     class PostSimilarSuggestionResponse(BaseModel):
         option: int
@@ -109,6 +118,7 @@ class AggregatorEndpoints:
             params=Aggregator.SimilarSuggestionParams(
                 suggestion=request.suggestion,
                 options=request.options,
+                language=request.language,
             )
         )
 
@@ -125,6 +135,7 @@ class AggregatorEndpoints:
 
         suggestion_a: Suggestion
         suggestion_b: Suggestion
+        language: str = DEFAULT_LANGUAGE
 
     class PostMergeSuggestionsResponse(BaseModel):
         class Suggestion(BaseModel):
@@ -148,6 +159,7 @@ class AggregatorEndpoints:
                     description=request.suggestion_b.description,
                     reason=request.suggestion_b.reason,
                 ),
+                language=request.language,
             )
         )
 
@@ -0,0 +1,2 @@
+from .chat import Chat
+from .endpoints import ChatEndpoints
@@ -0,0 +1,114 @@
+import re
+from typing import Any, Dict, Iterator, List, Optional
+
+import litellm
+from pydantic import BaseModel
+
+from src.common import SYSTEM_PROMPT, Usage
+from src.common.utils import get_tokens
+from src.config import Config
+
+CHAT_PROMPT = """
+Answer the question of a product manager about what the customers of a product (context is provided) say.
+- Answer ONLY with the information of the sources, if the sources do not answer the question, say so.
+- Cite the feedbacks that support every statement with their ID between square brackets, for example [cnq2nbm0l4s5j1e8r2tg].
+- Do not cite issues or suggestions, they summarize many feedbacks.
+- Answer in the same language as the question.
+""".strip()  # fmt: skip
+
+CITATION_REGEX = re.compile(r"\[([0-9a-v]{20})\]")
+
+
+class Chat:
+    def __init__(self, config: Config, backend: Dict[str, Any]) -> None:
+        self.config = config
+        self.backend = backend
+
+    class AnswerQuestionParams(BaseModel):
+        class Feedback(BaseModel):
+            id: str
+            content: str
+
+        class Issue(BaseModel):
+            title: str
+            description: str
+
+        class Suggestion(BaseModel):
+            title: str
+            description: str
+
+        class Message(BaseModel):
+            question: str
+            answer: str
+
+        context: str
+        question: str
+        feedbacks: List[Feedback]
+        issues: List[Issue]
+        suggestions: List[Suggestion]
+        history: List[Message] = []
+
+    class AnswerQuestionEvent(BaseModel):
+        delta: Optional[str] = None
+        citations: Optional[List[str]] = None
+        usage: Optional[Usage] = None
+
+    def _new_sources(self, params: AnswerQuestionParams) -> str:
+        sources = [f"Context: {params.context}"]
+
+        sources.append("Feedbacks:")
+        sources.extend([f"[{feedback.id}] {feedback.content}" for feedback in params.feedbacks])
+
+        sources.append("Issues:")
+        sources.extend([f"- {issue.title}: {issue.description}" for issue in params.issues])
+
+        sources.append("Suggestions:")
+        sources.extend([f"- {suggestion.title}: {suggestion.description}" for suggestion in params.suggestions])
+
+        return "\n".join(sources)
+
+    def answer_question(self, params: AnswerQuestionParams) -> Iterator[AnswerQuestionEvent]:
+        messages = [
+            {"role": "system", "content": SYSTEM_PROMPT},
+            {"role": "system", "content": CHAT_PROMPT},
+            {"role": "system", "content": self._new_sources(params)},
+        ]
+
+        for message in params.history:
+            messages.append({"role": "user", "content": message.question})
+            messages.append({"role": "assistant", "content": message.answer})
+
+        messages.append({"role": "user", "content": params.question})
+
+        stream = litellm.completion(
+            **self.backend,
+            messages=messages,
+            max_tokens=self.config.lm.max_tokens,
+            temperature=self.config.lm.temperature,
+            stream=True,
+        )
+
+        answer = ""
+        for chunk in stream:
+            delta = chunk.choices[0].delta.content
+            if not delta:
+                continue
+
+            answer += delta
+            yield self.AnswerQuestionEvent(delta=delta)
+
+        # Only the feedbacks provided can be cited, as models tend to make up IDs
+        ids = set(feedback.id for feedback in params.feedbacks)
+        citations = list(dict.fromkeys([id for id in CITATION_REGEX.findall(answer) if id in ids]))
+
+        # TODO: Use real usage
+        input_tokens = sum([get_tokens(message["content"]) for message in messages])
+        output_tokens = get_tokens(answer)
+
+        yield self.AnswerQuestionEvent(
+            citations=citations,
+            usage=Usage(
+                input=input_tokens,
+                output=output_tokens,
+            ),
+        )
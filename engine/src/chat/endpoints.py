@@ -0,0 +1,41 @@
+from typing import Iterator, List
+
+from fastapi.responses import StreamingResponse
+from pydantic import BaseModel
+
+from src.config import Config
+
+from .chat import Chat
+
+
+class ChatEndpoints:
+    def __init__(self, config: Config, chat: Chat) -> None:
+        self.config = config
+        self.chat = chat
+
+    class PostAnswerQuestionRequest(BaseModel):
+        context: str
+        question: str
+        feedbacks: List[Chat.AnswerQuestionParams.Feedback]
+        issues: List[Chat.AnswerQuestionParams.Issue]
+        suggestions: List[Chat.AnswerQuestionParams.Suggestion]
+        history: List[Chat.AnswerQuestionParams.Message] = []
+
+    async def post_answer_question(self, request: PostAnswerQuestionRequest) -> StreamingResponse:
+        events = self.chat.answer_question(
+            params=Chat.AnswerQuestionParams(
+                context=request.context,
+                question=request.question,
+                feedbacks=request.feedbacks,
+                issues=request.issues,
+                suggestions=request.suggestions,
+                history=request.history,
+            )
+        )
+
+        # Every event is a JSON line, the last one has the citations and the usage
+        def stream() -> Iterator[str]:
+            for event in events:
+                yield event.model_dump_json(exclude_none=True) + "\n"
+
+        return StreamingResponse(stream(), media_type="application/x-ndjson")
@@ -14,6 +14,7 @@ from fastapi.exceptions import RequestValidationError
 from starlette.exceptions import HTTPException
 
 from src.aggregator import Aggregator, AggregatorEndpoints
+from src.chat import Chat, ChatEndpoints
 from src.common import SYSTEM_PROMPT
 from src.config import get_config
 from src.exception_handler import ExceptionHandler
@@ -130,6 +131,7 @@ server.middleware("http")(logger_middleware.handle)
 translator = Translator(config=config)
 processor = Processor(config=config)
 aggregator = Aggregator(config=config)
+chat = Chat(config=config, backend=backends[config.lm.backend])
 
 # ENDPOINTS
 
@@ -137,6 +139,7 @@ health_endpoints = HealthEndpoints(config=config)
 translator_endpoints = TranslatorEndpoints(config=config, translator=translator)
 processor_endpoints = ProcessorEndpoints(config=config, processor=processor)
 aggregator_endpoints = AggregatorEndpoints(config=config, aggregator=aggregator)
+chat_endpoints = ChatEndpoints(config=config, chat=chat)
 
 # MIDDLEWARES
 
@@ -150,9 +153,14 @@ server.post("/translator/translate-feedback")(translator_endpoints.post_translat
 server.post("/processor/extract-issues")(processor_endpoints.post_extract_issues)
 server.post("/processor/extract-suggestions")(processor_endpoints.post_extract_suggestions)
 server.post("/processor/extract-review")(processor_endpoints.post_extract_review)
+server.post("/processor/propose-category")(processor_endpoints.post_propose_category)
+server.post("/processor/synthesize-persona")(processor_endpoints.post_synthesize_persona)
+server.post("/processor/summarize-digest")(processor_endpoints.post_summarize_digest)
 
 server.post("/aggregator/compute-embedding")(aggregator_endpoints.post_compute_embedding)
 server.post("/aggregator/similar-issue")(aggregator_endpoints.post_similar_issue)
 server.post("/aggregator/merge-issues")(aggregator_endpoints.post_merge_issues)
 server.post("/aggregator/similar-suggestion")(aggregator_endpoints.post_similar_suggestion)
 server.post("/aggregator/merge-suggestions")(aggregator_endpoints.post_merge_suggestions)
+
+server.post("/chat/answer-question")(chat_endpoints.post_answer_question)
@@ -0,0 +1,111 @@
+from typing import List
+
+from dspy import InputField, Module, OutputField, Prediction, Signature, Suggest, backtrack_handler
+from pydantic import BaseModel, Field
+
+from src.common import ChainOfThought
+from src.common.utils import StrEnum
+from src.config import Config
+
+
+class AspectExtractor(Module):
+    class Input(BaseModel):
+        context: str
+        feedback: str
+
+    class Output(BaseModel):
+        class Aspect(BaseModel):
+            aspect: str
+            sentiment: str
+            quote: str
+
+        aspects: List[Aspect]
+
+    class ExtractAspects(Signature):
+        """
+Extract the aspects of a product (context is provided) the customer's feedback gives an opinion about, and the sentiment towards each one.
+- An aspect is a feature, a quality or a part of the product or of the company, like its pricing, support or performance.
+- Name each aspect with 1 to 3 generic words, in English, which cannot contain the product's name.
+- Discern the sentiment towards each aspect on its own, valid options (`sentiments`) are provided.
+- Quote the shortest fragment of the feedback where the opinion about the aspect is stated, copied exactly as written.
+        """  # fmt: skip
+
+        class Input(BaseModel):
+            context: str
+            feedback: str
+            sentiments: List[str]
+
+        class Output(BaseModel):
+            class Sentiment(StrEnum):
+                POSITIVE = "POSITIVE"
+                NEUTRAL = "NEUTRAL"
+                NEGATIVE = "NEGATIVE"
+
+            class Aspect(BaseModel):
+                aspect: str = Field(description="1 to 3 generic words.", max_length=50)
+                sentiment: str = Field(description="The valid option that best fits.")
+                quote: str = Field(description="An exact fragment of the feedback.")
+
+            aspects: List[Aspect] = Field(description="If any, else `[]`.", max_items=10)
+
+        input: Input = InputField()
+        output: Output = OutputField()
+
+    def __init__(self, config: Config) -> None:
+        super().__init__()
+
+        self.extract_aspects = ChainOfThought(self.ExtractAspects, max_retries=3, explain_errors=False)
+
+        # There is no tuned artifact yet, the quotes are checked against the feedback anyway
+        self.activate_assertions(handler=backtrack_handler, max_backtracks=3)
+
+    def forward(self, input: Input) -> Prediction:
+        aspects = self.extract_aspects(
+            input=self.ExtractAspects.Input(
+                context=input.context,
+                feedback=input.feedback,
+                sentiments=self.ExtractAspects.Output.Sentiment.list(),
+            )
+        ).output.aspects
+
+        sentiments = self.ExtractAspects.Output.Sentiment.list()
+
+        invalid_sentiments = [aspect.sentiment for aspect in aspects if aspect.sentiment.upper() not in sentiments]
+        Suggest(
+            not invalid_sentiments,
+            "Sentiments must be one of the valid options! Invalid options:\n"
+            + "".join([f"- {sentiment}\n" for sentiment in invalid_sentiments]),
+        )
+
+        # The quotes are located case insensitively, as models tend to change the case of the first letter
+        inexistent_quotes = [
+            aspect.quote for aspect in aspects if input.feedback.lower().find(aspect.quote.strip().lower()) < 0
+        ]
+        Suggest(
+            not inexistent_quotes,
+            "All quotes must be copied exactly from the customer's feedback! Quotes not included:\n"
+            + "".join([f"- {quote}\n" for quote in inexistent_quotes]),
+        )
+
+        result = []
+        seen = set()
+        for aspect in aspects:
+            name = aspect.aspect.strip().upper().replace(" ", "_")
+            sentiment = aspect.sentiment.strip().upper()
+            start = input.feedback.lower().find(aspect.quote.strip().lower())
+
+            if not name or sentiment not in sentiments or start < 0 or (name, sentiment) in seen:
+                continue
+
+            seen.add((name, sentiment))
+            end = start + len(aspect.quote.strip())
+
+            result.append(
+                self.Output.Aspect(
+                    aspect=name,
+                    sentiment=sentiment,
+                    quote=input.feedback[start:end],
+                )
+            )
+
+        return Prediction(output=self.Output(aspects=result))
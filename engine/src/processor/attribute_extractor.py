@@ -0,0 +1,119 @@
+from typing import List, Optional
+
+from dspy import InputField, Module, OutputField, Prediction, Signature, Suggest, backtrack_handler
+from pydantic import BaseModel, Field
+
+from src.common import ChainOfThought
+from src.config import Config
+
+ATTRIBUTE_TYPE_ENUM = "ENUM"
+ATTRIBUTE_TYPE_NUMBER = "NUMBER"
+ATTRIBUTE_TYPE_TEXT = "TEXT"
+
+
+class AttributeExtractor(Module):
+    class Input(BaseModel):
+        class Attribute(BaseModel):
+            name: str
+            type: str
+            values: List[str]
+            description: str
+
+        context: str
+        attributes: List[Attribute]
+        feedback: str
+
+    class Output(BaseModel):
+        class Attribute(BaseModel):
+            name: str
+            value: str
+
+        attributes: List[Attribute]
+
+    class ExtractAttributes(Signature):
+        """
+Extract the value of every attribute, defined by a product (context is provided), that the customer's feedback mentions.
+- Only extract an attribute when the feedback clearly states its value, otherwise leave its value empty.
+- ENUM attributes must take exactly one of their allowed values.
+- NUMBER attributes must take a plain number, without units.
+- TEXT attributes must take a short phrase, in English.
+        """  # fmt: skip
+
+        class Input(BaseModel):
+            class Attribute(BaseModel):
+                name: str
+                type: str
+                values: List[str]
+                description: str
+
+            context: str
+            attributes: List[Attribute]
+            feedback: str
+
+        class Output(BaseModel):
+            class Attribute(BaseModel):
+                name: str
+                value: Optional[str] = Field(
+                    description="The value of the attribute or empty when the feedback does not mention it.",
+                    max_length=100,
+                )
+
+            attributes: List[Attribute]
+
+        input: Input = InputField()
+        output: Output = OutputField()
+
+    def __init__(self, config: Config) -> None:
+        super().__init__()
+
+        self.extract_attributes = ChainOfThought(self.ExtractAttributes, max_retries=3, explain_errors=False)
+
+        # There is no tuned artifact yet, the values are validated against the definitions afterwards anyway
+        self.activate_assertions(handler=backtrack_handler, max_backtracks=3)
+
+    def forward(self, input: Input) -> Prediction:
+        attributes = self.extract_attributes(
+            input=self.ExtractAttributes.Input(
+                context=input.context,
+                attributes=[
+                    self.ExtractAttributes.Input.Attribute(
+                        name=attribute.name,
+                        type=attribute.type,
+                        values=attribute.values,
+                        description=attribute.description,
+                    )
+                    for attribute in input.attributes
+                ],
+                feedback=input.feedback,
+            )
+        ).output.attributes
+
+        definitions = {attribute.name: attribute for attribute in input.attributes}
+
+        values = {}
+        for attribute in attributes:
+            if attribute.name not in definitions or not attribute.value:
+                continue
+
+            definition = definitions[attribute.name]
+            value = attribute.value.strip()
+
+            if definition.type == ATTRIBUTE_TYPE_ENUM:
+                value = value.upper().replace(" ", "_")
+                Suggest(
+                    value in definition.values,
+                    f"The value `{value}` of the attribute `{definition.name}` is not one of {definition.values}!",
+                )
+            elif definition.type == ATTRIBUTE_TYPE_NUMBER:
+                try:
+                    float(value)
+                except ValueError:
+                    Suggest(False, f"The value `{value}` of the attribute `{definition.name}` is not a number!")
+
+            values[definition.name] = value
+
+        return Prediction(
+            output=self.Output(
+                attributes=[self.Output.Attribute(name=name, value=value) for name, value in values.items()],
+            )
+        )
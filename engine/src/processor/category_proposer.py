@@ -0,0 +1,89 @@
+from typing import List
+
+from dspy import InputField, Module, OutputField, Prediction, Signature, Suggest, backtrack_handler
+from pydantic import BaseModel, Field
+
+from src.common import ChainOfThought
+from src.config import Config
+
+
+class CategoryProposer(Module):
+    class Input(BaseModel):
+        context: str
+        categories: List[str]
+        items: List[str]
+
+    class Output(BaseModel):
+        class Category(BaseModel):
+            name: str
+            description: str
+            examples: List[str]
+
+        category: Category
+
+    class ProposeCategory(Signature):
+        """
+Propose a new category, for a product (context is provided), that groups all the customer's feedback items.
+- The category must be distinct from the existing categories of the product.
+- The category must be general enough to also fit future items about the same area of the product.
+- The examples must be short phrases summarizing the items, not copies of them.
+        """  # fmt: skip
+
+        class Input(BaseModel):
+            context: str
+            categories: List[str]
+            items: List[str]
+
+        class Output(BaseModel):
+            class Category(BaseModel):
+                name: str = Field(
+                    description="1 to 3 words, which cannot contain the product's name.",
+                    max_length=50,
+                )
+                description: str = Field(
+                    description="One concise sentence explaining which feedback belongs to the category.",
+                    max_length=500,
+                )
+                examples: List[str] = Field(
+                    description="Short example phrases of feedback that belongs to the category.",
+                    max_items=5,
+                )
+
+            category: Category
+
+        input: Input = InputField()
+        output: Output = OutputField()
+
+    def __init__(self, config: Config) -> None:
+        super().__init__()
+
+        self.propose_category = ChainOfThought(self.ProposeCategory, max_retries=3, explain_errors=False)
+
+        # There is no tuned artifact yet, proposals are reviewed by a human before being accepted anyway
+        self.activate_assertions(handler=backtrack_handler, max_backtracks=3)
+
+    def forward(self, input: Input) -> Prediction:
+        category = self.propose_category(
+            input=self.ProposeCategory.Input(
+                context=input.context,
+                categories=input.categories,
+                items=input.items,
+            )
+        ).output.category
+
+        name = category.name.strip().upper().replace(" ", "_")
+
+        Suggest(
+            name not in input.categories,
+            f"The proposed category `{name}` already exists, propose a distinct one!",
+        )
+
+        return Prediction(
+            output=self.Output(
+                category=self.Output.Category(
+                    name=name,
+                    description=category.description,
+                    examples=category.examples,
+                ),
+            )
+        )
@@ -0,0 +1,95 @@
+import re
+from typing import List
+
+from dspy import InputField, Module, OutputField, Prediction, Signature, Suggest, backtrack_handler
+from pydantic import BaseModel, Field
+
+from src.common import ChainOfThought
+from src.config import Config
+
+NUMBER_PATTERN = re.compile(r"-?\d+(?:[.,]\d+)?")
+
+
+class DigestSummarizer(Module):
+    class Input(BaseModel):
+        context: str
+        facts: List[str]
+        issues: List[str]
+        suggestions: List[str]
+        quotes: List[str]
+
+    class Output(BaseModel):
+        class Digest(BaseModel):
+            summary: str
+            highlights: List[str]
+
+        digest: Digest
+
+    class SummarizeDigest(Signature):
+        """
+Summarize what happened with the customer feedback of a product (context is provided) during the period of a digest for the product's stakeholders.
+- The facts are the metrics of the period compared to the previous one, the only numbers you can mention.
+- The issues are the new and rising problems of the period and the suggestions the most requested ones.
+- The quotes are notable feedbacks of the period, use them to understand what customers feel but do not copy them.
+- Write the summary as a short executive briefing: what changed, what needs attention and what is going well.
+- Highlights are the few points a stakeholder should act upon.
+        """  # fmt: skip
+
+        class Input(BaseModel):
+            context: str
+            facts: List[str]
+            issues: List[str]
+            suggestions: List[str]
+            quotes: List[str]
+
+        class Output(BaseModel):
+            class Digest(BaseModel):
+                summary: str = Field(description="One paragraph of 2 to 4 concise sentences.", max_length=1000)
+                highlights: List[str] = Field(description="Short sentences.", max_items=5)
+
+            digest: Digest
+
+        input: Input = InputField()
+        output: Output = OutputField()
+
+    def __init__(self, config: Config) -> None:
+        super().__init__()
+
+        self.summarize_digest = ChainOfThought(self.SummarizeDigest, max_retries=3, explain_errors=False)
+
+        # There is no tuned artifact yet, the numbers are checked against the facts anyway
+        self.activate_assertions(handler=backtrack_handler, max_backtracks=3)
+
+    def forward(self, input: Input) -> Prediction:
+        digest = self.summarize_digest(
+            input=self.SummarizeDigest.Input(
+                context=input.context,
+                facts=input.facts,
+                issues=input.issues,
+                suggestions=input.suggestions,
+                quotes=input.quotes,
+            )
+        ).output.digest
+
+        # The numbers are computed beforehand, so the model must never make them up
+        numbers = set(NUMBER_PATTERN.findall(" ".join(input.facts + input.issues + input.suggestions)))
+        inexistent_numbers = [
+            number
+            for text in [digest.summary, *digest.highlights]
+            for number in NUMBER_PATTERN.findall(text)
+            if number not in numbers
+        ]
+        Suggest(
+            not inexistent_numbers,
+            "All numbers must be taken from the facts! Numbers not included:\n"
+            + "".join([f"- {number}\n" for number in dict.fromkeys(inexistent_numbers)]),
+        )
+
+        return Prediction(
+            output=self.Output(
+                digest=self.Output.Digest(
+                    summary=digest.summary.strip(),
+                    highlights=[highlight.strip() for highlight in digest.highlights if highlight.strip()],
+                ),
+            )
+        )
@@ -1,11 +1,11 @@
-from typing import List
+from typing import Dict, List
 
 from pydantic import BaseModel
 
 from src.common import Usage
 from src.config import Config
 
-from .processor import Processor
+from .processor import DEFAULT_LANGUAGE, Attribute, Category, Processor
 
 
 class ProcessorEndpoints:
@@ -16,7 +16,9 @@ class ProcessorEndpoints:
     class PostExtractIssuesRequest(BaseModel):
         context: str
         categories: List[str]
+        taxonomy: List[Category] = []
         feedback: str
+        language: str = DEFAULT_LANGUAGE
 
     class PostExtractIssuesResponse(BaseModel):
         class Issue(BaseModel):
@@ -34,7 +36,9 @@ class ProcessorEndpoints:
             params=Processor.ExtractIssuesParams(
                 context=request.context,
                 categories=request.categories,
+                taxonomy=request.taxonomy,
                 feedback=request.feedback,
+                language=request.language,
             )
         )
 
@@ -55,7 +59,9 @@ class ProcessorEndpoints:
     class PostExtractSuggestionsRequest(BaseModel):
         context: str
         categories: List[str]
+        taxonomy: List[Category] = []
         feedback: str
+        language: str = DEFAULT_LANGUAGE
 
     class PostExtractSuggestionsResponse(BaseModel):
         class Suggestion(BaseModel):
@@ -73,7 +79,9 @@ class ProcessorEndpoints:
             params=Processor.ExtractSuggestionsParams(
                 context=request.context,
                 categories=request.categories,
+                taxonomy=request.taxonomy,
                 feedback=request.feedback,
+                language=request.language,
             )
         )
 
@@ -94,9 +102,17 @@ class ProcessorEndpoints:
     class PostExtractReviewRequest(BaseModel):
         context: str
         categories: List[str]
+        taxonomy: List[Category] = []
+        attributes: List[Attribute] = []
         feedback: str
+        language: str = DEFAULT_LANGUAGE
 
     class PostExtractReviewResponse(BaseModel):
+        class Aspect(BaseModel):
+            aspect: str
+            sentiment: str
+            quote: str
+
         class Review(BaseModel):
             content: str
             keywords: List[str]
@@ -104,6 +120,8 @@ class ProcessorEndpoints:
             emotions: List[str]
             intention: str
             category: str
+            attributes: Dict[str, str]
+            aspects: List[Aspect]
 
         review: Review
         usage: Usage
@@ -113,7 +131,10 @@ class ProcessorEndpoints:
             params=Processor.ExtractReviewParams(
                 context=request.context,
                 categories=request.categories,
+                taxonomy=request.taxonomy,
+                attributes=request.attributes,
                 feedback=request.feedback,
+                language=request.language,
             )
         )
 
@@ -125,6 +146,121 @@ class ProcessorEndpoints:
                 emotions=result.review.emotions,
                 intention=result.review.intention,
                 category=result.review.category,
+                attributes=result.review.attributes,
+                aspects=[
+                    self.PostExtractReviewResponse.Aspect(
+                        aspect=aspect.aspect,
+                        sentiment=aspect.sentiment,
+                        quote=aspect.quote,
+                    )
+                    for aspect in result.review.aspects
+                ],
+            ),
+            usage=result.usage,
+        )
+
+    class PostProposeCategoryRequest(BaseModel):
+        context: str
+        categories: List[str]
+        items: List[str]
+
+    class PostProposeCategoryResponse(BaseModel):
+        class Category(BaseModel):
+            name: str
+            description: str
+            examples: List[str]
+
+        category: Category
+        usage: Usage
+
+    async def post_propose_category(self, request: PostProposeCategoryRequest) -> PostProposeCategoryResponse:
+        result = self.processor.propose_category(
+            params=Processor.ProposeCategoryParams(
+                context=request.context,
+                categories=request.categories,
+                items=request.items,
+            )
+        )
+
+        return self.PostProposeCategoryResponse(
+            category=self.PostProposeCategoryResponse.Category(
+                name=result.category.name,
+                description=result.category.description,
+                examples=result.category.examples,
+            ),
+            usage=result.usage,
+        )
+
+    class PostSynthesizePersonaRequest(BaseModel):
+        context: str
+        traits: List[str]
+        feedbacks: List[str]
+        language: str = DEFAULT_LANGUAGE
+
+    class PostSynthesizePersonaResponse(BaseModel):
+        class Persona(BaseModel):
+            name: str
+            description: str
+            goals: List[str]
+            pains: List[str]
+            quotes: List[str]
+
+        persona: Persona
+        usage: Usage
+
+    async def post_synthesize_persona(self, request: PostSynthesizePersonaRequest) -> PostSynthesizePersonaResponse:
+        result = self.processor.synthesize_persona(
+            params=Processor.SynthesizePersonaParams(
+                context=request.context,
+                traits=request.traits,
+                feedbacks=request.feedbacks,
+                language=request.language,
+            )
+        )
+
+        return self.PostSynthesizePersonaResponse(
+            persona=self.PostSynthesizePersonaResponse.Persona(
+                name=result.persona.name,
+                description=result.persona.description,
+                goals=result.persona.goals,
+                pains=result.persona.pains,
+                quotes=result.persona.quotes,
+            ),
+            usage=result.usage,
+        )
+
+    class PostSummarizeDigestRequest(BaseModel):
+        context: str
+        facts: List[str]
+        issues: List[str]
+        suggestions: List[str]
+        quotes: List[str]
+        language: str = DEFAULT_LANGUAGE
+
+    class PostSummarizeDigestResponse(BaseModel):
+        class Digest(BaseModel):
+            summary: str
+            highlights: List[str]
+
+        digest: Digest
+        usage: Usage
+
+    async def post_summarize_digest(self, request: PostSummarizeDigestRequest) -> PostSummarizeDigestResponse:
+        result = self.processor.summarize_digest(
+            params=Processor.SummarizeDigestParams(
+                context=request.context,
+                facts=request.facts,
+                issues=request.issues,
+                suggestions=request.suggestions,
+                quotes=request.quotes,
+                language=request.language,
+            )
+        )
+
+        return self.PostSummarizeDigestResponse(
+            digest=self.PostSummarizeDigestResponse.Digest(
+                summary=result.digest.summary,
+                highlights=result.digest.highlights,
             ),
             usage=result.usage,
         )
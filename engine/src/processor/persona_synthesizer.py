@@ -0,0 +1,103 @@
+from typing import List
+
+from dspy import InputField, Module, OutputField, Prediction, Signature, Suggest, backtrack_handler
+from pydantic import BaseModel, Field
+
+from src.common import ChainOfThought
+from src.config import Config
+
+
+class PersonaSynthesizer(Module):
+    class Input(BaseModel):
+        context: str
+        traits: List[str]
+        feedbacks: List[str]
+
+    class Output(BaseModel):
+        class Persona(BaseModel):
+            name: str
+            description: str
+            goals: List[str]
+            pains: List[str]
+            quotes: List[str]
+
+        persona: Persona
+
+    class SynthesizePersona(Signature):
+        """
+Synthesize the profile of a persona, for a product (context is provided), that represents all the customers who wrote the feedbacks.
+- The traits are what the feedbacks have most in common, use them to ground the profile.
+- Name the persona with 1 to 4 words describing who they are, which cannot contain the product's name.
+- Goals are what the persona wants to achieve with the product and pains what prevents or bothers them.
+- Quote the most representative fragments of the feedbacks, copied exactly as written.
+        """  # fmt: skip
+
+        class Input(BaseModel):
+            context: str
+            traits: List[str]
+            feedbacks: List[str]
+
+        class Output(BaseModel):
+            class Persona(BaseModel):
+                name: str = Field(description="1 to 4 words.", max_length=50)
+                description: str = Field(
+                    description="Two concise sentences about who the persona is and how they use the product.",
+                    max_length=500,
+                )
+                goals: List[str] = Field(description="Short phrases.", max_items=5)
+                pains: List[str] = Field(description="Short phrases.", max_items=5)
+                quotes: List[str] = Field(description="Exact fragments of the feedbacks.", max_items=5)
+
+            persona: Persona
+
+        input: Input = InputField()
+        output: Output = OutputField()
+
+    def __init__(self, config: Config) -> None:
+        super().__init__()
+
+        self.synthesize_persona = ChainOfThought(self.SynthesizePersona, max_retries=3, explain_errors=False)
+
+        # There is no tuned artifact yet, the quotes are checked against the feedbacks anyway
+        self.activate_assertions(handler=backtrack_handler, max_backtracks=3)
+
+    def forward(self, input: Input) -> Prediction:
+        persona = self.synthesize_persona(
+            input=self.SynthesizePersona.Input(
+                context=input.context,
+                traits=input.traits,
+                feedbacks=input.feedbacks,
+            )
+        ).output.persona
+
+        # The quotes are located case insensitively, as models tend to change the case of the first letter
+        feedbacks = [feedback.lower() for feedback in input.feedbacks]
+        inexistent_quotes = [
+            quote for quote in persona.quotes if not any(quote.strip().lower() in feedback for feedback in feedbacks)
+        ]
+        Suggest(
+            not inexistent_quotes,
+            "All quotes must be copied exactly from the customers' feedbacks! Quotes not included:\n"
+            + "".join([f"- {quote}\n" for quote in inexistent_quotes]),
+        )
+
+        quotes = []
+        for quote in persona.quotes:
+            quote = quote.strip()
+            for feedback in input.feedbacks:
+                start = feedback.lower().find(quote.lower())
+                if quote and start >= 0:
+                    quotes.append(feedback[start : start + len(quote)])
+                    break
+
+        return Prediction(
+            output=self.Output(
+                persona=self.Output.Persona(
+                    name=persona.name.strip(),
+                    description=persona.description.strip(),
+                    goals=[goal.strip() for goal in persona.goals if goal.strip()],
+                    pains=[pain.strip() for pain in persona.pains if pain.strip()],
+                    quotes=list(dict.fromkeys(quotes)),
+                ),
+            )
+        )
@@ -1,16 +1,41 @@
 import json
-from typing import List
+from typing import Dict, List, Tuple
 
 from pydantic import BaseModel
 
 from src.common import Usage
 from src.common.utils import get_tokens
 from src.config import Config
+from src.translator.texts_translator import TextsTranslator
 
+from .aspect_extractor import AspectExtractor
+from .attribute_extractor import ATTRIBUTE_TYPE_TEXT, AttributeExtractor
+from .category_proposer import CategoryProposer
+from .digest_summarizer import DigestSummarizer
 from .issue_extractor import IssueExtractor
+from .persona_synthesizer import PersonaSynthesizer
 from .review_extractor import ReviewExtractor
 from .suggestion_extractor import SuggestionExtractor
 
+# The extractors are tuned in English, so their output is localized afterwards when another language is requested
+DEFAULT_LANGUAGE = "ENGLISH"
+
+# Subcategories are keyed as `CATEGORY/SUBCATEGORY`
+CATEGORY_SEPARATOR = "/"
+
+
+class Category(BaseModel):
+    key: str
+    description: str = ""
+    examples: List[str] = []
+
+
+class Attribute(BaseModel):
+    name: str
+    type: str
+    values: List[str] = []
+    description: str = ""
+
 
 class Processor:
     def __init__(self, config: Config) -> None:
@@ -19,11 +44,60 @@ class Processor:
         self.issue_extractor = IssueExtractor(config=config)
         self.suggestion_extractor = SuggestionExtractor(config=config)
         self.review_extractor = ReviewExtractor(config=config)
+        self.attribute_extractor = AttributeExtractor(config=config)
+        self.aspect_extractor = AspectExtractor(config=config)
+        self.texts_translator = TextsTranslator(config=config)
+        self.category_proposer = CategoryProposer(config=config)
+        self.persona_synthesizer = PersonaSynthesizer(config=config)
+        self.digest_summarizer = DigestSummarizer(config=config)
+
+    # All the texts of a result are localized at once, so a result costs a single translation whatever its length
+    def localize(self, texts: List[str], language: str) -> Tuple[List[str], Usage]:
+        if not any(texts) or language == DEFAULT_LANGUAGE:
+            return texts, Usage(input=0, output=0)
+
+        translations = self.texts_translator(
+            input=TextsTranslator.Input(
+                texts=texts,
+                from_language=DEFAULT_LANGUAGE,
+                to_language=language,
+            )
+        ).output.translations
+
+        # TODO: Use real usage
+        input_tokens = get_tokens(json.dumps(texts)) + 1000
+        output_tokens = get_tokens(json.dumps(translations)) + 100
+
+        return translations, Usage(input=input_tokens, output=output_tokens)
+
+    # The extractors' signatures are tuned with plain category names, so the taxonomy is described in the context
+    def describe(self, context: str, taxonomy: List[Category]) -> str:
+        guide = ""
+        for category in taxonomy:
+            if not category.description and not category.examples:
+                continue
+
+            guide += f"- {category.key.replace('_', ' ')}"
+            if category.description:
+                guide += f": {category.description}"
+            if category.examples:
+                guide += f" (e.g. {'; '.join(category.examples)})"
+            guide += "\n"
+
+        if not guide:
+            return context
+
+        return (
+            f"{context}\n\nCategories guide (subcategories are written as `CATEGORY{CATEGORY_SEPARATOR}SUBCATEGORY`, "
+            + f"prefer the most specific one that fits):\n{guide}"
+        )
 
     class ExtractIssuesParams(BaseModel):
         context: str
         categories: List[str]
+        taxonomy: List[Category] = []
         feedback: str
+        language: str = DEFAULT_LANGUAGE
 
     class ExtractIssuesResult(BaseModel):
         class Issue(BaseModel):
@@ -39,7 +113,7 @@ class Processor:
     def extract_issues(self, params: ExtractIssuesParams) -> ExtractIssuesResult:
         issues = self.issue_extractor(
             input=IssueExtractor.Input(
-                context=params.context,
+                context=self.describe(params.context, params.taxonomy),
                 categories=params.categories,
                 feedback=params.feedback,
             )
@@ -51,27 +125,34 @@ class Processor:
         ) * 2
         output_tokens = (sum([get_tokens(issue.model_dump_json()) for issue in issues]) + 100) * 2
 
+        localized, usage = self.localize(
+            [text for issue in issues for text in [issue.title, issue.description, *issue.steps]], params.language
+        )
+        texts = iter(localized)
+
         return self.ExtractIssuesResult(
             issues=[
                 self.ExtractIssuesResult.Issue(
-                    title=issue.title,
-                    description=issue.description,
-                    steps=issue.steps,
+                    title=next(texts),
+                    description=next(texts),
+                    steps=[next(texts) for _ in issue.steps],
                     severity=issue.severity,
                     category=issue.category,
                 )
                 for issue in issues
             ],
             usage=Usage(
-                input=input_tokens,
-                output=output_tokens,
+                input=input_tokens + usage.input,
+                output=output_tokens + usage.output,
             ),
         )
 
     class ExtractSuggestionsParams(BaseModel):
         context: str
         categories: List[str]
+        taxonomy: List[Category] = []
         feedback: str
+        language: str = DEFAULT_LANGUAGE
 
     class ExtractSuggestionsResult(BaseModel):
         class Suggestion(BaseModel):
@@ -87,7 +168,7 @@ class Processor:
     def extract_suggestions(self, params: ExtractSuggestionsParams) -> ExtractSuggestionsResult:
         suggestions = self.suggestion_extractor(
             input=SuggestionExtractor.Input(
-                context=params.context,
+                context=self.describe(params.context, params.taxonomy),
                 categories=params.categories,
                 feedback=params.feedback,
             )
@@ -99,29 +180,47 @@ class Processor:
         ) * 2
         output_tokens = (sum([get_tokens(suggestion.model_dump_json()) for suggestion in suggestions]) + 100) * 2
 
+        localized, usage = self.localize(
+            [
+                text
+                for suggestion in suggestions
+                for text in [suggestion.title, suggestion.description, suggestion.reason]
+            ],
+            params.language,
+        )
+        texts = iter(localized)
+
         return self.ExtractSuggestionsResult(
             suggestions=[
                 self.ExtractSuggestionsResult.Suggestion(
-                    title=suggestion.title,
-                    description=suggestion.description,
-                    reason=suggestion.reason,
+                    title=next(texts),
+                    description=next(texts),
+                    reason=next(texts),
                     importance=suggestion.importance,
                     category=suggestion.category,
                 )
                 for suggestion in suggestions
             ],
             usage=Usage(
-                input=input_tokens,
-                output=output_tokens,
+                input=input_tokens + usage.input,
+                output=output_tokens + usage.output,
             ),
         )
 
     class ExtractReviewParams(BaseModel):
         context: str
         categories: List[str]
+        taxonomy: List[Category] = []
+        attributes: List[Attribute] = []
         feedback: str
+        language: str = DEFAULT_LANGUAGE
 
     class ExtractReviewResult(BaseModel):
+        class Aspect(BaseModel):
+            aspect: str
+            sentiment: str
+            quote: str
+
         class Review(BaseModel):
             content: str
             keywords: List[str]
@@ -129,6 +228,8 @@ class Processor:
             emotions: List[str]
             intention: str
             category: str
+            attributes: Dict[str, str]
+            aspects: List[Aspect]
 
         review: Review
         usage: Usage
@@ -136,29 +237,223 @@ class Processor:
     def extract_review(self, params: ExtractReviewParams) -> ExtractReviewResult:
         review = self.review_extractor(
             input=ReviewExtractor.Input(
-                context=params.context,
+                context=self.describe(params.context, params.taxonomy),
                 categories=params.categories,
                 feedback=params.feedback,
             )
         ).output.review
 
+        types = {attribute.name: attribute.type for attribute in params.attributes}
+        attributes = {}
+        if params.attributes:
+            attributes = {
+                attribute.name: attribute.value
+                for attribute in self.attribute_extractor(
+                    input=AttributeExtractor.Input(
+                        context=params.context,
+                        attributes=[
+                            AttributeExtractor.Input.Attribute(
+                                name=attribute.name,
+                                type=attribute.type,
+                                values=attribute.values,
+                                description=attribute.description,
+                            )
+                            for attribute in params.attributes
+                        ],
+                        feedback=params.feedback,
+                    )
+                ).output.attributes
+            }
+
+        # Quotes are not localized, so their spans keep pointing to the feedback
+        aspects = self.aspect_extractor(
+            input=AspectExtractor.Input(
+                context=params.context,
+                feedback=params.feedback,
+            )
+        ).output.aspects
+
         # TODO: Use real usage
         input_tokens = (
             get_tokens(json.dumps(self.ExtractReviewResult.model_json_schema()) + params.model_dump_json()) + 1500
+        ) + (get_tokens(params.feedback) + 1000)
+        output_tokens = (get_tokens(review.model_dump_json()) + 100) + (
+            sum([get_tokens(aspect.model_dump_json()) for aspect in aspects]) + 100
         )
-        output_tokens = get_tokens(review.model_dump_json()) + 100
+        if params.attributes:
+            input_tokens += get_tokens(params.model_dump_json()) + 1000
+            output_tokens += get_tokens(json.dumps(attributes)) + 100
+
+        # Only free text is localized, enums and numbers are the same in every language
+        names = [name for name in attributes if types[name] == ATTRIBUTE_TYPE_TEXT]
+        localized, usage = self.localize(
+            [review.content, *review.keywords, *[attributes[name] for name in names]], params.language
+        )
+        texts = iter(localized)
+        content = next(texts)
+        keywords = [next(texts) for _ in review.keywords]
+        attributes = {**attributes, **{name: next(texts) for name in names}}
 
         return self.ExtractReviewResult(
             review=self.ExtractReviewResult.Review(
-                content=review.content,
-                keywords=review.keywords,
+                content=content,
+                keywords=keywords,
                 sentiment=review.sentiment,
                 emotions=review.emotions,
                 intention=review.intention,
                 category=review.category,
+                attributes=attributes,
+                aspects=[
+                    self.ExtractReviewResult.Aspect(
+                        aspect=aspect.aspect,
+                        sentiment=aspect.sentiment,
+                        quote=aspect.quote,
+                    )
+                    for aspect in aspects
+                ],
+            ),
+            usage=Usage(
+                input=input_tokens + usage.input,
+                output=output_tokens + usage.output,
+            ),
+        )
+
+    class ProposeCategoryParams(BaseModel):
+        context: str
+        categories: List[str]
+        items: List[str]
+
+    class ProposeCategoryResult(BaseModel):
+        class Category(BaseModel):
+            name: str
+            description: str
+            examples: List[str]
+
+        category: Category
+        usage: Usage
+
+    def propose_category(self, params: ProposeCategoryParams) -> ProposeCategoryResult:
+        category = self.category_proposer(
+            input=CategoryProposer.Input(
+                context=params.context,
+                categories=params.categories,
+                items=params.items,
+            )
+        ).output.category
+
+        # TODO: Use real usage
+        input_tokens = (
+            get_tokens(json.dumps(self.ProposeCategoryResult.model_json_schema()) + params.model_dump_json()) + 1000
+        )
+        output_tokens = get_tokens(category.model_dump_json()) + 100
+
+        return self.ProposeCategoryResult(
+            category=self.ProposeCategoryResult.Category(
+                name=category.name,
+                description=category.description,
+                examples=category.examples,
             ),
             usage=Usage(
                 input=input_tokens,
                 output=output_tokens,
             ),
         )
+
+    class SynthesizePersonaParams(BaseModel):
+        context: str
+        traits: List[str]
+        feedbacks: List[str]
+        language: str
+
+    class SynthesizePersonaResult(BaseModel):
+        class Persona(BaseModel):
+            name: str
+            description: str
+            goals: List[str]
+            pains: List[str]
+            quotes: List[str]
+
+        persona: Persona
+        usage: Usage
+
+    def synthesize_persona(self, params: SynthesizePersonaParams) -> SynthesizePersonaResult:
+        persona = self.persona_synthesizer(
+            input=PersonaSynthesizer.Input(
+                context=params.context,
+                traits=params.traits,
+                feedbacks=params.feedbacks,
+            )
+        ).output.persona
+
+        # TODO: Use real usage
+        input_tokens = (
+            get_tokens(json.dumps(self.SynthesizePersonaResult.model_json_schema()) + params.model_dump_json()) + 1000
+        )
+        output_tokens = get_tokens(persona.model_dump_json()) + 100
+
+        # Quotes are not localized, so they keep being the customers' own words
+        localized, usage = self.localize(
+            [persona.name, persona.description, *persona.goals, *persona.pains], params.language
+        )
+        texts = iter(localized)
+
+        return self.SynthesizePersonaResult(
+            persona=self.SynthesizePersonaResult.Persona(
+                name=next(texts),
+                description=next(texts),
+                goals=[next(texts) for _ in persona.goals],
+                pains=[next(texts) for _ in persona.pains],
+                quotes=persona.quotes,
+            ),
+            usage=Usage(
+                input=input_tokens + usage.input,
+                output=output_tokens + usage.output,
+            ),
+        )
+
+    class SummarizeDigestParams(BaseModel):
+        context: str
+        facts: List[str]
+        issues: List[str]
+        suggestions: List[str]
+        quotes: List[str]
+        language: str
+
+    class SummarizeDigestResult(BaseModel):
+        class Digest(BaseModel):
+            summary: str
+            highlights: List[str]
+
+        digest: Digest
+        usage: Usage
+
+    def summarize_digest(self, params: SummarizeDigestParams) -> SummarizeDigestResult:
+        digest = self.digest_summarizer(
+            input=DigestSummarizer.Input(
+                context=params.context,
+                facts=params.facts,
+                issues=params.issues,
+                suggestions=params.suggestions,
+                quotes=params.quotes,
+            )
+        ).output.digest
+
+        # TODO: Use real usage
+        input_tokens = (
+            get_tokens(json.dumps(self.SummarizeDigestResult.model_json_schema()) + params.model_dump_json()) + 1000
+        )
+        output_tokens = get_tokens(digest.model_dump_json()) + 100
+
+        localized, usage = self.localize([digest.summary, *digest.highlights], params.language)
+        texts = iter(localized)
+
+        return self.SummarizeDigestResult(
+            digest=self.SummarizeDigestResult.Digest(
+                summary=next(texts),
+                highlights=[next(texts) for _ in digest.highlights],
+            ),
+            usage=Usage(
+                input=input_tokens + usage.input,
+                output=output_tokens + usage.output,
+            ),
+        )
@@ -0,0 +1,87 @@
+from typing import List
+
+from dspy import Assert, InputField, Module, OutputField, Prediction, Signature, backtrack_handler
+from pydantic import BaseModel
+
+from src.common import ChainOfThought
+from src.config import Config
+
+
+class TextsTranslator(Module):
+    class Input(BaseModel):
+        texts: List[str]
+        from_language: str
+        to_language: str
+
+    class Output(BaseModel):
+        translations: List[str]
+
+    class TranslateTexts(Signature):
+        """
+Translate every text of a list from a language to a language, in the same order.
+Translate each text on its own, never merge, split, drop or add texts.
+Maintain the texts':
+- Style
+- Format (including newlines and tabs)
+- Emphasis
+- Emojis
+- Punctuation
+- Names
+- Measures
+- Units
+- Dates (use the translated format)
+        """  # fmt: skip
+
+        class Input(BaseModel):
+            texts: List[str]
+            from_language: str
+            to_language: str
+
+        class Output(BaseModel):
+            translations: List[str]
+
+        input: Input = InputField()
+        output: Output = OutputField()
+
+    def __init__(self, config: Config) -> None:
+        super().__init__()
+
+        self.translate_texts = ChainOfThought(self.TranslateTexts, max_retries=3, explain_errors=False)
+
+        # There is no tuned artifact yet, the translations are checked to match the texts one to one anyway
+        self.activate_assertions(handler=backtrack_handler, max_backtracks=3)
+
+    def forward(self, input: Input) -> Prediction:
+        # Empty texts are kept as they are, so only the rest take part in the translation
+        indexes = [index for index, text in enumerate(input.texts) if text]
+
+        if input.from_language == input.to_language or not indexes:
+            return Prediction(
+                output=self.Output(
+                    translations=input.texts,
+                )
+            )
+
+        translations = self.translate_texts(
+            input=self.TranslateTexts.Input(
+                texts=[input.texts[index] for index in indexes],
+                from_language=input.from_language,
+                to_language=input.to_language,
+            )
+        ).output.translations
+
+        Assert(
+            len(translations) == len(indexes),
+            f"There must be exactly {len(indexes)} translations, one for each text!",
+        )
+        Assert(all(translation != "" for translation in translations), "Translations cannot be empty!")
+
+        localized = list(input.texts)
+        for index, translation in zip(indexes, translations):
+            localized[index] = translation
+
+        return Prediction(
+            output=self.Output(
+                translations=localized,
+            )
+        )